@@ -34,6 +34,14 @@ const (
 	// reconciler for that resource. This condition indicates the state of the
 	// reconciler.
 	ResourceGroupConditionTypeReconcilerReady ConditionType = "ReconcilerReady"
+	// ResourceGroupConditionTypeCustomResourceDefinitionDeletionBlocked indicates
+	// that the ResourceGroup asks for its CRD to be deleted along with it, but
+	// the controller is not allowed to delete CRDs.
+	ResourceGroupConditionTypeCustomResourceDefinitionDeletionBlocked ConditionType = "CustomResourceDefinitionDeletionBlocked"
+	// ResourceGroupConditionTypeInstanceDeletionBlocked indicates that the
+	// deletion of the ResourceGroup waits for its remaining instances to be
+	// deleted.
+	ResourceGroupConditionTypeInstanceDeletionBlocked ConditionType = "InstanceDeletionBlocked"
 )
 
 const (
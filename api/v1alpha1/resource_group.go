@@ -14,6 +14,7 @@
 package v1alpha1
 
 import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -24,6 +25,86 @@ const (
 	DefaultServiceAccountKey = "*"
 )
 
+// CRDDeletionPolicy defines what happens to the CRD generated for a
+// resourcegroup when the resourcegroup is deleted.
+//
+// +kubebuilder:validation:Enum=Delete;Orphan
+type CRDDeletionPolicy string
+
+const (
+	// CRDDeletionPolicyDelete deletes the CRD, and thus all the instances,
+	// when the resourcegroup is deleted. The CRD is only deleted if the
+	// controller is also allowed to delete CRDs.
+	CRDDeletionPolicyDelete CRDDeletionPolicy = "Delete"
+	// CRDDeletionPolicyOrphan leaves the CRD and its instances in the
+	// cluster when the resourcegroup is deleted.
+	CRDDeletionPolicyOrphan CRDDeletionPolicy = "Orphan"
+)
+
+// InstanceDeletionPolicy defines what happens to the live instances of a
+// resourcegroup when the resourcegroup is deleted.
+//
+// +kubebuilder:validation:Enum=Block;Delete;Orphan
+type InstanceDeletionPolicy string
+
+const (
+	// InstanceDeletionPolicyBlock keeps the resourcegroup until all its
+	// instances are deleted by their owners.
+	InstanceDeletionPolicyBlock InstanceDeletionPolicy = "Block"
+	// InstanceDeletionPolicyDelete deletes the instances, and thus their
+	// resources, before the resourcegroup is deleted.
+	InstanceDeletionPolicyDelete InstanceDeletionPolicy = "Delete"
+	// InstanceDeletionPolicyOrphan leaves the instances in the cluster, they
+	// are no longer reconciled once the resourcegroup is deleted.
+	InstanceDeletionPolicyOrphan InstanceDeletionPolicy = "Orphan"
+)
+
+// UnknownFieldsPolicy defines how the generated CRD treats the fields of the
+// instance spec that aren't declared in its schema.
+//
+// +kubebuilder:validation:Enum=Strict;Preserve
+type UnknownFieldsPolicy string
+
+const (
+	// UnknownFieldsPolicyStrict prunes the unknown fields of the instance
+	// spec when instances are created or updated.
+	UnknownFieldsPolicyStrict UnknownFieldsPolicy = "Strict"
+	// UnknownFieldsPolicyPreserve keeps the unknown fields of the instance
+	// spec, so that instances can carry pass-through configuration.
+	UnknownFieldsPolicyPreserve UnknownFieldsPolicy = "Preserve"
+)
+
+// StatusFieldStorageStrategy defines how the value of an instance status
+// field is stored.
+//
+// +kubebuilder:validation:Enum=Compress;ConfigMap
+type StatusFieldStorageStrategy string
+
+const (
+	// StatusFieldStorageCompress stores the gzip compressed, base64 encoded
+	// value in the status field, which is then a string.
+	StatusFieldStorageCompress StatusFieldStorageStrategy = "Compress"
+	// StatusFieldStorageConfigMap stores the value in a ConfigMap named after
+	// the instance (`<instance name>-status`) and owned by it. The status
+	// field then holds a reference to the value: the name of the ConfigMap
+	// and its key.
+	StatusFieldStorageConfigMap StatusFieldStorageStrategy = "ConfigMap"
+)
+
+// StatusFieldStorage defines how the value of an instance status field is
+// stored, typically to keep a large value (e.g a rendered configuration) from
+// inflating the instances.
+type StatusFieldStorage struct {
+	// Field is the path to the instance status field, e.g `status.config`.
+	//
+	// +kubebuilder:validation:Required
+	Field string `json:"field"`
+	// Strategy is how the value of the field is stored.
+	//
+	// +kubebuilder:validation:Required
+	Strategy StatusFieldStorageStrategy `json:"strategy"`
+}
+
 // ResourceGroupSpec defines the desired state of ResourceGroup
 type ResourceGroupSpec struct {
 	// The schema of the resourcegroup, which includes the
@@ -43,6 +124,40 @@ type ResourceGroupSpec struct {
 	//
 	// +kubebuilder:validation:Optional
 	DefaultServiceAccounts map[string]string `json:"defaultServiceAccounts,omitempty"`
+	// CRDDeletionPolicy decides whether the generated CRD, and thus all the
+	// instances, is deleted when the resourcegroup is deleted. When unset,
+	// the CRD is deleted only if the controller allows CRD deletion.
+	//
+	// +kubebuilder:validation:Optional
+	CRDDeletionPolicy CRDDeletionPolicy `json:"crdDeletionPolicy,omitempty"`
+	// InstanceDeletionPolicy decides what happens to the live instances when
+	// the resourcegroup is deleted. When unset, the instances are orphaned.
+	//
+	// +kubebuilder:validation:Optional
+	InstanceDeletionPolicy InstanceDeletionPolicy `json:"instanceDeletionPolicy,omitempty"`
+	// Constraints are CEL expressions checked against the rendered resources
+	// of each instance, e.g to make sure a Service selects the pods of a
+	// Deployment. A resource isn't applied while a constraint referring to it
+	// doesn't hold.
+	//
+	// +kubebuilder:validation:Optional
+	Constraints []Constraint `json:"constraints,omitempty"`
+}
+
+// Constraint is a consistency rule between the resources of a resourcegroup.
+type Constraint struct {
+	// Expression is a standalone CEL expression referring to the resources
+	// by their ids, evaluated against their rendered templates, e.g
+	// `${service.spec.selector.all(k, k in deployment.spec.template.metadata.labels && deployment.spec.template.metadata.labels[k] == service.spec.selector[k])}`.
+	// It must evaluate to a boolean.
+	//
+	// +kubebuilder:validation:Required
+	Expression string `json:"expression,omitempty"`
+	// Message is reported when the constraint doesn't hold. Defaults to the
+	// expression.
+	//
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
 }
 
 // Schema represents the attributes that define an instance of
@@ -72,6 +187,119 @@ type Schema struct {
 	// resourcegroup.
 	// Not implemented yet.
 	Validation []string `json:"validation,omitempty"`
+	// AdditionalPrinterColumns are extra columns added to the table output
+	// of the generated CRD (e.g `kubectl get <kind>`). They are appended
+	// after the default State, Synced and Age columns.
+	//
+	// +kubebuilder:validation:Optional
+	AdditionalPrinterColumns []extv1.CustomResourceColumnDefinition `json:"additionalPrinterColumns,omitempty"`
+	// Conversion is a list of rules used by the conversion webhook to
+	// convert instances between versions of the resourcegroup kind.
+	// When no rule is declared for a pair of versions, instances are
+	// converted as is (identity conversion).
+	//
+	// +kubebuilder:validation:Optional
+	Conversion []ConversionRule `json:"conversion,omitempty"`
+	// IdentityFields is a list of paths to instance spec fields (e.g
+	// `spec.name`) that anchor the identity of the managed resources,
+	// typically because they are used to name them. Once an instance is
+	// created, changes to these fields are refused, as they would orphan
+	// the resources created with the previous values.
+	//
+	// +kubebuilder:validation:Optional
+	IdentityFields []string `json:"identityFields,omitempty"`
+	// SensitiveFields is a list of paths to instance status fields (e.g
+	// `status.token`) holding sensitive values. Instead of the instance
+	// status, their values are written into a Secret named after the
+	// instance (`<instance name>-sensitive`) and owned by it. The keys of
+	// the Secret are the paths of the fields relative to the status.
+	//
+	// +kubebuilder:validation:Optional
+	SensitiveFields []string `json:"sensitiveFields,omitempty"`
+	// RequiredStatusFields is a list of paths to instance status fields (e.g
+	// `status.endpoint`) that must be resolved before the instance is
+	// considered synced. Until then, the instance stays IN_PROGRESS. The other
+	// status fields are optional, and can be absent.
+	//
+	// +kubebuilder:validation:Optional
+	RequiredStatusFields []string `json:"requiredStatusFields,omitempty"`
+	// StatusStorage defines how the values of some instance status fields
+	// are stored, e.g compressed or in a ConfigMap, instead of being written
+	// as is in the instance status. The values read back must be decoded
+	// according to the strategy of their field.
+	//
+	// +kubebuilder:validation:Optional
+	StatusStorage []StatusFieldStorage `json:"statusStorage,omitempty"`
+	// Scale enables the scale subresource (`/scale`) of the generated CRD,
+	// so that instances can be scaled with `kubectl scale` or by an
+	// HorizontalPodAutoscaler. The paths (e.g `.spec.replicas`) must exist
+	// in the instance schema.
+	//
+	// +kubebuilder:validation:Optional
+	Scale *extv1.CustomResourceSubresourceScale `json:"scale,omitempty"`
+	// FeatureGates are named boolean flags of the instances, set under
+	// `spec.features`. The resource templates can refer to them through the
+	// `features` variable, e.g `${features.canary}`, to include resources
+	// (includeWhen) or to overlay fields, enabling progressive rollouts.
+	//
+	// +kubebuilder:validation:Optional
+	FeatureGates []FeatureGate `json:"featureGates,omitempty"`
+	// UnknownFields defines how the generated CRD treats the fields of the
+	// instance spec that aren't declared in the schema: Strict prunes them,
+	// Preserve keeps them (`x-kubernetes-preserve-unknown-fields`). Defaults
+	// to Strict.
+	//
+	// +kubebuilder:validation:Optional
+	UnknownFields UnknownFieldsPolicy `json:"unknownFields,omitempty"`
+	// Composition enables the report of the resources composing each
+	// instance in its status.resources field: their apiVersion, kind, name,
+	// namespace, state and readiness. It is disabled by default to keep the
+	// instance status small.
+	//
+	// +kubebuilder:validation:Optional
+	Composition bool `json:"composition,omitempty"`
+	// Pausable declares the `spec.paused` boolean field in the instances.
+	// The reconciliation of an instance is paused while the field is true:
+	// its resources are left untouched until it is unpaused. The deletion
+	// of the instances isn't paused.
+	//
+	// +kubebuilder:validation:Optional
+	Pausable bool `json:"pausable,omitempty"`
+}
+
+// FeatureGate is a named boolean flag of the instances of a resourcegroup.
+type FeatureGate struct {
+	// Name is the name of the gate. It must be lower camelCase.
+	//
+	// +kubebuilder:validation:Required
+	Name string `json:"name,omitempty"`
+	// Default is the value of the gate when the instance doesn't set it.
+	//
+	// +kubebuilder:validation:Optional
+	Default bool `json:"default,omitempty"`
+	// Description describes the feature gated by the gate.
+	//
+	// +kubebuilder:validation:Optional
+	Description string `json:"description,omitempty"`
+}
+
+// ConversionRule describes how to convert an instance from one version
+// to another.
+type ConversionRule struct {
+	// From is the version the instance is converted from.
+	//
+	// +kubebuilder:validation:Required
+	From string `json:"from,omitempty"`
+	// To is the version the instance is converted to.
+	//
+	// +kubebuilder:validation:Required
+	To string `json:"to,omitempty"`
+	// Spec is the spec of the converted instance. Fields can contain CEL
+	// expressions referring to the original instance using `schema`, e.g
+	// `${schema.spec.replicas}`.
+	//
+	// +kubebuilder:validation:Required
+	Spec runtime.RawExtension `json:"spec,omitempty"`
 }
 
 type Validation struct {
@@ -88,6 +316,61 @@ type Resource struct {
 	ReadyWhen []string `json:"readyWhen,omitempty"`
 	// +kubebuilder:validation:Optional
 	IncludeWhen []string `json:"includeWhen,omitempty"`
+	// AutoEncodeBytes makes kro base64 encode the values produced by the
+	// expressions feeding "byte" formatted fields (e.g the Secret data
+	// values), instead of expecting them to be encoded already.
+	//
+	// +kubebuilder:validation:Optional
+	AutoEncodeBytes bool `json:"autoEncodeBytes,omitempty"`
+	// WaitFor lists the ids of the resources that must be ready before this
+	// resource is applied, even if it doesn't refer to any of their fields.
+	//
+	// +kubebuilder:validation:Optional
+	WaitFor []string `json:"waitFor,omitempty"`
+	// Conditions are named conditions computed from CEL expressions
+	// evaluated against the resource at each reconciliation. They are
+	// written in the instance status.resources field.
+	//
+	// +kubebuilder:validation:Optional
+	Conditions []ResourceCondition `json:"conditions,omitempty"`
+	// MinReadyDuration is how long the readyWhen expressions of the
+	// resource must hold continuously before it is considered ready, and the
+	// resources depending on it are applied, e.g `30s`. The time the
+	// resource became ready is reported in the instance status.resources
+	// field.
+	//
+	// +kubebuilder:validation:Optional
+	MinReadyDuration *metav1.Duration `json:"minReadyDuration,omitempty"`
+	// Finalizers are added to the resource when it is created, and kept
+	// when it is updated, along with the finalizers set by other controllers.
+	// kro removes them when it deletes the resource, on the deletion of the
+	// instance, leaving the other finalizers to their controllers.
+	//
+	// +kubebuilder:validation:Optional
+	Finalizers []string `json:"finalizers,omitempty"`
+	// EvaluateOnce lists the paths of the template fields computed by
+	// expressions that are only evaluated when the resource is first
+	// rendered, e.g `data.password`. Their values are stored in the instance
+	// status.evaluatedOnce field and reused by the next reconciliations. A
+	// value cleared from the status is evaluated again.
+	//
+	// +kubebuilder:validation:Optional
+	EvaluateOnce []string `json:"evaluateOnce,omitempty"`
+}
+
+// ResourceCondition is a named condition of a resource, computed from a CEL
+// expression.
+type ResourceCondition struct {
+	// Type is the type of the condition, e.g DatabaseProvisioned.
+	//
+	// +kubebuilder:validation:Required
+	Type string `json:"type,omitempty"`
+	// Expression is a standalone CEL expression referring to the resource
+	// by its id, e.g `${database.status.state == "available"}`. It must
+	// evaluate to a boolean.
+	//
+	// +kubebuilder:validation:Required
+	Expression string `json:"expression,omitempty"`
 }
 
 // ResourceGroupStatus defines the observed state of ResourceGroup
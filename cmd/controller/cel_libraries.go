@@ -0,0 +1,22 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+// Custom CEL function libraries are made available to the ResourceGroup
+// expressions by blank importing, in this file, the packages registering them
+// with krocel.RegisterLibrary from their init function, e.g:
+//
+//	import _ "example.com/org/kro-functions"
+//
+// See krocel.RegisterLibrary for the contract the libraries must honor.
@@ -0,0 +1,133 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// redactedValue replaces the values of the sensitive flags in the effective
+// configuration.
+const redactedValue = "<redacted>"
+
+// sensitiveFlagWords are the words marking a flag holding a secret, or the
+// path of one, whose value must not be exposed.
+var sensitiveFlagWords = []string{"kubeconfig", "token", "password", "secret", "credential"}
+
+// effectiveConfig is the configuration the controller runs with, served by
+// the /config debug endpoint.
+type effectiveConfig struct {
+	// Flags are the resolved values of all the flags, defaults included,
+	// indexed by flag name.
+	Flags map[string]string `json:"flags"`
+	// Overridden are the names of the flags set on the command line.
+	Overridden []string `json:"overridden"`
+	// Redacted are the names of the flags whose value isn't exposed.
+	Redacted []string `json:"redacted"`
+}
+
+func isSensitiveFlag(name string) bool {
+	for _, word := range sensitiveFlagWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// newEffectiveConfig collects the resolved values of the flags of fs, which
+// must be parsed.
+func newEffectiveConfig(fs *flag.FlagSet) effectiveConfig {
+	config := effectiveConfig{
+		Flags:      map[string]string{},
+		Overridden: []string{},
+		Redacted:   []string{},
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if isSensitiveFlag(f.Name) {
+			value = redactedValue
+			config.Redacted = append(config.Redacted, f.Name)
+		}
+		config.Flags[f.Name] = value
+	})
+	fs.Visit(func(f *flag.Flag) {
+		config.Overridden = append(config.Overridden, f.Name)
+	})
+	sort.Strings(config.Overridden)
+	return config
+}
+
+// newDebugHandler returns the handler of the debug endpoints: the effective
+// configuration of the controller at /config, and the pprof profiles at
+// /debug/pprof/.
+func newDebugHandler(fs *flag.FlagSet) http.Handler {
+	config := newEffectiveConfig(fs)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(config)
+	})
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// debugServer serves the debug endpoints until the manager stops.
+type debugServer struct {
+	addr    string
+	handler http.Handler
+}
+
+var _ manager.LeaderElectionRunnable = &debugServer{}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable: the debug
+// endpoints are served on all the replicas.
+func (s *debugServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *debugServer) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	setupLog.Info("serving debug endpoints", "address", s.addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
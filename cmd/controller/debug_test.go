@@ -0,0 +1,65 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandlerConfig(t *testing.T) {
+	fs := flag.NewFlagSet("kro", flag.ContinueOnError)
+	fs.Int("max-object-history", 10, "")
+	fs.Bool("enable-self-healing", true, "")
+	fs.String("kubeconfig", "", "")
+	fs.String("tracing-token", "", "")
+	require.NoError(t, fs.Parse([]string{
+		"--max-object-history=20",
+		"--kubeconfig=/home/kro/.kube/config",
+		"--tracing-token=s3cr3t",
+	}))
+
+	recorder := httptest.NewRecorder()
+	newDebugHandler(fs).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/config", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.NotContains(t, recorder.Body.String(), "s3cr3t")
+	assert.NotContains(t, recorder.Body.String(), "/home/kro")
+
+	var got effectiveConfig
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	assert.Equal(t, effectiveConfig{
+		Flags: map[string]string{
+			"max-object-history":  "20",
+			"enable-self-healing": "true",
+			"kubeconfig":          redactedValue,
+			"tracing-token":       redactedValue,
+		},
+		Overridden: []string{"kubeconfig", "max-object-history", "tracing-token"},
+		Redacted:   []string{"kubeconfig", "tracing-token"},
+	}, got)
+}
+
+func TestDebugHandlerPprof(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newDebugHandler(flag.NewFlagSet("kro", flag.ContinueOnError)).
+		ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
@@ -17,6 +17,8 @@ import (
 	"context"
 	"flag"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"go.uber.org/zap/zapcore"
@@ -24,16 +26,19 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
-	ctrlrtcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
-	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	xv1alpha1 "github.com/awslabs/kro/api/v1alpha1"
+	instancectrl "github.com/awslabs/kro/internal/controller/instance"
 	resourcegroupctrl "github.com/awslabs/kro/internal/controller/resourcegroup"
 	"github.com/awslabs/kro/internal/graph"
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/tracing"
+	"github.com/awslabs/kro/internal/webhook"
+	krocel "github.com/awslabs/kro/pkg/cel"
 	kroclient "github.com/awslabs/kro/pkg/client"
 	"github.com/awslabs/kro/pkg/dynamiccontroller"
 	//+kubebuilder:scaffold:imports
@@ -59,30 +64,120 @@ func (c customLevelEnabler) Enabled(lvl zapcore.Level) bool {
 	return -int(lvl) <= c.level
 }
 
+// newZapOptions returns the options of the root logger. Development mode
+// enables stacktraces on warnings and disables sampling, which is only
+// suitable when developing kro.
+func newZapOptions(development bool, logLevel int) zap.Options {
+	return zap.Options{
+		Development: development,
+		Level:       customLevelEnabler{level: logLevel},
+		TimeEncoder: zapcore.ISO8601TimeEncoder,
+	}
+}
+
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var debugAddr string
 	var allowCRDDeletion bool
+	var allowBuiltinKindShadowing bool
+	var maxReferencedResources int
+	var expressionCostBudget uint64
+	var allowedAPIGroups string
+	var deniedAPIGroups string
+	var celProgramCacheSize int
+	var celEnabledExtensions string
 	var resourceGroupConcurrentReconciles int
 	var dynamicControllerConcurrentReconciles int
+	var dynamicControllerFairQueueing bool
+	var disableLeaderElectionForDynamicController bool
+	var requeueOnChildResync bool
+	var startupReconcileBurst int
+	var maxConcurrentReconcilesPerResourceGroup int
+	var maxResourceGroups int
 	// reconciler parameters
 	var resyncPeriod int
 	var queueMaxRetries int
 	var shutdownTimeout int
 	// var dynamicControllerDefaultResyncPeriod int
 	var logLevel int
+	var logDevelopment bool
 	var qps float64
 	var burst int
+	var maxObjectHistory int
+	var resourceTypeWaitTimeout int
+	var enableSelfHealing bool
+	var dependencyNotFoundPolicy string
+	var serverRejectionPolicy string
+	var excludedDependencyPolicy string
+	var excludedResourceGracePeriod int
+	var maxManagedObjects int
+	var enableConfigHash bool
+	var configHashPath string
+	var createBatchConcurrency int
+	var createQPS float64
+	var createBurst int
+	var impersonationPreflight bool
+	var coerceNumericStrings bool
+	var informerSelectorValues []string
+	// conversion webhook parameters
+	var enableConversionWebhook bool
+	var webhookPort int
+	var webhookCertDir string
+	var webhookServiceName string
+	var webhookServiceNamespace string
+	var webhookServicePort int
+	// tracing parameters
+	var enableTracing bool
+	var tracingOTLPEndpoint string
+	var tracingInsecure bool
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8078", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8079", "The address the probe endpoint binds to.")
+	flag.StringVar(&debugAddr, "debug-bind-address", "",
+		"The address the debug endpoints bind to: the effective configuration, sensitive values redacted, at /config "+
+			"and the pprof profiles at /debug/pprof/. Empty disables the debug endpoints")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&allowCRDDeletion, "allow-crd-deletion", false, "allow kro to delete CRDs")
+	flag.BoolVar(&allowBuiltinKindShadowing, "allow-builtin-kind-shadowing", false,
+		"allow resource groups to declare a kind colliding with a built-in Kubernetes kind (e.g Pod or Deployment)")
+	flag.IntVar(&maxReferencedResources, "max-referenced-resources-per-field", 0,
+		"The maximum number of distinct resources the expressions of a single resource field can reference. 0 means no limit")
+	flag.Uint64Var(&expressionCostBudget, "expression-cost-budget", 0,
+		"The maximum estimated cost of all the CEL expressions of a resource group. 0 means no limit")
+	flag.StringVar(&allowedAPIGroups, "allowed-api-groups", "",
+		"The comma separated API groups resource groups are allowed to create resources in, \"core\" being the core API group. "+
+			"Empty means all the API groups that aren't denied")
+	flag.StringVar(&deniedAPIGroups, "denied-api-groups", "",
+		"The comma separated API groups resource groups are denied to create resources in, e.g \"rbac.authorization.k8s.io\". "+
+			"The denied API groups take precedence over the allowed ones")
+	flag.IntVar(&celProgramCacheSize, "cel-program-cache-size", krocel.DefaultProgramCacheSize,
+		"The maximum number of compiled CEL programs cached to evaluate the expressions of the instances. 0 disables the cache")
+	flag.StringVar(&celEnabledExtensions, "cel-enabled-extensions", strings.Join(krocel.DefaultExtensions, ","),
+		"The comma separated CEL extension libraries available to the expressions, among "+
+			strings.Join(krocel.AvailableExtensions(), ", ")+". Empty disables all of them")
 	flag.IntVar(&resourceGroupConcurrentReconciles, "resource-group-concurrent-reconciles", 1, "The number of resource group reconciles to run in parallel")
 	flag.IntVar(&dynamicControllerConcurrentReconciles, "dynamic-controller-concurrent-reconciles", 1, "The number of dynamic controller reconciles to run in parallel")
+	flag.BoolVar(&dynamicControllerFairQueueing, "dynamic-controller-fair-queueing", false,
+		"Give each resource group its own dynamic controller queue, so that the instances of one resource group can't starve the others")
+	flag.BoolVar(&disableLeaderElectionForDynamicController, "disable-leader-election-for-dynamic-controller", false,
+		"Run the dynamic controller, and thus reconcile the instances, on all the replicas instead of only on the leader. "+
+			"The resource groups, their CRDs and their status are still only written by the leader. "+
+			"Every replica reconciles every instance it watches, the replicas may race on the instance status updates")
+	flag.BoolVar(&requeueOnChildResync, "requeue-on-child-resync", false,
+		"Reconcile the instances on every resync of the informers watching their resources, to pick up the changes missed while the watches were broken. "+
+			"The reconciles of an instance triggered by the resync of its resources are coalesced")
+	flag.IntVar(&startupReconcileBurst, "startup-reconcile-burst", 0,
+		"The number of dynamic controller reconciles run in parallel right after the startup, while every existing instance is reconciled. "+
+			"The parallelism then ramps up to --dynamic-controller-concurrent-reconciles. 0 means no ramp up")
+	flag.IntVar(&maxConcurrentReconcilesPerResourceGroup, "max-concurrent-reconciles-per-resource-group", 0,
+		"The maximum number of instances of a single resource group reconciled in parallel, when fair queueing is enabled. 0 means no limit")
+	flag.IntVar(&maxResourceGroups, "max-resource-groups", 0,
+		"The maximum number of resource groups, and thus of instance informers, managed by the controller. "+
+			"The oldest resource groups are managed first, the others are rejected. 0 means no limit")
 	// reconciler parametes
 	flag.IntVar(&resyncPeriod, "dynamic-controller-default-resync-period", 10,
 		"interval at which the controller will re list resources even with no changes, in hours")
@@ -92,22 +187,144 @@ func main() {
 		"maximum duration to wait for the controller to gracefully shutdown, in seconds")
 	// log level flags
 	flag.IntVar(&logLevel, "log-level", 10, "The log level verbosity. 0 is the least verbose, 5 is the most verbose.")
+	flag.BoolVar(&logDevelopment, "log-development", false,
+		"Log in development mode: stacktraces on warnings and no sampling. Not meant for production")
 	// qps and burst
 	flag.Float64Var(&qps, "client-qps", 100, "The number of queries per second to allow")
 	flag.IntVar(&burst, "client-burst", 150,
 		"The number of requests that can be stored for processing before the server starts enforcing the QPS limit")
+	flag.IntVar(&maxObjectHistory, "max-object-history", 10,
+		"The maximum number of conditions kept in the status of instances, only the most recent condition "+
+			"of each type is kept. 0 disables the cap")
+	flag.IntVar(&resourceTypeWaitTimeout, "resource-type-wait-timeout", 120,
+		"maximum duration to retry, with backoff, the resources whose type is not served yet by the API server "+
+			"(e.g. right after their CRD was created), in seconds. 0 disables the wait")
+	flag.BoolVar(&enableSelfHealing, "enable-self-healing", true,
+		"Watch the resources managed by kro, and recreate them when they are deleted out-of-band")
+	flag.StringVar(&dependencyNotFoundPolicy, "dependency-not-found-policy", string(instancectrl.DependencyNotFoundPolicyRecreate),
+		"How to handle a resource found deleted while computing the status of its instance: "+
+			"Recreate recreates it, Wait reports the instance as waiting for it")
+	flag.StringVar(&serverRejectionPolicy, "server-rejection-policy", string(instancectrl.ServerRejectionPolicyTerminal),
+		"How to handle a resource the API server rejects as invalid or forbidden, e.g. denied by an admission webhook: "+
+			"Terminal reports the instance in error with the server message until the instance changes, Retry retries with a backoff")
+	flag.StringVar(&excludedDependencyPolicy, "excluded-dependency-policy", string(instancectrl.ExcludedDependencyPolicySkip),
+		"How to handle a resource without includeWhen expressions depending on a resource excluded by its includeWhen expressions: "+
+			"Skip excludes it along with its dependency, Block reports the instance in error until the instance changes")
+	flag.IntVar(&excludedResourceGracePeriod, "excluded-resource-grace-period", 0,
+		"how long to keep a resource excluded by its includeWhen expressions before deleting it, in seconds. "+
+			"The deletion is cancelled if the resource is included again in the meantime. 0 leaves the excluded resources in the cluster")
+	flag.IntVar(&maxManagedObjects, "max-managed-objects", 0,
+		"The maximum number of objects managed by the instances of all the resource groups, a last resort safety valve "+
+			"against a runaway resource group or a flood of instances. Past it, the creations of new objects are held, "+
+			"with a "+instancectrl.ManagedObjectLimitReason+" condition, until objects are freed. It should be generous, "+
+			"e.g 100000. The objects are counted as the instances are reconciled. 0 disables the cap")
+	flag.BoolVar(&enableConfigHash, "enable-config-hash", false,
+		"Inject a "+metadata.ConfigHashAnnotation+" annotation, holding the hash of the resources referenced by "+
+			"their template, in the resources bearing a pod template, so that a change of a referenced resource "+
+			"(e.g a ConfigMap) rolls out their pods")
+	flag.StringVar(&configHashPath, "config-hash-path", instancectrl.DefaultConfigHashPath,
+		"The path of the annotations the config hash annotation is injected in, when enabled")
+	flag.IntVar(&createBatchConcurrency, "create-batch-concurrency", 0,
+		"The maximum number of independent resources of an instance created in parallel. The resources of a same "+
+			"dependency level are created together. 0 or 1 creates the resources one at a time")
+	flag.Float64Var(&createQPS, "create-qps", 0,
+		"The maximum number of resource creations per second for the instances of each resource group. 0 means no limit")
+	flag.IntVar(&createBurst, "create-burst", 10,
+		"The maximum burst of resource creations for the instances of each resource group, when --create-qps is set")
+	flag.BoolVar(&impersonationPreflight, "impersonation-preflight", false,
+		"Before applying the resources of an instance under an impersonated service account, check with a "+
+			"SelfSubjectAccessReview per resource type that the service account is allowed to create them")
+	flag.BoolVar(&coerceNumericStrings, "coerce-numeric-strings", false,
+		"Parse the strings computed by expressions for the integer and number fields of the resources, e.g \"8080\", "+
+			"into the expected type. Non numeric strings fail the reconciliation of the instance")
+	// conversion webhook flags
+	flag.BoolVar(&enableConversionWebhook, "enable-conversion-webhook", false,
+		"Enable the conversion webhook used to convert instances between the versions of their kind")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory containing the webhook server certificate (tls.crt and tls.key) and, optionally, "+
+			"the CA bundle (ca.crt) used by the API server to validate it")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", "kro-webhook-service",
+		"The name of the service exposing the webhook server")
+	flag.StringVar(&webhookServiceNamespace, "webhook-service-namespace", "kro-system",
+		"The namespace of the service exposing the webhook server")
+	flag.IntVar(&webhookServicePort, "webhook-service-port", 443, "The port of the service exposing the webhook server")
+	// informer flags
+	flag.Func("informer-selector",
+		"Restrict the objects watched by the informer of a GVR, as gvr=labelSelector[;fieldSelector], where the GVR "+
+			"is version/resource or group/version/resource, e.g. apps/v1/deployments=app.kubernetes.io/managed-by=kro. "+
+			"Can be repeated, once per GVR",
+		func(value string) error {
+			informerSelectorValues = append(informerSelectorValues, value)
+			return nil
+		})
+	// tracing flags
+	flag.BoolVar(&enableTracing, "enable-tracing", false,
+		"Enable the OpenTelemetry tracing of the instance reconciliations")
+	flag.StringVar(&tracingOTLPEndpoint, "tracing-otlp-endpoint", "localhost:4317",
+		"The host:port of the OTLP gRPC collector receiving the traces")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false,
+		"Disable the transport security of the connection to the OTLP collector")
 
 	flag.Parse()
 
-	opts := zap.Options{
-		Development: true,
-		Level:       customLevelEnabler{level: logLevel},
-		TimeEncoder: zapcore.ISO8601TimeEncoder,
-	}
+	opts := newZapOptions(logDevelopment, logLevel)
 	rootLogger := zap.New(zap.UseFlagOptions(&opts))
 
 	ctrl.SetLogger(rootLogger)
 
+	dependencyPolicy, err := instancectrl.ParseDependencyNotFoundPolicy(dependencyNotFoundPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid dependency not found policy")
+		os.Exit(1)
+	}
+
+	rejectionPolicy, err := instancectrl.ParseServerRejectionPolicy(serverRejectionPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid server rejection policy")
+		os.Exit(1)
+	}
+
+	exclusionPolicy, err := instancectrl.ParseExcludedDependencyPolicy(excludedDependencyPolicy)
+	if err != nil {
+		setupLog.Error(err, "invalid excluded dependency policy")
+		os.Exit(1)
+	}
+	informerSelectors, err := dynamiccontroller.ParseInformerSelectors(informerSelectorValues)
+	if err != nil {
+		setupLog.Error(err, "invalid informer selectors")
+		os.Exit(1)
+	}
+	celExtensions, err := krocel.ParseExtensions(celEnabledExtensions)
+	if err != nil {
+		setupLog.Error(err, "invalid CEL extensions")
+		os.Exit(1)
+	}
+
+	var configHashSegments []string
+	if enableConfigHash {
+		configHashSegments, err = instancectrl.ParseConfigHashPath(configHashPath)
+		if err != nil {
+			setupLog.Error(err, "invalid config hash path")
+			os.Exit(1)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:      enableTracing,
+		OTLPEndpoint: tracingOTLPEndpoint,
+		Insecure:     tracingInsecure,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "unable to shut down tracing")
+		}
+	}()
+
 	set, err := kroclient.NewSet(kroclient.Config{
 		QPS:   float32(qps),
 		Burst: burst,
@@ -118,12 +335,21 @@ func main() {
 	}
 	restConfig := set.RESTConfig()
 
+	var webhookServer ctrlwebhook.Server
+	if enableConversionWebhook {
+		webhookServer = ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		})
+	}
+
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
 		HealthProbeBindAddress: probeAddr,
+		WebhookServer:          webhookServer,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "6f0f64a5.kro.run",
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
@@ -143,22 +369,94 @@ func main() {
 		os.Exit(1)
 	}
 
+	if debugAddr != "" {
+		if err := mgr.Add(&debugServer{addr: debugAddr, handler: newDebugHandler(flag.CommandLine)}); err != nil {
+			setupLog.Error(err, "unable to add debug server to manager")
+			os.Exit(1)
+		}
+	}
+
 	dc := dynamiccontroller.NewDynamicController(rootLogger, dynamiccontroller.Config{
 		Workers: dynamicControllerConcurrentReconciles,
 		// TODO(a-hilaly): expose these as flags
 		ShutdownTimeout: time.Duration(shutdownTimeout) * time.Second,
 		ResyncPeriod:    time.Duration(resyncPeriod) * time.Hour,
 		QueueMaxRetries: queueMaxRetries,
+
+		FairQueueing:                  dynamicControllerFairQueueing,
+		MaxConcurrentReconcilesPerGVR: maxConcurrentReconcilesPerResourceGroup,
+		DisableLeaderElection:         disableLeaderElectionForDynamicController,
+		RequeueOnChildResync:          requeueOnChildResync,
+		StartupReconcileBurst:         startupReconcileBurst,
+		InformerSelectors:             informerSelectors,
 	}, set.Dynamic())
+	if err := mgr.Add(dc); err != nil {
+		setupLog.Error(err, "unable to add dynamic controller to manager")
+		os.Exit(1)
+	}
+
+	// When the dynamic controller runs on all the replicas, so must the
+	// resource group controller starting the instance controllers. Only the
+	// leader writes the resource groups though.
+	var leader func() bool
+	if disableLeaderElectionForDynamicController {
+		leader = func() bool {
+			select {
+			case <-mgr.Elected():
+				return true
+			default:
+				return false
+			}
+		}
+	}
+
+	krocel.DefaultProgramCache.Resize(celProgramCacheSize)
+	if err := krocel.SetEnabledExtensions(celExtensions); err != nil {
+		setupLog.Error(err, "unable to enable the CEL extensions")
+		os.Exit(1)
+	}
+	if libraries := krocel.RegisteredLibraries(); len(libraries) > 0 {
+		setupLog.Info("registered custom CEL libraries", "libraries", libraries)
+	}
 
 	resourceGroupGraphBuilder, err := graph.NewBuilder(
 		restConfig,
+		graph.WithBuiltinKindShadowing(allowBuiltinKindShadowing),
+		graph.WithMaxReferencedResources(maxReferencedResources),
+		graph.WithExpressionCostBudget(expressionCostBudget),
+		graph.WithAllowedAPIGroups(graph.ParseAPIGroups(allowedAPIGroups)),
+		graph.WithDeniedAPIGroups(graph.ParseAPIGroups(deniedAPIGroups)),
 	)
 	if err != nil {
 		setupLog.Error(err, "unable to create resource group graph builder")
 		os.Exit(1)
 	}
 
+	var conversionWebhook *webhook.ConversionWebhook
+	if enableConversionWebhook {
+		// The CA bundle is optional, it can also be injected in the CRDs by
+		// an external tool (e.g cert-manager).
+		caBundle, err := os.ReadFile(filepath.Join(webhookCertDir, "ca.crt"))
+		if err != nil && !os.IsNotExist(err) {
+			setupLog.Error(err, "unable to read conversion webhook CA bundle")
+			os.Exit(1)
+		}
+		conversionWebhook = webhook.NewConversionWebhook(rootLogger, webhook.ConversionWebhookConfig{
+			ServiceName:      webhookServiceName,
+			ServiceNamespace: webhookServiceNamespace,
+			ServicePort:      int32(webhookServicePort),
+			CABundle:         caBundle,
+		})
+		mgr.GetWebhookServer().Register(webhook.ConversionPath, conversionWebhook)
+		// The webhook is served by every replica, each of them registers
+		// the converters of the resource groups.
+		crds := set.CRD(kroclient.CRDWrapperConfig{Log: rootLogger})
+		if err := webhook.NewConverterReconciler(rootLogger, mgr.GetClient(), crds, conversionWebhook).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ConversionWebhookConverters")
+			os.Exit(1)
+		}
+	}
+
 	reconciler := resourcegroupctrl.NewResourceGroupReconciler(
 		rootLogger,
 		mgr.GetClient(),
@@ -166,25 +464,32 @@ func main() {
 		allowCRDDeletion,
 		dc,
 		resourceGroupGraphBuilder,
-	)
-	err = ctrl.NewControllerManagedBy(
-		mgr,
-	).For(
-		&xv1alpha1.ResourceGroup{},
-	).WithEventFilter(
-		predicate.GenerationChangedPredicate{},
-	).WithOptions(
-		ctrlrtcontroller.Options{
-			MaxConcurrentReconciles: resourceGroupConcurrentReconciles,
+		conversionWebhook,
+		resourcegroupctrl.ReconcilerConfig{
+			MaxInstanceConditions:       maxObjectHistory,
+			ResourceTypeWaitTimeout:     time.Duration(resourceTypeWaitTimeout) * time.Second,
+			SelfHealing:                 enableSelfHealing,
+			DependencyNotFoundPolicy:    dependencyPolicy,
+			ServerRejectionPolicy:       rejectionPolicy,
+			ExcludedDependencyPolicy:    exclusionPolicy,
+			ExcludedResourceGracePeriod: time.Duration(excludedResourceGracePeriod) * time.Second,
+			MaxManagedObjects:           maxManagedObjects,
+			ConfigHashPath:              configHashSegments,
+			MaxResourceGroups:           maxResourceGroups,
+			CreateBatchConcurrency:      createBatchConcurrency,
+			CreateQPS:                   createQPS,
+			CreateBurst:                 createBurst,
+			ImpersonationPreflight:      impersonationPreflight,
+			CoerceNumericStrings:        coerceNumericStrings,
+			Leader:                      leader,
+			MaxConcurrentReconciles:     resourceGroupConcurrentReconciles,
 		},
-	).Complete(reconcile.AsReconciler[*xv1alpha1.ResourceGroup](mgr.GetClient(), reconciler))
-	if err != nil {
+	)
+	if err := reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ResourceGroup")
 		os.Exit(1)
 	}
 
-	go dc.Run(context.Background())
-
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
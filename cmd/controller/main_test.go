@@ -0,0 +1,43 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewZapOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		development bool
+	}{
+		{name: "production", development: false},
+		{name: "development", development: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := newZapOptions(tt.development, 3)
+			assert.Equal(t, tt.development, opts.Development)
+			assert.Equal(t, customLevelEnabler{level: 3}, opts.Level)
+			assert.NotNil(t, opts.TimeEncoder)
+
+			// The custom level enabler is kept in both modes.
+			assert.True(t, opts.Level.Enabled(zapcore.Level(-3)))
+			assert.False(t, opts.Level.Enabled(zapcore.Level(-4)))
+		})
+	}
+}
@@ -21,15 +21,18 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/awslabs/kro/api/v1alpha1"
 	"github.com/awslabs/kro/internal/graph"
 	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
 	kroclient "github.com/awslabs/kro/pkg/client"
 )
 
@@ -47,6 +50,75 @@ type ReconcileConfig struct {
 	// TODO(a-hilaly): need to define think the different deletion policies we need to
 	// support.
 	DeletionPolicy string
+	// MaxConditions is the maximum number of conditions kept in the status of
+	// an instance. When the cap is reached, the oldest conditions are pruned.
+	// A value of 0 or less disables the cap.
+	MaxConditions int
+	// ResourceGroupName is the name of the ResourceGroup the instances belong
+	// to. It is used to annotate the reconciliation traces.
+	ResourceGroupName string
+	// ResourceTypeWaitTimeout bounds how long the reconciler waits for the API
+	// server to serve the type of a resource, e.g. right after its CRD was
+	// created. While waiting, the instance is requeued with an exponential
+	// backoff. Past the timeout, the error is handled as any other error. A
+	// value of 0 or less disables the wait.
+	ResourceTypeWaitTimeout time.Duration
+	// ResourceTypeWaitInitialBackoff is the delay before the first retry while
+	// waiting for the type of a resource to be served. It doubles at each
+	// retry.
+	ResourceTypeWaitInitialBackoff time.Duration
+	// ResourceTypeWaitMaxBackoff is the maximum delay between two retries
+	// while waiting for the type of a resource to be served.
+	ResourceTypeWaitMaxBackoff time.Duration
+	// DependencyNotFoundPolicy defines how a resource found deleted while
+	// computing the instance status is handled. The resource is recreated
+	// by default.
+	DependencyNotFoundPolicy DependencyNotFoundPolicy
+	// ConfigHashPath is the path of the annotations the config hash
+	// annotation is injected in, see ParseConfigHashPath. The annotation is
+	// not injected when empty.
+	ConfigHashPath []string
+	// CreateBatchConcurrency is the maximum number of resources of an
+	// instance created in parallel. The resources of a same dependency level
+	// are then created together, the ones of the next levels once they are
+	// ready. A value of 1 or less disables the batching: the resources are
+	// created one at a time.
+	CreateBatchConcurrency int
+	// CreateQPS is the maximum rate of resource creations per second, across
+	// the instances of the resource group. A value of 0 or less disables the
+	// rate limit.
+	CreateQPS float64
+	// CreateBurst is the maximum burst of resource creations allowed by the
+	// CreateQPS rate limit.
+	CreateBurst int
+	// ImpersonationPreflight enables, when the resources of an instance are
+	// applied under an impersonated service account, a SelfSubjectAccessReview
+	// per resource type before applying them. The instance fails fast if the
+	// service account isn't allowed to create them, instead of getting a
+	// Forbidden error mid-apply.
+	ImpersonationPreflight bool
+	// CoerceNumericStrings makes the strings computed for the integer and
+	// number fields of the resources parsed into the expected type, failing
+	// the reconciliation if they aren't numeric.
+	CoerceNumericStrings bool
+	// ServerRejectionPolicy defines how a resource rejected by the API server
+	// as invalid or forbidden is handled. The instance isn't retried by
+	// default.
+	ServerRejectionPolicy ServerRejectionPolicy
+	// ExcludedDependencyPolicy defines how a resource without includeWhen
+	// expressions depending on an excluded resource is handled. It is
+	// excluded along with it by default.
+	ExcludedDependencyPolicy ExcludedDependencyPolicy
+	// ExcludedResourceGracePeriod is how long a resource created by an
+	// instance, then excluded by its includeWhen expressions, is kept before
+	// being deleted. The deletion is cancelled if the resource is included
+	// again within the grace period. A value of 0 or less disables the
+	// deletion: the excluded resources are left in the cluster.
+	ExcludedResourceGracePeriod time.Duration
+	// ManagedObjects caps the total number of objects managed by the
+	// instances. It is shared by the controllers of all the resource groups.
+	// The number of objects isn't capped when nil.
+	ManagedObjects *ManagedObjects
 }
 
 // Controller manages the reconciliation of a single instance of a ResourceGroup,
@@ -88,6 +160,21 @@ type Controller struct {
 	reconcileConfig ReconcileConfig
 	// defaultServiceAccounts is a map of service accounts to use for controller impersonation.
 	defaultServiceAccounts map[string]string
+	// tracer is used to trace the reconciliation of the instances.
+	tracer trace.Tracer
+	// resourceTypeWaits records the resources waiting for their type to be
+	// served by the API server, across reconciliations.
+	resourceTypeWaits *resourceTypeWaits
+	// recorder records the reconciliation failures as events on the
+	// instances.
+	recorder record.EventRecorder
+	// createLimiter rate limits the resource creations of the instances. It
+	// is nil when the creations aren't rate limited.
+	createLimiter *rate.Limiter
+	// renderCache caches the rendered resources of the instances across
+	// reconciliations. The controller is recreated when the ResourceGroup
+	// generation changes, invalidating the cache with it.
+	renderCache *runtime.RenderCache
 }
 
 // NewController creates a new Controller instance.
@@ -97,6 +184,7 @@ func NewController(
 	gvr schema.GroupVersionResource,
 	rg *graph.Graph,
 	clientSet *kroclient.Set,
+	recorder record.EventRecorder,
 	defaultServiceAccounts map[string]string,
 	instanceLabeler metadata.Labeler,
 ) *Controller {
@@ -108,19 +196,41 @@ func NewController(
 		instanceLabeler:        instanceLabeler,
 		reconcileConfig:        reconcileConfig,
 		defaultServiceAccounts: defaultServiceAccounts,
+		tracer:                 defaultTracer(),
+		resourceTypeWaits:      newResourceTypeWaits(),
+		recorder:               recorder,
+		createLimiter:          newCreateLimiter(reconcileConfig),
+		renderCache:            runtime.NewRenderCache(),
 	}
 }
 
+// newCreateLimiter returns the rate limiter of the resource creations, or nil
+// if they aren't rate limited.
+func newCreateLimiter(config ReconcileConfig) *rate.Limiter {
+	if config.CreateQPS <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(config.CreateQPS), max(config.CreateBurst, 1))
+}
+
 // Reconcile is a handler function that reconciles the instance and its sub-resources.
-func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) error {
+func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (err error) {
 	namespace, name := getNamespaceName(req)
 
+	ctx, span := c.tracer.Start(ctx, "Reconcile", trace.WithAttributes(
+		attributeResourceGroup.String(c.reconcileConfig.ResourceGroupName),
+		attributeInstanceNamespace.String(namespace),
+		attributeInstanceName.String(name),
+	))
+	defer func() { endSpan(span, err) }()
+
 	log := c.log.WithValues("namespace", namespace, "name", name)
 
 	instance, err := c.clientSet.Dynamic().Resource(c.gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Instance not found, it may have been deleted")
+			c.renderCache.Forget(namespace, name)
 			return nil
 		}
 		log.Error(err, "Failed to get instance")
@@ -132,7 +242,13 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) error {
 	// instance of the resource group. The instance graph reconciler is responsible
 	// for reconciling the instance and its sub-resources, while keeping the same
 	// runtime object in it's fields.
-	rgRuntime, err := c.rg.NewGraphRuntime(instance)
+	_, runtimeSpan := c.tracer.Start(ctx, "NewGraphRuntime")
+	runtimeOpts := []runtime.Option{runtime.WithRenderCache(c.renderCache)}
+	if c.reconcileConfig.CoerceNumericStrings {
+		runtimeOpts = append(runtimeOpts, runtime.WithNumericStringCoercion())
+	}
+	rgRuntime, err := c.rg.NewGraphRuntime(instance, runtimeOpts...)
+	endSpan(runtimeSpan, err)
 	if err != nil {
 		return fmt.Errorf("failed to create runtime resource group: %w", err)
 	}
@@ -144,7 +260,7 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) error {
 
 	// If possible, use a service account to create the execution client
 	// TODO(a-hilaly): client caching
-	executionClient, err := c.getExecutionClient(namespace)
+	executionClient, serviceAccount, err := c.getExecutionClient(namespace)
 	if err != nil {
 		return fmt.Errorf("failed to create execution client: %w", err)
 	}
@@ -152,14 +268,28 @@ func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) error {
 	instanceGraphReconciler := &instanceGraphReconciler{
 		log:                         log,
 		gvr:                         c.gvr,
-		client:                      executionClient,
+		client:                      executionClient.Dynamic(),
 		runtime:                     rgRuntime,
 		instanceLabeler:             c.instanceLabeler,
 		instanceSubResourcesLabeler: instanceSubResourcesLabeler,
 		reconcileConfig:             c.reconcileConfig,
+		tracer:                      c.tracer,
+		identityFields:              c.rg.IdentityFields,
+		sensitiveFields:             c.rg.SensitiveFields,
+		statusStorage:               c.rg.StatusStorage,
+		requiredStatusFields:        c.rg.RequiredStatusFields,
+		composition:                 c.rg.Composition,
+		pausable:                    c.rg.Pausable,
+		resourceTypeWaits:           c.resourceTypeWaits,
+		recorder:                    c.recorder,
+		createLimiter:               c.createLimiter,
 		// Fresh instance state at each reconciliation loop.
 		state: newInstanceState(),
 	}
+	if c.reconcileConfig.ImpersonationPreflight && serviceAccount != "" {
+		instanceGraphReconciler.serviceAccount = serviceAccount
+		instanceGraphReconciler.accessReviews = executionClient.Kubernetes().AuthorizationV1().SelfSubjectAccessReviews()
+	}
 	return instanceGraphReconciler.reconcile(ctx)
 }
 
@@ -182,17 +312,19 @@ const (
 	errorInvalidSA    errorCategory = "invalid_sa"
 	errorClientCreate errorCategory = "client_create"
 	errorPermissions  errorCategory = "permissions"
+	errorForbidden    errorCategory = "forbidden"
 )
 
 // getExecutionClient determines the execution client to use for the instance.
 // If the instance is created in a namespace of which a service account is specified,
 // the execution client will be created using the service account. If no service account
-// is specified for the namespace, the default client will be used.
-func (c *Controller) getExecutionClient(namespace string) (dynamic.Interface, error) {
+// is specified for the namespace, the default client will be used. The impersonated
+// service account is returned along with the client, empty for the default client.
+func (c *Controller) getExecutionClient(namespace string) (*kroclient.Set, string, error) {
 	// if no service accounts are specified, use the default client
 	if len(c.defaultServiceAccounts) == 0 {
 		c.log.V(1).Info("no service accounts configured, using default client")
-		return c.clientSet.Dynamic(), nil
+		return c.clientSet, "", nil
 	}
 
 	timer := prometheus.NewTimer(impersonationDuration.WithLabelValues(namespace, ""))
@@ -203,17 +335,17 @@ func (c *Controller) getExecutionClient(namespace string) (dynamic.Interface, er
 		userName, err := getServiceAccountUserName(namespace, sa)
 		if err != nil {
 			c.handleImpersonateError(namespace, sa, err)
-			return nil, fmt.Errorf("invalid service account configuration: %w", err)
+			return nil, "", fmt.Errorf("invalid service account configuration: %w", err)
 		}
 
 		pivotedClient, err := c.clientSet.WithImpersonation(userName)
 		if err != nil {
 			c.handleImpersonateError(namespace, sa, err)
-			return nil, fmt.Errorf("failed to create impersonated client: %w", err)
+			return nil, "", fmt.Errorf("failed to create impersonated client: %w", err)
 		}
 
 		impersonationTotal.WithLabelValues(namespace, sa, "success").Inc()
-		return pivotedClient.Dynamic(), nil
+		return pivotedClient, sa, nil
 	}
 
 	// Check for default service account (marked by "*")
@@ -221,22 +353,22 @@ func (c *Controller) getExecutionClient(namespace string) (dynamic.Interface, er
 		userName, err := getServiceAccountUserName(namespace, defaultSA)
 		if err != nil {
 			c.handleImpersonateError(namespace, defaultSA, err)
-			return nil, fmt.Errorf("invalid default service account configuration: %w", err)
+			return nil, "", fmt.Errorf("invalid default service account configuration: %w", err)
 		}
 
 		pivotedClient, err := c.clientSet.WithImpersonation(userName)
 		if err != nil {
 			c.handleImpersonateError(namespace, defaultSA, err)
-			return nil, fmt.Errorf("failed to create impersonated client with default SA: %w", err)
+			return nil, "", fmt.Errorf("failed to create impersonated client with default SA: %w", err)
 		}
 
 		impersonationTotal.WithLabelValues(namespace, defaultSA, "success").Inc()
-		return pivotedClient.Dynamic(), nil
+		return pivotedClient, defaultSA, nil
 	}
 
 	impersonationTotal.WithLabelValues(namespace, "", "default").Inc()
 	// Fallback to the default client
-	return c.clientSet.Dynamic(), nil
+	return c.clientSet, "", nil
 }
 
 // handleImpersonateError logs the error and records the error in the metrics
@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// compositionRuntime is a fake runtime whose "app" resource isn't ready.
+type compositionRuntime struct {
+	*fakeRuntime
+}
+
+func (r *compositionRuntime) IsResourceReady(id string) (bool, string, error) {
+	if id == "app" {
+		return false, "app not available", nil
+	}
+	return true, "", nil
+}
+
+func TestReconcileComposition(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	resources := map[string]*unstructured.Unstructured{}
+	objects := []k8sruntime.Object{instance.DeepCopy()}
+	for _, id := range []string{"database", "app", "cache"} {
+		resources[id] = newTestObject("v1", "ConfigMap", "my-app-"+id)
+		applied := resources[id].DeepCopy()
+		metadata.GenericLabeler{}.ApplyLabels(applied)
+		require.NoError(t, setSpecHash(applied))
+		objects = append(objects, applied)
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		objects...,
+	)
+	igr := &instanceGraphReconciler{
+		log:    logr.Discard(),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &compositionRuntime{&fakeRuntime{
+			instance:  instance,
+			order:     []string{"database", "app", "cache"},
+			resources: resources,
+		}},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		composition:                 true,
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	// The reconciliation stops at the app, waiting for it to be ready.
+	require.Error(t, igr.reconcile(context.Background()))
+
+	observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+	require.NoError(t, err)
+	composition, _, _ := unstructured.NestedSlice(observed.Object, "status", "resources")
+	child := func(id, state string, ready bool) map[string]interface{} {
+		return map[string]interface{}{
+			"id":         id,
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"name":       "my-app-" + id,
+			"namespace":  "default",
+			"state":      state,
+			"ready":      ready,
+		}
+	}
+	assert.Equal(t, []interface{}{
+		child("database", "SYNCED", true),
+		child("app", "WAITING_FOR_READINESS", false),
+		child("cache", "PENDING", false),
+	}, composition)
+}
@@ -0,0 +1,100 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import "errors"
+
+// The reasons of the InstanceSynced condition. They are part of the kro API:
+// tools can rely on them to tell why an instance isn't synced, so they must
+// not be changed. Besides these, the following reasons report specific
+// failures:
+//   - IdentityFieldChangedReason
+//   - InstanceNameTooLongReason
+//   - ResourceTypeNotServedReason
+//   - DependencyNotFoundReason
+const (
+	// SyncedReason is used when all the resources of the instance are applied
+	// and its status is up to date.
+	SyncedReason = "Synced"
+	// ExpressionErrorReason is used when a CEL expression of the
+	// ResourceGroup fails to evaluate.
+	ExpressionErrorReason = "ExpressionError"
+	// DependencyNotReadyReason is used while waiting for a resource to be
+	// ready, before reconciling the resources depending on it.
+	DependencyNotReadyReason = "DependencyNotReady"
+	// SubResourceApplyFailedReason is used when a resource of the instance
+	// can't be read, created or updated.
+	SubResourceApplyFailedReason = "SubResourceApplyFailed"
+	// SubResourceDeleteFailedReason is used when a resource of the instance
+	// can't be deleted.
+	SubResourceDeleteFailedReason = "SubResourceDeleteFailed"
+	// DeletingReason is used while waiting for the resources of a deleted
+	// instance to be deleted.
+	DeletingReason = "Deleting"
+	// ReconciliationFailedReason is used for the other errors.
+	ReconciliationFailedReason = "ReconciliationFailed"
+)
+
+// The reasons of the named resource conditions, reported in the instance
+// status.resources field.
+const (
+	// ConditionMetReason is used when the condition expression evaluates to
+	// true.
+	ConditionMetReason = "ConditionMet"
+	// ConditionNotMetReason is used when the condition expression evaluates
+	// to false.
+	ConditionNotMetReason = "ConditionNotMet"
+	// ResourceNotObservedReason is used while the resource hasn't been
+	// observed yet, e.g before it is created.
+	ResourceNotObservedReason = "ResourceNotObserved"
+)
+
+// reasonedError is implemented by the errors reporting their own reason.
+type reasonedError interface {
+	error
+	Reason() string
+}
+
+// reasonError attaches a condition reason to an error.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+// withReason returns the given error with the given condition reason.
+func withReason(reason string, err error) error {
+	return &reasonError{reason: reason, err: err}
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+func (e *reasonError) Reason() string {
+	return e.reason
+}
+
+// errorReason returns the condition reason of the given reconcile error, and
+// the message to report with it.
+func errorReason(err error) (string, string) {
+	var reasoned reasonedError
+	if errors.As(err, &reasoned) {
+		return reasoned.Reason(), reasoned.Error()
+	}
+	return ReconciliationFailedReason, err.Error()
+}
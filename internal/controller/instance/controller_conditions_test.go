@@ -0,0 +1,228 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// faultyRuntime is a fake runtime failing in configurable ways.
+type faultyRuntime struct {
+	*fakeRuntime
+	syncErr    error
+	statusErr  error
+	readyErr   error
+	notReady   bool
+	unresolved bool
+}
+
+func (r *faultyRuntime) Synchronize() (bool, error) { return false, r.syncErr }
+func (r *faultyRuntime) SynchronizeStatus() error   { return r.statusErr }
+func (r *faultyRuntime) IsResourceReady(string) (bool, string, error) {
+	return !r.notReady && r.readyErr == nil, "", r.readyErr
+}
+func (r *faultyRuntime) GetResource(id string) (*unstructured.Unstructured, runtime.ResourceState) {
+	if r.unresolved {
+		return nil, runtime.ResourceStateWaitingOnDependencies
+	}
+	return r.fakeRuntime.GetResource(id)
+}
+
+func TestReconcileConditionReasons(t *testing.T) {
+	tests := []struct {
+		name string
+		// deleted reconciles the deletion of the instance.
+		deleted bool
+		// absent doesn't create the config map beforehand.
+		absent  bool
+		runtime func(rt *faultyRuntime)
+		// reactor fails the given verb on the config maps.
+		failVerb   string
+		wantReason string
+		wantStatus string
+	}{
+		{
+			name:       "synced",
+			wantReason: SyncedReason,
+			wantStatus: "True",
+		},
+		{
+			name:       "expression error",
+			runtime:    func(rt *faultyRuntime) { rt.syncErr = errors.New("no such key: spec") },
+			wantReason: ExpressionErrorReason,
+		},
+		{
+			name:       "status expression error",
+			runtime:    func(rt *faultyRuntime) { rt.statusErr = errors.New("no such key: status") },
+			wantReason: ExpressionErrorReason,
+		},
+		{
+			name:       "readyWhen expression error",
+			runtime:    func(rt *faultyRuntime) { rt.readyErr = errors.New("no such key: status") },
+			wantReason: ExpressionErrorReason,
+		},
+		{
+			name:       "resource not ready",
+			runtime:    func(rt *faultyRuntime) { rt.notReady = true },
+			wantReason: DependencyNotReadyReason,
+		},
+		{
+			name:       "resource not resolved",
+			runtime:    func(rt *faultyRuntime) { rt.unresolved = true },
+			wantReason: DependencyNotReadyReason,
+		},
+		{
+			name:       "resource just created",
+			absent:     true,
+			wantReason: DependencyNotReadyReason,
+		},
+		{
+			name:       "resource creation failure",
+			absent:     true,
+			failVerb:   "create",
+			wantReason: SubResourceApplyFailedReason,
+		},
+		{
+			name:       "resource read failure",
+			failVerb:   "get",
+			wantReason: SubResourceApplyFailedReason,
+		},
+		{
+			name:       "resource deletion in progress",
+			deleted:    true,
+			wantReason: DeletingReason,
+		},
+		{
+			name:       "resource deletion failure",
+			deleted:    true,
+			failVerb:   "delete",
+			wantReason: SubResourceDeleteFailedReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			if tt.deleted {
+				now := metav1.Now()
+				instance.SetDeletionTimestamp(&now)
+			}
+			configMap := newTestObject("v1", "ConfigMap", "app-config")
+
+			objects := []k8sruntime.Object{instance.DeepCopy()}
+			if !tt.absent {
+				objects = append(objects, configMap.DeepCopy())
+			}
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				objects...,
+			)
+			if tt.failVerb != "" {
+				client.PrependReactor(tt.failVerb, "configmaps", func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+					return true, nil, fmt.Errorf("%s failed", tt.failVerb)
+				})
+			}
+
+			rt := &faultyRuntime{fakeRuntime: &fakeRuntime{
+				instance:  instance,
+				order:     []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{"configmap": configMap},
+			}}
+			if tt.runtime != nil {
+				tt.runtime(rt)
+			}
+			igr := &instanceGraphReconciler{
+				log:                         logr.Discard(),
+				gvr:                         testInstanceGVR,
+				client:                      client,
+				runtime:                     rt,
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+			}
+			_ = igr.reconcile(context.Background())
+
+			observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+			require.NoError(t, err)
+			conditions, _, _ := unstructured.NestedSlice(observed.Object, "status", "conditions")
+			require.Len(t, conditions, 1)
+			condition := conditions[0].(map[string]interface{})
+			assert.Equal(t, tt.wantReason, condition["reason"])
+			wantStatus := tt.wantStatus
+			if wantStatus == "" {
+				wantStatus = "False"
+			}
+			assert.Equal(t, wantStatus, condition["status"])
+		})
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:        "unknown error",
+			err:         errors.New("boom"),
+			wantReason:  ReconciliationFailedReason,
+			wantMessage: "boom",
+		},
+		{
+			name:        "wrapped reason",
+			err:         requeue.NeededAfter(fmt.Errorf("wrapped: %w", withReason(ExpressionErrorReason, errors.New("boom"))), 0),
+			wantReason:  ExpressionErrorReason,
+			wantMessage: "boom",
+		},
+		{
+			name:        "typed error",
+			err:         requeue.None(&identityFieldChangedError{field: "spec.name", recorded: "a", current: "b"}),
+			wantReason:  IdentityFieldChangedReason,
+			wantMessage: (&identityFieldChangedError{field: "spec.name", recorded: "a", current: "b"}).Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, message := errorReason(tt.err)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
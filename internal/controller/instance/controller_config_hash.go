@@ -0,0 +1,117 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// DefaultConfigHashPath is the default path of the annotations the config hash
+// annotation is injected in: the ones of the pod template of the resources
+// bearing one, e.g Deployments or StatefulSets.
+const DefaultConfigHashPath = "spec.template.metadata.annotations"
+
+// ParseConfigHashPath parses the path of the annotations the config hash
+// annotation is injected in, e.g spec.template.metadata.annotations. The path
+// must lead to the annotations of an object metadata.
+func ParseConfigHashPath(path string) ([]string, error) {
+	segments := strings.Split(path, ".")
+	if len(segments) < 2 || segments[len(segments)-2] != "metadata" || segments[len(segments)-1] != "annotations" {
+		return nil, fmt.Errorf("config hash path %q must lead to the annotations of an object metadata (e.g %s)", path, DefaultConfigHashPath)
+	}
+	if slices.Contains(segments, "") {
+		return nil, fmt.Errorf("config hash path %q has an empty segment", path)
+	}
+	return segments, nil
+}
+
+// injectConfigHash stamps the given resource with the config hash annotation,
+// holding the hash of the content of the resources its template references. A
+// change of their content changes the hash, rolling out the pods of the
+// resource.
+//
+// The annotation is only injected in the resources bearing the object the
+// annotations belong to (e.g spec.template) and referencing other resources.
+func (igr *instanceGraphReconciler) injectConfigHash(resourceID string, resource *unstructured.Unstructured) error {
+	path := igr.reconcileConfig.ConfigHashPath
+	if len(path) == 0 {
+		return nil
+	}
+	// The object holding the metadata, the resource itself for metadata.annotations.
+	if holder := path[:len(path)-2]; len(holder) > 0 {
+		if _, found, _ := unstructured.NestedMap(resource.Object, holder...); !found {
+			return nil
+		}
+	}
+
+	dependencies := slices.Clone(igr.runtime.ResourceDescriptor(resourceID).GetDependencies())
+	if len(dependencies) == 0 {
+		return nil
+	}
+	slices.Sort(dependencies)
+
+	objects := make([]*unstructured.Unstructured, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		object, _ := igr.runtime.GetResource(dependency)
+		objects = append(objects, object)
+	}
+	hash, err := configHash(objects)
+	if err != nil {
+		return fmt.Errorf("failed to compute the config hash of resource %s: %w", resourceID, err)
+	}
+
+	annotations, _, err := unstructured.NestedStringMap(resource.Object, path...)
+	if err != nil {
+		return fmt.Errorf("failed to get the annotations at %s: %w", strings.Join(path, "."), err)
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[metadata.ConfigHashAnnotation] = hash
+	return unstructured.SetNestedStringMap(resource.Object, annotations, path...)
+}
+
+// configHash returns the hash of the content of the given objects. Their
+// metadata and status aren't part of their content: they change without the
+// configuration they hold changing.
+func configHash(objects []*unstructured.Unstructured) (string, error) {
+	contents := make([]map[string]interface{}, 0, len(objects))
+	for _, object := range objects {
+		content := map[string]interface{}{}
+		if object != nil {
+			for field, value := range object.Object {
+				if field != "metadata" && field != "status" {
+					content[field] = value
+				}
+			}
+		}
+		contents = append(contents, content)
+	}
+	// encoding/json sorts map keys, the hash is stable across reconciles.
+	raw, err := json.Marshal(contents)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
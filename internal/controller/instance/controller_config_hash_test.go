@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// configHashRuntime is a fake runtime in which the "app" resource references
+// the "config" resource.
+type configHashRuntime struct {
+	*fakeRuntime
+}
+
+// configHashDescriptor describes a resource referencing the config.
+type configHashDescriptor struct {
+	fakeDescriptor
+}
+
+func (configHashDescriptor) GetDependencies() []string { return []string{"config"} }
+
+func (r *configHashRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	if id == "app" {
+		return configHashDescriptor{fakeDescriptor{gvr: testConfigMapGVR}}
+	}
+	return r.fakeRuntime.ResourceDescriptor(id)
+}
+
+func newConfigHashDeployment() *unstructured.Unstructured {
+	deployment := newTestObject("apps/v1", "Deployment", "app")
+	deployment.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"app": "app"},
+			},
+		},
+	}
+	return deployment
+}
+
+func TestParseConfigHashPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: DefaultConfigHashPath, want: []string{"spec", "template", "metadata", "annotations"}},
+		{path: "metadata.annotations", want: []string{"metadata", "annotations"}},
+		{path: "spec.jobTemplate.spec.template.metadata.annotations", want: []string{"spec", "jobTemplate", "spec", "template", "metadata", "annotations"}},
+		{path: "annotations", wantErr: true},
+		{path: "spec.template.metadata.labels", wantErr: true},
+		{path: "spec..metadata.annotations", wantErr: true},
+		{path: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseConfigHashPath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInjectConfigHash(t *testing.T) {
+	config := newTestObject("v1", "ConfigMap", "config")
+	config.Object["data"] = map[string]interface{}{"level": "info"}
+
+	rt := &configHashRuntime{fakeRuntime: &fakeRuntime{
+		order:     []string{"config", "app"},
+		resources: map[string]*unstructured.Unstructured{"config": config},
+	}}
+	igr := &instanceGraphReconciler{
+		log:     logr.Discard(),
+		runtime: rt,
+		reconcileConfig: ReconcileConfig{
+			ConfigHashPath: []string{"spec", "template", "metadata", "annotations"},
+		},
+	}
+
+	injectedHash := func(t *testing.T) string {
+		deployment := newConfigHashDeployment()
+		require.NoError(t, igr.injectConfigHash("app", deployment))
+		annotations, _, err := unstructured.NestedStringMap(deployment.Object, "spec", "template", "metadata", "annotations")
+		require.NoError(t, err)
+		return annotations[metadata.ConfigHashAnnotation]
+	}
+
+	hash := injectedHash(t)
+	require.NotEmpty(t, hash)
+
+	// The hash is stable while the content of the config is unchanged, even
+	// though its metadata changes.
+	config.SetResourceVersion("2")
+	config.SetLabels(map[string]string{"team": "payments"})
+	assert.Equal(t, hash, injectedHash(t))
+
+	// Changing the content of the config changes the hash.
+	config.Object["data"] = map[string]interface{}{"level": "debug"}
+	changedHash := injectedHash(t)
+	assert.NotEmpty(t, changedHash)
+	assert.NotEqual(t, hash, changedHash)
+
+	t.Run("existing annotations are kept", func(t *testing.T) {
+		deployment := newConfigHashDeployment()
+		require.NoError(t, unstructured.SetNestedStringMap(deployment.Object,
+			map[string]string{"team": "payments"}, "spec", "template", "metadata", "annotations"))
+		require.NoError(t, igr.injectConfigHash("app", deployment))
+		annotations, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "template", "metadata", "annotations")
+		assert.Equal(t, map[string]string{"team": "payments", metadata.ConfigHashAnnotation: changedHash}, annotations)
+	})
+
+	t.Run("resources without a pod template aren't annotated", func(t *testing.T) {
+		service := newTestObject("v1", "Service", "app")
+		require.NoError(t, igr.injectConfigHash("app", service))
+		assert.NotContains(t, service.Object, "spec")
+	})
+
+	t.Run("resources without references aren't annotated", func(t *testing.T) {
+		deployment := newConfigHashDeployment()
+		require.NoError(t, igr.injectConfigHash("config", deployment))
+		assert.Equal(t, newConfigHashDeployment(), deployment)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := &instanceGraphReconciler{log: logr.Discard(), runtime: rt}
+		deployment := newConfigHashDeployment()
+		require.NoError(t, disabled.injectConfigHash("app", deployment))
+		assert.Equal(t, newConfigHashDeployment(), deployment)
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// pendingCreate is the creation of a resource deferred to the end of its
+// dependency level, to be issued along with the creations of the other
+// resources of the level.
+type pendingCreate struct {
+	resourceID string
+	rc         dynamic.ResourceInterface
+	resource   *unstructured.Unstructured
+	state      *ResourceState
+}
+
+// batchingCreates returns true if the creations of the resources of a same
+// dependency level are batched.
+func (igr *instanceGraphReconciler) batchingCreates() bool {
+	return igr.reconcileConfig.CreateBatchConcurrency > 1
+}
+
+// resourceLevels groups the resources by dependency level, in topological
+// order. A resource belongs to the level following the highest level of its
+// dependencies, so the resources of a level don't depend on each other. When
+// the creations aren't batched, each resource is in its own level.
+func (igr *instanceGraphReconciler) resourceLevels() [][]string {
+	order := igr.runtime.TopologicalOrder()
+	if !igr.batchingCreates() {
+		levels := make([][]string, 0, len(order))
+		for _, resourceID := range order {
+			levels = append(levels, []string{resourceID})
+		}
+		return levels
+	}
+
+	resourceLevel := make(map[string]int, len(order))
+	var levels [][]string
+	for _, resourceID := range order {
+		level := 0
+		for _, dependency := range igr.runtime.ResourceDescriptor(resourceID).GetDependencies() {
+			if dependencyLevel, ok := resourceLevel[dependency]; ok && dependencyLevel >= level {
+				level = dependencyLevel + 1
+			}
+		}
+		resourceLevel[resourceID] = level
+		for len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], resourceID)
+	}
+	return levels
+}
+
+// createPendingResources issues the deferred creations, with at most
+// CreateBatchConcurrency creations in flight. It returns the first error
+// preventing the reconciliation from going on, preferring the failures over the
+// requeues awaiting the creation completion, and nil if no creation was
+// pending.
+func (igr *instanceGraphReconciler) createPendingResources(ctx context.Context) error {
+	pending := igr.pendingCreates
+	igr.pendingCreates = nil
+	if len(pending) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(pending))
+	slots := make(chan struct{}, igr.reconcileConfig.CreateBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, create := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			_, errs[i] = igr.createResource(ctx, create.rc, create.resource, create.resourceID)
+		}()
+	}
+	wg.Wait()
+
+	// The results are handled sequentially, in topological order, as they
+	// update the state shared by the resources.
+	var result error
+	for i, create := range pending {
+		err := igr.handleResourceCreated(create.resourceID, errs[i], create.state)
+		if err == nil || (result != nil && !isRequeue(result)) {
+			continue
+		}
+		if result == nil || !isRequeue(err) {
+			result = err
+			igr.state.FailedResourceID = create.resourceID
+		}
+	}
+	return result
+}
+
+// isRequeue returns true if the error only requeues the instance.
+func isRequeue(err error) bool {
+	var requeueErr *requeue.RequeueNeededAfter
+	return errors.As(err, &requeueErr)
+}
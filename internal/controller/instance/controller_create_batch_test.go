@@ -0,0 +1,168 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// dependenciesRuntime is a fake runtime whose resources have the given
+// dependencies.
+type dependenciesRuntime struct {
+	*fakeRuntime
+	dependencies map[string][]string
+}
+
+// dependenciesDescriptor describes a resource with dependencies.
+type dependenciesDescriptor struct {
+	fakeDescriptor
+	dependencies []string
+}
+
+func (d dependenciesDescriptor) GetDependencies() []string { return d.dependencies }
+
+func (r *dependenciesRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	return dependenciesDescriptor{fakeDescriptor{gvr: testConfigMapGVR}, r.dependencies[id]}
+}
+
+// creationTracker records the creations issued through a dynamic client, and
+// how many of them were in flight at once.
+type creationTracker struct {
+	dynamic.Interface
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	created     []string
+}
+
+func (t *creationTracker) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return trackedResource{t.Interface.Resource(gvr), t}
+}
+
+type trackedResource struct {
+	dynamic.NamespaceableResourceInterface
+	tracker *creationTracker
+}
+
+func (r trackedResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return trackedNamespacedResource{r.NamespaceableResourceInterface.Namespace(namespace), r.tracker}
+}
+
+type trackedNamespacedResource struct {
+	dynamic.ResourceInterface
+	tracker *creationTracker
+}
+
+func (r trackedNamespacedResource) Create(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	options metav1.CreateOptions,
+	subresources ...string,
+) (*unstructured.Unstructured, error) {
+	t := r.tracker
+	t.mu.Lock()
+	t.inFlight++
+	t.maxInFlight = max(t.maxInFlight, t.inFlight)
+	t.created = append(t.created, obj.GetName())
+	t.mu.Unlock()
+
+	// Leave the time for the other creations of the batch to start.
+	time.Sleep(20 * time.Millisecond)
+
+	t.mu.Lock()
+	t.inFlight--
+	t.mu.Unlock()
+	return r.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func TestReconcileCreateBatch(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	resources := map[string]*unstructured.Unstructured{}
+	for _, id := range []string{"config", "secret", "account", "volume", "app"} {
+		resources[id] = newTestObject("v1", "ConfigMap", id)
+	}
+	rt := &dependenciesRuntime{
+		fakeRuntime: &fakeRuntime{
+			instance:  instance,
+			order:     []string{"config", "secret", "account", "volume", "app"},
+			resources: resources,
+		},
+		// The app depends on the other resources, which are independent.
+		dependencies: map[string][]string{"app": {"config", "secret", "account", "volume"}},
+	}
+	tracker := &creationTracker{Interface: fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+	)}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      tracker,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		reconcileConfig:             ReconcileConfig{CreateBatchConcurrency: 2},
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	assert.Equal(t, [][]string{{"config", "secret", "account", "volume"}, {"app"}}, igr.resourceLevels())
+
+	// The independent resources are created in a single reconciliation, two
+	// at a time, and the app only once they exist.
+	err := igr.reconcile(context.Background())
+	var requeueErr *requeue.RequeueNeededAfter
+	require.ErrorAs(t, err, &requeueErr, "awaiting the creation completion")
+	assert.ElementsMatch(t, []string{"config", "secret", "account", "volume"}, tracker.created)
+	assert.Equal(t, 2, tracker.maxInFlight)
+	for _, id := range []string{"config", "secret", "account", "volume"} {
+		assert.Equal(t, "CREATED", igr.state.ResourceStates[id].State, id)
+	}
+	assert.Equal(t, "PENDING", igr.state.ResourceStates["app"].State)
+
+	tracker.created = nil
+	err = igr.reconcile(context.Background())
+	require.ErrorAs(t, err, &requeueErr, "awaiting the creation completion")
+	assert.Equal(t, []string{"app"}, tracker.created)
+	assert.Equal(t, "CREATED", igr.state.ResourceStates["app"].State)
+}
+
+func TestResourceLevelsWithoutBatching(t *testing.T) {
+	rt := &dependenciesRuntime{
+		fakeRuntime:  &fakeRuntime{order: []string{"config", "secret", "app"}},
+		dependencies: map[string][]string{"app": {"config"}},
+	}
+	igr := &instanceGraphReconciler{runtime: rt}
+	assert.Equal(t, [][]string{{"config"}, {"secret"}, {"app"}}, igr.resourceLevels())
+}
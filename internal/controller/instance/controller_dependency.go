@@ -0,0 +1,121 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DependencyNotFoundPolicy defines how the instance controller handles a
+// resource found deleted, e.g. out-of-band by a user, while its live state is
+// read to compute the status of the instance.
+type DependencyNotFoundPolicy string
+
+const (
+	// DependencyNotFoundPolicyRecreate recreates the deleted resource. kro
+	// manages the resource, so this is the default.
+	DependencyNotFoundPolicyRecreate DependencyNotFoundPolicy = "Recreate"
+	// DependencyNotFoundPolicyWait reports the instance as waiting for the
+	// deleted resource, and requeues it.
+	DependencyNotFoundPolicyWait DependencyNotFoundPolicy = "Wait"
+)
+
+// ParseDependencyNotFoundPolicy parses a DependencyNotFoundPolicy, case
+// insensitively. An empty string is parsed as the default policy.
+func ParseDependencyNotFoundPolicy(s string) (DependencyNotFoundPolicy, error) {
+	switch {
+	case s == "", strings.EqualFold(s, string(DependencyNotFoundPolicyRecreate)):
+		return DependencyNotFoundPolicyRecreate, nil
+	case strings.EqualFold(s, string(DependencyNotFoundPolicyWait)):
+		return DependencyNotFoundPolicyWait, nil
+	default:
+		return "", fmt.Errorf("unknown dependency not found policy %q, must be one of %s, %s",
+			s, DependencyNotFoundPolicyRecreate, DependencyNotFoundPolicyWait)
+	}
+}
+
+// DependencyNotFoundReason is the reason of the InstanceSynced condition
+// while the instance waits for one of its resources that was deleted.
+const DependencyNotFoundReason = "WaitingForDependency"
+
+// dependencyNotFoundError is returned while waiting for a resource that was
+// deleted, when the DependencyNotFoundPolicyWait policy is used.
+type dependencyNotFoundError struct {
+	resourceID string
+	err        error
+}
+
+func (e *dependencyNotFoundError) Error() string {
+	return fmt.Sprintf("waiting for deleted resource %s: %v", e.resourceID, e.err)
+}
+
+func (e *dependencyNotFoundError) Reason() string {
+	return DependencyNotFoundReason
+}
+
+func (e *dependencyNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// handleDependencyNotFound handles a resource found deleted while reading its
+// live state, according to the configured DependencyNotFoundPolicy.
+func (igr *instanceGraphReconciler) handleDependencyNotFound(ctx context.Context, resourceID string, err error) error {
+	resourceState := igr.state.ResourceStates[resourceID]
+
+	if igr.reconcileConfig.DependencyNotFoundPolicy == DependencyNotFoundPolicyWait {
+		igr.log.V(1).Info("Waiting for deleted resource", "resourceID", resourceID)
+		resourceState.State = "WAITING_FOR_DEPENDENCY"
+		resourceState.Err = &dependencyNotFoundError{resourceID: resourceID, err: err}
+		return igr.delayedRequeue(resourceState.Err)
+	}
+
+	igr.log.Info("Recreating deleted resource", "resourceID", resourceID)
+	observed, _ := igr.runtime.GetResource(resourceID)
+	return igr.handleResourceCreation(ctx, igr.getResourceClient(resourceID), recreatableObject(observed), resourceID, resourceState)
+}
+
+// serverSetMetadataFields are the metadata fields set by the API server, that
+// must be removed from an observed object to create it again.
+var serverSetMetadataFields = []string{
+	"creationTimestamp",
+	"deletionGracePeriodSeconds",
+	"deletionTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// recreatableObject returns a copy of the last observed state of a resource
+// that can be used to create it again.
+func recreatableObject(observed *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := observed.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range serverSetMetadataFields {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	return obj
+}
+
+// isDependencyNotFound returns true if the error indicates that the resource
+// itself doesn't exist, as opposed to its type not being served.
+func isDependencyNotFound(err error) bool {
+	return apierrors.IsNotFound(err) && !isResourceTypeNotServed(err)
+}
@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+func TestParseDependencyNotFoundPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    DependencyNotFoundPolicy
+		wantErr bool
+	}{
+		{input: "", want: DependencyNotFoundPolicyRecreate},
+		{input: "Recreate", want: DependencyNotFoundPolicyRecreate},
+		{input: "wait", want: DependencyNotFoundPolicyWait},
+		{input: "Ignore", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDependencyNotFoundPolicy(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileDeletedDependency(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        DependencyNotFoundPolicy
+		wantRecreated bool
+		wantReason    string
+	}{
+		{
+			name:          "default policy recreates the dependency",
+			wantRecreated: true,
+			wantReason:    DependencyNotReadyReason,
+		},
+		{
+			name:       "wait policy waits for the dependency",
+			policy:     DependencyNotFoundPolicyWait,
+			wantReason: DependencyNotFoundReason,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			configMap := newTestObject("v1", "ConfigMap", "app-config")
+			configMap.Object["data"] = map[string]interface{}{"phase": "Applied"}
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+			)
+			// The config map is observed up to date once while it is applied,
+			// and is deleted before it is read again to compute the instance
+			// status.
+			applied := configMap.DeepCopy()
+			metadata.GenericLabeler{}.ApplyLabels(applied)
+			require.NoError(t, setSpecHash(applied))
+			gets := 0
+			client.PrependReactor("get", "configmaps", func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+				gets++
+				if gets > 1 {
+					return false, nil, nil
+				}
+				observed := applied.DeepCopy()
+				observed.SetResourceVersion("42")
+				observed.SetUID("config-uid")
+				observed.Object["status"] = map[string]interface{}{"observed": true}
+				return true, observed, nil
+			})
+
+			rt := &statusRuntime{fakeRuntime: &fakeRuntime{
+				instance:  instance,
+				order:     []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{"configmap": configMap},
+			}}
+			igr := &instanceGraphReconciler{
+				log:                         logr.Discard(),
+				gvr:                         testInstanceGVR,
+				client:                      client,
+				runtime:                     rt,
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				reconcileConfig:             ReconcileConfig{DependencyNotFoundPolicy: tt.policy},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+			}
+			err := igr.reconcile(context.Background())
+			var requeueErr *requeue.RequeueNeededAfter
+			require.True(t, errors.As(err, &requeueErr), "expected a requeue, got %v", err)
+			assert.Equal(t, 0, rt.synchronized)
+
+			recreated, err := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+			if tt.wantRecreated {
+				require.NoError(t, err)
+				assert.Equal(t, "CREATED", igr.state.ResourceStates["configmap"].State)
+				assert.Equal(t, configMap.Object["data"], recreated.Object["data"])
+				assert.NotContains(t, recreated.Object, "status")
+				assert.NotEqual(t, "config-uid", string(recreated.GetUID()))
+			} else {
+				assert.True(t, apierrors.IsNotFound(err), "expected the config map not to be recreated, got %v", err)
+				assert.Equal(t, "WAITING_FOR_DEPENDENCY", igr.state.ResourceStates["configmap"].State)
+			}
+
+			observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+			require.NoError(t, err)
+			conditions, _, _ := unstructured.NestedSlice(observed.Object, "status", "conditions")
+			require.Len(t, conditions, 1)
+			assert.Equal(t, tt.wantReason, conditions[0].(map[string]interface{})["reason"])
+		})
+	}
+}
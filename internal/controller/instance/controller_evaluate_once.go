@@ -0,0 +1,53 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"maps"
+
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// prepareEvaluatedOnce stores, in the status.evaluatedOnce field of the given
+// status, the values of the evaluateOnce fields of the resources rendered
+// during the reconciliation. The values already stored were reused by the
+// runtime, the ones of the fields cleared from the status were evaluated
+// again. The values of the resources not rendered are kept.
+func (igr *instanceGraphReconciler) prepareEvaluatedOnce(status map[string]interface{}) {
+	// The existing status is shared with the instance, it is copied before
+	// being changed.
+	existing, _ := status[runtime.EvaluatedOnceField].(map[string]interface{})
+	evaluatedOnce := maps.Clone(existing)
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		paths := igr.runtime.ResourceDescriptor(resourceID).GetEvaluateOnce()
+		if len(paths) == 0 {
+			continue
+		}
+		resource, state := igr.runtime.GetResource(resourceID)
+		if state != runtime.ResourceStateResolved || resource == nil {
+			continue
+		}
+		values := runtime.EvaluatedOnceValues(resource, paths)
+		if len(values) == 0 {
+			continue
+		}
+		if evaluatedOnce == nil {
+			evaluatedOnce = make(map[string]interface{})
+		}
+		evaluatedOnce[resourceID] = values
+	}
+	if evaluatedOnce != nil {
+		status[runtime.EvaluatedOnceField] = evaluatedOnce
+	}
+}
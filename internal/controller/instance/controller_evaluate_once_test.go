@@ -0,0 +1,93 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// evaluateOnceDescriptor describes a resource with evaluateOnce fields.
+type evaluateOnceDescriptor struct {
+	fakeDescriptor
+	evaluateOnce []string
+}
+
+func (d evaluateOnceDescriptor) GetEvaluateOnce() []string { return d.evaluateOnce }
+
+// evaluateOnceRuntime is a fake runtime in which the "credentials" resource
+// has an evaluateOnce password, and the "token" resource isn't rendered.
+type evaluateOnceRuntime struct {
+	*fakeRuntime
+}
+
+func (r *evaluateOnceRuntime) ResourceDescriptor(string) runtime.ResourceDescriptor {
+	return evaluateOnceDescriptor{
+		fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR},
+		evaluateOnce:   []string{"data.password"},
+	}
+}
+
+func (r *evaluateOnceRuntime) GetResource(id string) (*unstructured.Unstructured, runtime.ResourceState) {
+	if id == "token" {
+		return nil, runtime.ResourceStateWaitingOnDependencies
+	}
+	return r.fakeRuntime.GetResource(id)
+}
+
+func TestPrepareEvaluatedOnce(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	storedToken := map[string]interface{}{"data.password": "t0ken"}
+	instance.Object["status"] = map[string]interface{}{
+		runtime.EvaluatedOnceField: map[string]interface{}{"token": storedToken},
+	}
+	credentials := newTestObject("v1", "ConfigMap", "credentials")
+	credentials.Object["data"] = map[string]interface{}{"user": "admin", "password": "s3cret"}
+
+	igr := &instanceGraphReconciler{
+		runtime: &evaluateOnceRuntime{fakeRuntime: &fakeRuntime{
+			instance:  instance,
+			order:     []string{"credentials", "token"},
+			resources: map[string]*unstructured.Unstructured{"credentials": credentials},
+		}},
+		state: newInstanceState(),
+	}
+	status := igr.prepareStatus()
+
+	// The value of the rendered resource is stored, the one of the resource
+	// not rendered is kept.
+	assert.Equal(t, map[string]interface{}{
+		"credentials": map[string]interface{}{"data.password": "s3cret"},
+		"token":       storedToken,
+	}, status[runtime.EvaluatedOnceField])
+	// The status of the instance isn't changed in place.
+	assert.Equal(t, map[string]interface{}{"token": storedToken},
+		instance.Object["status"].(map[string]interface{})[runtime.EvaluatedOnceField])
+}
+
+func TestPrepareEvaluatedOnceWithoutFields(t *testing.T) {
+	igr := &instanceGraphReconciler{
+		runtime: &fakeRuntime{
+			instance:  newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+			order:     []string{"configmap"},
+			resources: map[string]*unstructured.Unstructured{"configmap": newTestObject("v1", "ConfigMap", "app-config")},
+		},
+		state: newInstanceState(),
+	}
+	assert.NotContains(t, igr.prepareStatus(), runtime.EvaluatedOnceField)
+}
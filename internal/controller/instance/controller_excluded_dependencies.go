@@ -0,0 +1,95 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// ExcludedDependencyPolicy defines how the instance controller handles a
+// resource without includeWhen expressions depending on a resource excluded
+// by its includeWhen expressions.
+type ExcludedDependencyPolicy string
+
+const (
+	// ExcludedDependencyPolicySkip excludes the dependents of an excluded
+	// resource along with it. This is the default.
+	ExcludedDependencyPolicySkip ExcludedDependencyPolicy = "Skip"
+	// ExcludedDependencyPolicyBlock reports the instance in error instead,
+	// and doesn't reconcile the dependent resource until the instance
+	// changes. The resources conditionally included themselves are still
+	// excluded along with their dependencies.
+	ExcludedDependencyPolicyBlock ExcludedDependencyPolicy = "Block"
+)
+
+// ParseExcludedDependencyPolicy parses an ExcludedDependencyPolicy, case
+// insensitively. An empty string is parsed as the default policy.
+func ParseExcludedDependencyPolicy(s string) (ExcludedDependencyPolicy, error) {
+	switch {
+	case s == "", strings.EqualFold(s, string(ExcludedDependencyPolicySkip)):
+		return ExcludedDependencyPolicySkip, nil
+	case strings.EqualFold(s, string(ExcludedDependencyPolicyBlock)):
+		return ExcludedDependencyPolicyBlock, nil
+	default:
+		return "", fmt.Errorf("unknown excluded dependency policy %q, must be one of %s, %s",
+			s, ExcludedDependencyPolicySkip, ExcludedDependencyPolicyBlock)
+	}
+}
+
+// ExcludedDependencyReason is the reason of the InstanceSynced condition when
+// a resource is blocked by an excluded dependency.
+const ExcludedDependencyReason = "ExcludedDependency"
+
+// excludedDependencyError is returned when a resource without includeWhen
+// expressions depends on a resource excluded by its includeWhen expressions.
+type excludedDependencyError struct {
+	resourceID string
+	dependency string
+}
+
+func (e *excludedDependencyError) Error() string {
+	return fmt.Sprintf("resource %s depends on resource %s, which is excluded by its includeWhen expressions: "+
+		"add includeWhen expressions to resource %s, or change the instance to include resource %s",
+		e.resourceID, e.dependency, e.resourceID, e.dependency)
+}
+
+func (e *excludedDependencyError) Reason() string {
+	return ExcludedDependencyReason
+}
+
+// checkExcludedDependencies returns a terminal error if the configured
+// ExcludedDependencyPolicy blocks the given resource, i.e. if it isn't
+// conditionally included but one of its dependencies was excluded.
+func (igr *instanceGraphReconciler) checkExcludedDependencies(resourceID string, resourceState *ResourceState) error {
+	if igr.reconcileConfig.ExcludedDependencyPolicy != ExcludedDependencyPolicyBlock {
+		return nil
+	}
+	descriptor := igr.runtime.ResourceDescriptor(resourceID)
+	if len(descriptor.GetIncludeWhenExpressions()) > 0 {
+		return nil
+	}
+	for _, dependency := range descriptor.GetDependencies() {
+		if state, ok := igr.state.ResourceStates[dependency]; !ok || state.State != "SKIPPED" {
+			continue
+		}
+		resourceState.State = "ERROR"
+		resourceState.Err = &excludedDependencyError{resourceID: resourceID, dependency: dependency}
+		igr.state.State = InstanceStateError
+		return requeue.None(resourceState.Err)
+	}
+	return nil
+}
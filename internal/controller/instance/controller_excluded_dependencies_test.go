@@ -0,0 +1,183 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// includeWhenDescriptor describes a resource with dependencies and includeWhen
+// expressions.
+type includeWhenDescriptor struct {
+	fakeDescriptor
+	dependencies []string
+	includeWhen  []string
+}
+
+func (d includeWhenDescriptor) GetDependencies() []string           { return d.dependencies }
+func (d includeWhenDescriptor) GetIncludeWhenExpressions() []string { return d.includeWhen }
+
+// excludingRuntime is a fake runtime in which the "vpc" resource is excluded
+// by its includeWhen expressions, along with the resources depending on it,
+// as in the actual runtime.
+type excludingRuntime struct {
+	*fakeRuntime
+	descriptors map[string]includeWhenDescriptor
+	ignored     map[string]bool
+}
+
+func (r *excludingRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	return r.descriptors[id]
+}
+
+func (r *excludingRuntime) WantToCreateResource(id string) (bool, error) {
+	for _, dependency := range r.descriptors[id].dependencies {
+		if r.ignored[dependency] {
+			return false, nil
+		}
+	}
+	if id == "vpc" {
+		return false, errors.New("Skipping resource creation due to condition schema.spec.createVPC")
+	}
+	return true, nil
+}
+
+func (r *excludingRuntime) IgnoreResource(id string) { r.ignored[id] = true }
+
+func TestParseExcludedDependencyPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ExcludedDependencyPolicy
+		wantErr bool
+	}{
+		{in: "", want: ExcludedDependencyPolicySkip},
+		{in: "Skip", want: ExcludedDependencyPolicySkip},
+		{in: "block", want: ExcludedDependencyPolicyBlock},
+		{in: "Fail", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseExcludedDependencyPolicy(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileExcludedDependency(t *testing.T) {
+	condition := []string{"schema.spec.createVPC"}
+	tests := []struct {
+		name   string
+		policy ExcludedDependencyPolicy
+		// subnetIncludeWhen are the includeWhen expressions of the subnet,
+		// which depends on the vpc.
+		subnetIncludeWhen []string
+		wantErr           bool
+	}{
+		{name: "skip", policy: ExcludedDependencyPolicySkip},
+		{name: "block", policy: ExcludedDependencyPolicyBlock, wantErr: true},
+		{name: "block conditioned dependent", policy: ExcludedDependencyPolicyBlock, subnetIncludeWhen: condition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			instance.SetUID("instance-uid")
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+			)
+
+			rt := &excludingRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance: instance.DeepCopy(),
+					order:    []string{"vpc", "subnet", "configmap"},
+					resources: map[string]*unstructured.Unstructured{
+						"vpc":       newTestObject("v1", "ConfigMap", "vpc"),
+						"subnet":    newTestObject("v1", "ConfigMap", "subnet"),
+						"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+					},
+				},
+				descriptors: map[string]includeWhenDescriptor{
+					"vpc":       {fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR}, includeWhen: condition},
+					"subnet":    {fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR}, dependencies: []string{"vpc"}, includeWhen: tt.subnetIncludeWhen},
+					"configmap": {fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR}},
+				},
+				ignored: map[string]bool{},
+			}
+			igr := &instanceGraphReconciler{
+				log:                         logr.Discard(),
+				gvr:                         testInstanceGVR,
+				client:                      client,
+				runtime:                     rt,
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				reconcileConfig:             ReconcileConfig{ExcludedDependencyPolicy: tt.policy},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+				resourceTypeWaits:           newResourceTypeWaits(),
+			}
+			err := igr.reconcile(context.Background())
+			assert.Equal(t, "SKIPPED", igr.state.ResourceStates["vpc"].State)
+
+			got, getErr := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+			require.NoError(t, getErr)
+			conditions, _, _ := unstructured.NestedSlice(got.Object, "status", "conditions")
+			require.Len(t, conditions, 1)
+			condition := conditions[0].(map[string]interface{})
+
+			if !tt.wantErr {
+				// The subnet is excluded along with the vpc, the other
+				// resources are created.
+				assert.NotEqual(t, ExcludedDependencyReason, condition["reason"], "unexpected error: %v", err)
+				assert.Equal(t, "SKIPPED", igr.state.ResourceStates["subnet"].State)
+				_, getErr := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+				assert.NoError(t, getErr)
+				return
+			}
+
+			var noRequeueErr *requeue.NoRequeue
+			require.True(t, errors.As(err, &noRequeueErr), "unexpected error: %v", err)
+			assert.Equal(t, InstanceStateError, igr.state.State)
+			assert.Equal(t, "ERROR", igr.state.ResourceStates["subnet"].State)
+			assert.Equal(t, "False", condition["status"])
+			assert.Equal(t, ExcludedDependencyReason, condition["reason"])
+			assert.Contains(t, condition["message"], "resource subnet depends on resource vpc, which is excluded")
+		})
+	}
+}
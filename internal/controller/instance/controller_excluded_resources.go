@@ -0,0 +1,220 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// PendingDeletionReason is the reason of the InstanceSynced condition while
+// excluded resources wait for their grace period to elapse before being
+// deleted.
+const PendingDeletionReason = "PendingDeletion"
+
+// pendingDeletionsField is the instance status field listing the pending
+// deletions of the excluded resources.
+const pendingDeletionsField = "pendingDeletions"
+
+// pendingDeletion is the deletion of a resource excluded by its includeWhen
+// expressions, deferred until its grace period elapses.
+type pendingDeletion struct {
+	// name and namespace identify the resource as it was created, the
+	// excluded resource may not be rendered anymore.
+	name      string
+	namespace string
+	// deleteAfter is the time the grace period of the resource elapses.
+	deleteAfter time.Time
+}
+
+// reconcileExcludedResource deletes the given excluded resource, if it was
+// created by the instance, once the ExcludedResourceGracePeriod elapses. The
+// deletion is tracked through the status.pendingDeletions field of the
+// instance until then, and is cancelled if the resource is included again.
+//
+// A resource whose name can't be rendered anymore, e.g. because its
+// dependencies were excluded with it, is only deleted if its deletion was
+// already pending.
+func (igr *instanceGraphReconciler) reconcileExcludedResource(ctx context.Context, resourceID string) error {
+	gracePeriod := igr.reconcileConfig.ExcludedResourceGracePeriod
+	if gracePeriod <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	pending, ok := igr.previousPendingDeletion(resourceID)
+	if !ok {
+		resource, state := igr.runtime.GetResource(resourceID)
+		if state != runtime.ResourceStateResolved || resource == nil {
+			return nil
+		}
+		rc := igr.getResourceClient(resourceID)
+		observed, err := igr.getExcludedResource(ctx, rc, resourceID, resource.GetName())
+		if err != nil || observed == nil {
+			return err
+		}
+		pending = pendingDeletion{
+			name:        observed.GetName(),
+			namespace:   observed.GetNamespace(),
+			deleteAfter: now.Add(gracePeriod),
+		}
+	}
+
+	if now.Before(pending.deleteAfter) {
+		igr.state.PendingDeletions[resourceID] = pending
+		return nil
+	}
+
+	igr.log.V(1).Info("Deleting excluded resource", "resourceID", resourceID, "name", pending.name)
+	rc := igr.getExcludedResourceClient(resourceID, pending.namespace)
+	observed, err := igr.getExcludedResource(ctx, rc, resourceID, pending.name)
+	if err != nil || observed == nil {
+		return err
+	}
+	// Release the finalizers kro manages on the resource, as on the deletion
+	// of the instance.
+	finalizers := igr.runtime.ResourceDescriptor(resourceID).GetFinalizers()
+	if err := removeFinalizers(ctx, rc, observed, finalizers); err != nil && !apierrors.IsNotFound(err) {
+		igr.state.PendingDeletions[resourceID] = pending
+		return withReason(SubResourceDeleteFailedReason, err)
+	}
+	if err := rc.Delete(ctx, pending.name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		igr.state.PendingDeletions[resourceID] = pending
+		return withReason(SubResourceDeleteFailedReason, fmt.Errorf("failed to delete excluded resource %s: %w", resourceID, err))
+	}
+	return nil
+}
+
+// getExcludedResource returns the given excluded resource, or nil if it
+// doesn't exist or wasn't created by the instance.
+func (igr *instanceGraphReconciler) getExcludedResource(
+	ctx context.Context,
+	rc dynamic.ResourceInterface,
+	resourceID, name string,
+) (*unstructured.Unstructured, error) {
+	observed, err := rc.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, withReason(SubResourceDeleteFailedReason, fmt.Errorf("failed to get excluded resource %s: %w", resourceID, err))
+	}
+	// An object of the same name not created by the instance is left alone.
+	instanceUID := string(igr.runtime.GetInstance().GetUID())
+	if observed.GetLabels()[metadata.InstanceIDLabel] != instanceUID {
+		return nil, nil
+	}
+	return observed, nil
+}
+
+// getExcludedResourceClient returns the client of the given excluded
+// resource, in the namespace it was created in.
+func (igr *instanceGraphReconciler) getExcludedResourceClient(resourceID, namespace string) dynamic.ResourceInterface {
+	descriptor := igr.runtime.ResourceDescriptor(resourceID)
+	gvr := descriptor.GetGroupVersionResource()
+	if descriptor.IsNamespaced() {
+		return igr.client.Resource(gvr).Namespace(namespace)
+	}
+	return igr.client.Resource(gvr)
+}
+
+// checkPendingDeletions returns an error, requeuing the instance when the
+// earliest grace period elapses, if excluded resources are waiting for their
+// deletion.
+func (igr *instanceGraphReconciler) checkPendingDeletions() error {
+	if len(igr.state.PendingDeletions) == 0 {
+		return nil
+	}
+	var ids []string
+	var next time.Time
+	for resourceID, pending := range igr.state.PendingDeletions {
+		ids = append(ids, resourceID)
+		if next.IsZero() || pending.deleteAfter.Before(next) {
+			next = pending.deleteAfter
+		}
+	}
+	sort.Strings(ids)
+	err := withReason(PendingDeletionReason, fmt.Errorf(
+		"excluded resources %s pending deletion, the next one after %s",
+		strings.Join(ids, ", "), next.Format(time.RFC3339)))
+	return requeue.NeededAfter(err, max(time.Until(next), time.Second))
+}
+
+// preparePendingDeletions sets, in the status.pendingDeletions field of the
+// given status, the pending deletions of the excluded resources, in
+// topological order. The pending deletions of the resources not reconciled,
+// e.g. because the reconciliation failed before reaching them, are kept.
+func (igr *instanceGraphReconciler) preparePendingDeletions(status map[string]interface{}) {
+	var entries []interface{}
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		pending, ok := igr.state.PendingDeletions[resourceID]
+		if resourceState := igr.state.ResourceStates[resourceID]; resourceState == nil || resourceState.State == "PENDING" {
+			pending, ok = igr.previousPendingDeletion(resourceID)
+		}
+		if !ok {
+			continue
+		}
+		entry := map[string]interface{}{
+			"id":          resourceID,
+			"name":        pending.name,
+			"deleteAfter": pending.deleteAfter.Format(time.RFC3339),
+		}
+		if pending.namespace != "" {
+			entry["namespace"] = pending.namespace
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		delete(status, pendingDeletionsField)
+		return
+	}
+	status[pendingDeletionsField] = entries
+}
+
+// previousPendingDeletion returns the pending deletion of the given resource
+// in the status.pendingDeletions field of the instance.
+func (igr *instanceGraphReconciler) previousPendingDeletion(resourceID string) (pendingDeletion, bool) {
+	status, ok := igr.runtime.GetInstance().Object["status"].(map[string]interface{})
+	if !ok {
+		return pendingDeletion{}, false
+	}
+	entries, _ := status[pendingDeletionsField].([]interface{})
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok || entry["id"] != resourceID {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		namespace, _ := entry["namespace"].(string)
+		deleteAfter, _ := entry["deleteAfter"].(string)
+		t, err := time.Parse(time.RFC3339, deleteAfter)
+		if name == "" || err != nil {
+			return pendingDeletion{}, false
+		}
+		return pendingDeletion{name: name, namespace: namespace, deleteAfter: t}, true
+	}
+	return pendingDeletion{}, false
+}
@@ -0,0 +1,169 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// conditionalRuntime is a fake runtime in which the resources are excluded
+// by their includeWhen expressions when marked as such.
+type conditionalRuntime struct {
+	*fakeRuntime
+	excluded map[string]bool
+}
+
+func (r *conditionalRuntime) WantToCreateResource(id string) (bool, error) {
+	return !r.excluded[id], nil
+}
+
+func TestReconcileExcludedResource(t *testing.T) {
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		// excluded is true if the configmap is excluded by its includeWhen
+		// expressions.
+		excluded bool
+		// pending is the deleteAfter offset, from now, of the pending deletion
+		// of the configmap in the instance status. There is none when nil.
+		pending *time.Duration
+		// unowned is true if the configmap wasn't created by the instance.
+		unowned     bool
+		wantDeleted bool
+		wantPending bool
+	}{
+		{name: "grace period disabled", excluded: true},
+		{name: "deletion deferred", gracePeriod: time.Hour, excluded: true, wantPending: true},
+		{name: "deletion still pending", gracePeriod: time.Hour, excluded: true, pending: durationPtr(time.Minute), wantPending: true},
+		{name: "deletion after grace period", gracePeriod: time.Hour, excluded: true, pending: durationPtr(-time.Minute), wantDeleted: true},
+		{name: "deletion cancelled when included again", gracePeriod: time.Hour, pending: durationPtr(time.Minute)},
+		{name: "resource not created by the instance", gracePeriod: time.Hour, excluded: true, unowned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			instance.SetUID("instance-uid")
+			if tt.pending != nil {
+				instance.Object["status"] = map[string]interface{}{
+					"pendingDeletions": []interface{}{
+						map[string]interface{}{
+							"id":          "configmap",
+							"name":        "app-config",
+							"namespace":   "default",
+							"deleteAfter": time.Now().Add(*tt.pending).Format(time.RFC3339),
+						},
+					},
+				}
+			}
+			configMap := newTestObject("v1", "ConfigMap", "app-config")
+			if !tt.unowned {
+				configMap.SetLabels(map[string]string{metadata.InstanceIDLabel: "instance-uid"})
+			}
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+				configMap,
+			)
+			rt := &conditionalRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance: instance.DeepCopy(),
+					order:    []string{"configmap"},
+					resources: map[string]*unstructured.Unstructured{
+						"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+					},
+				},
+				excluded: map[string]bool{"configmap": tt.excluded},
+			}
+			igr := &instanceGraphReconciler{
+				log:                         logr.Discard(),
+				gvr:                         testInstanceGVR,
+				client:                      client,
+				runtime:                     rt,
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				reconcileConfig:             ReconcileConfig{ExcludedResourceGracePeriod: tt.gracePeriod},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+				resourceTypeWaits:           newResourceTypeWaits(),
+			}
+			start := time.Now()
+			err := igr.reconcile(context.Background())
+
+			_, getErr := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+			if tt.wantDeleted {
+				assert.True(t, apierrors.IsNotFound(getErr), "unexpected error: %v", getErr)
+			} else {
+				assert.NoError(t, getErr)
+			}
+
+			got, getErr := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+			require.NoError(t, getErr)
+			pendingDeletions, _, _ := unstructured.NestedSlice(got.Object, "status", "pendingDeletions")
+			if !tt.wantPending {
+				assert.Empty(t, pendingDeletions)
+				var requeueErr *requeue.RequeueNeededAfter
+				if errors.As(err, &requeueErr) {
+					assert.NotContains(t, requeueErr.Error(), "pending deletion")
+				}
+				return
+			}
+
+			require.Len(t, pendingDeletions, 1)
+			entry := pendingDeletions[0].(map[string]interface{})
+			assert.Equal(t, "configmap", entry["id"])
+			assert.Equal(t, "app-config", entry["name"])
+			assert.Equal(t, "default", entry["namespace"])
+			deleteAfter, parseErr := time.Parse(time.RFC3339, entry["deleteAfter"].(string))
+			require.NoError(t, parseErr)
+			if tt.pending == nil {
+				// The grace period starts with the first reconciliation
+				// excluding the resource.
+				assert.WithinDuration(t, start.Add(tt.gracePeriod), deleteAfter, 2*time.Second)
+			}
+
+			var requeueErr *requeue.RequeueNeededAfter
+			require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+			assert.LessOrEqual(t, requeueErr.Duration(), tt.gracePeriod)
+			conditions, _, _ := unstructured.NestedSlice(got.Object, "status", "conditions")
+			require.Len(t, conditions, 1)
+			assert.Equal(t, PendingDeletionReason, conditions[0].(map[string]interface{})["reason"])
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
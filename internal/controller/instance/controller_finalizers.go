@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// addFinalizers adds the given finalizers to the resource, if missing.
+func addFinalizers(resource *unstructured.Unstructured, finalizers []string) {
+	if len(finalizers) == 0 {
+		return
+	}
+	current := resource.GetFinalizers()
+	for _, finalizer := range finalizers {
+		if !slices.Contains(current, finalizer) {
+			current = append(current, finalizer)
+		}
+	}
+	resource.SetFinalizers(current)
+}
+
+// preserveFinalizers adds the finalizers of the observed resource, e.g set by
+// other controllers, to the desired resource setting finalizers. Merge patches
+// replace lists as a whole, the re-apply would strip them otherwise.
+func preserveFinalizers(resource, observed *unstructured.Unstructured) {
+	if _, ok, _ := unstructured.NestedFieldNoCopy(resource.Object, "metadata", "finalizers"); !ok {
+		return
+	}
+	desired := resource.GetFinalizers()
+	resource.SetFinalizers(observed.GetFinalizers())
+	addFinalizers(resource, desired)
+}
+
+// removeFinalizers removes the given finalizers from the observed resource,
+// leaving the other ones to their controllers. The patch is rejected if the
+// resource changed since it was observed.
+func removeFinalizers(
+	ctx context.Context,
+	rc dynamic.ResourceInterface,
+	observed *unstructured.Unstructured,
+	finalizers []string,
+) error {
+	current := observed.GetFinalizers()
+	remaining := make([]string, 0, len(current))
+	for _, finalizer := range current {
+		if !slices.Contains(finalizers, finalizer) {
+			remaining = append(remaining, finalizer)
+		}
+	}
+	if len(remaining) == len(current) {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      remaining,
+			"resourceVersion": observed.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build the finalizers patch of resource %s: %w", observed.GetName(), err)
+	}
+	if _, err := rc.Patch(ctx, observed.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to remove finalizers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// finalizerDescriptor describes a resource declaring finalizers.
+type finalizerDescriptor struct {
+	fakeDescriptor
+	finalizers []string
+}
+
+func (d finalizerDescriptor) GetFinalizers() []string { return d.finalizers }
+
+// finalizerRuntime is a fake runtime whose resources declare finalizers.
+type finalizerRuntime struct {
+	*fakeRuntime
+	finalizers []string
+}
+
+func (r *finalizerRuntime) ResourceDescriptor(string) runtime.ResourceDescriptor {
+	return finalizerDescriptor{fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR}, finalizers: r.finalizers}
+}
+
+func newFinalizerTestReconciler(desired *unstructured.Unstructured, objects ...k8sruntime.Object) (*instanceGraphReconciler, *fake.FakeDynamicClient) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{testConfigMapGVR: "ConfigMapList"},
+		objects...,
+	)
+	igr := &instanceGraphReconciler{
+		log:    logr.Discard(),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &finalizerRuntime{
+			fakeRuntime: &fakeRuntime{
+				instance:  newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+				order:     []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{"configmap": desired},
+			},
+			finalizers: []string{"kro.run/protection"},
+		},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	return igr, client
+}
+
+func TestResourceFinalizersOnCreate(t *testing.T) {
+	igr, client := newFinalizerTestReconciler(newTestObject("v1", "ConfigMap", "app-config"))
+
+	// The creation requeues the instance, awaiting the resource.
+	_ = igr.reconcileResource(context.Background(), "configmap")
+
+	created, err := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kro.run/protection"}, created.GetFinalizers())
+}
+
+func TestResourceFinalizersPreservedOnUpdate(t *testing.T) {
+	// The config map was applied before, and another controller added its
+	// own finalizer since.
+	observed := newTestObject("v1", "ConfigMap", "app-config")
+	observed.SetFinalizers([]string{"kro.run/protection", "example.com/backup"})
+	observed.Object["data"] = map[string]interface{}{"key": "old"}
+
+	desired := newTestObject("v1", "ConfigMap", "app-config")
+	desired.Object["data"] = map[string]interface{}{"key": "new"}
+	igr, client := newFinalizerTestReconciler(desired, observed)
+
+	require.NoError(t, igr.reconcileResource(context.Background(), "configmap"))
+
+	updated, err := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", updated.Object["data"].(map[string]interface{})["key"])
+	assert.ElementsMatch(t, []string{"kro.run/protection", "example.com/backup"}, updated.GetFinalizers())
+}
+
+func TestRemoveFinalizers(t *testing.T) {
+	observed := newTestObject("v1", "ConfigMap", "app-config")
+	observed.SetFinalizers([]string{"kro.run/protection", "example.com/backup"})
+	_, client := newFinalizerTestReconciler(nil, observed)
+	rc := client.Resource(testConfigMapGVR).Namespace("default")
+
+	live, err := rc.Get(context.Background(), "app-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NoError(t, removeFinalizers(context.Background(), rc, live, []string{"kro.run/protection"}))
+
+	live, err = rc.Get(context.Background(), "app-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com/backup"}, live.GetFinalizers())
+
+	// Nothing is patched when none of the finalizers is set.
+	client.ClearActions()
+	require.NoError(t, removeFinalizers(context.Background(), rc, live, []string{"kro.run/protection"}))
+	assert.Empty(t, client.Actions())
+}
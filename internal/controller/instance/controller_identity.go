@@ -0,0 +1,104 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// IdentityFieldChangedReason is the reason of the InstanceSynced condition
+// when an identity field of the instance was changed.
+const IdentityFieldChangedReason = "IdentityFieldChanged"
+
+// identityFieldChangedError is returned when an identity field of an instance
+// no longer has the value recorded when the instance was first reconciled.
+type identityFieldChangedError struct {
+	field    string
+	recorded interface{}
+	current  interface{}
+}
+
+func (e *identityFieldChangedError) Error() string {
+	return fmt.Sprintf(
+		"identity field %s cannot be changed from %v to %v: changing it would orphan the resources of the instance",
+		e.field, e.recorded, e.current,
+	)
+}
+
+func (e *identityFieldChangedError) Reason() string {
+	return IdentityFieldChangedReason
+}
+
+// checkIdentity compares the identity fields of the instance with the values
+// recorded in its identity annotation. It returns an error if any of them
+// changed, otherwise it returns the value the identity annotation should have,
+// which records the fields that weren't recorded yet.
+func checkIdentity(instance *unstructured.Unstructured, identityFields []string) (string, error) {
+	if len(identityFields) == 0 {
+		return "", nil
+	}
+
+	recorded := map[string]interface{}{}
+	if annotation, ok := instance.GetAnnotations()[metadata.IdentityAnnotation]; ok {
+		if err := json.Unmarshal([]byte(annotation), &recorded); err != nil {
+			return "", fmt.Errorf("failed to parse %s annotation: %w", metadata.IdentityAnnotation, err)
+		}
+	}
+
+	identity := make(map[string]interface{}, len(identityFields))
+	for _, field := range identityFields {
+		current, err := identityFieldValue(instance, field)
+		if err != nil {
+			return "", err
+		}
+		if previous, ok := recorded[field]; ok {
+			if !reflect.DeepEqual(previous, current) {
+				return "", &identityFieldChangedError{field: field, recorded: previous, current: current}
+			}
+		}
+		identity[field] = current
+	}
+
+	// encoding/json sorts map keys, the annotation is stable across reconciles.
+	annotation, err := json.Marshal(identity)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal instance identity: %w", err)
+	}
+	return string(annotation), nil
+}
+
+// identityFieldValue returns the value of an identity field of the instance,
+// in its JSON representation so that it can be compared with the recorded one.
+func identityFieldValue(instance *unstructured.Unstructured, field string) (interface{}, error) {
+	value, _, err := unstructured.NestedFieldNoCopy(instance.Object, strings.Split(field, ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity field %s: %w", field, err)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal identity field %s: %w", field, err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal identity field %s: %w", field, err)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,188 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+func newIdentityTestInstance(name string, annotation string) *unstructured.Unstructured {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.Object["spec"] = map[string]interface{}{
+		"name":     name,
+		"replicas": int64(2),
+	}
+	if annotation != "" {
+		instance.SetAnnotations(map[string]string{metadata.IdentityAnnotation: annotation})
+	}
+	return instance
+}
+
+func TestCheckIdentity(t *testing.T) {
+	tests := []struct {
+		name           string
+		instance       *unstructured.Unstructured
+		identityFields []string
+		want           string
+		wantErr        string
+	}{
+		{
+			name:     "no identity fields",
+			instance: newIdentityTestInstance("app", ""),
+			want:     "",
+		},
+		{
+			name:           "identity not recorded yet",
+			instance:       newIdentityTestInstance("app", ""),
+			identityFields: []string{"spec.name"},
+			want:           `{"spec.name":"app"}`,
+		},
+		{
+			name:           "identity unchanged",
+			instance:       newIdentityTestInstance("app", `{"spec.name":"app"}`),
+			identityFields: []string{"spec.name"},
+			want:           `{"spec.name":"app"}`,
+		},
+		{
+			name:           "non identity field changed",
+			instance:       newIdentityTestInstance("app", `{"spec.name":"app"}`),
+			identityFields: []string{"spec.name"},
+			want:           `{"spec.name":"app"}`,
+		},
+		{
+			name:           "new identity field is recorded",
+			instance:       newIdentityTestInstance("app", `{"spec.name":"app"}`),
+			identityFields: []string{"spec.name", "spec.replicas"},
+			want:           `{"spec.name":"app","spec.replicas":2}`,
+		},
+		{
+			name:           "unset identity field",
+			instance:       newIdentityTestInstance("app", ""),
+			identityFields: []string{"spec.namespace"},
+			want:           `{"spec.namespace":null}`,
+		},
+		{
+			name:           "identity field changed",
+			instance:       newIdentityTestInstance("other-app", `{"spec.name":"app"}`),
+			identityFields: []string{"spec.name"},
+			wantErr:        "identity field spec.name cannot be changed from app to other-app",
+		},
+		{
+			name:           "malformed annotation",
+			instance:       newIdentityTestInstance("app", `{`),
+			identityFields: []string{"spec.name"},
+			wantErr:        "failed to parse kro.run/identity annotation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkIdentity(tt.instance, tt.identityFields)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReconcileIdentityFieldChange(t *testing.T) {
+	configMapGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newReconciler := func(instance *unstructured.Unstructured) (*instanceGraphReconciler, *fake.FakeDynamicClient) {
+		client := fake.NewSimpleDynamicClientWithCustomListKinds(
+			k8sruntime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				testInstanceGVR: "WebAppList",
+				configMapGVR:    "ConfigMapList",
+			},
+			instance.DeepCopy(),
+		)
+		return &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &fakeRuntime{
+				instance: instance,
+				order:    []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{
+					"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+				},
+			},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			state:                       newInstanceState(),
+			tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+			identityFields:              []string{"spec.name"},
+		}, client
+	}
+
+	t.Run("identity is recorded on first reconcile", func(t *testing.T) {
+		igr, client := newReconciler(newIdentityTestInstance("app", ""))
+
+		err := igr.reconcile(context.Background())
+		// The config map is created, the reconciler waits for it.
+		var requeueErr *requeue.RequeueNeededAfter
+		require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+
+		instance, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, `{"spec.name":"app"}`, instance.GetAnnotations()[metadata.IdentityAnnotation])
+	})
+
+	t.Run("identity change is refused", func(t *testing.T) {
+		igr, client := newReconciler(newIdentityTestInstance("other-app", `{"spec.name":"app"}`))
+
+		err := igr.reconcile(context.Background())
+		var noRequeueErr *requeue.NoRequeue
+		require.True(t, errors.As(err, &noRequeueErr), "unexpected error: %v", err)
+
+		// No resource was created.
+		configMaps, err := client.Resource(configMapGVR).Namespace("default").List(context.Background(), metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, configMaps.Items)
+
+		instance, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		// The recorded identity is left untouched.
+		assert.Equal(t, `{"spec.name":"app"}`, instance.GetAnnotations()[metadata.IdentityAnnotation])
+
+		state, _, _ := unstructured.NestedString(instance.Object, "status", "state")
+		assert.Equal(t, InstanceStateError, state)
+		conditions, _, _ := unstructured.NestedSlice(instance.Object, "status", "conditions")
+		require.Len(t, conditions, 1)
+		condition := conditions[0].(map[string]interface{})
+		assert.Equal(t, "InstanceSynced", condition["type"])
+		assert.Equal(t, "False", condition["status"])
+		assert.Equal(t, IdentityFieldChangedReason, condition["reason"])
+		assert.Contains(t, condition["message"], "identity field spec.name cannot be changed from app to other-app")
+	})
+}
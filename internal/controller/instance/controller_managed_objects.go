@@ -0,0 +1,218 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ManagedObjectLimitReason is the reason of the InstanceSynced condition while
+// the creation of a resource is held because the controller already manages
+// the maximum number of objects.
+const ManagedObjectLimitReason = "ManagedObjectLimitReached"
+
+// ManagedObjects counts the objects managed by the instances of all the
+// resource groups, to cap their total number. It is a last resort safety
+// valve, protecting shared clusters from a runaway resource group or a flood
+// of instances: once the limit is reached, the creations of new objects are
+// held until objects are freed, the existing objects are still updated.
+//
+// The objects are counted as the instances are reconciled. After a restart of
+// the controller, the count is complete once all the instances were
+// reconciled again. They stop being counted when their instance is deleted,
+// or when the instances of their GVR stop being served, see ForgetGVR.
+//
+// A nil *ManagedObjects doesn't cap the number of objects.
+type ManagedObjects struct {
+	limit int
+
+	mu sync.Mutex
+	// objects maps the GVRs of the instances, then their UIDs, to the IDs of
+	// their resources that exist, or are being created.
+	objects map[schema.GroupVersionResource]map[types.UID]map[string]bool
+	total   int
+}
+
+// NewManagedObjects returns a counter capping the total number of managed
+// objects to limit, or nil if limit is 0 or less.
+func NewManagedObjects(limit int) *ManagedObjects {
+	if limit <= 0 {
+		return nil
+	}
+	return &ManagedObjects{
+		limit:   limit,
+		objects: make(map[schema.GroupVersionResource]map[types.UID]map[string]bool),
+	}
+}
+
+// Limit returns the maximum number of managed objects.
+func (m *ManagedObjects) Limit() int {
+	if m == nil {
+		return 0
+	}
+	return m.limit
+}
+
+// Total returns the number of managed objects.
+func (m *ManagedObjects) Total() int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+// ForgetGVR stops counting the resources of all the instances of the given
+// GVR, once they stop being served, e.g. when their resource group is
+// deleted. The resources of the instances orphaned by the resource group
+// aren't managed anymore.
+func (m *ManagedObjects) ForgetGVR(gvr schema.GroupVersionResource) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, resources := range m.objects[gvr] {
+		m.total -= len(resources)
+	}
+	delete(m.objects, gvr)
+	managedObjects.Set(float64(m.total))
+}
+
+// reserve counts the given resource of the given instance, about to be
+// created. It returns false, without counting it, if the limit is reached.
+func (m *ManagedObjects) reserve(gvr schema.GroupVersionResource, instance types.UID, resourceID string) bool {
+	if m == nil {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.objects[gvr][instance][resourceID] {
+		return true
+	}
+	if m.total >= m.limit {
+		return false
+	}
+	m.set(gvr, instance, resourceID, true)
+	return true
+}
+
+// update records, once the given instance is reconciled, which of its
+// resources exist. The resources missing from exists keep being counted as
+// before, e.g the ones the reconciliation didn't reach.
+func (m *ManagedObjects) update(gvr schema.GroupVersionResource, instance types.UID, exists map[string]bool) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for resourceID, exist := range exists {
+		m.set(gvr, instance, resourceID, exist)
+	}
+}
+
+// forget stops counting the resources of the given instance, once it is
+// deleted.
+func (m *ManagedObjects) forget(gvr schema.GroupVersionResource, instance types.UID) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total -= len(m.objects[gvr][instance])
+	delete(m.objects[gvr], instance)
+	if len(m.objects[gvr]) == 0 {
+		delete(m.objects, gvr)
+	}
+	managedObjects.Set(float64(m.total))
+}
+
+// set counts, or stops counting, the given resource of the given instance.
+// The lock must be held.
+func (m *ManagedObjects) set(gvr schema.GroupVersionResource, instance types.UID, resourceID string, exists bool) {
+	instances := m.objects[gvr]
+	resources := instances[instance]
+	if resources[resourceID] == exists {
+		return
+	}
+	if exists {
+		if instances == nil {
+			instances = make(map[types.UID]map[string]bool)
+			m.objects[gvr] = instances
+		}
+		if resources == nil {
+			resources = make(map[string]bool)
+			instances[instance] = resources
+		}
+		resources[resourceID] = true
+		m.total++
+	} else {
+		delete(resources, resourceID)
+		if len(resources) == 0 {
+			delete(instances, instance)
+		}
+		if len(instances) == 0 {
+			delete(m.objects, gvr)
+		}
+		m.total--
+	}
+	managedObjects.Set(float64(m.total))
+}
+
+// checkManagedObjectLimit returns an error, requeuing the instance, if the
+// given resource can't be created without exceeding the maximum number of
+// objects managed by the controller.
+func (igr *instanceGraphReconciler) checkManagedObjectLimit(resourceID string, resourceState *ResourceState) error {
+	limit := igr.reconcileConfig.ManagedObjects
+	if limit.reserve(igr.gvr, igr.runtime.GetInstance().GetUID(), resourceID) {
+		return nil
+	}
+	managedObjectLimitHolds.Inc()
+	resourceState.State = "WAITING_FOR_CAPACITY"
+	resourceState.Err = withReason(ManagedObjectLimitReason, fmt.Errorf(
+		"the controller already manages the maximum number of objects (%d), see --max-managed-objects, "+
+			"the creation of resource %s is held until objects are freed", limit.Limit(), resourceID))
+	return igr.delayedRequeue(resourceState.Err)
+}
+
+// updateManagedObjects records which resources of the instance exist after
+// its reconciliation.
+func (igr *instanceGraphReconciler) updateManagedObjects() {
+	if igr.reconcileConfig.ManagedObjects == nil {
+		return
+	}
+	exists := make(map[string]bool, len(igr.state.ResourceStates))
+	for resourceID, resourceState := range igr.state.ResourceStates {
+		switch resourceState.State {
+		case "CREATED", "SYNCED", "WAITING_FOR_READINESS":
+			exists[resourceID] = true
+		case "SKIPPED":
+			// Excluded resources waiting for their deletion still exist.
+			_, pending := igr.state.PendingDeletions[resourceID]
+			exists[resourceID] = pending
+		case "DELETED", "WAITING_FOR_CAPACITY":
+			exists[resourceID] = false
+		}
+	}
+	igr.reconcileConfig.ManagedObjects.update(igr.gvr, igr.runtime.GetInstance().GetUID(), exists)
+}
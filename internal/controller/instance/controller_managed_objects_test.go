@@ -0,0 +1,217 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+func TestManagedObjects(t *testing.T) {
+	gvr := testInstanceGVR
+	otherGVR := schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "databases"}
+
+	t.Run("disabled", func(t *testing.T) {
+		m := NewManagedObjects(0)
+		assert.Nil(t, m)
+		assert.True(t, m.reserve(gvr, "a", "configmap"))
+		m.update(gvr, "a", map[string]bool{"configmap": true})
+		m.forget(gvr, "a")
+		m.ForgetGVR(gvr)
+		assert.Equal(t, 0, m.Total())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		m := NewManagedObjects(2)
+		assert.True(t, m.reserve(gvr, "a", "configmap"))
+		assert.True(t, m.reserve(gvr, "a", "secret"))
+		// Resources already counted don't need more room.
+		assert.True(t, m.reserve(gvr, "a", "configmap"))
+		assert.False(t, m.reserve(gvr, "b", "configmap"))
+		assert.Equal(t, 2, m.Total())
+
+		// Deleted resources free room, unknown ones are left untouched.
+		m.update(gvr, "a", map[string]bool{"secret": false, "unknown": false})
+		assert.Equal(t, 1, m.Total())
+		assert.True(t, m.reserve(gvr, "b", "configmap"))
+		assert.False(t, m.reserve(gvr, "c", "configmap"))
+
+		m.forget(gvr, "a")
+		assert.Equal(t, 1, m.Total())
+		assert.True(t, m.reserve(gvr, "c", "configmap"))
+		assert.Equal(t, 2, m.Total())
+	})
+
+	t.Run("forget GVR", func(t *testing.T) {
+		m := NewManagedObjects(3)
+		assert.True(t, m.reserve(gvr, "a", "configmap"))
+		assert.True(t, m.reserve(gvr, "b", "configmap"))
+		assert.True(t, m.reserve(otherGVR, "c", "configmap"))
+		assert.False(t, m.reserve(otherGVR, "d", "configmap"))
+
+		// The instances of the GVR not served anymore free their room, the
+		// instances of the other GVRs are still counted.
+		m.ForgetGVR(gvr)
+		assert.Equal(t, 1, m.Total())
+		assert.True(t, m.reserve(otherGVR, "d", "configmap"))
+		assert.Equal(t, 2, m.Total())
+		m.ForgetGVR(gvr)
+		assert.Equal(t, 2, m.Total())
+	})
+}
+
+// newManagedObjectsReconcile returns a function reconciling the instance with
+// the given name, creating its configmap, with the given managed objects cap.
+func newManagedObjectsReconcile(
+	t *testing.T,
+	client dynamic.Interface,
+	managedObjects *ManagedObjects,
+) func(name string, deleting bool) error {
+	return func(name string, deleting bool) error {
+		instance, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		instance.SetUID(types.UID(name + "-uid"))
+		if deleting {
+			now := metav1.Now()
+			instance.SetDeletionTimestamp(&now)
+		}
+		igr := &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &fakeRuntime{
+				instance: instance,
+				order:    []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{
+					"configmap": newTestObject("v1", "ConfigMap", name+"-config"),
+				},
+			},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			reconcileConfig:             ReconcileConfig{ManagedObjects: managedObjects},
+			state:                       newInstanceState(),
+			tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+			resourceTypeWaits:           newResourceTypeWaits(),
+		}
+		return igr.reconcile(context.Background())
+	}
+}
+
+func TestReconcileManagedObjectLimit(t *testing.T) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		newTestObject("kro.run/v1alpha1", "WebApp", "first"),
+		newTestObject("kro.run/v1alpha1", "WebApp", "second"),
+	)
+	managedObjects := NewManagedObjects(1)
+	reconcile := newManagedObjectsReconcile(t, client, managedObjects)
+	configMaps := client.Resource(testConfigMapGVR).Namespace("default")
+
+	_ = reconcile("first", false)
+	_, err := configMaps.Get(context.Background(), "first-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, managedObjects.Total())
+
+	// The cap is reached, the creation of the second configmap is held.
+	err = reconcile("second", false)
+	assert.ErrorContains(t, err, "maximum number of objects (1)")
+	var requeueErr *requeue.RequeueNeededAfter
+	require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+	_, err = configMaps.Get(context.Background(), "second-config", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+	assertHeld(t, client, "second", true)
+
+	// Deleting the first instance frees its configmap.
+	for i := 0; i < 3 && managedObjects.Total() > 0; i++ {
+		_ = reconcile("first", true)
+	}
+	_, err = configMaps.Get(context.Background(), "first-config", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "unexpected error: %v", err)
+	assert.Equal(t, 0, managedObjects.Total())
+
+	// The creation of the second configmap resumes.
+	_ = reconcile("second", false)
+	_, err = configMaps.Get(context.Background(), "second-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assertHeld(t, client, "second", false)
+	assert.Equal(t, 1, managedObjects.Total())
+}
+
+// assertHeld checks whether the status of the given instance reports the
+// creation of its configmap as held by the managed object limit.
+func TestReconcileManagedObjectLimitResourceGroupDeletion(t *testing.T) {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		newTestObject("kro.run/v1alpha1", "WebApp", "first"),
+		newTestObject("kro.run/v1alpha1", "WebApp", "second"),
+	)
+	managedObjects := NewManagedObjects(1)
+	reconcile := newManagedObjectsReconcile(t, client, managedObjects)
+
+	_ = reconcile("first", false)
+	assert.Equal(t, 1, managedObjects.Total())
+	err := reconcile("second", false)
+	assert.ErrorContains(t, err, "maximum number of objects (1)")
+
+	// The resource group is deleted, orphaning its instances: the GVR of the
+	// instances isn't served anymore, their objects aren't managed and no
+	// instance deletion ever forgets them.
+	managedObjects.ForgetGVR(testInstanceGVR)
+	assert.Equal(t, 0, managedObjects.Total())
+	_, err = client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "first-config", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Once the resource group is recreated, the instances are counted again.
+	_ = reconcile("first", false)
+	assert.Equal(t, 1, managedObjects.Total())
+}
+
+func assertHeld(t *testing.T, client dynamic.Interface, name string, held bool) {
+	t.Helper()
+	instance, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	conditions, _, _ := unstructured.NestedSlice(instance.Object, "status", "conditions")
+	require.Len(t, conditions, 1)
+	reason := conditions[0].(map[string]interface{})["reason"]
+	if held {
+		assert.Equal(t, ManagedObjectLimitReason, reason)
+	} else {
+		assert.NotEqual(t, ManagedObjectLimitReason, reason)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// checkMinReadyDuration returns an error, requeuing the instance, if the given
+// ready resource hasn't been ready for its minReadyDuration yet. The time the
+// resource became ready is carried over across reconciliations through the
+// readySince field of the instance status.resources entries.
+func (igr *instanceGraphReconciler) checkMinReadyDuration(resourceID string, resourceState *ResourceState) error {
+	minReadyDuration := igr.runtime.ResourceDescriptor(resourceID).GetMinReadyDuration()
+	if minReadyDuration <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	readySince, ok := igr.previousReadySince(resourceID)
+	if !ok || readySince.After(now) {
+		readySince = now
+	}
+	igr.state.ReadySince[resourceID] = readySince
+
+	if remaining := minReadyDuration - now.Sub(readySince); remaining > 0 {
+		resourceState.State = "WAITING_FOR_READINESS"
+		resourceState.Err = withReason(DependencyNotReadyReason, fmt.Errorf(
+			"resource ready since %s, waiting for its minReadyDuration of %s",
+			readySince.Format(time.RFC3339), minReadyDuration))
+		return requeue.NeededAfter(resourceState.Err, remaining)
+	}
+	return nil
+}
+
+// resetReadySince records that the given resource isn't ready, restarting its
+// minReadyDuration window once it is ready again.
+func (igr *instanceGraphReconciler) resetReadySince(resourceID string) {
+	if igr.runtime.ResourceDescriptor(resourceID).GetMinReadyDuration() > 0 {
+		igr.state.ReadySince[resourceID] = time.Time{}
+	}
+}
+
+// readySince returns the time the given resource became ready, as reported in
+// the instance status. Resources that weren't checked during this
+// reconciliation keep the time previously reported.
+func (igr *instanceGraphReconciler) readySince(resourceID string) (time.Time, bool) {
+	if readySince, ok := igr.state.ReadySince[resourceID]; ok {
+		return readySince, !readySince.IsZero()
+	}
+	return igr.previousReadySince(resourceID)
+}
+
+// previousReadySince returns the readySince field of the given resource in
+// the status.resources field of the instance.
+func (igr *instanceGraphReconciler) previousReadySince(resourceID string) (time.Time, bool) {
+	status, ok := igr.runtime.GetInstance().Object["status"].(map[string]interface{})
+	if !ok {
+		return time.Time{}, false
+	}
+	resources, _ := status["resources"].([]interface{})
+	for _, resource := range resources {
+		entry, ok := resource.(map[string]interface{})
+		if !ok || entry["id"] != resourceID {
+			continue
+		}
+		value, _ := entry["readySince"].(string)
+		readySince, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return readySince, true
+	}
+	return time.Time{}, false
+}
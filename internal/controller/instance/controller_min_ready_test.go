@@ -0,0 +1,175 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// minReadyDescriptor describes a resource with a minReadyDuration.
+type minReadyDescriptor struct {
+	fakeDescriptor
+	minReadyDuration time.Duration
+}
+
+func (d minReadyDescriptor) GetMinReadyDuration() time.Duration { return d.minReadyDuration }
+
+// minReadyRuntime is a fake runtime whose "database" resource must be ready
+// for a minute before the "app" depending on it is applied.
+type minReadyRuntime struct {
+	*fakeRuntime
+	notReady bool
+}
+
+func (r *minReadyRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	if id != "database" {
+		return r.fakeRuntime.ResourceDescriptor(id)
+	}
+	return minReadyDescriptor{fakeDescriptor{gvr: testConfigMapGVR}, time.Minute}
+}
+
+func (r *minReadyRuntime) IsResourceReady(id string) (bool, string, error) {
+	if id == "database" && r.notReady {
+		return false, "database not available", nil
+	}
+	return true, "", nil
+}
+
+func TestReconcileMinReadyDuration(t *testing.T) {
+	database := newTestObject("v1", "ConfigMap", "database")
+	applied := database.DeepCopy()
+	metadata.GenericLabeler{}.ApplyLabels(applied)
+	require.NoError(t, setSpecHash(applied))
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+		applied,
+	)
+
+	// reconcile reconciles the instance as stored in the cluster, and returns
+	// the reconciliation error and the instance status.resources entry of the
+	// database.
+	reconcile := func(t *testing.T, notReady bool) (map[string]interface{}, error) {
+		instances := client.Resource(testInstanceGVR).Namespace("default")
+		instance, err := instances.Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		igr := &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &minReadyRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance: instance,
+					order:    []string{"database", "app"},
+					resources: map[string]*unstructured.Unstructured{
+						"database": database.DeepCopy(),
+						"app":      newTestObject("v1", "ConfigMap", "app"),
+					},
+				},
+				notReady: notReady,
+			},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			state:                       newInstanceState(),
+			tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+		}
+		reconcileErr := igr.reconcile(context.Background())
+
+		instance, err = instances.Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		resources, _, _ := unstructured.NestedSlice(instance.Object, "status", "resources")
+		require.Len(t, resources, 1)
+		return resources[0].(map[string]interface{}), reconcileErr
+	}
+
+	// backdateReadySince moves the time the database became ready back by the
+	// given duration.
+	backdateReadySince := func(t *testing.T, d time.Duration) {
+		instances := client.Resource(testInstanceGVR).Namespace("default")
+		instance, err := instances.Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		resources, _, _ := unstructured.NestedSlice(instance.Object, "status", "resources")
+		entry := resources[0].(map[string]interface{})
+		readySince, err := time.Parse(time.RFC3339, entry["readySince"].(string))
+		require.NoError(t, err)
+		entry["readySince"] = readySince.Add(-d).Format(time.RFC3339)
+		require.NoError(t, unstructured.SetNestedSlice(instance.Object, resources, "status", "resources"))
+		_, err = instances.UpdateStatus(context.Background(), instance, metav1.UpdateOptions{})
+		require.NoError(t, err)
+	}
+
+	appExists := func(t *testing.T) bool {
+		_, err := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app", metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	// The database just became ready, the app waits for the window to elapse.
+	entry, err := reconcile(t, false)
+	var requeueErr *requeue.RequeueNeededAfter
+	require.True(t, errors.As(err, &requeueErr), "expected a requeue, got %v", err)
+	assert.InDelta(t, time.Minute, requeueErr.Duration(), float64(2*time.Second))
+	reason, _ := errorReason(err)
+	assert.Equal(t, DependencyNotReadyReason, reason)
+	assert.Equal(t, "database", entry["id"])
+	assert.NotEmpty(t, entry["readySince"])
+	assert.False(t, appExists(t))
+
+	// Part of the window elapsed, the app still waits for the remaining time.
+	backdateReadySince(t, 40*time.Second)
+	entry, err = reconcile(t, false)
+	require.True(t, errors.As(err, &requeueErr), "expected a requeue, got %v", err)
+	assert.InDelta(t, 20*time.Second, requeueErr.Duration(), float64(2*time.Second))
+	assert.False(t, appExists(t))
+
+	// The database stopped being ready, the window starts over.
+	entry, _ = reconcile(t, true)
+	assert.NotContains(t, entry, "readySince")
+	entry, err = reconcile(t, false)
+	require.True(t, errors.As(err, &requeueErr), "expected a requeue, got %v", err)
+	assert.InDelta(t, time.Minute, requeueErr.Duration(), float64(2*time.Second))
+	assert.False(t, appExists(t))
+
+	// The database has been ready for the whole window, the app is applied.
+	backdateReadySince(t, time.Minute)
+	entry, _ = reconcile(t, false)
+	assert.NotEmpty(t, entry["readySince"])
+	assert.True(t, appExists(t))
+}
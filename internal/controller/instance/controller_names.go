@@ -0,0 +1,203 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// InstanceNameTooLongReason is the reason of the InstanceSynced condition
+// when the name of the instance is too long to derive the names of its
+// resources from it.
+const InstanceNameTooLongReason = "InstanceNameTooLong"
+
+// InvalidResourceNameReason is the reason of the InstanceSynced condition when
+// the name of a resource computed from expressions isn't a valid DNS name.
+const InvalidResourceNameReason = "InvalidResourceName"
+
+// ResourceNameCollisionReason is the reason of the InstanceSynced condition
+// when two resources of an instance are rendered with the same name.
+const ResourceNameCollisionReason = "ResourceNameCollision"
+
+// instanceNameExpression is the expression referencing the instance name in
+// the resource templates.
+const instanceNameExpression = "schema.metadata.name"
+
+// dnsLabelNamedResources are the resources whose names must be DNS labels,
+// instead of DNS subdomains like most resources.
+var dnsLabelNamedResources = map[schema.GroupResource]bool{
+	{Resource: "namespaces"}: true,
+	{Resource: "services"}:   true,
+}
+
+// instanceNameTooLongError is returned when a resource name derived from the
+// instance name exceeds the maximum length of the resource names.
+type instanceNameTooLongError struct {
+	instanceName string
+	resourceID   string
+	resourceName string
+	maxLength    int
+}
+
+func (e *instanceNameTooLongError) Error() string {
+	excess := len(e.resourceName) - e.maxLength
+	return fmt.Sprintf(
+		"instance name %q is too long: the name of resource %s derived from it, %q, exceeds the maximum length of %d characters by %d. "+
+			"Shorten the instance name by at least %d characters",
+		e.instanceName, e.resourceID, e.resourceName, e.maxLength, excess, excess,
+	)
+}
+
+func (e *instanceNameTooLongError) Reason() string {
+	return InstanceNameTooLongReason
+}
+
+// checkInstanceName verifies that the names of the resources derived from the
+// instance name don't exceed the maximum length of the resource names. Only
+// the resources whose name is already resolved are checked, which is the case
+// of the names derived from the instance only.
+func checkInstanceName(rt runtime.Interface) error {
+	instanceName := rt.GetInstance().GetName()
+	for _, resourceID := range rt.TopologicalOrder() {
+		descriptor := rt.ResourceDescriptor(resourceID)
+		if !nameDerivesFromInstanceName(descriptor) {
+			continue
+		}
+		if want, err := rt.WantToCreateResource(resourceID); err != nil || !want {
+			continue
+		}
+		resource, state := rt.GetResource(resourceID)
+		if state != runtime.ResourceStateResolved {
+			continue
+		}
+
+		maxLength := maxResourceNameLength(descriptor.GetGroupVersionResource())
+		if name := resource.GetName(); len(name) > maxLength {
+			return &instanceNameTooLongError{
+				instanceName: instanceName,
+				resourceID:   resourceID,
+				resourceName: name,
+				maxLength:    maxLength,
+			}
+		}
+	}
+	return nil
+}
+
+// nameDerivesFromInstanceName returns true if the name of the resource is
+// computed from the name of the instance.
+func nameDerivesFromInstanceName(descriptor runtime.ResourceDescriptor) bool {
+	for _, v := range descriptor.GetVariables() {
+		if v.Path != "metadata.name" {
+			continue
+		}
+		for _, expr := range v.Expressions {
+			if strings.Contains(expr, instanceNameExpression) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkResourceName verifies that the name of a resource computed from
+// expressions is a valid name for its type, a DNS label or subdomain, rather
+// than letting the API server reject it. Literal names are validated when the
+// ResourceGroup is created.
+func checkResourceName(resourceID string, descriptor runtime.ResourceDescriptor, resource *unstructured.Unstructured) error {
+	if !nameFromExpressions(descriptor) {
+		return nil
+	}
+	name := resource.GetName()
+	var errs []string
+	if dnsLabelNamedResources[descriptor.GetGroupVersionResource().GroupResource()] {
+		errs = validation.IsDNS1123Label(name)
+	} else {
+		errs = validation.IsDNS1123Subdomain(name)
+	}
+	if len(errs) > 0 {
+		return withReason(InvalidResourceNameReason, fmt.Errorf(
+			"name %q of resource %s is not a valid DNS name: %s", name, resourceID, strings.Join(errs, "; "),
+		))
+	}
+	return nil
+}
+
+// checkNameCollision verifies that the object rendered for a resource isn't
+// the object already rendered for another resource of the instance, of the
+// same kind, namespace and name. They would overwrite each other on every
+// reconcile. The literal names are compared when the ResourceGroup is
+// created, the names computed from expressions only once rendered, e.g. when
+// an expression doesn't depend on the instance values it should.
+func (igr *instanceGraphReconciler) checkNameCollision(resourceID string, resource *unstructured.Unstructured) error {
+	groupResource := igr.runtime.ResourceDescriptor(resourceID).GetGroupVersionResource().GroupResource()
+	namespace := igr.renderedNamespace(resourceID)
+	for _, otherID := range igr.runtime.TopologicalOrder() {
+		if otherID == resourceID {
+			continue
+		}
+		// Only the resources already reconciled are rendered.
+		state, ok := igr.state.ResourceStates[otherID]
+		if !ok || state.State == "PENDING" || state.State == "SKIPPED" {
+			continue
+		}
+		other, _ := igr.runtime.GetResource(otherID)
+		if other == nil || other.GetName() != resource.GetName() ||
+			igr.runtime.ResourceDescriptor(otherID).GetGroupVersionResource().GroupResource() != groupResource ||
+			igr.renderedNamespace(otherID) != namespace {
+			continue
+		}
+		return withReason(ResourceNameCollisionReason, fmt.Errorf(
+			"resources %s and %s are both rendered as the %s %q: the names computed by their expressions must differ",
+			otherID, resourceID, resource.GetKind(), strings.TrimPrefix(namespace+"/"+resource.GetName(), "/"),
+		))
+	}
+	return nil
+}
+
+// renderedNamespace returns the namespace a resource is applied in, or an
+// empty string if it is cluster scoped.
+func (igr *instanceGraphReconciler) renderedNamespace(resourceID string) string {
+	if !igr.runtime.ResourceDescriptor(resourceID).IsNamespaced() {
+		return ""
+	}
+	return igr.getResourceNamespace(resourceID)
+}
+
+// nameFromExpressions returns true if the name of the resource is computed
+// from expressions.
+func nameFromExpressions(descriptor runtime.ResourceDescriptor) bool {
+	for _, v := range descriptor.GetVariables() {
+		if v.Path == "metadata.name" {
+			return true
+		}
+	}
+	return false
+}
+
+// maxResourceNameLength returns the maximum length of the names of the
+// resources of the given type.
+func maxResourceNameLength(gvr schema.GroupVersionResource) int {
+	if dnsLabelNamedResources[gvr.GroupResource()] {
+		return validation.DNS1123LabelMaxLength
+	}
+	return validation.DNS1123SubdomainMaxLength
+}
@@ -0,0 +1,292 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// namedDescriptor describes a resource whose name is computed from the given
+// expression.
+type namedDescriptor struct {
+	fakeDescriptor
+	nameExpression string
+}
+
+func (d namedDescriptor) GetVariables() []*variable.ResourceField {
+	return []*variable.ResourceField{{
+		FieldDescriptor: variable.FieldDescriptor{
+			Path:        "metadata.name",
+			Expressions: []string{d.nameExpression},
+		},
+		Kind: variable.ResourceVariableKindStatic,
+	}}
+}
+
+// namedRuntime is a fake runtime whose resources are named from expressions.
+type namedRuntime struct {
+	*fakeRuntime
+	descriptors map[string]runtime.ResourceDescriptor
+}
+
+func (r *namedRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	return r.descriptors[id]
+}
+
+func TestCheckInstanceName(t *testing.T) {
+	servicesGVR := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	tests := []struct {
+		name          string
+		instanceName  string
+		resourceGVR   schema.GroupVersionResource
+		nameTemplate  string
+		derived       bool
+		wantErr       bool
+		wantErrSubstr string
+	}{
+		{
+			name:         "derived name fits",
+			instanceName: "my-app",
+			resourceGVR:  servicesGVR,
+			nameTemplate: "%s-service",
+			derived:      true,
+		},
+		{
+			name:          "derived service name overflows a DNS label",
+			instanceName:  strings.Repeat("a", 60),
+			resourceGVR:   servicesGVR,
+			nameTemplate:  "%s-service",
+			derived:       true,
+			wantErr:       true,
+			wantErrSubstr: "exceeds the maximum length of 63 characters by 5. Shorten the instance name by at least 5 characters",
+		},
+		{
+			name:         "derived config map name fits a DNS subdomain",
+			instanceName: strings.Repeat("a", 60),
+			resourceGVR:  testConfigMapGVR,
+			nameTemplate: "%s-config",
+			derived:      true,
+		},
+		{
+			name:          "derived config map name overflows a DNS subdomain",
+			instanceName:  strings.Repeat("a", 250),
+			resourceGVR:   testConfigMapGVR,
+			nameTemplate:  "%s-config",
+			derived:       true,
+			wantErr:       true,
+			wantErrSubstr: "exceeds the maximum length of 253 characters by 4",
+		},
+		{
+			name:         "names not derived from the instance name are not checked",
+			instanceName: strings.Repeat("a", 60),
+			resourceGVR:  servicesGVR,
+			nameTemplate: "%s-service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", tt.instanceName)
+			service := newTestObject("v1", "Service", strings.Replace(tt.nameTemplate, "%s", tt.instanceName, 1))
+
+			nameExpression := "schema.spec.name + '-service'"
+			if tt.derived {
+				nameExpression = "schema.metadata.name + '-service'"
+			}
+			rt := &namedRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance:  instance,
+					order:     []string{"service"},
+					resources: map[string]*unstructured.Unstructured{"service": service},
+				},
+				descriptors: map[string]runtime.ResourceDescriptor{
+					"service": namedDescriptor{
+						fakeDescriptor: fakeDescriptor{gvr: tt.resourceGVR},
+						nameExpression: nameExpression,
+					},
+				},
+			}
+
+			err := checkInstanceName(rt)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			var nameErr *instanceNameTooLongError
+			require.True(t, errors.As(err, &nameErr), "expected an instanceNameTooLongError, got %v", err)
+			assert.Equal(t, "service", nameErr.resourceID)
+			assert.Contains(t, err.Error(), tt.wantErrSubstr)
+		})
+	}
+}
+
+func TestReconcileResourceInvalidName(t *testing.T) {
+	servicesGVR := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	tests := []struct {
+		name          string
+		resourceGVR   schema.GroupVersionResource
+		resourceName  string
+		wantErrSubstr string
+	}{
+		{
+			name:          "uppercase config map name",
+			resourceGVR:   testConfigMapGVR,
+			resourceName:  "My_Config",
+			wantErrSubstr: `name "My_Config" of resource resource is not a valid DNS name`,
+		},
+		{
+			name:          "dotted service name",
+			resourceGVR:   servicesGVR,
+			resourceName:  "my.service",
+			wantErrSubstr: `name "my.service" of resource resource is not a valid DNS name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &namedRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance:  newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+					order:     []string{"resource"},
+					resources: map[string]*unstructured.Unstructured{"resource": newTestObject("v1", "Service", tt.resourceName)},
+				},
+				descriptors: map[string]runtime.ResourceDescriptor{
+					"resource": namedDescriptor{
+						fakeDescriptor: fakeDescriptor{gvr: tt.resourceGVR},
+						nameExpression: "schema.spec.name",
+					},
+				},
+			}
+			igr := &instanceGraphReconciler{
+				log:     logr.Discard(),
+				runtime: rt,
+				state:   newInstanceState(),
+				tracer:  noop.NewTracerProvider().Tracer(tracerName),
+			}
+
+			// The resource is refused before any request to the API server,
+			// there is no client.
+			err := igr.reconcileResource(context.Background(), "resource")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErrSubstr)
+			reason, _ := errorReason(err)
+			assert.Equal(t, InvalidResourceNameReason, reason)
+			assert.Equal(t, "ERROR", igr.state.ResourceStates["resource"].State)
+		})
+	}
+}
+
+func TestReconcileResourceNameCollision(t *testing.T) {
+	secretsGVR := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	namespaced := func(namespace, name string) *unstructured.Unstructured {
+		obj := newTestObject("v1", "ConfigMap", name)
+		obj.SetNamespace(namespace)
+		return obj
+	}
+
+	tests := []struct {
+		name string
+		// worker is rendered after app, which was synced.
+		app, worker *unstructured.Unstructured
+		workerGVR   schema.GroupVersionResource
+		appState    string
+		wantErr     bool
+	}{
+		{
+			name: "name expression not unique",
+			app:  namespaced("default", "my-app"), worker: namespaced("default", "my-app"),
+			workerGVR: testConfigMapGVR, appState: "SYNCED", wantErr: true,
+		},
+		{
+			name: "different names",
+			app:  namespaced("default", "my-app"), worker: namespaced("default", "my-app-worker"),
+			workerGVR: testConfigMapGVR, appState: "SYNCED",
+		},
+		{
+			name: "different namespaces",
+			app:  namespaced("default", "my-app"), worker: namespaced("team-a", "my-app"),
+			workerGVR: testConfigMapGVR, appState: "SYNCED",
+		},
+		{
+			name: "different kinds",
+			app:  namespaced("default", "my-app"), worker: namespaced("default", "my-app"),
+			workerGVR: secretsGVR, appState: "SYNCED",
+		},
+		{
+			name: "excluded resource",
+			app:  namespaced("default", "my-app"), worker: namespaced("default", "my-app"),
+			workerGVR: testConfigMapGVR, appState: "SKIPPED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &namedRuntime{
+				fakeRuntime: &fakeRuntime{
+					instance:  newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+					order:     []string{"app", "worker"},
+					resources: map[string]*unstructured.Unstructured{"app": tt.app, "worker": tt.worker},
+				},
+				descriptors: map[string]runtime.ResourceDescriptor{
+					"app": namedDescriptor{
+						fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR},
+						nameExpression: "schema.metadata.name",
+					},
+					"worker": namedDescriptor{
+						fakeDescriptor: fakeDescriptor{gvr: tt.workerGVR},
+						nameExpression: "schema.spec.workerName",
+					},
+				},
+			}
+			igr := &instanceGraphReconciler{
+				log:     logr.Discard(),
+				runtime: rt,
+				state:   newInstanceState(),
+				tracer:  noop.NewTracerProvider().Tracer(tracerName),
+			}
+			igr.state.ResourceStates["app"] = &ResourceState{State: tt.appState}
+
+			err := igr.checkNameCollision("worker", tt.worker)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+
+			// The resource is refused before any request to the API server,
+			// there is no client.
+			err = igr.reconcileResource(context.Background(), "worker")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `resources app and worker are both rendered as the ConfigMap "default/my-app"`)
+			reason, _ := errorReason(err)
+			assert.Equal(t, ResourceNameCollisionReason, reason)
+			assert.Equal(t, "ERROR", igr.state.ResourceStates["worker"].State)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	tests := []struct {
+		name        string
+		pausable    bool
+		paused      bool
+		wantApplied bool
+	}{
+		{
+			name:     "paused instance",
+			pausable: true,
+			paused:   true,
+		},
+		{
+			name:        "unpaused instance",
+			pausable:    true,
+			wantApplied: true,
+		},
+		{
+			name:        "paused field ignored when not pausable",
+			paused:      true,
+			wantApplied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			require.NoError(t, unstructured.SetNestedField(instance.Object, tt.paused, "spec", "paused"))
+			configMap := newTestObject("v1", "ConfigMap", "app-config")
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+			)
+			igr := &instanceGraphReconciler{
+				log:    logr.Discard(),
+				gvr:    testInstanceGVR,
+				client: client,
+				runtime: &fakeRuntime{
+					instance:  instance,
+					order:     []string{"configmap"},
+					resources: map[string]*unstructured.Unstructured{"configmap": configMap},
+				},
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+				pausable:                    tt.pausable,
+			}
+			client.ClearActions()
+			err := igr.reconcile(context.Background())
+			if !tt.wantApplied {
+				require.NoError(t, err)
+				// Neither the resources nor the instance status are written.
+				assert.Empty(t, client.Actions())
+				return
+			}
+
+			// The creation of the config map requeues the instance.
+			_, err = client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+			assert.NoError(t, err)
+		})
+	}
+}
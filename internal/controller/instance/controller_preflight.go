@@ -0,0 +1,78 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ImpersonationForbiddenReason is the reason of the InstanceSynced condition
+// when the impersonated service account isn't allowed to create the resources
+// of the instance.
+const ImpersonationForbiddenReason = "ImpersonationForbidden"
+
+// preflightAccessReviews checks, with a SelfSubjectAccessReview per resource
+// type, that the impersonated service account is allowed to create the
+// resources of the instance. The namespaced resources are checked in the
+// namespace of the instance, the service account being configured for it.
+// Nothing is checked when the preflight is disabled.
+func (igr *instanceGraphReconciler) preflightAccessReviews(ctx context.Context) error {
+	if igr.accessReviews == nil {
+		return nil
+	}
+	namespace := igr.runtime.GetInstance().GetNamespace()
+
+	reviewed := make(map[schema.GroupVersionResource]bool)
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		descriptor := igr.runtime.ResourceDescriptor(resourceID)
+		gvr := descriptor.GetGroupVersionResource()
+		if reviewed[gvr] {
+			continue
+		}
+		reviewed[gvr] = true
+
+		attributes := &authorizationv1.ResourceAttributes{
+			Verb:     "create",
+			Group:    gvr.Group,
+			Version:  gvr.Version,
+			Resource: gvr.Resource,
+		}
+		if descriptor.IsNamespaced() {
+			attributes.Namespace = namespace
+		}
+		review, err := igr.accessReviews.Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to review the access of service account %s to %s: %w", igr.serviceAccount, gvr.GroupResource(), err)
+		}
+		if !review.Status.Allowed {
+			igr.state.FailedResourceID = resourceID
+			recordImpersonateError(namespace, igr.serviceAccount, errorForbidden)
+			scope := "at the cluster scope"
+			if attributes.Namespace != "" {
+				scope = "in namespace " + attributes.Namespace
+			}
+			return withReason(ImpersonationForbiddenReason, fmt.Errorf(
+				"service account %s is not allowed to create %s %s: %s",
+				igr.serviceAccount, gvr.GroupResource(), scope, review.Status.Reason))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+func TestReconcilePreflightAccessReviews(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     bool
+		wantErr     string
+		wantCreated bool
+	}{
+		{
+			name:        "allowed",
+			allowed:     true,
+			wantCreated: true,
+		},
+		{
+			name:    "forbidden",
+			allowed: false,
+			wantErr: `service account kro-sa is not allowed to create configmaps in namespace default: no RBAC policy matched`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			configMap := newTestObject("v1", "ConfigMap", "app-config")
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+			)
+			var reviews []*authorizationv1.ResourceAttributes
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, k8sruntime.Object, error) {
+				review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				reviews = append(reviews, review.Spec.ResourceAttributes)
+				review.Status.Allowed = tt.allowed
+				if !tt.allowed {
+					review.Status.Reason = "no RBAC policy matched"
+				}
+				return true, review, nil
+			})
+
+			forbidden := impersonationErrors.WithLabelValues("default", "kro-sa", string(errorForbidden))
+			forbiddenBefore := testutil.ToFloat64(forbidden)
+
+			igr := &instanceGraphReconciler{
+				log:    logr.Discard(),
+				gvr:    testInstanceGVR,
+				client: client,
+				runtime: &fakeRuntime{
+					instance: instance,
+					// Both resources are config maps, reviewed once.
+					order: []string{"configmap", "other"},
+					resources: map[string]*unstructured.Unstructured{
+						"configmap": configMap,
+						"other":     newTestObject("v1", "ConfigMap", "other-config"),
+					},
+				},
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				accessReviews:               kubeClient.AuthorizationV1().SelfSubjectAccessReviews(),
+				serviceAccount:              "kro-sa",
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+			}
+			err := igr.reconcile(context.Background())
+
+			require.Len(t, reviews, 1)
+			assert.Equal(t, &authorizationv1.ResourceAttributes{
+				Verb:      "create",
+				Version:   "v1",
+				Resource:  "configmaps",
+				Namespace: "default",
+			}, reviews[0])
+
+			_, getErr := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+			if tt.wantCreated {
+				require.NoError(t, getErr)
+				assert.Equal(t, forbiddenBefore, testutil.ToFloat64(forbidden))
+				return
+			}
+			assert.True(t, apierrors.IsNotFound(getErr), "no resource should be created, got %v", getErr)
+			require.Error(t, err)
+			reason, message := errorReason(err)
+			assert.Equal(t, ImpersonationForbiddenReason, reason)
+			assert.Equal(t, tt.wantErr, message)
+			assert.Equal(t, forbiddenBefore+1, testutil.ToFloat64(forbidden))
+		})
+	}
+}
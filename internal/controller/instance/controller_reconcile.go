@@ -18,13 +18,18 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/awslabs/kro/api/v1alpha1"
 	"github.com/awslabs/kro/internal/metadata"
 	"github.com/awslabs/kro/internal/runtime"
 	"github.com/awslabs/kro/pkg/requeue"
@@ -54,6 +59,47 @@ type instanceGraphReconciler struct {
 	reconcileConfig ReconcileConfig
 	// state holds the current state of the instance and its sub-resources.
 	state *InstanceState
+	// tracer is used to trace the reconciliation of the instance.
+	tracer trace.Tracer
+	// identityFields are the paths to the instance spec fields that can't be
+	// changed once the instance is created.
+	identityFields []string
+	// sensitiveFields are the paths to the instance status fields whose values
+	// are written into a Secret instead of the instance status.
+	sensitiveFields []string
+	// statusStorage defines how the values of some instance status fields are
+	// stored instead of being written as is in the instance status.
+	statusStorage []v1alpha1.StatusFieldStorage
+	// accessReviews reviews the access of the impersonated service account to
+	// the resources before they are applied. It is nil when the resources
+	// aren't applied under an impersonated service account, or when the
+	// preflight is disabled.
+	accessReviews authorizationv1client.SelfSubjectAccessReviewInterface
+	// serviceAccount is the impersonated service account the resources are
+	// applied under, if any.
+	serviceAccount string
+	// requiredStatusFields are the paths to the instance status fields that
+	// must be resolved before the instance is considered synced.
+	requiredStatusFields []string
+	// composition enables the report of the resources composing the instance
+	// in its status.resources field.
+	composition bool
+	// pausable is true if the instance declares the spec.paused field,
+	// pausing its reconciliation.
+	pausable bool
+	// resourceTypeWaits records the resources waiting for their type to be
+	// served by the API server.
+	resourceTypeWaits *resourceTypeWaits
+	// recorder records the reconciliation failures as events on the
+	// instance. No event is recorded when nil.
+	recorder record.EventRecorder
+	// createLimiter rate limits the creations of resources, it is shared by
+	// the instances of the resource group. The creations aren't rate limited
+	// when nil.
+	createLimiter *rate.Limiter
+	// pendingCreates are the creations deferred to the end of the dependency
+	// level being reconciled, when the creations are batched.
+	pendingCreates []pendingCreate
 }
 
 // reconcile performs the reconciliation of the instance and its sub-resources.
@@ -69,6 +115,13 @@ func (igr *instanceGraphReconciler) reconcile(ctx context.Context) error {
 		return igr.handleReconciliation(ctx, igr.handleInstanceDeletion)
 	}
 
+	// Paused instances are left untouched, status included, until they are
+	// unpaused. Unpausing updates the instance, triggering a reconciliation.
+	if igr.pausable && isPaused(instance) {
+		igr.log.V(1).Info("Instance reconciliation is paused")
+		return nil
+	}
+
 	return igr.handleReconciliation(ctx, igr.reconcileInstance)
 }
 
@@ -78,9 +131,17 @@ func (igr *instanceGraphReconciler) handleReconciliation(ctx context.Context, re
 	defer func() {
 		// Update instance state based on reconciliation result
 		igr.updateInstanceState()
+		igr.recordReconcileError()
+		igr.updateManagedObjects()
 
 		// Prepare and patch status
 		status := igr.prepareStatus()
+		if err := igr.reconcileSensitiveFields(ctx, status); err != nil {
+			igr.log.Error(err, "Failed to reconcile sensitive fields")
+		}
+		if err := igr.reconcileStatusStorage(ctx, status); err != nil {
+			igr.log.Error(err, "Failed to reconcile status storage")
+		}
 		if err := igr.patchInstanceStatus(ctx, status); err != nil {
 			// Only log error if instance still exists
 			if !apierrors.IsNotFound(err) {
@@ -97,35 +158,116 @@ func (igr *instanceGraphReconciler) handleReconciliation(ctx context.Context, re
 func (igr *instanceGraphReconciler) reconcileInstance(ctx context.Context) error {
 	instance := igr.runtime.GetInstance()
 
+	// Refuse changes to the identity fields, as they would orphan the
+	// resources created with the previous values.
+	identity, err := checkIdentity(instance, igr.identityFields)
+	if err != nil {
+		igr.state.State = InstanceStateError
+		return requeue.None(err)
+	}
+
+	// Refuse instance names producing invalid resource names, before creating
+	// any resource.
+	if err := checkInstanceName(igr.runtime); err != nil {
+		igr.state.State = InstanceStateError
+		return requeue.None(err)
+	}
+
 	// Set managed state and handle instance labels
-	if err := igr.setupInstance(ctx, instance); err != nil {
+	if err := igr.setupInstance(ctx, instance, identity); err != nil {
 		return fmt.Errorf("failed to setup instance: %w", err)
 	}
 
+	// Fail fast if the impersonated service account can't create the
+	// resources, instead of getting a Forbidden error mid-apply.
+	if err := igr.preflightAccessReviews(ctx); err != nil {
+		return err
+	}
+
 	// Initialize resource states
 	for _, resourceID := range igr.runtime.TopologicalOrder() {
 		igr.state.ResourceStates[resourceID] = &ResourceState{State: "PENDING"}
 	}
 
-	// Reconcile resources in topological order
-	for _, resourceID := range igr.runtime.TopologicalOrder() {
-		if err := igr.reconcileResource(ctx, resourceID); err != nil {
+	// Reconcile resources in topological order, one dependency level at a
+	// time. When batched, the creations of a level are issued at its end.
+	for _, level := range igr.resourceLevels() {
+		for _, resourceID := range level {
+			if err := igr.reconcileResource(ctx, resourceID); err != nil {
+				igr.state.FailedResourceID = resourceID
+				// The creations deferred before the failure are issued anyway,
+				// their outcome is reported in their resource state.
+				if createErr := igr.createPendingResources(ctx); createErr != nil && !isRequeue(createErr) {
+					igr.log.Error(createErr, "Failed to create resources")
+				}
+				return err
+			}
+
+			// Synchronize runtime state after each resource
+			if err := igr.synchronize(ctx, resourceID); err != nil {
+				igr.state.FailedResourceID = resourceID
+				return withReason(ExpressionErrorReason, fmt.Errorf("failed to synchronize reconciling resource %s: %w", resourceID, err))
+			}
+		}
+
+		// The next levels depend on the resources created in this one, which
+		// can't be ready yet.
+		if err := igr.createPendingResources(ctx); err != nil {
 			return err
 		}
+	}
 
-		// Synchronize runtime state after each resource
-		if _, err := igr.runtime.Synchronize(); err != nil {
-			return fmt.Errorf("failed to synchronize reconciling resource %s: %w", resourceID, err)
+	// All the resources are applied, the status can now be computed against
+	// their live state.
+	if err := igr.synchronizeStatus(ctx); err != nil {
+		return err
+	}
+	if err := igr.checkRequiredStatusFields(); err != nil {
+		return err
+	}
+	return igr.checkPendingDeletions()
+}
+
+// synchronizeStatus refreshes the applied resources from the cluster, and
+// evaluates the instance status expressions against their live state.
+func (igr *instanceGraphReconciler) synchronizeStatus(ctx context.Context) error {
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		if igr.state.ResourceStates[resourceID].State != "SYNCED" {
+			continue
 		}
+		resource, _ := igr.runtime.GetResource(resourceID)
+		observed, err := igr.getResourceClient(resourceID).Get(ctx, resource.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if isDependencyNotFound(err) {
+				return igr.handleDependencyNotFound(ctx, resourceID, err)
+			}
+			return withReason(SubResourceApplyFailedReason, fmt.Errorf("failed to refresh resource %s: %w", resourceID, err))
+		}
+		igr.runtime.SetResource(resourceID, observed)
 	}
 
+	if err := igr.runtime.SynchronizeStatus(); err != nil {
+		return withReason(ExpressionErrorReason, fmt.Errorf("failed to synchronize instance status: %w", err))
+	}
 	return nil
 }
 
+// synchronize evaluates the CEL expressions that can be resolved after the
+// reconciliation of the given resource.
+func (igr *instanceGraphReconciler) synchronize(ctx context.Context, resourceID string) (err error) {
+	_, span := igr.tracer.Start(ctx, "Synchronize", trace.WithAttributes(
+		attributeResourceID.String(resourceID),
+	))
+	defer func() { endSpan(span, err) }()
+
+	_, err = igr.runtime.Synchronize()
+	return err
+}
+
 // setupInstance prepares an instance for reconciliation by setting up necessary
-// labels and managed state.
-func (igr *instanceGraphReconciler) setupInstance(ctx context.Context, instance *unstructured.Unstructured) error {
-	patched, err := igr.setManaged(ctx, instance, instance.GetUID())
+// labels, managed state and identity annotation.
+func (igr *instanceGraphReconciler) setupInstance(ctx context.Context, instance *unstructured.Unstructured, identity string) error {
+	patched, err := igr.setManaged(ctx, instance, instance.GetUID(), identity)
 	if err != nil {
 		return err
 	}
@@ -136,29 +278,92 @@ func (igr *instanceGraphReconciler) setupInstance(ctx context.Context, instance
 }
 
 // reconcileResource handles the reconciliation of a single resource within the instance
-func (igr *instanceGraphReconciler) reconcileResource(ctx context.Context, resourceID string) error {
+func (igr *instanceGraphReconciler) reconcileResource(ctx context.Context, resourceID string) (err error) {
+	ctx, span := igr.tracer.Start(ctx, "ReconcileResource", trace.WithAttributes(
+		attributeResourceID.String(resourceID),
+	))
+	defer func() { endSpan(span, err) }()
+
 	log := igr.log.WithValues("resourceID", resourceID)
 	resourceState := &ResourceState{State: "IN_PROGRESS"}
 	igr.state.ResourceStates[resourceID] = resourceState
 
+	// A resource without includeWhen expressions isn't silently excluded
+	// with its dependencies if the policy says otherwise.
+	if err := igr.checkExcludedDependencies(resourceID, resourceState); err != nil {
+		return err
+	}
+
 	// Check if resource should be created
 	if want, err := igr.runtime.WantToCreateResource(resourceID); err != nil || !want {
 		log.V(1).Info("Skipping resource creation", "reason", err)
 		resourceState.State = "SKIPPED"
+		// The resource may have been created before being excluded.
+		if err := igr.reconcileExcludedResource(ctx, resourceID); err != nil {
+			return err
+		}
 		igr.runtime.IgnoreResource(resourceID)
 		return nil
 	}
 
+	// Don't apply the resource before the resources it waits for are ready
+	if err := igr.checkWaitFor(resourceID, resourceState); err != nil {
+		return err
+	}
+
 	// Get and validate resource state
 	resource, state := igr.runtime.GetResource(resourceID)
 	if state != runtime.ResourceStateResolved {
-		return igr.delayedRequeue(fmt.Errorf("resource %s not resolved: state=%v", resourceID, state))
+		return igr.delayedRequeue(withReason(DependencyNotReadyReason, fmt.Errorf("resource %s not resolved: state=%v", resourceID, state)))
+	}
+
+	// Refuse invalid names computed from expressions, the resource can't be
+	// applied until the expressions inputs are fixed.
+	if err := checkResourceName(resourceID, igr.runtime.ResourceDescriptor(resourceID), resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = err
+		return requeue.None(err)
+	}
+
+	// Two resources rendered as the same object would overwrite each other.
+	if err := igr.checkNameCollision(resourceID, resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = err
+		return requeue.None(err)
+	}
+
+	// Don't apply a resource inconsistent with the other rendered resources,
+	// e.g a Service not selecting the pods of its Deployment.
+	if err := igr.runtime.CheckConstraints(resourceID); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = err
+		return requeue.None(err)
 	}
 
 	// Handle resource reconciliation
 	return igr.handleResourceReconciliation(ctx, resourceID, resource, resourceState)
 }
 
+// checkWaitFor returns an error, requeuing the instance, if one of the resources
+// listed in the waitFor of the given resource isn't ready yet.
+func (igr *instanceGraphReconciler) checkWaitFor(resourceID string, resourceState *ResourceState) error {
+	for _, dependency := range igr.runtime.ResourceDescriptor(resourceID).GetWaitFor() {
+		ready, reason, err := igr.runtime.IsResourceReady(dependency)
+		if err == nil && ready {
+			continue
+		}
+		igr.log.V(1).Info("Waiting for resource to be ready", "resourceID", resourceID, "waitFor", dependency, "reason", reason)
+		resourceState.State = "WAITING_FOR_DEPENDENCY"
+		if err != nil {
+			resourceState.Err = withReason(ExpressionErrorReason, fmt.Errorf("waiting for resource %s to be ready: %w", dependency, err))
+		} else {
+			resourceState.Err = withReason(DependencyNotReadyReason, fmt.Errorf("waiting for resource %s to be ready: %s", dependency, reason))
+		}
+		return igr.delayedRequeue(resourceState.Err)
+	}
+	return nil
+}
+
 // handleResourceReconciliation manages the reconciliation of a specific resource,
 // including creation, updates, and readiness checks.
 func (igr *instanceGraphReconciler) handleResourceReconciliation(
@@ -169,33 +374,60 @@ func (igr *instanceGraphReconciler) handleResourceReconciliation(
 ) error {
 	log := igr.log.WithValues("resourceID", resourceID)
 
+	if err := igr.injectConfigHash(resourceID, resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
+		return resourceState.Err
+	}
+
 	// Get resource client and namespace
 	rc := igr.getResourceClient(resourceID)
 
 	// Check if resource exists
 	observed, err := rc.Get(ctx, resource.GetName(), metav1.GetOptions{})
 	if err != nil {
+		if isResourceTypeNotServed(err) {
+			return igr.handleResourceTypeNotServed(resourceID, err, resourceState)
+		}
 		if apierrors.IsNotFound(err) {
 			return igr.handleResourceCreation(ctx, rc, resource, resourceID, resourceState)
 		}
 		resourceState.State = "ERROR"
-		resourceState.Err = fmt.Errorf("failed to get resource: %w", err)
+		resourceState.Err = withReason(SubResourceApplyFailedReason, fmt.Errorf("failed to get resource: %w", err))
 		return resourceState.Err
 	}
+	igr.resourceTypeWaits.forget(igr.resourceTypeWaitKey(resourceID))
 
 	// Update runtime with observed state
 	igr.runtime.SetResource(resourceID, observed)
 
+	// Re-apply the resource if its desired state changed since it was last
+	// applied, before its readiness is checked against the new state.
+	if err := igr.updateResource(ctx, rc, resource, observed, resourceID, resourceState); err != nil {
+		return err
+	}
+
 	// Check resource readiness
 	if ready, reason, err := igr.runtime.IsResourceReady(resourceID); err != nil || !ready {
 		log.V(1).Info("Resource not ready", "reason", reason, "error", err)
+		igr.resetReadySince(resourceID)
 		resourceState.State = "WAITING_FOR_READINESS"
-		resourceState.Err = fmt.Errorf("resource not ready: %s: %w", reason, err)
+		// An error means the readyWhen expressions couldn't be evaluated.
+		conditionReason := DependencyNotReadyReason
+		if err != nil {
+			conditionReason = ExpressionErrorReason
+		}
+		resourceState.Err = withReason(conditionReason, fmt.Errorf("resource not ready: %s: %w", reason, err))
 		return igr.delayedRequeue(resourceState.Err)
 	}
 
+	// Don't proceed downstream before the resource has been ready long enough
+	if err := igr.checkMinReadyDuration(resourceID, resourceState); err != nil {
+		return err
+	}
+
 	resourceState.State = "SYNCED"
-	return igr.updateResource(ctx, rc, resource, observed, resourceID, resourceState)
+	return nil
 }
 
 // getResourceClient returns the appropriate dynamic client and namespace for a resource
@@ -220,34 +452,151 @@ func (igr *instanceGraphReconciler) handleResourceCreation(
 ) error {
 	igr.log.V(1).Info("Creating new resource", "resourceID", resourceID)
 
-	// Apply labels and create resource
+	// Hold the creation if the controller manages too many objects already
+	if err := igr.checkManagedObjectLimit(resourceID, resourceState); err != nil {
+		return err
+	}
+
+	// Apply labels and finalizers and create resource
 	igr.instanceSubResourcesLabeler.ApplyLabels(resource)
-	if _, err := rc.Create(ctx, resource, metav1.CreateOptions{}); err != nil {
+	addFinalizers(resource, igr.runtime.ResourceDescriptor(resourceID).GetFinalizers())
+	if err := setSpecHash(resource); err != nil {
 		resourceState.State = "ERROR"
-		resourceState.Err = fmt.Errorf("failed to create resource: %w", err)
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
 		return resourceState.Err
 	}
+	if err := setAppliedFields(resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
+		return resourceState.Err
+	}
+	if igr.batchingCreates() {
+		igr.pendingCreates = append(igr.pendingCreates, pendingCreate{
+			resourceID: resourceID,
+			rc:         rc,
+			resource:   resource,
+			state:      resourceState,
+		})
+		resourceState.State = "PENDING_CREATION"
+		return nil
+	}
+
+	_, err := igr.createResource(ctx, rc, resource, resourceID)
+	return igr.handleResourceCreated(resourceID, err, resourceState)
+}
+
+// createResource creates the given resource, once allowed by the rate limit
+// of the resource group creations.
+func (igr *instanceGraphReconciler) createResource(
+	ctx context.Context,
+	rc dynamic.ResourceInterface,
+	resource *unstructured.Unstructured,
+	resourceID string,
+) (*unstructured.Unstructured, error) {
+	if igr.createLimiter != nil {
+		if err := igr.createLimiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return rc.Create(ctx, resource, metav1.CreateOptions{FieldManager: mergePatchFieldManager(resourceID)})
+}
+
+// fieldManagerPrefix is the prefix of the field managers of the resources.
+const fieldManagerPrefix = "kro/"
+
+// maxFieldManagerLength is the maximum length of a field manager accepted by
+// the API server.
+const maxFieldManagerLength = 128
+
+// mergePatchFieldManager returns the field manager kro creates and merge
+// patches a resource with. It includes the resource ID, so that the managed
+// fields of an object written by several resources, e.g. adopted by a resource
+// of another resource group, are attributed to each of them.
+//
+// kro doesn't use server-side apply: the fields are recorded as written by an
+// Update operation of the manager, they aren't owned in the server-side apply
+// sense, and the conflicts with the other managers aren't detected.
+func mergePatchFieldManager(resourceID string) string {
+	manager := fieldManagerPrefix + resourceID
+	if len(manager) > maxFieldManagerLength {
+		manager = manager[:maxFieldManagerLength]
+	}
+	return manager
+}
+
+// handleResourceCreated updates the state of a resource after its creation,
+// failed when err is not nil.
+func (igr *instanceGraphReconciler) handleResourceCreated(resourceID string, err error, resourceState *ResourceState) error {
+	if err != nil {
+		if isResourceTypeNotServed(err) {
+			return igr.handleResourceTypeNotServed(resourceID, err, resourceState)
+		}
+		if isServerRejection(err) {
+			return igr.handleServerRejection(resourceID, "creation", err, resourceState)
+		}
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, fmt.Errorf("failed to create resource: %w", err))
+		return resourceState.Err
+	}
+
+	igr.resourceTypeWaits.forget(igr.resourceTypeWaitKey(resourceID))
 
 	resourceState.State = "CREATED"
-	return igr.delayedRequeue(fmt.Errorf("awaiting resource creation completion"))
+	return igr.delayedRequeue(withReason(DependencyNotReadyReason, fmt.Errorf("awaiting resource creation completion")))
 }
 
-// updateResource handles updates to an existing resource.
-// Currently performs basic state management, but could be extended to include
-// more sophisticated update logic and diffing.
+// updateResource handles updates to an existing resource. The resource is
+// only re-applied if its desired state changed since it was last applied, as
+// recorded by its spec hash annotation. It is merge patched, leaving untouched
+// the fields it doesn't set, but the fields kro applied before and that are no
+// longer desired, see specPatch.
 func (igr *instanceGraphReconciler) updateResource(
-	_ context.Context,
-	_ dynamic.ResourceInterface,
-	_, _ *unstructured.Unstructured,
+	ctx context.Context,
+	rc dynamic.ResourceInterface,
+	resource, observed *unstructured.Unstructured,
 	resourceID string,
 	resourceState *ResourceState,
 ) error {
 	igr.log.V(1).Info("Processing potential resource update", "resourceID", resourceID)
 
-	// TODO: Implement resource diffing logic
-	// TODO: Add update strategy options (e.g., server-side apply)
+	igr.instanceSubResourcesLabeler.ApplyLabels(resource)
+	addFinalizers(resource, igr.runtime.ResourceDescriptor(resourceID).GetFinalizers())
+	if err := setSpecHash(resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
+		return resourceState.Err
+	}
+	if !specChanged(resource, observed) {
+		igr.log.V(1).Info("Skipping unchanged resource", "resourceID", resourceID)
+		return nil
+	}
+	if err := setAppliedFields(resource); err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
+		return resourceState.Err
+	}
+	preserveFinalizers(resource, observed)
 
-	resourceState.State = "SYNCED"
+	// TODO: Add update strategy options (e.g., server-side apply)
+	igr.log.V(1).Info("Applying changed resource", "resourceID", resourceID)
+	patch, err := specPatch(resource, observed)
+	if err != nil {
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, err)
+		return resourceState.Err
+	}
+	updated, err := rc.Patch(ctx, resource.GetName(), types.MergePatchType, patch,
+		metav1.PatchOptions{FieldManager: mergePatchFieldManager(resourceID)})
+	if err != nil {
+		if isServerRejection(err) {
+			return igr.handleServerRejection(resourceID, "update", err, resourceState)
+		}
+		resourceState.State = "ERROR"
+		resourceState.Err = withReason(SubResourceApplyFailedReason, fmt.Errorf("failed to update resource: %w", err))
+		return resourceState.Err
+	}
+	igr.runtime.SetResource(resourceID, updated)
+	resourceState.Updated = true
 	return nil
 }
 
@@ -275,7 +624,7 @@ func (igr *instanceGraphReconciler) handleInstanceDeletion(ctx context.Context)
 func (igr *instanceGraphReconciler) initializeDeletionState() error {
 	for _, resourceID := range igr.runtime.TopologicalOrder() {
 		if _, err := igr.runtime.Synchronize(); err != nil {
-			return fmt.Errorf("failed to synchronize during deletion state initialization: %w", err)
+			return withReason(ExpressionErrorReason, fmt.Errorf("failed to synchronize during deletion state initialization: %w", err))
 		}
 
 		resource, state := igr.runtime.GetResource(resourceID)
@@ -296,7 +645,7 @@ func (igr *instanceGraphReconciler) initializeDeletionState() error {
 				}
 				continue
 			}
-			return fmt.Errorf("failed to check resource %s existence: %w", resourceID, err)
+			return withReason(SubResourceDeleteFailedReason, fmt.Errorf("failed to check resource %s existence: %w", resourceID, err))
 		}
 
 		igr.runtime.SetResource(resourceID, observed)
@@ -321,6 +670,7 @@ func (igr *instanceGraphReconciler) deleteResourcesInOrder(ctx context.Context)
 		}
 
 		if err := igr.deleteResource(ctx, resourceID); err != nil {
+			igr.state.FailedResourceID = resourceID
 			return err
 		}
 	}
@@ -334,6 +684,15 @@ func (igr *instanceGraphReconciler) deleteResource(ctx context.Context, resource
 	resource, _ := igr.runtime.GetResource(resourceID)
 	rc := igr.getResourceClient(resourceID)
 
+	// Release the finalizers kro manages on the resource, the others are
+	// left to their controllers.
+	finalizers := igr.runtime.ResourceDescriptor(resourceID).GetFinalizers()
+	if err := removeFinalizers(ctx, rc, resource, finalizers); err != nil && !apierrors.IsNotFound(err) {
+		igr.state.ResourceStates[resourceID].State = InstanceStateError
+		igr.state.ResourceStates[resourceID].Err = withReason(SubResourceDeleteFailedReason, err)
+		return igr.state.ResourceStates[resourceID].Err
+	}
+
 	// Attempt to delete the resource
 	err := rc.Delete(ctx, resource.GetName(), metav1.DeleteOptions{})
 	if err != nil {
@@ -342,12 +701,12 @@ func (igr *instanceGraphReconciler) deleteResource(ctx context.Context, resource
 			return nil
 		}
 		igr.state.ResourceStates[resourceID].State = InstanceStateError
-		igr.state.ResourceStates[resourceID].Err = fmt.Errorf("failed to delete resource: %w", err)
+		igr.state.ResourceStates[resourceID].Err = withReason(SubResourceDeleteFailedReason, fmt.Errorf("failed to delete resource: %w", err))
 		return igr.state.ResourceStates[resourceID].Err
 	}
 
 	igr.state.ResourceStates[resourceID].State = InstanceStateDeleting
-	return igr.delayedRequeue(fmt.Errorf("resource deletion in progress"))
+	return igr.delayedRequeue(withReason(DeletingReason, fmt.Errorf("resource deletion in progress")))
 }
 
 // finalizeDeletion checks if all resources are deleted and removes the instance finalizer
@@ -356,7 +715,7 @@ func (igr *instanceGraphReconciler) finalizeDeletion(ctx context.Context) error
 	// Check if all resources are deleted
 	for _, resourceState := range igr.state.ResourceStates {
 		if resourceState.State != "DELETED" && resourceState.State != "SKIPPED" {
-			return igr.delayedRequeue(fmt.Errorf("waiting for resource deletion completion"))
+			return igr.delayedRequeue(withReason(DeletingReason, fmt.Errorf("waiting for resource deletion completion")))
 		}
 	}
 
@@ -366,25 +725,43 @@ func (igr *instanceGraphReconciler) finalizeDeletion(ctx context.Context) error
 	if err != nil {
 		return fmt.Errorf("failed to remove instance finalizer: %w", err)
 	}
+	igr.reconcileConfig.ManagedObjects.forget(igr.gvr, instance.GetUID())
 
 	igr.runtime.SetInstance(patched)
 	return nil
 }
 
-// setManaged ensures the instance has the necessary finalizer and labels.
-func (igr *instanceGraphReconciler) setManaged(ctx context.Context, obj *unstructured.Unstructured, uid types.UID) (*unstructured.Unstructured, error) {
-	if exist, _ := metadata.HasInstanceFinalizerUnstructured(obj, uid); exist {
+// setManaged ensures the instance has the necessary finalizer and labels, and
+// records the values of its identity fields, if any.
+func (igr *instanceGraphReconciler) setManaged(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	uid types.UID,
+	identity string,
+) (*unstructured.Unstructured, error) {
+	hasFinalizer, _ := metadata.HasInstanceFinalizerUnstructured(obj, uid)
+	identityRecorded := identity == "" || obj.GetAnnotations()[metadata.IdentityAnnotation] == identity
+	if hasFinalizer && identityRecorded {
 		return obj, nil
 	}
 
 	igr.log.V(1).Info("Setting managed state", "name", obj.GetName(), "namespace", obj.GetNamespace())
 
 	copy := obj.DeepCopy()
-	if err := metadata.SetInstanceFinalizerUnstructured(copy, uid); err != nil {
-		return nil, fmt.Errorf("failed to set finalizer: %w", err)
+	if !hasFinalizer {
+		if err := metadata.SetInstanceFinalizerUnstructured(copy, uid); err != nil {
+			return nil, fmt.Errorf("failed to set finalizer: %w", err)
+		}
+		igr.instanceLabeler.ApplyLabels(copy)
+	}
+	if !identityRecorded {
+		annotations := copy.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[metadata.IdentityAnnotation] = identity
+		copy.SetAnnotations(annotations)
 	}
-
-	igr.instanceLabeler.ApplyLabels(copy)
 
 	updated, err := igr.client.Resource(igr.gvr).
 		Namespace(obj.GetNamespace()).
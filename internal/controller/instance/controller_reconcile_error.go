@@ -0,0 +1,196 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"errors"
+	"slices"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// waitingReasons are the reasons of the errors reported while the instance
+// waits for something to happen, e.g a resource to be ready. They are part of
+// a normal reconciliation, not failures.
+var waitingReasons = []string{
+	DependencyNotReadyReason,
+	DeletingReason,
+	DependencyNotFoundReason,
+	ResourceTypeNotServedReason,
+	PendingDeletionReason,
+	ManagedObjectLimitReason,
+}
+
+const (
+	// ReconcileStageParse is the stage of the failures refusing the instance,
+	// or the resources rendered from it, before applying anything, e.g an
+	// invalid resource name or a violated constraint.
+	ReconcileStageParse = "parse"
+	// ReconcileStageEvaluate is the stage of the failures to evaluate the
+	// expressions of the resource group.
+	ReconcileStageEvaluate = "evaluate"
+	// ReconcileStageApply is the stage of the failures to apply the resources
+	// to the cluster, or to delete them. The failures of an unknown stage are
+	// reported as such, most come from the API server.
+	ReconcileStageApply = "apply"
+)
+
+// maxReconcileErrorMessageLength is the maximum length, in bytes, of the
+// message reported in the status.lastReconcileError field. Longer messages,
+// e.g embedding a large object, are truncated to keep the status small.
+const maxReconcileErrorMessageLength = 1024
+
+// reconcileError describes the failure of a reconciliation, as reported in the
+// status.lastReconcileError field of the instance.
+type reconcileError struct {
+	// resource is the ID of the resource the failure relates to, if any.
+	resource string
+	// path is the path of the field whose expression failed to evaluate, if
+	// any.
+	path   string
+	reason string
+	// stage is the stage of the reconciliation that failed, one of the
+	// ReconcileStage constants.
+	stage string
+	// message is the full error message.
+	message string
+}
+
+// lastReconcileError returns the failure of the reconciliation, or nil if the
+// reconciliation succeeded or is waiting for something to happen.
+func (igr *instanceGraphReconciler) lastReconcileError() *reconcileError {
+	if igr.state.ReconcileErr == nil {
+		return nil
+	}
+	reason, message := errorReason(igr.state.ReconcileErr)
+	if slices.Contains(waitingReasons, reason) {
+		return nil
+	}
+
+	failure := &reconcileError{
+		resource: igr.state.FailedResourceID,
+		reason:   reason,
+		stage:    reconcileStage(reason, igr.state.ReconcileErr),
+		message:  message,
+	}
+	// The expression failing to evaluate can belong to another resource than
+	// the one being reconciled, e.g one depending on it.
+	var evalErr *runtime.EvalError
+	if errors.As(igr.state.ReconcileErr, &evalErr) && evalErr.Expression != "" {
+		if resourceID, path, ok := igr.findExpression(evalErr.Expression); ok {
+			failure.resource = resourceID
+			failure.path = path
+		}
+	}
+	return failure
+}
+
+// reconcileStage returns the stage of the reconciliation the given error,
+// of the given reason, failed at.
+func reconcileStage(reason string, err error) string {
+	var evalErr *runtime.EvalError
+	if errors.As(err, &evalErr) {
+		return ReconcileStageEvaluate
+	}
+	var constraintErr *runtime.ConstraintViolationError
+	if errors.As(err, &constraintErr) {
+		return ReconcileStageParse
+	}
+	switch reason {
+	case ExpressionErrorReason, RequiredStatusNotResolvedReason:
+		return ReconcileStageEvaluate
+	case IdentityFieldChangedReason, InstanceNameTooLongReason, InvalidResourceNameReason,
+		ResourceNameCollisionReason, ExcludedDependencyReason:
+		return ReconcileStageParse
+	default:
+		return ReconcileStageApply
+	}
+}
+
+// truncateMessage truncates the given message to maxLength bytes, without
+// splitting a character, marking the truncation with an ellipsis.
+func truncateMessage(message string, maxLength int) string {
+	const ellipsis = "..."
+	if len(message) <= maxLength {
+		return message
+	}
+	end := maxLength - len(ellipsis)
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end] + ellipsis
+}
+
+// findExpression returns the ID of the resource and the path of the field
+// holding the given expression.
+func (igr *instanceGraphReconciler) findExpression(expression string) (string, string, bool) {
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		for _, variable := range igr.runtime.ResourceDescriptor(resourceID).GetVariables() {
+			if slices.Contains(variable.Expressions, expression) {
+				return resourceID, variable.Path, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// prepareLastReconcileError sets the status.lastReconcileError field of the
+// given status on failure, and clears it on success. It is left unchanged
+// while the instance waits for something to happen.
+func (igr *instanceGraphReconciler) prepareLastReconcileError(status map[string]interface{}) {
+	if igr.state.ReconcileErr == nil {
+		delete(status, "lastReconcileError")
+		return
+	}
+	failure := igr.lastReconcileError()
+	if failure == nil {
+		return
+	}
+	lastReconcileError := map[string]interface{}{
+		"stage":   failure.stage,
+		"message": truncateMessage(failure.message, maxReconcileErrorMessageLength),
+	}
+	if failure.resource != "" {
+		lastReconcileError["resource"] = failure.resource
+	}
+	if failure.path != "" {
+		lastReconcileError["path"] = failure.path
+	}
+	status["lastReconcileError"] = lastReconcileError
+}
+
+// recordReconcileError records the failure of the reconciliation, if any, as a
+// Warning event on the instance, so that it shows up in kubectl describe.
+func (igr *instanceGraphReconciler) recordReconcileError() {
+	if igr.recorder == nil {
+		return
+	}
+	failure := igr.lastReconcileError()
+	if failure == nil {
+		return
+	}
+	switch {
+	case failure.resource != "" && failure.path != "":
+		igr.recorder.Eventf(igr.runtime.GetInstance(), corev1.EventTypeWarning, failure.reason,
+			"resource %s, field %s: %s", failure.resource, failure.path, failure.message)
+	case failure.resource != "":
+		igr.recorder.Eventf(igr.runtime.GetInstance(), corev1.EventTypeWarning, failure.reason,
+			"resource %s: %s", failure.resource, failure.message)
+	default:
+		igr.recorder.Event(igr.runtime.GetInstance(), corev1.EventTypeWarning, failure.reason, failure.message)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// evalErrorRuntime is a fake runtime in which the "app" resource data.level
+// field is computed from the "config" resource, failing to evaluate while
+// err is set.
+type evalErrorRuntime struct {
+	*fakeRuntime
+	err error
+}
+
+// evalErrorDescriptor describes the resource computed from the config.
+type evalErrorDescriptor struct {
+	fakeDescriptor
+}
+
+func (evalErrorDescriptor) GetVariables() []*variable.ResourceField {
+	return []*variable.ResourceField{{
+		FieldDescriptor: variable.FieldDescriptor{
+			Path:        "data.level",
+			Expressions: []string{"config.data.level"},
+		},
+		Kind:         variable.ResourceVariableKindDynamic,
+		Dependencies: []string{"config"},
+	}}
+}
+
+func (r *evalErrorRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	if id == "app" {
+		return evalErrorDescriptor{fakeDescriptor{gvr: testConfigMapGVR}}
+	}
+	return r.fakeRuntime.ResourceDescriptor(id)
+}
+
+func (r *evalErrorRuntime) Synchronize() (bool, error) {
+	if r.err != nil {
+		return true, &runtime.EvalError{Expression: "config.data.level", Err: r.err}
+	}
+	return false, nil
+}
+
+func TestReconcileLastReconcileError(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	config := newTestObject("v1", "ConfigMap", "config")
+	app := newTestObject("v1", "ConfigMap", "app")
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		config.DeepCopy(),
+		app.DeepCopy(),
+	)
+	recorder := record.NewFakeRecorder(10)
+	rt := &evalErrorRuntime{
+		fakeRuntime: &fakeRuntime{
+			instance:  instance,
+			order:     []string{"config", "app"},
+			resources: map[string]*unstructured.Unstructured{"config": config, "app": app},
+		},
+		err: errors.New("no such key: level"),
+	}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      client,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+		recorder:                    recorder,
+	}
+	ctx := context.Background()
+	getInstance := func(t *testing.T) *unstructured.Unstructured {
+		observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(ctx, "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		return observed
+	}
+
+	// The expression of the app fails to evaluate once the config is applied.
+	require.Error(t, igr.reconcile(ctx))
+
+	observed := getInstance(t)
+	lastReconcileError, found, err := unstructured.NestedStringMap(observed.Object, "status", "lastReconcileError")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "app", lastReconcileError["resource"])
+	assert.Equal(t, "data.level", lastReconcileError["path"])
+	assert.Equal(t, ReconcileStageEvaluate, lastReconcileError["stage"])
+	assert.Contains(t, lastReconcileError["message"], "no such key: level")
+
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	assert.Contains(t, event, "Warning ExpressionError resource app, field data.level: ")
+	assert.Contains(t, event, "no such key: level")
+
+	// The field is cleared once the instance reconciles successfully, and no
+	// event is recorded.
+	rt.err = nil
+	rt.instance = observed
+	require.NoError(t, igr.reconcile(ctx))
+
+	observed = getInstance(t)
+	_, found, err = unstructured.NestedFieldNoCopy(observed.Object, "status", "lastReconcileError")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestLastReconcileErrorWhileWaiting(t *testing.T) {
+	igr := &instanceGraphReconciler{
+		runtime: &fakeRuntime{},
+		state:   newInstanceState(),
+	}
+	previous := map[string]interface{}{"resource": "app", "message": "failed to create resource"}
+	status := map[string]interface{}{"lastReconcileError": previous}
+
+	// Waiting for a resource isn't a failure, the last failure is kept.
+	igr.state.ReconcileErr = igr.delayedRequeue(withReason(DependencyNotReadyReason, errors.New("awaiting resource creation completion")))
+	igr.state.FailedResourceID = "database"
+	assert.Nil(t, igr.lastReconcileError())
+	igr.prepareLastReconcileError(status)
+	assert.Equal(t, previous, status["lastReconcileError"])
+
+	igr.state.ReconcileErr = withReason(SubResourceApplyFailedReason, errors.New("failed to create resource: forbidden"))
+	igr.prepareLastReconcileError(status)
+	assert.Equal(t, map[string]interface{}{
+		"resource": "database",
+		"stage":    ReconcileStageApply,
+		"message":  "failed to create resource: forbidden",
+	}, status["lastReconcileError"])
+}
+
+func TestReconcileStage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "expression failing to evaluate",
+			err:  &runtime.EvalError{Expression: "config.data.level", Err: errors.New("no such key: level")},
+			want: ReconcileStageEvaluate,
+		},
+		{
+			name: "expression error",
+			err:  withReason(ExpressionErrorReason, errors.New("failed to synchronize")),
+			want: ReconcileStageEvaluate,
+		},
+		{
+			name: "invalid resource name",
+			err:  withReason(InvalidResourceNameReason, errors.New("invalid name")),
+			want: ReconcileStageParse,
+		},
+		{
+			name: "violated constraint",
+			err:  &runtime.ConstraintViolationError{Expression: "service.spec.selector == deployment.spec.selector"},
+			want: ReconcileStageParse,
+		},
+		{
+			name: "resource failing to apply",
+			err:  withReason(SubResourceApplyFailedReason, errors.New("failed to create resource: forbidden")),
+			want: ReconcileStageApply,
+		},
+		{
+			name: "unknown failure",
+			err:  errors.New("failed to setup instance"),
+			want: ReconcileStageApply,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, _ := errorReason(tt.err)
+			assert.Equal(t, tt.want, reconcileStage(reason, tt.err))
+		})
+	}
+}
+
+func TestLastReconcileErrorTruncated(t *testing.T) {
+	igr := &instanceGraphReconciler{
+		runtime: &fakeRuntime{},
+		state:   newInstanceState(),
+	}
+	message := "failed to create resource: " + strings.Repeat("é", maxReconcileErrorMessageLength)
+	igr.state.ReconcileErr = withReason(SubResourceApplyFailedReason, errors.New(message))
+	status := map[string]interface{}{}
+	igr.prepareLastReconcileError(status)
+
+	got := status["lastReconcileError"].(map[string]interface{})["message"].(string)
+	assert.LessOrEqual(t, len(got), maxReconcileErrorMessageLength)
+	assert.True(t, utf8.ValidString(got))
+	assert.True(t, strings.HasPrefix(got, "failed to create resource: é"))
+	assert.True(t, strings.HasSuffix(got, "..."))
+
+	assert.Equal(t, "short", truncateMessage("short", maxReconcileErrorMessageLength))
+}
@@ -0,0 +1,54 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import "time"
+
+// prepareReconcileSummary sets the status.reconcileSummary field of the given
+// status, counting the resources created, updated, skipped and failed during
+// the reconciliation. The time of the last successful reconciliation is only
+// moved forward when the reconciliation succeeds. The summary is left
+// unchanged while the instance is deleted.
+func (igr *instanceGraphReconciler) prepareReconcileSummary(status map[string]interface{}, now time.Time) {
+	if igr.state.State == InstanceStateDeleting {
+		return
+	}
+	var created, updated, skipped, failed int64
+	for _, resourceState := range igr.state.ResourceStates {
+		switch {
+		case resourceState.State == "CREATED":
+			created++
+		case resourceState.State == "SKIPPED":
+			skipped++
+		case resourceState.State == "ERROR":
+			failed++
+		case resourceState.Updated:
+			updated++
+		}
+	}
+	summary := map[string]interface{}{
+		"created": created,
+		"updated": updated,
+		"skipped": skipped,
+		"failed":  failed,
+	}
+	if igr.state.ReconcileErr == nil {
+		summary["lastSuccessfulReconcileTime"] = now.Format(time.RFC3339)
+	} else if previous, ok := status["reconcileSummary"].(map[string]interface{}); ok {
+		if lastSuccess, ok := previous["lastSuccessfulReconcileTime"]; ok {
+			summary["lastSuccessfulReconcileTime"] = lastSuccess
+		}
+	}
+	status["reconcileSummary"] = summary
+}
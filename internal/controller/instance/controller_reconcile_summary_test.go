@@ -0,0 +1,156 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// skippingRuntime is a fake runtime excluding its "optional" resource.
+type skippingRuntime struct {
+	*fakeRuntime
+}
+
+func (r *skippingRuntime) WantToCreateResource(id string) (bool, error) {
+	return id != "optional", nil
+}
+
+func TestReconcileSummary(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	config := newTestObject("v1", "ConfigMap", "config")
+	app := newTestObject("v1", "ConfigMap", "app")
+
+	// The config exists but was never applied by kro, the app doesn't exist.
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		config.DeepCopy(),
+	)
+	rt := &skippingRuntime{fakeRuntime: &fakeRuntime{
+		instance:  instance,
+		order:     []string{"config", "optional", "app"},
+		resources: map[string]*unstructured.Unstructured{"config": config, "app": app},
+	}}
+	newReconciler := func() *instanceGraphReconciler {
+		return &instanceGraphReconciler{
+			log:                         logr.Discard(),
+			gvr:                         testInstanceGVR,
+			client:                      client,
+			runtime:                     rt,
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			state:                       newInstanceState(),
+			tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+		}
+	}
+	ctx := context.Background()
+	getSummary := func(t *testing.T) map[string]interface{} {
+		observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(ctx, "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		rt.instance = observed
+		summary, found, err := unstructured.NestedMap(observed.Object, "status", "reconcileSummary")
+		require.NoError(t, err)
+		require.True(t, found)
+		return summary
+	}
+
+	// The config is updated, the optional resource skipped and the app
+	// created, the reconciliation then waits for the app.
+	require.Error(t, newReconciler().reconcile(ctx))
+	assert.Equal(t, map[string]interface{}{
+		"created": int64(1),
+		"updated": int64(1),
+		"skipped": int64(1),
+		"failed":  int64(0),
+	}, getSummary(t))
+
+	// Nothing changes once everything is applied, and the reconciliation
+	// succeeds.
+	require.NoError(t, newReconciler().reconcile(ctx))
+	summary := getSummary(t)
+	lastSuccess, ok := summary["lastSuccessfulReconcileTime"].(string)
+	require.True(t, ok)
+	_, err := time.Parse(time.RFC3339, lastSuccess)
+	require.NoError(t, err)
+	delete(summary, "lastSuccessfulReconcileTime")
+	assert.Equal(t, map[string]interface{}{
+		"created": int64(0),
+		"updated": int64(0),
+		"skipped": int64(1),
+		"failed":  int64(0),
+	}, summary)
+}
+
+func TestPrepareReconcileSummary(t *testing.T) {
+	lastSuccess := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := lastSuccess.Add(time.Hour)
+	igr := &instanceGraphReconciler{state: newInstanceState()}
+	igr.state.ResourceStates = map[string]*ResourceState{
+		"vpc":        {State: "SYNCED"},
+		"subnet":     {State: "SYNCED", Updated: true},
+		"cluster":    {State: "WAITING_FOR_READINESS", Updated: true},
+		"database":   {State: "CREATED"},
+		"monitoring": {State: "SKIPPED"},
+		"bucket":     {State: "ERROR", Err: errors.New("failed to update resource: forbidden")},
+		"policy":     {State: "PENDING"},
+	}
+
+	// The time of the last successful reconciliation is kept on failure.
+	igr.state.ReconcileErr = withReason(SubResourceApplyFailedReason, errors.New("failed to update resource: forbidden"))
+	status := map[string]interface{}{
+		"reconcileSummary": map[string]interface{}{
+			"created":                     int64(3),
+			"lastSuccessfulReconcileTime": lastSuccess.Format(time.RFC3339),
+		},
+	}
+	igr.prepareReconcileSummary(status, now)
+	assert.Equal(t, map[string]interface{}{
+		"created":                     int64(1),
+		"updated":                     int64(2),
+		"skipped":                     int64(1),
+		"failed":                      int64(1),
+		"lastSuccessfulReconcileTime": lastSuccess.Format(time.RFC3339),
+	}, status["reconcileSummary"])
+
+	// And moved forward on success.
+	igr.state.ReconcileErr = nil
+	igr.prepareReconcileSummary(status, now)
+	assert.Equal(t, now.Format(time.RFC3339), status["reconcileSummary"].(map[string]interface{})["lastSuccessfulReconcileTime"])
+
+	// The summary is left unchanged during the deletion.
+	previous := status["reconcileSummary"]
+	igr.state.State = InstanceStateDeleting
+	igr.state.ResourceStates = map[string]*ResourceState{"vpc": {State: "DELETED"}}
+	igr.prepareReconcileSummary(status, now.Add(time.Hour))
+	assert.Equal(t, previous, status["reconcileSummary"])
+}
@@ -0,0 +1,274 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// statusRuntime is a fake runtime reporting the data of the config map in the
+// instance status.
+type statusRuntime struct {
+	*fakeRuntime
+	// synchronized records the number of SynchronizeStatus calls.
+	synchronized int
+}
+
+func (r *statusRuntime) SynchronizeStatus() error {
+	r.synchronized++
+	data, _, _ := unstructured.NestedString(r.resources["configmap"].Object, "data", "phase")
+	return unstructured.SetNestedField(r.instance.Object, data, "status", "phase")
+}
+
+func TestReconcileStatusAfterApply(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	configMap := newTestObject("v1", "ConfigMap", "app-config")
+	configMap.Object["data"] = map[string]interface{}{"phase": "Applying"}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		configMap.DeepCopy(),
+	)
+	// The config map is observed in its pre-apply state during the first
+	// phase, and in its live post-apply state afterwards.
+	gets := 0
+	client.PrependReactor("get", "configmaps", func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+		gets++
+		observed := configMap.DeepCopy()
+		if gets > 1 {
+			observed.Object["data"] = map[string]interface{}{"phase": "Applied"}
+		}
+		return true, observed, nil
+	})
+
+	rt := &statusRuntime{fakeRuntime: &fakeRuntime{
+		instance:  instance,
+		order:     []string{"configmap"},
+		resources: map[string]*unstructured.Unstructured{"configmap": configMap},
+	}}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      client,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	require.NoError(t, igr.reconcile(context.Background()))
+	assert.Equal(t, 1, rt.synchronized)
+	assert.Equal(t, 2, gets)
+
+	observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+	require.NoError(t, err)
+	phase, _, _ := unstructured.NestedString(observed.Object, "status", "phase")
+	assert.Equal(t, "Applied", phase)
+}
+
+// waitForRuntime is a fake runtime in which the "app" resource waits for the
+// "database" resource, whose readiness is configurable.
+type waitForRuntime struct {
+	*fakeRuntime
+	databaseReady bool
+}
+
+// waitForDescriptor describes a resource waiting for the database.
+type waitForDescriptor struct {
+	fakeDescriptor
+}
+
+func (waitForDescriptor) GetWaitFor() []string { return []string{"database"} }
+
+func (r *waitForRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	if id == "app" {
+		return waitForDescriptor{fakeDescriptor{gvr: testConfigMapGVR}}
+	}
+	return r.fakeRuntime.ResourceDescriptor(id)
+}
+
+func (r *waitForRuntime) IsResourceReady(id string) (bool, string, error) {
+	if id == "database" && !r.databaseReady {
+		return false, "expression database.status.ready evaluated to false", nil
+	}
+	return true, "", nil
+}
+
+func TestReconcileWaitFor(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	database := newTestObject("v1", "ConfigMap", "database")
+	app := newTestObject("v1", "ConfigMap", "app")
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		database.DeepCopy(),
+	)
+
+	rt := &waitForRuntime{fakeRuntime: &fakeRuntime{
+		instance:  instance,
+		order:     []string{"database", "app"},
+		resources: map[string]*unstructured.Unstructured{"database": database, "app": app},
+	}}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      client,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	ctx := context.Background()
+
+	// The app isn't applied while the database isn't ready, even though it
+	// doesn't refer to any of its fields.
+	err := igr.reconcileResource(ctx, "app")
+	require.Error(t, err)
+	var requeueErr *requeue.RequeueNeededAfter
+	require.ErrorAs(t, err, &requeueErr)
+	reason, message := errorReason(err)
+	assert.Equal(t, DependencyNotReadyReason, reason)
+	assert.Contains(t, message, "waiting for resource database to be ready")
+	assert.Equal(t, "WAITING_FOR_DEPENDENCY", igr.state.ResourceStates["app"].State)
+
+	_, err = client.Resource(testConfigMapGVR).Namespace("default").Get(ctx, "app", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "the app must not be created")
+
+	// Once the database is ready, the app is applied.
+	rt.databaseReady = true
+	err = igr.reconcileResource(ctx, "app")
+	require.ErrorAs(t, err, &requeueErr, "awaiting the creation completion")
+	assert.Equal(t, "CREATED", igr.state.ResourceStates["app"].State)
+
+	_, err = client.Resource(testConfigMapGVR).Namespace("default").Get(ctx, "app", metav1.GetOptions{})
+	require.NoError(t, err)
+}
+
+// fieldManagerClient is a dynamic client recording the field managers of the
+// creations and patches, which the fake client doesn't record.
+type fieldManagerClient struct {
+	dynamic.Interface
+	managers map[string]string
+}
+
+func (c fieldManagerClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return fieldManagerResource{c.Interface.Resource(gvr), c.managers}
+}
+
+type fieldManagerResource struct {
+	dynamic.NamespaceableResourceInterface
+	managers map[string]string
+}
+
+func (r fieldManagerResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return fieldManagerNamespacedResource{r.NamespaceableResourceInterface.Namespace(namespace), r.managers}
+}
+
+type fieldManagerNamespacedResource struct {
+	dynamic.ResourceInterface
+	managers map[string]string
+}
+
+func (r fieldManagerNamespacedResource) Create(
+	ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string,
+) (*unstructured.Unstructured, error) {
+	r.managers["create "+obj.GetName()] = options.FieldManager
+	return r.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func (r fieldManagerNamespacedResource) Patch(
+	ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string,
+) (*unstructured.Unstructured, error) {
+	r.managers["patch "+name] = options.FieldManager
+	return r.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+}
+
+func TestReconcileFieldManagers(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	database := newTestObject("v1", "ConfigMap", "database")
+	app := newTestObject("v1", "ConfigMap", "app")
+
+	// The database exists and is patched, the app is created.
+	client := fieldManagerClient{managers: map[string]string{}}
+	client.Interface = fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		database.DeepCopy(),
+	)
+
+	igr := &instanceGraphReconciler{
+		log:    logr.Discard(),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &fakeRuntime{
+			instance:  instance,
+			order:     []string{"database", "app"},
+			resources: map[string]*unstructured.Unstructured{"database": database, "app": app},
+		},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	ctx := context.Background()
+	require.NoError(t, igr.reconcileResource(ctx, "database"))
+	var requeueErr *requeue.RequeueNeededAfter
+	require.ErrorAs(t, igr.reconcileResource(ctx, "app"), &requeueErr, "awaiting the creation completion")
+
+	assert.Equal(t, map[string]string{
+		"patch database": "kro/database",
+		"create app":     "kro/app",
+	}, client.managers)
+}
+
+func TestMergePatchFieldManager(t *testing.T) {
+	assert.Equal(t, "kro/vpc", mergePatchFieldManager("vpc"))
+	// The field managers are limited to 128 characters.
+	assert.Len(t, mergePatchFieldManager(strings.Repeat("a", 200)), 128)
+}
@@ -0,0 +1,122 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// ServerRejectionPolicy defines how the instance controller handles a
+// resource the API server rejects as invalid or forbidden, e.g. denied by a
+// validating admission webhook.
+type ServerRejectionPolicy string
+
+const (
+	// ServerRejectionPolicyTerminal reports the instance in error, with the
+	// message of the API server, and doesn't retry to apply the resource
+	// until the instance changes: the same object would be rejected again.
+	// This is the default.
+	ServerRejectionPolicyTerminal ServerRejectionPolicy = "Terminal"
+	// ServerRejectionPolicyRetry retries to apply the resource with an
+	// exponential backoff, as for the other errors. It suits the rejections
+	// lifted without changing the instance, e.g. by an admission policy
+	// update.
+	ServerRejectionPolicyRetry ServerRejectionPolicy = "Retry"
+)
+
+// ParseServerRejectionPolicy parses a ServerRejectionPolicy, case
+// insensitively. An empty string is parsed as the default policy.
+func ParseServerRejectionPolicy(s string) (ServerRejectionPolicy, error) {
+	switch {
+	case s == "", strings.EqualFold(s, string(ServerRejectionPolicyTerminal)):
+		return ServerRejectionPolicyTerminal, nil
+	case strings.EqualFold(s, string(ServerRejectionPolicyRetry)):
+		return ServerRejectionPolicyRetry, nil
+	default:
+		return "", fmt.Errorf("unknown server rejection policy %q, must be one of %s, %s",
+			s, ServerRejectionPolicyTerminal, ServerRejectionPolicyRetry)
+	}
+}
+
+// ResourceRejectedReason is the reason of the InstanceSynced condition when
+// the API server rejects a resource of the instance as invalid or forbidden.
+const ResourceRejectedReason = "ResourceRejected"
+
+// resourceRejectedError is returned when the API server rejects a resource as
+// invalid or forbidden. It holds the message of the API server verbatim.
+type resourceRejectedError struct {
+	resourceID string
+	// operation is the rejected operation, e.g. create.
+	operation string
+	message   string
+	err       error
+}
+
+func (e *resourceRejectedError) Error() string {
+	return fmt.Sprintf("the API server rejected the %s of resource %s: %s", e.operation, e.resourceID, e.message)
+}
+
+func (e *resourceRejectedError) Reason() string {
+	return ResourceRejectedReason
+}
+
+func (e *resourceRejectedError) Unwrap() error {
+	return e.err
+}
+
+// isServerRejection returns true if the API server rejected a request as
+// invalid or forbidden, which retrying the same request won't fix. The
+// creations forbidden in a terminating namespace are transient.
+func isServerRejection(err error) bool {
+	if apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause) {
+		return false
+	}
+	return apierrors.IsInvalid(err) || apierrors.IsForbidden(err)
+}
+
+// serverMessage returns the message of the status returned by the API server,
+// or the error message if there is none.
+func serverMessage(err error) string {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Message != "" {
+		return status.Status().Message
+	}
+	return err.Error()
+}
+
+// handleServerRejection reports a resource rejected by the API server, and
+// decides whether to retry according to the configured ServerRejectionPolicy.
+func (igr *instanceGraphReconciler) handleServerRejection(resourceID, operation string, err error, resourceState *ResourceState) error {
+	resourceState.State = "ERROR"
+	resourceState.Err = &resourceRejectedError{
+		resourceID: resourceID,
+		operation:  operation,
+		message:    serverMessage(err),
+		err:        err,
+	}
+	if igr.reconcileConfig.ServerRejectionPolicy == ServerRejectionPolicyRetry {
+		return resourceState.Err
+	}
+	igr.log.Info("Resource rejected by the API server, not retrying until the instance changes",
+		"resourceID", resourceID, "message", serverMessage(err))
+	igr.state.State = InstanceStateError
+	return requeue.None(resourceState.Err)
+}
@@ -0,0 +1,176 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+const webhookMessage = `admission webhook "validate.example.com" denied the request: replicas must be at most 10`
+
+func TestParseServerRejectionPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ServerRejectionPolicy
+		wantErr bool
+	}{
+		{in: "", want: ServerRejectionPolicyTerminal},
+		{in: "Terminal", want: ServerRejectionPolicyTerminal},
+		{in: "retry", want: ServerRejectionPolicyRetry},
+		{in: "Ignore", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseServerRejectionPolicy(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsServerRejection(t *testing.T) {
+	gr := testConfigMapGVR.GroupResource()
+	terminating := apierrors.NewForbidden(gr, "app-config", errors.New("namespace is being terminated"))
+	terminating.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: corev1.NamespaceTerminatingCause}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "invalid",
+			err: apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, "app-config", field.ErrorList{
+				field.Invalid(field.NewPath("metadata", "name"), "App", "must be lowercase"),
+			}),
+			want: true,
+		},
+		{
+			name: "forbidden",
+			err:  apierrors.NewForbidden(gr, "app-config", errors.New(webhookMessage)),
+			want: true,
+		},
+		{
+			name: "namespace terminating",
+			err:  terminating,
+			want: false,
+		},
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(gr, "app-config", errors.New("conflict")),
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  errors.New("connection refused"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isServerRejection(tt.err))
+		})
+	}
+}
+
+func TestReconcileServerRejection(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ServerRejectionPolicy
+	}{
+		{name: "terminal", policy: ServerRejectionPolicyTerminal},
+		{name: "retry", policy: ServerRejectionPolicyRetry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+			instance.SetUID("instance-uid")
+
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(
+				k8sruntime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					testInstanceGVR:  "WebAppList",
+					testConfigMapGVR: "ConfigMapList",
+				},
+				instance.DeepCopy(),
+			)
+			client.PrependReactor("create", testConfigMapGVR.Resource, func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+				return true, nil, apierrors.NewForbidden(testConfigMapGVR.GroupResource(), "app-config", errors.New(webhookMessage))
+			})
+
+			igr := &instanceGraphReconciler{
+				log:    logr.Discard(),
+				gvr:    testInstanceGVR,
+				client: client,
+				runtime: &fakeRuntime{
+					instance: instance.DeepCopy(),
+					order:    []string{"configmap"},
+					resources: map[string]*unstructured.Unstructured{
+						"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+					},
+				},
+				instanceLabeler:             metadata.GenericLabeler{},
+				instanceSubResourcesLabeler: metadata.GenericLabeler{},
+				reconcileConfig:             ReconcileConfig{ServerRejectionPolicy: tt.policy},
+				state:                       newInstanceState(),
+				tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+				resourceTypeWaits:           newResourceTypeWaits(),
+			}
+			err := igr.reconcile(context.Background())
+			require.Error(t, err)
+
+			var noRequeueErr *requeue.NoRequeue
+			if tt.policy == ServerRejectionPolicyTerminal {
+				require.True(t, errors.As(err, &noRequeueErr), "unexpected error: %v", err)
+				assert.Equal(t, InstanceStateError, igr.state.State)
+			} else {
+				assert.False(t, errors.As(err, &noRequeueErr), "unexpected error: %v", err)
+			}
+			assert.Equal(t, "ERROR", igr.state.ResourceStates["configmap"].State)
+
+			got, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+			require.NoError(t, err)
+			conditions, _, _ := unstructured.NestedSlice(got.Object, "status", "conditions")
+			require.Len(t, conditions, 1)
+			condition := conditions[0].(map[string]interface{})
+			assert.Equal(t, "False", condition["status"])
+			assert.Equal(t, ResourceRejectedReason, condition["reason"])
+			assert.Contains(t, condition["message"], "the API server rejected the creation of resource configmap")
+			assert.Contains(t, condition["message"], webhookMessage)
+		})
+	}
+}
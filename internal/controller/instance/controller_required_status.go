@@ -0,0 +1,59 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RequiredStatusNotResolvedReason is the reason of the InstanceSynced
+// condition when required instance status fields aren't resolved yet.
+const RequiredStatusNotResolvedReason = "RequiredStatusNotResolved"
+
+// checkRequiredStatusFields returns an error, requeuing the instance, if one
+// of the required status fields couldn't be resolved. The instance stays in
+// progress until they are all resolved.
+func (igr *instanceGraphReconciler) checkRequiredStatusFields() error {
+	if len(igr.requiredStatusFields) == 0 {
+		return nil
+	}
+	unresolved := igr.runtime.UnresolvedStatusFields()
+
+	var missing []string
+	for _, field := range igr.requiredStatusFields {
+		for _, path := range unresolved {
+			if pathsOverlap(field, path) {
+				missing = append(missing, field)
+				break
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return igr.delayedRequeue(withReason(RequiredStatusNotResolvedReason,
+			fmt.Errorf("required status fields not resolved yet: %s", strings.Join(missing, ", "))))
+	}
+	return nil
+}
+
+// pathsOverlap returns true if one of the given field paths is the other, or
+// a path to one of its nested fields.
+func pathsOverlap(a, b string) bool {
+	return a == b || isNestedPath(a, b) || isNestedPath(b, a)
+}
+
+// isNestedPath returns true if path is a path to a field nested in parent.
+func isNestedPath(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
@@ -0,0 +1,138 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// requiredStatusRuntime is a fake runtime reporting the phase of the config
+// map in the instance status, once it is set. The status.endpoint field is
+// never resolved.
+type requiredStatusRuntime struct {
+	*fakeRuntime
+}
+
+func (r *requiredStatusRuntime) SynchronizeStatus() error {
+	phase, found, _ := unstructured.NestedString(r.resources["configmap"].Object, "data", "phase")
+	if !found {
+		return nil
+	}
+	return unstructured.SetNestedField(r.instance.Object, phase, "status", "phase")
+}
+
+func (r *requiredStatusRuntime) UnresolvedStatusFields() []string {
+	unresolved := []string{"status.endpoint"}
+	if _, found, _ := unstructured.NestedString(r.instance.Object, "status", "phase"); !found {
+		unresolved = append(unresolved, "status.phase")
+	}
+	return unresolved
+}
+
+// syncedCondition returns the single InstanceSynced condition of the instance.
+func syncedCondition(t *testing.T, instance *unstructured.Unstructured) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(instance.Object, "status", "conditions")
+	require.Len(t, conditions, 1)
+	condition := conditions[0].(map[string]interface{})
+	require.Equal(t, "InstanceSynced", condition["type"])
+	return condition
+}
+
+func TestReconcileRequiredStatusFields(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	configMap := newTestObject("v1", "ConfigMap", "app-config")
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		configMap.DeepCopy(),
+	)
+
+	// reconcile reconciles the instance, and returns it as stored in the
+	// cluster along with the reconciliation error.
+	reconcile := func(t *testing.T) (*unstructured.Unstructured, error) {
+		igr := &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &requiredStatusRuntime{&fakeRuntime{
+				instance:  instance.DeepCopy(),
+				order:     []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{"configmap": configMap.DeepCopy()},
+			}},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			// The status.endpoint field is optional.
+			requiredStatusFields: []string{"status.phase"},
+			state:                newInstanceState(),
+			tracer:               noop.NewTracerProvider().Tracer(tracerName),
+		}
+		reconcileErr := igr.reconcile(context.Background())
+
+		observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		return observed, reconcileErr
+	}
+
+	// The phase of the config map isn't set yet, the instance stays in
+	// progress.
+	observed, err := reconcile(t)
+	var requeueErr *requeue.RequeueNeededAfter
+	require.True(t, errors.As(err, &requeueErr), "expected a requeue, got %v", err)
+	state, _, _ := unstructured.NestedString(observed.Object, "status", "state")
+	assert.Equal(t, "IN_PROGRESS", state)
+	condition := syncedCondition(t, observed)
+	assert.Equal(t, "False", condition["status"])
+	assert.Equal(t, RequiredStatusNotResolvedReason, condition["reason"])
+	assert.Equal(t, "required status fields not resolved yet: status.phase", condition["message"])
+
+	// The phase is set and resolved, the instance is synced.
+	configMap.Object["data"] = map[string]interface{}{"phase": "Applied"}
+	observed, err = reconcile(t)
+	require.NoError(t, err)
+	state, _, _ = unstructured.NestedString(observed.Object, "status", "state")
+	assert.Equal(t, InstanceStateActive, state)
+	condition = syncedCondition(t, observed)
+	assert.Equal(t, "True", condition["status"])
+	phase, _, _ := unstructured.NestedString(observed.Object, "status", "phase")
+	assert.Equal(t, "Applied", phase)
+}
+
+func TestPathsOverlap(t *testing.T) {
+	assert.True(t, pathsOverlap("status.endpoint", "status.endpoint"))
+	assert.True(t, pathsOverlap("status.network", "status.network.id"))
+	assert.True(t, pathsOverlap("status.network.id", "status.network"))
+	assert.True(t, pathsOverlap("status.ips", "status.ips[0]"))
+	assert.False(t, pathsOverlap("status.endpoint", "status.endpoints"))
+	assert.False(t, pathsOverlap("status.network.id", "status.network.name"))
+}
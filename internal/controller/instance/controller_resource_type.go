@@ -0,0 +1,172 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+// ResourceTypeNotServedReason is the reason of the InstanceSynced condition
+// while the instance waits for the API server to serve the type of one of its
+// resources.
+const ResourceTypeNotServedReason = "WaitingForResourceType"
+
+// resourceTypeNotServedError is returned while waiting for the API server to
+// serve the type of a resource, typically right after its CRD was created.
+type resourceTypeNotServedError struct {
+	resourceID string
+	gvr        schema.GroupVersionResource
+	err        error
+}
+
+func (e *resourceTypeNotServedError) Error() string {
+	return fmt.Sprintf("waiting for the API server to serve %s of resource %s: %v", e.gvr, e.resourceID, e.err)
+}
+
+func (e *resourceTypeNotServedError) Reason() string {
+	return ResourceTypeNotServedReason
+}
+
+func (e *resourceTypeNotServedError) Unwrap() error {
+	return e.err
+}
+
+// isResourceTypeNotServed returns true if the error indicates that the API
+// server doesn't serve the type of the requested resource (yet), as opposed to
+// the resource itself not being found.
+func isResourceTypeNotServed(err error) bool {
+	if meta.IsNoMatchError(err) {
+		return true
+	}
+	if !apierrors.IsNotFound(err) {
+		return false
+	}
+	// The API server sets the name of the resource when it isn't found.
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		if details := status.Status().Details; details != nil && details.Name != "" {
+			return false
+		}
+	}
+	message := err.Error()
+	return strings.Contains(message, "could not find the requested resource") ||
+		strings.Contains(message, "no matches for kind")
+}
+
+// resourceTypeWait tracks the wait of an instance resource for its type to be
+// served.
+type resourceTypeWait struct {
+	// since is the time the type was first found not served.
+	since time.Time
+	// attempts is the number of times the type was found not served.
+	attempts int
+}
+
+// resourceTypeWaits records the resources waiting for their type to be served
+// across the reconciliations of the instances. It is safe for concurrent use.
+// A nil resourceTypeWaits records nothing.
+type resourceTypeWaits struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	waits map[string]resourceTypeWait
+}
+
+func newResourceTypeWaits() *resourceTypeWaits {
+	return &resourceTypeWaits{
+		now:   time.Now,
+		waits: make(map[string]resourceTypeWait),
+	}
+}
+
+// observe records that the type of the given resource isn't served, and
+// returns the updated wait along with the current time.
+func (w *resourceTypeWaits) observe(key string) (resourceTypeWait, time.Time) {
+	if w == nil {
+		return resourceTypeWait{}, time.Time{}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	wait, ok := w.waits[key]
+	if !ok {
+		wait.since = now
+	}
+	wait.attempts++
+	w.waits[key] = wait
+	return wait, now
+}
+
+// forget stops tracking the given resource, once its type is served.
+func (w *resourceTypeWaits) forget(key string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waits, key)
+}
+
+// resourceTypeWaitKey returns the key identifying a resource of the instance
+// in the resourceTypeWaits.
+func (igr *instanceGraphReconciler) resourceTypeWaitKey(resourceID string) string {
+	return string(igr.runtime.GetInstance().GetUID()) + "/" + resourceID
+}
+
+// handleResourceTypeNotServed handles the errors returned while the API server
+// doesn't serve the type of a resource. Within the configured timeout, the
+// instance is requeued with an exponential backoff, and reported as waiting
+// for the resource type. Past the timeout, the error is returned as is.
+func (igr *instanceGraphReconciler) handleResourceTypeNotServed(resourceID string, err error, resourceState *ResourceState) error {
+	gvr := igr.runtime.ResourceDescriptor(resourceID).GetGroupVersionResource()
+	config := igr.reconcileConfig
+
+	wait, now := igr.resourceTypeWaits.observe(igr.resourceTypeWaitKey(resourceID))
+	if config.ResourceTypeWaitTimeout <= 0 || wait.attempts == 0 || now.Sub(wait.since) > config.ResourceTypeWaitTimeout {
+		resourceState.State = "ERROR"
+		resourceState.Err = fmt.Errorf("resource type %s of resource %s is not served: %w", gvr, resourceID, err)
+		return resourceState.Err
+	}
+
+	resourceState.State = "WAITING_FOR_RESOURCE_TYPE"
+	resourceState.Err = &resourceTypeNotServedError{resourceID: resourceID, gvr: gvr, err: err}
+	return requeue.NeededAfter(resourceState.Err, resourceTypeWaitBackoff(config, wait.attempts))
+}
+
+// resourceTypeWaitBackoff returns the delay before the given attempt to find
+// the type of a resource served. The delay doubles at each attempt, up to the
+// configured maximum.
+func resourceTypeWaitBackoff(config ReconcileConfig, attempts int) time.Duration {
+	backoff := config.ResourceTypeWaitInitialBackoff
+	if backoff <= 0 {
+		return config.DefaultRequeueDuration
+	}
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if config.ResourceTypeWaitMaxBackoff > 0 && backoff >= config.ResourceTypeWaitMaxBackoff {
+			return config.ResourceTypeWaitMaxBackoff
+		}
+	}
+	return backoff
+}
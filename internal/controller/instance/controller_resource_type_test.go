@@ -0,0 +1,250 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+func newResourceTypeNotServedError(verb string) error {
+	return apierrors.NewGenericServerResponse(http.StatusNotFound, verb, testConfigMapGVR.GroupResource(), "", "", 0, false)
+}
+
+func TestIsResourceTypeNotServed(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "resource type not served",
+			err:  newResourceTypeNotServedError("get"),
+			want: true,
+		},
+		{
+			name: "no kind match",
+			err:  &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "kro.run", Kind: "WebApp"}},
+			want: true,
+		},
+		{
+			name: "resource not found",
+			err:  apierrors.NewNotFound(testConfigMapGVR.GroupResource(), "app-config"),
+			want: false,
+		},
+		{
+			name: "other error",
+			err:  apierrors.NewForbidden(testConfigMapGVR.GroupResource(), "app-config", errors.New("forbidden")),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isResourceTypeNotServed(tt.err))
+		})
+	}
+}
+
+func TestResourceTypeWaitBackoff(t *testing.T) {
+	config := ReconcileConfig{
+		DefaultRequeueDuration:         3 * time.Second,
+		ResourceTypeWaitInitialBackoff: time.Second,
+		ResourceTypeWaitMaxBackoff:     5 * time.Second,
+	}
+	var got []time.Duration
+	for attempts := 1; attempts <= 5; attempts++ {
+		got = append(got, resourceTypeWaitBackoff(config, attempts))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, got)
+
+	config.ResourceTypeWaitInitialBackoff = 0
+	assert.Equal(t, 3*time.Second, resourceTypeWaitBackoff(config, 1))
+}
+
+func TestReconcileResourceTypeNotServed(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.SetUID("instance-uid")
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	waits := newResourceTypeWaits()
+	waits.now = func() time.Time { return now }
+
+	// The config map type is served once its "CRD" is established.
+	established := false
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+	)
+	for _, verb := range []string{"get", "create"} {
+		verb := verb
+		client.PrependReactor(verb, testConfigMapGVR.Resource, func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+			if !established {
+				return true, nil, newResourceTypeNotServedError(verb)
+			}
+			return false, nil, nil
+		})
+	}
+
+	newReconciler := func() *instanceGraphReconciler {
+		return &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &fakeRuntime{
+				instance: instance.DeepCopy(),
+				order:    []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{
+					"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+				},
+			},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			reconcileConfig: ReconcileConfig{
+				DefaultRequeueDuration:         3 * time.Second,
+				ResourceTypeWaitTimeout:        time.Minute,
+				ResourceTypeWaitInitialBackoff: time.Second,
+				ResourceTypeWaitMaxBackoff:     30 * time.Second,
+			},
+			state:             newInstanceState(),
+			tracer:            noop.NewTracerProvider().Tracer(tracerName),
+			resourceTypeWaits: waits,
+		}
+	}
+	instanceCondition := func(t *testing.T) map[string]interface{} {
+		t.Helper()
+		got, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+		require.NoError(t, err)
+		conditions, _, _ := unstructured.NestedSlice(got.Object, "status", "conditions")
+		require.Len(t, conditions, 1)
+		return conditions[0].(map[string]interface{})
+	}
+
+	// While the type isn't served, the instance is requeued with backoff.
+	for _, wantDelay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		igr := newReconciler()
+		err := igr.reconcile(context.Background())
+
+		var requeueErr *requeue.RequeueNeededAfter
+		require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+		assert.Equal(t, wantDelay, requeueErr.Duration())
+		assert.Equal(t, "WAITING_FOR_RESOURCE_TYPE", igr.state.ResourceStates["configmap"].State)
+
+		condition := instanceCondition(t)
+		assert.Equal(t, "False", condition["status"])
+		assert.Equal(t, ResourceTypeNotServedReason, condition["reason"])
+		assert.Contains(t, condition["message"], "waiting for the API server to serve /v1, Resource=configmaps of resource configmap")
+		now = now.Add(wantDelay)
+	}
+
+	// Once the type is served, the resource is created.
+	established = true
+	igr := newReconciler()
+	err := igr.reconcile(context.Background())
+	var requeueErr *requeue.RequeueNeededAfter
+	require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+	assert.Equal(t, "CREATED", igr.state.ResourceStates["configmap"].State)
+	_, err = client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "app-config", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, waits.waits)
+
+	// And the next reconciliation syncs it.
+	igr = newReconciler()
+	require.NoError(t, igr.reconcile(context.Background()))
+	assert.Equal(t, "SYNCED", igr.state.ResourceStates["configmap"].State)
+	condition := instanceCondition(t)
+	assert.Equal(t, "True", condition["status"])
+}
+
+func TestReconcileResourceTypeNotServedTimeout(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.SetUID("instance-uid")
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	waits := newResourceTypeWaits()
+	waits.now = func() time.Time { return now }
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+	)
+	client.PrependReactor("get", testConfigMapGVR.Resource, func(clienttesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, newResourceTypeNotServedError("get")
+	})
+
+	reconcile := func() (*instanceGraphReconciler, error) {
+		igr := &instanceGraphReconciler{
+			log:    logr.Discard(),
+			gvr:    testInstanceGVR,
+			client: client,
+			runtime: &fakeRuntime{
+				instance: instance.DeepCopy(),
+				order:    []string{"configmap"},
+				resources: map[string]*unstructured.Unstructured{
+					"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+				},
+			},
+			instanceLabeler:             metadata.GenericLabeler{},
+			instanceSubResourcesLabeler: metadata.GenericLabeler{},
+			reconcileConfig: ReconcileConfig{
+				ResourceTypeWaitTimeout:        time.Minute,
+				ResourceTypeWaitInitialBackoff: time.Second,
+			},
+			state:             newInstanceState(),
+			tracer:            noop.NewTracerProvider().Tracer(tracerName),
+			resourceTypeWaits: waits,
+		}
+		return igr, igr.reconcile(context.Background())
+	}
+
+	_, err := reconcile()
+	var requeueErr *requeue.RequeueNeededAfter
+	require.True(t, errors.As(err, &requeueErr), "unexpected error: %v", err)
+
+	// Past the timeout, the error is no longer retried as a wait.
+	now = now.Add(2 * time.Minute)
+	igr, err := reconcile()
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &requeueErr))
+	assert.Contains(t, err.Error(), "resource type /v1, Resource=configmaps of resource configmap is not served")
+	assert.Equal(t, "ERROR", igr.state.ResourceStates["configmap"].State)
+	assert.Equal(t, InstanceStateError, igr.state.State)
+}
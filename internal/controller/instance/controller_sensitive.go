@@ -0,0 +1,181 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// sensitiveSecretSuffix is appended to the name of an instance to name the
+// Secret holding the values of its sensitive fields.
+const sensitiveSecretSuffix = "-sensitive"
+
+var secretGVR = corev1.SchemeGroupVersion.WithResource("secrets")
+
+// sensitiveSecretName returns the name of the Secret holding the values of the
+// sensitive fields of the given instance.
+func sensitiveSecretName(instance *unstructured.Unstructured) string {
+	return instance.GetName() + sensitiveSecretSuffix
+}
+
+// extractSensitiveFields removes the sensitive fields (e.g status.token) from
+// the given instance status, and returns their values keyed by their path
+// relative to the status (e.g token). String values are returned as is, other
+// values are JSON encoded. Unset fields are skipped.
+//
+// Note that the nested maps of the status are modified in place.
+func extractSensitiveFields(status map[string]interface{}, sensitiveFields []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(sensitiveFields))
+	for _, field := range sensitiveFields {
+		path := strings.Split(strings.TrimPrefix(field, "status."), ".")
+		value, found, err := unstructured.NestedFieldNoCopy(status, path...)
+		if err != nil || !found {
+			continue
+		}
+		unstructured.RemoveNestedField(status, path...)
+
+		if s, ok := value.(string); ok {
+			values[strings.Join(path, ".")] = []byte(s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			// Don't include the value in the error, it is sensitive.
+			return nil, fmt.Errorf("failed to encode sensitive field %s", field)
+		}
+		values[strings.Join(path, ".")] = encoded
+	}
+	return values, nil
+}
+
+// reconcileSensitiveFields moves the values of the sensitive fields out of the
+// given instance status, into the Secret owned by the instance. The values
+// are always removed from the status, even if the Secret can't be written.
+func (igr *instanceGraphReconciler) reconcileSensitiveFields(ctx context.Context, status map[string]interface{}) error {
+	if len(igr.sensitiveFields) == 0 {
+		return nil
+	}
+
+	values, err := extractSensitiveFields(status, igr.sensitiveFields)
+	if err != nil {
+		return err
+	}
+
+	instance := igr.runtime.GetInstance()
+	if len(values) == 0 || !instance.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	return igr.applySensitiveSecret(ctx, instance, values)
+}
+
+// applySensitiveSecret creates or updates the Secret holding the values of the
+// sensitive fields of the instance. Keys that are not part of the given values
+// are left untouched, as the status of an instance is resolved on a best effort
+// basis.
+func (igr *instanceGraphReconciler) applySensitiveSecret(
+	ctx context.Context,
+	instance *unstructured.Unstructured,
+	values map[string][]byte,
+) error {
+	name := sensitiveSecretName(instance)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	// Only the keys are logged, the values are sensitive.
+	log := igr.log.WithValues("secret", name, "keys", keys)
+
+	rc := igr.client.Resource(secretGVR).Namespace(instance.GetNamespace())
+	observed, err := rc.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get sensitive fields secret: %w", err)
+		}
+
+		secret := &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.GetNamespace(),
+				OwnerReferences: []metav1.OwnerReference{
+					metadata.NewInstanceOwnerReference(instance.GroupVersionKind(), instance.GetName(), instance.GetUID()),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: values,
+		}
+		obj, err := toUnstructured(secret)
+		if err != nil {
+			return err
+		}
+		igr.instanceSubResourcesLabeler.ApplyLabels(obj)
+
+		log.V(1).Info("Creating sensitive fields secret")
+		if _, err := rc.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create sensitive fields secret: %w", err)
+		}
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(observed.Object, secret); err != nil {
+		return fmt.Errorf("failed to convert sensitive fields secret: %w", err)
+	}
+	changed := false
+	for key, value := range values {
+		if !bytes.Equal(secret.Data[key], value) {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	obj, err := toUnstructured(secret)
+	if err != nil {
+		return err
+	}
+	log.V(1).Info("Updating sensitive fields secret")
+	if _, err := rc.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update sensitive fields secret: %w", err)
+	}
+	return nil
+}
+
+// toUnstructured converts the given secret to an unstructured object.
+func toUnstructured(secret *corev1.Secret) (*unstructured.Unstructured, error) {
+	obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sensitive fields secret: %w", err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
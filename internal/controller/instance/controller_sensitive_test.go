@@ -0,0 +1,142 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+func TestExtractSensitiveFields(t *testing.T) {
+	status := map[string]interface{}{
+		"endpoint": "https://example.com",
+		"token":    "s3cr3t",
+		"database": map[string]interface{}{
+			"host":     "db.example.com",
+			"password": "hunter2",
+			"port":     int64(5432),
+		},
+		"credentials": map[string]interface{}{
+			"user": "admin",
+		},
+	}
+
+	values, err := extractSensitiveFields(status, []string{
+		"status.token",
+		"status.database.password",
+		"status.database.port",
+		"status.credentials",
+		"status.unset",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"token":             []byte("s3cr3t"),
+		"database.password": []byte("hunter2"),
+		"database.port":     []byte("5432"),
+		"credentials":       []byte(`{"user":"admin"}`),
+	}, values)
+	assert.Equal(t, map[string]interface{}{
+		"endpoint": "https://example.com",
+		"database": map[string]interface{}{
+			"host": "db.example.com",
+		},
+	}, status)
+}
+
+func TestReconcileSensitiveFields(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.SetUID("instance-uid")
+	instance.Object["status"] = map[string]interface{}{
+		"endpoint": "https://example.com",
+		"token":    "s3cr3t",
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+			secretGVR:        "SecretList",
+		},
+		instance.DeepCopy(),
+		newTestObject("v1", "ConfigMap", "app-config"),
+	)
+
+	var logs strings.Builder
+	igr := &instanceGraphReconciler{
+		log: funcr.New(func(prefix, args string) {
+			logs.WriteString(prefix + args + "\n")
+		}, funcr.Options{Verbosity: 10}),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &fakeRuntime{
+			instance: instance,
+			order:    []string{"configmap"},
+			resources: map[string]*unstructured.Unstructured{
+				"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+			},
+		},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{"kro.run/instance-name": "my-app"},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+		sensitiveFields:             []string{"status.token"},
+	}
+	require.NoError(t, igr.reconcile(context.Background()))
+
+	// The value lands in the secret owned by the instance.
+	secret, err := client.Resource(secretGVR).Namespace("default").Get(context.Background(), "my-app-sensitive", metav1.GetOptions{})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(secret.Object, "data")
+	assert.Equal(t, map[string]string{"token": "czNjcjN0"}, data)
+	require.Len(t, secret.GetOwnerReferences(), 1)
+	assert.Equal(t, "my-app", secret.GetOwnerReferences()[0].Name)
+	assert.Equal(t, "WebApp", secret.GetOwnerReferences()[0].Kind)
+	assert.Equal(t, "my-app", secret.GetLabels()["kro.run/instance-name"])
+
+	// And not in the instance status.
+	observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+	require.NoError(t, err)
+	status, _, _ := unstructured.NestedMap(observed.Object, "status")
+	assert.Equal(t, "https://example.com", status["endpoint"])
+	assert.NotContains(t, status, "token")
+
+	// Nor in the logs.
+	assert.Contains(t, logs.String(), "my-app-sensitive")
+	assert.NotContains(t, logs.String(), "s3cr3t")
+
+	// A new value of the field updates the secret.
+	igr.runtime.GetInstance().Object["status"].(map[string]interface{})["token"] = "n3w-s3cr3t"
+	igr.state = newInstanceState()
+	require.NoError(t, igr.reconcile(context.Background()))
+
+	secret, err = client.Resource(secretGVR).Namespace("default").Get(context.Background(), "my-app-sensitive", metav1.GetOptions{})
+	require.NoError(t, err)
+	data, _, _ = unstructured.NestedStringMap(secret.Object, "data")
+	assert.Equal(t, map[string]string{"token": "bjN3LXMzY3IzdA=="}, data)
+	assert.NotContains(t, logs.String(), "n3w-s3cr3t")
+}
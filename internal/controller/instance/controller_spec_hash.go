@@ -0,0 +1,151 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// desiredState returns the desired state of a resource: the whole object but
+// its status, its spec hash and its applied fields annotations.
+func desiredState(resource *unstructured.Unstructured) map[string]interface{} {
+	desired := resource.DeepCopy()
+	delete(desired.Object, "status")
+	unstructured.RemoveNestedField(desired.Object, "metadata", "annotations", metadata.SpecHashAnnotation)
+	unstructured.RemoveNestedField(desired.Object, "metadata", "annotations", metadata.AppliedFieldsAnnotation)
+	if annotations := desired.GetAnnotations(); len(annotations) == 0 {
+		unstructured.RemoveNestedField(desired.Object, "metadata", "annotations")
+	}
+	return desired.Object
+}
+
+// specHash returns the hash of the desired state of a resource.
+func specHash(resource *unstructured.Unstructured) (string, error) {
+	// encoding/json sorts map keys, the hash is stable across reconciles.
+	raw, err := json.Marshal(desiredState(resource))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setSpecHash sets the spec hash annotation of the resource to the hash of
+// its desired state.
+func setSpecHash(resource *unstructured.Unstructured) error {
+	hash, err := specHash(resource)
+	if err != nil {
+		return fmt.Errorf("failed to compute the spec hash of resource %s: %w", resource.GetName(), err)
+	}
+	annotations := resource.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[metadata.SpecHashAnnotation] = hash
+	resource.SetAnnotations(annotations)
+	return nil
+}
+
+// setAppliedFields sets the applied fields annotation of the resource to the
+// fields of its desired state. The values are not recorded, e.g. the data of
+// a Secret doesn't leak in its annotations: the objects are recorded with
+// their fields, the other values as true.
+func setAppliedFields(resource *unstructured.Unstructured) error {
+	raw, err := json.Marshal(fieldSet(desiredState(resource)))
+	if err != nil {
+		return fmt.Errorf("failed to record the applied fields of resource %s: %w", resource.GetName(), err)
+	}
+	annotations := resource.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[metadata.AppliedFieldsAnnotation] = string(raw)
+	resource.SetAnnotations(annotations)
+	return nil
+}
+
+// fieldSet returns the fields of the given object, see setAppliedFields.
+func fieldSet(object map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok {
+			fields[key] = fieldSet(nested)
+		} else {
+			fields[key] = true
+		}
+	}
+	return fields
+}
+
+// specChanged returns true if the desired state of the resource differs from
+// the one last applied, as recorded in the spec hash annotation of the
+// observed resource.
+func specChanged(desired, observed *unstructured.Unstructured) bool {
+	applied, ok := observed.GetAnnotations()[metadata.SpecHashAnnotation]
+	return !ok || applied != desired.GetAnnotations()[metadata.SpecHashAnnotation]
+}
+
+// specPatch returns the merge patch applying the desired state of the
+// resource, status excluded. The fields last applied, as recorded in the
+// applied fields annotation of the observed resource, that are no longer
+// desired are removed. The other fields of the observed resource, e.g. set by
+// other controllers, are left untouched.
+func specPatch(resource, observed *unstructured.Unstructured) ([]byte, error) {
+	desired := resource.DeepCopy()
+	delete(desired.Object, "status")
+	if recorded, ok := observed.GetAnnotations()[metadata.AppliedFieldsAnnotation]; ok {
+		var applied map[string]interface{}
+		if err := json.Unmarshal([]byte(recorded), &applied); err != nil {
+			return nil, fmt.Errorf("failed to read the applied fields of resource %s: %w", resource.GetName(), err)
+		}
+		removeUndesiredFields(desired.Object, applied)
+	}
+	raw, err := json.Marshal(desired.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the patch of resource %s: %w", resource.GetName(), err)
+	}
+	return raw, nil
+}
+
+// removeUndesiredFields sets to null, removing them when merge patched, the
+// applied fields missing from the desired state. The fields of an applied
+// object missing from the desired state are removed one by one, leaving its
+// other fields untouched. The lists are replaced as a whole by merge patches,
+// their items are not recorded.
+func removeUndesiredFields(desired, applied map[string]interface{}) {
+	for key, appliedValue := range applied {
+		desiredValue, ok := desired[key]
+		appliedObject, isObject := appliedValue.(map[string]interface{})
+		if !ok {
+			if isObject {
+				removed := map[string]interface{}{}
+				removeUndesiredFields(removed, appliedObject)
+				desired[key] = removed
+			} else {
+				desired[key] = nil
+			}
+			continue
+		}
+		if desiredObject, ok := desiredValue.(map[string]interface{}); ok && isObject {
+			removeUndesiredFields(desiredObject, appliedObject)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+func TestReconcileOnlyChangedResources(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	desired := map[string]*unstructured.Unstructured{
+		"config": newTestObject("v1", "ConfigMap", "config"),
+		"env":    newTestObject("v1", "ConfigMap", "env"),
+	}
+	desired["config"].Object["data"] = map[string]interface{}{"replicas": "1"}
+	desired["env"].Object["data"] = map[string]interface{}{"stage": "prod"}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+	)
+	rt := &fakeRuntime{instance: instance, order: []string{"config", "env"}}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      client,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	// reconcile reconciles the instance with a fresh runtime, and returns the
+	// names of the resources patched.
+	reconcile := func() []string {
+		rt.resources = map[string]*unstructured.Unstructured{}
+		for id, resource := range desired {
+			rt.resources[id] = resource.DeepCopy()
+		}
+		client.ClearActions()
+		_ = igr.reconcile(context.Background())
+
+		var patched []string
+		for _, action := range client.Actions() {
+			if patch, ok := action.(clienttesting.PatchAction); ok {
+				patched = append(patched, patch.GetName())
+			}
+		}
+		return patched
+	}
+
+	// The resources are created one at a time, and left alone once applied.
+	assert.Empty(t, reconcile())
+	assert.Empty(t, reconcile())
+	assert.Empty(t, reconcile())
+	for _, id := range []string{"config", "env"} {
+		assert.Equal(t, "SYNCED", igr.state.ResourceStates[id].State, id)
+	}
+
+	// Only the resource whose desired state changed is re-applied.
+	desired["config"].Object["data"] = map[string]interface{}{"replicas": "3"}
+	assert.Equal(t, []string{"config"}, reconcile())
+	assert.Equal(t, "SYNCED", igr.state.ResourceStates["config"].State)
+
+	observed, err := client.Resource(testConfigMapGVR).Namespace("default").Get(context.Background(), "config", metav1.GetOptions{})
+	require.NoError(t, err)
+	replicas, _, _ := unstructured.NestedString(observed.Object, "data", "replicas")
+	assert.Equal(t, "3", replicas)
+	hash, err := specHash(desired["config"])
+	require.NoError(t, err)
+	assert.Equal(t, hash, observed.GetAnnotations()[metadata.SpecHashAnnotation])
+
+	// Once applied, the changed resource is left alone too.
+	assert.Empty(t, reconcile())
+}
+
+func TestReconcileRemovesFieldsDroppedFromTemplate(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	desired := newTestObject("v1", "ConfigMap", "config")
+	desired.Object["data"] = map[string]interface{}{"replicas": "1", "debug": "true"}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+	)
+	rt := &fakeRuntime{instance: instance, order: []string{"config"}}
+	igr := &instanceGraphReconciler{
+		log:                         logr.Discard(),
+		gvr:                         testInstanceGVR,
+		client:                      client,
+		runtime:                     rt,
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+	}
+	reconcile := func() {
+		rt.resources = map[string]*unstructured.Unstructured{"config": desired.DeepCopy()}
+		_ = igr.reconcile(context.Background())
+	}
+	configMaps := client.Resource(testConfigMapGVR).Namespace("default")
+
+	reconcile()
+	reconcile()
+	assert.Equal(t, "SYNCED", igr.state.ResourceStates["config"].State)
+
+	// Another controller sets a field kro doesn't manage.
+	_, err := configMaps.Patch(context.Background(), "config", types.MergePatchType,
+		[]byte(`{"data":{"external":"kept"}}`), metav1.PatchOptions{})
+	require.NoError(t, err)
+
+	// The field dropped from the template is removed, the others are kept.
+	desired.Object["data"] = map[string]interface{}{"replicas": "3"}
+	reconcile()
+	assert.Equal(t, "SYNCED", igr.state.ResourceStates["config"].State)
+
+	observed, err := configMaps.Get(context.Background(), "config", metav1.GetOptions{})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(observed.Object, "data")
+	assert.Equal(t, map[string]string{"replicas": "3", "external": "kept"}, data)
+
+	// The applied fields are recorded without their values.
+	assert.JSONEq(t,
+		`{"apiVersion":true,"kind":true,"metadata":{"name":true,"namespace":true},"data":{"replicas":true}}`,
+		observed.GetAnnotations()[metadata.AppliedFieldsAnnotation])
+}
+
+func TestSpecHash(t *testing.T) {
+	resource := newTestObject("v1", "ConfigMap", "config")
+	resource.Object["data"] = map[string]interface{}{"key": "value"}
+	hash, err := specHash(resource)
+	require.NoError(t, err)
+
+	// The status and the spec hash annotation itself don't change the hash.
+	require.NoError(t, setSpecHash(resource))
+	resource.Object["status"] = map[string]interface{}{"ready": true}
+	rehash, err := specHash(resource)
+	require.NoError(t, err)
+	assert.Equal(t, hash, rehash)
+
+	resource.Object["data"] = map[string]interface{}{"key": "other"}
+	changed, err := specHash(resource)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+}
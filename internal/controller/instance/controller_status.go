@@ -16,12 +16,14 @@ package instance
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/awslabs/kro/api/v1alpha1"
+	krocel "github.com/awslabs/kro/pkg/cel"
 	"github.com/awslabs/kro/pkg/requeue"
 )
 
@@ -42,11 +44,33 @@ func (igr *instanceGraphReconciler) prepareStatus() map[string]interface{} {
 	generation := igr.runtime.GetInstance().GetGeneration()
 
 	status["state"] = igr.state.State
-	status["conditions"] = igr.prepareConditions(igr.state.ReconcileErr, generation)
+	if uid := igr.runtime.GetInstance().GetUID(); uid != "" {
+		status["stableID"] = krocel.StableIDFromUID(string(uid))
+	}
+	conditions := mergeConditions(igr.getExistingConditions(), igr.prepareConditions(igr.state.ReconcileErr, generation))
+	status["conditions"] = pruneConditions(conditions, igr.reconcileConfig.MaxConditions)
+	if resources := igr.prepareResourcesStatus(generation); len(resources) > 0 {
+		status["resources"] = resources
+	}
+	igr.prepareLastReconcileError(status)
+	igr.prepareEvaluatedOnce(status)
+	igr.preparePendingDeletions(status)
+	igr.prepareReconcileSummary(status, time.Now())
 
 	return status
 }
 
+// getExistingConditions returns the conditions currently set in the instance
+// status.
+func (igr *instanceGraphReconciler) getExistingConditions() []interface{} {
+	existingStatus, ok := igr.runtime.GetInstance().Object["status"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	conditions, _ := existingStatus["conditions"].([]interface{})
+	return conditions
+}
+
 // getResolvedStatus retrieves the current status while preserving non-condition fields.
 func (igr *instanceGraphReconciler) getResolvedStatus() map[string]interface{} {
 	status := map[string]interface{}{
@@ -74,18 +98,19 @@ func (igr *instanceGraphReconciler) prepareConditions(
 
 	// Add primary reconciliation condition
 	if reconcileErr != nil {
+		reason, message := errorReason(reconcileErr)
 		conditions = append(conditions, createCondition(
 			"InstanceSynced",
 			corev1.ConditionFalse,
-			"ReconciliationFailed",
-			reconcileErr.Error(),
+			reason,
+			message,
 			generation,
 		))
 	} else {
 		conditions = append(conditions, createCondition(
 			"InstanceSynced",
 			corev1.ConditionTrue,
-			"ReconciliationSucceeded",
+			SyncedReason,
 			"Instance reconciled successfully",
 			generation,
 		))
@@ -94,6 +119,192 @@ func (igr *instanceGraphReconciler) prepareConditions(
 	return conditions
 }
 
+// prepareResourcesStatus evaluates the named conditions of the resources and
+// returns the entries of the status.resources field, in topological order.
+// Resources without conditions nor minReadyDuration are left out, unless the
+// composition of the instance is reported.
+func (igr *instanceGraphReconciler) prepareResourcesStatus(generation int64) []interface{} {
+	var resources []interface{}
+	for _, resourceID := range igr.runtime.TopologicalOrder() {
+		descriptor := igr.runtime.ResourceDescriptor(resourceID)
+		expressions := descriptor.GetConditionExpressions()
+		if len(expressions) == 0 && descriptor.GetMinReadyDuration() <= 0 && !igr.composition {
+			continue
+		}
+		entry := map[string]interface{}{
+			"id": resourceID,
+		}
+		if igr.composition {
+			igr.addComposition(entry, resourceID)
+		}
+		if readySince, ok := igr.readySince(resourceID); ok {
+			entry["readySince"] = readySince.Format(time.RFC3339)
+		}
+		if len(expressions) == 0 {
+			resources = append(resources, entry)
+			continue
+		}
+
+		values, err := igr.runtime.EvaluateResourceConditions(resourceID)
+		conditions := make([]interface{}, 0, len(expressions))
+		for _, expression := range expressions {
+			var condition map[string]interface{}
+			value, evaluated := values[expression.Type]
+			switch {
+			case err != nil:
+				condition = createCondition(v1alpha1.ConditionType(expression.Type), corev1.ConditionUnknown,
+					ExpressionErrorReason, err.Error(), generation)
+			case !evaluated:
+				condition = createCondition(v1alpha1.ConditionType(expression.Type), corev1.ConditionUnknown,
+					ResourceNotObservedReason, "resource not observed yet", generation)
+			case value:
+				condition = createCondition(v1alpha1.ConditionType(expression.Type), corev1.ConditionTrue,
+					ConditionMetReason, expression.Expression, generation)
+			default:
+				condition = createCondition(v1alpha1.ConditionType(expression.Type), corev1.ConditionFalse,
+					ConditionNotMetReason, expression.Expression, generation)
+			}
+			conditions = append(conditions, condition)
+		}
+
+		entry["conditions"] = conditions
+		resources = append(resources, entry)
+	}
+	return resources
+}
+
+// addComposition adds the identity of the given resource, its state and its
+// readiness to its status.resources entry. The identity fields that aren't
+// resolved yet are left out.
+func (igr *instanceGraphReconciler) addComposition(entry map[string]interface{}, resourceID string) {
+	descriptor := igr.runtime.ResourceDescriptor(resourceID)
+	entry["apiVersion"] = descriptor.GetGroupVersionResource().GroupVersion().String()
+
+	if resource, _ := igr.runtime.GetResource(resourceID); resource != nil {
+		entry["apiVersion"] = resource.GetAPIVersion()
+		entry["kind"] = resource.GetKind()
+		if name := resource.GetName(); name != "" {
+			entry["name"] = name
+		}
+		if descriptor.IsNamespaced() {
+			entry["namespace"] = igr.getResourceNamespace(resourceID)
+		}
+	}
+
+	state := "PENDING"
+	if resourceState, ok := igr.state.ResourceStates[resourceID]; ok && resourceState != nil {
+		state = resourceState.State
+	}
+	entry["state"] = state
+	entry["ready"] = state == "SYNCED"
+}
+
+// kroConditionTypes are the instance condition types owned by kro. An existing
+// condition of one of these types that isn't emitted by the current reconcile
+// is stale, e.g. written by an older version of the controller, and dropped.
+var kroConditionTypes = map[string]struct{}{
+	"InstanceSynced": {},
+	string(v1alpha1.InstanceConditionTypeReady):       {},
+	string(v1alpha1.InstanceConditionTypeProgressing): {},
+	string(v1alpha1.InstanceConditionTypeDegraded):    {},
+	string(v1alpha1.InstanceConditionTypeError):       {},
+}
+
+// mergeConditions merges the new conditions into the existing ones. Existing
+// conditions of the same type as a new condition are replaced by it, and
+// existing conditions of a kro owned type that isn't emitted anymore are
+// dropped. The conditions of the other types, e.g. set by users or other
+// controllers, are kept.
+func mergeConditions(existing, conditions []interface{}) []interface{} {
+	newTypes := make(map[string]struct{}, len(conditions))
+	for _, condition := range conditions {
+		newTypes[conditionType(condition)] = struct{}{}
+	}
+
+	merged := make([]interface{}, 0, len(existing)+len(conditions))
+	for _, condition := range existing {
+		t := conditionType(condition)
+		if _, ok := newTypes[t]; ok {
+			continue
+		}
+		if _, ok := kroConditionTypes[t]; ok {
+			continue
+		}
+		merged = append(merged, condition)
+	}
+	return append(merged, conditions...)
+}
+
+// pruneConditions bounds the growth of the instance status conditions. It only
+// keeps the most recent condition of each type, and if there are still more
+// than maxConditions conditions, it drops the oldest ones. The order of the
+// remaining conditions is preserved. Malformed conditions (without a type) are
+// dropped. A maxConditions of 0 or less disables the cap.
+func pruneConditions(conditions []interface{}, maxConditions int) []interface{} {
+	// latest maps a condition type to the index of its most recent condition.
+	latest := make(map[string]int)
+	for i, condition := range conditions {
+		t := conditionType(condition)
+		if t == "" {
+			continue
+		}
+		// On ties, the condition appearing last wins.
+		if j, ok := latest[t]; !ok || !conditionTime(condition).Before(conditionTime(conditions[j])) {
+			latest[t] = i
+		}
+	}
+
+	indexes := make([]int, 0, len(latest))
+	for _, i := range latest {
+		indexes = append(indexes, i)
+	}
+
+	if maxConditions > 0 && len(indexes) > maxConditions {
+		// Keep the most recent conditions.
+		sort.SliceStable(indexes, func(a, b int) bool {
+			ta, tb := conditionTime(conditions[indexes[a]]), conditionTime(conditions[indexes[b]])
+			if ta.Equal(tb) {
+				return indexes[a] > indexes[b]
+			}
+			return ta.After(tb)
+		})
+		indexes = indexes[:maxConditions]
+	}
+
+	sort.Ints(indexes)
+	pruned := make([]interface{}, 0, len(indexes))
+	for _, i := range indexes {
+		pruned = append(pruned, conditions[i])
+	}
+	return pruned
+}
+
+// conditionType returns the type of the given condition, or an empty string
+// if the condition is malformed.
+func conditionType(condition interface{}) string {
+	c, ok := condition.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	t, _ := c["type"].(string)
+	return t
+}
+
+// conditionTime returns the last transition time of the given condition. The
+// zero time is returned if the condition doesn't have a valid transition time.
+func conditionTime(condition interface{}) time.Time {
+	c, ok := condition.(map[string]interface{})
+	if !ok {
+		return time.Time{}
+	}
+	s, _ := c["lastTransitionTime"].(string)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // patchInstanceStatus updates the status subresource of the instance.
 func (igr *instanceGraphReconciler) patchInstanceStatus(ctx context.Context, status map[string]interface{}) error {
 	instance := igr.runtime.GetInstance().DeepCopy()
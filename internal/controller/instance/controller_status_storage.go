@@ -0,0 +1,227 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// statusConfigMapSuffix is appended to the name of an instance to name the
+// ConfigMap holding the values of its status fields stored in a ConfigMap.
+const statusConfigMapSuffix = "-status"
+
+var configMapGVR = corev1.SchemeGroupVersion.WithResource("configmaps")
+
+// statusConfigMapName returns the name of the ConfigMap holding the values of
+// the status fields of the given instance stored in a ConfigMap.
+func statusConfigMapName(instance *unstructured.Unstructured) string {
+	return instance.GetName() + statusConfigMapSuffix
+}
+
+// encodeStatusValue returns the bytes of a status value: strings as is, the
+// other values JSON encoded.
+func encodeStatusValue(value interface{}) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(value)
+}
+
+// compressStatusValue returns the gzip compressed, base64 encoded, bytes of a
+// status value.
+func compressStatusValue(value interface{}) (string, error) {
+	encoded, err := encodeStatusValue(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(encoded); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// storeStatusFields replaces the values of the stored status fields of the
+// given instance status by what they hold instead: the compressed value, or
+// a reference to the key of the status ConfigMap holding the value. It
+// returns the values to write in the ConfigMap, keyed by their path relative
+// to the status. Unset fields are skipped.
+//
+// Note that the nested maps of the status are modified in place.
+func storeStatusFields(
+	status map[string]interface{},
+	storage []v1alpha1.StatusFieldStorage,
+	configMapName string,
+) (map[string]string, error) {
+	values := map[string]string{}
+	for _, s := range storage {
+		path := strings.Split(strings.TrimPrefix(s.Field, "status."), ".")
+		value, found, err := unstructured.NestedFieldNoCopy(status, path...)
+		if err != nil || !found {
+			continue
+		}
+
+		switch s.Strategy {
+		case v1alpha1.StatusFieldStorageCompress:
+			compressed, err := compressStatusValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compress status field %s: %w", s.Field, err)
+			}
+			if err := unstructured.SetNestedField(status, compressed, path...); err != nil {
+				return nil, fmt.Errorf("failed to set status field %s: %w", s.Field, err)
+			}
+		case v1alpha1.StatusFieldStorageConfigMap:
+			encoded, err := encodeStatusValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode status field %s: %w", s.Field, err)
+			}
+			key := strings.Join(path, ".")
+			values[key] = string(encoded)
+			reference := map[string]interface{}{
+				"configMapName": configMapName,
+				"key":           key,
+			}
+			if err := unstructured.SetNestedField(status, reference, path...); err != nil {
+				return nil, fmt.Errorf("failed to set status field %s: %w", s.Field, err)
+			}
+		}
+	}
+	return values, nil
+}
+
+// reconcileStatusStorage stores the values of the stored status fields of the
+// given instance status according to their strategy. The values are always
+// replaced in the status, even if the ConfigMap can't be written.
+func (igr *instanceGraphReconciler) reconcileStatusStorage(ctx context.Context, status map[string]interface{}) error {
+	if len(igr.statusStorage) == 0 {
+		return nil
+	}
+
+	instance := igr.runtime.GetInstance()
+	values, err := storeStatusFields(status, igr.statusStorage, statusConfigMapName(instance))
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 || !instance.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+	return igr.applyStatusConfigMap(ctx, instance, values)
+}
+
+// applyStatusConfigMap creates or updates the ConfigMap holding the values of
+// the status fields of the instance stored in a ConfigMap. Keys that are not
+// part of the given values are left untouched, as the status of an instance
+// is resolved on a best effort basis.
+func (igr *instanceGraphReconciler) applyStatusConfigMap(
+	ctx context.Context,
+	instance *unstructured.Unstructured,
+	values map[string]string,
+) error {
+	name := statusConfigMapName(instance)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log := igr.log.WithValues("configMap", name, "keys", keys)
+
+	rc := igr.client.Resource(configMapGVR).Namespace(instance.GetNamespace())
+	observed, err := rc.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get status configmap: %w", err)
+		}
+
+		configMap := &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.GetNamespace(),
+				OwnerReferences: []metav1.OwnerReference{
+					metadata.NewInstanceOwnerReference(instance.GroupVersionKind(), instance.GetName(), instance.GetUID()),
+				},
+			},
+			Data: values,
+		}
+		obj, err := configMapToUnstructured(configMap)
+		if err != nil {
+			return err
+		}
+		igr.instanceSubResourcesLabeler.ApplyLabels(obj)
+
+		log.V(1).Info("Creating status configmap")
+		if _, err := rc.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create status configmap: %w", err)
+		}
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := k8sruntime.DefaultUnstructuredConverter.FromUnstructured(observed.Object, configMap); err != nil {
+		return fmt.Errorf("failed to convert status configmap: %w", err)
+	}
+	changed := false
+	for key, value := range values {
+		if current, ok := configMap.Data[key]; !ok || current != value {
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	obj, err := configMapToUnstructured(configMap)
+	if err != nil {
+		return err
+	}
+	log.V(1).Info("Updating status configmap")
+	if _, err := rc.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update status configmap: %w", err)
+	}
+	return nil
+}
+
+// configMapToUnstructured converts the given ConfigMap to an unstructured
+// object.
+func configMapToUnstructured(configMap *corev1.ConfigMap) (*unstructured.Unstructured, error) {
+	obj, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert status configmap: %w", err)
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
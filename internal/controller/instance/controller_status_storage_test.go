@@ -0,0 +1,168 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// decompressStatusValue decodes a value compressed by compressStatusValue, as
+// the readers of the instance status do.
+func decompressStatusValue(t *testing.T, compressed interface{}) string {
+	t.Helper()
+	s, ok := compressed.(string)
+	require.True(t, ok, "compressed value must be a string, got %T", compressed)
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(decoded))
+	require.NoError(t, err)
+	value, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(value)
+}
+
+func TestCompressStatusValue(t *testing.T) {
+	large := string(bytes.Repeat([]byte("key: value\n"), 1000))
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{name: "string", value: large, want: large},
+		{name: "object", value: map[string]interface{}{"replicas": int64(3)}, want: `{"replicas":3}`},
+		{name: "list", value: []interface{}{"a", "b"}, want: `["a","b"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressed, err := compressStatusValue(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, decompressStatusValue(t, compressed))
+		})
+	}
+
+	compressed, err := compressStatusValue(large)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(large)/10)
+}
+
+func TestStoreStatusFields(t *testing.T) {
+	status := map[string]interface{}{
+		"endpoint": "https://example.com",
+		"rendered": map[string]interface{}{
+			"config":   "a: b",
+			"manifest": map[string]interface{}{"kind": "ConfigMap"},
+		},
+	}
+
+	values, err := storeStatusFields(status, []v1alpha1.StatusFieldStorage{
+		{Field: "status.rendered.config", Strategy: v1alpha1.StatusFieldStorageCompress},
+		{Field: "status.rendered.manifest", Strategy: v1alpha1.StatusFieldStorageConfigMap},
+		{Field: "status.unset", Strategy: v1alpha1.StatusFieldStorageConfigMap},
+	}, "my-app-status")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"rendered.manifest": `{"kind":"ConfigMap"}`}, values)
+
+	assert.Equal(t, "https://example.com", status["endpoint"])
+	rendered := status["rendered"].(map[string]interface{})
+	assert.Equal(t, "a: b", decompressStatusValue(t, rendered["config"]))
+	assert.Equal(t, map[string]interface{}{
+		"configMapName": "my-app-status",
+		"key":           "rendered.manifest",
+	}, rendered["manifest"])
+	assert.NotContains(t, status, "unset")
+}
+
+func TestReconcileStatusStorage(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.SetUID("instance-uid")
+	instance.Object["status"] = map[string]interface{}{
+		"endpoint": "https://example.com",
+		"config":   "large: config",
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		newTestObject("v1", "ConfigMap", "app-config"),
+	)
+
+	igr := &instanceGraphReconciler{
+		log:    logr.Discard(),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &fakeRuntime{
+			instance: instance,
+			order:    []string{"configmap"},
+			resources: map[string]*unstructured.Unstructured{
+				"configmap": newTestObject("v1", "ConfigMap", "app-config"),
+			},
+		},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{"kro.run/instance-name": "my-app"},
+		state:                       newInstanceState(),
+		tracer:                      noop.NewTracerProvider().Tracer(tracerName),
+		statusStorage: []v1alpha1.StatusFieldStorage{
+			{Field: "status.config", Strategy: v1alpha1.StatusFieldStorageConfigMap},
+		},
+	}
+	require.NoError(t, igr.reconcile(context.Background()))
+
+	// The value lands in the configmap owned by the instance.
+	configMap, err := client.Resource(configMapGVR).Namespace("default").Get(context.Background(), "my-app-status", metav1.GetOptions{})
+	require.NoError(t, err)
+	data, _, _ := unstructured.NestedStringMap(configMap.Object, "data")
+	assert.Equal(t, map[string]string{"config": "large: config"}, data)
+	require.Len(t, configMap.GetOwnerReferences(), 1)
+	assert.Equal(t, "my-app", configMap.GetOwnerReferences()[0].Name)
+	assert.Equal(t, "my-app", configMap.GetLabels()["kro.run/instance-name"])
+
+	// And the instance status references it.
+	observed, err := client.Resource(testInstanceGVR).Namespace("default").Get(context.Background(), "my-app", metav1.GetOptions{})
+	require.NoError(t, err)
+	status, _, _ := unstructured.NestedMap(observed.Object, "status")
+	assert.Equal(t, "https://example.com", status["endpoint"])
+	assert.Equal(t, map[string]interface{}{"configMapName": "my-app-status", "key": "config"}, status["config"])
+
+	// A new value of the field updates the configmap.
+	igr.runtime.GetInstance().Object["status"].(map[string]interface{})["config"] = "larger: config"
+	igr.state = newInstanceState()
+	require.NoError(t, igr.reconcile(context.Background()))
+
+	configMap, err = client.Resource(configMapGVR).Namespace("default").Get(context.Background(), "my-app-status", metav1.GetOptions{})
+	require.NoError(t, err)
+	data, _, _ = unstructured.NestedStringMap(configMap.Object, "data")
+	assert.Equal(t, map[string]string{"config": "larger: config"}, data)
+}
@@ -0,0 +1,253 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/runtime"
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+// conditionsDescriptor describes a resource with a single named condition.
+type conditionsDescriptor struct {
+	fakeDescriptor
+	condition variable.ConditionExpression
+}
+
+func (d conditionsDescriptor) GetConditionExpressions() []variable.ConditionExpression {
+	return []variable.ConditionExpression{d.condition}
+}
+
+// conditionsRuntime is a fake runtime whose "database" resource is
+// provisioned once its status.state is available.
+type conditionsRuntime struct {
+	*fakeRuntime
+	evalErr error
+}
+
+func (r *conditionsRuntime) ResourceDescriptor(id string) runtime.ResourceDescriptor {
+	if id != "database" {
+		return r.fakeRuntime.ResourceDescriptor(id)
+	}
+	return conditionsDescriptor{
+		fakeDescriptor: fakeDescriptor{gvr: testConfigMapGVR},
+		condition: variable.ConditionExpression{
+			Type:       "DatabaseProvisioned",
+			Expression: `database.status.state == "available"`,
+		},
+	}
+}
+
+func (r *conditionsRuntime) EvaluateResourceConditions(id string) (map[string]bool, error) {
+	if r.evalErr != nil {
+		return nil, r.evalErr
+	}
+	observed, ok := r.resources[id]
+	if !ok {
+		return nil, nil
+	}
+	state, _, _ := unstructured.NestedString(observed.Object, "status", "state")
+	return map[string]bool{"DatabaseProvisioned": state == "available"}, nil
+}
+
+func testCondition(conditionType, status, lastTransitionTime string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               conditionType,
+		"status":             status,
+		"lastTransitionTime": lastTransitionTime,
+	}
+}
+
+func TestPruneConditions(t *testing.T) {
+	tests := []struct {
+		name          string
+		conditions    []interface{}
+		maxConditions int
+		want          []interface{}
+	}{
+		{
+			name:          "no conditions",
+			conditions:    nil,
+			maxConditions: 10,
+			want:          []interface{}{},
+		},
+		{
+			name: "old duplicate conditions are pruned",
+			conditions: []interface{}{
+				testCondition("InstanceSynced", "False", "2024-01-01T00:00:00Z"),
+				testCondition("Ready", "True", "2024-01-02T00:00:00Z"),
+				testCondition("InstanceSynced", "True", "2024-01-03T00:00:00Z"),
+				testCondition("InstanceSynced", "Unknown", "2024-01-02T00:00:00Z"),
+			},
+			maxConditions: 10,
+			want: []interface{}{
+				testCondition("Ready", "True", "2024-01-02T00:00:00Z"),
+				testCondition("InstanceSynced", "True", "2024-01-03T00:00:00Z"),
+			},
+		},
+		{
+			name: "latest condition wins on ties",
+			conditions: []interface{}{
+				testCondition("InstanceSynced", "False", "2024-01-01T00:00:00Z"),
+				testCondition("InstanceSynced", "True", "2024-01-01T00:00:00Z"),
+			},
+			maxConditions: 10,
+			want: []interface{}{
+				testCondition("InstanceSynced", "True", "2024-01-01T00:00:00Z"),
+			},
+		},
+		{
+			name: "oldest conditions are dropped above the cap",
+			conditions: []interface{}{
+				testCondition("A", "True", "2024-01-03T00:00:00Z"),
+				testCondition("B", "True", "2024-01-01T00:00:00Z"),
+				testCondition("C", "True", "2024-01-04T00:00:00Z"),
+				testCondition("D", "True", "2024-01-02T00:00:00Z"),
+			},
+			maxConditions: 2,
+			want: []interface{}{
+				testCondition("A", "True", "2024-01-03T00:00:00Z"),
+				testCondition("C", "True", "2024-01-04T00:00:00Z"),
+			},
+		},
+		{
+			name: "cap disabled",
+			conditions: []interface{}{
+				testCondition("A", "True", "2024-01-03T00:00:00Z"),
+				testCondition("B", "True", "2024-01-01T00:00:00Z"),
+				testCondition("A", "False", "2024-01-01T00:00:00Z"),
+			},
+			maxConditions: 0,
+			want: []interface{}{
+				testCondition("A", "True", "2024-01-03T00:00:00Z"),
+				testCondition("B", "True", "2024-01-01T00:00:00Z"),
+			},
+		},
+		{
+			name: "malformed conditions are dropped",
+			conditions: []interface{}{
+				"not a condition",
+				map[string]interface{}{"status": "True"},
+				testCondition("A", "True", "invalid time"),
+			},
+			maxConditions: 10,
+			want: []interface{}{
+				testCondition("A", "True", "invalid time"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pruneConditions(tt.conditions, tt.maxConditions))
+		})
+	}
+}
+
+func TestMergeConditions(t *testing.T) {
+	existing := []interface{}{
+		testCondition("InstanceSynced", "False", "2024-01-01T00:00:00Z"),
+		testCondition("External", "True", "2024-01-01T00:00:00Z"),
+		// Stale kro owned condition, not emitted anymore.
+		testCondition("Progressing", "True", "2024-01-01T00:00:00Z"),
+	}
+	conditions := []interface{}{
+		testCondition("InstanceSynced", "True", "2024-01-02T00:00:00Z"),
+	}
+
+	assert.Equal(t, []interface{}{
+		testCondition("External", "True", "2024-01-01T00:00:00Z"),
+		testCondition("InstanceSynced", "True", "2024-01-02T00:00:00Z"),
+	}, mergeConditions(existing, conditions))
+}
+
+func TestPrepareResourcesStatus(t *testing.T) {
+	rt := &conditionsRuntime{fakeRuntime: &fakeRuntime{
+		instance:  newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+		order:     []string{"configmap", "database"},
+		resources: map[string]*unstructured.Unstructured{},
+	}}
+	igr := &instanceGraphReconciler{runtime: rt, state: newInstanceState()}
+
+	// assertCondition checks the single entry of status.resources.
+	assertCondition := func(t *testing.T, wantStatus, wantReason string) {
+		t.Helper()
+		status := igr.prepareStatus()
+		resources, ok := status["resources"].([]interface{})
+		require.True(t, ok)
+		// The configmap doesn't declare any condition.
+		require.Len(t, resources, 1)
+		entry := resources[0].(map[string]interface{})
+		assert.Equal(t, "database", entry["id"])
+		conditions := entry["conditions"].([]interface{})
+		require.Len(t, conditions, 1)
+		condition := conditions[0].(map[string]interface{})
+		assert.Equal(t, "DatabaseProvisioned", condition["type"])
+		assert.Equal(t, wantStatus, condition["status"])
+		assert.Equal(t, wantReason, condition["reason"])
+	}
+
+	assertCondition(t, "Unknown", ResourceNotObservedReason)
+
+	database := newTestObject("v1", "ConfigMap", "database")
+	require.NoError(t, unstructured.SetNestedField(database.Object, "creating", "status", "state"))
+	rt.SetResource("database", database)
+	assertCondition(t, "False", ConditionNotMetReason)
+
+	require.NoError(t, unstructured.SetNestedField(database.Object, "available", "status", "state"))
+	assertCondition(t, "True", ConditionMetReason)
+
+	require.NoError(t, unstructured.SetNestedField(database.Object, "deleting", "status", "state"))
+	assertCondition(t, "False", ConditionNotMetReason)
+
+	rt.evalErr = errors.New("no such key: state")
+	assertCondition(t, "Unknown", ExpressionErrorReason)
+}
+
+func TestPrepareResourcesStatusWithoutConditions(t *testing.T) {
+	igr := &instanceGraphReconciler{
+		runtime: &fakeRuntime{
+			instance: newTestObject("kro.run/v1alpha1", "WebApp", "my-app"),
+			order:    []string{"configmap"},
+		},
+		state: newInstanceState(),
+	}
+	assert.NotContains(t, igr.prepareStatus(), "resources")
+}
+
+func TestPrepareStatusStableID(t *testing.T) {
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	instance.SetUID("0b6c7a8e-2f4d-4c1a-9e3b-5d7f1a2c4e6b")
+	igr := &instanceGraphReconciler{
+		runtime: &fakeRuntime{instance: instance},
+		state:   newInstanceState(),
+	}
+
+	first := igr.prepareStatus()
+	assert.Equal(t, krocel.StableIDFromUID("0b6c7a8e-2f4d-4c1a-9e3b-5d7f1a2c4e6b"), first["stableID"])
+	// The identifier doesn't change across reconciles.
+	instance.Object["status"] = first
+	assert.Equal(t, first["stableID"], igr.prepareStatus()["stableID"])
+
+	// An instance not created yet has no identifier.
+	igr.runtime = &fakeRuntime{instance: newTestObject("kro.run/v1alpha1", "WebApp", "my-app")}
+	assert.NotContains(t, igr.prepareStatus(), "stableID")
+}
@@ -13,6 +13,8 @@
 
 package instance
 
+import "time"
+
 const (
 	InstanceStateInProgress = "IN_PROGRESS"
 	InstanceStateFailed     = "FAILED"
@@ -24,8 +26,10 @@ const (
 // newInstanceState creates a new InstanceState with initialized fields
 func newInstanceState() *InstanceState {
 	return &InstanceState{
-		State:          "IN_PROGRESS",
-		ResourceStates: make(map[string]*ResourceState),
+		State:            "IN_PROGRESS",
+		ResourceStates:   make(map[string]*ResourceState),
+		ReadySince:       make(map[string]time.Time),
+		PendingDeletions: make(map[string]pendingDeletion),
 	}
 }
 
@@ -36,6 +40,8 @@ type ResourceState struct {
 	State string
 	// Err captures any error associated with the current state
 	Err error
+	// Updated is true if the resource was patched during the reconciliation.
+	Updated bool
 }
 
 // InstanceState tracks the overall state of resources being managed
@@ -46,4 +52,14 @@ type InstanceState struct {
 	ResourceStates map[string]*ResourceState
 	// Any error encountered during reconciliation
 	ReconcileErr error
+	// FailedResourceID is the ID of the resource being reconciled when
+	// ReconcileErr was encountered, if any.
+	FailedResourceID string
+	// ReadySince maps the IDs of the resources with a minReadyDuration, that
+	// were checked for readiness, to the time they became ready. The zero time
+	// means the resource isn't ready.
+	ReadySince map[string]time.Time
+	// PendingDeletions maps the IDs of the excluded resources waiting for
+	// their grace period to elapse to their pending deletion.
+	PendingDeletions map[string]pendingDeletion
 }
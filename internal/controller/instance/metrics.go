@@ -27,6 +27,12 @@ const (
 	MetricImpersonationErrors = "controller_impersonation_errors_total"
 	// MetricImpersonationDuration tracks the duration of impersonation operations
 	MetricImpersonationDuration = "controller_impersonation_duration_seconds"
+	// MetricManagedObjects is the number of objects managed by the instances,
+	// when capped
+	MetricManagedObjects = "controller_managed_objects"
+	// MetricManagedObjectLimitHolds is the total number of resource creations
+	// held because the controller manages the maximum number of objects
+	MetricManagedObjectLimitHolds = "controller_managed_object_limit_holds_total"
 )
 
 var (
@@ -54,6 +60,20 @@ var (
 		},
 		[]string{"namespace", "service_account"},
 	)
+
+	managedObjects = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: MetricManagedObjects,
+			Help: "Number of objects managed by the instances of all the resource groups, counted when capped",
+		},
+	)
+
+	managedObjectLimitHolds = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: MetricManagedObjectLimitHolds,
+			Help: "Total number of resource creations held because the maximum number of managed objects is reached",
+		},
+	)
 )
 
 func recordImpersonateError(namespace, sa string, category errorCategory) {
@@ -65,5 +85,7 @@ func init() {
 		impersonationTotal,
 		impersonationErrors,
 		impersonationDuration,
+		managedObjects,
+		managedObjectLimitHolds,
 	)
 }
@@ -0,0 +1,62 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/awslabs/kro/pkg/requeue"
+)
+
+const (
+	// tracerName is the name of the tracer used to instrument the instance
+	// reconciliation.
+	tracerName = "github.com/awslabs/kro/internal/controller/instance"
+
+	attributeResourceGroup     = attribute.Key("kro.resourcegroup")
+	attributeInstanceName      = attribute.Key("kro.instance.name")
+	attributeInstanceNamespace = attribute.Key("kro.instance.namespace")
+	attributeResourceID        = attribute.Key("kro.resource.id")
+)
+
+// defaultTracer returns the tracer of the globally registered tracer provider.
+// Unless tracing is enabled, this is a no-op tracer.
+func defaultTracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// endSpan records the given error, if any, and ends the span. Delayed
+// requeues are part of the normal reconciliation flow (e.g waiting for a
+// resource to become ready), so they are recorded as events rather than
+// failing the span.
+func endSpan(span trace.Span, err error) {
+	var requeueErr *requeue.RequeueNeededAfter
+	switch {
+	case err == nil:
+	case errors.As(err, &requeueErr):
+		span.AddEvent("requeue", trace.WithAttributes(
+			attribute.String("reason", err.Error()),
+			attribute.String("after", requeueErr.Duration().String()),
+		))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
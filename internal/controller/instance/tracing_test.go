@@ -0,0 +1,173 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package instance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+var (
+	testInstanceGVR  = schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "webapps"}
+	testConfigMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+// fakeDescriptor describes a namespaced resource of a given GVR.
+type fakeDescriptor struct {
+	gvr schema.GroupVersionResource
+}
+
+func (d fakeDescriptor) GetGroupVersionResource() schema.GroupVersionResource    { return d.gvr }
+func (d fakeDescriptor) GetVariables() []*variable.ResourceField                 { return nil }
+func (d fakeDescriptor) GetDependencies() []string                               { return nil }
+func (d fakeDescriptor) GetReadyWhenExpressions() []string                       { return nil }
+func (d fakeDescriptor) GetIncludeWhenExpressions() []string                     { return nil }
+func (d fakeDescriptor) GetWaitFor() []string                                    { return nil }
+func (d fakeDescriptor) GetMinReadyDuration() time.Duration                      { return 0 }
+func (d fakeDescriptor) GetFinalizers() []string                                 { return nil }
+func (d fakeDescriptor) GetEvaluateOnce() []string                               { return nil }
+func (d fakeDescriptor) GetConditionExpressions() []variable.ConditionExpression { return nil }
+func (d fakeDescriptor) GetTopLevelFields() []string                             { return nil }
+func (d fakeDescriptor) IsNamespaced() bool                                      { return true }
+
+// fakeRuntime is a runtime in which all the resources are resolved and ready.
+type fakeRuntime struct {
+	instance  *unstructured.Unstructured
+	order     []string
+	resources map[string]*unstructured.Unstructured
+}
+
+func (r *fakeRuntime) Synchronize() (bool, error)       { return false, nil }
+func (r *fakeRuntime) SynchronizeStatus() error         { return nil }
+func (r *fakeRuntime) UnresolvedStatusFields() []string { return nil }
+func (r *fakeRuntime) TopologicalOrder() []string       { return r.order }
+func (r *fakeRuntime) ResourceDescriptor(string) runtime.ResourceDescriptor {
+	return fakeDescriptor{gvr: testConfigMapGVR}
+}
+func (r *fakeRuntime) GetResource(id string) (*unstructured.Unstructured, runtime.ResourceState) {
+	return r.resources[id], runtime.ResourceStateResolved
+}
+func (r *fakeRuntime) SetResource(id string, obj *unstructured.Unstructured) { r.resources[id] = obj }
+func (r *fakeRuntime) GetInstance() *unstructured.Unstructured               { return r.instance }
+func (r *fakeRuntime) SetInstance(obj *unstructured.Unstructured)            { r.instance = obj }
+func (r *fakeRuntime) IsResourceReady(string) (bool, string, error)          { return true, "", nil }
+func (r *fakeRuntime) WantToCreateResource(string) (bool, error)             { return true, nil }
+func (r *fakeRuntime) IgnoreResource(string)                                 {}
+func (r *fakeRuntime) EvaluateResourceConditions(string) (map[string]bool, error) {
+	return nil, nil
+}
+func (r *fakeRuntime) CheckConstraints(string) error { return nil }
+
+func newTestObject(apiVersion, kind, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	return obj
+}
+
+func TestReconcileTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	instance := newTestObject("kro.run/v1alpha1", "WebApp", "my-app")
+	configMap := newTestObject("v1", "ConfigMap", "my-config")
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(
+		k8sruntime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			testInstanceGVR:  "WebAppList",
+			testConfigMapGVR: "ConfigMapList",
+		},
+		instance.DeepCopy(),
+		configMap.DeepCopy(),
+	)
+
+	igr := &instanceGraphReconciler{
+		log:    logr.Discard(),
+		gvr:    testInstanceGVR,
+		client: client,
+		runtime: &fakeRuntime{
+			instance:  instance,
+			order:     []string{"configmap"},
+			resources: map[string]*unstructured.Unstructured{"configmap": configMap},
+		},
+		instanceLabeler:             metadata.GenericLabeler{},
+		instanceSubResourcesLabeler: metadata.GenericLabeler{},
+		reconcileConfig:             ReconcileConfig{ResourceGroupName: "webapp"},
+		state:                       newInstanceState(),
+		tracer:                      provider.Tracer(tracerName),
+	}
+
+	ctx, parent := provider.Tracer(tracerName).Start(context.Background(), "Reconcile")
+	require.NoError(t, igr.reconcile(ctx))
+	parent.End()
+
+	spans := recorder.Ended()
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	assert.ElementsMatch(t, []string{"ReconcileResource", "Synchronize", "Reconcile"}, names)
+
+	for _, span := range spans {
+		if span.Name() == "Reconcile" {
+			continue
+		}
+		// Child spans are part of the reconcile trace.
+		assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Contains(t, span.Attributes(), attributeResourceID.String("configmap"))
+	}
+}
+
+func TestEndSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(tracerName)
+	igr := &instanceGraphReconciler{}
+
+	_, span := tracer.Start(context.Background(), "requeue")
+	endSpan(span, igr.delayedRequeue(assert.AnError))
+	_, span = tracer.Start(context.Background(), "failure")
+	endSpan(span, assert.AnError)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	// Requeues are recorded as events and don't fail the span.
+	assert.Equal(t, "Unset", spans[0].Status().Code.String())
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "requeue", spans[0].Events()[0].Name)
+	assert.Contains(t, spans[0].Events()[0].Attributes, attribute.String("reason", assert.AnError.Error()))
+
+	assert.Equal(t, "Error", spans[1].Status().Code.String())
+	assert.Equal(t, assert.AnError.Error(), spans[1].Status().Description)
+}
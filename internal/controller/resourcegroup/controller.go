@@ -15,17 +15,24 @@ package resourcegroup
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/awslabs/kro/api/v1alpha1"
+	instancectrl "github.com/awslabs/kro/internal/controller/instance"
 	"github.com/awslabs/kro/internal/graph"
 	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/webhook"
 	kroclient "github.com/awslabs/kro/pkg/client"
 	"github.com/awslabs/kro/pkg/dynamiccontroller"
 )
@@ -33,6 +40,74 @@ import (
 //+kubebuilder:rbac:groups=kro.run,resources=resourcegroups,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=kro.run,resources=resourcegroups/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=kro.run,resources=resourcegroups/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// ReconcilerConfig holds the configuration of the ResourceGroupReconciler and
+// of the instance controllers it starts.
+type ReconcilerConfig struct {
+	// MaxInstanceConditions is the maximum number of conditions kept in the
+	// status of instances. A value of 0 or less disables the cap.
+	MaxInstanceConditions int
+	// ResourceTypeWaitTimeout bounds how long the instance controllers wait
+	// for the API server to serve the type of a resource, e.g. right after its
+	// CRD was created. A value of 0 or less disables the wait.
+	ResourceTypeWaitTimeout time.Duration
+	// SelfHealing makes the instances reconcile when one of their
+	// sub-resources is deleted, e.g. out-of-band by a user, so that it gets
+	// recreated.
+	SelfHealing bool
+	// DependencyNotFoundPolicy defines how the instance controllers handle a
+	// resource found deleted while computing the status of its instance.
+	DependencyNotFoundPolicy instancectrl.DependencyNotFoundPolicy
+	// ServerRejectionPolicy defines how the instance controllers handle a
+	// resource rejected by the API server as invalid or forbidden.
+	ServerRejectionPolicy instancectrl.ServerRejectionPolicy
+	// ExcludedDependencyPolicy defines how the instance controllers handle a
+	// resource without includeWhen expressions depending on an excluded
+	// resource.
+	ExcludedDependencyPolicy instancectrl.ExcludedDependencyPolicy
+	// ExcludedResourceGracePeriod is how long the instance controllers keep
+	// a resource excluded by its includeWhen expressions before deleting it.
+	// A value of 0 or less disables the deletion.
+	ExcludedResourceGracePeriod time.Duration
+	// MaxManagedObjects is the maximum number of objects managed by the
+	// instances of all the resource groups. Past it, the creations of new
+	// objects are held until objects are freed. A value of 0 or less
+	// disables the cap.
+	MaxManagedObjects int
+	// ConfigHashPath is the path of the annotations the instance controllers
+	// inject the config hash annotation in. The annotation is not injected
+	// when empty.
+	ConfigHashPath []string
+	// MaxResourceGroups is the maximum number of resource groups, and thus
+	// of instance informers, the controller manages. The additional resource
+	// groups are rejected. A value of 0 or less disables the cap.
+	MaxResourceGroups int
+	// CreateBatchConcurrency is the maximum number of resources of an
+	// instance created in parallel, see instancectrl.ReconcileConfig.
+	CreateBatchConcurrency int
+	// CreateQPS and CreateBurst rate limit the resource creations of the
+	// instances of each resource group. A CreateQPS of 0 or less disables
+	// the rate limit.
+	CreateQPS   float64
+	CreateBurst int
+	// ImpersonationPreflight checks, before applying the resources of an
+	// instance under an impersonated service account, that the service
+	// account is allowed to create them.
+	ImpersonationPreflight bool
+	// CoerceNumericStrings parses the strings computed for the integer and
+	// number fields of the resources into the expected type.
+	CoerceNumericStrings bool
+	// Leader reports whether the controller is the leader. Only the leader
+	// writes the resource groups, their CRDs and their status, the other
+	// replicas only run the instance controllers. It is set when the instance
+	// controllers run on all the replicas, the controller is otherwise always
+	// the leader.
+	Leader func() bool
+	// MaxConcurrentReconciles is the maximum number of resource groups
+	// reconciled in parallel.
+	MaxConcurrentReconciles int
+}
 
 // ResourceGroupReconciler reconciles a ResourceGroup object
 type ResourceGroupReconciler struct {
@@ -48,6 +123,17 @@ type ResourceGroupReconciler struct {
 	metadataLabeler   metadata.Labeler
 	rgBuilder         *graph.Builder
 	dynamicController *dynamiccontroller.DynamicController
+	// conversionWebhook is used to convert instances between versions of
+	// their kind. It is nil when the conversion webhook is disabled.
+	conversionWebhook *webhook.ConversionWebhook
+	// recorder records events on the resource groups and their instances.
+	// It is set up with the manager, see SetupWithManager.
+	recorder record.EventRecorder
+	// managedObjects caps the number of objects managed by the instances of
+	// all the resource groups. It is nil when the cap is disabled.
+	managedObjects *instancectrl.ManagedObjects
+
+	config ReconcilerConfig
 }
 
 func NewResourceGroupReconciler(
@@ -57,6 +143,8 @@ func NewResourceGroupReconciler(
 	allowCRDDeletion bool,
 	dynamicController *dynamiccontroller.DynamicController,
 	builder *graph.Builder,
+	conversionWebhook *webhook.ConversionWebhook,
+	config ReconcilerConfig,
 ) *ResourceGroupReconciler {
 	crdWrapper := clientSet.CRD(kroclient.CRDWrapperConfig{
 		Log: log,
@@ -73,13 +161,29 @@ func NewResourceGroupReconciler(
 		dynamicController: dynamicController,
 		metadataLabeler:   metadata.NewKroMetaLabeler("0.1.0", "kro-pod"),
 		rgBuilder:         builder,
+		conversionWebhook: conversionWebhook,
+		managedObjects:    instancectrl.NewManagedObjects(config.MaxManagedObjects),
+		config:            config,
 	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ResourceGroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("kro")
+	// The resource group controller runs on all the replicas when its
+	// config tells the leader apart, see ReconcilerConfig.Leader.
+	needLeaderElection := r.config.Leader == nil
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.ResourceGroup{}).
+		WithEventFilter(
+			// The annotations, e.g. the resync period override, configure
+			// the resource groups too.
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}),
+		).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.config.MaxConcurrentReconciles,
+			NeedLeaderElection:      &needLeaderElection,
+		}).
 		Complete(reconcile.AsReconciler[*v1alpha1.ResourceGroup](mgr.GetClient(), r))
 }
 
@@ -89,10 +193,30 @@ func (r *ResourceGroupReconciler) Reconcile(ctx context.Context, resourcegroup *
 
 	if !resourcegroup.DeletionTimestamp.IsZero() {
 		rlog.V(1).Info("ResourceGroup is being deleted")
+		if !r.isLeader() {
+			// The leader enforces the deletion policies and releases the
+			// resource group, the other replicas only stop serving it.
+			return ctrl.Result{}, r.cleanupResourceGroup(ctx, resourcegroup)
+		}
+		proceed, err := r.enforceInstanceDeletionPolicy(ctx, resourcegroup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !proceed {
+			return ctrl.Result{RequeueAfter: instanceDeletionRequeueDelay}, nil
+		}
+
 		if err := r.cleanupResourceGroup(ctx, resourcegroup); err != nil {
 			return ctrl.Result{}, err
 		}
 
+		if _, blocked := r.crdDeletionPolicy(resourcegroup); blocked != "" {
+			rlog.Info("CRD deletion blocked", "reason", blocked)
+			if err := r.setCRDDeletionBlocked(ctx, resourcegroup, blocked); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
 		rlog.V(1).Info("Setting resourcegroup as unmanaged")
 		if err := r.setUnmanaged(ctx, resourcegroup); err != nil {
 			return ctrl.Result{}, err
@@ -101,11 +225,27 @@ func (r *ResourceGroupReconciler) Reconcile(ctx context.Context, resourcegroup *
 		return ctrl.Result{}, nil
 	}
 
+	if !r.isLeader() {
+		return r.reconcileAsFollower(ctx, resourcegroup)
+	}
+
 	rlog.V(1).Info("Setting resource group as managed")
 	if err := r.setManaged(ctx, resourcegroup); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := r.checkResourceGroupLimit(ctx, resourcegroup); err != nil {
+		var limitErr *resourceGroupLimitError
+		if !errors.As(err, &limitErr) {
+			return ctrl.Result{}, err
+		}
+		rlog.Info("Rejecting resourcegroup", "reason", err.Error())
+		if err := r.setResourceGroupStatus(ctx, resourcegroup, nil, nil, err); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: resourceGroupLimitRequeueDelay}, nil
+	}
+
 	rlog.V(1).Info("Syncing resourcegroup")
 	topologicalOrder, resourcesInformation, reconcileErr := r.reconcileResourceGroup(ctx, resourcegroup)
 
@@ -116,3 +256,9 @@ func (r *ResourceGroupReconciler) Reconcile(ctx context.Context, resourcegroup *
 
 	return ctrl.Result{}, nil
 }
+
+// isLeader returns whether the controller is the leader, see
+// ReconcilerConfig.Leader.
+func (r *ResourceGroupReconciler) isLeader() bool {
+	return r.config.Leader == nil || r.config.Leader()
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/gobuffalo/flect"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/awslabs/kro/api/v1alpha1"
@@ -29,20 +30,43 @@ import (
 // cleanupResourceGroup handles the deletion of a ResourceGroup by shutting down its associated
 // microcontroller and cleaning up the CRD if enabled. It executes cleanup operations in order:
 // 1. Shuts down the microcontroller
-// 2. Deletes the associated CRD (if CRD deletion is enabled)
+// 2. Deletes the associated CRD (if the CRD deletion policy allows it)
+//
+// A resource group whose kind is owned by another resource group never served
+// the kind, so both the microcontroller and the CRD are left to the owner.
 func (r *ResourceGroupReconciler) cleanupResourceGroup(ctx context.Context, rg *v1alpha1.ResourceGroup) error {
 	log, _ := logr.FromContext(ctx)
 	log.V(1).Info("cleaning up resource group", "name", rg.Name)
 
+	crdName := extractCRDName(rg.Spec.Schema.Kind)
+	owner, ownedByAnother, err := r.crdOwnedByAnotherResourceGroup(ctx, rg)
+	if err != nil {
+		return fmt.Errorf("failed to get CRD %s: %w", crdName, err)
+	}
+	if ownedByAnother {
+		log.Info("skipping microcontroller shutdown (CRD owned by another resource group)", "crd", crdName,
+			"owner", owner)
+		r.dynamicController.StopWatchingChildren(string(rg.UID))
+		return nil
+	}
+
 	// shutdown microcontroller
 	gvr := metadata.GetResourceGroupInstanceGVR(rg.Spec.Schema.APIVersion, rg.Spec.Schema.Kind)
 	if err := r.shutdownResourceGroupMicroController(ctx, &gvr); err != nil {
 		return fmt.Errorf("failed to shutdown microcontroller: %w", err)
 	}
+	// The objects of the remaining instances, e.g. orphaned, aren't managed
+	// anymore.
+	r.managedObjects.ForgetGVR(gvr)
+	r.dynamicController.StopWatchingChildren(string(rg.UID))
+
+	// The CRD is only deleted by the leader.
+	if !r.isLeader() {
+		return nil
+	}
 
 	// cleanup CRD
-	crdName := extractCRDName(rg.Spec.Schema.Kind)
-	if err := r.cleanupResourceGroupCRD(ctx, crdName); err != nil {
+	if err := r.cleanupResourceGroupCRD(ctx, rg, crdName); err != nil {
 		return fmt.Errorf("failed to cleanup CRD %s: %w", crdName, err)
 	}
 
@@ -58,12 +82,57 @@ func (r *ResourceGroupReconciler) shutdownResourceGroupMicroController(ctx conte
 	return nil
 }
 
-// cleanupResourceGroupCRD deletes the CRD with the given name if CRD deletion is enabled.
-// If CRD deletion is disabled, it logs the skip and returns nil.
-func (r *ResourceGroupReconciler) cleanupResourceGroupCRD(ctx context.Context, crdName string) error {
-	if !r.allowCRDDeletion {
-		log, _ := logr.FromContext(ctx)
-		log.Info("skipping CRD deletion (disabled)", "crd", crdName)
+// crdDeletionPolicy combines the CRD deletion policy of the given resource
+// group with the controller wide --allow-crd-deletion flag. It returns whether
+// the CRD should be deleted along with the resource group, and a non empty
+// message when the resource group asks for the CRD to be deleted but the
+// controller doesn't allow it.
+//
+//	policy \ flag | allowed | disallowed
+//	(unset)       | delete  | keep
+//	Delete        | delete  | keep (blocked)
+//	Orphan        | keep    | keep
+func (r *ResourceGroupReconciler) crdDeletionPolicy(rg *v1alpha1.ResourceGroup) (bool, string) {
+	switch rg.Spec.CRDDeletionPolicy {
+	case v1alpha1.CRDDeletionPolicyOrphan:
+		return false, ""
+	case v1alpha1.CRDDeletionPolicyDelete:
+		if !r.allowCRDDeletion {
+			return false, "crdDeletionPolicy is Delete but the controller does not allow CRD deletion " +
+				"(--allow-crd-deletion=false), the CRD and its instances will be kept"
+		}
+		return true, ""
+	default:
+		return r.allowCRDDeletion, ""
+	}
+}
+
+// cleanupResourceGroupCRD deletes the CRD with the given name if the CRD deletion
+// policy of the resource group, combined with the controller configuration,
+// allows it. If the CRD must be kept, or if it is owned by another resource group,
+// it logs the skip and returns nil.
+func (r *ResourceGroupReconciler) cleanupResourceGroupCRD(ctx context.Context, rg *v1alpha1.ResourceGroup, crdName string) error {
+	log, _ := logr.FromContext(ctx)
+	deleteCRD, blocked := r.crdDeletionPolicy(rg)
+	if !deleteCRD {
+		if blocked != "" {
+			log.Info("skipping CRD deletion (blocked)", "crd", crdName, "reason", blocked)
+		} else {
+			log.Info("skipping CRD deletion (disabled)", "crd", crdName, "policy", rg.Spec.CRDDeletionPolicy)
+		}
+		return nil
+	}
+
+	crd, err := r.crdManager.Get(ctx, crdName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("error getting CRD: %w", err)
+	}
+	if owner := crd.Labels[metadata.ResourceGroupIDLabel]; owner != "" && owner != string(rg.UID) {
+		log.Info("skipping CRD deletion (owned by another resource group)", "crd", crdName,
+			"owner", crd.Labels[metadata.ResourceGroupNameLabel])
 		return nil
 	}
 
@@ -73,6 +142,24 @@ func (r *ResourceGroupReconciler) cleanupResourceGroupCRD(ctx context.Context, c
 	return nil
 }
 
+// crdOwnedByAnotherResourceGroup returns whether the CRD of the given resource
+// group kind is labeled as owned by another resource group, along with the
+// name of that resource group. A CRD that doesn't exist, or that isn't labeled
+// with an owner, isn't owned by another resource group.
+func (r *ResourceGroupReconciler) crdOwnedByAnotherResourceGroup(ctx context.Context, rg *v1alpha1.ResourceGroup) (string, bool, error) {
+	crd, err := r.crdManager.Get(ctx, extractCRDName(rg.Spec.Schema.Kind))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if owner := crd.Labels[metadata.ResourceGroupIDLabel]; owner != "" && owner != string(rg.UID) {
+		return crd.Labels[metadata.ResourceGroupNameLabel], true, nil
+	}
+	return "", false, nil
+}
+
 // extractCRDName generates the CRD name from a given kind by converting it to plural form
 // and appending the Kro domain name.
 func extractCRDName(kind string) string {
@@ -0,0 +1,55 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+// followerResyncPeriod is the delay after which a replica that isn't the
+// leader reconciles a resource group again. It bounds the time a replica
+// becoming the leader takes to take over the writes of the resource groups.
+const followerResyncPeriod = time.Minute
+
+// reconcileAsFollower reconciles a resource group on a replica that isn't the
+// leader, when the instance controllers run on all the replicas. The replica
+// only starts the instance controller of the resource group: the resource
+// group, its CRD and its status are left to the leader, which also reports
+// the errors in the resource group status.
+func (r *ResourceGroupReconciler) reconcileAsFollower(ctx context.Context, rg *v1alpha1.ResourceGroup) (ctrl.Result, error) {
+	log, _ := logr.FromContext(ctx)
+	result := ctrl.Result{RequeueAfter: followerResyncPeriod}
+
+	if err := r.checkResourceGroupLimit(ctx, rg); err != nil {
+		var limitErr *resourceGroupLimitError
+		if !errors.As(err, &limitErr) {
+			return ctrl.Result{}, err
+		}
+		log.V(1).Info("not serving resourcegroup", "reason", err.Error())
+		return result, nil
+	}
+
+	log.V(1).Info("Starting resourcegroup instance controller as a follower")
+	if _, _, err := r.reconcileResourceGroup(ctx, rg); err != nil {
+		log.V(1).Info("failed to serve resourcegroup", "error", err.Error())
+	}
+	return result, nil
+}
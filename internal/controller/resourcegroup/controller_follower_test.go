@@ -0,0 +1,76 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/pkg/dynamiccontroller"
+)
+
+func TestReconcileDeletionAsFollower(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	rg := newTestResourceGroup("webapp", "a", "WebApp")
+	metadata.SetResourceGroupFinalizer(rg)
+	now := metav1.NewTime(time.Now())
+	rg.DeletionTimestamp = &now
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rg).
+		WithStatusSubresource(&v1alpha1.ResourceGroup{}).
+		Build()
+
+	leader := false
+	crdClient := newFakeCRDClient(newTestCRD(t, rg))
+	r := &ResourceGroupReconciler{
+		log:              logr.Discard(),
+		Client:           fakeClient,
+		crdManager:       crdClient,
+		allowCRDDeletion: true,
+		dynamicController: dynamiccontroller.NewDynamicController(logr.Discard(), dynamiccontroller.Config{},
+			dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())),
+		config: ReconcilerConfig{Leader: func() bool { return leader }},
+	}
+	ctx := context.Background()
+
+	// A follower only stops serving the resource group, it neither deletes
+	// the CRD nor releases the resource group.
+	_, err := r.Reconcile(ctx, rg)
+	require.NoError(t, err)
+	assert.Empty(t, crdClient.deleted)
+	got := &v1alpha1.ResourceGroup{}
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(rg), got))
+	assert.True(t, metadata.HasResourceGroupFinalizer(got))
+
+	// The leader completes the deletion.
+	leader = true
+	_, err = r.Reconcile(ctx, got)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"webapps.kro.run"}, crdClient.deleted)
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+const (
+	// instanceDeletionRequeueDelay is the delay after which a resource group
+	// waiting for its instances to be deleted is reconciled again.
+	instanceDeletionRequeueDelay = 10 * time.Second
+	// maxListedInstances is the maximum number of remaining instances listed
+	// in the InstanceDeletionBlocked condition.
+	maxListedInstances = 10
+)
+
+// enforceInstanceDeletionPolicy applies the instance deletion policy of a
+// resource group being deleted. It returns whether the resource group cleanup
+// can proceed, that is whether no instance is left, or the instances are
+// orphaned.
+//
+// It must run before the instance controller is shut down, so that the
+// instances deleted by the Delete policy get their resources cleaned up.
+//
+// The instances of a kind owned by another resource group aren't instances of
+// the given resource group, the policy is then not enforced.
+func (r *ResourceGroupReconciler) enforceInstanceDeletionPolicy(ctx context.Context, rg *v1alpha1.ResourceGroup) (bool, error) {
+	policy := rg.Spec.InstanceDeletionPolicy
+	if policy == "" || policy == v1alpha1.InstanceDeletionPolicyOrphan {
+		return true, nil
+	}
+	log, _ := logr.FromContext(ctx)
+
+	owner, ownedByAnother, err := r.crdOwnedByAnotherResourceGroup(ctx, rg)
+	if err != nil {
+		return false, fmt.Errorf("failed to get CRD: %w", err)
+	}
+	if ownedByAnother {
+		log.Info("skipping instance deletion policy (CRD owned by another resource group)",
+			"crd", extractCRDName(rg.Spec.Schema.Kind), "owner", owner)
+		return true, nil
+	}
+
+	instances, err := r.listInstances(ctx, rg)
+	if err != nil {
+		return false, fmt.Errorf("failed to list instances: %w", err)
+	}
+	if len(instances) == 0 {
+		return true, nil
+	}
+
+	switch policy {
+	case v1alpha1.InstanceDeletionPolicyBlock:
+		message := remainingInstancesMessage(instances)
+		log.Info("resource group deletion blocked", "reason", message)
+		if err := r.setInstanceDeletionBlocked(ctx, rg, message); err != nil {
+			return false, err
+		}
+	case v1alpha1.InstanceDeletionPolicyDelete:
+		for i := range instances {
+			instance := &instances[i]
+			if instance.GetDeletionTimestamp() != nil {
+				continue
+			}
+			log.Info("deleting instance", "namespace", instance.GetNamespace(), "name", instance.GetName())
+			if err := r.Delete(ctx, instance); client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("failed to delete instance %s: %w", client.ObjectKeyFromObject(instance), err)
+			}
+		}
+	}
+	return false, nil
+}
+
+// listInstances lists the instances of the kind generated for the given
+// resource group. No instance is returned when the kind isn't served, e.g. if
+// its CRD was never created.
+func (r *ResourceGroupReconciler) listInstances(ctx context.Context, rg *v1alpha1.ResourceGroup) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   v1alpha1.KroDomainName,
+		Version: rg.Spec.Schema.APIVersion,
+		Kind:    rg.Spec.Schema.Kind + "List",
+	})
+	if err := r.List(ctx, list); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// remainingInstancesMessage describes the instances blocking the deletion of
+// their resource group.
+func remainingInstancesMessage(instances []unstructured.Unstructured) string {
+	names := make([]string, 0, min(len(instances), maxListedInstances))
+	for _, instance := range instances[:min(len(instances), maxListedInstances)] {
+		names = append(names, client.ObjectKeyFromObject(&instance).String())
+	}
+	if len(instances) > maxListedInstances {
+		names = append(names, fmt.Sprintf("and %d more", len(instances)-maxListedInstances))
+	}
+	return fmt.Sprintf("instanceDeletionPolicy is Block, waiting for %d remaining instances to be deleted: %s",
+		len(instances), strings.Join(names, ", "))
+}
+
+// setInstanceDeletionBlocked sets the InstanceDeletionBlocked condition on a
+// resource group being deleted, whose instances must be deleted first.
+func (r *ResourceGroupReconciler) setInstanceDeletionBlocked(ctx context.Context, resourcegroup *v1alpha1.ResourceGroup, message string) error {
+	return r.setDeletionCondition(ctx, resourcegroup, newInstanceDeletionBlockedCondition(message))
+}
@@ -0,0 +1,149 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+func newTestInstance(namespace, name string) *unstructured.Unstructured {
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion(v1alpha1.KroDomainName + "/v1alpha1")
+	instance.SetKind("WebApp")
+	instance.SetNamespace(namespace)
+	instance.SetName(name)
+	return instance
+}
+
+func TestEnforceInstanceDeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        v1alpha1.InstanceDeletionPolicy
+		noInstances   bool
+		wantProceed   bool
+		wantRemaining int
+		wantBlocked   bool
+	}{
+		{name: "unset policy", wantProceed: true, wantRemaining: 2},
+		{name: "Orphan policy", policy: v1alpha1.InstanceDeletionPolicyOrphan, wantProceed: true, wantRemaining: 2},
+		{name: "Block policy", policy: v1alpha1.InstanceDeletionPolicyBlock, wantRemaining: 2, wantBlocked: true},
+		{name: "Block policy, no instance", policy: v1alpha1.InstanceDeletionPolicyBlock, noInstances: true, wantProceed: true},
+		{name: "Delete policy", policy: v1alpha1.InstanceDeletionPolicyDelete, wantRemaining: 0},
+		{name: "Delete policy, no instance", policy: v1alpha1.InstanceDeletionPolicyDelete, noInstances: true, wantProceed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+			rg := newTestResourceGroup("webapp", "a", "WebApp")
+			rg.Spec.InstanceDeletionPolicy = tt.policy
+			objects := []client.Object{rg}
+			if !tt.noInstances {
+				objects = append(objects, newTestInstance("team-a", "app"), newTestInstance("team-b", "app"))
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(objects...).
+				WithStatusSubresource(&v1alpha1.ResourceGroup{}).
+				Build()
+			r := &ResourceGroupReconciler{Client: fakeClient, crdManager: newFakeCRDClient(newTestCRD(t, rg))}
+			ctx := logr.NewContext(context.Background(), logr.Discard())
+
+			proceed, err := r.enforceInstanceDeletionPolicy(ctx, rg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantProceed, proceed)
+
+			remaining, err := r.listInstances(ctx, rg)
+			require.NoError(t, err)
+			assert.Len(t, remaining, tt.wantRemaining)
+
+			got := &v1alpha1.ResourceGroup{}
+			require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(rg), got))
+			condition := v1alpha1.GetCondition(got.Status.Conditions, v1alpha1.ResourceGroupConditionTypeInstanceDeletionBlocked)
+			if !tt.wantBlocked {
+				assert.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			assert.Equal(t, metav1.ConditionTrue, condition.Status)
+			assert.Equal(t, "InstancesRemaining", *condition.Reason)
+			assert.Contains(t, *condition.Message, "waiting for 2 remaining instances to be deleted: team-a/app, team-b/app")
+		})
+	}
+}
+
+func TestEnforceInstanceDeletionPolicyOwnedByAnotherResourceGroup(t *testing.T) {
+	for _, policy := range []v1alpha1.InstanceDeletionPolicy{
+		v1alpha1.InstanceDeletionPolicyBlock,
+		v1alpha1.InstanceDeletionPolicyDelete,
+	} {
+		t.Run(string(policy), func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+			// Both resource groups declare the WebApp kind, whose CRD and
+			// instances belong to the first one.
+			webApp := newTestResourceGroup("webapp", "a", "WebApp")
+			duplicate := newTestResourceGroup("webapp-duplicate", "b", "WebApp")
+			duplicate.Spec.InstanceDeletionPolicy = policy
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(webApp, duplicate, newTestInstance("team-a", "app"), newTestInstance("team-b", "app")).
+				WithStatusSubresource(&v1alpha1.ResourceGroup{}).
+				Build()
+			r := &ResourceGroupReconciler{Client: fakeClient, crdManager: newFakeCRDClient(newTestCRD(t, webApp))}
+			ctx := logr.NewContext(context.Background(), logr.Discard())
+
+			// The duplicate neither deletes nor waits for the instances of
+			// the owner.
+			proceed, err := r.enforceInstanceDeletionPolicy(ctx, duplicate)
+			require.NoError(t, err)
+			assert.True(t, proceed)
+
+			remaining, err := r.listInstances(ctx, webApp)
+			require.NoError(t, err)
+			assert.Len(t, remaining, 2)
+
+			got := &v1alpha1.ResourceGroup{}
+			require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(duplicate), got))
+			assert.Nil(t, v1alpha1.GetCondition(got.Status.Conditions,
+				v1alpha1.ResourceGroupConditionTypeInstanceDeletionBlocked))
+		})
+	}
+}
+
+func TestRemainingInstancesMessage(t *testing.T) {
+	var instances []unstructured.Unstructured
+	for i := 0; i < maxListedInstances+2; i++ {
+		instances = append(instances, *newTestInstance("default", string(rune('a'+i))))
+	}
+	message := remainingInstancesMessage(instances)
+	assert.Contains(t, message, "waiting for 12 remaining instances")
+	assert.Contains(t, message, "default/j, and 2 more")
+	assert.NotContains(t, message, "default/k")
+}
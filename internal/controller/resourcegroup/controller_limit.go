@@ -0,0 +1,66 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+// resourceGroupLimitRequeueDelay is the delay after which a resource group
+// rejected because of the resource group limit is reconciled again, in case
+// another resource group was deleted in the meantime.
+const resourceGroupLimitRequeueDelay = time.Minute
+
+// checkResourceGroupLimit returns an error if the controller can't manage the
+// given resource group without exceeding the maximum number of resource groups
+// it manages. The oldest resource groups are managed first, so that a resource
+// group keeps being managed across restarts of the controller.
+func (r *ResourceGroupReconciler) checkResourceGroupLimit(ctx context.Context, rg *v1alpha1.ResourceGroup) error {
+	limit := r.config.MaxResourceGroups
+	if limit <= 0 {
+		return nil
+	}
+
+	list := &v1alpha1.ResourceGroupList{}
+	if err := r.List(ctx, list); err != nil {
+		return fmt.Errorf("failed to list resource groups: %w", err)
+	}
+	// The resource groups being deleted release their slot.
+	rgs := slices.DeleteFunc(list.Items, func(item v1alpha1.ResourceGroup) bool {
+		return !item.DeletionTimestamp.IsZero()
+	})
+	slices.SortFunc(rgs, func(a, b v1alpha1.ResourceGroup) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	// A resource group not listed yet is the newest one.
+	position := slices.IndexFunc(rgs, func(item v1alpha1.ResourceGroup) bool { return item.UID == rg.UID })
+	if position == -1 {
+		position = len(rgs)
+	}
+	if position < limit {
+		return nil
+	}
+	return newResourceGroupLimitError(fmt.Errorf(
+		"the controller already manages the maximum number of resource groups (%d), see --max-resource-groups", limit))
+}
@@ -0,0 +1,95 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+func TestResourceGroupLimit(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var rgs []*v1alpha1.ResourceGroup
+	for i, kind := range []string{"First", "Second", "Third"} {
+		rg := newTestResourceGroup(kind, kind, kind)
+		rg.CreationTimestamp = metav1.NewTime(created.Add(time.Duration(i) * time.Minute))
+		rgs = append(rgs, rg)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rgs[0], rgs[1], rgs[2]).
+		WithStatusSubresource(&v1alpha1.ResourceGroup{}).
+		Build()
+	r := &ResourceGroupReconciler{
+		log:    logr.Discard(),
+		Client: fakeClient,
+		config: ReconcilerConfig{MaxResourceGroups: 2},
+	}
+	ctx := context.Background()
+
+	t.Run("at the cap", func(t *testing.T) {
+		assert.NoError(t, r.checkResourceGroupLimit(ctx, rgs[0]))
+		assert.NoError(t, r.checkResourceGroupLimit(ctx, rgs[1]))
+	})
+
+	t.Run("over the cap", func(t *testing.T) {
+		err := r.checkResourceGroupLimit(ctx, rgs[2])
+		var limitErr *resourceGroupLimitError
+		require.ErrorAs(t, err, &limitErr)
+
+		rg := &v1alpha1.ResourceGroup{}
+		require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(rgs[2]), rg))
+		result, err := r.Reconcile(ctx, rg)
+		require.NoError(t, err)
+		assert.Equal(t, resourceGroupLimitRequeueDelay, result.RequeueAfter)
+
+		require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(rgs[2]), rg))
+		assert.Equal(t, v1alpha1.ResourceGroupStateInactive, rg.Status.State)
+		var ready *v1alpha1.Condition
+		for i := range rg.Status.Conditions {
+			if rg.Status.Conditions[i].Type == v1alpha1.ResourceGroupConditionTypeReconcilerReady {
+				ready = &rg.Status.Conditions[i]
+			}
+		}
+		require.NotNil(t, ready)
+		assert.Equal(t, metav1.ConditionFalse, ready.Status)
+		assert.Contains(t, *ready.Reason, "maximum number of resource groups (2)")
+	})
+
+	t.Run("resource groups being deleted release their slot", func(t *testing.T) {
+		first := &v1alpha1.ResourceGroup{}
+		require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(rgs[0]), first))
+		require.NoError(t, fakeClient.Delete(ctx, first))
+		assert.NoError(t, r.checkResourceGroupLimit(ctx, rgs[2]))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r := &ResourceGroupReconciler{Client: fakeClient}
+		assert.NoError(t, r.checkResourceGroupLimit(ctx, rgs[2]))
+	})
+}
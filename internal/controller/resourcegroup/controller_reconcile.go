@@ -16,9 +16,11 @@ package resourcegroup
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -43,30 +45,64 @@ func (r *ResourceGroupReconciler) reconcileResourceGroup(ctx context.Context, rg
 		return nil, nil, err
 	}
 
-	// Ensure CRD exists and is up to date
-	log.V(1).Info("reconciling resource group CRD")
-	if err := r.reconcileResourceGroupCRD(ctx, processedRG.Instance.GetCRD()); err != nil {
-		return processedRG.TopologicalOrder, resourcesInfo, err
-	}
-
 	// Setup metadata labeling
 	graphExecLabeler, err := r.setupLabeler(rg)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to setup labeler: %w", err)
 	}
 
+	crd := processedRG.Instance.GetCRD().DeepCopy()
+	r.setupConversion(rg, crd)
+	// Label the CRD with the resource group owning it, so that the names it
+	// claims can be checked against the other resource groups.
+	graphExecLabeler.ApplyLabels(crd)
+
+	// Ensure CRD exists and is up to date. The CRD is written by the leader
+	// only.
+	if r.isLeader() {
+		log.V(1).Info("reconciling resource group CRD")
+		if err := r.reconcileResourceGroupCRD(ctx, rg, crd); err != nil {
+			return processedRG.TopologicalOrder, resourcesInfo, err
+		}
+	}
+
 	// Setup and start microcontroller
 	gvr := processedRG.Instance.GetGroupVersionResource()
-	controller := r.setupMicroController(gvr, processedRG, rg.Spec.DefaultServiceAccounts, graphExecLabeler)
+	controller := r.setupMicroController(rg.Name, gvr, processedRG, rg.Spec.DefaultServiceAccounts, graphExecLabeler)
+
+	resyncPeriod, err := resyncPeriodOverride(rg)
+	if err != nil {
+		return processedRG.TopologicalOrder, resourcesInfo, newMicroControllerError(err)
+	}
 
 	log.V(1).Info("reconciling resource group micro controller")
-	if err := r.reconcileResourceGroupMicroController(ctx, &gvr, controller.Reconcile); err != nil {
+	if err := r.reconcileResourceGroupMicroController(ctx, &gvr, controller.Reconcile,
+		dynamiccontroller.WithResyncPeriod(resyncPeriod)); err != nil {
 		return processedRG.TopologicalOrder, resourcesInfo, err
 	}
 
+	if r.config.SelfHealing {
+		log.V(1).Info("watching resource group sub-resources")
+		if err := r.watchResourceGroupChildren(ctx, rg, gvr, processedRG); err != nil {
+			return processedRG.TopologicalOrder, resourcesInfo, err
+		}
+	}
+
 	return processedRG.TopologicalOrder, resourcesInfo, nil
 }
 
+// setupConversion configures the CRD to use the conversion webhook. This is a
+// no-op if the conversion webhook is disabled or if the resource group doesn't
+// declare any conversion rule. The converter itself is registered with the
+// webhook by the webhook.ConverterReconciler, on every replica, once the CRD
+// is owned by the resource group.
+func (r *ResourceGroupReconciler) setupConversion(rg *v1alpha1.ResourceGroup, crd *v1.CustomResourceDefinition) {
+	if r.conversionWebhook == nil || len(rg.Spec.Schema.Conversion) == 0 {
+		return
+	}
+	crd.Spec.Conversion = r.conversionWebhook.CustomResourceConversion()
+}
+
 // setupLabeler creates and merges the required labelers for the resource group
 func (r *ResourceGroupReconciler) setupLabeler(rg *v1alpha1.ResourceGroup) (metadata.Labeler, error) {
 	rgLabeler := metadata.NewResourceGroupLabeler(rg)
@@ -75,6 +111,7 @@ func (r *ResourceGroupReconciler) setupLabeler(rg *v1alpha1.ResourceGroup) (meta
 
 // setupMicroController creates a new controller instance with the required configuration
 func (r *ResourceGroupReconciler) setupMicroController(
+	rgName string,
 	gvr schema.GroupVersionResource,
 	processedRG *graph.Graph,
 	defaultSVCs map[string]string,
@@ -89,22 +126,61 @@ func (r *ResourceGroupReconciler) setupMicroController(
 			DefaultRequeueDuration:    3 * time.Second,
 			DeletionGraceTimeDuration: 30 * time.Second,
 			DeletionPolicy:            "Delete",
+			MaxConditions:             r.config.MaxInstanceConditions,
+			ResourceGroupName:         rgName,
+			// Retry after 1s, 2s, 4s... up to every 30s while waiting for
+			// the type of a resource to be served.
+			ResourceTypeWaitTimeout:        r.config.ResourceTypeWaitTimeout,
+			ResourceTypeWaitInitialBackoff: time.Second,
+			ResourceTypeWaitMaxBackoff:     30 * time.Second,
+			DependencyNotFoundPolicy:       r.config.DependencyNotFoundPolicy,
+			ConfigHashPath:                 r.config.ConfigHashPath,
+			CreateBatchConcurrency:         r.config.CreateBatchConcurrency,
+			CreateQPS:                      r.config.CreateQPS,
+			CreateBurst:                    r.config.CreateBurst,
+			ImpersonationPreflight:         r.config.ImpersonationPreflight,
+			CoerceNumericStrings:           r.config.CoerceNumericStrings,
+			ServerRejectionPolicy:          r.config.ServerRejectionPolicy,
+			ExcludedDependencyPolicy:       r.config.ExcludedDependencyPolicy,
+			ExcludedResourceGracePeriod:    r.config.ExcludedResourceGracePeriod,
+			ManagedObjects:                 r.managedObjects,
 		},
 		gvr,
 		processedRG,
 		r.clientSet,
+		r.recorder,
 		defaultSVCs,
 		labeler,
 	)
 }
 
+// graphWarningReason is the reason of the events reporting the warnings found
+// while building the resource group graph.
+const graphWarningReason = "GraphWarning"
+
+// reportGraphWarnings logs the warnings found while building the resource
+// group graph, and records them as events on the resource group.
+func (r *ResourceGroupReconciler) reportGraphWarnings(ctx context.Context, rg *v1alpha1.ResourceGroup, warnings []string) {
+	if !r.isLeader() {
+		return
+	}
+	log, _ := logr.FromContext(ctx)
+	for _, warning := range warnings {
+		log.Info("resource group warning", "warning", warning)
+		if r.recorder != nil {
+			r.recorder.Event(rg, corev1.EventTypeWarning, graphWarningReason, warning)
+		}
+	}
+}
+
 // reconcileResourceGroupGraph processes the resource group to build a dependency graph
 // and extract resource information
-func (r *ResourceGroupReconciler) reconcileResourceGroupGraph(_ context.Context, rg *v1alpha1.ResourceGroup) (*graph.Graph, []v1alpha1.ResourceInformation, error) {
+func (r *ResourceGroupReconciler) reconcileResourceGroupGraph(ctx context.Context, rg *v1alpha1.ResourceGroup) (*graph.Graph, []v1alpha1.ResourceInformation, error) {
 	processedRG, err := r.rgBuilder.NewResourceGroup(rg)
 	if err != nil {
 		return nil, nil, newGraphError(err)
 	}
+	r.reportGraphWarnings(ctx, rg, processedRG.Warnings)
 
 	resourcesInfo := make([]v1alpha1.ResourceInformation, 0, len(processedRG.Resources))
 	for name, resource := range processedRG.Resources {
@@ -129,41 +205,167 @@ func buildResourceInfo(name string, deps []string) v1alpha1.ResourceInformation
 	}
 }
 
-// reconcileResourceGroupCRD ensures the CRD is present and up to date in the cluster
-func (r *ResourceGroupReconciler) reconcileResourceGroupCRD(ctx context.Context, crd *v1.CustomResourceDefinition) error {
+// reconcileResourceGroupCRD ensures the CRD is present and up to date in the cluster.
+// The CRD is not touched if its names collide with a CRD owned by another
+// resource group.
+func (r *ResourceGroupReconciler) reconcileResourceGroupCRD(ctx context.Context, rg *v1alpha1.ResourceGroup, crd *v1.CustomResourceDefinition) error {
+	if err := r.checkCRDNamesConflicts(ctx, rg, crd); err != nil {
+		return newCRDError(err)
+	}
 	if err := r.crdManager.Ensure(ctx, *crd); err != nil {
 		return newCRDError(err)
 	}
 	return nil
 }
 
+// checkCRDNamesConflicts returns an error if the names of the CRD generated
+// for the given resource group collide with the names of a CRD owned by
+// another resource group. The resource group owning a CRD is the one that
+// created it first, it keeps it until it is deleted.
+func (r *ResourceGroupReconciler) checkCRDNamesConflicts(ctx context.Context, rg *v1alpha1.ResourceGroup, crd *v1.CustomResourceDefinition) error {
+	existing, err := r.crdManager.List(ctx, metadata.OwnedLabel+"=true")
+	if err != nil {
+		return fmt.Errorf("failed to list kro owned CRDs: %w", err)
+	}
+	for _, other := range existing {
+		owner := other.Labels[metadata.ResourceGroupIDLabel]
+		// CRDs created before kro labeled them with their resource group
+		// can't be attributed, they are skipped.
+		if owner == "" || owner == string(rg.UID) {
+			continue
+		}
+		if name, ok := conflictingCRDName(crd, &other); ok {
+			return fmt.Errorf("conflicting CRD names: %s %q is already used by CRD %s of resource group %s",
+				name.field, name.value, other.Name, other.Labels[metadata.ResourceGroupNameLabel])
+		}
+	}
+	return nil
+}
+
+// crdName is one of the names a CRD claims in its API group.
+type crdName struct {
+	field string
+	value string
+	// kind is true for the kind names of the CRD (kind and list kind), and
+	// false for its resource names (plural, singular and short names).
+	kind bool
+}
+
+// crdNames returns the names claimed by the given CRD in its API group. The
+// API server requires the resource names, and the kind names, to be unique
+// across all the CRDs of a group.
+func crdNames(crd *v1.CustomResourceDefinition) []crdName {
+	names := []crdName{
+		{field: "plural", value: crd.Spec.Names.Plural},
+		{field: "singular", value: crd.Spec.Names.Singular},
+		{field: "kind", value: crd.Spec.Names.Kind, kind: true},
+		{field: "list kind", value: crd.Spec.Names.ListKind, kind: true},
+	}
+	for _, shortName := range crd.Spec.Names.ShortNames {
+		names = append(names, crdName{field: "short name", value: shortName})
+	}
+	return names
+}
+
+// conflictingCRDName returns the first name of crd that is also claimed by
+// other, if both CRDs belong to the same API group.
+func conflictingCRDName(crd, other *v1.CustomResourceDefinition) (crdName, bool) {
+	if crd.Spec.Group != other.Spec.Group {
+		return crdName{}, false
+	}
+	if crd.Name == other.Name {
+		return crdName{field: "plural", value: crd.Spec.Names.Plural}, true
+	}
+
+	type claim struct {
+		value string
+		kind  bool
+	}
+	claimed := make(map[claim]struct{})
+	for _, name := range crdNames(other) {
+		if name.value != "" {
+			claimed[claim{strings.ToLower(name.value), name.kind}] = struct{}{}
+		}
+	}
+	for _, name := range crdNames(crd) {
+		if _, ok := claimed[claim{strings.ToLower(name.value), name.kind}]; ok && name.value != "" {
+			return name, true
+		}
+	}
+	return crdName{}, false
+}
+
+// resyncPeriodOverride returns the resync period of the instances set by the
+// annotation of the resource group, or 0 if it isn't set.
+func resyncPeriodOverride(rg *v1alpha1.ResourceGroup) (time.Duration, error) {
+	value, ok := rg.Annotations[metadata.ResyncPeriodAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation: %w", metadata.ResyncPeriodAnnotation, err)
+	}
+	if period <= 0 {
+		return 0, fmt.Errorf("invalid %s annotation: %q is not a positive duration", metadata.ResyncPeriodAnnotation, value)
+	}
+	return period, nil
+}
+
 // reconcileResourceGroupMicroController starts the microcontroller for handling the resources
-func (r *ResourceGroupReconciler) reconcileResourceGroupMicroController(ctx context.Context, gvr *schema.GroupVersionResource, handler dynamiccontroller.Handler) error {
-	err := r.dynamicController.StartServingGVK(ctx, *gvr, handler)
+func (r *ResourceGroupReconciler) reconcileResourceGroupMicroController(
+	ctx context.Context,
+	gvr *schema.GroupVersionResource,
+	handler dynamiccontroller.Handler,
+	opts ...dynamiccontroller.ServeOption,
+) error {
+	err := r.dynamicController.StartServingGVK(ctx, *gvr, handler, opts...)
 	if err != nil {
 		return newMicroControllerError(err)
 	}
 	return nil
 }
 
+// watchResourceGroupChildren makes the dynamic controller reconcile the
+// instances whose sub-resources are deleted, so that they get recreated.
+func (r *ResourceGroupReconciler) watchResourceGroupChildren(
+	ctx context.Context,
+	rg *v1alpha1.ResourceGroup,
+	gvr schema.GroupVersionResource,
+	processedRG *graph.Graph,
+) error {
+	childGVRs := make([]schema.GroupVersionResource, 0, len(processedRG.Resources))
+	for _, id := range processedRG.TopologicalOrder {
+		childGVRs = append(childGVRs, processedRG.Resources[id].GetGroupVersionResource())
+	}
+	if err := r.dynamicController.StartWatchingChildren(ctx, gvr, string(rg.UID), childGVRs); err != nil {
+		return newMicroControllerError(err)
+	}
+	return nil
+}
+
 // Error types for the resourcegroup controller
 type (
-	graphError           struct{ err error }
-	crdError             struct{ err error }
-	microControllerError struct{ err error }
+	graphError              struct{ err error }
+	crdError                struct{ err error }
+	microControllerError    struct{ err error }
+	resourceGroupLimitError struct{ err error }
 )
 
 // Error interface implementation
-func (e *graphError) Error() string           { return e.err.Error() }
-func (e *crdError) Error() string             { return e.err.Error() }
-func (e *microControllerError) Error() string { return e.err.Error() }
+func (e *graphError) Error() string              { return e.err.Error() }
+func (e *crdError) Error() string                { return e.err.Error() }
+func (e *microControllerError) Error() string    { return e.err.Error() }
+func (e *resourceGroupLimitError) Error() string { return e.err.Error() }
 
 // Unwrap interface implementation
-func (e *graphError) Unwrap() error           { return e.err }
-func (e *crdError) Unwrap() error             { return e.err }
-func (e *microControllerError) Unwrap() error { return e.err }
+func (e *graphError) Unwrap() error              { return e.err }
+func (e *crdError) Unwrap() error                { return e.err }
+func (e *microControllerError) Unwrap() error    { return e.err }
+func (e *resourceGroupLimitError) Unwrap() error { return e.err }
 
 // Error constructors
-func newGraphError(err error) error           { return &graphError{err} }
-func newCRDError(err error) error             { return &crdError{err} }
-func newMicroControllerError(err error) error { return &microControllerError{err} }
+func newGraphError(err error) error              { return &graphError{err} }
+func newCRDError(err error) error                { return &crdError{err} }
+func newMicroControllerError(err error) error    { return &microControllerError{err} }
+func newResourceGroupLimitError(err error) error { return &resourceGroupLimitError{err} }
@@ -0,0 +1,332 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package resourcegroup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/crd"
+	"github.com/awslabs/kro/internal/metadata"
+	kroclient "github.com/awslabs/kro/pkg/client"
+	"github.com/awslabs/kro/pkg/dynamiccontroller"
+)
+
+var _ kroclient.CRDClient = &fakeCRDClient{}
+
+// fakeCRDClient is an in-memory CRDClient.
+type fakeCRDClient struct {
+	crds    map[string]extv1.CustomResourceDefinition
+	deleted []string
+}
+
+func newFakeCRDClient(crds ...*extv1.CustomResourceDefinition) *fakeCRDClient {
+	c := &fakeCRDClient{crds: map[string]extv1.CustomResourceDefinition{}}
+	for _, crd := range crds {
+		c.crds[crd.Name] = *crd
+	}
+	return c
+}
+
+func (c *fakeCRDClient) Ensure(_ context.Context, crd extv1.CustomResourceDefinition) error {
+	c.crds[crd.Name] = crd
+	return nil
+}
+
+func (c *fakeCRDClient) Delete(_ context.Context, name string) error {
+	delete(c.crds, name)
+	c.deleted = append(c.deleted, name)
+	return nil
+}
+
+func (c *fakeCRDClient) Get(_ context.Context, name string) (*extv1.CustomResourceDefinition, error) {
+	crd, ok := c.crds[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}, name)
+	}
+	return &crd, nil
+}
+
+func (c *fakeCRDClient) List(_ context.Context, labelSelector string) ([]extv1.CustomResourceDefinition, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	var crds []extv1.CustomResourceDefinition
+	for _, crd := range c.crds {
+		if selector.Matches(labels.Set(crd.Labels)) {
+			crds = append(crds, crd)
+		}
+	}
+	return crds, nil
+}
+
+func newTestResourceGroup(name, uid, kind string) *v1alpha1.ResourceGroup {
+	return &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID("uid-" + uid)},
+		Spec: v1alpha1.ResourceGroupSpec{
+			Schema: &v1alpha1.Schema{APIVersion: "v1alpha1", Kind: kind},
+		},
+	}
+}
+
+// newTestCRD returns the CRD generated for the given resource group, labeled
+// as owned by it.
+func newTestCRD(t *testing.T, rg *v1alpha1.ResourceGroup) *extv1.CustomResourceDefinition {
+	t.Helper()
+	c, err := crd.SynthesizeCRD(rg.Spec.Schema.APIVersion, rg.Spec.Schema.Kind,
+		extv1.JSONSchemaProps{Type: "object"}, extv1.JSONSchemaProps{Type: "object"}, true, nil, nil)
+	require.NoError(t, err)
+	labeler, err := metadata.NewKroMetaLabeler("0.1.0", "kro-pod").Merge(metadata.NewResourceGroupLabeler(rg))
+	require.NoError(t, err)
+	labeler.ApplyLabels(c)
+	return c
+}
+
+func TestReconcileResourceGroupCRDNamesConflicts(t *testing.T) {
+	webApp := newTestResourceGroup("webapp", "a", "WebApp")
+	// A different kind, with the same generated plural as WebApp.
+	webApps := newTestResourceGroup("webapps", "b", "WebApps")
+	// A different kind, named after the WebApp list kind.
+	webAppList := newTestResourceGroup("webapp-list", "c", "WebAppList")
+	database := newTestResourceGroup("database", "d", "Database")
+
+	unlabeled := newTestCRD(t, webApp)
+	unlabeled.Labels = nil
+
+	tests := []struct {
+		name     string
+		existing []*extv1.CustomResourceDefinition
+		rg       *v1alpha1.ResourceGroup
+		wantErr  string
+	}{
+		{
+			name: "no existing CRD",
+			rg:   webApp,
+		},
+		{
+			name:     "CRD owned by the same resource group",
+			existing: []*extv1.CustomResourceDefinition{newTestCRD(t, webApp)},
+			rg:       webApp,
+		},
+		{
+			name:     "CRD with different names",
+			existing: []*extv1.CustomResourceDefinition{newTestCRD(t, database)},
+			rg:       webApp,
+		},
+		{
+			name:     "CRD not attributed to a resource group",
+			existing: []*extv1.CustomResourceDefinition{unlabeled},
+			rg:       webApps,
+		},
+		{
+			name:     "colliding plural",
+			existing: []*extv1.CustomResourceDefinition{newTestCRD(t, database), newTestCRD(t, webApp)},
+			rg:       webApps,
+			wantErr:  `plural "webapps" is already used by CRD webapps.kro.run of resource group webapp`,
+		},
+		{
+			name:     "colliding kind",
+			existing: []*extv1.CustomResourceDefinition{newTestCRD(t, webApp)},
+			rg:       webAppList,
+			wantErr:  `kind "WebAppList" is already used by CRD webapps.kro.run of resource group webapp`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crdClient := newFakeCRDClient(tt.existing...)
+			r := &ResourceGroupReconciler{crdManager: crdClient}
+
+			want := newTestCRD(t, tt.rg)
+			err := r.reconcileResourceGroupCRD(context.Background(), tt.rg, want)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				var crdErr *crdError
+				assert.ErrorAs(t, err, &crdErr)
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				// The CRD of the other resource group is left untouched.
+				got, err := crdClient.Get(context.Background(), want.Name)
+				if err == nil {
+					assert.NotEqual(t, string(tt.rg.UID), got.Labels[metadata.ResourceGroupIDLabel])
+				}
+				return
+			}
+			require.NoError(t, err)
+			got, err := crdClient.Get(context.Background(), want.Name)
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.rg.UID), got.Labels[metadata.ResourceGroupIDLabel])
+		})
+	}
+}
+
+func TestCleanupResourceGroupCRDOwnedByAnotherResourceGroup(t *testing.T) {
+	webApp := newTestResourceGroup("webapp", "a", "WebApp")
+	webApps := newTestResourceGroup("webapps", "b", "WebApps")
+
+	crdClient := newFakeCRDClient(newTestCRD(t, webApp))
+	r := &ResourceGroupReconciler{crdManager: crdClient, allowCRDDeletion: true}
+	ctx := logr.NewContext(context.Background(), logr.Discard())
+
+	// The colliding resource group doesn't delete the CRD it never owned.
+	require.NoError(t, r.cleanupResourceGroupCRD(ctx, webApps, extractCRDName(webApps.Spec.Schema.Kind)))
+	assert.Empty(t, crdClient.deleted)
+
+	require.NoError(t, r.cleanupResourceGroupCRD(ctx, webApp, extractCRDName(webApp.Spec.Schema.Kind)))
+	assert.Equal(t, []string{"webapps.kro.run"}, crdClient.deleted)
+}
+
+func TestCleanupResourceGroupOwnedByAnotherResourceGroup(t *testing.T) {
+	webApp := newTestResourceGroup("webapp", "a", "WebApp")
+	duplicate := newTestResourceGroup("webapp-duplicate", "b", "WebApp")
+
+	gvr := metadata.GetResourceGroupInstanceGVR(webApp.Spec.Schema.APIVersion, webApp.Spec.Schema.Kind)
+	dynamicController := dynamiccontroller.NewDynamicController(logr.Discard(), dynamiccontroller.Config{},
+		dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "WebAppList"}))
+	ctx := logr.NewContext(context.Background(), logr.Discard())
+	require.NoError(t, dynamicController.StartServingGVK(ctx, gvr, func(context.Context, ctrl.Request) error {
+		return nil
+	}))
+
+	crdClient := newFakeCRDClient(newTestCRD(t, webApp))
+	r := &ResourceGroupReconciler{
+		crdManager:        crdClient,
+		allowCRDDeletion:  true,
+		dynamicController: dynamicController,
+	}
+
+	// The duplicate resource group neither stops serving the kind of the
+	// owner nor deletes its CRD.
+	require.NoError(t, r.cleanupResourceGroup(ctx, duplicate))
+	assert.True(t, dynamicController.IsServingGVK(gvr))
+	assert.Empty(t, crdClient.deleted)
+
+	require.NoError(t, r.cleanupResourceGroup(ctx, webApp))
+	assert.False(t, dynamicController.IsServingGVK(gvr))
+	assert.Equal(t, []string{"webapps.kro.run"}, crdClient.deleted)
+}
+
+func TestCleanupResourceGroupCRDDeletionPolicy(t *testing.T) {
+	tests := []struct {
+		name             string
+		allowCRDDeletion bool
+		policy           v1alpha1.CRDDeletionPolicy
+		wantDeleted      bool
+		wantBlocked      bool
+	}{
+		{name: "unset policy, deletion allowed", allowCRDDeletion: true, wantDeleted: true},
+		{name: "unset policy, deletion disallowed", allowCRDDeletion: false},
+		{name: "Delete policy, deletion allowed", allowCRDDeletion: true, policy: v1alpha1.CRDDeletionPolicyDelete, wantDeleted: true},
+		{name: "Delete policy, deletion disallowed", allowCRDDeletion: false, policy: v1alpha1.CRDDeletionPolicyDelete, wantBlocked: true},
+		{name: "Orphan policy, deletion allowed", allowCRDDeletion: true, policy: v1alpha1.CRDDeletionPolicyOrphan},
+		{name: "Orphan policy, deletion disallowed", allowCRDDeletion: false, policy: v1alpha1.CRDDeletionPolicyOrphan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := newTestResourceGroup("webapp", "a", "WebApp")
+			rg.Spec.CRDDeletionPolicy = tt.policy
+
+			crdClient := newFakeCRDClient(newTestCRD(t, rg))
+			r := &ResourceGroupReconciler{crdManager: crdClient, allowCRDDeletion: tt.allowCRDDeletion}
+			ctx := logr.NewContext(context.Background(), logr.Discard())
+
+			require.NoError(t, r.cleanupResourceGroupCRD(ctx, rg, extractCRDName(rg.Spec.Schema.Kind)))
+			if tt.wantDeleted {
+				assert.Equal(t, []string{"webapps.kro.run"}, crdClient.deleted)
+			} else {
+				assert.Empty(t, crdClient.deleted)
+			}
+
+			_, blocked := r.crdDeletionPolicy(rg)
+			assert.Equal(t, tt.wantBlocked, blocked != "")
+
+			processor := NewStatusProcessor()
+			processor.setDefaultConditions()
+			processor.processCRDDeletionPolicy(blocked)
+			condition := v1alpha1.GetCondition(processor.conditions,
+				v1alpha1.ResourceGroupConditionTypeCustomResourceDefinitionDeletionBlocked)
+			if !tt.wantBlocked {
+				assert.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			assert.Equal(t, metav1.ConditionTrue, condition.Status)
+			assert.Equal(t, "CRDDeletionDisabled", *condition.Reason)
+			assert.Contains(t, *condition.Message, "--allow-crd-deletion")
+		})
+	}
+}
+
+func TestResyncPeriodOverride(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+		wantErr     string
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "valid duration",
+			annotations: map[string]string{metadata.ResyncPeriodAnnotation: "30m"},
+			want:        30 * time.Minute,
+		},
+		{
+			name:        "invalid duration",
+			annotations: map[string]string{metadata.ResyncPeriodAnnotation: "often"},
+			wantErr:     `invalid kro.run/resync-period annotation: time: invalid duration "often"`,
+		},
+		{
+			name:        "zero duration",
+			annotations: map[string]string{metadata.ResyncPeriodAnnotation: "0s"},
+			wantErr:     `invalid kro.run/resync-period annotation: "0s" is not a positive duration`,
+		},
+		{
+			name:        "negative duration",
+			annotations: map[string]string{metadata.ResyncPeriodAnnotation: "-1h"},
+			wantErr:     `invalid kro.run/resync-period annotation: "-1h" is not a positive duration`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := &v1alpha1.ResourceGroup{ObjectMeta: metav1.ObjectMeta{Name: "my-rg", Annotations: tt.annotations}}
+			got, err := resyncPeriodOverride(rg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
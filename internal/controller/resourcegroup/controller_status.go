@@ -80,6 +80,26 @@ func (sp *StatusProcessor) processMicroControllerError(err error) {
 	sp.state = v1alpha1.ResourceGroupStateInactive
 }
 
+// processResourceGroupLimitError handles the resource groups rejected because
+// the controller already manages the maximum number of resource groups.
+func (sp *StatusProcessor) processResourceGroupLimitError(err error) {
+	sp.conditions = []v1alpha1.Condition{
+		newGraphVerifiedCondition(metav1.ConditionUnknown, "Resource group limit reached"),
+		newCustomResourceDefinitionSyncedCondition(metav1.ConditionUnknown, "Resource group limit reached"),
+		newReconcilerReadyCondition(metav1.ConditionFalse, err.Error()),
+	}
+	sp.state = v1alpha1.ResourceGroupStateInactive
+}
+
+// processCRDDeletionPolicy reports, through a condition, that the CRD won't be
+// deleted along with the resource group although its deletion policy asks for it.
+func (sp *StatusProcessor) processCRDDeletionPolicy(blocked string) {
+	if blocked == "" {
+		return
+	}
+	sp.conditions = append(sp.conditions, newCustomResourceDefinitionDeletionBlockedCondition(blocked))
+}
+
 // setResourceGroupStatus calculates the ResourceGroup status and updates it
 // in the API server.
 func (r *ResourceGroupReconciler) setResourceGroupStatus(
@@ -102,6 +122,7 @@ func (r *ResourceGroupReconciler) setResourceGroupStatus(
 		var graphErr *graphError
 		var crdErr *crdError
 		var microControllerErr *microControllerError
+		var limitErr *resourceGroupLimitError
 
 		switch {
 		case errors.As(reconcileErr, &graphErr):
@@ -110,11 +131,15 @@ func (r *ResourceGroupReconciler) setResourceGroupStatus(
 			processor.processCRDError(reconcileErr)
 		case errors.As(reconcileErr, &microControllerErr):
 			processor.processMicroControllerError(reconcileErr)
+		case errors.As(reconcileErr, &limitErr):
+			processor.processResourceGroupLimitError(reconcileErr)
 		default:
 			log.Error(reconcileErr, "unhandled reconciliation error type")
 			return fmt.Errorf("unhandled reconciliation error: %w", reconcileErr)
 		}
 	}
+	_, blocked := r.crdDeletionPolicy(resourcegroup)
+	processor.processCRDDeletionPolicy(blocked)
 
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Get fresh copy to avoid conflicts
@@ -139,6 +164,28 @@ func (r *ResourceGroupReconciler) setResourceGroupStatus(
 	})
 }
 
+// setCRDDeletionBlocked sets the CustomResourceDefinitionDeletionBlocked
+// condition on a resource group being deleted, whose CRD is kept although its
+// deletion policy asks for it to be deleted.
+func (r *ResourceGroupReconciler) setCRDDeletionBlocked(ctx context.Context, resourcegroup *v1alpha1.ResourceGroup, message string) error {
+	return r.setDeletionCondition(ctx, resourcegroup, newCustomResourceDefinitionDeletionBlockedCondition(message))
+}
+
+// setDeletionCondition sets the given condition on a resource group being
+// deleted, leaving its other conditions untouched.
+func (r *ResourceGroupReconciler) setDeletionCondition(ctx context.Context, resourcegroup *v1alpha1.ResourceGroup, condition v1alpha1.Condition) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &v1alpha1.ResourceGroup{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(resourcegroup), current); err != nil {
+			return fmt.Errorf("failed to get current resource group: %w", err)
+		}
+
+		dc := current.DeepCopy()
+		dc.Status.Conditions = v1alpha1.SetCondition(dc.Status.Conditions, condition)
+		return r.Status().Patch(ctx, dc, client.MergeFrom(current))
+	})
+}
+
 // setManaged sets the resourcegroup as managed, by adding the
 // default finalizer if it doesn't exist.
 func (r *ResourceGroupReconciler) setManaged(ctx context.Context, rg *v1alpha1.ResourceGroup) error {
@@ -182,3 +229,13 @@ func newGraphVerifiedCondition(status metav1.ConditionStatus, reason string) v1a
 func newCustomResourceDefinitionSyncedCondition(status metav1.ConditionStatus, reason string) v1alpha1.Condition {
 	return v1alpha1.NewCondition(v1alpha1.ResourceGroupConditionTypeCustomResourceDefinitionSynced, status, reason, "Custom Resource Definition is synced")
 }
+
+func newCustomResourceDefinitionDeletionBlockedCondition(message string) v1alpha1.Condition {
+	return v1alpha1.NewCondition(v1alpha1.ResourceGroupConditionTypeCustomResourceDefinitionDeletionBlocked,
+		metav1.ConditionTrue, "CRDDeletionDisabled", message)
+}
+
+func newInstanceDeletionBlockedCondition(message string) v1alpha1.Condition {
+	return v1alpha1.NewCondition(v1alpha1.ResourceGroupConditionTypeInstanceDeletionBlocked,
+		metav1.ConditionTrue, "InstancesRemaining", message)
+}
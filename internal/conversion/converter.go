@@ -0,0 +1,178 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package conversion
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/parser"
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/runtime/resolver"
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+const (
+	// instanceVariableName is the name of the CEL variable holding the
+	// instance being converted. It matches the name used to refer to the
+	// instance in resource templates.
+	instanceVariableName = "schema"
+)
+
+// Converter converts instances of a resource group kind between versions,
+// using the conversion rules declared in the resource group schema.
+//
+// When no rule is declared for a pair of versions, the instance is converted
+// as is, only its apiVersion is changed (identity conversion).
+type Converter struct {
+	rules map[versionPair]*compiledRule
+}
+
+// versionPair identifies a conversion from one version to another.
+type versionPair struct {
+	from string
+	to   string
+}
+
+// compiledRule is a conversion rule with its CEL expressions extracted
+// and compiled.
+type compiledRule struct {
+	// spec is the spec template of the converted instance.
+	spec map[string]interface{}
+	// fieldDescriptors are the fields of the spec template containing
+	// CEL expressions.
+	fieldDescriptors []variable.FieldDescriptor
+	// programs maps every expression found in the spec template to its
+	// compiled program.
+	programs map[string]cel.Program
+}
+
+// NewConverter creates a new Converter from the given conversion rules. It
+// returns an error if the rules are inconsistent or if any of their
+// expressions fails to compile.
+func NewConverter(rules []v1alpha1.ConversionRule) (*Converter, error) {
+	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{instanceVariableName}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	converter := &Converter{
+		rules: make(map[versionPair]*compiledRule, len(rules)),
+	}
+	for _, rule := range rules {
+		if rule.From == "" || rule.To == "" {
+			return nil, fmt.Errorf("conversion rule must specify both from and to versions")
+		}
+		if rule.From == rule.To {
+			return nil, fmt.Errorf("conversion rule from %s to %s converts a version to itself", rule.From, rule.To)
+		}
+		pair := versionPair{from: rule.From, to: rule.To}
+		if _, ok := converter.rules[pair]; ok {
+			return nil, fmt.Errorf("found duplicate conversion rules from %s to %s", rule.From, rule.To)
+		}
+
+		compiled, err := compileRule(env, rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid conversion rule from %s to %s: %w", rule.From, rule.To, err)
+		}
+		converter.rules[pair] = compiled
+	}
+	return converter, nil
+}
+
+// compileRule extracts the CEL expressions from the rule spec template and
+// compiles them.
+func compileRule(env *cel.Env, rule v1alpha1.ConversionRule) (*compiledRule, error) {
+	spec := map[string]interface{}{}
+	if err := yaml.UnmarshalStrict(rule.Spec.Raw, &spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %w", err)
+	}
+
+	fieldDescriptors, err := parser.ParseSchemalessResource(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract CEL expressions from spec: %w", err)
+	}
+
+	programs := make(map[string]cel.Program)
+	for _, fieldDescriptor := range fieldDescriptors {
+		for _, expression := range fieldDescriptor.Expressions {
+			if _, ok := programs[expression]; ok {
+				continue
+			}
+			ast, issues := env.Compile(expression)
+			if issues != nil && issues.Err() != nil {
+				return nil, fmt.Errorf("failed to compile expression %s at path %s: %w", expression, fieldDescriptor.Path, issues.Err())
+			}
+			program, err := env.Program(ast)
+			if err != nil {
+				return nil, fmt.Errorf("failed to program expression %s at path %s: %w", expression, fieldDescriptor.Path, err)
+			}
+			programs[expression] = program
+		}
+	}
+
+	return &compiledRule{
+		spec:             spec,
+		fieldDescriptors: fieldDescriptors,
+		programs:         programs,
+	}, nil
+}
+
+// Convert converts the given instance to the given version. The original
+// instance is not modified.
+func (c *Converter) Convert(instance *unstructured.Unstructured, toVersion string) (*unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(instance.GetAPIVersion())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse instance apiVersion: %w", err)
+	}
+
+	converted := instance.DeepCopy()
+	converted.SetAPIVersion(schema.GroupVersion{Group: gv.Group, Version: toVersion}.String())
+
+	rule, ok := c.rules[versionPair{from: gv.Version, to: toVersion}]
+	if !ok {
+		// identity conversion
+		return converted, nil
+	}
+
+	data := make(map[string]interface{}, len(rule.programs))
+	for expression, program := range rule.programs {
+		val, _, err := program.Eval(map[string]interface{}{
+			instanceVariableName: instance.Object,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression %s: %w", expression, err)
+		}
+		value, err := krocel.GoNativeType(val)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert result of expression %s: %w", expression, err)
+		}
+		data[expression] = value
+	}
+
+	spec := runtime.DeepCopyJSON(rule.spec)
+	summary := resolver.NewResolver(spec, data).Resolve(rule.fieldDescriptors)
+	if len(summary.Errors) > 0 {
+		return nil, fmt.Errorf("failed to resolve converted spec: %w", summary.Errors[0])
+	}
+	converted.Object["spec"] = spec
+
+	return converted, nil
+}
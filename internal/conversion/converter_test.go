@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+// renameRules renames spec.replicas (v1alpha1) to spec.replicaCount (v1alpha2)
+// and back.
+var renameRules = []v1alpha1.ConversionRule{
+	{
+		From: "v1alpha1",
+		To:   "v1alpha2",
+		Spec: runtime.RawExtension{Raw: []byte(`{"name": "${schema.spec.name}", "replicaCount": "${schema.spec.replicas}"}`)},
+	},
+	{
+		From: "v1alpha2",
+		To:   "v1alpha1",
+		Spec: runtime.RawExtension{Raw: []byte(`{"name": "${schema.spec.name}", "replicas": "${schema.spec.replicaCount}"}`)},
+	},
+}
+
+func newInstance(apiVersion string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "WebApp",
+			"metadata": map[string]interface{}{
+				"name":      "my-app",
+				"namespace": "default",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestConverter_Convert(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []v1alpha1.ConversionRule
+		instance  *unstructured.Unstructured
+		toVersion string
+		want      *unstructured.Unstructured
+	}{
+		{
+			name:      "renamed field from v1alpha1 to v1alpha2",
+			rules:     renameRules,
+			instance:  newInstance("kro.run/v1alpha1", map[string]interface{}{"name": "app", "replicas": int64(3)}),
+			toVersion: "v1alpha2",
+			want:      newInstance("kro.run/v1alpha2", map[string]interface{}{"name": "app", "replicaCount": int64(3)}),
+		},
+		{
+			name:      "renamed field from v1alpha2 to v1alpha1",
+			rules:     renameRules,
+			instance:  newInstance("kro.run/v1alpha2", map[string]interface{}{"name": "app", "replicaCount": int64(5)}),
+			toVersion: "v1alpha1",
+			want:      newInstance("kro.run/v1alpha1", map[string]interface{}{"name": "app", "replicas": int64(5)}),
+		},
+		{
+			name:      "identity conversion without rules",
+			rules:     nil,
+			instance:  newInstance("kro.run/v1alpha1", map[string]interface{}{"name": "app", "replicas": int64(3)}),
+			toVersion: "v1",
+			want:      newInstance("kro.run/v1", map[string]interface{}{"name": "app", "replicas": int64(3)}),
+		},
+		{
+			name:      "same version",
+			rules:     renameRules,
+			instance:  newInstance("kro.run/v1alpha1", map[string]interface{}{"name": "app", "replicas": int64(3)}),
+			toVersion: "v1alpha1",
+			want:      newInstance("kro.run/v1alpha1", map[string]interface{}{"name": "app", "replicas": int64(3)}),
+		},
+		{
+			name: "string templates",
+			rules: []v1alpha1.ConversionRule{{
+				From: "v1alpha1",
+				To:   "v1alpha2",
+				Spec: runtime.RawExtension{Raw: []byte(`{"image": "${schema.spec.repository}:${schema.spec.tag}"}`)},
+			}},
+			instance:  newInstance("kro.run/v1alpha1", map[string]interface{}{"repository": "nginx", "tag": "1.27"}),
+			toVersion: "v1alpha2",
+			want:      newInstance("kro.run/v1alpha2", map[string]interface{}{"image": "nginx:1.27"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			converter, err := NewConverter(tt.rules)
+			require.NoError(t, err)
+
+			original := tt.instance.DeepCopy()
+			got, err := converter.Convert(tt.instance, tt.toVersion)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, original, tt.instance, "original instance must not be modified")
+		})
+	}
+}
+
+func TestNewConverter_Validation(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []v1alpha1.ConversionRule
+		errContains string
+	}{
+		{
+			name: "expression does not compile",
+			rules: []v1alpha1.ConversionRule{{
+				From: "v1alpha1",
+				To:   "v1alpha2",
+				Spec: runtime.RawExtension{Raw: []byte(`{"replicaCount": "${schema.spec.replicas +}"}`)},
+			}},
+			errContains: "failed to compile expression",
+		},
+		{
+			name: "expression refers to an unknown variable",
+			rules: []v1alpha1.ConversionRule{{
+				From: "v1alpha1",
+				To:   "v1alpha2",
+				Spec: runtime.RawExtension{Raw: []byte(`{"replicaCount": "${instance.spec.replicas}"}`)},
+			}},
+			errContains: "undeclared reference to 'instance'",
+		},
+		{
+			name: "duplicate rules",
+			rules: []v1alpha1.ConversionRule{
+				renameRules[0],
+				renameRules[0],
+			},
+			errContains: "found duplicate conversion rules from v1alpha1 to v1alpha2",
+		},
+		{
+			name: "rule converting a version to itself",
+			rules: []v1alpha1.ConversionRule{{
+				From: "v1alpha1",
+				To:   "v1alpha1",
+				Spec: runtime.RawExtension{Raw: []byte(`{}`)},
+			}},
+			errContains: "converts a version to itself",
+		},
+		{
+			name: "missing version",
+			rules: []v1alpha1.ConversionRule{{
+				From: "v1alpha1",
+				Spec: runtime.RawExtension{Raw: []byte(`{}`)},
+			}},
+			errContains: "must specify both from and to versions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConverter(tt.rules)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// coreAPIGroup is the name the core API group, whose name is empty, is
+// referred to with in the API group lists.
+const coreAPIGroup = "core"
+
+// WithAllowedAPIGroups restricts the resources of the resource groups to the
+// given API groups, e.g "apps" or "core" for the core group. An empty list
+// allows all the API groups that aren't denied.
+func WithAllowedAPIGroups(groups []string) BuilderOption {
+	return func(b *Builder) {
+		b.allowedAPIGroups = groups
+	}
+}
+
+// WithDeniedAPIGroups forbids the resource groups to create resources in the
+// given API groups, e.g "rbac.authorization.k8s.io". The denied groups take
+// precedence over the allowed ones.
+func WithDeniedAPIGroups(groups []string) BuilderOption {
+	return func(b *Builder) {
+		b.deniedAPIGroups = groups
+	}
+}
+
+// ParseAPIGroups parses a comma separated list of API groups, e.g
+// "apps,core,batch". The empty entries are ignored.
+func ParseAPIGroups(list string) []string {
+	var groups []string
+	for _, group := range strings.Split(list, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// validateAPIGroup checks that the resource groups are allowed to create
+// resources in the API group of the given resource.
+func (b *Builder) validateAPIGroup(id string, gvk k8sschema.GroupVersionKind) error {
+	group := gvk.Group
+	if group == "" {
+		group = coreAPIGroup
+	}
+	if slices.Contains(b.deniedAPIGroups, group) {
+		return fmt.Errorf("resource %s of kind %s targets the API group %s, which resource groups are denied to create resources in",
+			id, gvk.Kind, group)
+	}
+	if len(b.allowedAPIGroups) > 0 && !slices.Contains(b.allowedAPIGroups, group) {
+		return fmt.Errorf("resource %s of kind %s targets the API group %s, resource groups are only allowed to create resources in the API groups %s",
+			id, gvk.Kind, group, strings.Join(b.allowedAPIGroups, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_APIGroups(t *testing.T) {
+	rg := generator.NewResourceGroup("test-group",
+		generator.WithSchema("Network", "v1alpha1", map[string]interface{}{"name": "string"}, nil),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+		generator.WithResource("config", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+		}, nil, nil),
+	)
+
+	tests := []struct {
+		name    string
+		allowed string
+		denied  string
+		wantErr string
+	}{
+		{
+			name: "no restriction",
+		},
+		{
+			name:    "allowed groups",
+			allowed: "ec2.services.k8s.aws, core",
+		},
+		{
+			name:    "group not allowed",
+			allowed: "ec2.services.k8s.aws,apps",
+			wantErr: "resource config of kind ConfigMap targets the API group core, " +
+				"resource groups are only allowed to create resources in the API groups ec2.services.k8s.aws, apps",
+		},
+		{
+			name:    "denied group",
+			denied:  "rbac.authorization.k8s.io,ec2.services.k8s.aws",
+			wantErr: "resource vpc of kind VPC targets the API group ec2.services.k8s.aws, which resource groups are denied to create resources in",
+		},
+		{
+			name:    "denied group taking precedence",
+			allowed: "ec2.services.k8s.aws,core",
+			denied:  "core",
+			wantErr: "resource config of kind ConfigMap targets the API group core, which resource groups are denied to create resources in",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+			builder := &Builder{
+				schemaResolver:   fakeResolver,
+				discoveryClient:  fakeDiscovery,
+				resourceEmulator: emulator.NewEmulator(),
+			}
+			WithAllowedAPIGroups(ParseAPIGroups(tt.allowed))(builder)
+			WithDeniedAPIGroups(ParseAPIGroups(tt.denied))(builder)
+
+			_, err := builder.NewResourceGroup(rg.DeepCopy())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -16,8 +16,12 @@ package graph
 import (
 	"fmt"
 	"slices"
+	"strings"
+	"time"
 
 	cel "github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
 	"golang.org/x/exp/maps"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -29,6 +33,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/conversion"
 	"github.com/awslabs/kro/internal/graph/crd"
 	"github.com/awslabs/kro/internal/graph/dag"
 	"github.com/awslabs/kro/internal/graph/emulator"
@@ -36,14 +41,47 @@ import (
 	"github.com/awslabs/kro/internal/graph/schema"
 	"github.com/awslabs/kro/internal/graph/variable"
 	"github.com/awslabs/kro/internal/metadata"
+	"github.com/awslabs/kro/internal/runtime"
 	krocel "github.com/awslabs/kro/pkg/cel"
 	"github.com/awslabs/kro/pkg/cel/ast"
 	"github.com/awslabs/kro/pkg/simpleschema"
 )
 
+// BuilderOption is a function that modifies the builder options.
+type BuilderOption func(*Builder)
+
+// WithBuiltinKindShadowing allows, or not, resource groups to declare a kind
+// that shadows a built-in Kubernetes kind (e.g Pod or Deployment).
+func WithBuiltinKindShadowing(allow bool) BuilderOption {
+	return func(b *Builder) {
+		b.allowBuiltinKindShadowing = allow
+	}
+}
+
+// WithMaxReferencedResources limits the number of distinct resources the
+// expressions of a single field can reference. Fields referencing many
+// resources create a wide dependency fan-in, slowing the reconciles down. A
+// limit of 0 or less disables the check.
+func WithMaxReferencedResources(limit int) BuilderOption {
+	return func(b *Builder) {
+		b.maxReferencedResources = limit
+	}
+}
+
+// WithExpressionCostBudget sets the maximum estimated cost of all the CEL
+// expressions of a resource group together. Expensive expressions slow the
+// reconciles down, the budget protects the controller against them. A budget
+// of 0 disables the check.
+func WithExpressionCostBudget(budget uint64) BuilderOption {
+	return func(b *Builder) {
+		b.expressionCostBudget = budget
+	}
+}
+
 // NewBuilder creates a new GraphBuilder instance.
 func NewBuilder(
 	clientConfig *rest.Config,
+	options ...BuilderOption,
 ) (*Builder, error) {
 	schemaResolver, dc, err := schema.NewCombinedResolver(clientConfig)
 	if err != nil {
@@ -57,6 +95,9 @@ func NewBuilder(
 		schemaResolver:   schemaResolver,
 		discoveryClient:  dc,
 	}
+	for _, opt := range options {
+		opt(rgBuilder)
+	}
 	return rgBuilder, nil
 }
 
@@ -94,6 +135,21 @@ type Builder struct {
 	// validate the CEL expressions. To revisit.
 	resourceEmulator *emulator.Emulator
 	discoveryClient  discovery.DiscoveryInterface
+	// allowBuiltinKindShadowing allows resource groups to declare a kind that
+	// collides with a built-in Kubernetes kind.
+	allowBuiltinKindShadowing bool
+	// maxReferencedResources is the maximum number of distinct resources the
+	// expressions of a field can reference. 0 means no limit.
+	maxReferencedResources int
+	// expressionCostBudget is the maximum estimated cost of all the
+	// expressions of a resource group. 0 means no limit.
+	expressionCostBudget uint64
+	// allowedAPIGroups are the API groups the resource groups can create
+	// resources in. Empty means all the groups that aren't denied.
+	allowedAPIGroups []string
+	// deniedAPIGroups are the API groups the resource groups can't create
+	// resources in.
+	deniedAPIGroups []string
 }
 
 // NewResourceGroup creates a new ResourceGroup object from the given ResourceGroup
@@ -116,6 +172,13 @@ func (b *Builder) NewResourceGroup(originalCR *v1alpha1.ResourceGroup) (*Graph,
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate resourcegroup: %w", err)
 	}
+	// Instance kinds colliding with built-in kinds are confusing to users and
+	// to tools resolving resources by kind or name (e.g kubectl get pods).
+	if !b.allowBuiltinKindShadowing {
+		if err := validateBuiltinKindShadowing(rg.Spec.Schema.Kind); err != nil {
+			return nil, fmt.Errorf("failed to validate resourcegroup: %w", err)
+		}
+	}
 
 	// Now that we did a basic validation of the resource group, we can start understanding
 	// the resources that are part of the resource group.
@@ -150,6 +213,12 @@ func (b *Builder) NewResourceGroup(originalCR *v1alpha1.ResourceGroup) (*Graph,
 		resources[rgResource.ID] = r
 	}
 
+	// Expressions referencing undeclared resources, e.g still referencing a
+	// resource removed from the resource group, are rejected upfront.
+	if err := validateResourceReferences(rg, resources); err != nil {
+		return nil, fmt.Errorf("failed to build resourcegroup '%v': %w", rg.Name, err)
+	}
+
 	// At this stage we have a superficial understanding of the resources that are
 	// part of the resource group. We have the OpenAPI schema for each resource, and
 	// we have extracted the CEL expressions from the schema.
@@ -201,6 +270,12 @@ func (b *Builder) NewResourceGroup(originalCR *v1alpha1.ResourceGroup) (*Graph,
 		return nil, fmt.Errorf("failed to build resourcegroup '%v': %w", rg.Name, err)
 	}
 
+	// A resource can't be rendered from its own fields, they are only known
+	// once it is applied.
+	if err := validateSelfReferences(resources); err != nil {
+		return nil, fmt.Errorf("failed to validate resource CEL expressions: %w", err)
+	}
+
 	// Before getting into the dependency graph, we need to validate the CEL expressions
 	// in the instance resource. In order to do that, we need to isolate each resource
 	// and evaluate the CEL expressions in the context of the resource group. This is done
@@ -210,6 +285,20 @@ func (b *Builder) NewResourceGroup(originalCR *v1alpha1.ResourceGroup) (*Graph,
 		return nil, fmt.Errorf("failed to validate resource CEL expressions: %w", err)
 	}
 
+	// The expressions are valid, we can now make sure they aren't too
+	// expensive to evaluate all together.
+	if err := b.validateExpressionsCost(resources, instance); err != nil {
+		return nil, fmt.Errorf("failed to validate the cost of the CEL expressions: %w", err)
+	}
+
+	// The conversion rules are used to convert instances between versions of
+	// the instance kind. We compile them now to reject invalid expressions
+	// before they reach the conversion webhook.
+	converter, err := buildConverter(rg.Spec.Schema.Conversion, instance.crd.Spec.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build conversion rules: %w", err)
+	}
+
 	// Now that we have the instance resource, we can move into the next stage of
 	// building the resource group. Understanding the relationships between the
 	// resources in the resource group a.k.a the dependency graph.
@@ -232,15 +321,76 @@ func (b *Builder) NewResourceGroup(originalCR *v1alpha1.ResourceGroup) (*Graph,
 		return nil, fmt.Errorf("failed to get topological order: %w", err)
 	}
 
+	// Resources managing the same object would overwrite each other. Only
+	// the literal names can be compared before the instances are reconciled.
+	if err := validateStaticNameCollisions(resources); err != nil {
+		return nil, fmt.Errorf("failed to validate the resource names: %w", err)
+	}
+
+	constraints, err := buildConstraints(rg.Spec.Constraints, resources, instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build constraints: %w", err)
+	}
+
+	// Printer columns reading undeclared status fields and expressions that
+	// don't reference anything are valid, but they are reported to the author
+	// as they are most likely a mistake.
+	expressionWarnings, err := constantExpressionWarnings(resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze the CEL expressions: %w", err)
+	}
+	instanceStatusSchema := instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	warnings := printerColumnWarnings(rg.Spec.Schema.AdditionalPrinterColumns, &instanceStatusSchema)
+	warnings = append(warnings, expressionWarnings...)
+	// A resource depending on a resource that may be excluded should be
+	// excluded along with it.
+	warnings = append(warnings, conditionalDependencyWarnings(resources)...)
+
 	resourceGroup := &Graph{
-		DAG:              dag,
-		Instance:         instance,
-		Resources:        resources,
-		TopologicalOrder: topologicalOrder,
+		DAG:                  dag,
+		Instance:             instance,
+		Resources:            resources,
+		TopologicalOrder:     topologicalOrder,
+		Converter:            converter,
+		IdentityFields:       rg.Spec.Schema.IdentityFields,
+		SensitiveFields:      rg.Spec.Schema.SensitiveFields,
+		RequiredStatusFields: rg.Spec.Schema.RequiredStatusFields,
+		StatusStorage:        rg.Spec.Schema.StatusStorage,
+		Composition:          rg.Spec.Schema.Composition,
+		Pausable:             rg.Spec.Schema.Pausable,
+		Constraints:          constraints,
+		Warnings:             warnings,
 	}
 	return resourceGroup, nil
 }
 
+// buildConverter validates the versions referenced by the conversion rules
+// and compiles the rules into a converter. The rules must convert to versions
+// served by the CRD of the instances, the instances are never requested in
+// other versions. They may convert from versions the CRD doesn't serve
+// anymore, e.g. the version objects were stored in.
+func buildConverter(rules []v1alpha1.ConversionRule, versions []extv1.CustomResourceDefinitionVersion) (*conversion.Converter, error) {
+	served := make([]string, 0, len(versions))
+	for _, version := range versions {
+		if version.Served {
+			served = append(served, version.Name)
+		}
+	}
+	for _, rule := range rules {
+		if err := validateKubernetesVersion(rule.From); err != nil {
+			return nil, fmt.Errorf("invalid conversion rule from version: %w", err)
+		}
+		if err := validateKubernetesVersion(rule.To); err != nil {
+			return nil, fmt.Errorf("invalid conversion rule to version: %w", err)
+		}
+		if !slices.Contains(served, rule.To) {
+			return nil, fmt.Errorf("conversion rule from %s to %s targets a version the CRD doesn't serve, served versions: %s",
+				rule.From, rule.To, strings.Join(served, ", "))
+		}
+	}
+	return conversion.NewConverter(rules)
+}
+
 // buildRGResource builds a resource from the given resource definition.
 // It provides a high-level understanding of the resource, by extracting the
 // OpenAPI schema, emualting the resource and extracting the cel expressions
@@ -265,6 +415,16 @@ func (b *Builder) buildRGResource(rgResource *v1alpha1.Resource, namespacedResou
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract GVK from resource %s: %w", rgResource.ID, err)
 	}
+	if err := b.validateAPIGroup(rgResource.ID, gvk); err != nil {
+		return nil, err
+	}
+
+	// The version of the kind must be served, though not necessarily the
+	// preferred one.
+	servedResource, err := b.servedResource(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion of resource %s: %w", rgResource.ID, err)
+	}
 
 	// 3. Load the OpenAPI schema for the resource.
 	resourceSchema, err := b.schemaResolver.ResolveSchema(gvk)
@@ -293,13 +453,22 @@ func (b *Builder) buildRGResource(rgResource *v1alpha1.Resource, namespacedResou
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate dummy CR for resource %s: %w", rgResource.ID, err)
 		}
-
-		// 5. Extract CEL fieldDescriptors from the schema.
+		// The resources exposed to the CEL expressions carry a synthesized
+		// ready field, derived from their readyWhen expressions.
+		emulatedResource.Object[runtime.ReadyField] = true
+
+		// 5. Extract CEL fieldDescriptors from the schema. Malformed array
+		//    schemas are rejected first, whatever the fields set in the
+		//    resource.
+		if err := parser.ValidateArraySchemas(resourceSchema); err != nil {
+			return nil, fmt.Errorf("invalid schema for resource %s: %w", rgResource.ID, err)
+		}
 		fieldDescriptors, err := parser.ParseResource(resourceObject, resourceSchema)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract CEL expressions from schema for resource %s: %w", rgResource.ID, err)
 		}
 		for _, fieldDescriptor := range fieldDescriptors {
+			fieldDescriptor.EncodeBytes = rgResource.AutoEncodeBytes
 			resourceVariables = append(resourceVariables, &variable.ResourceField{
 				// Assume variables are static, we'll validate them later
 				Kind:            variable.ResourceVariableKindStatic,
@@ -320,7 +489,46 @@ func (b *Builder) buildRGResource(rgResource *v1alpha1.Resource, namespacedResou
 		return nil, fmt.Errorf("failed to parse includeWhen expressions: %v", err)
 	}
 
+	// 8. Parse the named condition expressions
+	if err := validateResourceConditions(rgResource.Conditions); err != nil {
+		return nil, fmt.Errorf("invalid conditions for resource %s: %w", rgResource.ID, err)
+	}
+	conditions := make([]variable.ConditionExpression, 0, len(rgResource.Conditions))
+	for _, condition := range rgResource.Conditions {
+		expressions, err := parser.ParseConditionExpressions([]string{condition.Expression})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse condition %s expression: %v", condition.Type, err)
+		}
+		conditions = append(conditions, variable.ConditionExpression{Type: condition.Type, Expression: expressions[0]})
+	}
+
+	var minReadyDuration time.Duration
+	if rgResource.MinReadyDuration != nil {
+		minReadyDuration = rgResource.MinReadyDuration.Duration
+		if minReadyDuration < 0 {
+			return nil, fmt.Errorf("minReadyDuration of resource %s must not be negative, got %s", rgResource.ID, minReadyDuration)
+		}
+	}
+
+	if err := validateFinalizers(rgResource.Finalizers); err != nil {
+		return nil, fmt.Errorf("invalid finalizers for resource %s: %w", rgResource.ID, err)
+	}
+
+	evaluateOnce, err := validateEvaluateOnce(rgResource.ID, rgResource.EvaluateOnce, resourceVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	// The preferred namespaced resources don't include the kinds used at
+	// another served version.
 	_, isNamespaced := namespacedResources[gvk]
+	isNamespaced = isNamespaced || (servedResource != nil && servedResource.Namespaced)
+	// The scope of the kinds unknown to the discovery client isn't known.
+	if servedResource != nil && !isNamespaced {
+		if err := validateClusterScopedNamespace(rgResource.ID, gvk, resourceObject); err != nil {
+			return nil, err
+		}
+	}
 
 	// Note that at this point we don't inject the dependencies into the resource.
 	return &Resource{
@@ -332,10 +540,29 @@ func (b *Builder) buildRGResource(rgResource *v1alpha1.Resource, namespacedResou
 		variables:              resourceVariables,
 		readyWhenExpressions:   readyWhen,
 		includeWhenExpressions: includeWhen,
+		waitFor:                slices.Clone(rgResource.WaitFor),
+		conditionExpressions:   conditions,
+		minReadyDuration:       minReadyDuration,
+		finalizers:             slices.Clone(rgResource.Finalizers),
+		evaluateOnce:           evaluateOnce,
 		namespaced:             isNamespaced,
 	}, nil
 }
 
+// validateReferencedResources checks that the expressions of the given field
+// don't reference more distinct resources than allowed.
+func (b *Builder) validateReferencedResources(resourceName string, field *variable.ResourceField) error {
+	if b.maxReferencedResources <= 0 || len(field.Dependencies) <= b.maxReferencedResources {
+		return nil
+	}
+	referenced := slices.Clone(field.Dependencies)
+	slices.Sort(referenced)
+	return fmt.Errorf(
+		"field %s of resource %s references %d resources (%s), more than the maximum of %d",
+		field.Path, resourceName, len(referenced), strings.Join(referenced, ", "), b.maxReferencedResources,
+	)
+}
+
 // buildDependencyGraph builds the dependency graph between the resources in the
 // resource group. The dependency graph is an directed acyclic graph that represents
 // the relationships between the resources in the resource group. The graph is used
@@ -352,14 +579,17 @@ func (b *Builder) buildDependencyGraph(
 	error,
 ) {
 
-	resourceNames := maps.Keys(resources)
-	// We also want to allow users to refer to the instance spec in their expressions.
-	resourceNames = append(resourceNames, "schema")
+	resourceIDs := maps.Keys(resources)
+	// We also want to allow users to refer to the instance spec and feature
+	// gates in their expressions.
+	resourceNames := append(slices.Clone(resourceIDs), "schema", featuresVariable)
 
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resourceNames))
+	env, err := newResourcesEnvironment(resourceNames)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
 	}
+	// The resources map is inspected like any other resource.
+	resourceNames = append(resourceNames, resourcesMapVariable)
 
 	directedAcyclicGraph := dag.NewDirectedAcyclicGraph()
 	// Set the vertices of the graph to be the resources defined in the resource group.
@@ -384,6 +614,16 @@ func (b *Builder) buildDependencyGraph(
 				if err != nil {
 					return nil, fmt.Errorf("failed to extract dependencies: %w", err)
 				}
+				// Resources read through the resources map are dependencies too.
+				mapDependencies, err := resourcesMapDependencies(env, expression, resourceName, resourceIDs)
+				if err != nil {
+					return nil, fmt.Errorf("failed to extract dependencies: %w", err)
+				}
+				for _, dependency := range mapDependencies {
+					if !slices.Contains(resourceDependencies, dependency) {
+						resourceDependencies = append(resourceDependencies, dependency)
+					}
+				}
 
 				// Static until proven dynamic.
 				//
@@ -396,12 +636,41 @@ func (b *Builder) buildDependencyGraph(
 				resource.addDependencies(resourceDependencies...)
 				resourceVariable.AddDependencies(resourceDependencies...)
 				// We need to add the dependencies to the graph.
+				reference := dag.EdgeReference{
+					Path:       resourceVariable.Path,
+					Expression: expression,
+				}
 				for _, dependency := range resourceDependencies {
-					if err := directedAcyclicGraph.AddEdge(resourceName, dependency); err != nil {
+					if err := directedAcyclicGraph.AddEdge(resourceName, dependency, reference); err != nil {
 						return nil, err
 					}
 				}
 			}
+			if err := b.validateReferencedResources(resourceName, resourceVariable); err != nil {
+				return nil, err
+			}
+		}
+
+		// The resources listed in waitFor are dependencies too, even if
+		// none of the expressions refer to them.
+		if err := validateWaitFor(resourceName, resource.waitFor, resources); err != nil {
+			return nil, err
+		}
+		for _, dependency := range resource.waitFor {
+			resource.addDependencies(dependency)
+			if err := directedAcyclicGraph.AddEdge(resourceName, dependency, dag.EdgeReference{Path: "waitFor"}); err != nil {
+				return nil, fmt.Errorf("invalid waitFor of resource %s: %w", resourceName, err)
+			}
+		}
+	}
+
+	// The resources are created after the Namespace resources they are
+	// created in, even if none of their expressions refer to them.
+	for _, dependency := range namespaceDependencies(resources) {
+		resources[dependency.resourceID].addDependencies(dependency.namespaceID)
+		reference := dag.EdgeReference{Path: "metadata.namespace"}
+		if err := directedAcyclicGraph.AddEdge(dependency.resourceID, dependency.namespaceID, reference); err != nil {
+			return nil, fmt.Errorf("invalid namespace of resource %s: %w", dependency.resourceID, err)
 		}
 	}
 
@@ -430,6 +699,10 @@ func (b *Builder) buildInstanceResource(
 	// The instance resource is a Kubernetes resource, so it has a GroupVersionKind.
 	gvk := metadata.GetResourceGroupInstanceGVK(apiVersion, kind)
 
+	if err := validateInstanceSchema(rgDefinition); err != nil {
+		return nil, fmt.Errorf("invalid instance schema: %w", err)
+	}
+
 	// We need to unmarshal the instance schema to a map[string]interface{} to
 	// make it easier to work with.
 	unstructuredInstance := map[string]interface{}{}
@@ -443,15 +716,66 @@ func (b *Builder) buildInstanceResource(
 	if err != nil {
 		return nil, fmt.Errorf("failed to build OpenAPI schema for instance: %w", err)
 	}
+	if err := validateFeatureGates(rgDefinition.FeatureGates); err != nil {
+		return nil, fmt.Errorf("invalid feature gates: %w", err)
+	}
+	if err := addFeatureGates(instanceSpecSchema, rgDefinition.FeatureGates); err != nil {
+		return nil, fmt.Errorf("invalid feature gates: %w", err)
+	}
+	if err := addPausedField(instanceSpecSchema, rgDefinition.Pausable); err != nil {
+		return nil, fmt.Errorf("invalid instance spec: %w", err)
+	}
+	if err := applyUnknownFieldsPolicy(instanceSpecSchema, rgDefinition.UnknownFields); err != nil {
+		return nil, fmt.Errorf("invalid instance spec: %w", err)
+	}
 
 	instanceStatusSchema, statusVariables, err := buildStatusSchema(rgDefinition, resources)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build OpenAPI schema for instance status: %w", err)
 	}
+	unstructuredStatus := map[string]interface{}{}
+	if err := yaml.UnmarshalStrict(rgDefinition.Status.Raw, &unstructuredStatus); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal status schema: %w", err)
+	}
+	if err := validateStatusFields(unstructuredStatus, instanceSpecSchema); err != nil {
+		return nil, fmt.Errorf("invalid instance status: %w", err)
+	}
+	if err := validateSensitiveFields(rgDefinition.SensitiveFields, instanceStatusSchema); err != nil {
+		return nil, fmt.Errorf("invalid sensitive fields: %w", err)
+	}
+	if err := validateRequiredStatusFields(rgDefinition.RequiredStatusFields, instanceStatusSchema); err != nil {
+		return nil, fmt.Errorf("invalid required status fields: %w", err)
+	}
+	if err := validateStatusStorage(rgDefinition.StatusStorage, rgDefinition.SensitiveFields, instanceStatusSchema); err != nil {
+		return nil, fmt.Errorf("invalid status storage: %w", err)
+	}
+	// The sensitive fields are never written in the instance status, hence
+	// they are not part of its schema.
+	removeSensitiveFields(instanceStatusSchema, rgDefinition.SensitiveFields)
+	applyStatusStorage(instanceStatusSchema, rgDefinition.StatusStorage)
+	if err := addResourcesStatus(instanceStatusSchema, resources, rgDefinition.Composition); err != nil {
+		return nil, fmt.Errorf("invalid instance status: %w", err)
+	}
 
 	// Synthesize the CRD for the instance resource.
 	overrideStatusFields := true
-	instanceCRD := crd.SynthesizeCRD(apiVersion, kind, *instanceSpecSchema, *instanceStatusSchema, overrideStatusFields)
+	instanceCRD, err := crd.SynthesizeCRD(
+		apiVersion, kind,
+		*instanceSpecSchema, *instanceStatusSchema,
+		overrideStatusFields,
+		rgDefinition.AdditionalPrinterColumns,
+		rgDefinition.Scale,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize CRD for instance: %w", err)
+	}
+	if err := normalizeVersions(instanceCRD.Spec.Versions); err != nil {
+		return nil, fmt.Errorf("invalid instance versions: %w", err)
+	}
+
+	if err := validateIdentityFields(rgDefinition.IdentityFields, instanceCRD.Spec.Versions[0].Schema.OpenAPIV3Schema); err != nil {
+		return nil, fmt.Errorf("invalid identity fields: %w", err)
+	}
 
 	// Emulate the CRD
 	instanceSchemaExt := instanceCRD.Spec.Versions[0].Schema.OpenAPIV3Schema
@@ -464,11 +788,12 @@ func (b *Builder) buildInstanceResource(
 		return nil, fmt.Errorf("failed to generate dummy CR for instance: %w", err)
 	}
 
-	resourceNames := maps.Keys(resources)
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resourceNames))
+	resourceIDs := maps.Keys(resources)
+	env, err := newResourcesEnvironment(resourceIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
 	}
+	resourceNames := append(slices.Clone(resourceIDs), resourcesMapVariable)
 
 	// The instance resource has a set of variables that need to be resolved.
 	instance := &Resource{
@@ -489,6 +814,15 @@ func (b *Builder) buildInstanceResource(
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract dependencies: %w", err)
 		}
+		mapDependencies, err := resourcesMapDependencies(env, statusVariable.Expressions[0], instance.id, resourceIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract dependencies: %w", err)
+		}
+		for _, dependency := range mapDependencies {
+			if !slices.Contains(instanceDependencies, dependency) {
+				instanceDependencies = append(instanceDependencies, dependency)
+			}
+		}
 		if isStatic {
 			return nil, fmt.Errorf("instance status field must refer to a resource: %s", statusVariable.Path)
 		}
@@ -525,6 +859,47 @@ func buildInstanceSpecSchema(rgSchema *v1alpha1.Schema) (*extv1.JSONSchemaProps,
 	return instanceSchema, nil
 }
 
+// addResourcesStatus adds the status.resources field, reporting the named
+// conditions of the resources and the time they became ready, to the instance
+// status schema. The field is only added if at least one resource declares
+// conditions or a minReadyDuration, or if the composition of the instances is
+// reported.
+func addResourcesStatus(statusSchema *extv1.JSONSchemaProps, resources map[string]*Resource, composition bool) error {
+	declared := composition
+	for _, resource := range resources {
+		if len(resource.conditionExpressions) > 0 || resource.minReadyDuration > 0 {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return nil
+	}
+	if _, ok := statusSchema.Properties["resources"]; ok {
+		return fmt.Errorf("status field resources is reserved for the resource conditions and readiness")
+	}
+	if statusSchema.Properties == nil {
+		statusSchema.Properties = map[string]extv1.JSONSchemaProps{}
+	}
+	statusSchema.Properties["resources"] = crd.ResourcesStatusSchema()
+	return nil
+}
+
+// removeSensitiveFields removes the given sensitive fields (e.g status.token)
+// from the instance status schema.
+func removeSensitiveFields(statusSchema *extv1.JSONSchemaProps, sensitiveFields []string) {
+	for _, field := range sensitiveFields {
+		segments := strings.Split(field, ".")[1:]
+		current := statusSchema
+		for _, segment := range segments[:len(segments)-1] {
+			// The properties of the nested schemas are shared with their copy.
+			property := current.Properties[segment]
+			current = &property
+		}
+		delete(current.Properties, segments[len(segments)-1])
+	}
+}
+
 // buildStatusSchema builds the status schema for the instance resource. The
 // status schema is inferred from the CEL expressions in the status field.
 func buildStatusSchema(
@@ -552,10 +927,15 @@ func buildStatusSchema(
 	// Inspection of the CEL expressions to infer the types of the status fields.
 	resourceNames := maps.Keys(resources)
 
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resourceNames))
+	env, err := newResourcesEnvironment(resourceNames)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create CEL environment: %w", err)
 	}
+	resourceNames = append(resourceNames, resourcesMapVariable)
+
+	// resources is the context here, along with the resources map.
+	context := maps.Clone(resources)
+	context[resourcesMapVariable] = newResourcesMapContext(resources, "")
 
 	// statusStructureParts := make([]schema.FieldDescriptor, 0, len(extracted))
 	statusDryRunResults := make(map[string][]ref.Val, len(fieldDescriptors))
@@ -570,9 +950,11 @@ func buildStatusSchema(
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to validate expression context: %w", err)
 			}
+			if err := validateStatusFieldAccess(env, expr, resources); err != nil {
+				return nil, nil, fmt.Errorf("invalid field status.%s: expression %s: %w", found.Path, expr, err)
+			}
 
-			// resources is the context here.
-			value, err := dryRunExpression(env, expr, resources)
+			value, err := dryRunExpression(env, expr, context)
 			if err != nil {
 				return nil, nil, fmt.Errorf("failed to dry-run expression: %w", err)
 			}
@@ -654,7 +1036,16 @@ func extractDependencies(env *cel.Env, expression string, resourceNames []string
 	isStatic := true
 	dependencies := make([]string, 0)
 	for _, resource := range inspectionResult.ResourceDependencies {
-		if resource.ID != "schema" && !slices.Contains(dependencies, resource.ID) {
+		if resource.ID == resourcesMapVariable {
+			// The resources read through the resources map are extracted
+			// separately, see resourcesMapDependencies.
+			isStatic = false
+			continue
+		}
+		if resource.ID == "schema" || resource.ID == featuresVariable {
+			continue
+		}
+		if !slices.Contains(dependencies, resource.ID) {
 			isStatic = false
 			dependencies = append(dependencies, resource.ID)
 		}
@@ -678,14 +1069,17 @@ func extractDependencies(env *cel.Env, expression string, resourceNames []string
 // on.
 func validateResourceCELExpressions(resources map[string]*Resource, instance *Resource) error {
 	resourceNames := maps.Keys(resources)
-	// We also want to allow users to refer to the instance spec in their expressions.
-	resourceNames = append(resourceNames, "schema")
-	conditionFieldNames := []string{"schema"}
+	// We also want to allow users to refer to the instance spec and feature
+	// gates in their expressions.
+	resourceNames = append(resourceNames, "schema", featuresVariable)
+	conditionFieldNames := []string{"schema", featuresVariable}
 
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resourceNames))
+	env, err := newResourcesEnvironment(resourceNames)
 	if err != nil {
 		return fmt.Errorf("failed to create CEL environment: %w", err)
 	}
+	// The resources map is inspected like any other resource.
+	resourceNames = append(resourceNames, resourcesMapVariable)
 	instanceEmulatedCopy := instance.emulatedObject.DeepCopy()
 	if instanceEmulatedCopy != nil && instanceEmulatedCopy.Object != nil {
 		delete(instanceEmulatedCopy.Object, "apiVersion")
@@ -693,13 +1087,26 @@ func validateResourceCELExpressions(resources map[string]*Resource, instance *Re
 		delete(instanceEmulatedCopy.Object, "status")
 	}
 
+	// The macros can iterate over the lists of the instance spec too.
+	schemaResources := maps.Clone(resources)
+	schemaResources["schema"] = instance
+
 	for _, resource := range resources {
 		for _, resourceVariable := range resource.variables {
-			for _, expression := range resourceVariable.Expressions {
+			if err := validateSerializedOutput(env, resourceVariable); err != nil {
+				return fmt.Errorf("invalid field %s of resource %s: %w", resourceVariable.Path, resource.id, err)
+			}
+			for i, expression := range resourceVariable.Expressions {
+				if err := checkExpressionSyntax(env, resourceVariable, i); err != nil {
+					return fmt.Errorf("invalid field %s of resource %s: %w", resourceVariable.Path, resource.id, err)
+				}
 				err := validateCELExpressionContext(env, expression, resourceNames)
 				if err != nil {
 					return fmt.Errorf("failed to validate expression context: '%s' %w", expression, err)
 				}
+				if err := validateElementFieldAccess(env, expression, schemaResources); err != nil {
+					return fmt.Errorf("invalid field %s of resource %s: expression %s: %w", resourceVariable.Path, resource.id, expression, err)
+				}
 
 				// create context
 				context := map[string]*Resource{}
@@ -709,89 +1116,189 @@ func validateResourceCELExpressions(resources map[string]*Resource, instance *Re
 						context[resourceName] = contextResource
 					}
 				}
+				// add the resources map to the context
+				context[resourcesMapVariable] = newResourcesMapContext(resources, resource.id)
 				// add instance spec to the context
 				context["schema"] = &Resource{
 					emulatedObject: &unstructured.Unstructured{
 						Object: instanceEmulatedCopy.Object,
 					},
 				}
+				context[featuresVariable] = newFeaturesContext(instanceEmulatedCopy)
 
-				_, err = dryRunExpression(env, expression, context)
+				output, err := dryRunExpression(env, expression, context)
 				if err != nil {
 					return fmt.Errorf("failed to dry-run expression %s: %w", expression, err)
 				}
-			}
-
-			// validate readyWhen Expressions for resource
-			// Only accepting expressions accessing the status and spec for now
-			// and need to evaluate to a boolean type
-			//
-			// TODO(michaelhtm) It shares some of the logic with the loop from above..maybe
-			// we can refactor them or put it in one function.
-			// I would also suggest separating the dryRuns of readyWhenExpressions
-			// and the resourceExpressions.
-			for _, readyWhenExpression := range resource.readyWhenExpressions {
-				fieldEnv, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{resource.id}))
-				if err != nil {
-					return fmt.Errorf("failed to create CEL environment: %w", err)
+				if err := validateNameOutput(resourceVariable, output); err != nil {
+					return fmt.Errorf("invalid field %s of resource %s: %w", resourceVariable.Path, resource.id, err)
 				}
-
-				err = validateCELExpressionContext(fieldEnv, readyWhenExpression, []string{resource.id})
-				if err != nil {
-					return fmt.Errorf("failed to validate expression context: '%s' %w", readyWhenExpression, err)
+				if err := validateLiteralOutput(env, resourceVariable, output); err != nil {
+					return fmt.Errorf("invalid field %s of resource %s: %w", resourceVariable.Path, resource.id, err)
 				}
-				// create context
-				// add resource fields to the context
-				resourceEmulatedCopy := resource.emulatedObject.DeepCopy()
-				if resourceEmulatedCopy != nil && resourceEmulatedCopy.Object != nil {
-					delete(resourceEmulatedCopy.Object, "apiVersion")
-					delete(resourceEmulatedCopy.Object, "kind")
-				}
-				context := map[string]*Resource{}
-				context[resource.id] = &Resource{
-					emulatedObject: resourceEmulatedCopy,
-				}
-				output, err := dryRunExpression(fieldEnv, readyWhenExpression, context)
+			}
+		}
 
-				if err != nil {
-					return fmt.Errorf("failed to dry-run expression %s: %w", readyWhenExpression, err)
-				}
-				if !krocel.IsBoolType(output) {
-					return fmt.Errorf("output of readyWhen expression %s can only be of type bool", readyWhenExpression)
-				}
+		// validate readyWhen Expressions for resource
+		// Only accepting expressions accessing the status and spec for now
+		// and need to evaluate to a boolean type
+		//
+		// TODO(michaelhtm) It shares some of the logic with the loop from above..maybe
+		// we can refactor them or put it in one function.
+		// I would also suggest separating the dryRuns of readyWhenExpressions
+		// and the resourceExpressions.
+		for _, readyWhenExpression := range resource.readyWhenExpressions {
+			if err := validateResourceBoolExpression(resource, readyWhenExpression, "readyWhen"); err != nil {
+				return err
 			}
+		}
 
-			for _, includeWhenExpression := range resource.includeWhenExpressions {
-				instanceEnv, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resourceNames))
-				if err != nil {
-					return fmt.Errorf("failed to create CEL environment: %w", err)
-				}
+		// The named conditions follow the same rules as the readyWhen
+		// expressions.
+		for _, condition := range resource.conditionExpressions {
+			if err := validateResourceBoolExpression(resource, condition.Expression, "condition "+condition.Type); err != nil {
+				return err
+			}
+		}
 
-				err = validateCELExpressionContext(instanceEnv, includeWhenExpression, conditionFieldNames)
-				if err != nil {
-					return fmt.Errorf("failed to validate expression context: '%s' %w", includeWhenExpression, err)
-				}
-				// create context
-				context := map[string]*Resource{}
-				// for now we will only support the instance context for condition expressions.
-				// With this decision we will decide in creation time, and update time
-				// If we'll be creating resources or not
-				context["schema"] = &Resource{
-					emulatedObject: &unstructured.Unstructured{
-						Object: instanceEmulatedCopy.Object,
-					},
-				}
+		for _, includeWhenExpression := range resource.includeWhenExpressions {
+			instanceEnv, err := krocel.DefaultEnvironment(runtime.EnvironmentOptions(resourceNames)...)
+			if err != nil {
+				return fmt.Errorf("failed to create CEL environment: %w", err)
+			}
 
-				output, err := dryRunExpression(instanceEnv, includeWhenExpression, context)
-				if err != nil {
-					return fmt.Errorf("failed to dry-run expression %s: %w", includeWhenExpression, err)
-				}
-				if !krocel.IsBoolType(output) {
-					return fmt.Errorf("output of condition expression %s can only be of type bool", includeWhenExpression)
-				}
+			err = validateCELExpressionContext(instanceEnv, includeWhenExpression, conditionFieldNames)
+			if err != nil {
+				return fmt.Errorf("failed to validate expression context: '%s' %w", includeWhenExpression, err)
+			}
+			// create context
+			context := map[string]*Resource{}
+			// for now we will only support the instance context for condition expressions.
+			// With this decision we will decide in creation time, and update time
+			// If we'll be creating resources or not
+			context["schema"] = &Resource{
+				emulatedObject: &unstructured.Unstructured{
+					Object: instanceEmulatedCopy.Object,
+				},
+			}
+			context[featuresVariable] = newFeaturesContext(instanceEmulatedCopy)
+
+			output, err := dryRunExpression(instanceEnv, includeWhenExpression, context)
+			if err != nil {
+				return fmt.Errorf("failed to dry-run expression %s: %w", includeWhenExpression, err)
+			}
+			if !krocel.IsBoolType(output) {
+				return fmt.Errorf("output of condition expression %s can only be of type bool", includeWhenExpression)
 			}
 		}
 	}
 
 	return nil
 }
+
+// validateSerializedOutput checks that the output of the serialization
+// functions (e.g toYaml), which is a string, is only assigned to string fields.
+// Only the standalone expressions are checked, the others always produce
+// strings.
+func validateSerializedOutput(env *cel.Env, field *variable.ResourceField) error {
+	if !field.StandaloneExpression || len(field.Expressions) != 1 {
+		return nil
+	}
+	parsed, iss := env.Parse(field.Expressions[0])
+	if iss.Err() != nil {
+		// Reported by the other validations.
+		return nil
+	}
+	if parsed.NativeRep().Expr().Kind() != celast.CallKind {
+		return nil
+	}
+	call := parsed.NativeRep().Expr().AsCall()
+	if call.IsMemberFunction() || !slices.Contains(krocel.SerializationFunctions, call.FunctionName()) {
+		return nil
+	}
+	if field.ExpectedType != "string" {
+		return fmt.Errorf("the output of %s is a string and can't be assigned to a field of type %q",
+			call.FunctionName(), field.ExpectedType)
+	}
+	return nil
+}
+
+// nameFields are the paths of the name fields of the resources, whose values
+// must be DNS names.
+var nameFields = []string{"metadata.name", "metadata.generateName", "metadata.namespace"}
+
+// validateNameOutput checks that the standalone expressions setting a name
+// field output a string: the API server would reject any other type with an
+// opaque error. The other expressions are interpolated in a string template.
+func validateNameOutput(field *variable.ResourceField, output ref.Val) error {
+	if !field.StandaloneExpression || !slices.Contains(nameFields, field.Path) {
+		return nil
+	}
+	if output.Type() != types.StringType {
+		return fmt.Errorf("expression %s sets a name and must output a string, got %s",
+			field.Expressions[0], output.Type().TypeName())
+	}
+	return nil
+}
+
+// validateLiteralOutput checks that the list and map literals (e.g
+// ${[{"name": "http", "port": 80}]}) assigned by standalone expressions have
+// the structure of the field they set: the CEL type checker only knows they
+// are lists or maps, so a missing required field or a value of the wrong type
+// would otherwise only be reported by the API server.
+func validateLiteralOutput(env *cel.Env, field *variable.ResourceField, output ref.Val) error {
+	if !field.StandaloneExpression || len(field.Expressions) != 1 || field.ExpectedSchema == nil {
+		return nil
+	}
+	parsed, iss := env.Parse(field.Expressions[0])
+	if iss.Err() != nil {
+		return nil
+	}
+	switch parsed.NativeRep().Expr().Kind() {
+	case celast.ListKind, celast.MapKind:
+	default:
+		return nil
+	}
+	value, err := krocel.GoNativeType(output)
+	if err != nil {
+		return fmt.Errorf("failed to convert the output of expression %s: %w", field.Expressions[0], err)
+	}
+	return schema.ValidateStructure(field.ExpectedSchema, value, field.Path)
+}
+
+// validateResourceBoolExpression validates an expression evaluated against the
+// resource itself only (e.g readyWhen), and checks that it outputs a boolean.
+func validateResourceBoolExpression(resource *Resource, expression, kind string) error {
+	fieldEnv, err := krocel.DefaultEnvironment(runtime.EnvironmentOptions([]string{resource.id})...)
+	if err != nil {
+		return fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	err = validateCELExpressionContext(fieldEnv, expression, []string{resource.id})
+	if err != nil {
+		return fmt.Errorf("failed to validate expression context: '%s' %w", expression, err)
+	}
+	// create context
+	// add resource fields to the context
+	resourceEmulatedCopy := resource.emulatedObject.DeepCopy()
+	if resourceEmulatedCopy != nil && resourceEmulatedCopy.Object != nil {
+		delete(resourceEmulatedCopy.Object, "apiVersion")
+		delete(resourceEmulatedCopy.Object, "kind")
+		// The ready field is derived from the readyWhen
+		// expressions, they can't refer to it.
+		delete(resourceEmulatedCopy.Object, runtime.ReadyField)
+	}
+	context := map[string]*Resource{}
+	context[resource.id] = &Resource{
+		emulatedObject: resourceEmulatedCopy,
+	}
+	output, err := dryRunExpression(fieldEnv, expression, context)
+
+	if err != nil {
+		return fmt.Errorf("failed to dry-run expression %s: %w", expression, err)
+	}
+	if !krocel.IsBoolType(output) {
+		return fmt.Errorf("output of %s expression %s can only be of type bool", kind, expression)
+	}
+	return nil
+}
@@ -18,8 +18,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/dag"
 	"github.com/awslabs/kro/internal/graph/emulator"
 	"github.com/awslabs/kro/internal/graph/variable"
 	"github.com/awslabs/kro/internal/testutil/generator"
@@ -173,6 +177,13 @@ func TestGraphBuilder_Validation(t *testing.T) {
 					},
 					nil,
 				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "test-vpc",
+					},
+				}, nil, nil),
 				generator.WithResource("subnet", map[string]interface{}{
 					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
 					"kind":       "Subnet",
@@ -290,7 +301,7 @@ func TestGraphBuilder_Validation(t *testing.T) {
 				}, nil, nil),
 			},
 			wantErr: true,
-			errMsg:  "undeclared reference to 'nonexistent'",
+			errMsg:  "expression at status.status references undeclared resource 'nonexistent'",
 		},
 		{
 			name: "invalid field type in resource spec",
@@ -348,6 +359,60 @@ func TestGraphBuilder_Validation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "CEL expressions are not supported for CRDs",
 		},
+		{
+			name: "ready field of resources",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					map[string]interface{}{
+						"vpcReady": "${vpc.ready}",
+						"ready":    "${vpc.ready && subnet.ready}",
+					},
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "test-vpc",
+					},
+				}, []string{"${vpc.status.state == 'available'}"}, nil),
+				generator.WithResource("subnet", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "Subnet",
+					"metadata": map[string]interface{}{
+						"name": "test-subnet",
+					},
+					"spec": map[string]interface{}{
+						"vpcID": "${vpc.ready ? vpc.status.vpcID : ''}",
+					},
+				}, nil, nil),
+			},
+			wantErr: false,
+		},
+		{
+			name: "readyWhen referring to the resource ready field",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "test-vpc",
+					},
+				}, []string{"${vpc.ready}"}, nil),
+			},
+			wantErr: true,
+			errMsg:  "failed to dry-run expression vpc.ready",
+		},
 		{
 			name: "valid instance definition with complex types",
 			resourceGroupOpts: []generator.ResourceGroupOption{
@@ -360,8 +425,8 @@ func TestGraphBuilder_Validation(t *testing.T) {
 						"replicas": "integer | default=3",
 					},
 					map[string]interface{}{
-						"state": "${vpc.status.state}",
-						"id":    "${vpc.status.vpcID}",
+						"vpcState": "${vpc.status.state}",
+						"id":       "${vpc.status.vpcID}",
 					},
 				),
 				generator.WithResource("vpc", map[string]interface{}{
@@ -394,6 +459,230 @@ func TestGraphBuilder_Validation(t *testing.T) {
 	}
 }
 
+func TestGraphBuilder_BuiltinKindShadowing(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	rg := generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Pod", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "test-vpc",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+	)
+
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+	_, err := builder.NewResourceGroup(rg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "kind Pod shadows the built-in kind Pod of the core API group(s)")
+
+	WithBuiltinKindShadowing(true)(builder)
+	_, err = builder.NewResourceGroup(rg)
+	require.NoError(t, err)
+}
+
+func TestGraphBuilder_InstanceSchema(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		status  string
+		wantErr string
+	}{
+		{name: "valid schema", spec: `{"name": "string"}`, status: `{"vpcID": "${vpc.status.vpcID}"}`},
+		{name: "status absent", spec: `{"name": "string"}`},
+		{name: "spec absent", wantErr: "invalid instance schema: instance schema must have a spec property"},
+		{name: "empty spec", spec: `{}`, wantErr: "invalid instance schema: instance schema spec must declare at least one field"},
+		{name: "non-object spec", spec: `"string"`, wantErr: `invalid instance schema: instance schema spec must be of type object, got "string"`},
+		{name: "non-object status", spec: `{"name": "string"}`, status: `["a"]`, wantErr: `invalid instance schema: instance schema status must be of type object, got "array"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Test", "v1alpha1", nil, nil),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "test-vpc",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+			)
+			rg.Spec.Schema.Spec.Raw = []byte(tt.spec)
+			rg.Spec.Schema.Status.Raw = []byte(tt.status)
+
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGraphBuilder_ConversionVersions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{name: "to the served version", from: "v1alpha1", to: "v1alpha2"},
+		{
+			name:    "to a version the CRD doesn't serve",
+			from:    "v1alpha2",
+			to:      "v1beta1",
+			wantErr: "conversion rule from v1alpha2 to v1beta1 targets a version the CRD doesn't serve, served versions: v1alpha2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Test", "v1alpha2", map[string]interface{}{"name": "string"}, nil),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+				}, nil, nil),
+			)
+			rg.Spec.Schema.Conversion = []v1alpha1.ConversionRule{{
+				From: tt.from,
+				To:   tt.to,
+				Spec: runtime.RawExtension{Raw: []byte(`{"name": "${schema.spec.name}"}`)},
+			}}
+
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGraphBuilder_MaxReferencedResources(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	subnet := func(name string) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "Subnet",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+				"vpcID":     "${vpc.status.vpcID}",
+			},
+		}
+	}
+	rg := generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+		generator.WithResource("subnetA", subnet("subnet-a"), nil, nil),
+		generator.WithResource("subnetB", subnet("subnet-b"), nil, nil),
+		generator.WithResource("securityGroup", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "SecurityGroup",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}-sg",
+			},
+			"spec": map[string]interface{}{
+				"vpcID":       "${vpc.status.vpcID}",
+				"description": "${subnetB.status.subnetID} ${subnetA.status.subnetID} ${vpc.status.vpcID}",
+			},
+		}, nil, nil),
+	)
+
+	tests := []struct {
+		name    string
+		limit   int
+		wantErr string
+	}{
+		{
+			name: "no limit",
+		},
+		{
+			name:  "at the limit",
+			limit: 3,
+		},
+		{
+			name:    "beyond the limit",
+			limit:   2,
+			wantErr: "field spec.description of resource securityGroup references 3 resources (subnetA, subnetB, vpc), more than the maximum of 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := &Builder{
+				schemaResolver:   fakeResolver,
+				discoveryClient:  fakeDiscovery,
+				resourceEmulator: emulator.NewEmulator(),
+			}
+			WithMaxReferencedResources(tt.limit)(builder)
+
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
 func TestGraphBuilder_DependencyValidation(t *testing.T) {
 	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
 	builder := &Builder{
@@ -510,6 +799,18 @@ func TestGraphBuilder_DependencyValidation(t *testing.T) {
 
 				// Validate topological order
 				assert.Equal(t, []string{"clusterpolicy", "clusterrole", "vpc", "subnet1", "subnet2", "cluster"}, g.TopologicalOrder)
+
+				// Validate the edges record the fields referencing their dependencies
+				assert.Equal(t, []dag.EdgeReference{
+					{Path: "spec.vpcID", Expression: "vpc.status.vpcID"},
+				}, g.DAG.GetEdgeReferences("subnet1", "vpc"))
+				assert.Equal(t, []dag.EdgeReference{
+					{Path: "spec.roleARN", Expression: "clusterrole.status.roleID"},
+				}, g.DAG.GetEdgeReferences("cluster", "clusterrole"))
+				assert.Equal(t, []dag.EdgeReference{
+					{Path: "spec.resourcesVPCConfig.subnetIDs[1]", Expression: "subnet2.status.subnetID"},
+				}, g.DAG.GetEdgeReferences("cluster", "subnet2"))
+				assert.Empty(t, g.DAG.GetEdgeReferences("cluster", "vpc"))
 			},
 		},
 		{
@@ -535,7 +836,7 @@ func TestGraphBuilder_DependencyValidation(t *testing.T) {
 				}, nil, nil),
 			},
 			wantErr: true,
-			errMsg:  "undeclared reference to 'missingvpc'",
+			errMsg:  "expression at spec.vpcID of resource subnet references undeclared resource 'missingvpc'",
 		},
 		{
 			name: "cyclic dependency",
@@ -933,6 +1234,166 @@ func TestGraphBuilder_DependencyValidation(t *testing.T) {
 				}, g.TopologicalOrder)
 			},
 		},
+		{
+			name: "resources map dependencies",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					map[string]interface{}{
+						"subnetName": "${resources.subnet.metadata.name}",
+					},
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "testvpc",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+				generator.WithResource("subnet", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "Subnet",
+					"metadata": map[string]interface{}{
+						"name": "subnet",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlock": "10.0.1.0/24",
+						"vpcID":     "${resources[\"vpc\"].status.vpcID}",
+					},
+				}, nil, nil),
+			},
+			validateDeps: func(t *testing.T, g *Graph) {
+				assert.Empty(t, g.Resources["vpc"].GetDependencies())
+				assert.Equal(t, []string{"vpc"}, g.Resources["subnet"].GetDependencies())
+				assert.Equal(t, []string{"vpc", "subnet"}, g.TopologicalOrder)
+				assert.Equal(t, []string{"subnet"}, g.Instance.GetDependencies())
+				assert.Equal(t, variable.ResourceVariableKindDynamic, g.Resources["subnet"].GetVariables()[0].Kind)
+			},
+		},
+		{
+			name: "resources map used as a whole depends on all other resources",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "testvpc",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+				generator.WithResource("subnet", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "Subnet",
+					"metadata": map[string]interface{}{
+						"name": "subnet",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlock": "10.0.1.0/24",
+						"vpcID":     "${vpc.status.vpcID}",
+					},
+				}, nil, nil),
+				generator.WithResource("monitor", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]interface{}{
+						"name": "monitor",
+					},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":  "monitor",
+								"image": "monitor:latest",
+								"env": []interface{}{
+									map[string]interface{}{
+										"name":  "RESOURCES",
+										"value": "${resources.map(id, id).join(\",\")}",
+									},
+								},
+							},
+						},
+					},
+				}, nil, nil),
+			},
+			validateDeps: func(t *testing.T, g *Graph) {
+				assert.ElementsMatch(t, []string{"vpc", "subnet"}, g.Resources["monitor"].GetDependencies())
+				assert.Equal(t, []string{"vpc", "subnet", "monitor"}, g.TopologicalOrder)
+			},
+		},
+		{
+			name: "resources map with unknown resource",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "testvpc",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+				generator.WithResource("subnet", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "Subnet",
+					"metadata": map[string]interface{}{
+						"name": "subnet",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlock": "10.0.1.0/24",
+						"vpcID":     "${resources.vpcs.status.vpcID}",
+					},
+				}, nil, nil),
+			},
+			wantErr: true,
+			errMsg:  "failed to dry-run expression",
+		},
+		{
+			name: "resources map with self reference",
+			resourceGroupOpts: []generator.ResourceGroupOption{
+				generator.WithSchema(
+					"Test", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("subnet", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "Subnet",
+					"metadata": map[string]interface{}{
+						"name": "subnet",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlock": "10.0.1.0/24",
+						"vpcID":     "${resources.subnet.metadata.name}",
+					},
+				}, nil, nil),
+			},
+			wantErr: true,
+			errMsg:  "resource subnet refers to itself",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1221,6 +1682,37 @@ func validateVariables(t *testing.T, actual []*variable.ResourceField, expected
 	assert.ElementsMatch(t, expected, actualVars)
 }
 
+func TestRemoveSensitiveFields(t *testing.T) {
+	statusSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"endpoint": {Type: "string"},
+			"token":    {Type: "string"},
+			"database": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"host":     {Type: "string"},
+					"password": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	removeSensitiveFields(statusSchema, []string{"status.token", "status.database.password"})
+	assert.Equal(t, &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"endpoint": {Type: "string"},
+			"database": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"host": {Type: "string"},
+				},
+			},
+		},
+	}, statusSchema)
+}
+
 func TestNewBuilder(t *testing.T) {
 	builder, err := NewBuilder(&rest.Config{})
 	assert.Nil(t, err)
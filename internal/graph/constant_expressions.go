@@ -0,0 +1,63 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/awslabs/kro/pkg/cel/ast"
+)
+
+// constantExpressionWarnings returns a warning for every resource expression
+// that doesn't reference any variable. Such an expression always evaluates to
+// the same value, and is most likely better written as a plain value in the
+// resource template.
+//
+// The expressions are expected to be validated already, an expression that
+// can't be inspected is not reported.
+func constantExpressionWarnings(resources map[string]*Resource) ([]string, error) {
+	resourceNames := append(maps.Keys(resources), "schema", featuresVariable)
+	env, err := newResourcesEnvironment(resourceNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	resourceNames = append(resourceNames, resourcesMapVariable)
+	inspector := ast.NewInspectorWithEnv(env, resourceNames, nil)
+
+	var warnings []string
+	for _, resource := range resources {
+		for _, resourceVariable := range resource.variables {
+			for _, expression := range resourceVariable.Expressions {
+				inspection, err := inspector.Inspect(expression)
+				if err != nil {
+					continue
+				}
+				if len(inspection.ResourceDependencies) > 0 || len(inspection.UnknownResources) > 0 {
+					continue
+				}
+				warnings = append(warnings, fmt.Sprintf(
+					"expression %q in field %s of resource %s doesn't reference any variable, it always evaluates to the same value",
+					expression, resourceVariable.Path, resource.id,
+				))
+			}
+		}
+	}
+	// The resources are iterated in random order, sort the warnings to
+	// report them consistently.
+	slices.Sort(warnings)
+	return warnings, nil
+}
@@ -0,0 +1,78 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newConstantExpressionResourceGroup(name string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+	)
+}
+
+func TestGraphBuilder_ConstantExpressionWarnings(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	t.Run("constant expression", func(t *testing.T) {
+		g, err := builder.NewResourceGroup(newConstantExpressionResourceGroup("vpc-${string(1 + 1)}"))
+		require.NoError(t, err)
+		require.Len(t, g.Warnings, 1)
+		assert.Contains(t, g.Warnings[0], `expression "string(1 + 1)" in field metadata.name of resource vpc`)
+		assert.Contains(t, g.Warnings[0], "always evaluates to the same value")
+	})
+
+	t.Run("variable dependent expression", func(t *testing.T) {
+		g, err := builder.NewResourceGroup(newConstantExpressionResourceGroup("${schema.spec.name}"))
+		require.NoError(t, err)
+		assert.Empty(t, g.Warnings)
+	})
+
+	t.Run("instance hash expression", func(t *testing.T) {
+		// instanceHash() reads the instance, it isn't constant.
+		g, err := builder.NewResourceGroup(newConstantExpressionResourceGroup("vpc-${instanceHash()}"))
+		require.NoError(t, err)
+		assert.Empty(t, g.Warnings)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/parser"
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/runtime"
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+// buildConstraints parses the constraints of a resource group and validates
+// them against the emulated resources: they must refer to at least one
+// resource, as they are checked when the resources they refer to are
+// rendered, and evaluate to a boolean.
+func buildConstraints(constraints []v1alpha1.Constraint, resources map[string]*Resource, instance *Resource) ([]variable.Constraint, error) {
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	resourceNames := make([]string, 0, len(resources)+2)
+	for id := range resources {
+		resourceNames = append(resourceNames, id)
+	}
+	slices.Sort(resourceNames)
+	resourceNames = append(resourceNames, "schema", featuresVariable)
+	env, err := krocel.DefaultEnvironment(runtime.EnvironmentOptions(resourceNames)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	// The constraints see the instance spec like the resource templates do.
+	instanceEmulatedCopy := instance.emulatedObject.DeepCopy()
+	if instanceEmulatedCopy != nil && instanceEmulatedCopy.Object != nil {
+		delete(instanceEmulatedCopy.Object, "apiVersion")
+		delete(instanceEmulatedCopy.Object, "kind")
+		delete(instanceEmulatedCopy.Object, "status")
+	}
+	context := map[string]*Resource{
+		"schema":         {emulatedObject: instanceEmulatedCopy},
+		featuresVariable: newFeaturesContext(instanceEmulatedCopy),
+	}
+	for id, resource := range resources {
+		context[id] = resource
+	}
+
+	parsed := make([]variable.Constraint, 0, len(constraints))
+	for i, constraint := range constraints {
+		expressions, err := parser.ParseConditionExpressions([]string{constraint.Expression})
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse constraint %d expression: %w", i, err)
+		}
+		expression := expressions[0]
+		if err := validateCELExpressionContext(env, expression, resourceNames); err != nil {
+			return nil, fmt.Errorf("failed to validate constraint %d expression '%s': %w", i, expression, err)
+		}
+		dependencies, _, err := extractDependencies(env, expression, resourceNames)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract the dependencies of constraint %d: %w", i, err)
+		}
+		if len(dependencies) == 0 {
+			return nil, fmt.Errorf("constraint %d expression %s must refer to at least one resource", i, expression)
+		}
+		slices.Sort(dependencies)
+
+		output, err := dryRunExpression(env, expression, context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dry-run constraint %d expression %s: %w", i, expression, err)
+		}
+		if !krocel.IsBoolType(output) {
+			return nil, fmt.Errorf("output of constraint %d expression %s can only be of type bool", i, expression)
+		}
+
+		message := constraint.Message
+		if message == "" {
+			message = expression
+		}
+		parsed = append(parsed, variable.Constraint{
+			Expression:   expression,
+			Message:      message,
+			Dependencies: dependencies,
+		})
+	}
+	return parsed, nil
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_Constraints(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name       string
+		constraint v1alpha1.Constraint
+		want       variable.Constraint
+		wantErr    string
+	}{
+		{
+			name: "constraint between two resources",
+			constraint: v1alpha1.Constraint{
+				Expression: "${settings.data.all(k, k in defaults.data)}",
+				Message:    "the settings must override the defaults",
+			},
+			want: variable.Constraint{
+				Expression:   "settings.data.all(k, k in defaults.data)",
+				Message:      "the settings must override the defaults",
+				Dependencies: []string{"defaults", "settings"},
+			},
+		},
+		{
+			name: "message defaults to the expression",
+			constraint: v1alpha1.Constraint{
+				Expression: "${settings.metadata.name != schema.spec.name}",
+			},
+			want: variable.Constraint{
+				Expression:   "settings.metadata.name != schema.spec.name",
+				Message:      "settings.metadata.name != schema.spec.name",
+				Dependencies: []string{"settings"},
+			},
+		},
+		{
+			name:       "not a standalone expression",
+			constraint: v1alpha1.Constraint{Expression: "settings-${settings.metadata.name}"},
+			wantErr:    "only standalone expressions are allowed",
+		},
+		{
+			name:       "no resource referred to",
+			constraint: v1alpha1.Constraint{Expression: "${schema.spec.name != ''}"},
+			wantErr:    "must refer to at least one resource",
+		},
+		{
+			name:       "unknown resource",
+			constraint: v1alpha1.Constraint{Expression: "${database.spec.size > 0}"},
+			wantErr:    "found unknown resources",
+		},
+		{
+			name:       "not a boolean",
+			constraint: v1alpha1.Constraint{Expression: "${settings.metadata.name}"},
+			wantErr:    "can only be of type bool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema(
+					"App", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("defaults", configMapTemplate("${schema.spec.name}-defaults", ""), nil, nil),
+				generator.WithResource("settings", configMapTemplate("${schema.spec.name}-settings", ""), nil, nil),
+			)
+			rg.Spec.Constraints = []v1alpha1.Constraint{tt.constraint}
+			g, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []variable.Constraint{tt.want}, g.Constraints)
+		})
+	}
+}
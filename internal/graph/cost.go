@@ -0,0 +1,134 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/checker"
+	"golang.org/x/exp/maps"
+)
+
+// maxObjectSize is the maximum size, in bytes, of a Kubernetes object. It
+// bounds the size of the strings, lists and maps the expressions work on.
+const maxObjectSize = 3 * 1024 * 1024
+
+// mostExpensiveExpressionsCount is the number of expressions named in the
+// error reporting a ResourceGroup over its cost budget.
+const mostExpensiveExpressionsCount = 3
+
+// sizeEstimator helps the CEL cost estimation, by bounding the size of the
+// values of unknown sizes by the maximum size of a Kubernetes object.
+type sizeEstimator struct{}
+
+func (sizeEstimator) EstimateSize(checker.AstNode) *checker.SizeEstimate {
+	return &checker.SizeEstimate{Min: 0, Max: maxObjectSize}
+}
+
+func (sizeEstimator) EstimateCallCost(string, string, *checker.AstNode, []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
+
+// expressionCost is the estimated worst case cost of an expression.
+type expressionCost struct {
+	expression string
+	cost       uint64
+}
+
+// validateExpressionsCost sums the estimated worst case cost of all the
+// expressions of the resource group, and checks it doesn't exceed the cost
+// budget of the builder.
+func (b *Builder) validateExpressionsCost(resources map[string]*Resource, instance *Resource) error {
+	if b.expressionCostBudget == 0 {
+		return nil
+	}
+
+	resourceNames := append(maps.Keys(resources), "schema", featuresVariable)
+	env, err := newResourcesEnvironment(resourceNames)
+	if err != nil {
+		return fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	var total checker.CostEstimate
+	var costs []expressionCost
+	estimate := func(expression string) error {
+		ast, issues := env.Compile(expression)
+		if issues != nil && issues.Err() != nil {
+			return fmt.Errorf("failed to compile expression %s: %w", expression, issues.Err())
+		}
+		cost, err := env.EstimateCost(ast, sizeEstimator{})
+		if err != nil {
+			return fmt.Errorf("failed to estimate the cost of expression %s: %w", expression, err)
+		}
+		total = total.Add(cost)
+		costs = append(costs, expressionCost{expression: expression, cost: cost.Max})
+		return nil
+	}
+
+	for _, resource := range resources {
+		for _, resourceVariable := range resource.variables {
+			for _, expression := range resourceVariable.Expressions {
+				if err := estimate(expression); err != nil {
+					return err
+				}
+			}
+		}
+		for _, expression := range resource.readyWhenExpressions {
+			if err := estimate(expression); err != nil {
+				return err
+			}
+		}
+		for _, expression := range resource.includeWhenExpressions {
+			if err := estimate(expression); err != nil {
+				return err
+			}
+		}
+		for _, condition := range resource.conditionExpressions {
+			if err := estimate(condition.Expression); err != nil {
+				return err
+			}
+		}
+	}
+	for _, instanceVariable := range instance.variables {
+		for _, expression := range instanceVariable.Expressions {
+			if err := estimate(expression); err != nil {
+				return err
+			}
+		}
+	}
+
+	if total.Max <= b.expressionCostBudget {
+		return nil
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool {
+		if costs[i].cost == costs[j].cost {
+			return costs[i].expression < costs[j].expression
+		}
+		return costs[i].cost > costs[j].cost
+	})
+	if len(costs) > mostExpensiveExpressionsCount {
+		costs = costs[:mostExpensiveExpressionsCount]
+	}
+	mostExpensive := make([]string, 0, len(costs))
+	for _, c := range costs {
+		mostExpensive = append(mostExpensive, fmt.Sprintf("%q (%d)", c.expression, c.cost))
+	}
+	return fmt.Errorf(
+		"the estimated cost of the expressions, %d, exceeds the budget of %d. The most expensive expressions are: %s",
+		total.Max, b.expressionCostBudget, strings.Join(mostExpensive, ", "),
+	)
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newCostResourceGroup(description string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name":  "string",
+				"rules": "[]string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, []string{"${vpc.status.state == 'available'}"}, nil),
+		generator.WithResource("securityGroup", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "SecurityGroup",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}-sg",
+			},
+			"spec": map[string]interface{}{
+				"vpcID":       "${vpc.status.vpcID}",
+				"description": description,
+			},
+		}, nil, nil),
+	)
+}
+
+func TestGraphBuilder_ExpressionCostBudget(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	const expensive = "schema.spec.rules.map(r, schema.spec.rules.filter(o, o == r).size()).size() > 0"
+
+	tests := []struct {
+		name        string
+		description string
+		budget      uint64
+		wantErr     []string
+	}{
+		{
+			name:        "no budget",
+			description: "${string(" + expensive + ")}",
+		},
+		{
+			name:        "under the budget",
+			description: "${schema.spec.name}",
+			budget:      1000,
+		},
+		{
+			name:        "over the budget",
+			description: "${string(" + expensive + ")}",
+			budget:      1000,
+			wantErr: []string{
+				"exceeds the budget of 1000",
+				`The most expensive expressions are: "string(` + expensive + `)"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := &Builder{
+				schemaResolver:   fakeResolver,
+				discoveryClient:  fakeDiscovery,
+				resourceEmulator: emulator.NewEmulator(),
+			}
+			WithExpressionCostBudget(tt.budget)(builder)
+
+			_, err := builder.NewResourceGroup(newCostResourceGroup(tt.description))
+			if len(tt.wantErr) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
@@ -25,11 +25,39 @@ import (
 
 // SynthesizeCRD generates a CustomResourceDefinition for a given API version and kind
 // with the provided spec and status schemas~
-func SynthesizeCRD(apiVersion, kind string, spec, status extv1.JSONSchemaProps, statusFieldsOverride bool) *extv1.CustomResourceDefinition {
-	return newCRD(apiVersion, kind, newCRDSchema(spec, status, statusFieldsOverride))
+//
+// additionalPrinterColumns are appended to the default printer columns. An error
+// is returned if any of them is invalid.
+//
+// If scale is not nil, the scale subresource is enabled. An error is returned if
+// its paths don't exist in the spec and status schemas.
+func SynthesizeCRD(
+	apiVersion, kind string,
+	spec, status extv1.JSONSchemaProps,
+	statusFieldsOverride bool,
+	additionalPrinterColumns []extv1.CustomResourceColumnDefinition,
+	scale *extv1.CustomResourceSubresourceScale,
+) (*extv1.CustomResourceDefinition, error) {
+	if err := validateAdditionalPrinterColumns(additionalPrinterColumns); err != nil {
+		return nil, fmt.Errorf("invalid additional printer columns: %w", err)
+	}
+	schema := newCRDSchema(spec, status, statusFieldsOverride)
+	if err := validateScaleSubresource(scale, schema); err != nil {
+		return nil, fmt.Errorf("invalid scale subresource: %w", err)
+	}
+	return newCRD(apiVersion, kind, schema, additionalPrinterColumns, scale), nil
 }
 
-func newCRD(apiVersion, kind string, schema *extv1.JSONSchemaProps) *extv1.CustomResourceDefinition {
+func newCRD(
+	apiVersion, kind string,
+	schema *extv1.JSONSchemaProps,
+	additionalPrinterColumns []extv1.CustomResourceColumnDefinition,
+	scale *extv1.CustomResourceSubresourceScale,
+) *extv1.CustomResourceDefinition {
+	printerColumns := make([]extv1.CustomResourceColumnDefinition, 0, len(defaultAdditionalPrinterColumns)+len(additionalPrinterColumns))
+	printerColumns = append(printerColumns, defaultAdditionalPrinterColumns...)
+	printerColumns = append(printerColumns, additionalPrinterColumns...)
+
 	pluralKind := flect.Pluralize(strings.ToLower(kind))
 	return &extv1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
@@ -55,8 +83,9 @@ func newCRD(apiVersion, kind string, schema *extv1.JSONSchemaProps) *extv1.Custo
 					},
 					Subresources: &extv1.CustomResourceSubresources{
 						Status: &extv1.CustomResourceSubresourceStatus{},
+						Scale:  scale,
 					},
-					AdditionalPrinterColumns: defaultAdditionalPrinterColumns,
+					AdditionalPrinterColumns: printerColumns,
 				},
 			},
 		},
@@ -76,6 +105,21 @@ func newCRDSchema(spec, status extv1.JSONSchemaProps, statusFieldsOverride bool)
 		if _, ok := status.Properties["conditions"]; !ok {
 			status.Properties["conditions"] = defaultConditionsType
 		}
+		if _, ok := status.Properties["lastReconcileError"]; !ok {
+			status.Properties["lastReconcileError"] = defaultLastReconcileErrorType
+		}
+		if _, ok := status.Properties["reconcileSummary"]; !ok {
+			status.Properties["reconcileSummary"] = defaultReconcileSummaryType
+		}
+		if _, ok := status.Properties["stableID"]; !ok {
+			status.Properties["stableID"] = defaultStableIDType
+		}
+		if _, ok := status.Properties["evaluatedOnce"]; !ok {
+			status.Properties["evaluatedOnce"] = defaultEvaluatedOnceType
+		}
+		if _, ok := status.Properties["pendingDeletions"]; !ok {
+			status.Properties["pendingDeletions"] = defaultPendingDeletionsType
+		}
 	}
 
 	return &extv1.JSONSchemaProps{
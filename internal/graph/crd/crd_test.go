@@ -0,0 +1,191 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package crd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestSynthesizeCRD_AdditionalPrinterColumns(t *testing.T) {
+	tests := []struct {
+		name        string
+		columns     []extv1.CustomResourceColumnDefinition
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:    "no additional columns",
+			columns: nil,
+		},
+		{
+			name: "standard priority column",
+			columns: []extv1.CustomResourceColumnDefinition{
+				{Name: "Replicas", Type: "integer", JSONPath: ".spec.replicas", Priority: 0},
+			},
+		},
+		{
+			name: "wide priority column",
+			columns: []extv1.CustomResourceColumnDefinition{
+				{Name: "Image", Type: "string", JSONPath: ".spec.image", Priority: 1},
+			},
+		},
+		{
+			name: "priority greater than 1",
+			columns: []extv1.CustomResourceColumnDefinition{
+				{Name: "Replicas", Type: "integer", JSONPath: ".spec.replicas", Priority: 0},
+				{Name: "Image", Type: "string", JSONPath: ".spec.image", Priority: 2},
+			},
+			wantErr:     true,
+			errContains: `invalid priority 2 for printer column "Image"`,
+		},
+		{
+			name: "negative priority",
+			columns: []extv1.CustomResourceColumnDefinition{
+				{Name: "Replicas", Type: "integer", JSONPath: ".spec.replicas", Priority: -1},
+			},
+			wantErr:     true,
+			errContains: `invalid priority -1 for printer column "Replicas"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crd, err := SynthesizeCRD("v1alpha1", "WebApp", extv1.JSONSchemaProps{}, extv1.JSONSchemaProps{}, true, tt.columns, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			columns := crd.Spec.Versions[0].AdditionalPrinterColumns
+			require.Len(t, columns, len(defaultAdditionalPrinterColumns)+len(tt.columns))
+			assert.Equal(t, defaultAdditionalPrinterColumns, columns[:len(defaultAdditionalPrinterColumns)])
+			for i, column := range tt.columns {
+				assert.Equal(t, column, columns[len(defaultAdditionalPrinterColumns)+i])
+			}
+		})
+	}
+}
+
+func TestSynthesizeCRD_ScaleSubresource(t *testing.T) {
+	spec := extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"replicas": {Type: "integer"},
+			"image":    {Type: "string"},
+		},
+	}
+	status := extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"replicas": {Type: "integer"},
+			"selector": {Type: "string"},
+		},
+	}
+	labelSelectorPath := func(path string) *string { return &path }
+
+	tests := []struct {
+		name        string
+		scale       *extv1.CustomResourceSubresourceScale
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "no scale subresource",
+		},
+		{
+			name: "replicas paths",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.replicas",
+				StatusReplicasPath: ".status.replicas",
+			},
+		},
+		{
+			name: "replicas and label selector paths",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.replicas",
+				StatusReplicasPath: ".status.replicas",
+				LabelSelectorPath:  labelSelectorPath(".status.selector"),
+			},
+		},
+		{
+			name: "spec replicas path not found",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.size",
+				StatusReplicasPath: ".status.replicas",
+			},
+			wantErr:     true,
+			errContains: `specReplicasPath ".spec.size" not found in the instance schema`,
+		},
+		{
+			name: "status replicas path under spec",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.replicas",
+				StatusReplicasPath: ".spec.replicas",
+			},
+			wantErr:     true,
+			errContains: `statusReplicasPath ".spec.replicas" must be a path under .status`,
+		},
+		{
+			name: "path without leading dot",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   "spec.replicas",
+				StatusReplicasPath: ".status.replicas",
+			},
+			wantErr:     true,
+			errContains: `specReplicasPath "spec.replicas" must be a path under .spec`,
+		},
+		{
+			name: "replicas path of the wrong type",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.image",
+				StatusReplicasPath: ".status.replicas",
+			},
+			wantErr:     true,
+			errContains: `specReplicasPath ".spec.image" must point to a field of type integer, got string`,
+		},
+		{
+			name: "label selector path not found",
+			scale: &extv1.CustomResourceSubresourceScale{
+				SpecReplicasPath:   ".spec.replicas",
+				StatusReplicasPath: ".status.replicas",
+				LabelSelectorPath:  labelSelectorPath(".status.labels"),
+			},
+			wantErr:     true,
+			errContains: `labelSelectorPath ".status.labels" not found in the instance schema`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crd, err := SynthesizeCRD("v1alpha1", "WebApp", spec, status, true, nil, tt.scale)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			subresources := crd.Spec.Versions[0].Subresources
+			require.NotNil(t, subresources)
+			assert.NotNil(t, subresources.Status)
+			assert.Equal(t, tt.scale, subresources.Scale)
+		})
+	}
+}
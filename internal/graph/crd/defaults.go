@@ -49,6 +49,136 @@ var (
 			},
 		},
 	}
+	// defaultResourcesType is the schema of the status.resources field,
+	// reporting the named conditions of the instance resources, the time
+	// they became ready, and, when the composition is reported, their
+	// identity and readiness.
+	defaultResourcesType = extv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extv1.JSONSchemaPropsOrArray{
+			Schema: &extv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"id": {
+						Type: "string",
+					},
+					"conditions": defaultConditionsType,
+					"readySince": {
+						Type:   "string",
+						Format: "date-time",
+					},
+					"apiVersion": {
+						Type: "string",
+					},
+					"kind": {
+						Type: "string",
+					},
+					"name": {
+						Type: "string",
+					},
+					"namespace": {
+						Type: "string",
+					},
+					"state": {
+						Type: "string",
+					},
+					"ready": {
+						Type: "boolean",
+					},
+				},
+			},
+		},
+	}
+	// defaultLastReconcileErrorType is the schema of the
+	// status.lastReconcileError field, reporting the error of the last failed
+	// reconciliation of an instance.
+	defaultLastReconcileErrorType = extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"resource": {
+				Type: "string",
+			},
+			"path": {
+				Type: "string",
+			},
+			"stage": {
+				Type: "string",
+			},
+			"message": {
+				Type: "string",
+			},
+		},
+	}
+	// defaultReconcileSummaryType is the schema of the status.reconcileSummary
+	// field, counting the outcomes of the resources in the last
+	// reconciliation of an instance.
+	defaultReconcileSummaryType = extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"created": {
+				Type: "integer",
+			},
+			"updated": {
+				Type: "integer",
+			},
+			"skipped": {
+				Type: "integer",
+			},
+			"failed": {
+				Type: "integer",
+			},
+			"lastSuccessfulReconcileTime": {
+				Type:   "string",
+				Format: "date-time",
+			},
+		},
+	}
+	// defaultStableIDType is the schema of the status.stableID field, the
+	// identifier of an instance derived from its UID.
+	defaultStableIDType = extv1.JSONSchemaProps{
+		Type: "string",
+	}
+	// preserveUnknownFields is the value of the x-kubernetes-preserve-unknown-fields
+	// extension of the free form default status fields.
+	preserveUnknownFields = true
+	// defaultEvaluatedOnceType is the schema of the status.evaluatedOnce
+	// field, holding the values of the evaluateOnce fields of the resources
+	// keyed by resource ID then by path.
+	defaultEvaluatedOnceType = extv1.JSONSchemaProps{
+		Type: "object",
+		AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+			Schema: &extv1.JSONSchemaProps{
+				Type:                   "object",
+				XPreserveUnknownFields: &preserveUnknownFields,
+			},
+		},
+	}
+	// defaultPendingDeletionsType is the schema of the status.pendingDeletions
+	// field, listing the excluded resources waiting for their grace period
+	// to elapse before being deleted.
+	defaultPendingDeletionsType = extv1.JSONSchemaProps{
+		Type: "array",
+		Items: &extv1.JSONSchemaPropsOrArray{
+			Schema: &extv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"id": {
+						Type: "string",
+					},
+					"name": {
+						Type: "string",
+					},
+					"namespace": {
+						Type: "string",
+					},
+					"deleteAfter": {
+						Type:   "string",
+						Format: "date-time",
+					},
+				},
+			},
+		},
+	}
 	// additionalPrinterColumns specifies additional columns returned in Table output.
 	// See https://kubernetes.io/docs/reference/using-api/api-concepts/#receiving-resources-as-tables for details.
 	// Sample output for `kubectl get clusters`
@@ -82,3 +212,9 @@ var (
 		},
 	}
 )
+
+// ResourcesStatusSchema returns the schema of the status.resources field of the
+// instances, holding the named conditions of their resources.
+func ResourcesStatusSchema() extv1.JSONSchemaProps {
+	return *defaultResourcesType.DeepCopy()
+}
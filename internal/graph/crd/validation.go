@@ -0,0 +1,93 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package crd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	// printerColumnPriorityStandard is the priority of columns displayed
+	// in the standard table output.
+	printerColumnPriorityStandard int32 = 0
+	// printerColumnPriorityWide is the priority of columns only displayed
+	// in the wide table output (e.g `kubectl get -o wide`).
+	printerColumnPriorityWide int32 = 1
+)
+
+// validateAdditionalPrinterColumns validates the additional printer columns
+// provided by the user.
+//
+// Kubernetes only recognizes priority 0 (standard view) and 1 (wide view),
+// columns with any other priority are never displayed, so we reject them
+// instead of silently generating ineffective columns.
+func validateAdditionalPrinterColumns(columns []extv1.CustomResourceColumnDefinition) error {
+	for _, column := range columns {
+		if column.Priority != printerColumnPriorityStandard && column.Priority != printerColumnPriorityWide {
+			return fmt.Errorf(
+				"invalid priority %d for printer column %q: priority must be %d (standard) or %d (wide)",
+				column.Priority, column.Name, printerColumnPriorityStandard, printerColumnPriorityWide,
+			)
+		}
+	}
+	return nil
+}
+
+// validateScaleSubresource validates the paths of the scale subresource
+// against the schema of the CRD. The API server only validates their syntax,
+// so a path to a field that doesn't exist would give a scale subresource that
+// never works.
+func validateScaleSubresource(scale *extv1.CustomResourceSubresourceScale, schema *extv1.JSONSchemaProps) error {
+	if scale == nil {
+		return nil
+	}
+	if err := validateScalePath(schema, "specReplicasPath", scale.SpecReplicasPath, "integer", "spec"); err != nil {
+		return err
+	}
+	if err := validateScalePath(schema, "statusReplicasPath", scale.StatusReplicasPath, "integer", "status"); err != nil {
+		return err
+	}
+	if scale.LabelSelectorPath != nil {
+		if err := validateScalePath(schema, "labelSelectorPath", *scale.LabelSelectorPath, "string", "spec", "status"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateScalePath checks that the given path (e.g .spec.replicas) is under
+// one of the allowed roots, and points to a field of the expected type.
+func validateScalePath(schema *extv1.JSONSchemaProps, name, path, expectedType string, roots ...string) error {
+	segments := strings.Split(strings.TrimPrefix(path, "."), ".")
+	if !strings.HasPrefix(path, ".") || len(segments) < 2 || !slices.Contains(roots, segments[0]) {
+		return fmt.Errorf("%s %q must be a path under .%s (e.g .%s.replicas)", name, path, strings.Join(roots, " or ."), roots[0])
+	}
+
+	current := schema
+	for _, segment := range segments {
+		property, ok := current.Properties[segment]
+		if segment == "" || !ok {
+			return fmt.Errorf("%s %q not found in the instance schema", name, path)
+		}
+		current = &property
+	}
+	if current.Type != expectedType {
+		return fmt.Errorf("%s %q must point to a field of type %s, got %s", name, path, expectedType, current.Type)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+func init() {
+	// Libraries are registered once per process, like the controller does
+	// from the init functions of the packages it blank imports.
+	krocel.RegisterLibrary("graph-test", func() cel.EnvOption {
+		return cel.Function("acme.shout",
+			cel.Overload("acme_shout_string",
+				[]*cel.Type{cel.StringType},
+				cel.StringType,
+				cel.UnaryBinding(func(value ref.Val) ref.Val {
+					return types.String(strings.ToUpper(string(value.(types.String))))
+				}),
+			),
+		)
+	})
+}
+
+func TestGraphBuilder_CustomLibrary(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	g, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"WebApp", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			map[string]interface{}{
+				"node": "${acme.shout(pod.spec.nodeName)}",
+			},
+		),
+		generator.WithResource("pod", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"nodeName": "${acme.shout(schema.spec.name)}",
+			},
+		}, nil, nil),
+	))
+	require.NoError(t, err)
+
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kro.run/v1alpha1",
+		"kind":       "WebApp",
+		"metadata":   map[string]interface{}{"name": "my-app", "namespace": "default"},
+		"spec":       map[string]interface{}{"name": "web"},
+	}}
+	rt, err := g.NewGraphRuntime(instance)
+	require.NoError(t, err)
+
+	pod, _ := rt.GetResource("pod")
+	nodeName, _, err := unstructured.NestedString(pod.Object, "spec", "nodeName")
+	require.NoError(t, err)
+	assert.Equal(t, "WEB", nodeName)
+}
@@ -15,6 +15,7 @@ package dag
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -26,6 +27,20 @@ type Vertex struct {
 	// Edges stores the IDs of the nodes that this node has an outgoing edge to.
 	// In kro, this would be the children of a resource.
 	Edges map[string]struct{}
+	// EdgeReferences stores, for each outgoing edge, the references that
+	// caused the edge to be created. The map is keyed by the ID of the node
+	// the edge points to.
+	EdgeReferences map[string][]EdgeReference
+}
+
+// EdgeReference describes a field of a node that references another node,
+// e.g a resource field containing a CEL expression pointing to another
+// resource. It is mostly useful to understand why an edge exists.
+type EdgeReference struct {
+	// Path is the path of the field holding the expression.
+	Path string
+	// Expression is the expression referencing the other node.
+	Expression string
 }
 
 // DirectedAcyclicGraph represents a directed acyclic graph
@@ -47,8 +62,9 @@ func (d *DirectedAcyclicGraph) AddVertex(id string) error {
 		return fmt.Errorf("node %s already exists", id)
 	}
 	d.Vertices[id] = &Vertex{
-		ID:    id,
-		Edges: make(map[string]struct{}),
+		ID:             id,
+		Edges:          make(map[string]struct{}),
+		EdgeReferences: make(map[string][]EdgeReference),
 	}
 	return nil
 }
@@ -66,8 +82,10 @@ func formatCycle(cycle []string) string {
 	return strings.Join(cycle, " -> ")
 }
 
-// AddEdge adds a directed edge from one node to another.
-func (d *DirectedAcyclicGraph) AddEdge(from, to string) error {
+// AddEdge adds a directed edge from one node to another. The given references
+// are recorded on the edge, adding an edge that already exists only records
+// the new references.
+func (d *DirectedAcyclicGraph) AddEdge(from, to string, references ...EdgeReference) error {
 	fromNode, fromExists := d.Vertices[from]
 	_, toExists := d.Vertices[to]
 	if !fromExists {
@@ -94,9 +112,32 @@ func (d *DirectedAcyclicGraph) AddEdge(from, to string) error {
 		}
 	}
 
+	for _, reference := range references {
+		if !slices.Contains(fromNode.EdgeReferences[to], reference) {
+			fromNode.EdgeReferences[to] = append(fromNode.EdgeReferences[to], reference)
+		}
+	}
 	return nil
 }
 
+// GetEdgeReferences returns the references recorded on the edge going from
+// one node to another, sorted by path and expression. It returns nil if the
+// edge doesn't exist.
+func (d *DirectedAcyclicGraph) GetEdgeReferences(from, to string) []EdgeReference {
+	fromNode, ok := d.Vertices[from]
+	if !ok {
+		return nil
+	}
+	references := slices.Clone(fromNode.EdgeReferences[to])
+	sort.Slice(references, func(i, j int) bool {
+		if references[i].Path == references[j].Path {
+			return references[i].Expression < references[j].Expression
+		}
+		return references[i].Path < references[j].Path
+	})
+	return references
+}
+
 func (d *DirectedAcyclicGraph) TopologicalSort() ([]string, error) {
 	if cyclic, _ := d.HasCycle(); cyclic {
 		return nil, fmt.Errorf("graph has a cycle")
@@ -52,6 +52,45 @@ func TestDAGAddEdge(t *testing.T) {
 	}
 }
 
+func TestDAGEdgeReferences(t *testing.T) {
+	d := NewDirectedAcyclicGraph()
+	d.AddVertex("A")
+	d.AddVertex("B")
+	d.AddVertex("C")
+
+	nameRef := EdgeReference{Path: "spec.name", Expression: "B.metadata.name"}
+	idRef := EdgeReference{Path: "spec.id", Expression: "B.status.id"}
+
+	if err := d.AddEdge("A", "B", nameRef); err != nil {
+		t.Errorf("Failed to add edge: %v", err)
+	}
+	// Adding the same edge again records the new references only once.
+	if err := d.AddEdge("A", "B", idRef, nameRef); err != nil {
+		t.Errorf("Failed to add edge: %v", err)
+	}
+	if err := d.AddEdge("B", "C"); err != nil {
+		t.Errorf("Failed to add edge: %v", err)
+	}
+	// The references of an edge that would create a cycle are not recorded.
+	if err := d.AddEdge("C", "A", EdgeReference{Path: "spec.a", Expression: "A.spec"}); err == nil {
+		t.Error("Expected error when creating a cycle, but got nil")
+	}
+
+	expected := []EdgeReference{idRef, nameRef}
+	if got := d.GetEdgeReferences("A", "B"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected references %v, but got %v", expected, got)
+	}
+	if got := d.GetEdgeReferences("B", "C"); len(got) != 0 {
+		t.Errorf("Expected no references, but got %v", got)
+	}
+	if got := d.GetEdgeReferences("C", "A"); len(got) != 0 {
+		t.Errorf("Expected no references, but got %v", got)
+	}
+	if got := d.GetEdgeReferences("D", "A"); got != nil {
+		t.Errorf("Expected nil references for unknown node, but got %v", got)
+	}
+}
+
 func TestDAGHasCycle(t *testing.T) {
 	d := NewDirectedAcyclicGraph()
 	d.AddVertex("A")
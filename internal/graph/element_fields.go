@@ -0,0 +1,224 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// xKubernetesPreserveUnknownFields marks the schemas of the objects accepting
+// undeclared fields.
+const xKubernetesPreserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+
+// elementSchema is the schema of a sub-expression, as far as it is known.
+type elementSchema struct {
+	schema *spec.Schema
+	// path is the sub-expression, rooted at a macro variable, e.g c.ports.
+	// It is empty if the sub-expression isn't rooted at a macro variable.
+	path string
+	// owner describes what the schema is the schema of, e.g the list
+	// elements, in the errors.
+	owner string
+}
+
+// validateElementFieldAccess checks that the macro variables iterating over
+// the elements of a list (e.g c in deployment.spec.containers.map(c, c.name))
+// only access fields declared by the schema of the elements, when it is known.
+// The CEL type checker sees the resources as dynamic values, so a typo would
+// otherwise only fail at runtime, or never if the list happens to be empty.
+// The fields of the elements whose schema is open (e.g preserving unknown
+// fields) or unknown are left to the runtime.
+func validateElementFieldAccess(env *cel.Env, expression string, resources map[string]*Resource) error {
+	parsed, iss := env.Parse(expression)
+	if iss.Err() != nil {
+		// Reported by the other validations.
+		return nil
+	}
+	schemas := map[string]*elementSchema{}
+	for id, resource := range resources {
+		if resource.schema != nil {
+			schemas[id] = &elementSchema{schema: resource.schema}
+		}
+	}
+	_, err := walkElementSchemas(parsed.NativeRep().Expr(), schemas)
+	return err
+}
+
+// walkElementSchemas returns the schema of expr, or nil if it is unknown,
+// validating the fields accessed by its sub-expressions. scope holds the
+// schemas of the identifiers.
+func walkElementSchemas(expr celast.Expr, scope map[string]*elementSchema) (*elementSchema, error) {
+	switch expr.Kind() {
+	case celast.IdentKind:
+		return scope[expr.AsIdent()], nil
+	case celast.SelectKind:
+		sel := expr.AsSelect()
+		operand, err := walkElementSchemas(sel.Operand(), scope)
+		if err != nil || operand == nil {
+			return nil, err
+		}
+		field, declared := fieldSchema(operand.schema, sel.FieldName())
+		if !declared && operand.path != "" {
+			return nil, fmt.Errorf("%s has no field %q: it isn't declared by the schema of %s", operand.path, sel.FieldName(), operand.owner)
+		}
+		if field == nil {
+			return nil, nil
+		}
+		path := operand.path
+		if path != "" {
+			path += "." + sel.FieldName()
+		}
+		return &elementSchema{schema: field, path: path, owner: operand.owner}, nil
+	case celast.CallKind:
+		call := expr.AsCall()
+		if call.IsMemberFunction() {
+			if _, err := walkElementSchemas(call.Target(), scope); err != nil {
+				return nil, err
+			}
+		}
+		args := make([]*elementSchema, 0, len(call.Args()))
+		for _, arg := range call.Args() {
+			schema, err := walkElementSchemas(arg, scope)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, schema)
+		}
+		if call.FunctionName() == operators.Index && len(args) == 2 && args[0] != nil {
+			if item := indexSchema(args[0].schema); item != nil {
+				path := args[0].path
+				if path != "" {
+					path += "[]"
+				}
+				return &elementSchema{schema: item, path: path, owner: args[0].owner}, nil
+			}
+		}
+		return nil, nil
+	case celast.ComprehensionKind:
+		comp := expr.AsComprehension()
+		iterRange, err := walkElementSchemas(comp.IterRange(), scope)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := walkElementSchemas(comp.AccuInit(), scope); err != nil {
+			return nil, err
+		}
+		// The macro variables shadow the identifiers of the outer scope.
+		inner := make(map[string]*elementSchema, len(scope)+1)
+		for name, schema := range scope {
+			inner[name] = schema
+		}
+		delete(inner, comp.AccuVar())
+		delete(inner, comp.IterVar())
+		if iterRange != nil && isArraySchema(iterRange.schema) {
+			if item := indexSchema(iterRange.schema); item != nil {
+				inner[comp.IterVar()] = &elementSchema{schema: item, path: comp.IterVar(), owner: "the list elements"}
+			}
+		}
+		for _, e := range []celast.Expr{comp.LoopCondition(), comp.LoopStep(), comp.Result()} {
+			if _, err := walkElementSchemas(e, inner); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case celast.ListKind:
+		for _, element := range expr.AsList().Elements() {
+			if _, err := walkElementSchemas(element, scope); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case celast.MapKind:
+		for _, entry := range expr.AsMap().Entries() {
+			mapEntry := entry.AsMapEntry()
+			for _, e := range []celast.Expr{mapEntry.Key(), mapEntry.Value()} {
+				if _, err := walkElementSchemas(e, scope); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	case celast.StructKind:
+		for _, field := range expr.AsStruct().Fields() {
+			if _, err := walkElementSchemas(field.AsStructField().Value(), scope); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	default:
+		return nil, nil
+	}
+}
+
+// fieldSchema returns the schema of a field of an object, and whether the
+// field is declared. The fields of the objects whose schema is open or unknown
+// are always considered declared, their schema being unknown.
+func fieldSchema(schema *spec.Schema, name string) (*spec.Schema, bool) {
+	if schema == nil {
+		return nil, true
+	}
+	if property, ok := schema.Properties[name]; ok {
+		return &property, true
+	}
+	if schema.AdditionalProperties != nil {
+		return schema.AdditionalProperties.Schema, true
+	}
+	if isOpenSchema(schema) {
+		return nil, true
+	}
+	return nil, false
+}
+
+// isOpenSchema returns true if the fields of the objects described by schema
+// can't be known, e.g because it preserves unknown fields or doesn't declare
+// any property.
+func isOpenSchema(schema *spec.Schema) bool {
+	if preserve, ok := schema.Extensions[xKubernetesPreserveUnknownFields].(bool); ok && preserve {
+		return true
+	}
+	if len(schema.Type) > 0 && schema.Type[0] != "object" {
+		// Reported by the type checks, if at all.
+		return true
+	}
+	return len(schema.Properties) == 0 || schema.Ref.String() != "" ||
+		len(schema.AllOf) > 0 || len(schema.AnyOf) > 0 || len(schema.OneOf) > 0
+}
+
+// isArraySchema returns true if schema describes a list.
+func isArraySchema(schema *spec.Schema) bool {
+	return schema != nil && len(schema.Type) > 0 && schema.Type[0] == "array"
+}
+
+// indexSchema returns the schema of the values of a list or a map, or nil if
+// it is unknown.
+func indexSchema(schema *spec.Schema) *spec.Schema {
+	if schema == nil {
+		return nil
+	}
+	if isArraySchema(schema) {
+		if schema.Items == nil {
+			return nil
+		}
+		return schema.Items.Schema
+	}
+	if schema.AdditionalProperties != nil {
+		return schema.AdditionalProperties.Schema
+	}
+	return nil
+}
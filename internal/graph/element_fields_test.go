@@ -0,0 +1,197 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func objectSchema(properties map[string]spec.Schema) spec.Schema {
+	return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}, Properties: properties}}
+}
+
+func arraySchema(items spec.Schema) spec.Schema {
+	return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"array"}, Items: &spec.SchemaOrArray{Schema: &items}}}
+}
+
+func TestValidateElementFieldAccess(t *testing.T) {
+	stringSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}}
+	integerSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"integer"}}}
+	preserved := objectSchema(nil)
+	preserved.Extensions = spec.Extensions{xKubernetesPreserveUnknownFields: true}
+	container := objectSchema(map[string]spec.Schema{
+		"name":  stringSchema,
+		"image": stringSchema,
+		"ports": arraySchema(objectSchema(map[string]spec.Schema{
+			"containerPort": integerSchema,
+		})),
+		"resources": objectSchema(map[string]spec.Schema{
+			"limits": {SchemaProps: spec.SchemaProps{
+				Type:                 []string{"object"},
+				AdditionalProperties: &spec.SchemaOrBool{Allows: true, Schema: &stringSchema},
+			}},
+		}),
+		"extensions": arraySchema(preserved),
+	})
+	deployment := objectSchema(map[string]spec.Schema{
+		"spec": objectSchema(map[string]spec.Schema{
+			"template": objectSchema(map[string]spec.Schema{
+				"spec": objectSchema(map[string]spec.Schema{
+					"containers": arraySchema(container),
+					"anything":   {},
+				}),
+			}),
+		}),
+	})
+	resources := map[string]*Resource{"deployment": {id: "deployment", schema: &deployment}}
+	env, err := newResourcesEnvironment([]string{"deployment"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{
+			name:       "declared element field",
+			expression: "deployment.spec.template.spec.containers.map(c, c.name)",
+		},
+		{
+			name:       "undeclared element field",
+			expression: "deployment.spec.template.spec.containers.map(c, c.nmae)",
+			wantErr:    `c has no field "nmae": it isn't declared by the schema of the list elements`,
+		},
+		{
+			name:       "undeclared field in the filter of a macro",
+			expression: `deployment.spec.template.spec.containers.filter(c, c.img == "nginx")`,
+			wantErr:    `c has no field "img"`,
+		},
+		{
+			name:       "nested element field",
+			expression: "deployment.spec.template.spec.containers.all(c, c.ports.exists(p, p.containerPort == 80))",
+		},
+		{
+			name:       "undeclared nested element field",
+			expression: "deployment.spec.template.spec.containers.all(c, c.ports.exists(p, p.port == 80))",
+			wantErr:    `p has no field "port"`,
+		},
+		{
+			name:       "undeclared field of an element field",
+			expression: "deployment.spec.template.spec.containers.map(c, c.resources.requests)",
+			wantErr:    `c.resources has no field "requests"`,
+		},
+		{
+			name:       "indexed element",
+			expression: "deployment.spec.template.spec.containers.map(c, c.ports[0].hostPort)",
+			wantErr:    `c.ports[] has no field "hostPort"`,
+		},
+		{
+			name:       "map values",
+			expression: `deployment.spec.template.spec.containers.map(c, c.resources.limits.cpu)`,
+		},
+		{
+			name:       "elements preserving unknown fields",
+			expression: "deployment.spec.template.spec.containers.map(c, c.extensions.map(e, e.anything))",
+		},
+		{
+			name:       "elements of unknown schema",
+			expression: "deployment.spec.template.spec.anything.map(a, a.anything)",
+		},
+		{
+			name:       "literal list",
+			expression: `[{"port": 80}].map(p, p.port)`,
+		},
+		{
+			name:       "variable shadowing a resource",
+			expression: `[{"port": 80}].map(deployment, deployment.port)`,
+		},
+		{
+			// Fields accessed outside of the macros are left to the dry-run.
+			name:       "resource field",
+			expression: "deployment.spec.replicas",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateElementFieldAccess(env, tt.expression, resources)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGraphBuilder_ElementFieldAccess(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+	cluster := map[string]interface{}{
+		"apiVersion": "eks.services.k8s.aws/v1alpha1",
+		"kind":       "Cluster",
+		"metadata":   map[string]interface{}{"name": "cluster"},
+		"spec":       map[string]interface{}{"name": "cluster"},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{
+			name:       "declared element field",
+			expression: `${cluster.status.conditions.exists(c, c.type == "Ready") ? "ready" : "pending"}`,
+		},
+		{
+			name:       "undeclared element field",
+			expression: `${cluster.status.conditions.exists(c, c.reason == "Ready") ? "ready" : "pending"}`,
+			wantErr:    `invalid field data.state of resource settings: expression cluster.status.conditions.exists(c, c.reason == "Ready") ? "ready" : "pending": c has no field "reason"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Test", "v1alpha1",
+					map[string]interface{}{"name": "string"},
+					nil,
+				),
+				generator.WithResource("cluster", cluster, nil, nil),
+				generator.WithResource("settings", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]interface{}{"name": "settings"},
+					"data":       map[string]interface{}{"state": tt.expression},
+				}, nil, nil),
+			)
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
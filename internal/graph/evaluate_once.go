@@ -0,0 +1,58 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	"github.com/awslabs/kro/internal/graph/fieldpath"
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// validateEvaluateOnce makes sure the evaluateOnce paths of a resource are
+// fields of its template computed by expressions, and returns their
+// canonical form. The paths are compared to the paths of the variables, a
+// path inside a field computed by an expression isn't accepted: the stored
+// value replaces the whole field.
+func validateEvaluateOnce(resourceID string, paths []string, variables []*variable.ResourceField) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	computed := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		path, err := fieldpath.Canonicalize(v.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %s of resource %s: %w", v.Path, resourceID, err)
+		}
+		computed[path] = true
+	}
+
+	canonical := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		c, err := fieldpath.Canonicalize(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid evaluateOnce path %q of resource %s: %w", path, resourceID, err)
+		}
+		if !computed[c] {
+			return nil, fmt.Errorf("evaluateOnce path %q of resource %s is not a field computed by an expression", path, resourceID)
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("duplicate evaluateOnce path %q in resource %s", path, resourceID)
+		}
+		seen[c] = true
+		canonical = append(canonical, c)
+	}
+	return canonical, nil
+}
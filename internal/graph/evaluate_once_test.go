@@ -0,0 +1,100 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_EvaluateOnce(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name         string
+		evaluateOnce []string
+		want         []string
+		wantErr      string
+	}{
+		{
+			name:         "field computed by an expression",
+			evaluateOnce: []string{"data.password"},
+			want:         []string{"data.password"},
+		},
+		{
+			name:         "non canonical path",
+			evaluateOnce: []string{`data["password"]`},
+			want:         []string{"data.password"},
+		},
+		{
+			name:         "literal field",
+			evaluateOnce: []string{"data.user"},
+			wantErr:      `evaluateOnce path "data.user" of resource credentials is not a field computed by an expression`,
+		},
+		{
+			name:         "field inside a computed field",
+			evaluateOnce: []string{"data.password.value"},
+			wantErr:      "is not a field computed by an expression",
+		},
+		{
+			name:         "duplicate path",
+			evaluateOnce: []string{"data.password", `data["password"]`},
+			wantErr:      "duplicate evaluateOnce path",
+		},
+		{
+			name:         "invalid path",
+			evaluateOnce: []string{"data[password"},
+			wantErr:      `invalid evaluateOnce path "data[password"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Database", "v1alpha1", map[string]interface{}{"password": "string"}, nil),
+				generator.WithResource("credentials", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name": "credentials",
+					},
+					"data": map[string]interface{}{
+						"user":     "admin",
+						"password": "${schema.spec.password}",
+					},
+				}, nil, nil),
+			)
+			rg.Spec.Resources[0].EvaluateOnce = tt.evaluateOnce
+
+			g, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, g.Resources["credentials"].GetEvaluateOnce())
+		})
+	}
+}
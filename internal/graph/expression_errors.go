@@ -0,0 +1,55 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// checkExpressionSyntax parses the expression at the given index of a field.
+// The syntax errors are reported at their position in the field value, as
+// written by the author, rather than in the expression: e.g the error of
+// "${schema.spec.name +}" in "prefix-${schema.spec.name +}" is at character
+// 28 of the field value, and column 19 of the expression.
+func checkExpressionSyntax(env *cel.Env, field *variable.ResourceField, index int) error {
+	expression := field.Expressions[index]
+	_, iss := env.Parse(expression)
+	if iss.Err() == nil {
+		return nil
+	}
+	issue := iss.Errors()[0]
+	offset := 0
+	if index < len(field.ExpressionOffsets) {
+		offset = field.ExpressionOffsets[index]
+	}
+	return fmt.Errorf("syntax error in expression %q at character %d of the field value: %s",
+		expression, offset+expressionPosition(expression, issue.Location)+1, issue.Message)
+}
+
+// expressionPosition returns the 0-based position of a location in an
+// expression, which may span several lines.
+func expressionPosition(expression string, location common.Location) int {
+	position := 0
+	lines := strings.SplitAfter(expression, "\n")
+	for i := 0; i < location.Line()-1 && i < len(lines); i++ {
+		position += len(lines[i])
+	}
+	return position + location.Column()
+}
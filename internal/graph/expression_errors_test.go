@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_ExpressionSyntaxErrorPosition(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{
+			name:  "standalone expression",
+			value: "${schema.spec.name.}",
+			wantErr: `invalid field spec.cidrBlocks[0] of resource vpc: syntax error in expression "schema.spec.name." ` +
+				`at character 20 of the field value: Syntax error: no viable alternative at input '.'`,
+		},
+		{
+			name:  "expression in a string template",
+			value: "prefix-${schema.spec.name}-${schema.spec.name.}",
+			wantErr: `invalid field spec.cidrBlocks[0] of resource vpc: syntax error in expression "schema.spec.name." ` +
+				`at character 47 of the field value`,
+		},
+		{
+			name:  "multi-line expression",
+			value: "${schema.spec.name +\n  '-' +\n  schema.spec.name.}",
+			wantErr: `syntax error in expression "schema.spec.name +\n  '-' +\n  schema.spec.name." ` +
+				`at character 49 of the field value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The errors are reported right after the dot missing a field
+			// name, at the closing brace ending the value.
+			require.Equal(t, ".}", tt.value[len(tt.value)-2:])
+
+			_, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group",
+				generator.WithSchema("Network", "v1alpha1", map[string]interface{}{"name": "string"}, nil),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{tt.value},
+					},
+				}, nil, nil),
+			))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// featuresVariable is the name of the CEL variable exposing the feature gates
+// of an instance. The gates are set in the instance spec field of the same
+// name.
+const featuresVariable = runtime.FeaturesVariable
+
+// validateFeatureGates checks that the feature gates have unique, lower
+// camelCase names.
+func validateFeatureGates(gates []v1alpha1.FeatureGate) error {
+	seen := make(map[string]struct{}, len(gates))
+	for _, gate := range gates {
+		if !lowerCamelCaseRegex.MatchString(gate.Name) {
+			return fmt.Errorf("feature gate name %q is invalid: must be lower camelCase", gate.Name)
+		}
+		if _, ok := seen[gate.Name]; ok {
+			return fmt.Errorf("duplicate feature gate %s", gate.Name)
+		}
+		seen[gate.Name] = struct{}{}
+	}
+	return nil
+}
+
+// addFeatureGates declares the feature gates in the instance spec schema, as
+// boolean fields of the features object. The API server defaults the gates
+// the instances don't set.
+func addFeatureGates(specSchema *extv1.JSONSchemaProps, gates []v1alpha1.FeatureGate) error {
+	if len(gates) == 0 {
+		return nil
+	}
+	if _, ok := specSchema.Properties[featuresVariable]; ok {
+		return fmt.Errorf("spec field %s is reserved to the feature gates", featuresVariable)
+	}
+
+	features := extv1.JSONSchemaProps{
+		Type:       "object",
+		Default:    &extv1.JSON{Raw: []byte("{}")},
+		Properties: make(map[string]extv1.JSONSchemaProps, len(gates)),
+	}
+	for _, gate := range gates {
+		features.Properties[gate.Name] = extv1.JSONSchemaProps{
+			Type:        "boolean",
+			Description: gate.Description,
+			Default:     &extv1.JSON{Raw: []byte(fmt.Sprint(gate.Default))},
+		}
+	}
+	if specSchema.Properties == nil {
+		specSchema.Properties = make(map[string]extv1.JSONSchemaProps)
+	}
+	specSchema.Properties[featuresVariable] = features
+	return nil
+}
+
+// newFeaturesContext returns the features variable of the given emulated
+// instance, to dry-run expressions.
+func newFeaturesContext(instance *unstructured.Unstructured) *Resource {
+	features, _, _ := unstructured.NestedMap(instance.Object, "spec", featuresVariable)
+	if features == nil {
+		features = map[string]interface{}{}
+	}
+	return &Resource{
+		emulatedObject: &unstructured.Unstructured{Object: features},
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newFeatureGatesResourceGroup(gates []v1alpha1.FeatureGate, includeWhen string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithFeatureGates(gates...),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"${features.largeNetwork ? '10.0.0.0/8' : '10.0.0.0/16'}"},
+			},
+		}, nil, nil),
+		generator.WithResource("subnet", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "Subnet",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}-subnet",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+				"vpcID":     "${vpc.status.vpcID}",
+			},
+		}, nil, []string{includeWhen}),
+	)
+}
+
+func TestGraphBuilder_FeatureGates(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+	gates := []v1alpha1.FeatureGate{
+		{Name: "privateSubnet", Default: true},
+		{Name: "largeNetwork"},
+	}
+
+	g, err := builder.NewResourceGroup(newFeatureGatesResourceGroup(gates, "${features.privateSubnet}"))
+	require.NoError(t, err)
+
+	// The gates are declared in the instance spec, with their defaults.
+	features := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["features"]
+	assert.Equal(t, "object", features.Type)
+	assert.Equal(t, "boolean", features.Properties["privateSubnet"].Type)
+	assert.Equal(t, "true", string(features.Properties["privateSubnet"].Default.Raw))
+	assert.Equal(t, "false", string(features.Properties["largeNetwork"].Default.Raw))
+
+	// The expressions referring only to feature gates are static.
+	assert.Empty(t, g.Resources["vpc"].GetDependencies())
+
+	tests := []struct {
+		name          string
+		features      map[string]interface{}
+		wantSubnet    bool
+		wantCIDRBlock string
+	}{
+		{
+			name:          "gates enabled",
+			features:      map[string]interface{}{"privateSubnet": true, "largeNetwork": true},
+			wantSubnet:    true,
+			wantCIDRBlock: "10.0.0.0/8",
+		},
+		{
+			name:          "gates disabled",
+			features:      map[string]interface{}{"privateSubnet": false, "largeNetwork": false},
+			wantSubnet:    false,
+			wantCIDRBlock: "10.0.0.0/16",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "kro.run/v1alpha1",
+				"kind":       "Network",
+				"metadata":   map[string]interface{}{"name": "my-network", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"name":     "my-network",
+					"features": tt.features,
+				},
+			}}
+			rt, err := g.NewGraphRuntime(instance)
+			require.NoError(t, err)
+
+			wantSubnet, _ := rt.WantToCreateResource("subnet")
+			assert.Equal(t, tt.wantSubnet, wantSubnet)
+
+			vpc, _ := rt.GetResource("vpc")
+			cidrBlocks, _, _ := unstructured.NestedStringSlice(vpc.Object, "spec", "cidrBlocks")
+			assert.Equal(t, []string{tt.wantCIDRBlock}, cidrBlocks)
+		})
+	}
+}
+
+func TestGraphBuilder_FeatureGatesValidation(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name        string
+		gates       []v1alpha1.FeatureGate
+		includeWhen string
+		errMsg      string
+	}{
+		{
+			name:        "undeclared gate",
+			gates:       []v1alpha1.FeatureGate{{Name: "largeNetwork"}},
+			includeWhen: "${features.privateSubnet}",
+			errMsg:      "no such key: privateSubnet",
+		},
+		{
+			name:        "duplicate gates",
+			gates:       []v1alpha1.FeatureGate{{Name: "largeNetwork"}, {Name: "largeNetwork"}},
+			includeWhen: "${features.largeNetwork}",
+			errMsg:      "duplicate feature gate largeNetwork",
+		},
+		{
+			name:        "invalid gate name",
+			gates:       []v1alpha1.FeatureGate{{Name: "large-network"}},
+			includeWhen: "${true}",
+			errMsg:      `feature gate name "large-network" is invalid`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := builder.NewResourceGroup(newFeatureGatesResourceGroup(tt.gates, tt.includeWhen))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestAddFeatureGates(t *testing.T) {
+	t.Run("no gates", func(t *testing.T) {
+		spec := &extv1.JSONSchemaProps{Type: "object"}
+		require.NoError(t, addFeatureGates(spec, nil))
+		assert.NotContains(t, spec.Properties, "features")
+	})
+
+	t.Run("reserved field", func(t *testing.T) {
+		spec := &extv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"features": {Type: "string"},
+			},
+		}
+		err := addFeatureGates(spec, []v1alpha1.FeatureGate{{Name: "canary"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "spec field features is reserved")
+	})
+
+	t.Run("gates with descriptions", func(t *testing.T) {
+		spec := &extv1.JSONSchemaProps{Type: "object"}
+		err := addFeatureGates(spec, []v1alpha1.FeatureGate{
+			{Name: "canary", Default: true, Description: "Deploy the canary."},
+		})
+		require.NoError(t, err)
+		features := spec.Properties["features"]
+		assert.Equal(t, "{}", string(features.Default.Raw))
+		assert.Equal(t, "Deploy the canary.", features.Properties["canary"].Description)
+		assert.Equal(t, "true", string(features.Properties["canary"].Default.Raw))
+	})
+}
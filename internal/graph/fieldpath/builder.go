@@ -15,10 +15,13 @@ package fieldpath
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
-// Build constructs a field path string from a slice of segments.
+// Build constructs a field path string from a slice of segments. Field
+// names that are empty or contain characters with a special meaning in
+// paths (dots, brackets or quotes) are quoted.
 //
 // Examples:
 //   - [{Field: "spec"}, {Field: "containers", ArrayIdx: 0}] -> spec.containers[0]
@@ -26,22 +29,25 @@ import (
 func Build(segments []Segment) string {
 	var b strings.Builder
 
-	for i, segment := range segments {
-		if i > 0 && !strings.HasSuffix(b.String(), "]") {
-			b.WriteByte('.')
-		}
-
-		if segment.Index != -1 {
+	for _, segment := range segments {
+		switch {
+		case segment.Index != -1:
 			b.WriteString(fmt.Sprintf("[%d]", segment.Index))
-			continue
-		}
-
-		if strings.Contains(segment.Name, ".") {
-			b.WriteString(fmt.Sprintf(`[%q]`, segment.Name))
-		} else {
+		case needsQuoting(segment.Name):
+			b.WriteString("[" + strconv.Quote(segment.Name) + "]")
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
 			b.WriteString(segment.Name)
 		}
 	}
 
 	return b.String()
 }
+
+// needsQuoting returns true if the field name can't be written as an
+// unquoted path segment.
+func needsQuoting(name string) bool {
+	return name == "" || strings.ContainsAny(name, `.[]"\`)
+}
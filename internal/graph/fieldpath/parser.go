@@ -16,6 +16,7 @@ package fieldpath
 import (
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 // Segment represents a single part of a path
@@ -62,39 +63,33 @@ func (p *parser) parse() ([]Segment, error) {
 	var segments []Segment
 
 	for p.pos < p.len {
-		// NOTE(a-hilaly): unescape quoted fields? not sure if we need to do this.
-		// This is a very low level parser, and the paths we'll be dealing with
-		// are mainly produced by the `internal/typesystem/parser` package.
-
-		// check if we're starting with a quoted field.
-		if p.pos+1 < p.len && p.input[p.pos] == '[' && p.input[p.pos+1] == '"' {
+		switch {
+		case strings.HasPrefix(p.input[p.pos:], `["`):
 			field, err := p.parseQuotedField()
 			if err != nil {
 				return nil, err
 			}
-			segments = append(segments, Segment{Name: field, Index: -1})
-
-		} else if p.pos+1 < p.len && p.input[p.pos] != '[' {
-			// Parse unquoted field until we hit a [ or .
-			field, err := p.parseUnquotedField()
+			segments = append(segments, NewNamedSegment(field))
+		case p.input[p.pos] == '[':
+			idx, err := p.parseArrayIndex()
 			if err != nil {
 				return nil, err
 			}
-			segments = append(segments, Segment{Name: field, Index: -1})
-		}
-
-		// Check for array index
-		if p.pos < p.len && p.input[p.pos] == '[' && (p.pos+1 >= p.len || p.input[p.pos+1] != '"') {
-			idx, err := p.parseArrayIndex()
+			segments = append(segments, NewIndexedSegment(idx))
+		default:
+			// Unquoted fields are separated from the previous segment by
+			// a dot.
+			if len(segments) > 0 {
+				if p.input[p.pos] != '.' {
+					return nil, fmt.Errorf("expected '.' or '[' at position %d", p.pos)
+				}
+				p.pos++
+			}
+			field, err := p.parseUnquotedField()
 			if err != nil {
 				return nil, err
 			}
-			segments = append(segments, Segment{Name: "", Index: idx})
-		}
-
-		// Skip dot if present
-		if p.pos < p.len && p.input[p.pos] == '.' {
-			p.pos++
+			segments = append(segments, NewNamedSegment(field))
 		}
 	}
 
@@ -102,21 +97,32 @@ func (p *parser) parse() ([]Segment, error) {
 }
 
 // parseQuotedField parses a quoted field. It assumes the current
-// position is at the opening bracket and quote.
+// position is at the opening bracket and quote. The field is
+// unquoted following the Go string literal rules, so it can contain
+// escaped quotes and backslashes.
 //
 // e.g ["my.field.name"]
 func (p *parser) parseQuotedField() (string, error) {
+	start := p.pos
 	// Skip [ and opening quote. Note that we already checked the index
 	// bounds in the parse function.
 	p.pos += 2
 
-	start := p.pos
 	for p.pos < p.len {
+		if p.input[p.pos] == '\\' {
+			// Skip the escaped character.
+			p.pos += 2
+			continue
+		}
 		if p.input[p.pos] != '"' {
 			p.pos++
 			continue
 		}
-		field := p.input[start:p.pos]
+
+		field, err := strconv.Unquote(p.input[start+1 : p.pos+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted field at position %d: %w", start, err)
+		}
 		p.pos++ // skip closing quote
 
 		if p.pos < p.len && p.input[p.pos] == ']' {
@@ -165,7 +171,7 @@ func (p *parser) parseArrayIndex() (int, error) {
 	p.pos++ // skip ]
 
 	idx, err := strconv.Atoi(idxStr)
-	if err != nil {
+	if err != nil || idx < 0 {
 		return -1, fmt.Errorf("invalid array index '%s' at position %d", idxStr, start)
 	}
 
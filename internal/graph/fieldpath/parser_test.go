@@ -94,6 +94,24 @@ func TestParse(t *testing.T) {
 				{Name: "salut", Index: -1},
 			},
 		},
+		{
+			name: "single character fields",
+			path: "a.b[0].c",
+			want: []Segment{
+				{Name: "a", Index: -1},
+				{Name: "b", Index: -1},
+				{Name: "", Index: 0},
+				{Name: "c", Index: -1},
+			},
+		},
+		{
+			name: "quoted field with escaped quote",
+			path: `data["say \"hi\"."]`,
+			want: []Segment{
+				{Name: "data", Index: -1},
+				{Name: `say "hi".`, Index: -1},
+			},
+		},
 		{
 			name:    "unterminated quote",
 			path:    `spec["unterminated`,
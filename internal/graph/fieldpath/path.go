@@ -0,0 +1,56 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fieldpath
+
+// Path is a parsed field path, e.g spec.containers[0]["my.field"].
+//
+// The string representation of a path is canonical: parsing a path and
+// turning it back into a string always produces the same string for
+// equivalent paths, e.g both spec["containers"] and spec.containers
+// are canonicalized into spec.containers.
+type Path []Segment
+
+// ParsePath parses a path string into a Path.
+func ParsePath(path string) (Path, error) {
+	segments, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return Path(segments), nil
+}
+
+// String returns the canonical string representation of the path.
+func (p Path) String() string {
+	return Build(p)
+}
+
+// Child returns a copy of the path with the given field name appended.
+func (p Path) Child(name string) Path {
+	return append(p[:len(p):len(p)], NewNamedSegment(name))
+}
+
+// Index returns a copy of the path with the given array index appended.
+func (p Path) Index(index int) Path {
+	return append(p[:len(p):len(p)], NewIndexedSegment(index))
+}
+
+// Canonicalize parses the given path and returns its canonical string
+// representation.
+func Canonicalize(path string) (string, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
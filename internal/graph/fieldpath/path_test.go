@@ -0,0 +1,138 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fieldpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		path Path
+		want string
+	}{
+		{
+			name: "simple fields",
+			path: Path{NewNamedSegment("spec"), NewNamedSegment("replicas")},
+			want: "spec.replicas",
+		},
+		{
+			name: "array indices",
+			path: Path{NewNamedSegment("items"), NewIndexedSegment(0), NewIndexedSegment(12)},
+			want: "items[0][12]",
+		},
+		{
+			name: "dotted field name",
+			path: Path{NewNamedSegment("metadata"), NewNamedSegment("app.kubernetes.io/name")},
+			want: `metadata["app.kubernetes.io/name"]`,
+		},
+		{
+			name: "field name with quotes",
+			path: Path{NewNamedSegment("data"), NewNamedSegment(`say "hello"`)},
+			want: `data["say \"hello\""]`,
+		},
+		{
+			name: "field name with brackets",
+			path: Path{NewNamedSegment("data"), NewNamedSegment("a[0]"), NewNamedSegment("b]")},
+			want: `data["a[0]"]["b]"]`,
+		},
+		{
+			name: "field name with a quote and a bracket",
+			path: Path{NewNamedSegment(`"]`), NewNamedSegment("next")},
+			want: `["\"]"].next`,
+		},
+		{
+			name: "field name with backslashes",
+			path: Path{NewNamedSegment(`C:\dir\`), NewIndexedSegment(1)},
+			want: `["C:\\dir\\"][1]`,
+		},
+		{
+			name: "empty field name",
+			path: Path{NewNamedSegment(""), NewNamedSegment("field")},
+			want: `[""].field`,
+		},
+		{
+			name: "unicode field name",
+			path: Path{NewNamedSegment("données"), NewNamedSegment("clé.é")},
+			want: `données["clé.é"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.path.String()
+			if got != tt.want {
+				t.Fatalf("String() = %v, want %v", got, tt.want)
+			}
+			parsed, err := ParsePath(got)
+			if err != nil {
+				t.Fatalf("ParsePath(%q) error = %v", got, err)
+			}
+			if !reflect.DeepEqual(parsed, tt.path) {
+				t.Errorf("ParsePath(%q) = %v, want %v", got, parsed, tt.path)
+			}
+		})
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "already canonical", path: `spec.items[0]["my.field"]`, want: `spec.items[0]["my.field"]`},
+		{name: "needlessly quoted fields", path: `["spec"]["items"][0]["name"]`, want: "spec.items[0].name"},
+		{name: "escaped characters", path: `["\u0061"]["\x62"]`, want: "a.b"},
+		{name: "empty path", path: "", want: ""},
+		{name: "trailing dot", path: "spec.", wantErr: true},
+		{name: "leading dot", path: ".spec", wantErr: true},
+		{name: "missing dot", path: `["spec"]items`, wantErr: true},
+		{name: "negative index", path: "items[-1]", wantErr: true},
+		{name: "invalid escape", path: `["\q"]`, wantErr: true},
+		{name: "unterminated escape", path: `["\"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Canonicalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Canonicalize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathChildAndIndex(t *testing.T) {
+	base := Path{NewNamedSegment("spec")}
+	containers := base.Child("containers")
+	first := containers.Index(0)
+	second := containers.Index(1)
+
+	if got := base.String(); got != "spec" {
+		t.Errorf("base path was modified: %v", got)
+	}
+	if got := first.String(); got != "spec.containers[0]" {
+		t.Errorf("first.String() = %v, want spec.containers[0]", got)
+	}
+	if got := second.Child("image.tag").String(); got != `spec.containers[1]["image.tag"]` {
+		t.Errorf(`second.Child("image.tag").String() = %v, want spec.containers[1]["image.tag"]`, got)
+	}
+}
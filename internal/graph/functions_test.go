@@ -0,0 +1,193 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+// TestGraphBuilder_Functions checks that the kro CEL functions are available
+// to the expressions of the resource groups, at build time and at runtime.
+func TestGraphBuilder_Functions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+	}{
+		{
+			name:       "set functions",
+			expression: `${set.diff(schema.spec.names, ["b"]).join(",")}`,
+			want:       "c,a",
+		},
+		{
+			name:       "sort",
+			expression: `${sort(schema.spec.names).join(",")}`,
+			want:       "a,b,c",
+		},
+		{
+			name:       "sortBy",
+			expression: `${sortBy(schema.spec.names, n, n == "b" ? 0 : 1).join(",")}`,
+			want:       "b,c,a",
+		},
+		{
+			name:       "list accessors",
+			expression: `${first(schema.spec.names) + last(schema.spec.names) + at(schema.spec.names, 0)}`,
+			want:       "cac",
+		},
+		{
+			name:       "containers",
+			expression: `${toEnv({"NAME": schema.spec.name, "A": "b"}).map(e, e.name + "=" + e.value).join(",")}`,
+			want:       "A=b,NAME=web-app",
+		},
+		{
+			name:       "template string",
+			expression: `${templateString("{{ .name }}.{{ .namespace }}.svc", {"name": schema.spec.name, "namespace": "prod"})}`,
+			want:       "web-app.prod.svc",
+		},
+		{
+			name:       "advanced math",
+			expression: `${string(clamp(pow(2, clamp(schema.spec.count, 0, 10)), 1, 64)) + "-" + string(int(log2(1024)))}`,
+			want:       "64-10",
+		},
+		{
+			name:       "arithmetic",
+			expression: `${string(percentOf(schema.spec.count, 25, "ceil"))}`,
+			want:       "11",
+		},
+		{
+			name:       "string limits",
+			expression: `${truncate(schema.spec.name, 3) + truncateBytes("héllo", 2)}`,
+			want:       "webh",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group",
+				generator.WithSchema(
+					"WebApp", "v1alpha1",
+					map[string]interface{}{
+						"name":  "string",
+						"names": "[]string",
+						"count": "integer",
+					},
+					nil,
+				),
+				generator.WithResource("pod", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Pod",
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+					"spec": map[string]interface{}{
+						"nodeName": tt.expression,
+					},
+				}, nil, nil),
+			))
+			require.NoError(t, err)
+
+			instance := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "kro.run/v1alpha1",
+				"kind":       "WebApp",
+				"metadata":   map[string]interface{}{"name": "my-app", "namespace": "default"},
+				"spec": map[string]interface{}{
+					"name":  "web-app",
+					"names": []interface{}{"c", "b", "a"},
+					"count": int64(42),
+				},
+			}}
+			rt, err := g.NewGraphRuntime(instance)
+			require.NoError(t, err)
+
+			pod, _ := rt.GetResource("pod")
+			nodeName, _, err := unstructured.NestedString(pod.Object, "spec", "nodeName")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, nodeName)
+		})
+	}
+}
+
+// TestGraphBuilder_FunctionsInConditions checks that the kro CEL functions are
+// available to the readyWhen and includeWhen expressions too.
+func TestGraphBuilder_FunctionsInConditions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	g, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"WebApp", "v1alpha1",
+			map[string]interface{}{
+				"name":  "string",
+				"names": "[]string",
+			},
+			nil,
+		),
+		generator.WithResource("pod", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+		},
+			[]string{`${last(sort([pod.metadata.name, "~"])) == "~"}`},
+			[]string{`${size(set.diff(schema.spec.names, ["b"])) > 0}`},
+		),
+	))
+	require.NoError(t, err)
+
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kro.run/v1alpha1",
+		"kind":       "WebApp",
+		"metadata":   map[string]interface{}{"name": "my-app", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"name":  "web-app",
+			"names": []interface{}{"c", "b", "a"},
+		},
+	}}
+	rt, err := g.NewGraphRuntime(instance)
+	require.NoError(t, err)
+
+	include, err := rt.WantToCreateResource("pod")
+	require.NoError(t, err)
+	assert.True(t, include)
+
+	rt.SetResource("pod", &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name": "web-app",
+		},
+	}})
+	ready, reason, err := rt.IsResourceReady("pod")
+	require.NoError(t, err)
+	assert.True(t, ready, reason)
+}
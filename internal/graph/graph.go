@@ -16,7 +16,10 @@ package graph
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/conversion"
 	"github.com/awslabs/kro/internal/graph/dag"
+	"github.com/awslabs/kro/internal/graph/variable"
 	"github.com/awslabs/kro/internal/runtime"
 )
 
@@ -31,10 +34,39 @@ type Graph struct {
 	Resources map[string]*Resource
 	// TopologicalOrder is the topological order of the resources in the resource group.
 	TopologicalOrder []string
+	// Converter converts instances between the versions of the instance kind.
+	Converter *conversion.Converter
+	// IdentityFields are the paths to the instance spec fields that can't be
+	// changed once an instance is created.
+	IdentityFields []string
+	// SensitiveFields are the paths to the instance status fields whose values
+	// are written into a Secret instead of the instance status.
+	SensitiveFields []string
+	// RequiredStatusFields are the paths to the instance status fields that
+	// must be resolved before the instance is considered synced.
+	RequiredStatusFields []string
+	// StatusStorage defines how the values of some instance status fields
+	// are stored instead of being written as is in the instance status.
+	StatusStorage []v1alpha1.StatusFieldStorage
+	// Composition enables the report of the resources composing the
+	// instances in their status.resources field.
+	Composition bool
+	// Pausable is true if the instances declare the spec.paused field,
+	// pausing their reconciliation.
+	Pausable bool
+	// Constraints are the consistency rules checked against the rendered
+	// resources of the instances.
+	Constraints []variable.Constraint
+	// Warnings are the advisory findings of the resource group analysis. They
+	// don't prevent the resource group from being used.
+	Warnings []string
 }
 
 // NewGraphRuntime creates a new runtime resource group from the resource group instance.
-func (rg *Graph) NewGraphRuntime(newInstance *unstructured.Unstructured) (*runtime.ResourceGroupRuntime, error) {
+func (rg *Graph) NewGraphRuntime(
+	newInstance *unstructured.Unstructured,
+	opts ...runtime.Option,
+) (*runtime.ResourceGroupRuntime, error) {
 	// we need to copy the resources to the runtime resources, mainly focusing
 	// on the variables and dependencies.
 	resources := make(map[string]runtime.Resource)
@@ -44,7 +76,10 @@ func (rg *Graph) NewGraphRuntime(newInstance *unstructured.Unstructured) (*runti
 
 	instance := rg.Instance.DeepCopy()
 	instance.originalObject = newInstance
-	rt, err := runtime.NewResourceGroupRuntime(instance, resources, rg.TopologicalOrder)
+	if len(rg.Constraints) > 0 {
+		opts = append([]runtime.Option{runtime.WithConstraints(rg.Constraints)}, opts...)
+	}
+	rt, err := runtime.NewResourceGroupRuntime(instance, resources, rg.TopologicalOrder, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,57 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// conditionalDependencyWarnings returns a warning for every resource without
+// includeWhen expressions depending on a conditionally included resource.
+//
+// Such a resource is excluded along with its dependency at runtime, or blocks
+// the instance, depending on the excluded dependency policy of the
+// controller; either way it is hardly what the author expects of a resource
+// without includeWhen expressions. The conditions of the dependents aren't
+// compared to the ones of their dependencies: equivalent expressions can be
+// written in many ways.
+func conditionalDependencyWarnings(resources map[string]*Resource) []string {
+	resourceIDs := maps.Keys(resources)
+	slices.Sort(resourceIDs)
+	var warnings []string
+	for _, id := range resourceIDs {
+		resource := resources[id]
+		if len(resource.includeWhenExpressions) > 0 {
+			continue
+		}
+		dependencies := slices.Clone(resource.GetDependencies())
+		slices.Sort(dependencies)
+		for _, dependency := range dependencies {
+			conditions := resources[dependency].includeWhenExpressions
+			if len(conditions) == 0 {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf(
+				"resource %s depends on resource %s, which is only included when %s: "+
+					"resource %s should be conditionally included too, e.g with the same includeWhen expressions",
+				id, dependency, strings.Join(conditions, " and "), id,
+			))
+		}
+	}
+	return warnings
+}
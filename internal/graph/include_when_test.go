@@ -0,0 +1,118 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newConditionalResourceGroup(vpcIncludeWhen, subnetIncludeWhen []string, subnetVPCID string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name":      "string",
+				"createVPC": "boolean | default=true",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, vpcIncludeWhen),
+		generator.WithResource("subnet", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "Subnet",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}-subnet",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+				"vpcID":     subnetVPCID,
+			},
+		}, nil, subnetIncludeWhen),
+	)
+}
+
+func TestGraphBuilder_ConditionalDependencies(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+	condition := []string{"${schema.spec.createVPC}"}
+
+	tests := []struct {
+		name            string
+		rg              *v1alpha1.ResourceGroup
+		expectedWarning string
+	}{
+		{
+			name: "unconditioned resource depending on a conditioned resource",
+			rg:   newConditionalResourceGroup(condition, nil, "${vpc.status.vpcID}"),
+			expectedWarning: "resource subnet depends on resource vpc, which is only included when schema.spec.createVPC: " +
+				"resource subnet should be conditionally included too",
+		},
+		{
+			name: "unconditioned resource waiting for a conditioned resource",
+			rg: func() *v1alpha1.ResourceGroup {
+				rg := newConditionalResourceGroup(condition, nil, "vpc-12345")
+				generator.WithResourceWaitFor("subnet", "vpc")(rg)
+				return rg
+			}(),
+			expectedWarning: "resource subnet depends on resource vpc",
+		},
+		{
+			name: "conditioned resource depending on a conditioned resource",
+			rg:   newConditionalResourceGroup(condition, condition, "${vpc.status.vpcID}"),
+		},
+		{
+			name: "conditioned resource depending on an unconditioned resource",
+			rg:   newConditionalResourceGroup(nil, condition, "${vpc.status.vpcID}"),
+		},
+		{
+			name: "unconditioned resource independent of a conditioned resource",
+			rg:   newConditionalResourceGroup(condition, nil, "vpc-12345"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The conflicts are reported to the author, without rejecting
+			// the resource group.
+			g, err := builder.NewResourceGroup(tt.rg)
+			require.NoError(t, err)
+			if tt.expectedWarning == "" {
+				assert.Empty(t, g.Warnings)
+				return
+			}
+			require.Len(t, g.Warnings, 1)
+			assert.Contains(t, g.Warnings[0], tt.expectedWarning)
+		})
+	}
+}
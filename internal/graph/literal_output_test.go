@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_LiteralOutputStructure(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		field   string
+		value   interface{}
+		wantErr string
+	}{
+		{
+			name:  "map literal matching the field schema",
+			kind:  "ConfigMap",
+			field: "data",
+			value: `${{"name": schema.spec.name, "tier": "web"}}`,
+		},
+		{
+			name:    "map literal with a value of the wrong type",
+			kind:    "ConfigMap",
+			field:   "data",
+			value:   `${{"name": schema.spec.name, "replicas": 3}}`,
+			wantErr: "data.replicas must be a string, got integer",
+		},
+		{
+			name:  "list literal matching the field schema",
+			kind:  "Pod",
+			field: "spec",
+			value: map[string]interface{}{
+				"containers": `${[{"name": schema.spec.name, "image": "nginx", "env": [{"name": "A", "value": "b"}]}]}`,
+			},
+		},
+		{
+			name:  "list literal with a nested object of the wrong type",
+			kind:  "Pod",
+			field: "spec",
+			value: map[string]interface{}{
+				"containers": `${[{"name": schema.spec.name, "image": "nginx", "env": {"A": "b"}}]}`,
+			},
+			wantErr: "spec.containers[0].env must be an array, got object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema(
+					"App", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					nil,
+				),
+				generator.WithResource("app", map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       tt.kind,
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+					tt.field: tt.value,
+				}, nil, nil),
+			)
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
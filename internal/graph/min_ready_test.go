@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newMinReadyResourceGroup(minReadyDuration time.Duration) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, []string{`${vpc.status.state == "available"}`}, nil),
+		generator.WithResourceMinReadyDuration("vpc", minReadyDuration),
+	)
+}
+
+func TestGraphBuilder_MinReadyDuration(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	g, err := builder.NewResourceGroup(newMinReadyResourceGroup(30 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, g.Resources["vpc"].GetMinReadyDuration())
+
+	// The time the resource became ready is reported in the instance
+	// status.resources field.
+	status := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	resources := status.Properties["resources"]
+	assert.Equal(t, "array", resources.Type)
+	assert.Equal(t, "date-time", resources.Items.Schema.Properties["readySince"].Format)
+
+	_, err = builder.NewResourceGroup(newMinReadyResourceGroup(-time.Second))
+	assert.ErrorContains(t, err, "minReadyDuration of resource vpc must not be negative")
+}
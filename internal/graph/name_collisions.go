@@ -0,0 +1,79 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// staticObjectKey identifies the object a resource manages, when its name
+// and namespace are literals.
+type staticObjectKey struct {
+	groupResource schema.GroupResource
+	namespace     string
+	name          string
+}
+
+// validateStaticNameCollisions makes sure no two resources manage the same
+// object: the same kind, namespace and name. They would silently overwrite
+// each other on every reconcile.
+//
+// Only the literal names and namespaces can be compared here, the names
+// computed by expressions are only known at runtime. The versions of the
+// kinds are ignored since they serve the same objects. Two conditionally
+// included resources are not reported either, their includeWhen expressions
+// may be mutually exclusive.
+func validateStaticNameCollisions(resources map[string]*Resource) error {
+	resourceIDs := maps.Keys(resources)
+	slices.Sort(resourceIDs)
+	owners := make(map[staticObjectKey]string)
+	for _, id := range resourceIDs {
+		resource := resources[id]
+		key, ok := staticObjectKeyOf(resource)
+		if !ok {
+			continue
+		}
+		owner, found := owners[key]
+		if !found {
+			owners[key] = id
+			continue
+		}
+		if len(resource.includeWhenExpressions) > 0 && len(resources[owner].includeWhenExpressions) > 0 {
+			continue
+		}
+		return fmt.Errorf("resources %s and %s both manage the %s named %q: the resources of a kind must have different names",
+			owner, id, resource.originalObject.GetKind(), key.name)
+	}
+	return nil
+}
+
+// staticObjectKeyOf returns the key of the object managed by a resource, or
+// false if its name or namespace are computed by expressions.
+func staticObjectKeyOf(resource *Resource) (staticObjectKey, bool) {
+	name := resource.originalObject.GetName()
+	namespace := resource.originalObject.GetNamespace()
+	if name == "" || strings.Contains(name, "${") || strings.Contains(namespace, "${") {
+		return staticObjectKey{}, false
+	}
+	return staticObjectKey{
+		groupResource: resource.gvr.GroupResource(),
+		namespace:     namespace,
+		name:          name,
+	}, true
+}
@@ -0,0 +1,122 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func configMapTemplate(name, namespace string) map[string]interface{} {
+	metadata := map[string]interface{}{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   metadata,
+		"data": map[string]interface{}{
+			"owner": "${schema.spec.name}",
+		},
+	}
+}
+
+func TestGraphBuilder_StaticNameCollisions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name        string
+		first       map[string]interface{}
+		second      map[string]interface{}
+		includeWhen []string
+		wantErr     string
+	}{
+		{
+			name:    "same literal name",
+			first:   configMapTemplate("settings", ""),
+			second:  configMapTemplate("settings", ""),
+			wantErr: `resources first and second both manage the ConfigMap named "settings"`,
+		},
+		{
+			name:    "same literal name and namespace",
+			first:   configMapTemplate("settings", "apps"),
+			second:  configMapTemplate("settings", "apps"),
+			wantErr: `resources first and second both manage the ConfigMap named "settings"`,
+		},
+		{
+			name:   "different literal names",
+			first:  configMapTemplate("settings", ""),
+			second: configMapTemplate("defaults", ""),
+		},
+		{
+			name:   "different literal namespaces",
+			first:  configMapTemplate("settings", "apps"),
+			second: configMapTemplate("settings", "jobs"),
+		},
+		{
+			// The names are only known at runtime.
+			name:   "same name expression",
+			first:  configMapTemplate("${schema.spec.name}", ""),
+			second: configMapTemplate("${schema.spec.name}", ""),
+		},
+		{
+			name:   "namespace expression",
+			first:  configMapTemplate("settings", "${schema.spec.name}"),
+			second: configMapTemplate("settings", "apps"),
+		},
+		{
+			// The conditions may be mutually exclusive.
+			name:        "conditionally included resources",
+			first:       configMapTemplate("settings", ""),
+			second:      configMapTemplate("settings", ""),
+			includeWhen: []string{"${schema.spec.enabled}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema(
+					"App", "v1alpha1",
+					map[string]interface{}{
+						"name":    "string",
+						"enabled": "boolean",
+					},
+					nil,
+				),
+				generator.WithResource("first", tt.first, nil, tt.includeWhen),
+				generator.WithResource("second", tt.second, nil, tt.includeWhen),
+			)
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// namespaceGVR is the GVR of the Namespace resources.
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// namespaceDependency is the dependency of a namespaced resource on the
+// Namespace resource it is created in.
+type namespaceDependency struct {
+	resourceID  string
+	namespaceID string
+}
+
+// namespaceDependencies returns the dependencies of the resources on the
+// Namespace resources of the resource group they are created in, sorted by
+// resource id. As the names are usually only known at runtime, the namespace
+// of a resource is matched against the name of the Namespace resources as
+// written in their templates, e.g the same ${schema.spec.name} expression.
+func namespaceDependencies(resources map[string]*Resource) []namespaceDependency {
+	namespaceIDs := make(map[string][]string)
+	for id, resource := range resources {
+		if resource.gvr != namespaceGVR {
+			continue
+		}
+		if name := resource.originalObject.GetName(); name != "" {
+			namespaceIDs[name] = append(namespaceIDs[name], id)
+		}
+	}
+	if len(namespaceIDs) == 0 {
+		return nil
+	}
+
+	var dependencies []namespaceDependency
+	for id, resource := range resources {
+		if resource.gvr == namespaceGVR {
+			continue
+		}
+		for _, namespaceID := range namespaceIDs[resource.originalObject.GetNamespace()] {
+			dependencies = append(dependencies, namespaceDependency{resourceID: id, namespaceID: namespaceID})
+		}
+	}
+	slices.SortFunc(dependencies, func(a, b namespaceDependency) int {
+		if c := strings.Compare(a.resourceID, b.resourceID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.namespaceID, b.namespaceID)
+	})
+	return dependencies
+}
+
+// validateClusterScopedNamespace makes sure the template of a resource of a
+// cluster-scoped kind doesn't set a namespace, either literally or with an
+// expression, which the API server would reject.
+func validateClusterScopedNamespace(id string, gvk schema.GroupVersionKind, object map[string]interface{}) error {
+	namespace, found, _ := unstructured.NestedFieldNoCopy(object, "metadata", "namespace")
+	if !found || namespace == nil || namespace == "" {
+		return nil
+	}
+	return fmt.Errorf("resource %s of cluster-scoped kind %s sets metadata.namespace to %v, cluster-scoped resources have no namespace",
+		id, gvk.Kind, namespace)
+}
@@ -0,0 +1,154 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newNamespaceResourceGroup(namespaceName, appNamespace string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Tenant", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("app", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "${schema.spec.name}-app",
+				"namespace": appNamespace,
+			},
+			"data": map[string]interface{}{
+				"tenant": "${schema.spec.name}",
+			},
+		}, nil, nil),
+		generator.WithResource("tenant", map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": namespaceName,
+			},
+		}, nil, nil),
+	)
+}
+
+func TestGraphBuilder_NamespaceOrdering(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name          string
+		namespaceName string
+		appNamespace  string
+		wantDependent bool
+	}{
+		{
+			name:          "same namespace expression",
+			namespaceName: "${schema.spec.name}",
+			appNamespace:  "${schema.spec.name}",
+			wantDependent: true,
+		},
+		{
+			name:          "same static namespace",
+			namespaceName: "tenant-a",
+			appNamespace:  "tenant-a",
+			wantDependent: true,
+		},
+		{
+			name:          "other namespace",
+			namespaceName: "${schema.spec.name}",
+			appNamespace:  "shared",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := builder.NewResourceGroup(newNamespaceResourceGroup(tt.namespaceName, tt.appNamespace))
+			require.NoError(t, err)
+
+			if tt.wantDependent {
+				// The Namespace is created first, even though the app
+				// doesn't refer to it.
+				assert.Equal(t, []string{"tenant"}, g.Resources["app"].GetDependencies())
+				assert.Equal(t, []string{"tenant", "app"}, g.TopologicalOrder)
+			} else {
+				assert.Empty(t, g.Resources["app"].GetDependencies())
+			}
+			assert.Empty(t, g.Resources["tenant"].GetDependencies())
+		})
+	}
+}
+
+func TestGraphBuilder_ClusterScopedNamespace(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		wantErr   string
+	}{
+		{
+			name: "namespace omitted",
+		},
+		{
+			name:      "literal namespace",
+			namespace: "default",
+			wantErr:   "resource tenant of cluster-scoped kind Namespace sets metadata.namespace to default",
+		},
+		{
+			name:      "namespace expression",
+			namespace: "${schema.spec.name}",
+			wantErr:   "resource tenant of cluster-scoped kind Namespace sets metadata.namespace to ${schema.spec.name}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := newNamespaceResourceGroup("${schema.spec.name}", "${schema.spec.name}")
+			if tt.namespace != "" {
+				tenant := rg.Spec.Resources[1]
+				require.Equal(t, "tenant", tenant.ID)
+				tenant.Template.Raw = []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"${schema.spec.name}","namespace":"` +
+					tt.namespace + `"}}`)
+			}
+
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -29,7 +29,16 @@ var ErrNestedExpression = errors.New("nested expressions are not allowed")
 // extractExpressions extracts all non-nested CEL expressions from a string.
 // It returns an error if it encounters a nested expression.
 func extractExpressions(str string) ([]string, error) {
+	expressions, _, err := extractExpressionsWithOffsets(str)
+	return expressions, err
+}
+
+// extractExpressionsWithOffsets extracts all non-nested CEL expressions from
+// a string, along with their offsets: the byte index of their first character
+// in the string, right after "${".
+func extractExpressionsWithOffsets(str string) ([]string, []int, error) {
 	var expressions []string
+	var offsets []int
 
 	start := 0
 	// Iterate over the string and find all expressions
@@ -61,7 +70,7 @@ func extractExpressions(str string) ([]string, error) {
 			} else if endIdx+1 < len(str) && str[endIdx:endIdx+2] == "${" {
 				// We do not allow nested expressions. I'm not sure if this is a
 				// good idea, but its sounds like a reasonable restriction.
-				return nil, ErrNestedExpression
+				return nil, nil, ErrNestedExpression
 			}
 			endIdx++
 		}
@@ -76,9 +85,10 @@ func extractExpressions(str string) ([]string, error) {
 		// of '${' and the matching '}'
 		expr := str[startIdx+len(exprStart) : endIdx]
 		expressions = append(expressions, expr)
+		offsets = append(offsets, startIdx+len(exprStart))
 		start = endIdx + 1
 	}
-	return expressions, nil
+	return expressions, offsets, nil
 }
 
 // isStandaloneExpression returns true if the string is a single, complete non-nested expression.
@@ -91,3 +101,10 @@ func isStandaloneExpression(str string) (bool, error) {
 
 	return len(expressions) == 1 && str == exprStart+expressions[0]+exprEnd, nil
 }
+
+// trimExpressionDelimiters returns the expression of a standalone expression,
+// without its "${" and "}" delimiters. Only the delimiters are removed, so the
+// braces of a map literal (e.g ${{"key": 123}}) are kept.
+func trimExpressionDelimiters(str string) string {
+	return str[len(exprStart) : len(str)-len(exprEnd)]
+}
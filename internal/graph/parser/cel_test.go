@@ -14,6 +14,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -164,6 +165,40 @@ func TestExtractExpressions(t *testing.T) {
 	}
 }
 
+func TestExtractExpressionsWithOffsets(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []int
+	}{
+		{name: "Standalone expression", input: "${resource.field}", want: []int{2}},
+		{name: "Expression with prefix", input: "prefix-${resource.field}", want: []int{9}},
+		{name: "Multiple expressions", input: "${resource1.field}-middle-${resource2.field}", want: []int{2, 28}},
+		{name: "Expression with a map literal", input: "a-${{'key': resource.field}}", want: []int{4}},
+		{name: "No expression", input: "plain", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expressions, offsets, err := extractExpressionsWithOffsets(tt.input)
+			if err != nil {
+				t.Fatalf("extractExpressionsWithOffsets() error = %v", err)
+			}
+			if len(offsets) != len(tt.want) {
+				t.Fatalf("extractExpressionsWithOffsets() offsets = %v, want %v", offsets, tt.want)
+			}
+			for i, offset := range offsets {
+				if offset != tt.want[i] {
+					t.Errorf("extractExpressionsWithOffsets() offsets = %v, want %v", offsets, tt.want)
+				}
+				if !strings.HasPrefix(tt.input[offset:], expressions[i]) {
+					t.Errorf("expression %q isn't at offset %d of %q", expressions[i], offset, tt.input)
+				}
+			}
+		})
+	}
+}
+
 func equalStrings(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -15,7 +15,6 @@ package parser
 
 import (
 	"fmt"
-	"strings"
 )
 
 // This function parses resource condition expressions.
@@ -37,7 +36,7 @@ func ParseConditionExpressions(conditions []string) ([]string, error) {
 		if !ok {
 			return nil, fmt.Errorf("only standalone expressions are allowed")
 		}
-		expressions = append(expressions, strings.Trim(e, "${}"))
+		expressions = append(expressions, trimExpressionDelimiters(e))
 	}
 
 	return expressions, nil
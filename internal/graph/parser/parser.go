@@ -14,18 +14,52 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"k8s.io/kube-openapi/pkg/validation/spec"
 
+	"github.com/awslabs/kro/internal/graph/fieldpath"
 	"github.com/awslabs/kro/internal/graph/variable"
 )
 
 const (
 	xKubernetesPreserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+
+	// DefaultMaxDepth is the default maximum depth the parser will descend
+	// into a resource. Real world Kubernetes objects are nowhere near this
+	// deep, it only exists to protect against pathological resources and
+	// schemas.
+	DefaultMaxDepth = 128
+)
+
+var (
+	// ErrMaxDepthExceeded is returned when a resource is nested deeper than
+	// the maximum depth allowed by the parser.
+	ErrMaxDepthExceeded = errors.New("maximum parsing depth exceeded")
 )
 
+// ParseOption is a function that modifies the parser options.
+type ParseOption func(*parseOptions)
+
+// parseOptions holds the configuration of the parser.
+type parseOptions struct {
+	// maxDepth is the maximum depth the parser will descend into a
+	// resource before giving up.
+	maxDepth int
+}
+
+// WithMaxDepth sets the maximum depth the parser will descend into a resource.
+func WithMaxDepth(depth int) ParseOption {
+	return func(opts *parseOptions) {
+		opts.maxDepth = depth
+	}
+}
+
 // ParseResource extracts CEL expressions from a resource based on
 // the schema. The resource is expected to be a map[string]interface{}.
 //
@@ -33,14 +67,29 @@ const (
 // and return an error if the resource does not match the schema. When CEL
 // expressions are found, they are extracted and returned with the expected
 // type of the field (inferred from the schema).
-func ParseResource(resource map[string]interface{}, resourceSchema *spec.Schema) ([]variable.FieldDescriptor, error) {
-	return parseResource(resource, resourceSchema, "")
+//
+// The parser descends at most DefaultMaxDepth levels into the resource, this
+// can be changed using the WithMaxDepth option.
+func ParseResource(resource map[string]interface{}, resourceSchema *spec.Schema, options ...ParseOption) ([]variable.FieldDescriptor, error) {
+	opts := &parseOptions{
+		maxDepth: DefaultMaxDepth,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+	return parseResource(resource, resourceSchema, "", opts.maxDepth)
 }
 
 // parseResource is a helper function that recursively extracts CEL expressions
 // from a resource. It uses a depthh first search to traverse the resource and
-// extract expressions from string fields
-func parseResource(resource interface{}, schema *spec.Schema, path string) ([]variable.FieldDescriptor, error) {
+// extract expressions from string fields.
+//
+// remainingDepth is the number of levels the parser is still allowed to
+// descend into, it is decremented every time we go one level deeper.
+func parseResource(resource interface{}, schema *spec.Schema, path string, remainingDepth int) ([]variable.FieldDescriptor, error) {
+	if remainingDepth < 0 {
+		return nil, fmt.Errorf("%w at path %s", ErrMaxDepthExceeded, path)
+	}
 	if err := validateSchema(schema, path); err != nil {
 		return nil, err
 	}
@@ -49,9 +98,9 @@ func parseResource(resource interface{}, schema *spec.Schema, path string) ([]va
 
 	switch field := resource.(type) {
 	case map[string]interface{}:
-		return parseObject(field, schema, path, expectedType)
+		return parseObject(field, schema, path, expectedType, remainingDepth)
 	case []interface{}:
-		return parseArray(field, schema, path, expectedType)
+		return parseArray(field, schema, path, expectedType, remainingDepth)
 	case string:
 		return parseString(field, schema, path, expectedType)
 	case nil:
@@ -89,7 +138,7 @@ func getExpectedType(schema *spec.Schema) string {
 	return ""
 }
 
-func parseObject(field map[string]interface{}, schema *spec.Schema, path, expectedType string) ([]variable.FieldDescriptor, error) {
+func parseObject(field map[string]interface{}, schema *spec.Schema, path, expectedType string, remainingDepth int) ([]variable.FieldDescriptor, error) {
 	if expectedType != "object" && (schema.AdditionalProperties == nil || !schema.AdditionalProperties.Allows) {
 		return nil, fmt.Errorf("expected object type or AdditionalProperties allowed for path %s, got %v", path, field)
 	}
@@ -110,7 +159,14 @@ func parseObject(field map[string]interface{}, schema *spec.Schema, path, expect
 			return nil, fmt.Errorf("error getting field schema for path %s: %v", path+"."+fieldName, err)
 		}
 		fieldPath := joinPathAndFieldName(path, fieldName)
-		fieldExpressions, err := parseResource(value, fieldSchema, fieldPath)
+		var fieldExpressions []variable.FieldDescriptor
+		if fieldSchema == nil {
+			// Any value is allowed, its expressions are extracted without
+			// type checks.
+			fieldExpressions, err = parseSchemalessResource(value, fieldPath)
+		} else {
+			fieldExpressions, err = parseResource(value, fieldSchema, fieldPath, remainingDepth-1)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -119,7 +175,7 @@ func parseObject(field map[string]interface{}, schema *spec.Schema, path, expect
 	return expressionsFields, nil
 }
 
-func parseArray(field []interface{}, schema *spec.Schema, path, expectedType string) ([]variable.FieldDescriptor, error) {
+func parseArray(field []interface{}, schema *spec.Schema, path, expectedType string, remainingDepth int) ([]variable.FieldDescriptor, error) {
 	if expectedType != "array" {
 		return nil, fmt.Errorf("expected array type for path %s, got %v", path, field)
 	}
@@ -132,7 +188,7 @@ func parseArray(field []interface{}, schema *spec.Schema, path, expectedType str
 	var expressionsFields []variable.FieldDescriptor
 	for i, item := range field {
 		itemPath := fmt.Sprintf("%s[%d]", path, i)
-		itemExpressions, err := parseResource(item, itemSchema, itemPath)
+		itemExpressions, err := parseResource(item, itemSchema, itemPath, remainingDepth-1)
 		if err != nil {
 			return nil, err
 		}
@@ -142,15 +198,23 @@ func parseArray(field []interface{}, schema *spec.Schema, path, expectedType str
 }
 
 func parseString(field string, schema *spec.Schema, path, expectedType string) ([]variable.FieldDescriptor, error) {
+	pattern, err := compilePattern(schema.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for path %s: %w", path, err)
+	}
+
 	ok, err := isStandaloneExpression(field)
 	if err != nil {
 		return nil, err
 	}
 	if ok {
 		return []variable.FieldDescriptor{{
-			Expressions:          []string{strings.Trim(field, "${}")},
+			Expressions:          []string{trimExpressionDelimiters(field)},
+			ExpressionOffsets:    []int{len(exprStart)},
 			ExpectedType:         expectedType,
 			ExpectedSchema:       schema,
+			ExpectedFormat:       schema.Format,
+			ExpectedPattern:      pattern,
 			Path:                 path,
 			StandaloneExpression: true,
 		}}, nil
@@ -160,20 +224,50 @@ func parseString(field string, schema *spec.Schema, path, expectedType string) (
 		return nil, fmt.Errorf("expected string type or AdditionalProperties for path %s, got %v", path, field)
 	}
 
-	expressions, err := extractExpressions(field)
+	expressions, offsets, err := extractExpressionsWithOffsets(field)
 	if err != nil {
 		return nil, err
 	}
 	if len(expressions) > 0 {
 		return []variable.FieldDescriptor{{
-			Expressions:  expressions,
-			ExpectedType: expectedType,
-			Path:         path,
+			Expressions:       expressions,
+			ExpressionOffsets: offsets,
+			ExpectedType:      expectedType,
+			ExpectedFormat:    schema.Format,
+			ExpectedPattern:   pattern,
+			Path:              path,
 		}}, nil
 	}
+
+	// Literal strings are validated right away, the values computed from
+	// expressions are validated when the resource is rendered.
+	if pattern != nil && !pattern.MatchString(field) {
+		return nil, fmt.Errorf("value %q of field %s doesn't match pattern %q", field, path, schema.Pattern)
+	}
 	return nil, nil
 }
 
+// patterns caches the compiled schema patterns, keyed by pattern. The same
+// schemas, hence patterns, are parsed over and over.
+var patterns sync.Map
+
+// compilePattern returns the compiled OpenAPI pattern, or nil if the pattern
+// is empty.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
 func parseScalarTypes(field interface{}, _ *spec.Schema, path, expectedType string) ([]variable.FieldDescriptor, error) {
 	if expectedType == "any" {
 		return nil, nil
@@ -198,22 +292,42 @@ func parseScalarTypes(field interface{}, _ *spec.Schema, path, expectedType stri
 	return nil, nil
 }
 
+// getFieldSchema returns the schema of the given field of an object.
+//
+// An object schema can declare both properties and additionalProperties. The
+// declared properties always take precedence, additionalProperties only
+// constrain the other fields:
+// - a declared property is described by its own schema.
+// - any other field is described by the additionalProperties schema, if any.
+// - otherwise, if additionalProperties is true, the field can hold any value
+// and a nil schema is returned.
+//
+// An error is returned if the field is neither a declared property nor
+// allowed by additionalProperties.
 func getFieldSchema(schema *spec.Schema, field string) (*spec.Schema, error) {
-	if schema.Properties != nil {
-		if fieldSchema, ok := schema.Properties[field]; ok {
-			return &fieldSchema, nil
-		}
+	if fieldSchema, ok := schema.Properties[field]; ok {
+		return &fieldSchema, nil
 	}
 
 	if schema.AdditionalProperties != nil {
 		if schema.AdditionalProperties.Schema != nil {
 			return schema.AdditionalProperties.Schema, nil
-		} else if schema.AdditionalProperties.Allows {
-			return &spec.Schema{}, nil
+		}
+		if schema.AdditionalProperties.Allows {
+			return nil, nil
 		}
 	}
 
-	return nil, fmt.Errorf("schema not found for field %s", field)
+	if len(schema.Properties) == 0 {
+		return nil, fmt.Errorf("schema not found for field %s: the object declares no properties and doesn't allow additionalProperties", field)
+	}
+	properties := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	sort.Strings(properties)
+	return nil, fmt.Errorf("schema not found for field %s: not one of the declared properties (%s) and additionalProperties aren't allowed",
+		field, strings.Join(properties, ", "))
 }
 
 func getArrayItemSchema(schema *spec.Schema, path string) (*spec.Schema, error) {
@@ -240,15 +354,14 @@ func isInteger(v interface{}) bool {
 	}
 }
 
-// joinPathAndField appends a field name to a path. If the fieldName contains
-// a dot or is empty, the path will be appended using ["fieldName"] instead of
-// .fieldName to avoid ambiguity and simplify parsing back the path.
+// joinPathAndField appends a field name to a path. If the fieldName is empty
+// or contains characters with a special meaning in paths (e.g dots), the path
+// will be appended using ["fieldName"] instead of .fieldName to avoid
+// ambiguity and simplify parsing back the path.
 func joinPathAndFieldName(path, fieldName string) string {
-	if fieldName == "" || strings.Contains(fieldName, ".") {
-		return fmt.Sprintf("%s[%q]", path, fieldName)
-	}
-	if path == "" {
-		return fieldName
+	segment := fieldpath.Path{fieldpath.NewNamedSegment(fieldName)}.String()
+	if path == "" || strings.HasPrefix(segment, "[") {
+		return path + segment
 	}
-	return fmt.Sprintf("%s.%s", path, fieldName)
+	return path + "." + segment
 }
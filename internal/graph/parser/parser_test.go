@@ -14,7 +14,9 @@
 package parser
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"k8s.io/kube-openapi/pkg/validation/spec"
@@ -484,6 +486,117 @@ func TestParseWithExpectedSchema(t *testing.T) {
 	}
 }
 
+func TestParseByteFormat(t *testing.T) {
+	// The data field of a Secret.
+	schema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: []string{"object"},
+			Properties: map[string]spec.Schema{
+				"data": {
+					SchemaProps: spec.SchemaProps{
+						Type: []string{"object"},
+						AdditionalProperties: &spec.SchemaOrBool{
+							Allows: true,
+							Schema: &spec.Schema{
+								SchemaProps: spec.SchemaProps{Type: []string{"string"}, Format: "byte"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	resource := map[string]interface{}{
+		"data": map[string]interface{}{
+			"password": "${schema.spec.password}",
+			"token":    "token-${schema.spec.token}",
+		},
+	}
+
+	descriptors, err := ParseResource(resource, schema)
+	if err != nil {
+		t.Fatalf("ParseResource() error = %v", err)
+	}
+	if len(descriptors) != 2 {
+		t.Fatalf("ParseResource() got %d descriptors, want 2", len(descriptors))
+	}
+	for _, d := range descriptors {
+		if d.ExpectedFormat != "byte" {
+			t.Errorf("ParseResource() format of %s = %q, want %q", d.Path, d.ExpectedFormat, "byte")
+		}
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	schema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: []string{"object"},
+			Properties: map[string]spec.Schema{
+				"name": {
+					SchemaProps: spec.SchemaProps{Type: []string{"string"}, Pattern: "^[a-z]+$"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{
+			name:  "matching literal",
+			value: "app",
+		},
+		{
+			name:    "non matching literal",
+			value:   "App-1",
+			wantErr: `value "App-1" of field name doesn't match pattern "^[a-z]+$"`,
+		},
+		{
+			name:  "expression",
+			value: "${schema.spec.name}",
+		},
+		{
+			name:  "template",
+			value: "app-${schema.spec.name}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			descriptors, err := ParseResource(map[string]interface{}{"name": tt.value}, schema)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseResource() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseResource() error = %v", err)
+			}
+			// The values of the expressions are validated at render time.
+			for _, d := range descriptors {
+				if d.ExpectedPattern == nil || d.ExpectedPattern.String() != "^[a-z]+$" {
+					t.Errorf("ParseResource() pattern of %s = %v, want ^[a-z]+$", d.Path, d.ExpectedPattern)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		invalid := &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}, Pattern: "[a-z"}}
+		_, err := ParseResource(map[string]interface{}{"name": "app"}, &spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:       []string{"object"},
+				Properties: map[string]spec.Schema{"name": *invalid},
+			},
+		})
+		if err == nil || !strings.Contains(err.Error(), "invalid pattern for path name") {
+			t.Errorf("ParseResource() error = %v, want an invalid pattern error", err)
+		}
+	})
+}
+
 func TestParserEdgeCases(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -584,7 +697,8 @@ func TestParserEdgeCases(t *testing.T) {
 				"name":    "random parrot",
 				"surname": "the parrot",
 			},
-			expectedError: "error getting field schema for path .surname: schema not found for field surname",
+			expectedError: "error getting field schema for path .surname: schema not found for field surname: " +
+				"not one of the declared properties (age, name) and additionalProperties aren't allowed",
 		},
 		{
 			name: "valid schema and resource - no error expected",
@@ -622,7 +736,7 @@ func TestParserEdgeCases(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := parseResource(tc.resource, tc.schema, "")
+			_, err := parseResource(tc.resource, tc.schema, "", DefaultMaxDepth)
 
 			if tc.expectedError == "" {
 				if err != nil {
@@ -639,6 +753,76 @@ func TestParserEdgeCases(t *testing.T) {
 	}
 }
 
+func TestParseResourceMaxDepth(t *testing.T) {
+	// A self-referential schema: every nested object is described by
+	// the same schema.
+	schema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: []string{"object"},
+		},
+	}
+	schema.AdditionalProperties = &spec.SchemaOrBool{Allows: true, Schema: schema}
+
+	// nestedResource returns a resource with depth nested "child" objects,
+	// the innermost one holding a CEL expression.
+	nestedResource := func(depth int) map[string]interface{} {
+		resource := map[string]interface{}{"value": "${schema.spec.value}"}
+		for i := 0; i < depth; i++ {
+			resource = map[string]interface{}{"child": resource}
+		}
+		return resource
+	}
+
+	testCases := []struct {
+		name         string
+		resource     map[string]interface{}
+		options      []ParseOption
+		expectedPath string
+		expectedErr  bool
+	}{
+		{
+			name:         "nested resource within the default limit",
+			resource:     nestedResource(20),
+			expectedPath: strings.Repeat("child.", 20) + "value",
+		},
+		{
+			name:        "nested resource exceeding the default limit",
+			resource:    nestedResource(DefaultMaxDepth + 1),
+			expectedErr: true,
+		},
+		{
+			name:         "nested resource within a custom limit",
+			resource:     nestedResource(4),
+			options:      []ParseOption{WithMaxDepth(5)},
+			expectedPath: strings.Repeat("child.", 4) + "value",
+		},
+		{
+			name:        "nested resource exceeding a custom limit",
+			resource:    nestedResource(5),
+			options:     []ParseOption{WithMaxDepth(5)},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expressions, err := ParseResource(tc.resource, schema, tc.options...)
+			if tc.expectedErr {
+				if !errors.Is(err, ErrMaxDepthExceeded) {
+					t.Fatalf("Expected error %v, but got: %v", ErrMaxDepthExceeded, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if len(expressions) != 1 || expressions[0].Path != tc.expectedPath {
+				t.Errorf("Expected a single expression at path %s, got %v", tc.expectedPath, expressions)
+			}
+		})
+	}
+}
+
 func TestJoinPathAndFieldName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -666,3 +850,125 @@ func TestJoinPathAndFieldName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFieldSchema(t *testing.T) {
+	stringSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}}
+	integerSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"integer"}}}
+	properties := map[string]spec.Schema{"name": stringSchema}
+
+	tests := []struct {
+		name                 string
+		properties           map[string]spec.Schema
+		additionalProperties *spec.SchemaOrBool
+		field                string
+		want                 *spec.Schema
+		wantErr              string
+	}{
+		{
+			name:       "declared property",
+			properties: properties,
+			field:      "name",
+			want:       &stringSchema,
+		},
+		{
+			name:                 "declared property takes precedence over additionalProperties",
+			properties:           properties,
+			additionalProperties: &spec.SchemaOrBool{Allows: true, Schema: &integerSchema},
+			field:                "name",
+			want:                 &stringSchema,
+		},
+		{
+			name:                 "additionalProperties schema",
+			properties:           properties,
+			additionalProperties: &spec.SchemaOrBool{Allows: true, Schema: &integerSchema},
+			field:                "age",
+			want:                 &integerSchema,
+		},
+		{
+			name:                 "additionalProperties allowed",
+			properties:           properties,
+			additionalProperties: &spec.SchemaOrBool{Allows: true},
+			field:                "age",
+			want:                 nil,
+		},
+		{
+			name:       "neither declared nor additionalProperties",
+			properties: properties,
+			field:      "age",
+			wantErr:    "schema not found for field age: not one of the declared properties (name) and additionalProperties aren't allowed",
+		},
+		{
+			name:                 "neither declared nor allowed additionalProperties",
+			properties:           properties,
+			additionalProperties: &spec.SchemaOrBool{Allows: false},
+			field:                "age",
+			wantErr:              "schema not found for field age: not one of the declared properties (name) and additionalProperties aren't allowed",
+		},
+		{
+			name:    "no properties",
+			field:   "age",
+			wantErr: "schema not found for field age: the object declares no properties and doesn't allow additionalProperties",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &spec.Schema{
+				SchemaProps: spec.SchemaProps{
+					Type:                 []string{"object"},
+					Properties:           tt.properties,
+					AdditionalProperties: tt.additionalProperties,
+				},
+			}
+			got, err := getFieldSchema(schema, tt.field)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("getFieldSchema() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getFieldSchema() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getFieldSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResourceAdditionalProperties(t *testing.T) {
+	schema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: []string{"object"},
+			Properties: map[string]spec.Schema{
+				"name": {SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+			},
+			AdditionalProperties: &spec.SchemaOrBool{Allows: true},
+		},
+	}
+	resource := map[string]interface{}{
+		"name": "${schema.spec.name}",
+		"extra": map[string]interface{}{
+			"list": []interface{}{"${schema.spec.value}"},
+		},
+	}
+
+	expressions, err := ParseResource(resource, schema)
+	if err != nil {
+		t.Fatalf("ParseResource() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, expr := range expressions {
+		got[expr.Path] = expr.ExpectedType
+	}
+	want := map[string]string{
+		// The declared property keeps its type, the additional field can
+		// hold any value.
+		"name":          "string",
+		"extra.list[0]": "any",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseResource() expression types = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// ValidateArraySchemas walks the given resource schema and returns an error
+// naming the path of the first array field that doesn't describe its items,
+// either with Items.Schema or Items.Schemas.
+//
+// The parser only fails on such fields when it traverses them, this allows
+// to reject malformed schemas upfront, whatever the fields set in a resource.
+func ValidateArraySchemas(schema *spec.Schema) error {
+	return validateArraySchemas(schema, "", map[*spec.Schema]bool{})
+}
+
+// validateArraySchemas is a helper function that recursively validates the
+// array schemas. visiting holds the schemas being validated, to stop on
+// self-referential schemas.
+func validateArraySchemas(schema *spec.Schema, path string, visiting map[*spec.Schema]bool) error {
+	if schema == nil || visiting[schema] {
+		return nil
+	}
+	visiting[schema] = true
+	defer delete(visiting, schema)
+
+	if schema.Type.Contains("array") {
+		if schema.Items == nil || (schema.Items.Schema == nil && len(schema.Items.Schemas) == 0) {
+			return fmt.Errorf("invalid array schema for path %s: neither Items.Schema nor Items.Schemas are defined", path)
+		}
+	}
+
+	// Walk the properties in order, to always report the same path.
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property := schema.Properties[name]
+		if err := validateArraySchemas(&property, joinPathAndFieldName(path, name), visiting); err != nil {
+			return err
+		}
+	}
+	if schema.AdditionalProperties != nil {
+		if err := validateArraySchemas(schema.AdditionalProperties.Schema, path+"[*]", visiting); err != nil {
+			return err
+		}
+	}
+	if schema.Items != nil {
+		if err := validateArraySchemas(schema.Items.Schema, path+"[*]", visiting); err != nil {
+			return err
+		}
+		for i := range schema.Items.Schemas {
+			if err := validateArraySchemas(&schema.Items.Schemas[i], fmt.Sprintf("%s[%d]", path, i), visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+func TestValidateArraySchemas(t *testing.T) {
+	stringSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}}
+	objectSchema := func(properties map[string]spec.Schema) *spec.Schema {
+		return &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}, Properties: properties}}
+	}
+	arraySchema := func(items *spec.SchemaOrArray) spec.Schema {
+		return spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"array"}, Items: items}}
+	}
+
+	// A self-referential schema: the items of the array are described by
+	// the schema holding the array.
+	recursive := objectSchema(nil)
+	recursive.Properties = map[string]spec.Schema{
+		"children": arraySchema(&spec.SchemaOrArray{Schema: recursive}),
+	}
+
+	testCases := []struct {
+		name        string
+		schema      *spec.Schema
+		expectedErr string
+	}{
+		{
+			name: "well-formed array schemas",
+			schema: objectSchema(map[string]spec.Schema{
+				"ports": arraySchema(&spec.SchemaOrArray{Schema: objectSchema(map[string]spec.Schema{
+					"hosts": arraySchema(&spec.SchemaOrArray{Schema: &stringSchema}),
+				})}),
+				"tuple": arraySchema(&spec.SchemaOrArray{Schemas: []spec.Schema{stringSchema, stringSchema}}),
+			}),
+		},
+		{
+			name:   "self-referential schema",
+			schema: recursive,
+		},
+		{
+			name: "array without items",
+			schema: objectSchema(map[string]spec.Schema{
+				"spec": *objectSchema(map[string]spec.Schema{
+					"ports": arraySchema(nil),
+				}),
+			}),
+			expectedErr: "invalid array schema for path spec.ports: neither Items.Schema nor Items.Schemas are defined",
+		},
+		{
+			name: "array with empty items",
+			schema: objectSchema(map[string]spec.Schema{
+				"ports": arraySchema(&spec.SchemaOrArray{}),
+			}),
+			expectedErr: "invalid array schema for path ports: neither Items.Schema nor Items.Schemas are defined",
+		},
+		{
+			name: "nested array without items",
+			schema: objectSchema(map[string]spec.Schema{
+				"rules": arraySchema(&spec.SchemaOrArray{Schema: objectSchema(map[string]spec.Schema{
+					"hosts": arraySchema(nil),
+				})}),
+			}),
+			expectedErr: "invalid array schema for path rules[*].hosts: neither Items.Schema nor Items.Schemas are defined",
+		},
+		{
+			name: "array without items in a map",
+			schema: &spec.Schema{SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				AdditionalProperties: &spec.SchemaOrBool{Allows: true, Schema: &spec.Schema{
+					SchemaProps: spec.SchemaProps{Type: []string{"array"}},
+				}},
+			}},
+			expectedErr: "invalid array schema for path [*]: neither Items.Schema nor Items.Schemas are defined",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateArraySchemas(tc.schema)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Fatalf("Expected error %q, but got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
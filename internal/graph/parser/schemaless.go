@@ -15,7 +15,6 @@ package parser
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/awslabs/kro/internal/graph/variable"
 )
@@ -57,7 +56,7 @@ func parseSchemalessResource(resource interface{}, path string) ([]variable.Fiel
 		}
 		if ok {
 			expressionsFields = append(expressionsFields, variable.FieldDescriptor{
-				Expressions:          []string{strings.Trim(field, "${}")},
+				Expressions:          []string{trimExpressionDelimiters(field)},
 				ExpectedType:         "any",
 				Path:                 path,
 				StandaloneExpression: true,
@@ -0,0 +1,44 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// pausedField is the name of the instance spec field pausing the
+// reconciliation of the instance.
+const pausedField = "paused"
+
+// addPausedField declares the paused field in the instance spec schema, when
+// the instances are pausable.
+func addPausedField(specSchema *extv1.JSONSchemaProps, pausable bool) error {
+	if !pausable {
+		return nil
+	}
+	if _, ok := specSchema.Properties[pausedField]; ok {
+		return fmt.Errorf("spec field %s is reserved to pausable instances", pausedField)
+	}
+	if specSchema.Properties == nil {
+		specSchema.Properties = make(map[string]extv1.JSONSchemaProps)
+	}
+	specSchema.Properties[pausedField] = extv1.JSONSchemaProps{
+		Type:        "boolean",
+		Description: "Paused pauses the reconciliation of the instance while true.",
+		Default:     &extv1.JSON{Raw: []byte("false")},
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_Pausable(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+	newResourceGroup := func(spec map[string]interface{}, opts ...generator.ResourceGroupOption) *Graph {
+		t.Helper()
+		opts = append([]generator.ResourceGroupOption{
+			generator.WithSchema("WebApp", "v1alpha1", spec, nil),
+			generator.WithResource("config", map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name": "${schema.spec.name}",
+				},
+			}, nil, nil),
+		}, opts...)
+		g, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group", opts...))
+		require.NoError(t, err)
+		return g
+	}
+
+	t.Run("pausable", func(t *testing.T) {
+		g := newResourceGroup(map[string]interface{}{"name": "string"}, generator.WithPausable())
+		assert.True(t, g.Pausable)
+		paused, ok := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["paused"]
+		require.True(t, ok, "spec.paused must be declared")
+		assert.Equal(t, "boolean", paused.Type)
+		assert.Equal(t, "false", string(paused.Default.Raw))
+	})
+
+	t.Run("not pausable", func(t *testing.T) {
+		g := newResourceGroup(map[string]interface{}{"name": "string"})
+		assert.False(t, g.Pausable)
+		assert.NotContains(t, g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties, "paused")
+	})
+
+	t.Run("reserved field", func(t *testing.T) {
+		_, err := builder.NewResourceGroup(generator.NewResourceGroup("test-group",
+			generator.WithSchema("WebApp", "v1alpha1", map[string]interface{}{"name": "string", "paused": "string"}, nil),
+			generator.WithPausable(),
+		))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "spec field paused is reserved to pausable instances")
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"regexp"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+var (
+	// statusJSONPathRegex matches the printer column JSONPaths reading the
+	// status, made of field names optionally followed by an array index, e.g
+	// .status.endpoints[0].address. The other JSONPaths are not checked.
+	statusJSONPathRegex = regexp.MustCompile(`^\.status((\.[A-Za-z0-9_-]+(\[(\d+|\*)\])?)+)$`)
+	// jsonPathSegmentRegex matches a segment of a status JSONPath.
+	jsonPathSegmentRegex = regexp.MustCompile(`\.([A-Za-z0-9_-]+)(\[(\d+|\*)\])?`)
+)
+
+// printerColumnWarnings returns a warning for every printer column whose
+// JSONPath reads a field that isn't declared in the instance status schema.
+// Such a column is always empty, as the field is never populated.
+func printerColumnWarnings(columns []extv1.CustomResourceColumnDefinition, statusSchema *extv1.JSONSchemaProps) []string {
+	var warnings []string
+	for _, column := range columns {
+		match := statusJSONPathRegex.FindStringSubmatch(column.JSONPath)
+		if match == nil {
+			continue
+		}
+		if !statusFieldDeclared(statusSchema, jsonPathSegmentRegex.FindAllStringSubmatch(match[1], -1)) {
+			warnings = append(warnings, fmt.Sprintf(
+				"printer column %q reads %s, which is not a field of the instance status, the column will always be empty",
+				column.Name, column.JSONPath,
+			))
+		}
+	}
+	return warnings
+}
+
+// statusFieldDeclared walks the status schema along the given JSONPath
+// segments, and returns whether the field they point to is declared. The
+// fields under objects accepting arbitrary fields are considered declared.
+func statusFieldDeclared(statusSchema *extv1.JSONSchemaProps, segments [][]string) bool {
+	current := statusSchema
+	for _, segment := range segments {
+		if current == nil {
+			return false
+		}
+		name, indexed := segment[1], segment[2] != ""
+		property, ok := current.Properties[name]
+		if !ok {
+			return acceptsArbitraryFields(current)
+		}
+		current = &property
+		if indexed {
+			if current.Type != "array" || current.Items == nil {
+				return false
+			}
+			current = current.Items.Schema
+		}
+	}
+	return true
+}
+
+// acceptsArbitraryFields returns whether the given object schema accepts
+// fields that aren't declared in its properties.
+func acceptsArbitraryFields(schema *extv1.JSONSchemaProps) bool {
+	return (schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields) ||
+		(schema.AdditionalProperties != nil && (schema.AdditionalProperties.Allows || schema.AdditionalProperties.Schema != nil))
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_PrinterColumnWarnings(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name        string
+		jsonPath    string
+		wantWarning bool
+	}{
+		{name: "declared status field", jsonPath: ".status.vpcID"},
+		{name: "default status field", jsonPath: ".status.state"},
+		{name: "condition of the default status", jsonPath: ".status.conditions[0].type"},
+		{name: "spec field", jsonPath: ".spec.name"},
+		{name: "nonexistent status field", jsonPath: ".status.vpcId", wantWarning: true},
+		{name: "nonexistent nested status field", jsonPath: ".status.vpcID.value", wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema(
+					"Network", "v1alpha1",
+					map[string]interface{}{
+						"name": "string",
+					},
+					map[string]interface{}{
+						"vpcID": "${vpc.status.vpcID}",
+					},
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+			)
+			rg.Spec.Schema.AdditionalPrinterColumns = []extv1.CustomResourceColumnDefinition{
+				{Name: "Column", Type: "string", JSONPath: tt.jsonPath},
+			}
+
+			g, err := builder.NewResourceGroup(rg)
+			require.NoError(t, err)
+			if !tt.wantWarning {
+				assert.Empty(t, g.Warnings)
+				return
+			}
+			require.Len(t, g.Warnings, 1)
+			assert.Contains(t, g.Warnings[0], `printer column "Column" reads `+tt.jsonPath)
+			assert.Contains(t, g.Warnings[0], "not a field of the instance status")
+		})
+	}
+}
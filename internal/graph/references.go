@@ -0,0 +1,147 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/parser"
+	"github.com/awslabs/kro/internal/graph/variable"
+	"github.com/awslabs/kro/pkg/cel/ast"
+)
+
+// referencingExpression is a CEL expression of a resource group, along with
+// where it is declared, e.g "spec.vpcID of resource subnet".
+type referencingExpression struct {
+	location   string
+	expression string
+}
+
+// resourceGroupExpressions returns all the CEL expressions of a resource group
+// that can reference its resources: the ones of the resource templates, their
+// readyWhen, includeWhen and conditions, and the ones of the instance status.
+func resourceGroupExpressions(rg *v1alpha1.ResourceGroup, resources map[string]*Resource) []referencingExpression {
+	var expressions []referencingExpression
+	resourceIDs := maps.Keys(resources)
+	slices.Sort(resourceIDs)
+	for _, id := range resourceIDs {
+		resource := resources[id]
+		for _, resourceVariable := range resource.variables {
+			for _, expression := range resourceVariable.Expressions {
+				expressions = append(expressions, referencingExpression{
+					location:   fmt.Sprintf("%s of resource %s", resourceVariable.Path, id),
+					expression: expression,
+				})
+			}
+		}
+		for _, expression := range resource.readyWhenExpressions {
+			expressions = append(expressions, referencingExpression{
+				location:   fmt.Sprintf("readyWhen of resource %s", id),
+				expression: expression,
+			})
+		}
+		for _, expression := range resource.includeWhenExpressions {
+			expressions = append(expressions, referencingExpression{
+				location:   fmt.Sprintf("includeWhen of resource %s", id),
+				expression: expression,
+			})
+		}
+		for _, condition := range resource.conditionExpressions {
+			expressions = append(expressions, referencingExpression{
+				location:   fmt.Sprintf("condition %s of resource %s", condition.Type, id),
+				expression: condition.Expression,
+			})
+		}
+	}
+
+	// An invalid status is reported while building the instance resource.
+	status := map[string]interface{}{}
+	if err := yaml.UnmarshalStrict(rg.Spec.Schema.Status.Raw, &status); err != nil {
+		return expressions
+	}
+	fieldDescriptors, err := parser.ParseSchemalessResource(status)
+	if err != nil {
+		return expressions
+	}
+	slices.SortFunc(fieldDescriptors, func(a, b variable.FieldDescriptor) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	for _, fieldDescriptor := range fieldDescriptors {
+		for _, expression := range fieldDescriptor.Expressions {
+			expressions = append(expressions, referencingExpression{
+				location:   fmt.Sprintf("status.%s", fieldDescriptor.Path),
+				expression: expression,
+			})
+		}
+	}
+	return expressions
+}
+
+// removedResourceIDs returns the IDs of the resources of the last graph built
+// for the resource group, as reported in its status, that are no longer
+// declared in its spec.
+func removedResourceIDs(rg *v1alpha1.ResourceGroup, resources map[string]*Resource) []string {
+	previous := slices.Clone(rg.Status.TopologicalOrder)
+	for _, resource := range rg.Status.Resources {
+		previous = append(previous, resource.ID)
+	}
+	var removed []string
+	for _, id := range previous {
+		if _, ok := resources[id]; !ok && !slices.Contains(removed, id) {
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
+
+// validateResourceReferences checks that the root identifiers of all the
+// expressions of the resource group are declared resource IDs or known context
+// variables (e.g schema), before building the graph. Undeclared references
+// would otherwise only surface as cryptic compilation or evaluation errors.
+//
+// The references to a resource removed from the resource group, e.g when an
+// author removes a resource but leaves expressions referencing it elsewhere,
+// name the removed resource.
+func validateResourceReferences(rg *v1alpha1.ResourceGroup, resources map[string]*Resource) error {
+	removed := removedResourceIDs(rg, resources)
+
+	resourceNames := append(maps.Keys(resources), "schema", featuresVariable)
+	env, err := newResourcesEnvironment(resourceNames)
+	if err != nil {
+		return fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	inspector := ast.NewInspectorWithEnv(env, append(resourceNames, resourcesMapVariable), nil)
+
+	for _, expression := range resourceGroupExpressions(rg, resources) {
+		// Invalid expressions are reported later on.
+		inspection, err := inspector.Inspect(expression.expression)
+		if err != nil {
+			continue
+		}
+		for _, unknown := range inspection.UnknownResources {
+			if slices.Contains(removed, unknown.ID) {
+				return fmt.Errorf("expression at %s has a dangling reference %s: resource %s was removed from the resource group",
+					expression.location, unknown.Path, unknown.ID)
+			}
+			return fmt.Errorf("expression at %s references undeclared resource '%s'", expression.location, unknown.ID)
+		}
+	}
+	return nil
+}
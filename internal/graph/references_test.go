@@ -0,0 +1,196 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+// newNetworkResourceGroup returns a resource group with a subnet, and
+// optionally a vpc. The subnet refers to the vpc if subnetSpec says so.
+func newNetworkResourceGroup(status map[string]interface{}, withVPC bool, subnetSpec map[string]interface{}) *v1alpha1.ResourceGroup {
+	opts := []generator.ResourceGroupOption{
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			status,
+		),
+	}
+	if withVPC {
+		opts = append(opts, generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil))
+	}
+	opts = append(opts, generator.WithResource("subnet", map[string]interface{}{
+		"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+		"kind":       "Subnet",
+		"metadata": map[string]interface{}{
+			"name": "${schema.spec.name}-subnet",
+		},
+		"spec": subnetSpec,
+	}, nil, nil))
+	return generator.NewResourceGroup("test-group", opts...)
+}
+
+func TestGraphBuilder_RemovedResourceReferences(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	subnetSpec := map[string]interface{}{
+		"cidrBlock": "10.0.1.0/24",
+		"vpcID":     "${vpc.status.vpcID}",
+	}
+
+	// The resource group is first built with the vpc.
+	rg := newNetworkResourceGroup(map[string]interface{}{
+		"subnetID": "${subnet.status.subnetID}",
+	}, true, subnetSpec)
+	g, err := builder.NewResourceGroup(rg)
+	require.NoError(t, err)
+	rg.Status.TopologicalOrder = g.TopologicalOrder
+
+	t.Run("template still referencing the removed resource", func(t *testing.T) {
+		edited := newNetworkResourceGroup(map[string]interface{}{
+			"subnetID": "${subnet.status.subnetID}",
+		}, false, subnetSpec)
+		edited.Status = rg.Status
+
+		_, err := builder.NewResourceGroup(edited)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"expression at spec.vpcID of resource subnet has a dangling reference vpc.status.vpcID: resource vpc was removed from the resource group")
+	})
+
+	t.Run("status still referencing the removed resource", func(t *testing.T) {
+		edited := newNetworkResourceGroup(map[string]interface{}{
+			"vpcID": "${vpc.status.vpcID}",
+		}, false, map[string]interface{}{
+			"cidrBlock": "10.0.1.0/24",
+		})
+		edited.Status = rg.Status
+
+		_, err := builder.NewResourceGroup(edited)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(),
+			"expression at status.vpcID has a dangling reference vpc.status.vpcID: resource vpc was removed from the resource group")
+	})
+
+	t.Run("resource never part of the resource group", func(t *testing.T) {
+		// Without a previous graph, the reference is reported as unknown.
+		_, err := builder.NewResourceGroup(newNetworkResourceGroup(nil, false, subnetSpec))
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "was removed from the resource group")
+	})
+}
+
+func TestGraphBuilder_UndeclaredResourceReferences(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name       string
+		subnetSpec map[string]interface{}
+		status     map[string]interface{}
+		opts       []generator.ResourceGroupOption
+		wantErr    string
+	}{
+		{
+			name: "declared resources and context variables",
+			subnetSpec: map[string]interface{}{
+				"cidrBlock": "${vpc.spec.cidrBlocks.exists(c, c.startsWith('10.')) ? '10.0.1.0/24' : '10.1.1.0/24'}",
+				"vpcID":     "${resources.vpc.status.vpcID}",
+			},
+			status: map[string]interface{}{
+				"subnetID": "${subnet.status.subnetID}",
+			},
+		},
+		{
+			name: "undeclared resource in a template",
+			subnetSpec: map[string]interface{}{
+				"vpcID": "${network.status.vpcID}",
+			},
+			wantErr: "expression at spec.vpcID of resource subnet references undeclared resource 'network'",
+		},
+		{
+			name: "undeclared resource in a macro",
+			subnetSpec: map[string]interface{}{
+				"cidrBlock": "${vpc.spec.cidrBlocks.filter(c, c == network.spec.cidrBlock)[0]}",
+			},
+			wantErr: "expression at spec.cidrBlock of resource subnet references undeclared resource 'network'",
+		},
+		{
+			name: "undeclared resource in the status",
+			subnetSpec: map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+			},
+			status: map[string]interface{}{
+				"routeTableID": "${routetable.status.routeTableID}",
+			},
+			wantErr: "expression at status.routeTableID references undeclared resource 'routetable'",
+		},
+		{
+			name: "undeclared resource in a condition",
+			subnetSpec: map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+			},
+			opts: []generator.ResourceGroupOption{
+				generator.WithResourceConditions("subnet", v1alpha1.ResourceCondition{
+					Type:       "Routed",
+					Expression: `${routetable.status.state == "available"}`,
+				}),
+			},
+			wantErr: "expression at condition Routed of resource subnet references undeclared resource 'routetable'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := newNetworkResourceGroup(tt.status, true, tt.subnetSpec)
+			for _, opt := range tt.opts {
+				opt(rg)
+			}
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
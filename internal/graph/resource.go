@@ -15,6 +15,7 @@ package graph
 
 import (
 	"slices"
+	"time"
 
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -66,6 +67,21 @@ type Resource struct {
 	// includeWhenExpressions is a list of the expresisons that need to be evaluated
 	// to decide whether to create a resource group or not
 	includeWhenExpressions []string
+	// waitFor is a list of the resources that must be ready before the
+	// resource is applied. They are part of its dependencies.
+	waitFor []string
+	// conditionExpressions is a list of the named conditions computed from
+	// the resource and reported in the instance status.
+	conditionExpressions []variable.ConditionExpression
+	// minReadyDuration is how long the resource must be ready before it is
+	// considered ready.
+	minReadyDuration time.Duration
+	// finalizers are the finalizers kro adds to the resource on creation,
+	// and removes from it when deleting it.
+	finalizers []string
+	// evaluateOnce are the canonical paths of the fields only evaluated when
+	// the resource is first rendered.
+	evaluateOnce []string
 	// namespaced indicates if the resource is namespaced or cluster-scoped.
 	// This is useful when initiating the dynamic client to interact with the
 	// resource.
@@ -146,6 +162,34 @@ func (r *Resource) GetIncludeWhenExpressions() []string {
 	return r.includeWhenExpressions
 }
 
+// GetWaitFor returns the ids of the resources that must be ready before the
+// resource is applied.
+func (r *Resource) GetWaitFor() []string {
+	return r.waitFor
+}
+
+// GetMinReadyDuration returns how long the resource must be ready before it
+// is considered ready.
+func (r *Resource) GetMinReadyDuration() time.Duration {
+	return r.minReadyDuration
+}
+
+// GetFinalizers returns the finalizers kro manages on the resource.
+func (r *Resource) GetFinalizers() []string {
+	return r.finalizers
+}
+
+// GetEvaluateOnce returns the paths of the fields only evaluated when the
+// resource is first rendered.
+func (r *Resource) GetEvaluateOnce() []string {
+	return r.evaluateOnce
+}
+
+// GetConditionExpressions returns the named condition expressions of the resource.
+func (r *Resource) GetConditionExpressions() []variable.ConditionExpression {
+	return r.conditionExpressions
+}
+
 // GetTopLevelFields returns the top-level fields of the resource.
 func (r *Resource) GetTopLevelFields() []string {
 	return rgschema.GetResourceTopLevelFieldNames(r.schema)
@@ -167,6 +211,11 @@ func (r *Resource) DeepCopy() *Resource {
 		dependencies:           slices.Clone(r.dependencies),
 		readyWhenExpressions:   slices.Clone(r.readyWhenExpressions),
 		includeWhenExpressions: slices.Clone(r.includeWhenExpressions),
+		waitFor:                slices.Clone(r.waitFor),
+		conditionExpressions:   slices.Clone(r.conditionExpressions),
+		minReadyDuration:       r.minReadyDuration,
+		finalizers:             slices.Clone(r.finalizers),
+		evaluateOnce:           slices.Clone(r.evaluateOnce),
 		namespaced:             r.namespaced,
 	}
 }
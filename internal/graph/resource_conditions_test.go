@@ -0,0 +1,183 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newResourceConditionsResourceGroup(conditions ...v1alpha1.ResourceCondition) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+		generator.WithResourceConditions("vpc", conditions...),
+	)
+}
+
+func TestGraphBuilder_ResourceConditions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	g, err := builder.NewResourceGroup(newResourceConditionsResourceGroup(
+		v1alpha1.ResourceCondition{Type: "VPCAvailable", Expression: `${vpc.status.state == "available"}`},
+	))
+	require.NoError(t, err)
+
+	// The conditions are reported in the instance status.resources field.
+	status := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	resources := status.Properties["resources"]
+	assert.Equal(t, "array", resources.Type)
+	assert.Contains(t, resources.Items.Schema.Properties, "conditions")
+
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kro.run/v1alpha1",
+		"kind":       "Network",
+		"metadata":   map[string]interface{}{"name": "my-network", "namespace": "default"},
+		"spec":       map[string]interface{}{"name": "my-network"},
+	}}
+	rt, err := g.NewGraphRuntime(instance)
+	require.NoError(t, err)
+
+	// The conditions aren't evaluated until the resource is observed.
+	values, err := rt.EvaluateResourceConditions("vpc")
+	require.NoError(t, err)
+	assert.Nil(t, values)
+
+	// The condition follows the state of the resource.
+	for _, state := range []string{"pending", "available", "deleting"} {
+		vpc, _ := rt.GetResource("vpc")
+		observed := vpc.DeepCopy()
+		require.NoError(t, unstructured.SetNestedField(observed.Object, state, "status", "state"))
+		rt.SetResource("vpc", observed)
+
+		values, err := rt.EvaluateResourceConditions("vpc")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"VPCAvailable": state == "available"}, values, state)
+	}
+}
+
+func TestGraphBuilder_ResourceConditionsValidation(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name       string
+		conditions []v1alpha1.ResourceCondition
+		wantErr    string
+	}{
+		{
+			name: "lower case type",
+			conditions: []v1alpha1.ResourceCondition{
+				{Type: "available", Expression: `${vpc.status.state == "available"}`},
+			},
+			wantErr: "must be UpperCamelCase",
+		},
+		{
+			name: "duplicate type",
+			conditions: []v1alpha1.ResourceCondition{
+				{Type: "Available", Expression: `${vpc.status.state == "available"}`},
+				{Type: "Available", Expression: `${vpc.status.state == "pending"}`},
+			},
+			wantErr: "duplicate condition type Available",
+		},
+		{
+			name: "not a standalone expression",
+			conditions: []v1alpha1.ResourceCondition{
+				{Type: "Available", Expression: `state is ${vpc.status.state}`},
+			},
+			wantErr: "only standalone expressions are allowed",
+		},
+		{
+			name: "non boolean expression",
+			conditions: []v1alpha1.ResourceCondition{
+				{Type: "Available", Expression: `${vpc.status.state}`},
+			},
+			wantErr: "output of condition Available expression vpc.status.state can only be of type bool",
+		},
+		{
+			name: "reference to another resource",
+			conditions: []v1alpha1.ResourceCondition{
+				{Type: "Available", Expression: `${schema.spec.name == "test"}`},
+			},
+			wantErr: "undeclared reference to 'schema'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := builder.NewResourceGroup(newResourceConditionsResourceGroup(tt.conditions...))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGraphBuilder_Composition(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	// Without conditions, the status.resources field is only added when the
+	// composition is reported.
+	rg := newResourceConditionsResourceGroup()
+	g, err := builder.NewResourceGroup(rg)
+	require.NoError(t, err)
+	status := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	assert.NotContains(t, status.Properties, "resources")
+	assert.False(t, g.Composition)
+
+	rg.Spec.Schema.Composition = true
+	g, err = builder.NewResourceGroup(rg)
+	require.NoError(t, err)
+	assert.True(t, g.Composition)
+	status = g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	resources := status.Properties["resources"]
+	for _, field := range []string{"apiVersion", "kind", "name", "namespace", "state", "ready"} {
+		assert.Contains(t, resources.Items.Schema.Properties, field)
+	}
+}
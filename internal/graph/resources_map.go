@@ -0,0 +1,162 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/runtime"
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+// resourcesMapVariable is the name of the CEL variable exposing the created
+// resources of an instance by ID.
+const resourcesMapVariable = runtime.ResourcesMapVariable
+
+// newResourcesEnvironment returns a CEL environment declaring the given
+// resources and the resources map, along with the kro functions available to
+// the resource templates. The instanceHash and stableID functions are
+// available when the instance spec is declared.
+func newResourcesEnvironment(resourceNames []string) (*cel.Env, error) {
+	options := append(runtime.EnvironmentOptions(resourceNames),
+		krocel.WithResourcesMap(resourcesMapVariable),
+		krocel.WithSerializationFunctions(),
+	)
+	return krocel.DefaultEnvironment(options...)
+}
+
+// resourcesMapDependencies returns the IDs of the resources an expression
+// reads through the resources map. If the map is used as a whole, all the
+// given resource IDs except self are returned.
+func resourcesMapDependencies(env *cel.Env, expression string, self string, resourceIDs []string) ([]string, error) {
+	ast, iss := env.Parse(expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("failed to parse expression: %v", iss.Err())
+	}
+	parsed, err := cel.AstToParsedExpr(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression: %v", err)
+	}
+
+	refs := &resourcesMapReferences{}
+	refs.collect(parsed.GetExpr())
+
+	if refs.wholeMap {
+		dependencies := make([]string, 0, len(resourceIDs))
+		for _, id := range resourceIDs {
+			if id != self {
+				dependencies = append(dependencies, id)
+			}
+		}
+		return dependencies, nil
+	}
+
+	for _, id := range refs.ids {
+		if !slices.Contains(resourceIDs, id) {
+			return nil, fmt.Errorf("expression refers to unknown resource %q in the %s map", id, resourcesMapVariable)
+		}
+	}
+	return refs.ids, nil
+}
+
+// resourcesMapReferences collects the references made to the resources map
+// in a CEL expression.
+type resourcesMapReferences struct {
+	// ids are the resource IDs accessed with a constant key, in order of
+	// appearance.
+	ids []string
+	// wholeMap is true if the map is used in any other way (e.g iterated on
+	// or indexed with a dynamic key).
+	wholeMap bool
+}
+
+func (r *resourcesMapReferences) addID(id string) {
+	if !slices.Contains(r.ids, id) {
+		r.ids = append(r.ids, id)
+	}
+}
+
+func (r *resourcesMapReferences) collect(expr *exprpb.Expr) {
+	if expr == nil {
+		return
+	}
+	switch e := expr.ExprKind.(type) {
+	case *exprpb.Expr_IdentExpr:
+		if e.IdentExpr.Name == resourcesMapVariable {
+			r.wholeMap = true
+		}
+	case *exprpb.Expr_SelectExpr:
+		if isResourcesMapIdent(e.SelectExpr.Operand) {
+			r.addID(e.SelectExpr.Field)
+			return
+		}
+		r.collect(e.SelectExpr.Operand)
+	case *exprpb.Expr_CallExpr:
+		call := e.CallExpr
+		if call.Function == operators.Index && len(call.Args) == 2 && isResourcesMapIdent(call.Args[0]) {
+			if key, ok := call.Args[1].GetConstExpr().GetConstantKind().(*exprpb.Constant_StringValue); ok {
+				r.addID(key.StringValue)
+				return
+			}
+		}
+		r.collect(call.Target)
+		for _, arg := range call.Args {
+			r.collect(arg)
+		}
+	case *exprpb.Expr_ListExpr:
+		for _, elem := range e.ListExpr.Elements {
+			r.collect(elem)
+		}
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range e.StructExpr.Entries {
+			r.collect(entry.GetMapKey())
+			r.collect(entry.Value)
+		}
+	case *exprpb.Expr_ComprehensionExpr:
+		comprehension := e.ComprehensionExpr
+		r.collect(comprehension.IterRange)
+		r.collect(comprehension.AccuInit)
+		r.collect(comprehension.LoopCondition)
+		r.collect(comprehension.LoopStep)
+		r.collect(comprehension.Result)
+	}
+}
+
+// isResourcesMapIdent returns true if the expression is the resources map
+// identifier.
+func isResourcesMapIdent(expr *exprpb.Expr) bool {
+	return expr.GetIdentExpr().GetName() == resourcesMapVariable
+}
+
+// newResourcesMapContext returns a resource whose emulated object is a map of
+// the emulated objects of the given resources, keyed by resource ID. It is
+// used to dry-run the expressions referring to the resources map.
+func newResourcesMapContext(resources map[string]*Resource, exclude string) *Resource {
+	object := make(map[string]interface{}, len(resources))
+	for id, resource := range resources {
+		if id == exclude || resource.emulatedObject == nil {
+			continue
+		}
+		object[id] = resource.emulatedObject.Object
+	}
+	return &Resource{
+		emulatedObject: &unstructured.Unstructured{Object: object},
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// xKubernetesIntOrString marks the schemas accepting either an integer or a
+// string, e.g the container ports.
+const xKubernetesIntOrString = "x-kubernetes-int-or-string"
+
+// ValidateStructure checks that a value, made of maps, lists and native
+// types, has the structure described by an OpenAPI schema: the types of the
+// values and the required object properties. The other constraints (e.g the
+// formats, patterns or bounds) are left to the API server. The errors hold the
+// path of the offending value, relative to the given path.
+func ValidateStructure(schema *spec.Schema, value interface{}, path string) error {
+	if schema == nil || value == nil {
+		return nil
+	}
+	if enabled, ok := schema.Extensions[xKubernetesIntOrString].(bool); ok && enabled {
+		switch value.(type) {
+		case string, int64, int:
+			return nil
+		}
+		return fmt.Errorf("%s must be an integer or a string, got %s", path, typeName(value))
+	}
+
+	schemaType := ""
+	if len(schema.Type) > 0 {
+		schemaType = schema.Type[0]
+	}
+	switch schemaType {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object, got %s", path, typeName(value))
+		}
+		return validateObjectStructure(schema, object, path)
+	case "array":
+		list, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array, got %s", path, typeName(value))
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range list {
+			itemSchema := schema.Items.Schema
+			if len(schema.Items.Schemas) > i {
+				itemSchema = &schema.Items.Schemas[i]
+			}
+			if err := ValidateStructure(itemSchema, item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s must be a string, got %s", path, typeName(value))
+		}
+	case "integer":
+		switch value.(type) {
+		case int64, int, uint64:
+		default:
+			return fmt.Errorf("%s must be an integer, got %s", path, typeName(value))
+		}
+	case "number":
+		switch value.(type) {
+		case float64, int64, int, uint64:
+		default:
+			return fmt.Errorf("%s must be a number, got %s", path, typeName(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean, got %s", path, typeName(value))
+		}
+	default:
+		// Untyped schemas accept any value, but still describe the
+		// properties of the objects.
+		if object, ok := value.(map[string]interface{}); ok && len(schema.Properties) > 0 {
+			return validateObjectStructure(schema, object, path)
+		}
+	}
+	return nil
+}
+
+// validateObjectStructure checks the required properties of an object and the
+// structure of its values. The undeclared properties are left to the API
+// server, which prunes them or preserves them.
+func validateObjectStructure(schema *spec.Schema, object map[string]interface{}, path string) error {
+	for _, required := range schema.Required {
+		if _, ok := object[required]; !ok {
+			return fmt.Errorf("%s is missing the required field %s", path, required)
+		}
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var fieldSchema *spec.Schema
+		if property, ok := schema.Properties[key]; ok {
+			fieldSchema = &property
+		} else if schema.AdditionalProperties != nil {
+			fieldSchema = schema.AdditionalProperties.Schema
+		}
+		if err := ValidateStructure(fieldSchema, object[key], path+"."+key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeName returns the OpenAPI name of the type of a value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case int64, int, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
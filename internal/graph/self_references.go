@@ -0,0 +1,63 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"golang.org/x/exp/maps"
+)
+
+// validateSelfReferences makes sure the templates of the resources don't
+// refer to the resources themselves, e.g deployment.status.replicas in the
+// template of the deployment. The fields of a resource are only known once it
+// is applied, which its template is needed for. Its status can be checked in
+// its readyWhen expressions instead.
+//
+// The expressions that can't be inspected are skipped, they are reported by
+// the next validations.
+func validateSelfReferences(resources map[string]*Resource) error {
+	resourceIDs := maps.Keys(resources)
+	slices.Sort(resourceIDs)
+	resourceNames := append(slices.Clone(resourceIDs), "schema", featuresVariable)
+	env, err := newResourcesEnvironment(resourceNames)
+	if err != nil {
+		return fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	resourceNames = append(resourceNames, resourcesMapVariable)
+
+	for _, id := range resourceIDs {
+		for _, resourceVariable := range resources[id].variables {
+			for _, expression := range resourceVariable.Expressions {
+				dependencies, _, err := extractDependencies(env, expression, resourceNames)
+				if err != nil {
+					continue
+				}
+				mapDependencies, err := resourcesMapDependencies(env, expression, id, resourceIDs)
+				if err != nil {
+					continue
+				}
+				if slices.Contains(dependencies, id) || slices.Contains(mapDependencies, id) {
+					return fmt.Errorf(
+						"resource %s refers to itself in expression %q at path %s: "+
+							"a resource can't read its own fields in its template, check them in its readyWhen expressions instead",
+						id, expression, resourceVariable.Path,
+					)
+				}
+			}
+		}
+	}
+	return nil
+}
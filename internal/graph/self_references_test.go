@@ -0,0 +1,99 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestGraphBuilder_SelfReferences(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	newResourceGroup := func(vpcCIDR string, vpcReadyWhen []string) *v1alpha1.ResourceGroup {
+		return generator.NewResourceGroup("test-group",
+			generator.WithSchema("Network", "v1alpha1", map[string]interface{}{"name": "string"}, nil),
+			generator.WithResource("vpc", map[string]interface{}{
+				"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+				"kind":       "VPC",
+				"metadata": map[string]interface{}{
+					"name": "${schema.spec.name}",
+				},
+				"spec": map[string]interface{}{
+					"cidrBlocks": []interface{}{vpcCIDR},
+				},
+			}, vpcReadyWhen, nil),
+			generator.WithResource("subnet", map[string]interface{}{
+				"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+				"kind":       "Subnet",
+				"metadata": map[string]interface{}{
+					"name": "${schema.spec.name}-subnet",
+				},
+				"spec": map[string]interface{}{
+					"cidrBlock": "10.0.1.0/24",
+					"vpcID":     "${vpc.status.vpcID}",
+				},
+			}, nil, nil),
+		)
+	}
+
+	tests := []struct {
+		name    string
+		rg      *v1alpha1.ResourceGroup
+		wantErr string
+	}{
+		{
+			name: "spec referring to its own status",
+			rg:   newResourceGroup("${vpc.status.vpcID}", nil),
+			wantErr: `resource vpc refers to itself in expression "vpc.status.vpcID" at path spec.cidrBlocks[0]: ` +
+				"a resource can't read its own fields in its template",
+		},
+		{
+			name:    "self reference in a string template",
+			rg:      newResourceGroup("${vpc.metadata.name}/16", nil),
+			wantErr: `resource vpc refers to itself in expression "vpc.metadata.name"`,
+		},
+		{
+			name: "cross reference",
+			rg:   newResourceGroup("10.0.0.0/16", nil),
+		},
+		{
+			name: "own status read in readyWhen",
+			rg:   newResourceGroup("10.0.0.0/16", []string{"${vpc.status.state == 'available'}"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := builder.NewResourceGroup(tt.rg)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
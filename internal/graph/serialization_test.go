@@ -0,0 +1,111 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newSerializationResourceGroup(configMap map[string]interface{}) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"WebApp", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+				"port": "integer",
+			},
+			nil,
+		),
+		generator.WithResource("config", configMap, nil, nil),
+	)
+}
+
+func TestGraphBuilder_Serialization(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	g, err := builder.NewResourceGroup(newSerializationResourceGroup(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "${schema.spec.name}-config",
+		},
+		"data": map[string]interface{}{
+			"config.yaml": `${toYaml({"server": {"port": schema.spec.port, "host": "0.0.0.0"}, "name": schema.spec.name})}`,
+			"config.json": `${toJson({"server": {"port": schema.spec.port, "host": "0.0.0.0"}, "name": schema.spec.name})}`,
+		},
+	}))
+	require.NoError(t, err)
+
+	instance := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kro.run/v1alpha1",
+		"kind":       "WebApp",
+		"metadata":   map[string]interface{}{"name": "my-app", "namespace": "default"},
+		"spec":       map[string]interface{}{"name": "web", "port": int64(8080)},
+	}}
+	rt, err := g.NewGraphRuntime(instance)
+	require.NoError(t, err)
+
+	configMap, _ := rt.GetResource("config")
+	data, _, err := unstructured.NestedStringMap(configMap.Object, "data")
+	require.NoError(t, err)
+	assert.Equal(t, "name: web\nserver:\n  host: 0.0.0.0\n  port: 8080\n", data["config.yaml"])
+	assert.Equal(t, `{"name":"web","server":{"host":"0.0.0.0","port":8080}}`, data["config.json"])
+}
+
+func TestGraphBuilder_SerializationOutputType(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	_, err := builder.NewResourceGroup(newSerializationResourceGroup(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "${schema.spec.name}-config",
+		},
+		"immutable": `${toJson({"name": schema.spec.name})}`,
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid field immutable of resource config: the output of toJson is a string and can't be assigned to a field of type "boolean"`)
+
+	// Embedded in a larger string, the output is always a string.
+	_, err = builder.NewResourceGroup(newSerializationResourceGroup(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "${schema.spec.name}-config",
+		},
+		"data": map[string]interface{}{
+			"env": `CONFIG=${toJson({"name": schema.spec.name})}`,
+		},
+	}))
+	require.NoError(t, err)
+}
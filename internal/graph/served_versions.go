@@ -0,0 +1,89 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// servedResource returns the API resource of the given kind, checking through
+// discovery that the version of the kind is served by the API server. The
+// kinds of the CRDs serving several versions can be used at any of their
+// served versions, not only at the preferred one, the version being set
+// explicitly in the apiVersion of the resource template. An error is returned
+// if the kind is only served at other versions. The kinds that aren't served
+// at all aren't reported here, their schema can't be resolved either.
+func (b *Builder) servedResource(gvk k8sschema.GroupVersionKind) (*metav1.APIResource, error) {
+	if resource, err := b.findAPIResource(gvk); err != nil || resource != nil {
+		return resource, err
+	}
+
+	served, err := b.servedVersions(gvk.GroupKind())
+	if err != nil || len(served) == 0 {
+		return nil, err
+	}
+	return nil, fmt.Errorf("version %s of kind %s is not served by the API server, served versions are %s",
+		gvk.Version, gvk.GroupKind(), strings.Join(served, ", "))
+}
+
+// servedVersions returns the versions of the given kind served by the API
+// server.
+func (b *Builder) servedVersions(gk k8sschema.GroupKind) ([]string, error) {
+	groups, err := b.discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the API groups: %w", err)
+	}
+	var served []string
+	for _, group := range groups.Groups {
+		if group.Name != gk.Group {
+			continue
+		}
+		for _, version := range group.Versions {
+			resource, err := b.findAPIResource(gk.WithVersion(version.Version))
+			if err != nil {
+				return nil, err
+			}
+			if resource != nil {
+				served = append(served, version.Version)
+			}
+		}
+	}
+	return served, nil
+}
+
+// findAPIResource returns the API resource of the given kind, or nil if its
+// version isn't served.
+func (b *Builder) findAPIResource(gvk k8sschema.GroupVersionKind) (*metav1.APIResource, error) {
+	groupVersion := gvk.GroupVersion().String()
+	resources, err := b.discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the resources of %s: %w", groupVersion, err)
+	}
+	for i := range resources.APIResources {
+		resource := &resources.APIResources[i]
+		// Skip the subresources, e.g deployments/status.
+		if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+			return resource, nil
+		}
+	}
+	return nil, nil
+}
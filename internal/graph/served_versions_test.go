@@ -0,0 +1,85 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newVersionedVPCResourceGroup(apiVersion string) *v1alpha1.ResourceGroup {
+	return generator.NewResourceGroup("test-group",
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+	)
+}
+
+func TestGraphBuilder_ServedVersions(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	// The VPC CRD also serves a v1beta1 version, which isn't the preferred
+	// one.
+	v1alpha1VPC := k8sschema.GroupVersionKind{Group: "ec2.services.k8s.aws", Version: "v1alpha1", Kind: "VPC"}
+	vpcSchema, err := fakeResolver.ResolveSchema(v1alpha1VPC)
+	require.NoError(t, err)
+	fakeResolver.AddSchema(v1alpha1VPC.GroupKind().WithVersion("v1beta1"), vpcSchema)
+	fakeDiscovery.Resources = append(fakeDiscovery.Resources, &metav1.APIResourceList{
+		GroupVersion: "ec2.services.k8s.aws/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "vpcs", Namespaced: true, Kind: "VPC"},
+		},
+	})
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	t.Run("served version", func(t *testing.T) {
+		g, err := builder.NewResourceGroup(newVersionedVPCResourceGroup("ec2.services.k8s.aws/v1beta1"))
+		require.NoError(t, err)
+		vpc := g.Resources["vpc"]
+		assert.Equal(t, "v1beta1", vpc.GetGroupVersionResource().Version)
+		assert.True(t, vpc.IsNamespaced())
+	})
+
+	t.Run("unserved version", func(t *testing.T) {
+		_, err := builder.NewResourceGroup(newVersionedVPCResourceGroup("ec2.services.k8s.aws/v1"))
+		assert.ErrorContains(t, err,
+			"version v1 of kind VPC.ec2.services.k8s.aws is not served by the API server, served versions are v1alpha1, v1beta1")
+	})
+}
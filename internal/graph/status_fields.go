@@ -0,0 +1,63 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"maps"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+
+	"github.com/awslabs/kro/internal/runtime"
+)
+
+// validateStatusFieldAccess checks that the status expression only accesses
+// fields declared by the schemas of the resources it refers to, e.g to catch
+// a deployment.status.totalReplicas typo when the resource group is created.
+// The dry-run of the expression only catches the typos in the branches it
+// evaluates, and with a less helpful error.
+//
+// The fields of the elements iterated over by the macros are checked as in
+// validateElementFieldAccess. The fields under an open schema (e.g preserving
+// unknown fields) or of the resources whose schema is unknown are left to the
+// runtime.
+func validateStatusFieldAccess(env *cel.Env, expression string, resources map[string]*Resource) error {
+	parsed, iss := env.Parse(expression)
+	if iss.Err() != nil {
+		// Reported by the other validations.
+		return nil
+	}
+	schemas := map[string]*elementSchema{}
+	for id, resource := range resources {
+		if resource.schema != nil {
+			schemas[id] = &elementSchema{schema: withReadyField(resource.schema), path: id, owner: "resource " + id}
+		}
+	}
+	_, err := walkElementSchemas(parsed.NativeRep().Expr(), schemas)
+	return err
+}
+
+// withReadyField returns a copy of the schema of a resource declaring the
+// ready field synthesized on the resources exposed to the expressions.
+func withReadyField(schema *spec.Schema) *spec.Schema {
+	if isOpenSchema(schema) {
+		return schema
+	}
+	withReady := *schema
+	withReady.Properties = maps.Clone(schema.Properties)
+	withReady.Properties[runtime.ReadyField] = spec.Schema{
+		SchemaProps: spec.SchemaProps{Type: []string{"boolean"}},
+	}
+	return &withReady
+}
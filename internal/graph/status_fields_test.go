@@ -0,0 +1,153 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func TestValidateStatusFieldAccess(t *testing.T) {
+	stringSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}}
+	integerSchema := spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"integer"}}}
+	preserved := objectSchema(nil)
+	preserved.Extensions = spec.Extensions{xKubernetesPreserveUnknownFields: true}
+	deployment := objectSchema(map[string]spec.Schema{
+		"spec": objectSchema(map[string]spec.Schema{
+			"replicas": integerSchema,
+			"template": preserved,
+		}),
+		"status": objectSchema(map[string]spec.Schema{
+			"replicas":      integerSchema,
+			"readyReplicas": integerSchema,
+			"conditions": arraySchema(objectSchema(map[string]spec.Schema{
+				"type": stringSchema,
+			})),
+		}),
+	})
+	resources := map[string]*Resource{
+		"deployment": {id: "deployment", schema: &deployment},
+		"unknown":    {id: "unknown"},
+	}
+	env, err := newResourcesEnvironment([]string{"deployment", "unknown"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{
+			name:       "declared field",
+			expression: "deployment.status.readyReplicas",
+		},
+		{
+			name:       "undeclared field",
+			expression: "deployment.status.totalReplicas",
+			wantErr:    `deployment.status has no field "totalReplicas": it isn't declared by the schema of resource deployment`,
+		},
+		{
+			// The dry-run wouldn't evaluate the second branch.
+			name:       "undeclared field in a branch",
+			expression: "deployment.status.replicas > 0 ? deployment.status.readyReplicas : deployment.status.availableReplicas",
+			wantErr:    `deployment.status has no field "availableReplicas"`,
+		},
+		{
+			name:       "undeclared field tested for presence",
+			expression: "has(deployment.status.totalReplicas)",
+			wantErr:    `deployment.status has no field "totalReplicas"`,
+		},
+		{
+			name:       "undeclared element field",
+			expression: `deployment.status.conditions.exists(c, c.status == "True")`,
+			wantErr:    `c has no field "status": it isn't declared by the schema of the list elements`,
+		},
+		{
+			name:       "synthesized ready field",
+			expression: "deployment.ready",
+		},
+		{
+			name:       "field preserving unknown fields",
+			expression: "deployment.spec.template.spec.containers",
+		},
+		{
+			name:       "resource of unknown schema",
+			expression: "unknown.status.anything",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatusFieldAccess(env, tt.expression, resources)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGraphBuilder_StatusFieldAccess(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{
+			name:       "valid status reference",
+			expression: "${vpc.status.vpcID}",
+		},
+		{
+			name:       "typo in the status reference",
+			expression: "${vpc.status.vpcId}",
+			wantErr:    `invalid field status.vpcID: expression vpc.status.vpcId: vpc.status has no field "vpcId": it isn't declared by the schema of resource vpc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Test", "v1alpha1",
+					map[string]interface{}{"name": "string"},
+					map[string]interface{}{"vpcID": tt.expression},
+				),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata":   map[string]interface{}{"name": "vpc"},
+					"spec":       map[string]interface{}{"cidrBlocks": []interface{}{"10.0.0.0/16"}},
+				}, nil, nil),
+			)
+			_, err := builder.NewResourceGroup(rg)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
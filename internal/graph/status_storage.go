@@ -0,0 +1,79 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+// validateStatusStorage checks that the status storage of a resource group
+// targets unique, non sensitive, paths to fields of the instance status with
+// a known strategy.
+func validateStatusStorage(storage []v1alpha1.StatusFieldStorage, sensitiveFields []string, statusSchema *extv1.JSONSchemaProps) error {
+	fields := make([]string, 0, len(storage))
+	for _, s := range storage {
+		switch s.Strategy {
+		case v1alpha1.StatusFieldStorageCompress, v1alpha1.StatusFieldStorageConfigMap:
+		default:
+			return fmt.Errorf("storage strategy %q of field %s is invalid: must be %s or %s",
+				s.Strategy, s.Field, v1alpha1.StatusFieldStorageCompress, v1alpha1.StatusFieldStorageConfigMap)
+		}
+		if slices.Contains(sensitiveFields, s.Field) {
+			return fmt.Errorf("field %s is sensitive, its value is already stored in a Secret", s.Field)
+		}
+		fields = append(fields, s.Field)
+	}
+	return validateStatusFieldPaths("stored", fields, statusSchema)
+}
+
+// applyStatusStorage replaces the schemas of the stored status fields by the
+// schemas of what they hold instead of their value: a string for the
+// compressed values, and a reference for the values stored in a ConfigMap.
+func applyStatusStorage(statusSchema *extv1.JSONSchemaProps, storage []v1alpha1.StatusFieldStorage) {
+	for _, s := range storage {
+		segments := strings.Split(s.Field, ".")[1:]
+		current := statusSchema
+		for _, segment := range segments[:len(segments)-1] {
+			// The properties of the nested schemas are shared with their copy.
+			property := current.Properties[segment]
+			current = &property
+		}
+		current.Properties[segments[len(segments)-1]] = statusStorageSchema(s.Strategy)
+	}
+}
+
+// statusStorageSchema returns the schema of a status field stored with the
+// given strategy.
+func statusStorageSchema(strategy v1alpha1.StatusFieldStorageStrategy) extv1.JSONSchemaProps {
+	if strategy == v1alpha1.StatusFieldStorageConfigMap {
+		return extv1.JSONSchemaProps{
+			Type:        "object",
+			Description: "Reference to the value of the field, stored in a ConfigMap.",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"configMapName": {Type: "string"},
+				"key":           {Type: "string"},
+			},
+		}
+	}
+	return extv1.JSONSchemaProps{
+		Type:        "string",
+		Description: "The gzip compressed, base64 encoded, value of the field.",
+	}
+}
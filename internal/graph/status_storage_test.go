@@ -0,0 +1,110 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+func TestValidateStatusStorage(t *testing.T) {
+	statusSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"config": {Type: "string"},
+			"token":  {Type: "string"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		storage []v1alpha1.StatusFieldStorage
+		wantErr string
+	}{
+		{
+			name: "no storage",
+		},
+		{
+			name:    "valid storage",
+			storage: []v1alpha1.StatusFieldStorage{{Field: "status.config", Strategy: v1alpha1.StatusFieldStorageCompress}},
+		},
+		{
+			name:    "unknown strategy",
+			storage: []v1alpha1.StatusFieldStorage{{Field: "status.config", Strategy: "Drop"}},
+			wantErr: `storage strategy "Drop" of field status.config is invalid: must be Compress or ConfigMap`,
+		},
+		{
+			name:    "sensitive field",
+			storage: []v1alpha1.StatusFieldStorage{{Field: "status.token", Strategy: v1alpha1.StatusFieldStorageConfigMap}},
+			wantErr: "field status.token is sensitive, its value is already stored in a Secret",
+		},
+		{
+			name: "duplicate field",
+			storage: []v1alpha1.StatusFieldStorage{
+				{Field: "status.config", Strategy: v1alpha1.StatusFieldStorageCompress},
+				{Field: "status.config", Strategy: v1alpha1.StatusFieldStorageConfigMap},
+			},
+			wantErr: "duplicate stored field status.config",
+		},
+		{
+			name:    "unknown field",
+			storage: []v1alpha1.StatusFieldStorage{{Field: "status.manifest", Strategy: v1alpha1.StatusFieldStorageCompress}},
+			wantErr: "stored field status.manifest not found in the instance status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatusStorage(tt.storage, []string{"status.token"}, statusSchema)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestApplyStatusStorage(t *testing.T) {
+	preserve := true
+	statusSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"endpoint": {Type: "string"},
+			"rendered": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"config":   {Type: "string"},
+					"manifest": {Type: "object", XPreserveUnknownFields: &preserve},
+				},
+			},
+		},
+	}
+
+	applyStatusStorage(statusSchema, []v1alpha1.StatusFieldStorage{
+		{Field: "status.rendered.config", Strategy: v1alpha1.StatusFieldStorageCompress},
+		{Field: "status.rendered.manifest", Strategy: v1alpha1.StatusFieldStorageConfigMap},
+	})
+	rendered := statusSchema.Properties["rendered"].Properties
+	assert.Equal(t, extv1.JSONSchemaProps{Type: "string"}, statusSchema.Properties["endpoint"])
+	assert.Equal(t, "string", rendered["config"].Type)
+	assert.Equal(t, "object", rendered["manifest"].Type)
+	assert.Nil(t, rendered["manifest"].XPreserveUnknownFields)
+	assert.ElementsMatch(t, []string{"configMapName", "key"}, maps.Keys(rendered["manifest"].Properties))
+}
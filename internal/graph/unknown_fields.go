@@ -0,0 +1,39 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"fmt"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+// applyUnknownFieldsPolicy sets how the instance spec schema treats the fields
+// it doesn't declare. With the Preserve policy the spec is marked with
+// x-kubernetes-preserve-unknown-fields, otherwise the API server prunes them.
+func applyUnknownFieldsPolicy(specSchema *extv1.JSONSchemaProps, policy v1alpha1.UnknownFieldsPolicy) error {
+	switch policy {
+	case "", v1alpha1.UnknownFieldsPolicyStrict:
+		specSchema.XPreserveUnknownFields = nil
+	case v1alpha1.UnknownFieldsPolicyPreserve:
+		preserve := true
+		specSchema.XPreserveUnknownFields = &preserve
+	default:
+		return fmt.Errorf("unknown fields policy %q is invalid: must be %s or %s",
+			policy, v1alpha1.UnknownFieldsPolicyStrict, v1alpha1.UnknownFieldsPolicyPreserve)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+// pruneInstance prunes the instance as the API server does with the schema of
+// the given CRD.
+func pruneInstance(t *testing.T, crd *extv1.CustomResourceDefinition, instance map[string]interface{}) {
+	t.Helper()
+	internal := &apiextensions.JSONSchemaProps{}
+	require.NoError(t, extv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(
+		crd.Spec.Versions[0].Schema.OpenAPIV3Schema, internal, nil))
+	structural, err := structuralschema.NewStructural(internal)
+	require.NoError(t, err)
+	pruning.Prune(instance, structural, true)
+}
+
+func TestGraphBuilder_UnknownFields(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	tests := []struct {
+		name         string
+		policy       v1alpha1.UnknownFieldsPolicy
+		wantPreserve bool
+		wantErr      string
+	}{
+		{name: "strict by default"},
+		{name: "strict", policy: v1alpha1.UnknownFieldsPolicyStrict},
+		{name: "preserve", policy: v1alpha1.UnknownFieldsPolicyPreserve, wantPreserve: true},
+		{name: "invalid policy", policy: "Lenient", wantErr: `unknown fields policy "Lenient" is invalid`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rg := generator.NewResourceGroup("test-group",
+				generator.WithSchema("Network", "v1alpha1", map[string]interface{}{"name": "string"}, nil),
+				generator.WithUnknownFields(tt.policy),
+				generator.WithResource("vpc", map[string]interface{}{
+					"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+					"kind":       "VPC",
+					"metadata": map[string]interface{}{
+						"name": "${schema.spec.name}",
+					},
+					"spec": map[string]interface{}{
+						"cidrBlocks": []interface{}{"10.0.0.0/16"},
+					},
+				}, nil, nil),
+			)
+			g, err := builder.NewResourceGroup(rg)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			spec := g.Instance.crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"]
+			if tt.wantPreserve {
+				require.NotNil(t, spec.XPreserveUnknownFields)
+				assert.True(t, *spec.XPreserveUnknownFields)
+			} else {
+				assert.Nil(t, spec.XPreserveUnknownFields)
+			}
+
+			instance := map[string]interface{}{
+				"apiVersion": "kro.run/v1alpha1",
+				"kind":       "Network",
+				"metadata":   map[string]interface{}{"name": "network"},
+				"spec": map[string]interface{}{
+					"name":        "network",
+					"passThrough": map[string]interface{}{"tier": "gold"},
+				},
+			}
+			pruneInstance(t, g.Instance.crd, instance)
+			prunedSpec := instance["spec"].(map[string]interface{})
+			assert.Equal(t, "network", prunedSpec["name"])
+			if tt.wantPreserve {
+				assert.Equal(t, map[string]interface{}{"tier": "gold"}, prunedSpec["passThrough"])
+			} else {
+				assert.NotContains(t, prunedSpec, "passThrough", "strict mode must prune the unknown fields")
+			}
+		})
+	}
+}
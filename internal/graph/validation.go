@@ -15,9 +15,21 @@ package graph
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/awslabs/kro/api/v1alpha1"
 )
@@ -32,8 +44,9 @@ var (
 	lowerCamelCaseRegex = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
 	// UpperCamelCaseRegex
 	upperCamelCaseRegex = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
-	// kubernetesVersionRegex
-	kubernetesVersionRegex = regexp.MustCompile(`^v\d+(?:(?:alpha|beta)\d+)?$`)
+	// kubernetesVersionRegex matches the Kubernetes versions, capturing their
+	// major version, stability level and level version, e.g v1beta2.
+	kubernetesVersionRegex = regexp.MustCompile(`^v(\d+)(?:(alpha|beta)(\d+))?$`)
 
 	// reservedKeyWords is a list of reserved words in kro.
 	reservedKeyWords = []string{
@@ -45,6 +58,7 @@ var (
 		"externalReference",
 		"externalRefs",
 		"externalReferences",
+		"features",
 		"graph",
 		"instance",
 		"kind",
@@ -180,3 +194,370 @@ func validateKubernetesVersion(version string) error {
 	}
 	return nil
 }
+
+// kubernetesVersionStability orders the stability levels of the Kubernetes
+// versions, from the least to the most stable.
+var kubernetesVersionStability = map[string]int{
+	"alpha": 0,
+	"beta":  1,
+	"":      2,
+}
+
+// compareKubernetesVersions compares two valid Kubernetes versions by
+// precedence. It returns a negative number if a has a higher precedence than
+// b, a positive number if b has a higher precedence, and 0 if they are equal.
+// GA versions come first, then beta and alpha versions, and higher version
+// numbers come first within a stability level, e.g v2, v1, v2beta1, v1beta2,
+// v1beta1, v1alpha1.
+func compareKubernetesVersions(a, b string) int {
+	am, bm := kubernetesVersionRegex.FindStringSubmatch(a), kubernetesVersionRegex.FindStringSubmatch(b)
+	if c := kubernetesVersionStability[bm[2]] - kubernetesVersionStability[am[2]]; c != 0 {
+		return c
+	}
+	if c := versionNumber(bm[1]) - versionNumber(am[1]); c != 0 {
+		return c
+	}
+	return versionNumber(bm[3]) - versionNumber(am[3])
+}
+
+// versionNumber parses a number captured by kubernetesVersionRegex, 0 if empty.
+func versionNumber(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// normalizeVersions validates the versions of a CRD, and sorts them by
+// decreasing Kubernetes version precedence, for the API server to behave
+// predictably, e.g when picking the preferred version. The versions must be
+// unique valid Kubernetes versions, and exactly one of them must be the
+// storage version.
+func normalizeVersions(versions []extv1.CustomResourceDefinitionVersion) error {
+	seen := make(map[string]struct{}, len(versions))
+	var storage []string
+	for _, version := range versions {
+		if err := validateKubernetesVersion(version.Name); err != nil {
+			return err
+		}
+		if _, ok := seen[version.Name]; ok {
+			return fmt.Errorf("duplicate version %s", version.Name)
+		}
+		seen[version.Name] = struct{}{}
+		if version.Storage {
+			storage = append(storage, version.Name)
+		}
+	}
+	switch {
+	case len(storage) == 0:
+		return fmt.Errorf("no storage version, exactly one version must be the storage version")
+	case len(storage) > 1:
+		return fmt.Errorf("multiple storage versions %s, exactly one version must be the storage version", strings.Join(storage, ", "))
+	}
+
+	slices.SortStableFunc(versions, func(a, b extv1.CustomResourceDefinitionVersion) int {
+		return compareKubernetesVersions(a.Name, b.Name)
+	})
+	return nil
+}
+
+// validateInstanceSchema checks that the instance schema declared by a
+// resource group has the shape of a Kubernetes object kro can work with,
+// before the CRD of the instances is synthesized from it:
+// - it declares a spec, which is an object with at least one field
+// - if it declares a status, it is an object. status is allowed to be
+// absent, kro will inject the default status fields.
+func validateInstanceSchema(rgSchema *v1alpha1.Schema) error {
+	if rgSchema == nil {
+		return fmt.Errorf("instance schema is nil")
+	}
+
+	spec, err := decodeSchemaField(rgSchema.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to decode instance schema spec: %w", err)
+	}
+	if spec == nil {
+		return fmt.Errorf("instance schema must have a spec property")
+	}
+	fields, ok := spec.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("instance schema spec must be of type object, got %q", jsonType(spec))
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("instance schema spec must declare at least one field")
+	}
+
+	status, err := decodeSchemaField(rgSchema.Status)
+	if err != nil {
+		return fmt.Errorf("failed to decode instance schema status: %w", err)
+	}
+	if _, ok := status.(map[string]interface{}); status != nil && !ok {
+		return fmt.Errorf("instance schema status must be of type object, got %q", jsonType(status))
+	}
+	return nil
+}
+
+// decodeSchemaField decodes a field of the instance schema. It returns nil if
+// the field is absent or null.
+func decodeSchemaField(field runtime.RawExtension) (interface{}, error) {
+	if len(field.Raw) == 0 {
+		return nil, nil
+	}
+	var value interface{}
+	if err := yaml.Unmarshal(field.Raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// jsonType returns the JSON type of a decoded JSON value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case int64, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// validateIdentityFields checks that the identity fields of a resource group
+// are unique paths to fields of the instance spec, e.g spec.name.
+func validateIdentityFields(identityFields []string, instanceSchema *extv1.JSONSchemaProps) error {
+	seen := make(map[string]struct{}, len(identityFields))
+	for _, field := range identityFields {
+		if _, ok := seen[field]; ok {
+			return fmt.Errorf("duplicate identity field %s", field)
+		}
+		seen[field] = struct{}{}
+
+		segments := strings.Split(field, ".")
+		if len(segments) < 2 || segments[0] != "spec" {
+			return fmt.Errorf("identity field %s must be a path to a field of the instance spec (e.g spec.name)", field)
+		}
+
+		current := instanceSchema
+		for _, segment := range segments {
+			property, ok := current.Properties[segment]
+			if segment == "" || !ok {
+				return fmt.Errorf("identity field %s not found in the instance schema", field)
+			}
+			current = &property
+		}
+	}
+	return nil
+}
+
+// validateSensitiveFields checks that the sensitive fields of a resource group
+// are unique paths to fields of the instance status, e.g status.token.
+func validateSensitiveFields(sensitiveFields []string, statusSchema *extv1.JSONSchemaProps) error {
+	return validateStatusFieldPaths("sensitive", sensitiveFields, statusSchema)
+}
+
+// validateRequiredStatusFields checks that the required status fields of a
+// resource group are unique paths to fields of the instance status, e.g
+// status.endpoint.
+func validateRequiredStatusFields(requiredFields []string, statusSchema *extv1.JSONSchemaProps) error {
+	return validateStatusFieldPaths("required", requiredFields, statusSchema)
+}
+
+// validateStatusFieldPaths checks that the given fields are unique paths to
+// fields of the instance status. kind qualifies the fields in the errors.
+func validateStatusFieldPaths(kind string, fields []string, statusSchema *extv1.JSONSchemaProps) error {
+	seen := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if _, ok := seen[field]; ok {
+			return fmt.Errorf("duplicate %s field %s", kind, field)
+		}
+		seen[field] = struct{}{}
+
+		segments := strings.Split(field, ".")
+		if len(segments) < 2 || segments[0] != "status" {
+			return fmt.Errorf("%s field %s must be a path to a field of the instance status (e.g status.token)", kind, field)
+		}
+
+		current := statusSchema
+		for _, segment := range segments[1:] {
+			property, ok := current.Properties[segment]
+			if segment == "" || !ok {
+				return fmt.Errorf("%s field %s not found in the instance status", kind, field)
+			}
+			current = &property
+		}
+	}
+	return nil
+}
+
+// validateResourceConditions checks that the condition types of a resource are
+// unique and in UpperCamelCase (e.g DatabaseProvisioned).
+func validateResourceConditions(conditions []v1alpha1.ResourceCondition) error {
+	seen := make(map[string]struct{}, len(conditions))
+	for _, condition := range conditions {
+		if !upperCamelCaseRegex.MatchString(condition.Type) {
+			return fmt.Errorf("condition type %q must be UpperCamelCase", condition.Type)
+		}
+		if _, ok := seen[condition.Type]; ok {
+			return fmt.Errorf("duplicate condition type %s", condition.Type)
+		}
+		seen[condition.Type] = struct{}{}
+	}
+	return nil
+}
+
+// validateFinalizers checks that the finalizers of a resource are unique
+// qualified names, e.g example.com/protection.
+func validateFinalizers(finalizers []string) error {
+	seen := make(map[string]struct{}, len(finalizers))
+	for _, finalizer := range finalizers {
+		if errs := validation.IsQualifiedName(finalizer); len(errs) > 0 {
+			return fmt.Errorf("finalizer %q is invalid: %s", finalizer, strings.Join(errs, "; "))
+		}
+		if _, ok := seen[finalizer]; ok {
+			return fmt.Errorf("duplicate finalizer %s", finalizer)
+		}
+		seen[finalizer] = struct{}{}
+	}
+	return nil
+}
+
+// validateWaitFor checks that the resources a resource waits for are unique
+// resources of the resource group, other than itself.
+func validateWaitFor(id string, waitFor []string, resources map[string]*Resource) error {
+	seen := make(map[string]struct{}, len(waitFor))
+	for _, dependency := range waitFor {
+		if _, ok := resources[dependency]; !ok {
+			return fmt.Errorf("resource %s waits for unknown resource %s", id, dependency)
+		}
+		if dependency == id {
+			return fmt.Errorf("resource %s can't wait for itself", id)
+		}
+		if _, ok := seen[dependency]; ok {
+			return fmt.Errorf("resource %s waits for resource %s more than once", id, dependency)
+		}
+		seen[dependency] = struct{}{}
+	}
+	return nil
+}
+
+// builtinKinds maps the lowercased built-in Kubernetes kinds to their name and
+// the API groups serving them. It is lazily computed from the client-go scheme.
+var builtinKinds = sync.OnceValue(func() map[string]builtinKind {
+	kinds := make(map[string]builtinKind)
+	for gvk, t := range scheme.Scheme.AllKnownTypes() {
+		// Skip the list kinds and the option kinds (e.g ListOptions) that are
+		// registered in every group version.
+		if strings.HasSuffix(gvk.Kind, "List") || t.PkgPath() == metaV1PkgPath {
+			continue
+		}
+		key := strings.ToLower(gvk.Kind)
+		kind, ok := kinds[key]
+		if !ok {
+			kind = builtinKind{name: gvk.Kind, groups: map[string]struct{}{}}
+		}
+		kind.groups[gvk.Group] = struct{}{}
+		kinds[key] = kind
+	}
+	return kinds
+})
+
+// metaV1PkgPath is the package path of the meta/v1 types.
+var metaV1PkgPath = reflect.TypeOf(metav1.ListOptions{}).PkgPath()
+
+// builtinKind is a kind served by the built-in Kubernetes API groups.
+type builtinKind struct {
+	name   string
+	groups map[string]struct{}
+}
+
+// validateBuiltinKindShadowing checks that the given instance kind doesn't
+// collide, case insensitively, with a kind of the built-in Kubernetes API groups
+// (e.g Pod in the core group or Deployment in the apps group).
+func validateBuiltinKindShadowing(kind string) error {
+	builtin, ok := builtinKinds()[strings.ToLower(kind)]
+	if !ok {
+		return nil
+	}
+
+	groups := make([]string, 0, len(builtin.groups))
+	for group := range builtin.groups {
+		if group == "" {
+			group = "core"
+		}
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return fmt.Errorf("kind %s shadows the built-in kind %s of the %s API group(s)",
+		kind, builtin.name, strings.Join(groups, ", "))
+}
+
+// kroComputedStatusFields are the instance status fields computed by kro
+// itself, which can't be set by the status expressions of a resource group.
+var kroComputedStatusFields = []string{
+	"conditions", "state", "resources", "lastReconcileError", "reconcileSummary", "pendingDeletions", "stableID",
+	"evaluatedOnce",
+}
+
+// validateStatusFields checks that the instance status only holds fields
+// computed by kro, keeping it separate from the user provided spec:
+// - every status field is computed from a CEL expression. Literal values and
+// type markers (e.g "string") belong to the spec.
+// - no status field is reserved for the fields kro computes itself.
+// - no status field is declared at the same path as a spec field.
+//
+// Status expressions can't refer to the instance spec, hence they can't copy
+// user input into the status either.
+func validateStatusFields(status map[string]interface{}, specSchema *extv1.JSONSchemaProps) error {
+	var violations []string
+	for field := range status {
+		if slices.Contains(kroComputedStatusFields, field) {
+			violations = append(violations, fmt.Sprintf("status.%s is computed by kro", field))
+		}
+	}
+	violations = append(violations, statusFieldViolations(status, "status", specSchema)...)
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.Strings(violations)
+	return fmt.Errorf("status fields must only be computed by kro: %s", strings.Join(violations, "; "))
+}
+
+// statusFieldViolations walks the given status value, reporting the fields not
+// computed from a CEL expression and the ones overlapping the spec field at
+// the same path, if any.
+func statusFieldViolations(value interface{}, path string, specSchema *extv1.JSONSchemaProps) []string {
+	var violations []string
+	switch field := value.(type) {
+	case map[string]interface{}:
+		for name, fieldValue := range field {
+			var fieldSpecSchema *extv1.JSONSchemaProps
+			if specSchema != nil {
+				if property, ok := specSchema.Properties[name]; ok {
+					fieldSpecSchema = &property
+				}
+			}
+			violations = append(violations, statusFieldViolations(fieldValue, path+"."+name, fieldSpecSchema)...)
+		}
+	case []interface{}:
+		var itemSpecSchema *extv1.JSONSchemaProps
+		if specSchema != nil && specSchema.Items != nil {
+			itemSpecSchema = specSchema.Items.Schema
+		}
+		for i, item := range field {
+			violations = append(violations, statusFieldViolations(item, fmt.Sprintf("%s[%d]", path, i), itemSpecSchema)...)
+		}
+	case string:
+		if !strings.Contains(field, "${") {
+			violations = append(violations, fmt.Sprintf("%s is not a CEL expression", path))
+		} else if specSchema != nil {
+			violations = append(violations, fmt.Sprintf("%s overlaps spec.%s", path, strings.TrimPrefix(path, "status.")))
+		}
+	default:
+		violations = append(violations, fmt.Sprintf("%s is not a CEL expression", path))
+	}
+	return violations
+}
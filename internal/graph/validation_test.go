@@ -14,8 +14,13 @@
 package graph
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
 	"github.com/awslabs/kro/api/v1alpha1"
 )
 
@@ -250,3 +255,547 @@ func TestValidateKubernetesVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeVersions(t *testing.T) {
+	versions := func(names ...string) []extv1.CustomResourceDefinitionVersion {
+		versions := make([]extv1.CustomResourceDefinitionVersion, 0, len(names))
+		for i, name := range names {
+			// The first version is the storage version.
+			versions = append(versions, extv1.CustomResourceDefinitionVersion{Name: name, Served: true, Storage: i == 0})
+		}
+		return versions
+	}
+
+	tests := []struct {
+		name     string
+		versions []extv1.CustomResourceDefinitionVersion
+		want     []string
+		errMsg   string
+	}{
+		{
+			name:     "Single version",
+			versions: versions("v1alpha1"),
+			want:     []string{"v1alpha1"},
+		},
+		{
+			name:     "Correctly ordered versions",
+			versions: versions("v10", "v2", "v1", "v11beta2", "v10beta3", "v3beta1", "v12alpha1", "v11alpha2"),
+			want:     []string{"v10", "v2", "v1", "v11beta2", "v10beta3", "v3beta1", "v12alpha1", "v11alpha2"},
+		},
+		{
+			name:     "Mis-ordered versions",
+			versions: versions("v1alpha1", "v1beta1", "v2", "v1alpha2", "v1", "v1beta2"),
+			want:     []string{"v2", "v1", "v1beta2", "v1beta1", "v1alpha2", "v1alpha1"},
+		},
+		{
+			name: "Multiple storage versions",
+			versions: []extv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Storage: true},
+				{Name: "v1", Storage: true},
+			},
+			errMsg: "multiple storage versions v1alpha1, v1, exactly one version must be the storage version",
+		},
+		{
+			name: "No storage version",
+			versions: []extv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1"},
+			},
+			errMsg: "no storage version, exactly one version must be the storage version",
+		},
+		{
+			name:     "Duplicate version",
+			versions: versions("v1", "v1"),
+			errMsg:   "duplicate version v1",
+		},
+		{
+			name:     "Invalid version",
+			versions: versions("v1", "v1.1"),
+			errMsg:   "version v1.1 is not a valid Kubernetes version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeVersions(tt.versions)
+			if tt.errMsg != "" {
+				if err == nil || err.Error() != tt.errMsg {
+					t.Errorf("normalizeVersions() error = %v, want %v", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeVersions() unexpected error = %v", err)
+			}
+			got := make([]string, 0, len(tt.versions))
+			for _, version := range tt.versions {
+				got = append(got, version.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeVersions() order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInstanceSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  *v1alpha1.Schema
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "Valid schema with spec and status",
+			schema:  newSchema(`{"name": "string"}`, `{"ip": "${vpc.status.ip}"}`),
+			wantErr: false,
+		},
+		{
+			name:    "Valid schema without status",
+			schema:  newSchema(`{"name": "string"}`, ``),
+			wantErr: false,
+		},
+		{
+			name:    "Missing spec",
+			schema:  newSchema(``, `{"ip": "${vpc.status.ip}"}`),
+			wantErr: true,
+			errMsg:  "instance schema must have a spec property",
+		},
+		{
+			name:    "Null spec",
+			schema:  newSchema(`null`, ``),
+			wantErr: true,
+			errMsg:  "instance schema must have a spec property",
+		},
+		{
+			name:    "Empty spec",
+			schema:  newSchema(`{}`, ``),
+			wantErr: true,
+			errMsg:  "instance schema spec must declare at least one field",
+		},
+		{
+			name:    "Non-object spec",
+			schema:  newSchema(`["string"]`, ``),
+			wantErr: true,
+			errMsg:  `instance schema spec must be of type object, got "array"`,
+		},
+		{
+			name:    "Non-object status",
+			schema:  newSchema(`{"name": "string"}`, `42`),
+			wantErr: true,
+			errMsg:  `instance schema status must be of type object, got "number"`,
+		},
+		{
+			name:    "Nil schema",
+			schema:  nil,
+			wantErr: true,
+			errMsg:  "instance schema is nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInstanceSchema(tt.schema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateInstanceSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateInstanceSchema() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+// newSchema returns an instance schema declaring the given raw spec and
+// status.
+func newSchema(spec, status string) *v1alpha1.Schema {
+	return &v1alpha1.Schema{
+		Kind:       "Test",
+		APIVersion: "v1alpha1",
+		Spec:       runtime.RawExtension{Raw: []byte(spec)},
+		Status:     runtime.RawExtension{Raw: []byte(status)},
+	}
+}
+
+func TestValidateIdentityFields(t *testing.T) {
+	instanceSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"spec": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"name": {Type: "string"},
+					"database": {
+						Type: "object",
+						Properties: map[string]extv1.JSONSchemaProps{
+							"name": {Type: "string"},
+						},
+					},
+				},
+			},
+			"status": {Type: "object"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		identityFields []string
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:           "No identity fields",
+			identityFields: nil,
+			wantErr:        false,
+		},
+		{
+			name:           "Valid identity fields",
+			identityFields: []string{"spec.name", "spec.database.name"},
+			wantErr:        false,
+		},
+		{
+			name:           "Duplicate identity field",
+			identityFields: []string{"spec.name", "spec.name"},
+			wantErr:        true,
+			errMsg:         "duplicate identity field spec.name",
+		},
+		{
+			name:           "Field outside of the spec",
+			identityFields: []string{"status.name"},
+			wantErr:        true,
+			errMsg:         "identity field status.name must be a path to a field of the instance spec (e.g spec.name)",
+		},
+		{
+			name:           "Whole spec",
+			identityFields: []string{"spec"},
+			wantErr:        true,
+			errMsg:         "identity field spec must be a path to a field of the instance spec (e.g spec.name)",
+		},
+		{
+			name:           "Unknown field",
+			identityFields: []string{"spec.database.host"},
+			wantErr:        true,
+			errMsg:         "identity field spec.database.host not found in the instance schema",
+		},
+		{
+			name:           "Empty segment",
+			identityFields: []string{"spec..name"},
+			wantErr:        true,
+			errMsg:         "identity field spec..name not found in the instance schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIdentityFields(tt.identityFields, instanceSchema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateIdentityFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateIdentityFields() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSensitiveFields(t *testing.T) {
+	statusSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"token": {Type: "string"},
+			"database": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"password": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		sensitiveFields []string
+		wantErr         bool
+		errMsg          string
+	}{
+		{
+			name:            "No sensitive fields",
+			sensitiveFields: nil,
+			wantErr:         false,
+		},
+		{
+			name:            "Valid sensitive fields",
+			sensitiveFields: []string{"status.token", "status.database.password"},
+			wantErr:         false,
+		},
+		{
+			name:            "Duplicate sensitive field",
+			sensitiveFields: []string{"status.token", "status.token"},
+			wantErr:         true,
+			errMsg:          "duplicate sensitive field status.token",
+		},
+		{
+			name:            "Field outside of the status",
+			sensitiveFields: []string{"spec.token"},
+			wantErr:         true,
+			errMsg:          "sensitive field spec.token must be a path to a field of the instance status (e.g status.token)",
+		},
+		{
+			name:            "Unknown field",
+			sensitiveFields: []string{"status.database.user"},
+			wantErr:         true,
+			errMsg:          "sensitive field status.database.user not found in the instance status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSensitiveFields(tt.sensitiveFields, statusSchema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSensitiveFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateSensitiveFields() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateRequiredStatusFields(t *testing.T) {
+	statusSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"endpoint": {Type: "string"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		requiredFields []string
+		wantErr        bool
+		errMsg         string
+	}{
+		{
+			name:           "Valid required fields",
+			requiredFields: []string{"status.endpoint"},
+			wantErr:        false,
+		},
+		{
+			name:           "Duplicate required field",
+			requiredFields: []string{"status.endpoint", "status.endpoint"},
+			wantErr:        true,
+			errMsg:         "duplicate required field status.endpoint",
+		},
+		{
+			name:           "Unknown field",
+			requiredFields: []string{"status.address"},
+			wantErr:        true,
+			errMsg:         "required field status.address not found in the instance status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRequiredStatusFields(tt.requiredFields, statusSchema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRequiredStatusFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateRequiredStatusFields() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateStatusFields(t *testing.T) {
+	specSchema := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"name":     {Type: "string"},
+			"replicas": {Type: "integer"},
+			"database": {
+				Type: "object",
+				Properties: map[string]extv1.JSONSchemaProps{
+					"engine": {Type: "string"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		status  map[string]interface{}
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "No status fields",
+			status:  map[string]interface{}{},
+			wantErr: false,
+		},
+		{
+			name: "Status separated from the spec",
+			status: map[string]interface{}{
+				"availableReplicas": "${deployment.status.availableReplicas}",
+				"endpoint":          "https://${service.status.loadBalancer.ingress[0].hostname}",
+				"database": map[string]interface{}{
+					"address": "${database.status.address}",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Status field overlapping a spec field",
+			status: map[string]interface{}{
+				"replicas": "${deployment.status.replicas}",
+				"database": map[string]interface{}{
+					"engine": "${database.spec.engine}",
+				},
+			},
+			wantErr: true,
+			errMsg:  "status fields must only be computed by kro: status.database.engine overlaps spec.database.engine; status.replicas overlaps spec.replicas",
+		},
+		{
+			name: "Status field computed by kro",
+			status: map[string]interface{}{
+				"state": "${deployment.status.phase}",
+			},
+			wantErr: true,
+			errMsg:  "status fields must only be computed by kro: status.state is computed by kro",
+		},
+		{
+			name: "Status stableID computed by kro",
+			status: map[string]interface{}{
+				"stableID": "${deployment.metadata.uid}",
+			},
+			wantErr: true,
+			errMsg:  "status fields must only be computed by kro: status.stableID is computed by kro",
+		},
+		{
+			name: "Status evaluatedOnce computed by kro",
+			status: map[string]interface{}{
+				"evaluatedOnce": map[string]interface{}{
+					"createdAt": "${deployment.metadata.creationTimestamp}",
+				},
+			},
+			wantErr: true,
+			errMsg:  "status fields must only be computed by kro: status.evaluatedOnce is computed by kro",
+		},
+		{
+			name: "Status fields declared like spec fields",
+			status: map[string]interface{}{
+				"address": "string",
+				"ports":   []interface{}{int64(80)},
+			},
+			wantErr: true,
+			errMsg:  "status fields must only be computed by kro: status.address is not a CEL expression; status.ports[0] is not a CEL expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStatusFields(tt.status, specSchema)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateStatusFields() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateStatusFields() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateBuiltinKindShadowing(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "Safe kind",
+			kind:    "WebApplication",
+			wantErr: false,
+		},
+		{
+			name:    "Core kind",
+			kind:    "Pod",
+			wantErr: true,
+			errMsg:  "kind Pod shadows the built-in kind Pod of the core API group(s)",
+		},
+		{
+			name:    "Apps kind with a different case",
+			kind:    "DeployMent",
+			wantErr: true,
+			errMsg:  "kind DeployMent shadows the built-in kind Deployment of the apps, extensions API group(s)",
+		},
+		{
+			name:    "Batch kind",
+			kind:    "CronJob",
+			wantErr: true,
+			errMsg:  "kind CronJob shadows the built-in kind CronJob of the batch API group(s)",
+		},
+		{
+			name:    "Option kinds are not built-in kinds",
+			kind:    "ListOptions",
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBuiltinKindShadowing(tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBuiltinKindShadowing() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("validateBuiltinKindShadowing() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateFinalizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		finalizers []string
+		wantErr    bool
+		errMsg     string
+	}{
+		{
+			name:       "Domain prefixed finalizers",
+			finalizers: []string{"example.com/protection", "kubernetes.io/pvc-protection"},
+			wantErr:    false,
+		},
+		{
+			name:       "Invalid finalizer",
+			finalizers: []string{"example.com/not valid"},
+			wantErr:    true,
+			errMsg:     `finalizer "example.com/not valid" is invalid`,
+		},
+		{
+			name:       "Duplicate finalizer",
+			finalizers: []string{"example.com/protection", "example.com/protection"},
+			wantErr:    true,
+			errMsg:     "duplicate finalizer example.com/protection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFinalizers(tt.finalizers)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFinalizers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateFinalizers() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@
 package variable
 
 import (
+	"regexp"
 	"slices"
 
 	"k8s.io/kube-openapi/pkg/validation/spec"
@@ -31,12 +32,26 @@ type FieldDescriptor struct {
 	Path string
 	// Expressions is a list of CEL expressions in the field.
 	Expressions []string
+	// ExpressionOffsets are the byte offsets of the expressions in the field
+	// value, e.g 9 for "${schema.spec.name}" in "prefix-${schema.spec.name}".
+	// They are used to report the errors of the expressions at their position
+	// in the field value.
+	ExpressionOffsets []int
 	// ExpectedType is the expected type of the field.
 	ExpectedType string
 	// ExpectedSchema is the expected schema of the field if it is a complex type.
 	// This is only set if the field is a OneShotCEL expression, and the schema
 	// is expected to be a complex type (object or array).
 	ExpectedSchema *spec.Schema
+	// ExpectedFormat is the OpenAPI format of the field, if any. e.g "byte"
+	// for the base64 encoded fields like the Secret data values.
+	ExpectedFormat string
+	// ExpectedPattern is the compiled OpenAPI pattern the string value of the
+	// field must match, if any.
+	ExpectedPattern *regexp.Regexp
+	// EncodeBytes is true if the values of a "byte" formatted field are
+	// plaintext to be base64 encoded, rather than already encoded values.
+	EncodeBytes bool
 	// StandaloneExpression is true if the field contains a single CEL expression
 	// that is not part of a larger string. example: "${foo}" is a standalone expression
 	// but not "hello-${foo}" or "${foo}${bar}"
@@ -81,6 +96,32 @@ func (rv *ResourceField) AddDependencies(dep ...string) {
 	}
 }
 
+// ConditionExpression is a named condition of a resource, computed from a CEL
+// expression evaluated against the resource, e.g:
+//
+//	type: DatabaseProvisioned
+//	expression: database.status.state == "available"
+type ConditionExpression struct {
+	// Type is the type of the condition.
+	Type string
+	// Expression is the CEL expression, stripped from its ${} delimiters.
+	Expression string
+}
+
+// Constraint is a consistency rule between resources, computed from a CEL
+// expression evaluated against their rendered templates, e.g:
+//
+//	expression: service.spec.selector.all(k, k in deployment.spec.template.metadata.labels)
+//	message: the service must select the pods of the deployment
+type Constraint struct {
+	// Expression is the CEL expression, stripped from its ${} delimiters.
+	Expression string
+	// Message is reported when the expression evaluates to false.
+	Message string
+	// Dependencies are the ids of the resources the expression refers to.
+	Dependencies []string
+}
+
 // ResourceVariableKind represents the kind of a resource variable.
 type ResourceVariableKind string
 
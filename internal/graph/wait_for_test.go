@@ -0,0 +1,119 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/graph/emulator"
+	"github.com/awslabs/kro/internal/testutil/generator"
+	"github.com/awslabs/kro/internal/testutil/k8s"
+)
+
+func newWaitForResourceGroup(subnetVPCID string, opts ...generator.ResourceGroupOption) *v1alpha1.ResourceGroup {
+	opts = append([]generator.ResourceGroupOption{
+		generator.WithSchema(
+			"Network", "v1alpha1",
+			map[string]interface{}{
+				"name": "string",
+			},
+			nil,
+		),
+		generator.WithResource("vpc", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "VPC",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlocks": []interface{}{"10.0.0.0/16"},
+			},
+		}, nil, nil),
+		generator.WithResource("subnet", map[string]interface{}{
+			"apiVersion": "ec2.services.k8s.aws/v1alpha1",
+			"kind":       "Subnet",
+			"metadata": map[string]interface{}{
+				"name": "${schema.spec.name}-subnet",
+			},
+			"spec": map[string]interface{}{
+				"cidrBlock": "10.0.1.0/24",
+				"vpcID":     subnetVPCID,
+			},
+		}, nil, nil),
+	}, opts...)
+	return generator.NewResourceGroup("test-group", opts...)
+}
+
+func TestGraphBuilder_WaitFor(t *testing.T) {
+	fakeResolver, fakeDiscovery := k8s.NewFakeResolver()
+	builder := &Builder{
+		schemaResolver:   fakeResolver,
+		discoveryClient:  fakeDiscovery,
+		resourceEmulator: emulator.NewEmulator(),
+	}
+
+	t.Run("waiting resource depends on the awaited resource", func(t *testing.T) {
+		g, err := builder.NewResourceGroup(newWaitForResourceGroup("vpc-12345",
+			generator.WithResourceWaitFor("subnet", "vpc"),
+		))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"vpc"}, g.Resources["subnet"].GetWaitFor())
+		assert.Contains(t, g.Resources["subnet"].GetDependencies(), "vpc")
+		assert.Equal(t, []string{"vpc", "subnet"}, g.TopologicalOrder)
+	})
+
+	tests := []struct {
+		name        string
+		subnetVPCID string
+		opts        []generator.ResourceGroupOption
+		wantErr     string
+	}{
+		{
+			name:        "unknown resource",
+			subnetVPCID: "vpc-12345",
+			opts:        []generator.ResourceGroupOption{generator.WithResourceWaitFor("subnet", "database")},
+			wantErr:     "resource subnet waits for unknown resource database",
+		},
+		{
+			name:        "waiting for itself",
+			subnetVPCID: "vpc-12345",
+			opts:        []generator.ResourceGroupOption{generator.WithResourceWaitFor("subnet", "subnet")},
+			wantErr:     "resource subnet can't wait for itself",
+		},
+		{
+			name:        "duplicate resource",
+			subnetVPCID: "vpc-12345",
+			opts:        []generator.ResourceGroupOption{generator.WithResourceWaitFor("subnet", "vpc", "vpc")},
+			wantErr:     "resource subnet waits for resource vpc more than once",
+		},
+		{
+			name:        "cycle with a field reference",
+			subnetVPCID: "${vpc.status.vpcID}",
+			opts:        []generator.ResourceGroupOption{generator.WithResourceWaitFor("vpc", "subnet")},
+			wantErr:     "cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := builder.NewResourceGroup(newWaitForResourceGroup(tt.subnetVPCID, tt.opts...))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
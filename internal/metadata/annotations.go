@@ -0,0 +1,47 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metadata
+
+import (
+	"github.com/awslabs/kro/api/v1alpha1"
+)
+
+const (
+	// IdentityAnnotation is the annotation used to record the values of the
+	// identity fields of an instance, as observed when the instance was first
+	// reconciled.
+	IdentityAnnotation = v1alpha1.KroDomainName + "/identity"
+	// ReconcileNowAnnotation is the annotation users set, typically to the
+	// current timestamp, to force the immediate reconciliation of an object.
+	// Only changes of its value trigger a reconciliation.
+	ReconcileNowAnnotation = v1alpha1.KroDomainName + "/reconcile-now"
+	// ConfigHashAnnotation is the annotation holding the hash of the resources
+	// referenced by the template of a resource. It is injected in the pod
+	// template of the resource, so that a change of their content (e.g the data
+	// of a ConfigMap) rolls out its pods.
+	ConfigHashAnnotation = v1alpha1.KroDomainName + "/config-hash"
+	// SpecHashAnnotation is the annotation holding the hash of the desired
+	// state of a resource, as computed by kro when it last applied it. The
+	// resources whose desired state didn't change aren't applied again.
+	SpecHashAnnotation = v1alpha1.KroDomainName + "/spec-hash"
+	// AppliedFieldsAnnotation is the annotation holding the fields of a
+	// resource kro last applied, without their values. The fields kro applied
+	// and no longer desires are removed from the resource.
+	AppliedFieldsAnnotation = v1alpha1.KroDomainName + "/applied-fields"
+	// ResyncPeriodAnnotation is the annotation of a resource group overriding
+	// the resync period of the informer watching its instances, e.g. "30m".
+	// The instances are then reconciled at that cadence, correcting the drift
+	// of the resources they manage.
+	ResyncPeriodAnnotation = v1alpha1.KroDomainName + "/resync-period"
+)
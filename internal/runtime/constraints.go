@@ -0,0 +1,106 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// WithConstraints sets the consistency rules checked against the rendered
+// resources, see CheckConstraints.
+func WithConstraints(constraints []variable.Constraint) Option {
+	return func(rt *ResourceGroupRuntime) {
+		rt.constraints = constraints
+	}
+}
+
+// ConstraintViolationError is returned when a constraint doesn't hold for the
+// rendered resources.
+type ConstraintViolationError struct {
+	// Expression is the expression of the constraint.
+	Expression string
+	// Message is the message of the constraint.
+	Message string
+	// Resources are the ids of the resources the constraint refers to.
+	Resources []string
+}
+
+func (e *ConstraintViolationError) Error() string {
+	return fmt.Sprintf("constraint on resources %v violated: %s", e.Resources, e.Message)
+}
+
+// CheckConstraints evaluates the constraints referring to the given resource
+// against the rendered templates of the resources they refer to, and returns
+// a *ConstraintViolationError for the first one that doesn't hold.
+//
+// The constraints are checked once all the resources they refer to are
+// rendered. The constraints referring to a resource excluded by its
+// includeWhen expressions are skipped.
+func (rt *ResourceGroupRuntime) CheckConstraints(resourceID string) error {
+	for _, constraint := range rt.constraints {
+		if !slices.Contains(constraint.Dependencies, resourceID) {
+			continue
+		}
+		check, err := rt.constraintApplies(constraint)
+		if err != nil {
+			return err
+		}
+		if !check {
+			continue
+		}
+
+		variables := append(slices.Clone(constraint.Dependencies), "schema", FeaturesVariable)
+		env, err := newEnvironment(variables, false, false)
+		if err != nil {
+			return err
+		}
+		context := map[string]interface{}{
+			"schema":         rt.instance.Unstructured().Object,
+			FeaturesVariable: rt.features(),
+		}
+		for _, dependency := range constraint.Dependencies {
+			context[dependency] = rt.resources[dependency].Unstructured().Object
+		}
+		value, err := evaluateExpression(env, context, constraint.Expression)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate constraint %s: %w", constraint.Expression, err)
+		}
+		if holds, ok := value.(bool); !ok || !holds {
+			return &ConstraintViolationError{
+				Expression: constraint.Expression,
+				Message:    constraint.Message,
+				Resources:  slices.Clone(constraint.Dependencies),
+			}
+		}
+	}
+	return nil
+}
+
+// constraintApplies returns true if all the resources a constraint refers to
+// are rendered and included.
+func (rt *ResourceGroupRuntime) constraintApplies(constraint variable.Constraint) (bool, error) {
+	for _, dependency := range constraint.Dependencies {
+		if !rt.renderedResources[dependency] || rt.ignoredByConditionsResources[dependency] {
+			return false, nil
+		}
+		included, _, err := rt.evaluateIncludeWhen(dependency)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,125 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// selectorConstraint requires the service to select the pods of the
+// deployment.
+var selectorConstraint = variable.Constraint{
+	Expression: "service.spec.selector.all(k, k in deployment.spec.template.metadata.labels && " +
+		"deployment.spec.template.metadata.labels[k] == service.spec.selector[k])",
+	Message:      "the service must select the pods of the deployment",
+	Dependencies: []string{"deployment", "service"},
+}
+
+func newSelectorRuntime(t *testing.T, selector map[string]interface{}, serviceOpts ...mockResourceOption) *ResourceGroupRuntime {
+	t.Helper()
+	instance := newTestResource(withObject(map[string]interface{}{
+		"spec": map[string]interface{}{"expose": false},
+	}))
+	deployment := newTestResource(withObject(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "web", "tier": "frontend"},
+				},
+			},
+		},
+	}))
+	service := newTestResource(append([]mockResourceOption{withObject(map[string]interface{}{
+		"spec": map[string]interface{}{"selector": selector},
+	})}, serviceOpts...)...)
+	rt, err := NewResourceGroupRuntime(instance,
+		map[string]Resource{"deployment": deployment, "service": service},
+		[]string{"deployment", "service"},
+		WithConstraints([]variable.Constraint{selectorConstraint}),
+	)
+	if err != nil {
+		t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+	return rt
+}
+
+func Test_CheckConstraints(t *testing.T) {
+	t.Run("matching selector", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "web"})
+		for _, id := range []string{"deployment", "service"} {
+			if err := rt.CheckConstraints(id); err != nil {
+				t.Errorf("CheckConstraints(%s) error = %v", id, err)
+			}
+		}
+	})
+
+	t.Run("mismatched selector", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "api"})
+		err := rt.CheckConstraints("service")
+		var violation *ConstraintViolationError
+		if !errors.As(err, &violation) {
+			t.Fatalf("CheckConstraints() error = %v, want a constraint violation", err)
+		}
+		if violation.Message != selectorConstraint.Message {
+			t.Errorf("violation message = %q, want %q", violation.Message, selectorConstraint.Message)
+		}
+		want := "constraint on resources [deployment service] violated: the service must select the pods of the deployment"
+		if err.Error() != want {
+			t.Errorf("error = %q, want %q", err.Error(), want)
+		}
+	})
+
+	t.Run("selector on a label the pods don't have", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "web", "track": "stable"})
+		if err := rt.CheckConstraints("deployment"); err == nil {
+			t.Errorf("CheckConstraints() error = nil, want a constraint violation")
+		}
+	})
+
+	t.Run("resource not referred to", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "api"})
+		if err := rt.CheckConstraints("database"); err != nil {
+			t.Errorf("CheckConstraints() error = %v", err)
+		}
+	})
+
+	t.Run("excluded resource", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "api"},
+			withConditions([]string{"schema.spec.expose"}))
+		if err := rt.CheckConstraints("deployment"); err != nil {
+			t.Errorf("CheckConstraints() error = %v", err)
+		}
+	})
+
+	t.Run("resource not rendered yet", func(t *testing.T) {
+		rt := newSelectorRuntime(t, map[string]interface{}{"app": "api"},
+			withVariables([]*variable.ResourceField{{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "spec.clusterIP",
+					Expressions:          []string{"deployment.status.clusterIP"},
+					StandaloneExpression: true,
+				},
+				Kind:         variable.ResourceVariableKindDynamic,
+				Dependencies: []string{"deployment"},
+			}}),
+			withDependencies([]string{"deployment"}),
+		)
+		if err := rt.CheckConstraints("deployment"); err != nil {
+			t.Errorf("CheckConstraints() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+
+	krocel "github.com/awslabs/kro/pkg/cel"
+)
+
+// environment is a CEL environment along with the key identifying its
+// declarations, under which the programs compiled in it are cached.
+type environment struct {
+	env *cel.Env
+	key string
+}
+
+// EnvironmentOptions returns the options of the CEL environments declaring
+// the given variables and the kro functions. The instanceHash and stableID
+// functions are available when the instance is declared. The graph builder
+// validates the expressions in environments built from the same options.
+func EnvironmentOptions(variables []string) []krocel.EnvOption {
+	options := []krocel.EnvOption{
+		krocel.WithResourceIDs(variables),
+		krocel.WithSetFunctions(),
+		krocel.WithSortFunctions(),
+		krocel.WithListAccessors(),
+		krocel.WithContainerFunctions(),
+		krocel.WithTemplateStringFunction(),
+		krocel.WithAdvancedMathFunctions(),
+		krocel.WithArithmeticFunctions(),
+		krocel.WithStringLimitFunctions(),
+	}
+	if slices.Contains(variables, "schema") {
+		options = append(options, krocel.WithInstanceHashFunction(), krocel.WithStableIDFunction())
+	}
+	return options
+}
+
+// newEnvironment creates the CEL environment declaring the given variables
+// and the kro functions, see EnvironmentOptions, and optionally the resources
+// map and the serialization functions.
+func newEnvironment(variables []string, resourcesMap, serialization bool) (*environment, error) {
+	options := EnvironmentOptions(variables)
+	if resourcesMap {
+		options = append(options, krocel.WithResourcesMap(ResourcesMapVariable))
+	}
+	if serialization {
+		options = append(options, krocel.WithSerializationFunctions())
+	}
+	env, err := krocel.DefaultEnvironment(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := slices.Clone(variables)
+	slices.Sort(sorted)
+	key := fmt.Sprintf("%s;resourcesMap=%t;serialization=%t", strings.Join(sorted, ","), resourcesMap, serialization)
+	return &environment{env: env, key: key}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/runtime/resolver"
+)
+
+// EvaluatedOnceField is the instance status field holding the values of the
+// evaluateOnce fields of the resources, keyed by resource ID then by path:
+//
+//	status:
+//	  evaluatedOnce:
+//	    credentials:
+//	      data.password: c2VjcmV0
+const EvaluatedOnceField = "evaluatedOnce"
+
+// restoreEvaluatedOnceValues replaces the values of the evaluateOnce fields
+// of a resource, just resolved, with the values stored in the instance
+// status. The fields without a stored value keep the value they were just
+// evaluated to, which is stored at the end of the reconciliation.
+func (rt *ResourceGroupRuntime) restoreEvaluatedOnceValues(resourceID string, rs *resolver.Resolver) error {
+	paths := rt.resources[resourceID].GetEvaluateOnce()
+	if len(paths) == 0 {
+		return nil
+	}
+	stored, _, _ := unstructured.NestedMap(rt.instance.Unstructured().Object, "status", EvaluatedOnceField, resourceID)
+	for _, path := range paths {
+		value, ok := stored[path]
+		if !ok || value == nil {
+			continue
+		}
+		if err := rs.UpsertValueAtPath(path, value); err != nil {
+			return fmt.Errorf("failed to restore the value of field %s of resource %s: %w", path, resourceID, err)
+		}
+	}
+	return nil
+}
+
+// EvaluatedOnceValues returns the values of the given evaluateOnce fields of
+// a rendered resource, keyed by path. The fields missing from the resource
+// are omitted.
+func EvaluatedOnceValues(resource *unstructured.Unstructured, paths []string) map[string]interface{} {
+	rs := resolver.NewResolver(resource.Object, nil)
+	values := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		if value, err := rs.ValueAtPath(path); err == nil {
+			values[path] = value
+		}
+	}
+	return values
+}
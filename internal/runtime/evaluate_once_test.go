@@ -0,0 +1,127 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// renderCredentials renders a secret whose password is only evaluated once,
+// for an instance with the given spec and status, as a reconciliation would.
+func renderCredentials(t *testing.T, spec, status map[string]interface{}, opts ...Option) map[string]interface{} {
+	t.Helper()
+	instanceObj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "instance", "namespace": "default"},
+		"spec":     spec,
+	}
+	if status != nil {
+		instanceObj["status"] = status
+	}
+	credentials := newTestResource(
+		withObject(map[string]interface{}{
+			"data": map[string]interface{}{
+				"user":     "${schema.spec.user}",
+				"password": "${schema.spec.password}",
+			},
+		}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "data.user",
+					Expressions:          []string{"schema.spec.user"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "data.password",
+					Expressions:          []string{"schema.spec.password"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+		}),
+		withEvaluateOnce([]string{"data.password"}),
+	)
+	rt, err := NewResourceGroupRuntime(newTestResource(withObject(instanceObj)),
+		map[string]Resource{"credentials": credentials}, []string{"credentials"}, opts...)
+	if err != nil {
+		t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	rendered, state := rt.GetResource("credentials")
+	if state != ResourceStateResolved {
+		t.Fatalf("GetResource() state = %v, want %v", state, ResourceStateResolved)
+	}
+	return rendered.Object["data"].(map[string]interface{})
+}
+
+func Test_EvaluateOnce(t *testing.T) {
+	// The first reconciliation evaluates the field, and its value is stored.
+	data := renderCredentials(t, map[string]interface{}{"user": "admin", "password": "first"}, nil)
+	if data["password"] != "first" {
+		t.Fatalf("data.password = %v, want first", data["password"])
+	}
+	stored := EvaluatedOnceValues(newTestResource(withObject(map[string]interface{}{"data": data})).Unstructured(),
+		[]string{"data.password", "data.missing"})
+	if want := map[string]interface{}{"data.password": "first"}; !reflect.DeepEqual(stored, want) {
+		t.Fatalf("EvaluatedOnceValues() = %v, want %v", stored, want)
+	}
+	status := map[string]interface{}{
+		EvaluatedOnceField: map[string]interface{}{"credentials": stored},
+	}
+
+	// The next reconciliations reuse the stored value, the other fields are
+	// evaluated again.
+	spec := map[string]interface{}{"user": "root", "password": "second"}
+	data = renderCredentials(t, spec, status)
+	if data["password"] != "first" {
+		t.Errorf("data.password = %v, want the stored value first", data["password"])
+	}
+	if data["user"] != "root" {
+		t.Errorf("data.user = %v, want root", data["user"])
+	}
+	data = renderCredentials(t, spec, status)
+	if data["password"] != "first" {
+		t.Errorf("data.password = %v, want the stored value first", data["password"])
+	}
+
+	// A value cleared from the status is evaluated again.
+	data = renderCredentials(t, spec, map[string]interface{}{
+		EvaluatedOnceField: map[string]interface{}{"credentials": map[string]interface{}{}},
+	})
+	if data["password"] != "second" {
+		t.Errorf("data.password = %v, want second", data["password"])
+	}
+
+	t.Run("with a render cache", func(t *testing.T) {
+		cache := NewRenderCache()
+		data := renderCredentials(t, spec, status, WithRenderCache(cache))
+		if data["password"] != "first" {
+			t.Errorf("data.password = %v, want the stored value first", data["password"])
+		}
+		// Clearing the value renders the resource again, even though the
+		// spec didn't change.
+		data = renderCredentials(t, spec, nil, WithRenderCache(cache))
+		if data["password"] != "second" {
+			t.Errorf("data.password = %v, want second", data["password"])
+		}
+	})
+}
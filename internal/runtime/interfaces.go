@@ -14,6 +14,8 @@
 package runtime
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -35,6 +37,15 @@ type Interface interface {
 	// encounters any issues.
 	Synchronize() (bool, error)
 
+	// SynchronizeStatus evaluates the instance status expressions against the
+	// latest state of the resources and writes them in the instance status.
+	// It is meant to be called once all the resources are applied.
+	SynchronizeStatus() error
+
+	// UnresolvedStatusFields returns the paths (e.g status.endpoint) of the
+	// instance status fields that couldn't be resolved by SynchronizeStatus.
+	UnresolvedStatusFields() []string
+
 	// TopologicalOrder returns the topological order of resources.
 	TopologicalOrder() []string
 
@@ -68,6 +79,16 @@ type Interface interface {
 	// IgnoreResource ignores resource that has a condition expressison that evaluated
 	// to false
 	IgnoreResource(resourceID string)
+
+	// EvaluateResourceConditions evaluates the named condition expressions of
+	// a resource against its latest observed state, and returns their values
+	// by condition type. A nil map is returned if the resource isn't resolved.
+	EvaluateResourceConditions(resourceID string) (map[string]bool, error)
+
+	// CheckConstraints evaluates the constraints referring to a resource
+	// against the rendered resources, and returns an error if one of them
+	// doesn't hold.
+	CheckConstraints(resourceID string) error
 }
 
 // ResourceDescriptor provides metadata about a resource.
@@ -109,6 +130,27 @@ type ResourceDescriptor interface {
 	// be evaluated before deciding whether to create a resource
 	GetIncludeWhenExpressions() []string
 
+	// GetWaitFor returns the ids of the resources that must be ready before
+	// the resource is applied.
+	GetWaitFor() []string
+
+	// GetMinReadyDuration returns how long the readyWhen expressions of the
+	// resource must hold continuously before it is considered ready.
+	GetMinReadyDuration() time.Duration
+
+	// GetFinalizers returns the finalizers kro adds to the resource on
+	// creation, and removes from it when deleting it.
+	GetFinalizers() []string
+
+	// GetEvaluateOnce returns the paths of the fields only evaluated when
+	// the resource is first rendered. Their values are then read from the
+	// instance status.
+	GetEvaluateOnce() []string
+
+	// GetConditionExpressions returns the named condition expressions
+	// evaluated against the resource and reported in the instance status.
+	GetConditionExpressions() []variable.ConditionExpression
+
 	// GetTopLevelFields returns the list of top-level fields in the resource.
 	// e.g spec, status, metadata, etc.
 	GetTopLevelFields() []string
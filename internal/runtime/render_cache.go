@@ -0,0 +1,224 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/exp/maps"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/awslabs/kro/internal/runtime/resolver"
+)
+
+// RenderCache caches the rendered resources of the instances of a resource
+// group, across reconciliations. An entry is keyed by a fingerprint of the
+// inputs the resource was rendered from: the instance, and the observed state
+// of the resources it depends on. When the fingerprint is unchanged, the
+// runtime reuses the rendered resource instead of evaluating its expressions
+// again.
+//
+// The rendered resources only depend on the resource group through the graph
+// the runtime is created from, the cache must not outlive it: a new cache is
+// expected whenever the resource group generation changes.
+//
+// RenderCache is safe for concurrent use.
+type RenderCache struct {
+	mu      sync.Mutex
+	entries map[string]map[string]*renderCacheEntry
+}
+
+// renderCacheEntry is the last rendering of a resource of an instance.
+type renderCacheEntry struct {
+	// fingerprint is the fingerprint of the inputs the resource was rendered
+	// from.
+	fingerprint string
+	// values holds the values of the resource expressions, keyed by
+	// expression.
+	values map[string]interface{}
+	// object is the rendered resource.
+	object map[string]interface{}
+	// provenance is the provenance of the rendered resource fields.
+	provenance map[string]resolver.FieldProvenance
+}
+
+// NewRenderCache returns an empty RenderCache.
+func NewRenderCache() *RenderCache {
+	return &RenderCache{entries: make(map[string]map[string]*renderCacheEntry)}
+}
+
+// Forget drops the rendered resources of an instance, e.g once it is deleted.
+func (c *RenderCache) Forget(namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, instanceCacheKey(namespace, name))
+}
+
+// Len returns the number of instances with rendered resources in the cache.
+func (c *RenderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *RenderCache) get(instance, resourceID string) (*renderCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[instance][resourceID]
+	return entry, ok
+}
+
+func (c *RenderCache) set(instance, resourceID string, entry *renderCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[instance] == nil {
+		c.entries[instance] = make(map[string]*renderCacheEntry)
+	}
+	c.entries[instance][resourceID] = entry
+}
+
+func instanceCacheKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// WithRenderCache makes the runtime reuse the resources rendered by previous
+// runtimes of the same instance, when their inputs are unchanged.
+func WithRenderCache(cache *RenderCache) Option {
+	return func(rt *ResourceGroupRuntime) {
+		rt.renderCache = cache
+	}
+}
+
+// renderCacheKey returns the key of the instance in the render cache.
+func (rt *ResourceGroupRuntime) renderCacheKey() string {
+	instance := rt.instance.Unstructured()
+	return instanceCacheKey(instance.GetNamespace(), instance.GetName())
+}
+
+// restoreRenderedResources reuses the cached renderings of the resources not
+// rendered yet, whose inputs didn't change since they were cached. The values
+// of their expressions are restored along with them, so that they aren't
+// evaluated again.
+func (rt *ResourceGroupRuntime) restoreRenderedResources() error {
+	if rt.renderCache == nil {
+		return nil
+	}
+	key := rt.renderCacheKey()
+	for id, resource := range rt.resources {
+		if rt.renderedResources[id] {
+			continue
+		}
+		entry, ok := rt.renderCache.get(key, id)
+		if !ok {
+			continue
+		}
+		fingerprint, ok, err := rt.renderFingerprint(id)
+		if err != nil {
+			return err
+		}
+		if !ok || fingerprint != entry.fingerprint {
+			continue
+		}
+		for _, ees := range rt.runtimeVariables[id] {
+			if value, ok := entry.values[ees.Expression]; ok && !ees.Resolved {
+				ees.Resolved = true
+				ees.ResolvedValue = k8sruntime.DeepCopyJSONValue(value)
+			}
+		}
+		resource.Unstructured().Object = k8sruntime.DeepCopyJSON(entry.object)
+		if rt.provenance != nil {
+			rt.provenance[id] = entry.provenance
+		}
+		rt.renderedResources[id] = true
+	}
+	return nil
+}
+
+// cacheRenderedResource stores the rendering of a resource in the render
+// cache, if any.
+func (rt *ResourceGroupRuntime) cacheRenderedResource(id string) error {
+	if rt.renderCache == nil {
+		return nil
+	}
+	fingerprint, ok, err := rt.renderFingerprint(id)
+	if err != nil || !ok {
+		return err
+	}
+	values := make(map[string]interface{}, len(rt.runtimeVariables[id]))
+	for _, ees := range rt.runtimeVariables[id] {
+		if ees.Resolved {
+			values[ees.Expression] = k8sruntime.DeepCopyJSONValue(ees.ResolvedValue)
+		}
+	}
+	rt.renderCache.set(rt.renderCacheKey(), id, &renderCacheEntry{
+		fingerprint: fingerprint,
+		values:      values,
+		object:      k8sruntime.DeepCopyJSON(rt.resources[id].Unstructured().Object),
+		provenance:  rt.provenance[id],
+	})
+	return nil
+}
+
+// renderFingerprint returns the fingerprint of the inputs of the rendering of
+// a resource: the instance, without its status and the metadata fields
+// updated on every write, and the observed state of the resources it depends
+// on. It returns false if one of these resources isn't observed yet.
+func (rt *ResourceGroupRuntime) renderFingerprint(id string) (string, bool, error) {
+	dependencies := rt.resources[id].GetDependencies()
+	inputs := make(map[string]interface{}, len(dependencies)+1)
+	inputs["schema"] = fingerprintObject(rt.instance.Unstructured(), true)
+	for _, dep := range dependencies {
+		observed, ok := rt.resolvedResources[dep]
+		if !ok {
+			return "", false, nil
+		}
+		inputs[dep] = fingerprintObject(observed, false)
+	}
+
+	// encoding/json sorts the map keys, the encoding is deterministic.
+	data, err := json.Marshal(inputs)
+	if err != nil {
+		return "", false, err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true, nil
+}
+
+// fingerprintObject returns a shallow copy of the object, without its managed
+// fields. The status and resource version of the instance are dropped too:
+// they change on every status update, and aren't exposed to the expressions.
+// Only the values of the evaluateOnce fields are kept from the status, the
+// resources are rendered again when they are cleared.
+func fingerprintObject(obj *unstructured.Unstructured, isInstance bool) map[string]interface{} {
+	object := maps.Clone(obj.Object)
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		metadata = maps.Clone(metadata)
+		delete(metadata, "managedFields")
+		if isInstance {
+			delete(metadata, "resourceVersion")
+		}
+		object["metadata"] = metadata
+	}
+	if isInstance {
+		delete(object, "status")
+		if evaluatedOnce, ok, _ := unstructured.NestedFieldNoCopy(obj.Object, "status", EvaluatedOnceField); ok {
+			object["status"] = map[string]interface{}{EvaluatedOnceField: evaluatedOnce}
+		}
+	}
+	return object
+}
@@ -0,0 +1,203 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/awslabs/kro/internal/graph/variable"
+)
+
+// newRenderCacheTestRuntime returns a runtime for a graph where a configmap
+// is rendered from the instance spec, and a deployment from the configmap.
+func newRenderCacheTestRuntime(tb testing.TB, instance map[string]interface{}, opts ...Option) *ResourceGroupRuntime {
+	tb.Helper()
+	configmap := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "${schema.spec.name}"},
+			"data":     map[string]interface{}{"replicas": "${string(schema.spec.replicas)}"},
+		}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "metadata.name",
+					Expressions:          []string{"schema.spec.name"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "data.replicas",
+					Expressions:          []string{"string(schema.spec.replicas)"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+		}),
+	)
+	deployment := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app"},
+			"spec":     map[string]interface{}{"config": "${configmap.metadata.name}"},
+		}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "spec.config",
+					Expressions:          []string{"configmap.metadata.name"},
+					StandaloneExpression: true,
+				},
+				Kind:         variable.ResourceVariableKindDynamic,
+				Dependencies: []string{"configmap"},
+			},
+		}),
+		withDependencies([]string{"configmap"}),
+	)
+	rt, err := NewResourceGroupRuntime(
+		newTestResource(withObject(instance)),
+		map[string]Resource{"configmap": configmap, "deployment": deployment},
+		[]string{"configmap", "deployment"},
+		opts...,
+	)
+	if err != nil {
+		tb.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+	return rt
+}
+
+// newRenderCacheTestInstance returns an instance of the render cache test
+// graph.
+func newRenderCacheTestInstance(replicas int64, resourceVersion string) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "instance",
+			"namespace":       "default",
+			"resourceVersion": resourceVersion,
+		},
+		"spec":   map[string]interface{}{"name": "config", "replicas": replicas},
+		"status": map[string]interface{}{"observedVersion": resourceVersion},
+	}
+}
+
+// synchronizeAll walks the resources of the runtime in topological order, as
+// the instance controller does, observing them as rendered. It returns the
+// rendered resources.
+func synchronizeAll(tb testing.TB, rt *ResourceGroupRuntime) map[string]*unstructured.Unstructured {
+	tb.Helper()
+	rendered := make(map[string]*unstructured.Unstructured)
+	for _, id := range rt.TopologicalOrder() {
+		obj, state := rt.GetResource(id)
+		if state != ResourceStateResolved {
+			tb.Fatalf("GetResource(%s) state = %v, want %v", id, state, ResourceStateResolved)
+		}
+		rendered[id] = obj.DeepCopy()
+		rt.SetResource(id, obj.DeepCopy())
+		if _, err := rt.Synchronize(); err != nil {
+			tb.Fatalf("Synchronize() error = %v", err)
+		}
+	}
+	return rendered
+}
+
+func Test_RenderCache(t *testing.T) {
+	cache := NewRenderCache()
+
+	rt := newRenderCacheTestRuntime(t, newRenderCacheTestInstance(2, "1"), WithRenderCache(cache))
+	want := synchronizeAll(t, rt)
+	if rt.renders != 2 {
+		t.Fatalf("first reconcile renders = %d, want 2", rt.renders)
+	}
+	if got := want["deployment"].Object["spec"]; !reflect.DeepEqual(got, map[string]interface{}{"config": "config"}) {
+		t.Fatalf("deployment spec = %v, want config: config", got)
+	}
+
+	// A no-op reconcile doesn't render anything, even though the instance
+	// status was updated in the meantime.
+	rt = newRenderCacheTestRuntime(t, newRenderCacheTestInstance(2, "2"), WithRenderCache(cache))
+	got := synchronizeAll(t, rt)
+	if rt.renders != 0 {
+		t.Errorf("no-op reconcile renders = %d, want 0", rt.renders)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("no-op reconcile rendered %v, want %v", got, want)
+	}
+	if got := rt.ResourceProvenance("deployment"); len(got) != 1 {
+		t.Errorf("ResourceProvenance(deployment) = %v, want the provenance of spec.config", got)
+	}
+
+	// Changing the spec renders the configmap again, and the deployment as
+	// the configmap it depends on changed.
+	rt = newRenderCacheTestRuntime(t, newRenderCacheTestInstance(3, "3"), WithRenderCache(cache))
+	got = synchronizeAll(t, rt)
+	if rt.renders != 2 {
+		t.Errorf("reconcile after a spec change renders = %d, want 2", rt.renders)
+	}
+	if replicas := got["configmap"].Object["data"].(map[string]interface{})["replicas"]; replicas != "3" {
+		t.Errorf("configmap data.replicas = %v, want 3", replicas)
+	}
+
+	// Forgetting the instance renders everything again.
+	cache.Forget("default", "instance")
+	if cache.Len() != 0 {
+		t.Fatalf("Len() = %d after Forget, want 0", cache.Len())
+	}
+	rt = newRenderCacheTestRuntime(t, newRenderCacheTestInstance(3, "4"), WithRenderCache(cache))
+	synchronizeAll(t, rt)
+	if rt.renders != 2 {
+		t.Errorf("reconcile after Forget renders = %d, want 2", rt.renders)
+	}
+}
+
+func Test_RenderCacheDependencyDrift(t *testing.T) {
+	cache := NewRenderCache()
+	rt := newRenderCacheTestRuntime(t, newRenderCacheTestInstance(2, "1"), WithRenderCache(cache))
+	synchronizeAll(t, rt)
+
+	// The configmap was modified out of band: its observed state differs
+	// from the cached one, the deployment is rendered again.
+	rt = newRenderCacheTestRuntime(t, newRenderCacheTestInstance(2, "1"), WithRenderCache(cache))
+	configmap, _ := rt.GetResource("configmap")
+	drifted := configmap.DeepCopy()
+	drifted.SetLabels(map[string]string{"edited": "true"})
+	rt.SetResource("configmap", drifted)
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if _, state := rt.GetResource("deployment"); state != ResourceStateResolved {
+		t.Fatalf("GetResource(deployment) state = %v, want %v", state, ResourceStateResolved)
+	}
+	if rt.renders != 1 {
+		t.Errorf("renders = %d, want 1: only the deployment depends on the drifted configmap", rt.renders)
+	}
+}
+
+func Benchmark_RenderCache(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rt := newRenderCacheTestRuntime(b, newRenderCacheTestInstance(2, "1"))
+			synchronizeAll(b, rt)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := NewRenderCache()
+		for i := 0; i < b.N; i++ {
+			rt := newRenderCacheTestRuntime(b, newRenderCacheTestInstance(2, "1"), WithRenderCache(cache))
+			synchronizeAll(b, rt)
+		}
+	})
+}
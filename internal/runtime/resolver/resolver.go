@@ -14,7 +14,9 @@
 package resolver
 
 import (
+	"encoding/base64"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/awslabs/kro/internal/graph/fieldpath"
@@ -28,6 +30,10 @@ type ResolutionResult struct {
 	Original string
 	Replaced interface{}
 	Error    error
+	// Expressions are the expressions of the field.
+	Expressions []string
+	// StandaloneExpression is true if the field is a single expression.
+	StandaloneExpression bool
 }
 
 // ResolutionSummary provides a summary of the resolution process.
@@ -38,6 +44,38 @@ type ResolutionSummary struct {
 	Errors              []error
 }
 
+// FieldProvenance describes where the value of a resolved field comes from.
+// It is meant for debugging, to trace back a rendered field value to the
+// expressions that produced it.
+type FieldProvenance struct {
+	// Path is the path of the field in the resource.
+	Path string
+	// Expressions are the CEL expressions the field value was computed from.
+	Expressions []string
+	// StandaloneExpression is true if the field value is the result of a
+	// single expression, and false if the expressions were interpolated in a
+	// string template.
+	StandaloneExpression bool
+}
+
+// Provenance returns the provenance of the fields resolved during the
+// resolution process, keyed by field path. The resolved resource itself is
+// left untouched.
+func (s ResolutionSummary) Provenance() map[string]FieldProvenance {
+	provenance := make(map[string]FieldProvenance, s.ResolvedExpressions)
+	for _, result := range s.Results {
+		if !result.Resolved {
+			continue
+		}
+		provenance[result.Path] = FieldProvenance{
+			Path:                 result.Path,
+			Expressions:          result.Expressions,
+			StandaloneExpression: result.StandaloneExpression,
+		}
+	}
+	return provenance
+}
+
 // Resolver handles the resolution of CEL expressions in Kubernetes resources.
 type Resolver struct {
 	// The original resource to be resolved. In kro, this will typically
@@ -47,14 +85,34 @@ type Resolver struct {
 	// responsible for providing this only with available data aka CEL Expressions
 	// we've been able to resolve.
 	data map[string]interface{}
+	// coerceNumericStrings makes the resolver parse the strings resolved for
+	// integer and number fields.
+	coerceNumericStrings bool
+}
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// WithNumericStringCoercion makes the resolver parse the strings resolved for
+// the fields expecting an integer or a number, e.g "8080" built by
+// concatenating strings, into the expected type. Non numeric strings are
+// reported as errors instead of being left to the API server validation.
+func WithNumericStringCoercion() Option {
+	return func(r *Resolver) {
+		r.coerceNumericStrings = true
+	}
 }
 
 // NewResolver creates a new Resolver instance.
-func NewResolver(resource map[string]interface{}, data map[string]interface{}) *Resolver {
-	return &Resolver{
+func NewResolver(resource map[string]interface{}, data map[string]interface{}, opts ...Option) *Resolver {
+	r := &Resolver{
 		resource: resource,
 		data:     data,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Resolve processes all the given ExpressionFields and resolves their CEL expressions.
@@ -84,13 +142,21 @@ func (r *Resolver) UpsertValueAtPath(path string, value interface{}) error {
 	return r.setValueAtPath(path, value)
 }
 
+// ValueAtPath returns the value of the resource at the given path, or an
+// error if there is none.
+func (r *Resolver) ValueAtPath(path string) (interface{}, error) {
+	return r.getValueFromPath(path)
+}
+
 // resolveField handles the resolution of a single ExpressionField (one field) in
 // the resource. It returns a ResolutionResult containing information about the
 // resolution process
 func (r *Resolver) resolveField(field variable.FieldDescriptor) ResolutionResult {
 	result := ResolutionResult{
-		Path:     field.Path,
-		Original: fmt.Sprintf("%v", field.Expressions),
+		Path:                 field.Path,
+		Original:             fmt.Sprintf("%v", field.Expressions),
+		Expressions:          field.Expressions,
+		StandaloneExpression: field.StandaloneExpression,
 	}
 
 	value, err := r.getValueFromPath(field.Path)
@@ -102,11 +168,25 @@ func (r *Resolver) resolveField(field variable.FieldDescriptor) ResolutionResult
 	}
 
 	if field.StandaloneExpression {
-		resolvedValue, ok := r.data[strings.Trim(field.Expressions[0], "${}")]
+		resolvedValue, ok := r.data[field.Expressions[0]]
 		if !ok {
 			result.Error = fmt.Errorf("no data provided for expression: %s", field.Expressions[0])
 			return result
 		}
+		resolvedValue, err = coerceToFormat(field, resolvedValue)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		resolvedValue, err = r.coerceToType(field, resolvedValue)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		if err := validatePattern(field, resolvedValue); err != nil {
+			result.Error = err
+			return result
+		}
 		err = r.setValueAtPath(field.Path, resolvedValue)
 		if err != nil {
 			result.Error = fmt.Errorf("error setting value: %v", err)
@@ -123,8 +203,7 @@ func (r *Resolver) resolveField(field variable.FieldDescriptor) ResolutionResult
 
 		replaced := strValue
 		for _, expr := range field.Expressions {
-			key := strings.Trim(expr, "${}")
-			replacement, ok := r.data[key]
+			replacement, ok := r.data[expr]
 			if !ok {
 				result.Error = fmt.Errorf("no data provided for expression: %s", expr)
 				return result
@@ -132,18 +211,87 @@ func (r *Resolver) resolveField(field variable.FieldDescriptor) ResolutionResult
 			replaced = strings.Replace(replaced, "${"+expr+"}", fmt.Sprintf("%v", replacement), -1)
 		}
 
-		err = r.setValueAtPath(field.Path, replaced)
+		coerced, err := coerceToFormat(field, replaced)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		if err := validatePattern(field, coerced); err != nil {
+			result.Error = err
+			return result
+		}
+		err = r.setValueAtPath(field.Path, coerced)
 		if err != nil {
 			result.Error = fmt.Errorf("error setting value: %v", err)
 			return result
 		}
 		result.Resolved = true
-		result.Replaced = replaced
+		result.Replaced = coerced
 	}
 
 	return result
 }
 
+// coerceToFormat adapts a resolved value to the format of its field. The
+// values of "byte" formatted fields must be base64 encoded strings: byte
+// slices are always encoded, while strings are either encoded if the field
+// asks for it, or checked to be valid base64.
+func coerceToFormat(field variable.FieldDescriptor, value interface{}) (interface{}, error) {
+	if field.ExpectedFormat != "byte" {
+		return value, nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case string:
+		if field.EncodeBytes {
+			return base64.StdEncoding.EncodeToString([]byte(v)), nil
+		}
+		if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+			return nil, fmt.Errorf("value of field %s is not valid base64: %v", field.Path, err)
+		}
+	}
+	return value, nil
+}
+
+// coerceToType parses the strings resolved for integer and number fields into
+// the expected type, if the resolver coerces numeric strings. Other values
+// are left to the API server validation.
+func (r *Resolver) coerceToType(field variable.FieldDescriptor, value interface{}) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok || !r.coerceNumericStrings {
+		return value, nil
+	}
+	switch field.ExpectedType {
+	case "integer":
+		i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value %q of integer field %s is not an integer", s, field.Path)
+		}
+		return i, nil
+	case "number":
+		if i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64); err == nil {
+			return i, nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, fmt.Errorf("value %q of number field %s is not a number", s, field.Path)
+		}
+		return f, nil
+	}
+	return value, nil
+}
+
+// validatePattern checks that a resolved string value matches the pattern of
+// its field, if any. Non string values are left to the API server validation.
+func validatePattern(field variable.FieldDescriptor, value interface{}) error {
+	s, ok := value.(string)
+	if !ok || field.ExpectedPattern == nil || field.ExpectedPattern.MatchString(s) {
+		return nil
+	}
+	return fmt.Errorf("value %q of field %s doesn't match pattern %q", s, field.Path, field.ExpectedPattern.String())
+}
+
 // getValueFromPath retrieves a value from the resource using a dot separated path.
 // NOTE(a-hilaly): this is very similar to the `setValueAtPath` function maybe
 // we can refactor something here.
@@ -16,6 +16,7 @@ package resolver
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -662,3 +663,314 @@ func TestResolver(t *testing.T) {
 	assert.Equal(t, summary.ResolvedExpressions, 1)
 	assert.Equal(t, "resolved-done", summary.Results[0].Replaced)
 }
+
+func TestResolveByteFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       interface{}
+		template    string
+		encodeBytes bool
+		want        interface{}
+		wantErr     string
+	}{
+		{
+			name:  "valid base64 value",
+			value: "c2VjcmV0",
+			want:  "c2VjcmV0",
+		},
+		{
+			name:    "invalid base64 value",
+			value:   "not base64!",
+			wantErr: "value of field data.password is not valid base64",
+		},
+		{
+			name:        "auto encoded plaintext",
+			value:       "secret",
+			encodeBytes: true,
+			want:        "c2VjcmV0",
+		},
+		{
+			name:        "auto encoded template",
+			value:       "secret",
+			template:    "${value}-password",
+			encodeBytes: true,
+			want:        "c2VjcmV0LXBhc3N3b3Jk",
+		},
+		{
+			name:  "bytes are always encoded",
+			value: []byte("secret"),
+			want:  "c2VjcmV0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := variable.FieldDescriptor{
+				Path:                 "data.password",
+				Expressions:          []string{"value"},
+				ExpectedType:         "string",
+				ExpectedFormat:       "byte",
+				EncodeBytes:          tt.encodeBytes,
+				StandaloneExpression: tt.template == "",
+			}
+			template := tt.template
+			if template == "" {
+				template = "${value}"
+			}
+			resource := map[string]interface{}{
+				"data": map[string]interface{}{
+					"password": template,
+				},
+			}
+
+			got := NewResolver(resource, map[string]interface{}{"value": tt.value}).resolveField(field)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, got.Error, tt.wantErr)
+				assert.False(t, got.Resolved)
+				return
+			}
+			assert.NoError(t, got.Error)
+			assert.Equal(t, tt.want, got.Replaced)
+			assert.Equal(t, tt.want, resource["data"].(map[string]interface{})["password"])
+		})
+	}
+}
+
+func TestResolvePattern(t *testing.T) {
+	pattern := regexp.MustCompile("^[a-z]+(-[a-z]+)*$")
+	tests := []struct {
+		name     string
+		value    interface{}
+		template string
+		wantErr  string
+	}{
+		{
+			name:  "matching value",
+			value: "app",
+		},
+		{
+			name:    "non matching value",
+			value:   "App",
+			wantErr: `value "App" of field metadata.name doesn't match pattern "^[a-z]+(-[a-z]+)*$"`,
+		},
+		{
+			name:     "matching template",
+			value:    "app",
+			template: "${value}-config",
+		},
+		{
+			name:     "non matching template",
+			value:    "app_1",
+			template: "${value}-config",
+			wantErr:  `value "app_1-config" of field metadata.name doesn't match pattern`,
+		},
+		{
+			name:  "non string value",
+			value: int64(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := tt.template
+			if template == "" {
+				template = "${value}"
+			}
+			field := variable.FieldDescriptor{
+				Path:                 "metadata.name",
+				Expressions:          []string{"value"},
+				ExpectedType:         "string",
+				ExpectedPattern:      pattern,
+				StandaloneExpression: tt.template == "",
+			}
+			resource := map[string]interface{}{
+				"metadata": map[string]interface{}{"name": template},
+			}
+
+			got := NewResolver(resource, map[string]interface{}{"value": tt.value}).resolveField(field)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, got.Error, tt.wantErr)
+				assert.False(t, got.Resolved)
+				return
+			}
+			assert.NoError(t, got.Error)
+			assert.True(t, got.Resolved)
+		})
+	}
+}
+
+func TestResolutionSummaryProvenance(t *testing.T) {
+	resource := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "${schema.spec.name}-${suffix}",
+		},
+		"spec": map[string]interface{}{
+			"replicas": "${schema.spec.replicas}",
+			"ports": []interface{}{
+				map[string]interface{}{"port": "${service.spec.port}"},
+			},
+			"image": "${missing}",
+		},
+	}
+	r := NewResolver(resource, map[string]interface{}{
+		"schema.spec.name":     "app",
+		"suffix":               "web",
+		"schema.spec.replicas": int64(3),
+		"service.spec.port":    int64(8080),
+	})
+	summary := r.Resolve([]variable.FieldDescriptor{
+		{
+			Path:        "metadata.name",
+			Expressions: []string{"schema.spec.name", "suffix"},
+		},
+		{
+			Path:                 "spec.replicas",
+			Expressions:          []string{"schema.spec.replicas"},
+			StandaloneExpression: true,
+		},
+		{
+			Path:                 "spec.ports[0].port",
+			Expressions:          []string{"service.spec.port"},
+			StandaloneExpression: true,
+		},
+		{
+			Path:                 "spec.image",
+			Expressions:          []string{"missing"},
+			StandaloneExpression: true,
+		},
+	})
+
+	// Unresolved fields have no provenance.
+	assert.Equal(t, map[string]FieldProvenance{
+		"metadata.name": {
+			Path:        "metadata.name",
+			Expressions: []string{"schema.spec.name", "suffix"},
+		},
+		"spec.replicas": {
+			Path:                 "spec.replicas",
+			Expressions:          []string{"schema.spec.replicas"},
+			StandaloneExpression: true,
+		},
+		"spec.ports[0].port": {
+			Path:                 "spec.ports[0].port",
+			Expressions:          []string{"service.spec.port"},
+			StandaloneExpression: true,
+		},
+	}, summary.Provenance())
+
+	// The provenance doesn't end up in the resolved resource.
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "app-web",
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(8080)},
+			},
+			"image": "${missing}",
+		},
+	}, resource)
+}
+
+func TestResolveNumericStringCoercion(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedType string
+		value        interface{}
+		coerce       bool
+		want         interface{}
+		wantErr      string
+	}{
+		{
+			name:         "numeric string into an integer field",
+			expectedType: "integer",
+			value:        "8080",
+			coerce:       true,
+			want:         int64(8080),
+		},
+		{
+			name:         "non numeric string into an integer field",
+			expectedType: "integer",
+			value:        "abc",
+			coerce:       true,
+			wantErr:      `value "abc" of integer field spec.port is not an integer`,
+		},
+		{
+			name:         "decimal string into an integer field",
+			expectedType: "integer",
+			value:        "80.5",
+			coerce:       true,
+			wantErr:      `value "80.5" of integer field spec.port is not an integer`,
+		},
+		{
+			name:         "decimal string into a number field",
+			expectedType: "number",
+			value:        "0.75",
+			coerce:       true,
+			want:         0.75,
+		},
+		{
+			name:         "integral string into a number field",
+			expectedType: "number",
+			value:        "2",
+			coerce:       true,
+			want:         int64(2),
+		},
+		{
+			name:         "non numeric string into a number field",
+			expectedType: "number",
+			value:        "abc",
+			coerce:       true,
+			wantErr:      `value "abc" of number field spec.port is not a number`,
+		},
+		{
+			name:         "integer into an integer field",
+			expectedType: "integer",
+			value:        int64(8080),
+			coerce:       true,
+			want:         int64(8080),
+		},
+		{
+			name:         "string into a string field",
+			expectedType: "string",
+			value:        "8080",
+			coerce:       true,
+			want:         "8080",
+		},
+		{
+			name:         "coercion disabled",
+			expectedType: "integer",
+			value:        "8080",
+			want:         "8080",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := variable.FieldDescriptor{
+				Path:                 "spec.port",
+				Expressions:          []string{"value"},
+				ExpectedType:         tt.expectedType,
+				StandaloneExpression: true,
+			}
+			resource := map[string]interface{}{
+				"spec": map[string]interface{}{"port": "${value}"},
+			}
+			var opts []Option
+			if tt.coerce {
+				opts = append(opts, WithNumericStringCoercion())
+			}
+
+			got := NewResolver(resource, map[string]interface{}{"value": tt.value}, opts...).resolveField(field)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, got.Error, tt.wantErr)
+				assert.False(t, got.Resolved)
+				return
+			}
+			assert.NoError(t, got.Error)
+			assert.Equal(t, tt.want, got.Replaced)
+			assert.Equal(t, tt.want, resource["spec"].(map[string]interface{})["port"])
+		})
+	}
+}
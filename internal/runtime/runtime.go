@@ -18,7 +18,6 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/google/cel-go/cel"
 	"golang.org/x/exp/maps"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -27,6 +26,32 @@ import (
 	krocel "github.com/awslabs/kro/pkg/cel"
 )
 
+// ResourcesMapVariable is the name of the CEL variable exposing the created
+// resources of an instance, keyed by resource ID. For example:
+//
+//	${resources["deployment"].metadata.name}
+//
+// When evaluating an expression, the map only contains the resources the
+// expression depends on. These are guaranteed to be created (resolved) before
+// the expression is evaluated.
+const ResourcesMapVariable = "resources"
+
+// FeaturesVariable is the name of the CEL variable exposing the feature gates
+// of the instance, e.g ${features.canary}. The gates are set in the instance
+// spec field of the same name.
+const FeaturesVariable = "features"
+
+// ReadyField is the name of the boolean field synthesized on the resources
+// exposed to the CEL expressions. It holds the result of the evaluation of the
+// resource readyWhen expressions, so that other expressions can depend on the
+// readiness of a resource. For example:
+//
+//	${deployment.ready}
+//
+// The field is not available to the readyWhen expressions of the resource
+// itself, as they are what it is derived from.
+const ReadyField = "ready"
+
 // Compile time proof to ensure that ResourceGroupRuntime implements the
 // Runtime interface.
 var _ Interface = &ResourceGroupRuntime{}
@@ -45,6 +70,7 @@ func NewResourceGroupRuntime(
 	instance Resource,
 	resources map[string]Resource,
 	topologicalOrder []string,
+	opts ...Option,
 ) (*ResourceGroupRuntime, error) {
 	r := &ResourceGroupRuntime{
 		instance:                     instance,
@@ -54,6 +80,11 @@ func NewResourceGroupRuntime(
 		runtimeVariables:             make(map[string][]*expressionEvaluationState),
 		expressionsCache:             make(map[string]*expressionEvaluationState),
 		ignoredByConditionsResources: make(map[string]bool),
+		provenance:                   make(map[string]map[string]resolver.FieldProvenance),
+		renderedResources:            make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 	// make sure to copy the variables and the dependencies, to avoid
 	// modifying the original resource.
@@ -107,6 +138,12 @@ func NewResourceGroupRuntime(
 		}
 	}
 
+	// Reuse the resources rendered by a previous runtime of the instance, if
+	// any, before evaluating their static variables.
+	if err := r.restoreRenderedResources(); err != nil {
+		return nil, fmt.Errorf("failed to restore rendered resources: %w", err)
+	}
+
 	// Evaluate the static variables, so that the caller only needs to call Synchronize
 	// whenever a new resource is added or a variable is updated.
 	err := r.evaluateStaticVariables()
@@ -121,6 +158,9 @@ func NewResourceGroupRuntime(
 	return r, nil
 }
 
+// Option configures a ResourceGroupRuntime.
+type Option func(*ResourceGroupRuntime)
+
 // ResourceGroupRuntime implements the Interface for managing and synchronizing
 // resources. Is is the responsibility of the consumer to call Synchronize
 // appropriately, and decide whether to follow the TopologicalOrder or a
@@ -166,6 +206,38 @@ type ResourceGroupRuntime struct {
 	// ignoredByConditionsResources holds the resources whos defined conditions returned false
 	// or who's dependencies are ignored
 	ignoredByConditionsResources map[string]bool
+
+	// provenance maps resource ids to the provenance of their resolved
+	// fields, keyed by field path.
+	provenance map[string]map[string]resolver.FieldProvenance
+
+	// renderedResources holds the resources whose expressions are all
+	// evaluated and replaced in their template, either rendered by this
+	// runtime or restored from the renderCache.
+	renderedResources map[string]bool
+
+	// renderCache caches the rendered resources across the runtimes of the
+	// instance. It is nil when rendered resources aren't cached.
+	renderCache *RenderCache
+
+	// renders counts the resources rendered by this runtime.
+	renders int
+
+	// coerceNumericStrings makes the resolver parse the strings computed for
+	// integer and number fields, see resolver.WithNumericStringCoercion.
+	coerceNumericStrings bool
+
+	// constraints are the consistency rules checked against the rendered
+	// resources, see CheckConstraints.
+	constraints []variable.Constraint
+}
+
+// WithNumericStringCoercion makes the runtime parse the strings computed for
+// the integer and number fields of the resources into the expected type.
+func WithNumericStringCoercion() Option {
+	return func(rt *ResourceGroupRuntime) {
+		rt.coerceNumericStrings = true
+	}
 }
 
 // TopologicalOrder returns the topological order of resources.
@@ -234,6 +306,12 @@ func (rt *ResourceGroupRuntime) Synchronize() (bool, error) {
 		return false, nil
 	}
 
+	// Reuse the cached renderings of the resources whose dependencies were
+	// just resolved, if their inputs are unchanged.
+	if err := rt.restoreRenderedResources(); err != nil {
+		return true, fmt.Errorf("failed to restore rendered resources: %w", err)
+	}
+
 	// first synchronize the resources.
 	err := rt.evaluateDynamicVariables()
 	if err != nil {
@@ -246,25 +324,91 @@ func (rt *ResourceGroupRuntime) Synchronize() (bool, error) {
 		return true, fmt.Errorf("failed to propagate resource variables: %w", err)
 	}
 
-	// then synchronize the instance
-	err = rt.evaluateInstanceStatuses()
+	// The instance status is not written here, the resources it refers to
+	// might not be applied yet. See SynchronizeStatus.
+	return true, nil
+}
+
+// SynchronizeStatus is the second phase of the synchronization: it evaluates
+// the instance status expressions against the latest state of the resources,
+// as set by SetResource, and writes their values in the instance status.
+//
+// It is meant to be called once all the resources are applied, so that the
+// status reflects their live state rather than transient values observed
+// while they were being applied. The expressions whose dependencies aren't
+// resolved, or refer to fields that are not set yet, are skipped.
+func (rt *ResourceGroupRuntime) SynchronizeStatus() error {
+	resolvedResources := maps.Keys(rt.resolvedResources)
+	resolvedResources = append(resolvedResources, "schema", FeaturesVariable)
+	env, err := newEnvironment(resolvedResources, true, true)
 	if err != nil {
-		return true, fmt.Errorf("failed to evaluate instance statuses: %w", err)
+		return err
 	}
 
-	return true, nil
+	objects := make(map[string]map[string]interface{}, len(rt.resolvedResources))
+	for _, variable := range rt.instance.GetVariables() {
+		if !containsAllElements(resolvedResources, variable.Dependencies) {
+			continue
+		}
+		cached, ok := rt.expressionsCache[variable.Expressions[0]]
+		if !ok {
+			continue
+		}
+
+		evalContext, err := rt.evaluationContext(variable.Dependencies, objects)
+		if err != nil {
+			return err
+		}
+		value, err := evaluateExpression(env, evalContext, cached.Expression)
+		if err != nil {
+			if strings.Contains(err.Error(), "no such key") {
+				continue
+			}
+			return &EvalError{Expression: cached.Expression, Err: err}
+		}
+		cached.Resolved = true
+		cached.ResolvedValue = value
+	}
+
+	if err := rt.evaluateInstanceStatuses(); err != nil {
+		return fmt.Errorf("failed to evaluate instance statuses: %w", err)
+	}
+	return nil
+}
+
+// UnresolvedStatusFields returns the paths of the instance status fields whose
+// expressions couldn't be resolved yet, e.g because they refer to a resource
+// field that isn't set yet.
+func (rt *ResourceGroupRuntime) UnresolvedStatusFields() []string {
+	var paths []string
+	for _, variable := range rt.instance.GetVariables() {
+		if cached, ok := rt.expressionsCache[variable.Expressions[0]]; !ok || !cached.Resolved {
+			paths = append(paths, variable.Path)
+		}
+	}
+	return paths
 }
 
 // propagateResourceVariables iterates over all resources and evaluates their
-// variables if all dependencies are resolved.
+// variables if all dependencies are resolved. Resources are only rendered
+// once, the values of their variables don't change once resolved.
 func (rt *ResourceGroupRuntime) propagateResourceVariables() error {
+	if rt.renderedResources == nil {
+		rt.renderedResources = make(map[string]bool)
+	}
 	for id := range rt.resources {
-		if rt.canProcessResource(id) {
-			// evaluate the resource variables
-			err := rt.evaluateResourceExpressions(id)
-			if err != nil {
-				return fmt.Errorf("failed to evaluate resource variables for %s: %w", id, err)
-			}
+		if rt.renderedResources[id] || !rt.canProcessResource(id) {
+			continue
+		}
+		// evaluate the resource variables
+		err := rt.evaluateResourceExpressions(id)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate resource variables for %s: %w", id, err)
+		}
+		rt.renderedResources[id] = true
+		rt.renders++
+		if err := rt.cacheRenderedResource(id); err != nil {
+			return fmt.Errorf("failed to cache rendered resource %s: %w", id, err)
 		}
 	}
 	return nil
@@ -302,16 +446,22 @@ func (rt *ResourceGroupRuntime) resourceVariablesResolved(resource string) bool
 // depending only on the initial configuration. This function is usually
 // called once during runtime initialization to set up the baseline state
 func (rt *ResourceGroupRuntime) evaluateStaticVariables() error {
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{"schema"}))
-	if err != nil {
-		return err
-	}
-
+	// The environment is only created if there is something to evaluate,
+	// all the variables might have been restored from the render cache.
+	var env *environment
 	evalContext := map[string]interface{}{
-		"schema": rt.instance.Unstructured().Object,
+		"schema":         rt.instance.Unstructured().Object,
+		FeaturesVariable: rt.features(),
 	}
 	for _, variable := range rt.expressionsCache {
-		if variable.Kind.IsStatic() {
+		if variable.Kind.IsStatic() && !variable.Resolved {
+			if env == nil {
+				var err error
+				env, err = newEnvironment([]string{"schema", FeaturesVariable}, false, true)
+				if err != nil {
+					return err
+				}
+			}
 			value, err := evaluateExpression(env, evalContext, variable.Expression)
 			if err != nil {
 				return err
@@ -326,7 +476,9 @@ func (rt *ResourceGroupRuntime) evaluateStaticVariables() error {
 
 type EvalError struct {
 	IsIncompleteData bool
-	Err              error
+	// Expression is the expression that failed to evaluate, if known.
+	Expression string
+	Err        error
 }
 
 func (e *EvalError) Error() string {
@@ -346,11 +498,14 @@ func (rt *ResourceGroupRuntime) evaluateDynamicVariables() error {
 	// and are resolved after all the dependencies are resolved.
 
 	resolvedResources := maps.Keys(rt.resolvedResources)
-	resolvedResources = append(resolvedResources, "schema")
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs(resolvedResources))
-	if err != nil {
-		return err
-	}
+	resolvedResources = append(resolvedResources, "schema", FeaturesVariable)
+	// The environment is created on first use, there is often nothing left
+	// to evaluate.
+	var env *environment
+
+	// objects holds the resolved resources as exposed to the expressions,
+	// computed on first use.
+	objects := make(map[string]map[string]interface{}, len(rt.resolvedResources))
 
 	// let's iterate over any resolved resource and try to resolve
 	// the dynamic variables that depend on it.
@@ -370,12 +525,18 @@ func (rt *ResourceGroupRuntime) evaluateDynamicVariables() error {
 				continue
 			}
 
-			evalContext := make(map[string]interface{})
-			for _, dep := range variable.Dependencies {
-				evalContext[dep] = rt.resolvedResources[dep].Object
+			if env == nil {
+				var err error
+				env, err = newEnvironment(resolvedResources, true, true)
+				if err != nil {
+					return err
+				}
 			}
 
-			evalContext["schema"] = rt.instance.Unstructured().Object
+			evalContext, err := rt.evaluationContext(variable.Dependencies, objects)
+			if err != nil {
+				return err
+			}
 
 			value, err := evaluateExpression(env, evalContext, variable.Expression)
 			if err != nil {
@@ -384,11 +545,13 @@ func (rt *ResourceGroupRuntime) evaluateDynamicVariables() error {
 					// these. Probably need to reiterate here.
 					return &EvalError{
 						IsIncompleteData: true,
+						Expression:       variable.Expression,
 						Err:              err,
 					}
 				}
 				return &EvalError{
-					Err: err,
+					Expression: variable.Expression,
+					Err:        err,
 				}
 			}
 
@@ -400,6 +563,63 @@ func (rt *ResourceGroupRuntime) evaluateDynamicVariables() error {
 	return nil
 }
 
+// evaluationContext returns the context to evaluate an expression with the
+// given dependencies. objects caches the resolved resources as exposed to the
+// expressions, across calls.
+func (rt *ResourceGroupRuntime) evaluationContext(
+	dependencies []string,
+	objects map[string]map[string]interface{},
+) (map[string]interface{}, error) {
+	evalContext := make(map[string]interface{})
+	// Only expose the dependencies of the expression, which are known
+	// to be created at this point, in the resources map.
+	resourcesMap := make(map[string]interface{}, len(dependencies))
+	for _, dep := range dependencies {
+		object, ok := objects[dep]
+		if !ok {
+			var err error
+			object, err = rt.resolvedResourceObject(dep)
+			if err != nil {
+				return nil, &EvalError{Err: err}
+			}
+			objects[dep] = object
+		}
+		evalContext[dep] = object
+		resourcesMap[dep] = object
+	}
+	evalContext[ResourcesMapVariable] = resourcesMap
+	evalContext["schema"] = rt.instance.Unstructured().Object
+	evalContext[FeaturesVariable] = rt.features()
+	return evalContext, nil
+}
+
+// features returns the feature gates set in the instance spec.
+func (rt *ResourceGroupRuntime) features() map[string]interface{} {
+	value, _, _ := unstructured.NestedFieldNoCopy(rt.instance.Unstructured().Object, "spec", FeaturesVariable)
+	features, ok := value.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return features
+}
+
+// resolvedResourceObject returns the object of a resolved resource as exposed
+// to the CEL expressions: the observed object, along with the synthesized
+// ReadyField.
+func (rt *ResourceGroupRuntime) resolvedResourceObject(resourceID string) (map[string]interface{}, error) {
+	ready, _, err := rt.IsResourceReady(resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate readiness of resource %s: %w", resourceID, err)
+	}
+	// Shallow copy the object, to avoid modifying the observed resource.
+	object := maps.Clone(rt.resolvedResources[resourceID].Object)
+	if object == nil {
+		object = make(map[string]interface{})
+	}
+	object[ReadyField] = ready
+	return object, nil
+}
+
 // evaluateInstanceStatuses updates the status of the main instance based on
 // the current state of all resources. This function aggregates information
 // from all managed resources to provide an overall status of the runtime,
@@ -439,15 +659,37 @@ func (rt *ResourceGroupRuntime) evaluateResourceExpressions(resource string) err
 		exprFields[i] = v.FieldDescriptor
 	}
 
-	rs := resolver.NewResolver(rt.resources[resource].Unstructured().Object, exprValues)
+	var opts []resolver.Option
+	if rt.coerceNumericStrings {
+		opts = append(opts, resolver.WithNumericStringCoercion())
+	}
+	rs := resolver.NewResolver(rt.resources[resource].Unstructured().Object, exprValues, opts...)
 
 	summary := rs.Resolve(exprFields)
 	if summary.Errors != nil {
 		return fmt.Errorf("failed to resolve resource %s: %v", resource, summary.Errors)
 	}
+	if err := rt.restoreEvaluatedOnceValues(resource, rs); err != nil {
+		return err
+	}
+	if rt.provenance != nil {
+		rt.provenance[resource] = summary.Provenance()
+	}
 	return nil
 }
 
+// ResourceProvenance returns, for debugging purposes, the provenance of the
+// fields of a resource computed from CEL expressions, keyed by field path.
+// For example:
+//
+//	"spec.replicas" -> {Expressions: ["schema.spec.replicas"], StandaloneExpression: true}
+//
+// Only the fields resolved so far are reported, the rendered resource is left
+// untouched.
+func (rt *ResourceGroupRuntime) ResourceProvenance(resourceID string) map[string]resolver.FieldProvenance {
+	return maps.Clone(rt.provenance[resourceID])
+}
+
 // allExpressionsAreResolved checks if every expression in the runtimes cache
 // has been successfully evaluated
 func (rt *ResourceGroupRuntime) allExpressionsAreResolved() bool {
@@ -470,14 +712,18 @@ func (rt *ResourceGroupRuntime) IsResourceReady(resourceID string) (bool, string
 		return false, fmt.Sprintf("resource %s is not resolved", resourceID), nil
 	}
 
-	expressions := rt.resources[resourceID].GetReadyWhenExpressions()
+	descriptor, ok := rt.resources[resourceID]
+	if !ok {
+		return false, fmt.Sprintf("resource %s is not part of the graph", resourceID), nil
+	}
+	expressions := descriptor.GetReadyWhenExpressions()
 	if len(expressions) == 0 {
 		return true, "", nil
 	}
 
 	// we should not expect errors here since we already compiled it
 	// in the dryRun
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{resourceID}))
+	env, err := newEnvironment([]string{resourceID}, false, false)
 	if err != nil {
 		return false, "", fmt.Errorf("failed creating new Environment: %w", err)
 	}
@@ -498,6 +744,46 @@ func (rt *ResourceGroupRuntime) IsResourceReady(resourceID string) (bool, string
 	return true, "", nil
 }
 
+// EvaluateResourceConditions evaluates the named condition expressions of the
+// given resource against its latest observed state. It returns nil if the
+// resource is not resolved yet.
+func (rt *ResourceGroupRuntime) EvaluateResourceConditions(resourceID string) (map[string]bool, error) {
+	observed, ok := rt.resolvedResources[resourceID]
+	if !ok {
+		return nil, nil
+	}
+	descriptor, ok := rt.resources[resourceID]
+	if !ok {
+		return nil, nil
+	}
+	conditions := descriptor.GetConditionExpressions()
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	env, err := newEnvironment([]string{resourceID}, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating new Environment: %w", err)
+	}
+	context := map[string]interface{}{
+		resourceID: observed.Object,
+	}
+
+	values := make(map[string]bool, len(conditions))
+	for _, condition := range conditions {
+		out, err := evaluateExpression(env, context, condition.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("failed evaluating condition %s expression %s: %w", condition.Type, condition.Expression, err)
+		}
+		value, ok := out.(bool)
+		if !ok {
+			return nil, fmt.Errorf("condition %s expression %s did not evaluate to a boolean", condition.Type, condition.Expression)
+		}
+		values[condition.Type] = value
+	}
+	return values, nil
+}
+
 // IgnoreResource ignores resource that has a conditions expressison that evaluated
 // to false or whose dependencies are ignored
 func (rt *ResourceGroupRuntime) IgnoreResource(resourceID string) {
@@ -526,46 +812,56 @@ func (rt *ResourceGroupRuntime) WantToCreateResource(resourceID string) (bool, e
 		return false, nil
 	}
 
+	included, condition, err := rt.evaluateIncludeWhen(resourceID)
+	if err != nil {
+		return false, err
+	}
+	// returning a reason here to point out which expression is not ready yet
+	if !included {
+		return false, fmt.Errorf("Skipping resource creation due to condition %s", condition)
+	}
+	return true, nil
+}
+
+// evaluateIncludeWhen evaluates the includeWhen expressions of a resource, and
+// returns the first one that evaluated to false, if any.
+func (rt *ResourceGroupRuntime) evaluateIncludeWhen(resourceID string) (bool, string, error) {
 	conditions := rt.resources[resourceID].GetIncludeWhenExpressions()
 	if len(conditions) == 0 {
-		return true, nil
+		return true, "", nil
 	}
 
 	// we should not expect errors here since we already compiled it
 	// in the dryRun
-	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{"schema"}))
+	env, err := newEnvironment([]string{"schema", FeaturesVariable}, false, false)
 	if err != nil {
-		return false, nil
+		return false, "", err
 	}
 
 	context := map[string]interface{}{
-		"schema": rt.instance.Unstructured().Object,
+		"schema":         rt.instance.Unstructured().Object,
+		FeaturesVariable: rt.features(),
 	}
 
 	for _, condition := range conditions {
 		// We should not expect an error here as well since we checked during dry-run
 		value, err := evaluateExpression(env, context, condition)
 		if err != nil {
-			return false, err
+			return false, "", err
 		}
-		// returning a reason here to point out which expression is not ready yet
 		if !value.(bool) {
-			return false, fmt.Errorf("Skipping resource creation due to condition %s", condition)
+			return false, condition, nil
 		}
 	}
-	return true, nil
+	return true, "", nil
 }
 
-// evaluateExpression evaluates an CEL expression and returns a value if successful, or error
-func evaluateExpression(env *cel.Env, context map[string]interface{}, expression string) (interface{}, error) {
-	ast, issues := env.Compile(expression)
-	if issues != nil && issues.Err() != nil {
-		return nil, fmt.Errorf("failed compiling expression %s: %w", expression, issues.Err())
-	}
-	// Here as well
-	program, err := env.Program(ast)
+// evaluateExpression evaluates an CEL expression and returns a value if successful, or error.
+// The programs are compiled once, and cached in the default program cache.
+func evaluateExpression(env *environment, context map[string]interface{}, expression string) (interface{}, error) {
+	program, err := krocel.DefaultProgramCache.Program(env.env, env.key, expression)
 	if err != nil {
-		return nil, fmt.Errorf("failed programming expression %s: %w", expression, err)
+		return nil, err
 	}
 	// We get an error here when the value field we're looking for is not yet defined
 	// For now leaving it as error, in the future when we see different scenarios
@@ -18,13 +18,13 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/google/cel-go/cel"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/awslabs/kro/internal/graph/variable"
-	krocel "github.com/awslabs/kro/pkg/cel"
+	"github.com/awslabs/kro/internal/runtime/resolver"
 )
 
 func Test_RuntimeWorkflow(t *testing.T) {
@@ -342,6 +342,14 @@ func Test_RuntimeWorkflow(t *testing.T) {
 		t.Error("Final Synchronize() should return false as everything is resolved")
 	}
 
+	// The instance status is only written by the second phase.
+	if _, ok := instance.Unstructured().Object["status"]; ok {
+		t.Error("Synchronize() should not write the instance status")
+	}
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+
 	// Verify instance status updated
 	if instance.Unstructured().Object["status"].(map[string]interface{})["ready"] != true {
 		t.Error("Instance status not properly updated")
@@ -356,6 +364,356 @@ func Test_RuntimeWorkflow(t *testing.T) {
 	}
 }
 
+func Test_RuntimeSynchronizeStatus(t *testing.T) {
+	instance := newTestResource(
+		withObject(map[string]interface{}{}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "status.phase",
+					Expressions:          []string{"app.status.phase"},
+					StandaloneExpression: true,
+				},
+				Kind:         variable.ResourceVariableKindDynamic,
+				Dependencies: []string{"app"},
+			},
+		}),
+	)
+	app := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app"},
+		}),
+	)
+	rt, err := NewResourceGroupRuntime(instance, map[string]Resource{"app": app}, []string{"app"})
+	if err != nil {
+		t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+	observed := func(status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app"},
+			"status":   status,
+		}}
+	}
+
+	// The app is observed while being applied.
+	rt.SetResource("app", observed(map[string]interface{}{"phase": "Pending"}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if _, ok := instance.Unstructured().Object["status"]; ok {
+		t.Errorf("Synchronize() should not write the instance status")
+	}
+
+	// Once applied, the status is computed against its live state, not the
+	// state observed while it was being applied.
+	rt.SetResource("app", observed(map[string]interface{}{"phase": "Running"}))
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	status := instance.Unstructured().Object["status"].(map[string]interface{})
+	if got := status["phase"]; got != "Running" {
+		t.Errorf("status.phase = %v, want Running", got)
+	}
+}
+
+func Test_RuntimeUnresolvedStatusFields(t *testing.T) {
+	instance := newTestResource(
+		withObject(map[string]interface{}{}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "status.phase",
+					Expressions:          []string{"app.status.phase"},
+					StandaloneExpression: true,
+				},
+				Kind:         variable.ResourceVariableKindDynamic,
+				Dependencies: []string{"app"},
+			},
+		}),
+	)
+	app := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "app"},
+		}),
+	)
+	rt, err := NewResourceGroupRuntime(instance, map[string]Resource{"app": app}, []string{"app"})
+	if err != nil {
+		t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+
+	// The phase of the app isn't set yet.
+	rt.SetResource("app", &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app"},
+	}})
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	if got := rt.UnresolvedStatusFields(); !reflect.DeepEqual(got, []string{"status.phase"}) {
+		t.Errorf("UnresolvedStatusFields() = %v, want [status.phase]", got)
+	}
+
+	rt.SetResource("app", &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app"},
+		"status":   map[string]interface{}{"phase": "Running"},
+	}})
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	if got := rt.UnresolvedStatusFields(); len(got) != 0 {
+		t.Errorf("UnresolvedStatusFields() = %v, want none", got)
+	}
+}
+
+func Test_RuntimeReadyField(t *testing.T) {
+	// newRuntime returns a runtime for a graph where the app depends on the
+	// readiness of the database, and the instance reports the readiness of
+	// both.
+	newRuntime := func(t *testing.T) (*ResourceGroupRuntime, *mockResource, *mockResource) {
+		instance := newTestResource(
+			withObject(map[string]interface{}{}),
+			withVariables([]*variable.ResourceField{
+				{
+					FieldDescriptor: variable.FieldDescriptor{
+						Path:                 "status.databaseReady",
+						Expressions:          []string{"database.ready"},
+						StandaloneExpression: true,
+					},
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"database"},
+				},
+				{
+					FieldDescriptor: variable.FieldDescriptor{
+						Path:                 "status.ready",
+						Expressions:          []string{"database.ready && app.ready"},
+						StandaloneExpression: true,
+					},
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"database", "app"},
+				},
+			}),
+		)
+		database := newTestResource(
+			withObject(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "db"},
+			}),
+			withReadyExpressions([]string{`database.status.phase == "Ready"`}),
+		)
+		app := newTestResource(
+			withObject(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "app"},
+				"spec": map[string]interface{}{
+					"databaseReady": "${database.ready}",
+				},
+			}),
+			withVariables([]*variable.ResourceField{
+				{
+					FieldDescriptor: variable.FieldDescriptor{
+						Path:                 "spec.databaseReady",
+						Expressions:          []string{"database.ready"},
+						StandaloneExpression: true,
+					},
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"database"},
+				},
+			}),
+			withDependencies([]string{"database"}),
+			withReadyExpressions([]string{"app.status.available"}),
+		)
+
+		rt, err := NewResourceGroupRuntime(instance, map[string]Resource{
+			"database": database,
+			"app":      app,
+		}, []string{"database", "app"})
+		if err != nil {
+			t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+		}
+		return rt, instance, app
+	}
+	observed := func(name string, status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"status":   status,
+		}}
+	}
+	status := func(instance *mockResource) map[string]interface{} {
+		status, _ := instance.Unstructured().Object["status"].(map[string]interface{})
+		return status
+	}
+
+	// First reconciliation: the database isn't ready yet.
+	rt, instance, app := newRuntime(t)
+	rt.SetResource("database", observed("db", map[string]interface{}{"phase": "Pending"}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	if got := status(instance)["databaseReady"]; got != false {
+		t.Errorf("status.databaseReady = %v, want false", got)
+	}
+	if got := app.Unstructured().Object["spec"].(map[string]interface{})["databaseReady"]; got != false {
+		t.Errorf("app spec.databaseReady = %v, want false", got)
+	}
+	if _, ok := status(instance)["ready"]; ok {
+		t.Errorf("status.ready should not be resolved before the app is")
+	}
+	// The observed resource is left untouched.
+	if _, ok := rt.resolvedResources["database"].Object[ReadyField]; ok {
+		t.Errorf("the ready field should not be set on the observed resource")
+	}
+
+	// Second reconciliation: the database is ready, the app isn't.
+	rt, instance, app = newRuntime(t)
+	rt.SetResource("database", observed("db", map[string]interface{}{"phase": "Ready"}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if got := app.Unstructured().Object["spec"].(map[string]interface{})["databaseReady"]; got != true {
+		t.Errorf("app spec.databaseReady = %v, want true", got)
+	}
+	rt.SetResource("app", observed("app", map[string]interface{}{"available": false}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	if got := status(instance)["databaseReady"]; got != true {
+		t.Errorf("status.databaseReady = %v, want true", got)
+	}
+	if got := status(instance)["ready"]; got != false {
+		t.Errorf("status.ready = %v, want false", got)
+	}
+
+	// Third reconciliation: both are ready.
+	rt, instance, _ = newRuntime(t)
+	rt.SetResource("database", observed("db", map[string]interface{}{"phase": "Ready"}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	rt.SetResource("app", observed("app", map[string]interface{}{"available": true}))
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+	if err := rt.SynchronizeStatus(); err != nil {
+		t.Fatalf("SynchronizeStatus() error = %v", err)
+	}
+	if got := status(instance)["ready"]; got != true {
+		t.Errorf("status.ready = %v, want true", got)
+	}
+}
+
+func Test_ResourceProvenance(t *testing.T) {
+	instance := newTestResource(
+		withObject(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"name":     "app",
+				"replicas": int64(2),
+			},
+		}),
+	)
+	service := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "${schema.spec.name}-svc"},
+		}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:        "metadata.name",
+					Expressions: []string{"schema.spec.name"},
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+		}),
+	)
+	deployment := newTestResource(
+		withObject(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "${schema.spec.name}"},
+			"spec": map[string]interface{}{
+				"replicas": "${schema.spec.replicas}",
+				"service":  "${service.metadata.name}",
+			},
+		}),
+		withVariables([]*variable.ResourceField{
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "metadata.name",
+					Expressions:          []string{"schema.spec.name"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "spec.replicas",
+					Expressions:          []string{"schema.spec.replicas"},
+					StandaloneExpression: true,
+				},
+				Kind: variable.ResourceVariableKindStatic,
+			},
+			{
+				FieldDescriptor: variable.FieldDescriptor{
+					Path:                 "spec.service",
+					Expressions:          []string{"service.metadata.name"},
+					StandaloneExpression: true,
+				},
+				Kind:         variable.ResourceVariableKindDynamic,
+				Dependencies: []string{"service"},
+			},
+		}),
+		withDependencies([]string{"service"}),
+	)
+
+	rt, err := NewResourceGroupRuntime(instance, map[string]Resource{
+		"service":    service,
+		"deployment": deployment,
+	}, []string{"service", "deployment"})
+	if err != nil {
+		t.Fatalf("NewResourceGroupRuntime() error = %v", err)
+	}
+
+	want := map[string]resolver.FieldProvenance{
+		"metadata.name": {Path: "metadata.name", Expressions: []string{"schema.spec.name"}},
+	}
+	if got := rt.ResourceProvenance("service"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourceProvenance(service) = %v, want %v", got, want)
+	}
+	// The deployment can't be rendered until the service is created.
+	if got := rt.ResourceProvenance("deployment"); len(got) != 0 {
+		t.Errorf("ResourceProvenance(deployment) = %v, want none", got)
+	}
+
+	rt.SetResource("service", &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app-svc"},
+	}})
+	if _, err := rt.Synchronize(); err != nil {
+		t.Fatalf("Synchronize() error = %v", err)
+	}
+
+	want = map[string]resolver.FieldProvenance{
+		"metadata.name": {Path: "metadata.name", Expressions: []string{"schema.spec.name"}, StandaloneExpression: true},
+		"spec.replicas": {Path: "spec.replicas", Expressions: []string{"schema.spec.replicas"}, StandaloneExpression: true},
+		"spec.service":  {Path: "spec.service", Expressions: []string{"service.metadata.name"}, StandaloneExpression: true},
+	}
+	if got := rt.ResourceProvenance("deployment"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourceProvenance(deployment) = %v, want %v", got, want)
+	}
+
+	// The rendered resource doesn't carry the provenance.
+	rendered, _ := rt.GetResource("deployment")
+	wantRendered := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app"},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"service":  "app-svc",
+		},
+	}
+	if !reflect.DeepEqual(rendered.Object, wantRendered) {
+		t.Errorf("GetResource(deployment) = %v, want %v", rendered.Object, wantRendered)
+	}
+}
+
 func Test_NewResourceGroupRuntime(t *testing.T) {
 	// Setup a test instance with a spec
 	instance := newTestResource(
@@ -1529,6 +1887,82 @@ func Test_evaluateDynamicVariables(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "resources map exposes resolved dependencies",
+			expressionsCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:   `resources["res1"].metadata.name + "-" + resources.res2.metadata.name`,
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"res1", "res2"},
+					Resolved:     false,
+				},
+			},
+			resolvedResources: map[string]*unstructured.Unstructured{
+				"res1": {
+					Object: map[string]interface{}{
+						"metadata": map[string]interface{}{"name": "deployment"},
+					},
+				},
+				"res2": {
+					Object: map[string]interface{}{
+						"metadata": map[string]interface{}{"name": "service"},
+					},
+				},
+			},
+			wantCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:    `resources["res1"].metadata.name + "-" + resources.res2.metadata.name`,
+					Kind:          variable.ResourceVariableKindDynamic,
+					Dependencies:  []string{"res1", "res2"},
+					Resolved:      true,
+					ResolvedValue: "deployment-service",
+				},
+			},
+		},
+		{
+			name: "resources map only contains the dependencies",
+			expressionsCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:   "resources.map(id, id)",
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"res1"},
+					Resolved:     false,
+				},
+			},
+			resolvedResources: map[string]*unstructured.Unstructured{
+				"res1": {Object: map[string]interface{}{}},
+				"res2": {Object: map[string]interface{}{}},
+			},
+			wantCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:    "resources.map(id, id)",
+					Kind:          variable.ResourceVariableKindDynamic,
+					Dependencies:  []string{"res1"},
+					Resolved:      true,
+					ResolvedValue: []interface{}{"res1"},
+				},
+			},
+		},
+		{
+			name: "resources map with unresolved dependency",
+			expressionsCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:   `resources["res1"].spec.count > 0`,
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"res1"},
+					Resolved:     false,
+				},
+			},
+			resolvedResources: map[string]*unstructured.Unstructured{},
+			wantCache: map[string]*expressionEvaluationState{
+				"expr1": {
+					Expression:   `resources["res1"].spec.count > 0`,
+					Kind:         variable.ResourceVariableKindDynamic,
+					Dependencies: []string{"res1"},
+					Resolved:     false,
+				},
+			},
+		},
 		{
 			name: "multiple dependencies all resolved",
 			expressionsCache: map[string]*expressionEvaluationState{
@@ -2280,6 +2714,75 @@ func Test_IsResourceReady(t *testing.T) {
 		})
 	}
 }
+
+func Test_EvaluateResourceConditions(t *testing.T) {
+	provisioned := variable.ConditionExpression{Type: "DatabaseProvisioned", Expression: `test.status.state == "available"`}
+	encrypted := variable.ConditionExpression{Type: "Encrypted", Expression: "test.spec.encrypted"}
+
+	tests := []struct {
+		name           string
+		resource       Resource
+		resolvedObject map[string]interface{}
+		want           map[string]bool
+		wantErr        bool
+	}{
+		{
+			name:           "no conditions",
+			resource:       newTestResource(),
+			resolvedObject: map[string]interface{}{},
+			want:           nil,
+		},
+		{
+			name:     "resource not resolved",
+			resource: newTestResource(withConditionExpressions(provisioned)),
+			want:     nil,
+		},
+		{
+			name:     "conditions evaluated",
+			resource: newTestResource(withConditionExpressions(provisioned, encrypted)),
+			resolvedObject: map[string]interface{}{
+				"spec":   map[string]interface{}{"encrypted": true},
+				"status": map[string]interface{}{"state": "creating"},
+			},
+			want: map[string]bool{"DatabaseProvisioned": false, "Encrypted": true},
+		},
+		{
+			name:     "non boolean expression",
+			resource: newTestResource(withConditionExpressions(variable.ConditionExpression{Type: "State", Expression: "test.status.state"})),
+			resolvedObject: map[string]interface{}{
+				"status": map[string]interface{}{"state": "creating"},
+			},
+			wantErr: true,
+		},
+		{
+			name:           "missing field",
+			resource:       newTestResource(withConditionExpressions(provisioned)),
+			resolvedObject: map[string]interface{}{},
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := &ResourceGroupRuntime{
+				resources:         map[string]Resource{"test": tt.resource},
+				resolvedResources: map[string]*unstructured.Unstructured{},
+			}
+			if tt.resolvedObject != nil {
+				rt.resolvedResources["test"] = &unstructured.Unstructured{Object: tt.resolvedObject}
+			}
+
+			got, err := rt.EvaluateResourceConditions("test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvaluateResourceConditions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvaluateResourceConditions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_WantToCreateResource(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -2465,8 +2968,8 @@ func Test_areDependenciesIgnored(t *testing.T) {
 	}
 }
 
-func setupTestEnv(names []string) (*cel.Env, error) {
-	return krocel.DefaultEnvironment(krocel.WithResourceIDs(names))
+func setupTestEnv(names []string) (*environment, error) {
+	return newEnvironment(names, false, false)
 }
 
 func Test_evaluateExpression(t *testing.T) {
@@ -2611,7 +3114,9 @@ type mockResource struct {
 	dependencies     []string
 	readyExpressions []string
 	conditions       []string
+	conditionExprs   []variable.ConditionExpression
 	topLevelFields   []string
+	evaluateOnce     []string
 	namespaced       bool
 	obj              *unstructured.Unstructured
 }
@@ -2644,6 +3149,26 @@ func (m *mockResource) GetIncludeWhenExpressions() []string {
 	return m.conditions
 }
 
+func (m *mockResource) GetWaitFor() []string {
+	return nil
+}
+
+func (m *mockResource) GetMinReadyDuration() time.Duration {
+	return 0
+}
+
+func (m *mockResource) GetFinalizers() []string {
+	return nil
+}
+
+func (m *mockResource) GetEvaluateOnce() []string {
+	return m.evaluateOnce
+}
+
+func (m *mockResource) GetConditionExpressions() []variable.ConditionExpression {
+	return m.conditionExprs
+}
+
 func (m *mockResource) GetTopLevelFields() []string {
 	return m.topLevelFields
 }
@@ -2674,6 +3199,12 @@ func withVariables(vars []*variable.ResourceField) mockResourceOption {
 	}
 }
 
+func withEvaluateOnce(paths []string) mockResourceOption {
+	return func(m *mockResource) {
+		m.evaluateOnce = paths
+	}
+}
+
 func withDependencies(deps []string) mockResourceOption {
 	return func(m *mockResource) {
 		m.dependencies = deps
@@ -2686,6 +3217,12 @@ func withReadyExpressions(exprs []string) mockResourceOption {
 	}
 }
 
+func withConditionExpressions(conditions ...variable.ConditionExpression) mockResourceOption {
+	return func(m *mockResource) {
+		m.conditionExprs = conditions
+	}
+}
+
 func withConditions(conditions []string) mockResourceOption {
 	return func(m *mockResource) {
 		m.conditions = conditions
@@ -15,6 +15,7 @@ package generator
 
 import (
 	"encoding/json"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -74,6 +75,30 @@ func WithSchema(kind, version string, spec, status map[string]interface{}) Resou
 	}
 }
 
+// WithFeatureGates sets the feature gates of the ResourceGroup. It must be
+// applied after WithSchema.
+func WithFeatureGates(gates ...krov1alpha1.FeatureGate) ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		rg.Spec.Schema.FeatureGates = gates
+	}
+}
+
+// WithUnknownFields sets the policy applied to the unknown fields of the
+// instance spec. It must be applied after WithSchema.
+func WithUnknownFields(policy krov1alpha1.UnknownFieldsPolicy) ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		rg.Spec.Schema.UnknownFields = policy
+	}
+}
+
+// WithPausable declares the spec.paused field in the instances. It must be
+// applied after WithSchema.
+func WithPausable() ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		rg.Spec.Schema.Pausable = true
+	}
+}
+
 // WithResource adds a resource to the ResourceGroup with the given name and definition
 // readyWhen and includeWhen expressions are optional.
 func WithResource(
@@ -98,3 +123,39 @@ func WithResource(
 		})
 	}
 }
+
+// WithResourceConditions sets the named conditions of the resource with the
+// given id. It must be applied after the WithResource adding the resource.
+func WithResourceConditions(id string, conditions ...krov1alpha1.ResourceCondition) ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		for _, resource := range rg.Spec.Resources {
+			if resource.ID == id {
+				resource.Conditions = conditions
+			}
+		}
+	}
+}
+
+// WithResourceMinReadyDuration sets the minReadyDuration of the resource with
+// the given id. It must be applied after the WithResource adding the resource.
+func WithResourceMinReadyDuration(id string, minReadyDuration time.Duration) ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		for _, resource := range rg.Spec.Resources {
+			if resource.ID == id {
+				resource.MinReadyDuration = &metav1.Duration{Duration: minReadyDuration}
+			}
+		}
+	}
+}
+
+// WithResourceWaitFor sets the resources the resource with the given id waits
+// for. It must be applied after the WithResource adding the resource.
+func WithResourceWaitFor(id string, waitFor ...string) ResourceGroupOption {
+	return func(rg *krov1alpha1.ResourceGroup) {
+		for _, resource := range rg.Spec.Resources {
+			if resource.ID == id {
+				resource.WaitFor = waitFor
+			}
+		}
+	}
+}
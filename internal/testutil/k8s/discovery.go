@@ -385,6 +385,36 @@ func NewFakeResolver() (*FakeResolver, *fake.FakeDiscovery) {
 				},
 			},
 		},
+		{Version: "v1", Kind: "ConfigMap"}: {
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"apiVersion": {SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+					"kind":       {SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+					"metadata":   metadataSchema(),
+					"data": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Allows: true,
+								Schema: &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+							},
+						},
+					},
+					"immutable": {SchemaProps: spec.SchemaProps{Type: []string{"boolean"}}},
+				},
+			},
+		},
+		{Version: "v1", Kind: "Namespace"}: {
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"apiVersion": {SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+					"kind":       {SchemaProps: spec.SchemaProps{Type: []string{"string"}}},
+					"metadata":   metadataSchema(),
+				},
+			},
+		},
 		// CRDs
 		{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}: {
 			SchemaProps: spec.SchemaProps{
@@ -488,6 +518,18 @@ func NewFakeResolver() (*FakeResolver, *fake.FakeDiscovery) {
 					Kind:       "Pod",
 					Verbs:      []string{"get", "list", "watch", "create", "update", "patch", "delete"},
 				},
+				{
+					Name:       "configmaps",
+					Namespaced: true,
+					Kind:       "ConfigMap",
+					Verbs:      []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+				},
+				{
+					Name:       "namespaces",
+					Namespaced: false,
+					Kind:       "Namespace",
+					Verbs:      []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+				},
 			},
 		},
 		// CRD
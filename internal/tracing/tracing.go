@@ -0,0 +1,90 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package tracing configures the OpenTelemetry tracer provider used by the
+// kro controllers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// DefaultServiceName is the service name reported in the traces exported by
+// kro when none is configured.
+const DefaultServiceName = "kro"
+
+// Config holds the configuration of the tracing pipeline.
+type Config struct {
+	// Enabled turns on the export of traces. When disabled, the global tracer
+	// provider is left untouched and spans are not recorded.
+	Enabled bool
+	// OTLPEndpoint is the host:port of the OTLP gRPC collector receiving the
+	// traces.
+	OTLPEndpoint string
+	// Insecure disables the transport security of the connection to the
+	// collector.
+	Insecure bool
+	// ServiceName is the service name reported in the exported traces.
+	ServiceName string
+}
+
+// ShutdownFunc flushes the pending spans and releases the resources held by
+// the tracing pipeline.
+type ShutdownFunc func(context.Context) error
+
+// Setup configures the global OpenTelemetry tracer provider and propagator
+// according to the given configuration. The returned function must be called
+// before the process exits to flush the pending spans.
+func Setup(ctx context.Context, cfg Config) (ShutdownFunc, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.OTLPEndpoint == "" {
+		return nil, fmt.Errorf("an OTLP endpoint is required when tracing is enabled")
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.Insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,194 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-logr/logr"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/awslabs/kro/internal/conversion"
+)
+
+const (
+	// ConversionPath is the path the conversion webhook is served on.
+	ConversionPath = "/convert"
+)
+
+// ConversionWebhookConfig holds the information the API server needs to
+// reach the conversion webhook.
+type ConversionWebhookConfig struct {
+	// ServiceName is the name of the service exposing the webhook server.
+	ServiceName string
+	// ServiceNamespace is the namespace of the service exposing the webhook
+	// server.
+	ServiceNamespace string
+	// ServicePort is the port of the service exposing the webhook server.
+	ServicePort int32
+	// CABundle is the PEM encoded CA bundle used to validate the webhook
+	// server certificate. It can be left empty when it is injected by an
+	// external tool (e.g cert-manager).
+	CABundle []byte
+}
+
+// ConversionWebhook is an http.Handler serving ConversionReview requests
+// for the kinds generated from resource groups.
+//
+// Converters are registered per kind by the ConverterReconciler, on every
+// replica serving the webhook, and are used to convert instances between the
+// versions of their kind.
+type ConversionWebhook struct {
+	log    logr.Logger
+	config ConversionWebhookConfig
+	// converters maps a schema.GroupKind to its *registeredConverter.
+	converters sync.Map
+}
+
+// registeredConverter is a converter along with the UID of the resource group
+// it was registered for.
+type registeredConverter struct {
+	owner     types.UID
+	converter *conversion.Converter
+}
+
+// NewConversionWebhook creates a new ConversionWebhook.
+func NewConversionWebhook(log logr.Logger, config ConversionWebhookConfig) *ConversionWebhook {
+	return &ConversionWebhook{
+		log:    log.WithName("conversion-webhook"),
+		config: config,
+	}
+}
+
+// RegisterConverter registers the converter used for the given kind, on
+// behalf of the resource group with the given UID. It replaces any previously
+// registered converter.
+func (w *ConversionWebhook) RegisterConverter(gk schema.GroupKind, owner types.UID, converter *conversion.Converter) {
+	w.converters.Store(gk, &registeredConverter{owner: owner, converter: converter})
+}
+
+// UnregisterConverter removes the converter registered for the given kind, if
+// it was registered on behalf of the resource group with the given UID. The
+// converter of another resource group is left untouched.
+func (w *ConversionWebhook) UnregisterConverter(gk schema.GroupKind, owner types.UID) {
+	if registered, ok := w.converters.Load(gk); ok && registered.(*registeredConverter).owner == owner {
+		w.converters.CompareAndDelete(gk, registered)
+	}
+}
+
+// CustomResourceConversion returns the conversion configuration to set on
+// the CRDs served by this webhook.
+func (w *ConversionWebhook) CustomResourceConversion() *extv1.CustomResourceConversion {
+	path := ConversionPath
+	port := w.config.ServicePort
+	return &extv1.CustomResourceConversion{
+		Strategy: extv1.WebhookConverter,
+		Webhook: &extv1.WebhookConversion{
+			ClientConfig: &extv1.WebhookClientConfig{
+				Service: &extv1.ServiceReference{
+					Namespace: w.config.ServiceNamespace,
+					Name:      w.config.ServiceName,
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: w.config.CABundle,
+			},
+			ConversionReviewVersions: []string{"v1"},
+		},
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (w *ConversionWebhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	review := &extv1.ConversionReview{}
+	if err := json.NewDecoder(req.Body).Decode(review); err != nil {
+		w.log.Error(err, "failed to decode conversion review")
+		http.Error(rw, fmt.Sprintf("failed to decode conversion review: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(rw, "conversion review has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = w.convert(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(review); err != nil {
+		w.log.Error(err, "failed to encode conversion review")
+	}
+}
+
+// convert converts all the objects of the request to the desired version.
+func (w *ConversionWebhook) convert(req *extv1.ConversionRequest) *extv1.ConversionResponse {
+	desiredGV, err := schema.ParseGroupVersion(req.DesiredAPIVersion)
+	if err != nil {
+		return conversionFailure(fmt.Errorf("invalid desired apiVersion %s: %w", req.DesiredAPIVersion, err))
+	}
+
+	convertedObjects := make([]runtime.RawExtension, 0, len(req.Objects))
+	for _, raw := range req.Objects {
+		instance := &unstructured.Unstructured{}
+		if err := instance.UnmarshalJSON(raw.Raw); err != nil {
+			return conversionFailure(fmt.Errorf("failed to unmarshal object: %w", err))
+		}
+
+		gk := instance.GroupVersionKind().GroupKind()
+		if gk.Group != desiredGV.Group {
+			return conversionFailure(fmt.Errorf("cannot convert %s to group %s", gk, desiredGV.Group))
+		}
+		converter, ok := w.converters.Load(gk)
+		if !ok {
+			return conversionFailure(fmt.Errorf("no converter registered for %s", gk))
+		}
+
+		converted, err := converter.(*registeredConverter).converter.Convert(instance, desiredGV.Version)
+		if err != nil {
+			return conversionFailure(fmt.Errorf("failed to convert %s %s/%s: %w",
+				gk, instance.GetNamespace(), instance.GetName(), err))
+		}
+
+		convertedRaw, err := converted.MarshalJSON()
+		if err != nil {
+			return conversionFailure(fmt.Errorf("failed to marshal converted object: %w", err))
+		}
+		convertedObjects = append(convertedObjects, runtime.RawExtension{Raw: convertedRaw})
+	}
+
+	return &extv1.ConversionResponse{
+		ConvertedObjects: convertedObjects,
+		Result: metav1.Status{
+			Status: metav1.StatusSuccess,
+		},
+	}
+}
+
+func conversionFailure(err error) *extv1.ConversionResponse {
+	return &extv1.ConversionResponse{
+		Result: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/conversion"
+)
+
+func TestConversionWebhook_ServeHTTP(t *testing.T) {
+	converter, err := conversion.NewConverter([]v1alpha1.ConversionRule{{
+		From: "v1alpha1",
+		To:   "v1alpha2",
+		Spec: runtime.RawExtension{Raw: []byte(`{"replicaCount": "${schema.spec.replicas}"}`)},
+	}})
+	require.NoError(t, err)
+
+	w := NewConversionWebhook(logr.Discard(), ConversionWebhookConfig{})
+	w.RegisterConverter(schema.GroupKind{Group: "kro.run", Kind: "WebApp"}, "rg-uid", converter)
+
+	instance := []byte(`{
+		"apiVersion": "kro.run/v1alpha1",
+		"kind": "WebApp",
+		"metadata": {"name": "my-app", "namespace": "default"},
+		"spec": {"replicas": 3}
+	}`)
+	unknownKind := []byte(`{"apiVersion": "kro.run/v1alpha1", "kind": "Unknown", "metadata": {"name": "foo"}}`)
+
+	tests := []struct {
+		name        string
+		objects     [][]byte
+		wantStatus  string
+		wantSpecs   []interface{}
+		wantMessage string
+	}{
+		{
+			name:       "converts renamed field",
+			objects:    [][]byte{instance},
+			wantStatus: metav1.StatusSuccess,
+			wantSpecs:  []interface{}{map[string]interface{}{"replicaCount": int64(3)}},
+		},
+		{
+			name:        "fails for kinds without a converter",
+			objects:     [][]byte{instance, unknownKind},
+			wantStatus:  metav1.StatusFailure,
+			wantMessage: "no converter registered for Unknown.kro.run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := extv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+				Request: &extv1.ConversionRequest{
+					UID:               "uid",
+					DesiredAPIVersion: "kro.run/v1alpha2",
+				},
+			}
+			for _, object := range tt.objects {
+				review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: object})
+			}
+			body, err := json.Marshal(review)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			w.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ConversionPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			got := extv1.ConversionReview{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			require.NotNil(t, got.Response)
+			assert.Equal(t, "uid", string(got.Response.UID))
+			assert.Equal(t, tt.wantStatus, got.Response.Result.Status)
+			assert.Contains(t, got.Response.Result.Message, tt.wantMessage)
+
+			require.Len(t, got.Response.ConvertedObjects, len(tt.wantSpecs))
+			for i, raw := range got.Response.ConvertedObjects {
+				converted := &unstructured.Unstructured{}
+				require.NoError(t, converted.UnmarshalJSON(raw.Raw))
+				assert.Equal(t, "kro.run/v1alpha2", converted.GetAPIVersion())
+				assert.Equal(t, tt.wantSpecs[i], converted.Object["spec"])
+			}
+		})
+	}
+}
+
+func TestConversionWebhook_ServeHTTPBadRequest(t *testing.T) {
+	w := NewConversionWebhook(logr.Discard(), ConversionWebhookConfig{})
+
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ConversionPath, bytes.NewReader([]byte(`{}`))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
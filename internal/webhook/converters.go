@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/gobuffalo/flect"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/conversion"
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// converterRequeueDelay is the delay after which a resource group whose CRD
+// isn't owned by it yet, e.g. because the leader didn't create it yet, is
+// reconciled again.
+const converterRequeueDelay = 10 * time.Second
+
+// CRDGetter gets the CRDs by name.
+type CRDGetter interface {
+	Get(ctx context.Context, name string) (*extv1.CustomResourceDefinition, error)
+}
+
+// ConverterReconciler registers the converters of the resource groups with
+// the conversion webhook. The webhook is served by every replica, so the
+// reconciler runs on every replica too, regardless of the leader election.
+//
+// A converter is only registered once the CRD of the resource group kind is
+// labeled with the resource group UID, that is once the leader accepted the
+// resource group as the owner of the kind. A resource group rejected because
+// its kind is already used by another resource group never replaces the
+// converter of the owner.
+type ConverterReconciler struct {
+	client  client.Client
+	crds    CRDGetter
+	webhook *ConversionWebhook
+	log     logr.Logger
+
+	mu sync.Mutex
+	// registered maps the resource group names to the converter registered
+	// on their behalf.
+	registered map[string]registration
+}
+
+// registration identifies a converter registered on behalf of a resource
+// group.
+type registration struct {
+	gk         schema.GroupKind
+	owner      types.UID
+	generation int64
+}
+
+// NewConverterReconciler creates a new ConverterReconciler.
+func NewConverterReconciler(log logr.Logger, c client.Client, crds CRDGetter, webhook *ConversionWebhook) *ConverterReconciler {
+	return &ConverterReconciler{
+		client:     c,
+		crds:       crds,
+		webhook:    webhook,
+		log:        log.WithName("conversion-webhook-converters"),
+		registered: make(map[string]registration),
+	}
+}
+
+// SetupWithManager sets up the reconciler with the Manager.
+func (r *ConverterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	needLeaderElection := false
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("conversion-webhook-converters").
+		For(&v1alpha1.ResourceGroup{}).
+		WithOptions(controller.Options{NeedLeaderElection: &needLeaderElection}).
+		Complete(r)
+}
+
+// Reconcile registers, or unregisters, the converter of a resource group.
+func (r *ConverterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.log.WithValues("resourcegroup", req.Name)
+
+	rg := &v1alpha1.ResourceGroup{}
+	if err := r.client.Get(ctx, req.NamespacedName, rg); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.unregister(req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if rg.Spec.Schema == nil || len(rg.Spec.Schema.Conversion) == 0 {
+		r.unregister(rg.Name)
+		return ctrl.Result{}, nil
+	}
+
+	crdName := fmt.Sprintf("%s.%s", flect.Pluralize(strings.ToLower(rg.Spec.Schema.Kind)), v1alpha1.KroDomainName)
+	crd, err := r.crds.Get(ctx, crdName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("CRD not created yet, not registering the converter", "crd", crdName)
+			return ctrl.Result{RequeueAfter: converterRequeueDelay}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get CRD %s: %w", crdName, err)
+	}
+	if owner := crd.Labels[metadata.ResourceGroupIDLabel]; owner != string(rg.UID) {
+		log.V(1).Info("CRD not owned by the resource group, not registering the converter", "crd", crdName,
+			"owner", crd.Labels[metadata.ResourceGroupNameLabel])
+		r.unregister(rg.Name)
+		return ctrl.Result{RequeueAfter: converterRequeueDelay}, nil
+	}
+
+	gk := schema.GroupKind{Group: crd.Spec.Group, Kind: crd.Spec.Names.Kind}
+	if r.isRegistered(rg, gk) {
+		return ctrl.Result{}, nil
+	}
+	// The rules were validated by the graph builder before the leader wrote
+	// the CRD, they only fail to compile if the resource group changed since.
+	converter, err := conversion.NewConverter(rg.Spec.Schema.Conversion)
+	if err != nil {
+		log.Error(err, "invalid conversion rules, not registering the converter")
+		r.unregister(rg.Name)
+		return ctrl.Result{}, nil
+	}
+	r.register(rg, gk, converter)
+	log.V(1).Info("registered converter", "kind", gk)
+	return ctrl.Result{}, nil
+}
+
+// isRegistered returns whether the converter of the current generation of the
+// resource group is registered for the given kind.
+func (r *ConverterReconciler) isRegistered(rg *v1alpha1.ResourceGroup, gk schema.GroupKind) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	registered, ok := r.registered[rg.Name]
+	return ok && registered == registration{gk: gk, owner: rg.UID, generation: rg.Generation}
+}
+
+// register registers the converter of the resource group for the given kind.
+func (r *ConverterReconciler) register(rg *v1alpha1.ResourceGroup, gk schema.GroupKind, converter *conversion.Converter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if previous, ok := r.registered[rg.Name]; ok && previous.gk != gk {
+		r.webhook.UnregisterConverter(previous.gk, previous.owner)
+	}
+	r.webhook.RegisterConverter(gk, rg.UID, converter)
+	r.registered[rg.Name] = registration{gk: gk, owner: rg.UID, generation: rg.Generation}
+}
+
+// unregister unregisters the converter registered on behalf of the resource
+// group with the given name, if any.
+func (r *ConverterReconciler) unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registered, ok := r.registered[name]; ok {
+		r.webhook.UnregisterConverter(registered.gk, registered.owner)
+		delete(r.registered, name)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/awslabs/kro/api/v1alpha1"
+	"github.com/awslabs/kro/internal/conversion"
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// fakeCRDs is a CRDGetter serving the CRDs of a map.
+type fakeCRDs map[string]*extv1.CustomResourceDefinition
+
+func (f fakeCRDs) Get(_ context.Context, name string) (*extv1.CustomResourceDefinition, error) {
+	crd, ok := f[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}, name)
+	}
+	return crd, nil
+}
+
+func newConvertedResourceGroup(name string, uid types.UID) *v1alpha1.ResourceGroup {
+	return &v1alpha1.ResourceGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: uid, Generation: 1},
+		Spec: v1alpha1.ResourceGroupSpec{
+			Schema: &v1alpha1.Schema{
+				Kind:       "WebApp",
+				APIVersion: "v1alpha2",
+				Conversion: []v1alpha1.ConversionRule{{
+					From: "v1alpha1",
+					To:   "v1alpha2",
+					Spec: runtime.RawExtension{Raw: []byte(`{"replicaCount": "${schema.spec.replicas}"}`)},
+				}},
+			},
+		},
+	}
+}
+
+func newOwnedCRD(owner types.UID) *extv1.CustomResourceDefinition {
+	return &extv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "webapps.kro.run",
+			Labels: map[string]string{metadata.ResourceGroupIDLabel: string(owner)},
+		},
+		Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "kro.run",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "WebApp"},
+		},
+	}
+}
+
+func TestConverterReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	gk := schema.GroupKind{Group: "kro.run", Kind: "WebApp"}
+
+	owner := newConvertedResourceGroup("webapp", "owner-uid")
+	// duplicate declares the same kind as owner, it is rejected by the
+	// leader.
+	duplicate := newConvertedResourceGroup("webapp-duplicate", "duplicate-uid")
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(owner, duplicate).Build()
+	crds := fakeCRDs{}
+	w := NewConversionWebhook(logr.Discard(), ConversionWebhookConfig{})
+	r := NewConverterReconciler(logr.Discard(), fakeClient, crds, w)
+	ctx := context.Background()
+	reconcile := func(rg *v1alpha1.ResourceGroup) ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(rg)})
+		require.NoError(t, err)
+		return result
+	}
+	registeredOwner := func() types.UID {
+		registered, ok := w.converters.Load(gk)
+		if !ok {
+			return ""
+		}
+		return registered.(*registeredConverter).owner
+	}
+
+	// The converter isn't registered until the leader created the CRD.
+	result := reconcile(owner)
+	assert.Equal(t, converterRequeueDelay, result.RequeueAfter)
+	assert.Empty(t, registeredOwner())
+
+	crds["webapps.kro.run"] = newOwnedCRD(owner.UID)
+	reconcile(owner)
+	assert.Equal(t, owner.UID, registeredOwner())
+
+	// A resource group declaring the same kind doesn't replace the converter
+	// of the owner of the CRD.
+	result = reconcile(duplicate)
+	assert.Equal(t, converterRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, owner.UID, registeredOwner())
+
+	// Deleting the rejected resource group leaves the converter of the owner.
+	require.NoError(t, fakeClient.Delete(ctx, duplicate))
+	reconcile(duplicate)
+	assert.Equal(t, owner.UID, registeredOwner())
+
+	// Deleting the owner unregisters its converter.
+	require.NoError(t, fakeClient.Delete(ctx, owner))
+	reconcile(owner)
+	assert.Empty(t, registeredOwner())
+}
+
+func TestConversionWebhook_UnregisterConverter(t *testing.T) {
+	converter, err := conversion.NewConverter(nil)
+	require.NoError(t, err)
+	gk := schema.GroupKind{Group: "kro.run", Kind: "WebApp"}
+
+	w := NewConversionWebhook(logr.Discard(), ConversionWebhookConfig{})
+	w.RegisterConverter(gk, "owner-uid", converter)
+
+	w.UnregisterConverter(gk, "other-uid")
+	_, ok := w.converters.Load(gk)
+	assert.True(t, ok, "a resource group must not unregister the converter of another one")
+
+	w.UnregisterConverter(gk, "owner-uid")
+	_, ok = w.converters.Load(gk)
+	assert.False(t, ok)
+}
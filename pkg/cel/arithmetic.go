@@ -0,0 +1,155 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Arithmetic returns a CEL library that provides integer arithmetic with
+// explicit rounding, e.g. to compute replica counts as a share of a total
+// without the silent truncation of int(double(total) * percent / 100.0).
+//
+// The following functions are available:
+//
+//	percentOf(total, percent, mode) - percent % of total, rounded to an int
+//
+// percent is an int or a double, mode is one of "ceil", "floor" and "round",
+// the latter rounding halves away from zero. percentOf reports an error for
+// negative inputs, unknown modes and results overflowing an int.
+//
+// Examples:
+//
+//	percentOf(10, 25, "ceil")                    // 3
+//	percentOf(10, 25, "floor")                   // 2
+//	percentOf(10, 25, "round")                   // 3
+//	percentOf(7, 12.5, "floor")                  // 0
+//	percentOf(schema.spec.replicas, -10, "ceil") // error: percentOf: negative percent -10
+func Arithmetic() cel.EnvOption {
+	return cel.Lib(&arithmeticLib{})
+}
+
+type arithmeticLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*arithmeticLib) LibraryName() string {
+	return "kro.arithmetic"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*arithmeticLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("percentOf",
+			cel.Overload("kro_percent_of_int_int_string",
+				[]*cel.Type{cel.IntType, cel.IntType, cel.StringType}, cel.IntType,
+				cel.FunctionBinding(percentOf),
+			),
+			cel.Overload("kro_percent_of_int_double_string",
+				[]*cel.Type{cel.IntType, cel.DoubleType, cel.StringType}, cel.IntType,
+				cel.FunctionBinding(percentOf),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*arithmeticLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// Rounding modes of percentOf.
+const (
+	roundingCeil  = "ceil"
+	roundingFloor = "floor"
+	roundingRound = "round"
+)
+
+// percentOf computes a percentage of a total, rounded to an int with the
+// given mode.
+func percentOf(args ...ref.Val) ref.Val {
+	if len(args) != 3 {
+		return types.NewErr("percentOf: expected 3 arguments, got %d", len(args))
+	}
+	total, ok := args[0].(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(args[0])
+	}
+	mode, ok := args[2].(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(args[2])
+	}
+	switch mode {
+	case roundingCeil, roundingFloor, roundingRound:
+	default:
+		return types.NewErr("percentOf: unknown rounding mode %q, expected %q, %q or %q",
+			string(mode), roundingCeil, roundingFloor, roundingRound)
+	}
+	if total < 0 {
+		return types.NewErr("percentOf: negative total %d", total)
+	}
+
+	switch percent := args[1].(type) {
+	case types.Int:
+		if percent < 0 {
+			return types.NewErr("percentOf: negative percent %d", percent)
+		}
+		return percentOfInt(int64(total), int64(percent), string(mode))
+	case types.Double:
+		if !(percent >= 0) || math.IsInf(float64(percent), 0) {
+			return types.NewErr("percentOf: percent %v is not a non-negative number", float64(percent))
+		}
+		return percentOfDouble(int64(total), float64(percent), string(mode))
+	default:
+		return types.MaybeNoSuchOverloadErr(args[1])
+	}
+}
+
+// percentOfInt computes an int percentage of a total without going through
+// doubles, to avoid their rounding errors.
+func percentOfInt(total, percent int64, mode string) ref.Val {
+	if percent != 0 && total > math.MaxInt64/percent {
+		return types.NewErr("percentOf: %d%% of %d overflows an int", percent, total)
+	}
+	product := total * percent
+	quotient, remainder := product/100, product%100
+	switch {
+	case mode == roundingCeil && remainder > 0:
+		quotient++
+	case mode == roundingRound && remainder >= 50:
+		quotient++
+	}
+	return types.Int(quotient)
+}
+
+// percentOfDouble computes a double percentage of a total.
+func percentOfDouble(total int64, percent float64, mode string) ref.Val {
+	value := float64(total) * percent / 100
+	switch mode {
+	case roundingCeil:
+		value = math.Ceil(value)
+	case roundingFloor:
+		value = math.Floor(value)
+	case roundingRound:
+		value = math.Round(value)
+	}
+	// MaxInt64 isn't representable as a double, the closest one is 2^63.
+	if value >= math.MaxInt64 {
+		return types.NewErr("percentOf: %v%% of %d overflows an int", percent, total)
+	}
+	return types.Int(value)
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       interface{}
+		wantErr    string
+	}{
+		// rounding modes
+		{name: "ceil", expression: `percentOf(10, 25, "ceil")`, want: int64(3)},
+		{name: "floor", expression: `percentOf(10, 25, "floor")`, want: int64(2)},
+		{name: "round half up", expression: `percentOf(10, 25, "round")`, want: int64(3)},
+		{name: "round down", expression: `percentOf(10, 24, "round")`, want: int64(2)},
+		{name: "exact percentage", expression: `percentOf(8, 50, "ceil")`, want: int64(4)},
+		{name: "zero percent", expression: `percentOf(8, 0, "ceil")`, want: int64(0)},
+		{name: "more than a hundred percent", expression: `percentOf(8, 150, "floor")`, want: int64(12)},
+		{name: "double percent ceil", expression: `percentOf(7, 12.5, "ceil")`, want: int64(1)},
+		{name: "double percent floor", expression: `percentOf(7, 12.5, "floor")`, want: int64(0)},
+		{name: "double percent round", expression: `percentOf(20, 12.5, "round")`, want: int64(3)},
+
+		// errors
+		{name: "negative total", expression: `percentOf(-10, 25, "ceil")`, wantErr: "percentOf: negative total -10"},
+		{name: "negative percent", expression: `percentOf(10, -25, "floor")`, wantErr: "percentOf: negative percent -25"},
+		{name: "negative double percent", expression: `percentOf(10, -2.5, "round")`, wantErr: "percentOf: percent -2.5 is not a non-negative number"},
+		{name: "unknown mode", expression: `percentOf(10, 25, "up")`, wantErr: `percentOf: unknown rounding mode "up"`},
+		{name: "int overflow", expression: `percentOf(9223372036854775807, 200, "floor")`, wantErr: "overflows an int"},
+		{name: "double overflow", expression: `percentOf(9223372036854775807, 200.0, "floor")`, wantErr: "overflows an int"},
+
+		// combinations
+		{
+			name:       "surge replicas",
+			expression: `percentOf(schema.spec.replicas, schema.spec.maxSurge, "ceil")`,
+			vars: map[string]interface{}{"schema": map[string]interface{}{"spec": map[string]interface{}{
+				"replicas": 3,
+				"maxSurge": 25,
+			}}},
+			want: int64(1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithArithmeticFunctions(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArithmeticDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `percentOf(10, 25, "ceil")`, nil)
+	assert.Error(t, err)
+}
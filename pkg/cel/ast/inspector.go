@@ -209,6 +209,15 @@ func (a *Inspector) inspectCall(call *exprpb.Expr_Call, currentPath string) Expr
 		inspection.UnknownFunctions = append(inspection.UnknownFunctions, argInspection.UnknownFunctions...)
 	}
 
+	// Functions declared in a namespace of the environment (e.g acme.hash(x))
+	// are parsed as calls on a target, they are handled like global functions.
+	if call.Target != nil {
+		if name := a.exprToString(call.Target) + "." + call.Function; a.isDeclaredFunction(name) {
+			inspection.FunctionCalls = append(inspection.FunctionCalls, FunctionCall{Name: name})
+			return inspection
+		}
+	}
+
 	// Handle the current function - only if it's not part of a chain
 	if _, isFunction := a.functions[call.Function]; isFunction && call.Target == nil {
 		functionCall := FunctionCall{
@@ -232,7 +241,7 @@ func (a *Inspector) inspectCall(call *exprpb.Expr_Call, currentPath string) Expr
 		inspection.FunctionCalls = append(inspection.FunctionCalls, FunctionCall{
 			Name: fmt.Sprintf("%s.%s", a.exprToString(call.Target), call.Function),
 		})
-	} else if !isInternalFunction(call.Function) {
+	} else if !isInternalFunction(call.Function) && !a.isDeclaredFunction(call.Function) {
 		// This is an unknown function, but not an internal one
 		inspection.UnknownFunctions = append(inspection.UnknownFunctions, UnknownFunction{Name: call.Function})
 	}
@@ -312,6 +321,21 @@ func (a *Inspector) inspectComprehension(comp *exprpb.Expr_Comprehension, curren
 	inspection.UnknownResources = append(inspection.UnknownResources, iterRangeInspection.UnknownResources...)
 	inspection.UnknownFunctions = append(inspection.UnknownFunctions, iterRangeInspection.UnknownFunctions...)
 
+	// Inspect the initial value of the accumulator. It's empty for the
+	// standard macros, but holds the bound value of macros such as sortBy.
+	// The accumulator variable is only in scope in the loop and the result.
+	if comp.AccuInit != nil {
+		accuInitInspection := a.inspectAst(comp.AccuInit, "")
+		inspection.ResourceDependencies = append(inspection.ResourceDependencies, accuInitInspection.ResourceDependencies...)
+		inspection.FunctionCalls = append(inspection.FunctionCalls, accuInitInspection.FunctionCalls...)
+		inspection.UnknownResources = append(inspection.UnknownResources, accuInitInspection.UnknownResources...)
+		inspection.UnknownFunctions = append(inspection.UnknownFunctions, accuInitInspection.UnknownFunctions...)
+	}
+	if _, shadowed := a.loopVars[comp.AccuVar]; !shadowed {
+		a.loopVars[comp.AccuVar] = struct{}{}
+		defer delete(a.loopVars, comp.AccuVar)
+	}
+
 	// For filters, inspect the condition
 	if comp.LoopCondition != nil {
 		conditionInspection := a.inspectAst(comp.LoopCondition, "")
@@ -496,6 +520,12 @@ func isInternalIdentifier(name string) bool {
 	return name == "__result__" || strings.HasPrefix(name, "$$")
 }
 
+// isDeclaredFunction returns true if the function is declared in the CEL
+// environment of the inspector, e.g by a kro or a custom library.
+func (a *Inspector) isDeclaredFunction(name string) bool {
+	return a.env != nil && a.env.HasFunction(name)
+}
+
 func isInternalFunction(name string) bool {
 	internalFunctions := map[string]bool{
 		"_+_":     true,
@@ -16,6 +16,10 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+
+	"github.com/google/cel-go/cel"
+
+	krocel "github.com/awslabs/kro/pkg/cel"
 )
 
 func TestInspector_InspectionResults(t *testing.T) {
@@ -526,3 +530,79 @@ func Test_InvalidExpression(t *testing.T) {
 		t.Errorf("Expected error")
 	}
 }
+
+func TestInspector_DeclaredFunctions(t *testing.T) {
+	env, err := cel.NewEnv(
+		cel.Variable("config", cel.AnyType),
+		cel.Function("shout", cel.Overload("shout_string", []*cel.Type{cel.StringType}, cel.StringType)),
+		cel.Function("acme.shout", cel.Overload("acme_shout_string", []*cel.Type{cel.StringType}, cel.StringType)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create environment: %v", err)
+	}
+	inspector := NewInspectorWithEnv(env, []string{"config"}, nil)
+
+	tests := []struct {
+		expression    string
+		wantResources []ResourceDependency
+		wantFunctions []string
+	}{
+		{
+			expression:    `shout(config.spec.name)`,
+			wantResources: []ResourceDependency{{ID: "config", Path: "config.spec.name"}},
+		},
+		{
+			expression:    `acme.shout(config.spec.name)`,
+			wantResources: []ResourceDependency{{ID: "config", Path: "config.spec.name"}},
+			wantFunctions: []string{"acme.shout"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := inspector.Inspect(tt.expression)
+			if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+			if len(got.UnknownFunctions) != 0 || len(got.UnknownResources) != 0 {
+				t.Errorf("unexpected unknown functions %v or resources %v", got.UnknownFunctions, got.UnknownResources)
+			}
+			if !reflect.DeepEqual(got.ResourceDependencies, tt.wantResources) {
+				t.Errorf("ResourceDependencies = %v, want %v", got.ResourceDependencies, tt.wantResources)
+			}
+			var gotFunctions []string
+			for _, f := range got.FunctionCalls {
+				gotFunctions = append(gotFunctions, f.Name)
+			}
+			if !reflect.DeepEqual(gotFunctions, tt.wantFunctions) {
+				t.Errorf("FunctionCalls = %v, want %v", gotFunctions, tt.wantFunctions)
+			}
+		})
+	}
+}
+
+func TestInspector_SortMacros(t *testing.T) {
+	env, err := krocel.DefaultEnvironment(krocel.WithResourceIDs([]string{"config"}), krocel.WithSortFunctions())
+	if err != nil {
+		t.Fatalf("Failed to create environment: %v", err)
+	}
+	inspector := NewInspectorWithEnv(env, []string{"config"}, nil)
+
+	for _, expression := range []string{
+		`sort(config.spec.names)`,
+		`sortBy(config.spec.names, n, size(n))`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			got, err := inspector.Inspect(expression)
+			if err != nil {
+				t.Fatalf("Inspect() error = %v", err)
+			}
+			if len(got.UnknownResources) != 0 {
+				t.Errorf("unexpected unknown resources %v", got.UnknownResources)
+			}
+			want := []ResourceDependency{{ID: "config", Path: "config.spec.names"}}
+			if !reflect.DeepEqual(got.ResourceDependencies, want) {
+				t.Errorf("ResourceDependencies = %v, want %v", got.ResourceDependencies, want)
+			}
+		})
+	}
+}
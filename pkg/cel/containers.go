@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// Containers returns a CEL library that provides functions to build the
+// fields of Kubernetes containers.
+//
+// The following functions are available:
+//
+//	toEnv(map) - the env list of a container, made of a {name, value}
+//	             object for each entry of map, sorted by name
+//
+// The entries are sorted by name so that the rendered list is stable, and
+// doesn't change between two reconciliations of the same map.
+//
+// Examples:
+//
+//	toEnv({"LOG_LEVEL": "debug", "API_URL": "http://api"})
+//	// [{"name": "API_URL", "value": "http://api"}, {"name": "LOG_LEVEL", "value": "debug"}]
+func Containers() cel.EnvOption {
+	return cel.Lib(&containersLib{})
+}
+
+type containersLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*containersLib) LibraryName() string {
+	return "kro.containers"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*containersLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("toEnv",
+			cel.Overload("kro_to_env_map",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType)},
+				cel.ListType(cel.MapType(cel.StringType, cel.StringType)),
+				cel.UnaryBinding(toEnv),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*containersLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// toEnv converts a map of environment variables into a list of {name, value}
+// objects sorted by name.
+func toEnv(mapVal ref.Val) ref.Val {
+	m, ok := mapVal.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(mapVal)
+	}
+
+	values := make(map[string]string, int(m.Size().(types.Int)))
+	for it := m.Iterator(); it.HasNext() == types.True; {
+		key := it.Next()
+		name, ok := key.(types.String)
+		if !ok {
+			return types.NewErr("toEnv: env var names must be strings, got %s", key.Type().TypeName())
+		}
+		value, ok := m.Get(key).(types.String)
+		if !ok {
+			return types.NewErr("toEnv: the value of env var %s must be a string, got %s", name, m.Get(key).Type().TypeName())
+		}
+		values[string(name)] = string(value)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		env = append(env, map[string]interface{}{"name": name, "value": values[name]})
+	}
+	return types.DefaultTypeAdapter.NativeToValue(env)
+}
@@ -0,0 +1,102 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       interface{}
+		wantErr    string
+	}{
+		{
+			name:       "entries are sorted by name",
+			expression: `toEnv({"LOG_LEVEL": "debug", "API_URL": "http://api", "REGION": "us-west-2"})`,
+			want: []interface{}{
+				map[string]interface{}{"name": "API_URL", "value": "http://api"},
+				map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+				map[string]interface{}{"name": "REGION", "value": "us-west-2"},
+			},
+		},
+		{
+			name:       "map from a resource",
+			expression: `toEnv(schema.spec.env)`,
+			vars: map[string]interface{}{
+				"schema": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"env": map[string]interface{}{"B": "2", "A": "1"},
+					},
+				},
+			},
+			want: []interface{}{
+				map[string]interface{}{"name": "A", "value": "1"},
+				map[string]interface{}{"name": "B", "value": "2"},
+			},
+		},
+		{
+			name:       "empty map",
+			expression: `toEnv({})`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "non string value",
+			expression: `toEnv(schema.spec.env)`,
+			vars: map[string]interface{}{
+				"schema": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"env": map[string]interface{}{"REPLICAS": 3},
+					},
+				},
+			},
+			wantErr: "toEnv: the value of env var REPLICAS must be a string, got int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithContainerFunctions(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToEnvIsDeterministic(t *testing.T) {
+	expression := `toEnv({"C": "3", "A": "1", "E": "5", "B": "2", "D": "4"})`
+	first, err := evalExpression(t, expression, nil, WithContainerFunctions())
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		got, err := evalExpression(t, expression, nil, WithContainerFunctions())
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+	}
+}
+
+func TestContainerFunctionsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `toEnv({"A": "1"})`, nil)
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
 )
 
 var (
@@ -41,9 +42,9 @@ func GoNativeType(v ref.Val) (interface{}, error) {
 	case types.StringType:
 		return v.Value().(string), nil
 	case types.ListType:
-		return v.ConvertToNative(reflect.TypeOf([]interface{}{}))
+		return goNativeList(v)
 	case types.MapType:
-		return v.ConvertToNative(reflect.TypeOf(map[string]interface{}{}))
+		return goNativeMap(v)
 	case types.NullType:
 		return nil, nil
 	default:
@@ -52,6 +53,48 @@ func GoNativeType(v ref.Val) (interface{}, error) {
 	}
 }
 
+// goNativeList converts a CEL list into a list of Go values, converting its
+// elements recursively: converting it to a native list leaves the elements
+// that aren't Go values, e.g the maps of a list literal, as CEL values.
+func goNativeList(v ref.Val) (interface{}, error) {
+	lister, ok := v.(traits.Lister)
+	if !ok {
+		return v.ConvertToNative(reflect.TypeOf([]interface{}{}))
+	}
+	list := []interface{}{}
+	for it := lister.Iterator(); it.HasNext() == types.True; {
+		elem, err := GoNativeType(it.Next())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, elem)
+	}
+	return list, nil
+}
+
+// goNativeMap converts a CEL map with string keys into a map of Go values,
+// converting its values recursively.
+func goNativeMap(v ref.Val) (interface{}, error) {
+	mapper, ok := v.(traits.Mapper)
+	if !ok {
+		return v.ConvertToNative(reflect.TypeOf(map[string]interface{}{}))
+	}
+	object := map[string]interface{}{}
+	for it := mapper.Iterator(); it.HasNext() == types.True; {
+		key := it.Next()
+		name, ok := key.Value().(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: map key of type %v", ErrUnsupportedType, key.Type())
+		}
+		value, err := GoNativeType(mapper.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		object[name] = value
+	}
+	return object, nil
+}
+
 // IsBoolType checks if the given ref.Val is of type BoolType
 func IsBoolType(v ref.Val) bool {
 	return v.Type() == types.BoolType
@@ -15,7 +15,6 @@ package cel
 
 import (
 	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/ext"
 )
 
 // EnvOption is a function that modifies the environment options.
@@ -28,8 +27,36 @@ type envOptions struct {
 	//
 	// TODO(a-hilaly): Add support for custom types.
 	resourceIDs []string
+	// resourcesMaps will be converted to CEL variable declarations of type
+	// 'map(string, dyn)', holding resources keyed by ID.
+	resourcesMaps []string
 	// customDeclarations will be added to the CEL environment.
 	customDeclarations []cel.EnvOption
+	// setFunctions enables the set.diff, set.union and set.intersect
+	// functions.
+	setFunctions bool
+	// sortFunctions enables the sort and sortBy functions.
+	sortFunctions bool
+	// listAccessors enables the first, last and at functions.
+	listAccessors bool
+	// containerFunctions enables the toEnv function.
+	containerFunctions bool
+	// serializationFunctions enables the toJson and toYaml functions.
+	serializationFunctions bool
+	// templateStringFunction enables the templateString function.
+	templateStringFunction bool
+	// advancedMathFunctions enables the pow, log2, log10 and clamp
+	// functions.
+	advancedMathFunctions bool
+	// arithmeticFunctions enables the percentOf function.
+	arithmeticFunctions bool
+	// instanceHashFunction enables the instanceHash function.
+	instanceHashFunction bool
+	// stableIDFunction enables the stableID function.
+	stableIDFunction bool
+	// stringLimitFunctions enables the truncate and truncateBytes
+	// functions.
+	stringLimitFunctions bool
 }
 
 // WithResourceIDs adds resource ids that will be declared as CEL variables.
@@ -39,6 +66,14 @@ func WithResourceIDs(ids []string) EnvOption {
 	}
 }
 
+// WithResourcesMap declares a CEL variable holding a map of resources keyed
+// by resource ID.
+func WithResourcesMap(name string) EnvOption {
+	return func(opts *envOptions) {
+		opts.resourcesMaps = append(opts.resourcesMaps, name)
+	}
+}
+
 // WithCustomDeclarations adds custom declarations to the CEL environment.
 func WithCustomDeclarations(declarations []cel.EnvOption) EnvOption {
 	return func(opts *envOptions) {
@@ -46,21 +81,149 @@ func WithCustomDeclarations(declarations []cel.EnvOption) EnvOption {
 	}
 }
 
-// DefaultEnvironment returns the default CEL environment.
+// WithSetFunctions enables the set operations library (set.diff, set.union
+// and set.intersect) in the CEL environment.
+func WithSetFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.setFunctions = true
+	}
+}
+
+// WithSortFunctions enables the sort library (sort and sortBy) in the CEL
+// environment.
+func WithSortFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.sortFunctions = true
+	}
+}
+
+// WithListAccessors enables the list accessors library (first, last and at)
+// in the CEL environment.
+func WithListAccessors() EnvOption {
+	return func(opts *envOptions) {
+		opts.listAccessors = true
+	}
+}
+
+// WithContainerFunctions enables the containers library (toEnv) in the CEL
+// environment.
+func WithContainerFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.containerFunctions = true
+	}
+}
+
+// WithSerializationFunctions enables the serialization library (toJson and
+// toYaml) in the CEL environment.
+func WithSerializationFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.serializationFunctions = true
+	}
+}
+
+// WithTemplateStringFunction enables the template string library
+// (templateString) in the CEL environment.
+func WithTemplateStringFunction() EnvOption {
+	return func(opts *envOptions) {
+		opts.templateStringFunction = true
+	}
+}
+
+// WithAdvancedMathFunctions enables the advanced math library (pow, log2,
+// log10 and clamp) in the CEL environment.
+func WithAdvancedMathFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.advancedMathFunctions = true
+	}
+}
+
+// WithArithmeticFunctions enables the arithmetic library (percentOf) in the
+// CEL environment.
+func WithArithmeticFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.arithmeticFunctions = true
+	}
+}
+
+// WithInstanceHashFunction enables the instance hash library (instanceHash)
+// in the CEL environment. The environment must declare the schema variable.
+func WithInstanceHashFunction() EnvOption {
+	return func(opts *envOptions) {
+		opts.instanceHashFunction = true
+	}
+}
+
+// WithStableIDFunction enables the stable ID library (stableID) in the CEL
+// environment. The environment must declare the schema variable.
+func WithStableIDFunction() EnvOption {
+	return func(opts *envOptions) {
+		opts.stableIDFunction = true
+	}
+}
+
+// WithStringLimitFunctions enables the string limits library (truncate,
+// counting runes, and truncateBytes, counting bytes) in the CEL environment.
+func WithStringLimitFunctions() EnvOption {
+	return func(opts *envOptions) {
+		opts.stringLimitFunctions = true
+	}
+}
+
+// DefaultEnvironment returns the default CEL environment. It includes the
+// enabled CEL extension libraries, see SetEnabledExtensions, and the custom
+// function libraries registered with RegisterLibrary.
 func DefaultEnvironment(options ...EnvOption) (*cel.Env, error) {
 	opts := &envOptions{}
+	WithCustomDeclarations(registeredLibraryDeclarations())(opts)
 	for _, opt := range options {
 		opt(opts)
 	}
 
-	declarations := []cel.EnvOption{
-		// default stdlibs
-		ext.Lists(),
-		ext.Strings(),
+	// The CEL extension libraries enabled with SetEnabledExtensions, the
+	// lists and strings ones by default.
+	declarations := enabledExtensionDeclarations()
+
+	if opts.setFunctions {
+		declarations = append(declarations, Sets())
+	}
+	if opts.sortFunctions {
+		declarations = append(declarations, Sort())
+	}
+	if opts.listAccessors {
+		declarations = append(declarations, ListAccessors())
+	}
+	if opts.containerFunctions {
+		declarations = append(declarations, Containers())
+	}
+	if opts.serializationFunctions {
+		declarations = append(declarations, Serialization())
+	}
+	if opts.templateStringFunction {
+		declarations = append(declarations, TemplateString())
 	}
+	if opts.advancedMathFunctions {
+		declarations = append(declarations, AdvancedMath())
+	}
+	if opts.arithmeticFunctions {
+		declarations = append(declarations, Arithmetic())
+	}
+	if opts.instanceHashFunction {
+		declarations = append(declarations, InstanceHash())
+	}
+	if opts.stableIDFunction {
+		declarations = append(declarations, StableID())
+	}
+	if opts.stringLimitFunctions {
+		declarations = append(declarations, StringLimits())
+	}
+
+	declarations = append(declarations, opts.customDeclarations...)
 
 	for _, name := range opts.resourceIDs {
 		declarations = append(declarations, cel.Variable(name, cel.AnyType))
 	}
+	for _, name := range opts.resourcesMaps {
+		declarations = append(declarations, cel.Variable(name, cel.MapType(cel.StringType, cel.DynType)))
+	}
 	return cel.NewEnv(declarations...)
 }
@@ -0,0 +1,127 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+const (
+	// ExtensionLists is the name of the CEL lists extension library, e.g
+	// providing the slice and flatten functions.
+	ExtensionLists = "lists"
+	// ExtensionStrings is the name of the CEL strings extension library, e.g
+	// providing the split, replace and lowerAscii functions.
+	ExtensionStrings = "strings"
+)
+
+// extensions are the CEL extension libraries that can be enabled, keyed by
+// name.
+var extensions = map[string]func() cel.EnvOption{
+	ExtensionLists:   func() cel.EnvOption { return ext.Lists() },
+	ExtensionStrings: func() cel.EnvOption { return ext.Strings() },
+}
+
+// DefaultExtensions are the CEL extension libraries enabled by default.
+var DefaultExtensions = []string{ExtensionLists, ExtensionStrings}
+
+var (
+	extensionsMu      sync.RWMutex
+	enabledExtensions = slices.Clone(DefaultExtensions)
+)
+
+// ParseExtensions parses a comma separated list of CEL extension library
+// names, e.g "lists,strings". An empty string is parsed as no extension.
+func ParseExtensions(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if err := checkExtension(name); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// AvailableExtensions returns the sorted names of the CEL extension libraries
+// that can be enabled.
+func AvailableExtensions() []string {
+	names := make([]string, 0, len(extensions))
+	for name := range extensions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// checkExtension returns an error if no CEL extension library has the given
+// name.
+func checkExtension(name string) error {
+	if _, ok := extensions[name]; !ok {
+		return fmt.Errorf("unknown CEL extension %q, must be one of %s",
+			name, strings.Join(AvailableExtensions(), ", "))
+	}
+	return nil
+}
+
+// SetEnabledExtensions restricts the CEL extension libraries enabled in the
+// environments created afterwards to the given ones. It is meant to be called
+// once, when the controller starts, so that the resource groups are
+// validated and their instances evaluated with the same functions.
+//
+// SetEnabledExtensions returns an error if one of the names is unknown, see
+// AvailableExtensions.
+func SetEnabledExtensions(names []string) error {
+	for _, name := range names {
+		if err := checkExtension(name); err != nil {
+			return err
+		}
+	}
+	extensionsMu.Lock()
+	defer extensionsMu.Unlock()
+	enabledExtensions = slices.Clone(names)
+	return nil
+}
+
+// EnabledExtensions returns the names of the enabled CEL extension libraries.
+func EnabledExtensions() []string {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+	return slices.Clone(enabledExtensions)
+}
+
+// enabledExtensionDeclarations returns the options of the enabled CEL
+// extension libraries.
+func enabledExtensionDeclarations() []cel.EnvOption {
+	extensionsMu.RLock()
+	defer extensionsMu.RUnlock()
+
+	declarations := make([]cel.EnvOption, 0, len(enabledExtensions))
+	for _, name := range enabledExtensions {
+		declarations = append(declarations, extensions[name]())
+	}
+	return declarations
+}
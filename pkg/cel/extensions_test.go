@@ -0,0 +1,71 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExtensions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr string
+	}{
+		{in: "", want: nil},
+		{in: "lists,strings", want: []string{ExtensionLists, ExtensionStrings}},
+		{in: " Strings , lists,strings", want: []string{ExtensionStrings, ExtensionLists}},
+		{in: "lists,math", wantErr: `unknown CEL extension "math", must be one of lists, strings`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseExtensions(tt.in)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetEnabledExtensions(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetEnabledExtensions(DefaultExtensions)) })
+
+	// The lists and strings extensions are enabled by default.
+	assert.Equal(t, DefaultExtensions, EnabledExtensions())
+	got, err := evalExpression(t, `"a,b".split(",")`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"a", "b"}, got)
+	got, err = evalExpression(t, `[[1], [2]].flatten()`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, got)
+
+	// The functions of the disabled extensions are rejected.
+	require.NoError(t, SetEnabledExtensions([]string{ExtensionLists}))
+	assert.Equal(t, []string{ExtensionLists}, EnabledExtensions())
+	_, err = evalExpression(t, `"a,b".split(",")`, nil)
+	assert.ErrorContains(t, err, "undeclared reference to 'split'")
+	got, err = evalExpression(t, `[[1], [2]].flatten()`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, got)
+
+	// Unknown extensions are refused, the enabled ones are left unchanged.
+	assert.Error(t, SetEnabledExtensions([]string{"math"}))
+	assert.Equal(t, []string{ExtensionLists}, EnabledExtensions())
+}
@@ -0,0 +1,127 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// instanceHashFunction is the internal function the instanceHash macro
+// expands to.
+const instanceHashFunction = "@kro.instanceHash"
+
+// instanceVariable is the variable holding the instance the hash is derived
+// from.
+const instanceVariable = "schema"
+
+// instanceHashLength is the number of hexadecimal characters of the hash.
+const instanceHashLength = 8
+
+// InstanceHash returns a CEL library that provides a short hash identifying
+// the instance, e.g. to give its resources globally unique names.
+//
+// The following function is available:
+//
+//	instanceHash() - 8 lowercase hexadecimal characters derived from the
+//	                 UID, namespace and name of the instance
+//
+// The hash only depends on the instance identity: it is the same across all
+// the reconciles of an instance, and differs between instances, including an
+// instance deleted and recreated with the same name. It is a valid DNS label
+// and can be used anywhere in the name of a resource. The instance is read
+// from the schema variable, which must be declared in the environment.
+//
+// Examples:
+//
+//	"${schema.spec.name}-${instanceHash()}" // "my-app-3f2a9c1e"
+func InstanceHash() cel.EnvOption {
+	return cel.Lib(&instanceHashLib{})
+}
+
+type instanceHashLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*instanceHashLib) LibraryName() string {
+	return "kro.instanceHash"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*instanceHashLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function(instanceHashFunction,
+			cel.Overload("kro_instance_hash_dyn",
+				[]*cel.Type{cel.DynType}, cel.StringType,
+				cel.UnaryBinding(instanceHash),
+			),
+		),
+		// instanceHash is a macro reading the instance variable, so that the
+		// expressions using it depend on the instance like any other
+		// expression reading it.
+		cel.Macros(
+			cel.GlobalMacro("instanceHash", 0, instanceHashMacro),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*instanceHashLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// instanceHashMacro expands instanceHash() into @kro.instanceHash(schema).
+func instanceHashMacro(meh cel.MacroExprFactory, _ ast.Expr, _ []ast.Expr) (ast.Expr, *cel.Error) {
+	return meh.NewCall(instanceHashFunction, meh.NewIdent(instanceVariable)), nil
+}
+
+// instanceHash returns the hash of the UID, namespace and name of the given
+// instance. The missing metadata fields are hashed as empty strings.
+func instanceHash(instanceVal ref.Val) ref.Val {
+	instance, ok := instanceVal.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(instanceVal)
+	}
+	var uid, namespace, name string
+	if metadataVal, found := instance.Find(types.String("metadata")); found {
+		if metadata, ok := metadataVal.(traits.Mapper); ok {
+			uid = stringField(metadata, "uid")
+			namespace = stringField(metadata, "namespace")
+			name = stringField(metadata, "name")
+		}
+	}
+
+	// The fields are separated by a character that can't appear in them.
+	sum := sha256.Sum256([]byte(uid + "/" + namespace + "/" + name))
+	return types.String(hex.EncodeToString(sum[:])[:instanceHashLength])
+}
+
+// stringField returns the value of the given string field of a map, or an
+// empty string if it isn't set.
+func stringField(m traits.Mapper, field string) string {
+	val, found := m.Find(types.String(field))
+	if !found {
+		return ""
+	}
+	s, ok := val.(types.String)
+	if !ok {
+		return ""
+	}
+	return string(s)
+}
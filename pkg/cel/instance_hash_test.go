@@ -0,0 +1,81 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHashedInstance(uid, namespace, name string, replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"schema": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"uid":       uid,
+				"namespace": namespace,
+				"name":      name,
+			},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+			},
+		},
+	}
+}
+
+func TestInstanceHash(t *testing.T) {
+	hash := func(vars map[string]interface{}) string {
+		t.Helper()
+		got, err := evalExpression(t, `instanceHash()`, vars, WithInstanceHashFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		return got.(string)
+	}
+
+	first := hash(newHashedInstance("0b6c7a8e", "default", "app", 1))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}$`), first)
+
+	t.Run("stable across reconciles", func(t *testing.T) {
+		assert.Equal(t, first, hash(newHashedInstance("0b6c7a8e", "default", "app", 1)))
+		// The spec of the instance doesn't change its hash.
+		assert.Equal(t, first, hash(newHashedInstance("0b6c7a8e", "default", "app", 3)))
+	})
+
+	t.Run("unique across instances", func(t *testing.T) {
+		assert.NotEqual(t, first, hash(newHashedInstance("5d1e2f3a", "default", "other", 1)))
+		assert.NotEqual(t, first, hash(newHashedInstance("0b6c7a8e", "team-a", "app", 1)))
+		// An instance recreated with the same name gets a new UID.
+		assert.NotEqual(t, first, hash(newHashedInstance("9f8e7d6c", "default", "app", 1)))
+	})
+
+	t.Run("in a name template", func(t *testing.T) {
+		got, err := evalExpression(t, `"app-" + instanceHash()`, newHashedInstance("0b6c7a8e", "default", "app", 1),
+			WithInstanceHashFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		assert.Equal(t, "app-"+first, got)
+	})
+
+	t.Run("instance without metadata", func(t *testing.T) {
+		got, err := evalExpression(t, `instanceHash()`, map[string]interface{}{"schema": map[string]interface{}{}},
+			WithInstanceHashFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		assert.Len(t, got, 8)
+	})
+}
+
+func TestInstanceHashDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `instanceHash()`, nil, WithResourceIDs([]string{"schema"}))
+	assert.Error(t, err)
+}
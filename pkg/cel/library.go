@@ -0,0 +1,113 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Library constructs a custom CEL function library. It is called every time
+// a CEL environment is created, and the returned option is added to the
+// environment along with the kro libraries.
+type Library func() cel.EnvOption
+
+var (
+	librariesMu sync.RWMutex
+	libraries   = make(map[string]Library)
+)
+
+// RegisterLibrary makes a custom CEL function library available to all the
+// expressions of the ResourceGroups, without forking kro. It is meant to be
+// called from the init function of a package blank imported by the controller
+// main package, e.g:
+//
+//	import _ "example.com/org/kro-functions"
+//
+// The libraries must honor the following contract:
+//
+//   - Functions must be pure and deterministic: kro evaluates expressions many
+//     times, at graph build time against emulated resources and at each
+//     reconciliation, and expects the same output for the same input.
+//   - Functions must not perform I/O (network calls, files, ...), nor block.
+//     They run in the reconciliation loop of the instances.
+//   - Functions must be cheap, or declare their cost, since the expressions
+//     are subject to the expression cost budget.
+//   - Function and overload names must not collide with the CEL standard
+//     library, the kro libraries or other registered libraries, otherwise
+//     the creation of the environments fails. Prefixing them with the
+//     organization name (e.g acme.hash) is recommended.
+//   - Libraries should implement cel.SingletonLibrary, so that they are only
+//     added once to an environment.
+//
+// RegisterLibrary panics if the name is empty, if the library is nil, or if a
+// library is already registered under the same name.
+func RegisterLibrary(name string, library Library) {
+	librariesMu.Lock()
+	defer librariesMu.Unlock()
+
+	if name == "" {
+		panic("cel: RegisterLibrary name is empty")
+	}
+	if library == nil {
+		panic(fmt.Sprintf("cel: RegisterLibrary library %s is nil", name))
+	}
+	if _, ok := libraries[name]; ok {
+		panic(fmt.Sprintf("cel: RegisterLibrary called twice for library %s", name))
+	}
+	libraries[name] = library
+}
+
+// RegisteredLibraries returns the sorted names of the registered custom CEL
+// function libraries.
+func RegisteredLibraries() []string {
+	librariesMu.RLock()
+	defer librariesMu.RUnlock()
+
+	names := make([]string, 0, len(libraries))
+	for name := range libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registeredLibraryDeclarations returns the options of the registered custom
+// CEL function libraries, sorted by library name.
+func registeredLibraryDeclarations() []cel.EnvOption {
+	librariesMu.RLock()
+	defer librariesMu.RUnlock()
+
+	names := make([]string, 0, len(libraries))
+	for name := range libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	declarations := make([]cel.EnvOption, 0, len(names))
+	for _, name := range names {
+		declarations = append(declarations, libraries[name]())
+	}
+	return declarations
+}
+
+// unregisterLibrary removes a registered library. It is only meant for tests.
+func unregisterLibrary(name string) {
+	librariesMu.Lock()
+	defer librariesMu.Unlock()
+	delete(libraries, name)
+}
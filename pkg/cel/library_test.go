@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// acmeLibrary is a custom library providing acme.shout(string).
+func acmeLibrary() cel.EnvOption {
+	return cel.Function("acme.shout",
+		cel.Overload("acme_shout_string",
+			[]*cel.Type{cel.StringType},
+			cel.StringType,
+			cel.UnaryBinding(func(value ref.Val) ref.Val {
+				return types.String(strings.ToUpper(string(value.(types.String))) + "!")
+			}),
+		),
+	)
+}
+
+func TestRegisterLibrary(t *testing.T) {
+	_, err := evalExpression(t, `acme.shout("hello")`, nil)
+	require.Error(t, err, "the function must not be available before the library is registered")
+
+	RegisterLibrary("acme", acmeLibrary)
+	t.Cleanup(func() { unregisterLibrary("acme") })
+
+	assert.Equal(t, []string{"acme"}, RegisteredLibraries())
+
+	got, err := evalExpression(t, `acme.shout(schema.spec.name)`, map[string]interface{}{
+		"schema": map[string]interface{}{
+			"spec": map[string]interface{}{"name": "web"},
+		},
+	}, WithResourceIDs([]string{"schema"}))
+	require.NoError(t, err)
+	assert.Equal(t, "WEB!", got)
+
+	// Custom declarations passed explicitly are added along the libraries.
+	got, err = evalExpression(t, `acme.shout(greeting)`, map[string]interface{}{"greeting": "hi"},
+		WithCustomDeclarations([]cel.EnvOption{cel.Variable("greeting", cel.StringType)}))
+	require.NoError(t, err)
+	assert.Equal(t, "HI!", got)
+}
+
+func TestRegisterLibraryPanics(t *testing.T) {
+	RegisterLibrary("acme", acmeLibrary)
+	t.Cleanup(func() { unregisterLibrary("acme") })
+
+	assert.PanicsWithValue(t, "cel: RegisterLibrary called twice for library acme", func() {
+		RegisterLibrary("acme", acmeLibrary)
+	})
+	assert.Panics(t, func() { RegisterLibrary("", acmeLibrary) })
+	assert.Panics(t, func() { RegisterLibrary("nil", nil) })
+}
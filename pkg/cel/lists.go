@@ -0,0 +1,125 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// ListAccessors returns a CEL library that provides functions to access the
+// elements of a list with safe bounds.
+//
+// The following functions are available:
+//
+//	first(list)    - the first element of list
+//	last(list)     - the last element of list
+//	at(list, i)    - the element of list at index i
+//
+// When the list is empty or the index is out of range, they report an error
+// naming the function and the size of the list. Negative indexes are out of
+// range.
+//
+// Examples:
+//
+//	first(["a", "b", "c"])   // "a"
+//	last(["a", "b", "c"])    // "c"
+//	at(["a", "b", "c"], 1)   // "b"
+//	first([])                // error: first: list is empty
+//	at(["a"], 3)             // error: at: index 3 out of range for a list of size 1
+func ListAccessors() cel.EnvOption {
+	return cel.Lib(&listAccessorsLib{})
+}
+
+type listAccessorsLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*listAccessorsLib) LibraryName() string {
+	return "kro.lists"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*listAccessorsLib) CompileOptions() []cel.EnvOption {
+	elemType := cel.TypeParamType("T")
+	listType := cel.ListType(elemType)
+	return []cel.EnvOption{
+		cel.Function("first",
+			cel.Overload("kro_first_list",
+				[]*cel.Type{listType}, elemType,
+				cel.UnaryBinding(firstElement),
+			),
+		),
+		cel.Function("last",
+			cel.Overload("kro_last_list",
+				[]*cel.Type{listType}, elemType,
+				cel.UnaryBinding(lastElement),
+			),
+		),
+		cel.Function("at",
+			cel.Overload("kro_at_list_int",
+				[]*cel.Type{listType, cel.IntType}, elemType,
+				cel.BinaryBinding(elementAt),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*listAccessorsLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// firstElement returns the first element of the list.
+func firstElement(listVal ref.Val) ref.Val {
+	list, ok := listVal.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(listVal)
+	}
+	if list.Size() == types.IntZero {
+		return types.NewErr("first: list is empty")
+	}
+	return list.Get(types.IntZero)
+}
+
+// lastElement returns the last element of the list.
+func lastElement(listVal ref.Val) ref.Val {
+	list, ok := listVal.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(listVal)
+	}
+	size := list.Size().(types.Int)
+	if size == 0 {
+		return types.NewErr("last: list is empty")
+	}
+	return list.Get(size - 1)
+}
+
+// elementAt returns the element of the list at the given index.
+func elementAt(listVal, indexVal ref.Val) ref.Val {
+	list, ok := listVal.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(listVal)
+	}
+	index, ok := indexVal.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(indexVal)
+	}
+	size := list.Size().(types.Int)
+	if index < 0 || index >= size {
+		return types.NewErr("at: index %d out of range for a list of size %d", index, size)
+	}
+	return list.Get(index)
+}
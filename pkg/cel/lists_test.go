@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccessors(t *testing.T) {
+	ingressVars := map[string]interface{}{
+		"service": map[string]interface{}{
+			"status": map[string]interface{}{
+				"loadBalancer": map[string]interface{}{
+					"ingress": []interface{}{
+						map[string]interface{}{"hostname": "a.example.com"},
+						map[string]interface{}{"hostname": "b.example.com"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       interface{}
+		wantErr    string
+	}{
+		// first
+		{
+			name:       "first of a populated list",
+			expression: `first(service.status.loadBalancer.ingress).hostname`,
+			vars:       ingressVars,
+			want:       "a.example.com",
+		},
+		{
+			name:       "first of an empty list",
+			expression: `first([])`,
+			wantErr:    "first: list is empty",
+		},
+
+		// last
+		{
+			name:       "last of a populated list",
+			expression: `last(service.status.loadBalancer.ingress).hostname`,
+			vars:       ingressVars,
+			want:       "b.example.com",
+		},
+		{
+			name:       "last of a single element list",
+			expression: `last([1])`,
+			want:       int64(1),
+		},
+		{
+			name:       "last of an empty list",
+			expression: `last([])`,
+			wantErr:    "last: list is empty",
+		},
+
+		// at
+		{
+			name:       "at within bounds",
+			expression: `at(["a", "b", "c"], 1)`,
+			want:       "b",
+		},
+		{
+			name:       "at out of range",
+			expression: `at(["a", "b", "c"], 3)`,
+			wantErr:    "at: index 3 out of range for a list of size 3",
+		},
+		{
+			name:       "at negative index",
+			expression: `at(["a", "b", "c"], -1)`,
+			wantErr:    "at: index -1 out of range for a list of size 3",
+		},
+		{
+			name:       "at of an empty list",
+			expression: `at([], 0)`,
+			wantErr:    "at: index 0 out of range for a list of size 0",
+		},
+		{
+			name:       "guarded access of an empty list",
+			expression: `size([]) > 0 ? first([]) : "none"`,
+			want:       "none",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithListAccessors(), WithResourceIDs([]string{"service"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListAccessorsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `first([1])`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// AdvancedMath returns a CEL library that provides exponential and
+// logarithmic functions with domain checks, along with a function bounding a
+// value, e.g. to compute autoscaling hints from the instance spec.
+//
+// The following functions are available:
+//
+//	pow(base, exp)       - base raised to the power of exp
+//	log2(x)              - the binary logarithm of x
+//	log10(x)             - the decimal logarithm of x
+//	clamp(x, low, high)  - x bounded to the [low, high] range
+//
+// pow is available for ints and doubles. The int overload rejects negative
+// exponents and results overflowing an int. The double overload rejects the
+// results that aren't finite numbers, e.g. the root of a negative base. log2
+// and log10 accept ints and doubles, and report an error for non-positive
+// inputs. clamp reports an error when low is greater than high.
+//
+// Examples:
+//
+//	pow(2, 10)                             // 1024
+//	pow(2.0, 0.5)                          // 1.4142135623730951
+//	log2(1024)                             // 10.0
+//	log10(0)                               // error: log10: 0 is not positive
+//	clamp(pow(2, schema.spec.tier), 1, 64) // between 1 and 64
+func AdvancedMath() cel.EnvOption {
+	return cel.Lib(&advancedMathLib{})
+}
+
+type advancedMathLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*advancedMathLib) LibraryName() string {
+	return "kro.math"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*advancedMathLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("pow",
+			cel.Overload("kro_pow_int_int",
+				[]*cel.Type{cel.IntType, cel.IntType}, cel.IntType,
+				cel.BinaryBinding(powInt),
+			),
+			cel.Overload("kro_pow_double_double",
+				[]*cel.Type{cel.DoubleType, cel.DoubleType}, cel.DoubleType,
+				cel.BinaryBinding(powDouble),
+			),
+		),
+		cel.Function("log2", logOverloads("log2", math.Log2)...),
+		cel.Function("log10", logOverloads("log10", math.Log10)...),
+		cel.Function("clamp",
+			cel.Overload("kro_clamp_int_int_int",
+				[]*cel.Type{cel.IntType, cel.IntType, cel.IntType}, cel.IntType,
+				cel.FunctionBinding(clamp),
+			),
+			cel.Overload("kro_clamp_double_double_double",
+				[]*cel.Type{cel.DoubleType, cel.DoubleType, cel.DoubleType}, cel.DoubleType,
+				cel.FunctionBinding(clamp),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*advancedMathLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// powInt raises an int base to a non-negative int exponent.
+func powInt(baseVal, expVal ref.Val) ref.Val {
+	base, ok := baseVal.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(baseVal)
+	}
+	exp, ok := expVal.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(expVal)
+	}
+	if exp < 0 {
+		return types.NewErr("pow: negative exponent %d for an int base, use doubles instead", exp)
+	}
+	switch base {
+	case 0:
+		if exp == 0 {
+			return types.IntOne
+		}
+		return types.IntZero
+	case 1:
+		return types.IntOne
+	case -1:
+		if exp%2 == 0 {
+			return types.IntOne
+		}
+		return types.IntNegOne
+	}
+	// The magnitude of the base is at least 2, the loop overflows after at
+	// most 63 iterations.
+	result := int64(1)
+	for i := types.Int(0); i < exp; i++ {
+		next := result * int64(base)
+		if next/int64(base) != result {
+			return types.NewErr("pow: %d to the power of %d overflows an int", base, exp)
+		}
+		result = next
+	}
+	return types.Int(result)
+}
+
+// powDouble raises a double base to a double exponent.
+func powDouble(baseVal, expVal ref.Val) ref.Val {
+	base, ok := baseVal.(types.Double)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(baseVal)
+	}
+	exp, ok := expVal.(types.Double)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(expVal)
+	}
+	result := math.Pow(float64(base), float64(exp))
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return types.NewErr("pow: %v to the power of %v is not a finite number", float64(base), float64(exp))
+	}
+	return types.Double(result)
+}
+
+// logOverloads returns the int and double overloads of the given logarithm
+// function, rejecting non-positive inputs.
+func logOverloads(name string, log func(float64) float64) []cel.FunctionOpt {
+	binding := func(val ref.Val) ref.Val {
+		var x float64
+		switch v := val.(type) {
+		case types.Int:
+			x = float64(v)
+		case types.Double:
+			x = float64(v)
+		default:
+			return types.MaybeNoSuchOverloadErr(val)
+		}
+		if !(x > 0) {
+			return types.NewErr("%s: %v is not positive", name, x)
+		}
+		return types.Double(log(x))
+	}
+	return []cel.FunctionOpt{
+		cel.Overload("kro_"+name+"_int",
+			[]*cel.Type{cel.IntType}, cel.DoubleType,
+			cel.UnaryBinding(binding),
+		),
+		cel.Overload("kro_"+name+"_double",
+			[]*cel.Type{cel.DoubleType}, cel.DoubleType,
+			cel.UnaryBinding(binding),
+		),
+	}
+}
+
+// clamp bounds a value to the given range. The three values are of the same
+// type, ints or doubles.
+func clamp(args ...ref.Val) ref.Val {
+	if len(args) != 3 {
+		return types.NewErr("clamp: expected 3 arguments, got %d", len(args))
+	}
+	value, ok := args[0].(traits.Comparer)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(args[0])
+	}
+	low, ok := args[1].(traits.Comparer)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(args[1])
+	}
+	high := args[2]
+	if low.Compare(high) == types.IntOne {
+		return types.NewErr("clamp: low bound %v is greater than high bound %v", low, high)
+	}
+	if value.Compare(args[1]) == types.IntNegOne {
+		return args[1]
+	}
+	if value.Compare(high) == types.IntOne {
+		return high
+	}
+	return args[0]
+}
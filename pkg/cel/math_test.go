@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvancedMath(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       interface{}
+		wantErr    string
+	}{
+		// pow
+		{name: "int power", expression: `pow(2, 10)`, want: int64(1024)},
+		{name: "int zero exponent", expression: `pow(7, 0)`, want: int64(1)},
+		{name: "int negative base", expression: `pow(-3, 3)`, want: int64(-27)},
+		{name: "int base minus one, huge exponent", expression: `pow(-1, 9223372036854775807)`, want: int64(-1)},
+		{name: "double power", expression: `pow(2.0, 0.5)`, want: 1.4142135623730951},
+		{name: "double negative exponent", expression: `pow(2.0, -1.0)`, want: 0.5},
+		{name: "int negative exponent", expression: `pow(2, -1)`, wantErr: "pow: negative exponent -1"},
+		{name: "int overflow", expression: `pow(2, 63)`, wantErr: "pow: 2 to the power of 63 overflows an int"},
+		{name: "double root of a negative base", expression: `pow(-8.0, 0.5)`, wantErr: "is not a finite number"},
+		{name: "double division by zero", expression: `pow(0.0, -1.0)`, wantErr: "is not a finite number"},
+
+		// log2 and log10
+		{name: "log2 of an int", expression: `log2(1024)`, want: 10.0},
+		{name: "log2 of a double", expression: `log2(0.5)`, want: -1.0},
+		{name: "log10 of an int", expression: `log10(1000)`, want: 3.0},
+		{name: "log2 of zero", expression: `log2(0)`, wantErr: "log2: 0 is not positive"},
+		{name: "log10 of a negative double", expression: `log10(-1.5)`, wantErr: "log10: -1.5 is not positive"},
+
+		// clamp
+		{name: "clamp within bounds", expression: `clamp(5, 1, 10)`, want: int64(5)},
+		{name: "clamp below", expression: `clamp(-5, 1, 10)`, want: int64(1)},
+		{name: "clamp above", expression: `clamp(2.5, 0.0, 1.0)`, want: 1.0},
+		{name: "clamp inverted bounds", expression: `clamp(5, 10, 1)`, wantErr: "clamp: low bound 10 is greater than high bound 1"},
+
+		// combinations
+		{
+			name:       "bounded exponential replicas",
+			expression: `clamp(pow(2, schema.spec.tier), 1, 16)`,
+			vars:       map[string]interface{}{"schema": map[string]interface{}{"spec": map[string]interface{}{"tier": 6}}},
+			want:       int64(16),
+		},
+		{
+			name:       "cache size from a log",
+			expression: `int(log2(double(schema.spec.users)))`,
+			vars:       map[string]interface{}{"schema": map[string]interface{}{"spec": map[string]interface{}{"users": 4096}}},
+			want:       int64(12),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithAdvancedMathFunctions(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAdvancedMathDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `pow(2, 2)`, nil)
+	assert.Error(t, err)
+}
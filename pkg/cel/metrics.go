@@ -0,0 +1,52 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		programCacheHits,
+		programCacheMisses,
+		programCacheSize,
+	)
+}
+
+var (
+	// programCacheHits counts the programs found in the program caches.
+	programCacheHits = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cel_program_cache_hits_total",
+			Help: "Total number of CEL programs found in the program cache",
+		},
+	)
+	// programCacheMisses counts the programs compiled because they were not
+	// found in the program caches.
+	programCacheMisses = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "cel_program_cache_misses_total",
+			Help: "Total number of CEL programs compiled because they were not found in the program cache",
+		},
+	)
+	// programCacheSize is the number of programs held by the program caches.
+	programCacheSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cel_program_cache_size",
+			Help: "Number of CEL programs held in the program cache",
+		},
+	)
+)
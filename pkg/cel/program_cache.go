@@ -0,0 +1,144 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// DefaultProgramCacheSize is the default maximum number of programs kept in
+// the DefaultProgramCache.
+const DefaultProgramCacheSize = 1024
+
+// DefaultProgramCache is the program cache used to evaluate the expressions of
+// the instances.
+var DefaultProgramCache = NewProgramCache(DefaultProgramCacheSize)
+
+// programKey identifies a program in the cache. The same expression may
+// compile differently, or not at all, depending on the declarations of the
+// environment it is compiled in.
+type programKey struct {
+	env        string
+	expression string
+}
+
+type programEntry struct {
+	key     programKey
+	program cel.Program
+}
+
+// ProgramCache is a least recently used cache of the CEL programs compiled
+// from expressions, saving their compilation at each evaluation. It is safe
+// for concurrent use.
+type ProgramCache struct {
+	mu      sync.Mutex
+	maxSize int
+	// entries holds the programEntry, the most recently used first.
+	entries *list.List
+	index   map[programKey]*list.Element
+}
+
+// NewProgramCache returns a program cache keeping at most maxSize programs. A
+// maxSize of 0 or less disables the cache.
+func NewProgramCache(maxSize int) *ProgramCache {
+	return &ProgramCache{
+		maxSize: maxSize,
+		entries: list.New(),
+		index:   make(map[programKey]*list.Element),
+	}
+}
+
+// Program returns the program of the expression compiled in the given
+// environment, compiling it on a cache miss. envKey identifies the
+// declarations of the environment: the environments sharing a key must
+// compile the expressions the same way. The compilation errors are not
+// cached.
+func (c *ProgramCache) Program(env *cel.Env, envKey, expression string) (cel.Program, error) {
+	key := programKey{env: envKey, expression: expression}
+	if program, ok := c.get(key); ok {
+		programCacheHits.Inc()
+		return program, nil
+	}
+	programCacheMisses.Inc()
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed compiling expression %s: %w", expression, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed programming expression %s: %w", expression, err)
+	}
+	c.add(key, program)
+	return program, nil
+}
+
+// Len returns the number of programs in the cache.
+func (c *ProgramCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Len()
+}
+
+// Resize changes the maximum number of programs kept in the cache, evicting
+// the least recently used ones if needed. A maxSize of 0 or less disables the
+// cache.
+func (c *ProgramCache) Resize(maxSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = maxSize
+	c.evict()
+}
+
+func (c *ProgramCache) get(key programKey) (cel.Program, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(element)
+	return element.Value.(*programEntry).program, true
+}
+
+func (c *ProgramCache) add(key programKey, program cel.Program) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxSize <= 0 {
+		return
+	}
+	// The program may have been compiled concurrently.
+	if element, ok := c.index[key]; ok {
+		c.entries.MoveToFront(element)
+		return
+	}
+	c.index[key] = c.entries.PushFront(&programEntry{key: key, program: program})
+	programCacheSize.Inc()
+	c.evict()
+}
+
+// evict removes the least recently used programs exceeding the maximum size.
+// It must be called with the lock held.
+func (c *ProgramCache) evict() {
+	for c.entries.Len() > max(c.maxSize, 0) {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*programEntry).key)
+		programCacheSize.Dec()
+	}
+}
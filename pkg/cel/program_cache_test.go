@@ -0,0 +1,98 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestProgramCache(t *testing.T) {
+	env, err := DefaultEnvironment(WithResourceIDs([]string{"vpc"}))
+	require.NoError(t, err)
+	cache := NewProgramCache(2)
+
+	hits := testutil.ToFloat64(programCacheHits)
+	misses := testutil.ToFloat64(programCacheMisses)
+	size := testutil.ToFloat64(programCacheSize)
+	assertCounters := func(t *testing.T, wantHits, wantMisses, wantSize int) {
+		t.Helper()
+		assert.Equal(t, hits+float64(wantHits), testutil.ToFloat64(programCacheHits), "hits")
+		assert.Equal(t, misses+float64(wantMisses), testutil.ToFloat64(programCacheMisses), "misses")
+		assert.Equal(t, size+float64(wantSize), testutil.ToFloat64(programCacheSize), "size")
+		assert.Equal(t, wantSize, cache.Len())
+	}
+
+	// The first evaluation compiles the program, the next ones reuse it.
+	program, err := cache.Program(env, "vpc", "vpc.spec.cidr")
+	require.NoError(t, err)
+	assertCounters(t, 0, 1, 1)
+	cached, err := cache.Program(env, "vpc", "vpc.spec.cidr")
+	require.NoError(t, err)
+	assert.Same(t, program, cached)
+	assertCounters(t, 1, 1, 1)
+
+	out, _, err := cached.Eval(map[string]interface{}{
+		"vpc": map[string]interface{}{"spec": map[string]interface{}{"cidr": "10.0.0.0/16"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/16", out.Value())
+
+	// The same expression is compiled again in another environment.
+	_, err = cache.Program(env, "vpc,subnet", "vpc.spec.cidr")
+	require.NoError(t, err)
+	assertCounters(t, 1, 2, 2)
+
+	// The compilation errors aren't cached.
+	_, err = cache.Program(env, "vpc", "subnet.spec.cidr")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed compiling expression subnet.spec.cidr")
+	assertCounters(t, 1, 3, 2)
+
+	// Past its maximum size, the least recently used program is evicted.
+	_, err = cache.Program(env, "vpc", "vpc.spec.cidr")
+	require.NoError(t, err)
+	_, err = cache.Program(env, "vpc", "vpc.metadata.name")
+	require.NoError(t, err)
+	assertCounters(t, 2, 4, 2)
+	_, err = cache.Program(env, "vpc,subnet", "vpc.spec.cidr")
+	require.NoError(t, err)
+	assertCounters(t, 2, 5, 2)
+
+	// Shrinking the cache evicts the programs, disabling it keeps none.
+	cache.Resize(0)
+	assertCounters(t, 2, 5, 0)
+	_, err = cache.Program(env, "vpc", "vpc.spec.cidr")
+	require.NoError(t, err)
+	assertCounters(t, 2, 6, 0)
+}
+
+func TestProgramCacheMetrics(t *testing.T) {
+	// The metrics are registered with the controller-runtime registry, under
+	// their documented names, as a single series each: no label makes their
+	// cardinality grow with the expressions.
+	for _, name := range []string{
+		"cel_program_cache_hits_total",
+		"cel_program_cache_misses_total",
+		"cel_program_cache_size",
+	} {
+		count, err := testutil.GatherAndCount(metrics.Registry, name)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count, name)
+	}
+}
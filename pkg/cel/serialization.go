@@ -0,0 +1,143 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"sigs.k8s.io/yaml"
+)
+
+// SerializationFunctions are the names of the functions of the serialization
+// library. Their output is a string, and can only be assigned to string
+// fields.
+var SerializationFunctions = []string{"toJson", "toYaml"}
+
+// Serialization returns a CEL library that provides functions to embed
+// structured values as strings, e.g in the data of a ConfigMap.
+//
+// The following functions are available:
+//
+//	toJson(map|list) - the JSON document representing the value
+//	toYaml(map|list) - the YAML document representing the value
+//
+// The keys of the maps are sorted, so that the output is stable and doesn't
+// change between two reconciliations of the same value.
+//
+// Examples:
+//
+//	toJson({"b": 1, "a": [true]})
+//	// '{"a":[true],"b":1}'
+//	toYaml({"b": 1, "a": [true]})
+//	// "a:\n- true\nb: 1\n"
+func Serialization() cel.EnvOption {
+	return cel.Lib(&serializationLib{})
+}
+
+type serializationLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*serializationLib) LibraryName() string {
+	return "kro.serialization"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*serializationLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("toJson",
+			cel.Overload("kro_to_json_map",
+				[]*cel.Type{cel.MapType(cel.DynType, cel.DynType)},
+				cel.StringType,
+				cel.UnaryBinding(serializeWith("toJson", json.Marshal)),
+			),
+			cel.Overload("kro_to_json_list",
+				[]*cel.Type{cel.ListType(cel.DynType)},
+				cel.StringType,
+				cel.UnaryBinding(serializeWith("toJson", json.Marshal)),
+			),
+		),
+		cel.Function("toYaml",
+			cel.Overload("kro_to_yaml_map",
+				[]*cel.Type{cel.MapType(cel.DynType, cel.DynType)},
+				cel.StringType,
+				cel.UnaryBinding(serializeWith("toYaml", yaml.Marshal)),
+			),
+			cel.Overload("kro_to_yaml_list",
+				[]*cel.Type{cel.ListType(cel.DynType)},
+				cel.StringType,
+				cel.UnaryBinding(serializeWith("toYaml", yaml.Marshal)),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*serializationLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// serializeWith returns the implementation of a serialization function using
+// the given marshal function. Both encoding/json and sigs.k8s.io/yaml sort the
+// keys of the maps.
+func serializeWith(function string, marshal func(interface{}) ([]byte, error)) func(ref.Val) ref.Val {
+	return func(value ref.Val) ref.Val {
+		native, err := toSerializable(value)
+		if err != nil {
+			return types.NewErr("%s: %v", function, err)
+		}
+		out, err := marshal(native)
+		if err != nil {
+			return types.NewErr("%s: %v", function, err)
+		}
+		return types.String(out)
+	}
+}
+
+// toSerializable recursively converts a CEL value into plain Go values.
+func toSerializable(value ref.Val) (interface{}, error) {
+	switch v := value.(type) {
+	case traits.Mapper:
+		out := make(map[string]interface{}, int(v.Size().(types.Int)))
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			key := it.Next()
+			name, ok := key.(types.String)
+			if !ok {
+				return nil, fmt.Errorf("map keys must be strings, got %s", key.Type().TypeName())
+			}
+			entry, err := toSerializable(v.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			out[string(name)] = entry
+		}
+		return out, nil
+	case traits.Lister:
+		out := make([]interface{}, 0, int(v.Size().(types.Int)))
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			entry, err := toSerializable(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, entry)
+		}
+		return out, nil
+	default:
+		return GoNativeType(value)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerialization(t *testing.T) {
+	config := map[string]interface{}{
+		"schema": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"config": map[string]interface{}{
+					"server":   map[string]interface{}{"port": 8080, "host": "0.0.0.0"},
+					"features": []interface{}{"metrics", map[string]interface{}{"tracing": true}},
+					"name":     "web",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       interface{}
+		wantErr    string
+	}{
+		{
+			name:       "toJson nested map",
+			expression: `toJson(schema.spec.config)`,
+			vars:       config,
+			want:       `{"features":["metrics",{"tracing":true}],"name":"web","server":{"host":"0.0.0.0","port":8080}}`,
+		},
+		{
+			name:       "toYaml nested map",
+			expression: `toYaml(schema.spec.config)`,
+			vars:       config,
+			want: `features:
+- metrics
+- tracing: true
+name: web
+server:
+  host: 0.0.0.0
+  port: 8080
+`,
+		},
+		{
+			name:       "toJson literal map keys are sorted",
+			expression: `toJson({"b": 1, "a": [true, null], "c": {"z": "x", "y": 2.5}})`,
+			want:       `{"a":[true,null],"b":1,"c":{"y":2.5,"z":"x"}}`,
+		},
+		{
+			name:       "toYaml list",
+			expression: `toYaml(["a", "b"])`,
+			want:       "- a\n- b\n",
+		},
+		{
+			name:       "toJson empty map",
+			expression: `toJson({})`,
+			want:       `{}`,
+		},
+		{
+			name:       "non string keys",
+			expression: `toJson({1: "a"})`,
+			wantErr:    "toJson: map keys must be strings, got int",
+		},
+		{
+			name:       "scalars are not serialized",
+			expression: `toYaml("a")`,
+			wantErr:    "found no matching overload for 'toYaml'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithSerializationFunctions(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSerializationIsDeterministic(t *testing.T) {
+	expression := `toYaml({"e": 5, "c": {"b": 2, "a": 1}, "a": 1, "d": [{"y": 1, "x": 2}], "b": 2})`
+	first, err := evalExpression(t, expression, nil, WithSerializationFunctions())
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		got, err := evalExpression(t, expression, nil, WithSerializationFunctions())
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+	}
+}
+
+func TestSerializationFunctionsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `toJson({"a": "1"})`, nil)
+	assert.Error(t, err)
+}
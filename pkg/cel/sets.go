@@ -0,0 +1,135 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// Sets returns a CEL library that provides set operations over lists.
+//
+// The following functions are available:
+//
+//	set.diff(a, b)      - elements of a that are not in b
+//	set.union(a, b)     - elements of a followed by elements of b
+//	set.intersect(a, b) - elements of a that are also in b
+//
+// Lists are treated as sets: the outputs never contain duplicates, even if
+// the inputs do. To keep the outputs deterministic, elements are returned in
+// the order of their first appearance in the inputs (a first, then b).
+//
+// Examples:
+//
+//	set.diff([1, 2, 2, 3], [2])      // [1, 3]
+//	set.union([3, 1], [1, 2])        // [3, 1, 2]
+//	set.intersect([1, 2, 3], [3, 1]) // [1, 3]
+func Sets() cel.EnvOption {
+	return cel.Lib(&setsLib{})
+}
+
+type setsLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*setsLib) LibraryName() string {
+	return "kro.sets"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*setsLib) CompileOptions() []cel.EnvOption {
+	listType := cel.ListType(cel.TypeParamType("T"))
+	return []cel.EnvOption{
+		cel.Function("set.diff",
+			cel.Overload("set_diff_list_list",
+				[]*cel.Type{listType, listType}, listType,
+				cel.BinaryBinding(setDiff),
+			),
+		),
+		cel.Function("set.union",
+			cel.Overload("set_union_list_list",
+				[]*cel.Type{listType, listType}, listType,
+				cel.BinaryBinding(setUnion),
+			),
+		),
+		cel.Function("set.intersect",
+			cel.Overload("set_intersect_list_list",
+				[]*cel.Type{listType, listType}, listType,
+				cel.BinaryBinding(setIntersect),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*setsLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+func setDiff(lhs, rhs ref.Val) ref.Val {
+	return combineLists(lhs, rhs, func(inA, inB bool) bool { return inA && !inB })
+}
+
+func setUnion(lhs, rhs ref.Val) ref.Val {
+	return combineLists(lhs, rhs, func(inA, inB bool) bool { return inA || inB })
+}
+
+func setIntersect(lhs, rhs ref.Val) ref.Val {
+	return combineLists(lhs, rhs, func(inA, inB bool) bool { return inA && inB })
+}
+
+// combineLists walks the elements of a then b, in order, and keeps the ones
+// for which keep returns true. Each element is kept at most once.
+func combineLists(lhs, rhs ref.Val, keep func(inA, inB bool) bool) ref.Val {
+	a, ok := lhs.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(lhs)
+	}
+	b, ok := rhs.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(rhs)
+	}
+
+	result := []ref.Val{}
+	for _, list := range []traits.Lister{a, b} {
+		it := list.Iterator()
+		for it.HasNext() == types.True {
+			elem := it.Next()
+			if containsElement(result, elem) {
+				continue
+			}
+			if keep(listContains(a, elem), listContains(b, elem)) {
+				result = append(result, elem)
+			}
+		}
+	}
+	return types.NewRefValList(types.DefaultTypeAdapter, result)
+}
+
+// listContains returns true if the list contains the given element.
+func listContains(list traits.Lister, elem ref.Val) bool {
+	return list.Contains(elem) == types.True
+}
+
+// containsElement returns true if elems contains the given element, using
+// CEL equality semantics.
+func containsElement(elems []ref.Val, elem ref.Val) bool {
+	for _, e := range elems {
+		if e.Equal(elem) == types.True {
+			return true
+		}
+	}
+	return false
+}
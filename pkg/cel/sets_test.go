@@ -0,0 +1,168 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evalExpression compiles and evaluates the given expression in env and
+// returns its Go native value.
+func evalExpression(t *testing.T, expression string, vars map[string]interface{}, options ...EnvOption) (interface{}, error) {
+	t.Helper()
+
+	env, err := DefaultEnvironment(options...)
+	require.NoError(t, err)
+
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	program, err := env.Program(ast)
+	require.NoError(t, err)
+
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	val, _, err := program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return GoNativeType(val)
+}
+
+func TestSetFunctions(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       []interface{}
+	}{
+		// set.diff
+		{
+			name:       "diff removes elements of b",
+			expression: `set.diff([1, 2, 3, 4], [2, 4])`,
+			want:       []interface{}{int64(1), int64(3)},
+		},
+		{
+			name:       "diff keeps order of a",
+			expression: `set.diff(["c", "a", "b"], ["a"])`,
+			want:       []interface{}{"c", "b"},
+		},
+		{
+			name:       "diff deduplicates a",
+			expression: `set.diff([1, 1, 2, 3, 3], [2])`,
+			want:       []interface{}{int64(1), int64(3)},
+		},
+		{
+			name:       "diff with empty a",
+			expression: `set.diff([], [1, 2])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "diff with empty b",
+			expression: `set.diff([1, 2, 2], [])`,
+			want:       []interface{}{int64(1), int64(2)},
+		},
+		{
+			name:       "diff with both empty",
+			expression: `set.diff([], [])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "diff of dynamic lists",
+			expression: `set.diff(desired.subnets, current.subnets)`,
+			vars: map[string]interface{}{
+				"desired": map[string]interface{}{"subnets": []interface{}{"subnet-a", "subnet-b", "subnet-c"}},
+				"current": map[string]interface{}{"subnets": []interface{}{"subnet-b"}},
+			},
+			want: []interface{}{"subnet-a", "subnet-c"},
+		},
+
+		// set.union
+		{
+			name:       "union appends new elements of b",
+			expression: `set.union([3, 1], [1, 2])`,
+			want:       []interface{}{int64(3), int64(1), int64(2)},
+		},
+		{
+			name:       "union deduplicates both inputs",
+			expression: `set.union(["a", "a", "b"], ["b", "c", "c"])`,
+			want:       []interface{}{"a", "b", "c"},
+		},
+		{
+			name:       "union with empty a",
+			expression: `set.union([], [2, 1, 2])`,
+			want:       []interface{}{int64(2), int64(1)},
+		},
+		{
+			name:       "union with empty b",
+			expression: `set.union([2, 1], [])`,
+			want:       []interface{}{int64(2), int64(1)},
+		},
+		{
+			name:       "union with both empty",
+			expression: `set.union([], [])`,
+			want:       []interface{}{},
+		},
+
+		// set.intersect
+		{
+			name:       "intersect keeps common elements in order of a",
+			expression: `set.intersect([1, 2, 3], [3, 1])`,
+			want:       []interface{}{int64(1), int64(3)},
+		},
+		{
+			name:       "intersect deduplicates",
+			expression: `set.intersect(["a", "b", "a", "b"], ["b", "b", "a"])`,
+			want:       []interface{}{"a", "b"},
+		},
+		{
+			name:       "intersect with disjoint lists",
+			expression: `set.intersect([1, 2], [3, 4])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "intersect with empty a",
+			expression: `set.intersect([], [1])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "intersect with empty b",
+			expression: `set.intersect([1], [])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "intersect of maps",
+			expression: `set.intersect([{"a": 1}, {"b": 2}], [{"b": 2}]).map(m, m.b)`,
+			want:       []interface{}{int64(2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithSetFunctions(), WithResourceIDs([]string{"desired", "current"}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetFunctionsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `set.diff([1], [2])`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/parser"
+)
+
+// sortByKeysFunction is the internal function the sort and sortBy macros
+// expand to.
+const sortByKeysFunction = "@kro.sortByKeys"
+
+// sortInputVariable is the variable the list to sort is bound to.
+const sortInputVariable = "@__sort_input__"
+
+// Sort returns a CEL library that provides functions to sort lists.
+//
+// The following functions are available:
+//
+//	sort(list)               - the elements of list in ascending order
+//	sortBy(list, x, keyExpr) - the elements of list ordered by the value of
+//	                           keyExpr, evaluated with x bound to each element
+//
+// The elements (or keys) must all be of the same comparable type: int, uint,
+// double, bool, string, bytes, duration or timestamp. Sorting is stable:
+// elements that compare equal keep their relative order, so the outputs are
+// deterministic.
+//
+// Examples:
+//
+//	sort([3, 1, 2])                          // [1, 2, 3]
+//	sortBy([{"n": "b"}, {"n": "a"}], e, e.n) // [{"n": "a"}, {"n": "b"}]
+//	sortBy(["bb", "a", "cc"], s, size(s))    // ["a", "bb", "cc"]
+func Sort() cel.EnvOption {
+	return cel.Lib(&sortLib{})
+}
+
+type sortLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*sortLib) LibraryName() string {
+	return "kro.sort"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*sortLib) CompileOptions() []cel.EnvOption {
+	listType := cel.ListType(cel.TypeParamType("T"))
+	keysType := cel.ListType(cel.TypeParamType("U"))
+	return []cel.EnvOption{
+		cel.Function(sortByKeysFunction,
+			cel.Overload("kro_sort_by_keys_list_list",
+				[]*cel.Type{listType, keysType}, listType,
+				cel.BinaryBinding(sortListByKeys),
+			),
+		),
+		// sort and sortBy are macros rather than functions, as the lists
+		// extension already declares a (member) sort function with a
+		// singleton binding that can't be overloaded.
+		cel.Macros(
+			cel.GlobalMacro("sort", 1, sortMacro),
+			cel.GlobalMacro("sortBy", 3, sortByMacro),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*sortLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// sortListByKeys stably sorts the elements of list according to the order of
+// the associated keys.
+func sortListByKeys(listVal, keysVal ref.Val) ref.Val {
+	list, ok := listVal.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(listVal)
+	}
+	keys, ok := keysVal.(traits.Lister)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(keysVal)
+	}
+
+	size := int(list.Size().(types.Int))
+	if keysSize := int(keys.Size().(types.Int)); size != keysSize {
+		return types.NewErr("sort: expected %d keys, got %d", size, keysSize)
+	}
+
+	elems := make([]ref.Val, size)
+	sortKeys := make([]traits.Comparer, size)
+	for i := 0; i < size; i++ {
+		elems[i] = list.Get(types.Int(i))
+		key := keys.Get(types.Int(i))
+		comparer, ok := key.(traits.Comparer)
+		if !ok {
+			return types.NewErr("sort: values of type %s are not comparable", key.Type().TypeName())
+		}
+		if i > 0 && key.Type() != keys.Get(types.IntZero).Type() {
+			return types.NewErr("sort: values must be of the same type, got %s and %s",
+				keys.Get(types.IntZero).Type().TypeName(), key.Type().TypeName())
+		}
+		sortKeys[i] = comparer
+	}
+
+	indices := make([]int, size)
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return sortKeys[indices[i]].Compare(sortKeys[indices[j]].(ref.Val)) == types.IntNegOne
+	})
+
+	sorted := make([]ref.Val, size)
+	for i, index := range indices {
+		sorted[i] = elems[index]
+	}
+	return types.NewRefValList(types.DefaultTypeAdapter, sorted)
+}
+
+// sortMacro expands sort(list) into an expression equivalent to:
+//
+//	cel.bind(input, list, @kro.sortByKeys(input, input))
+func sortMacro(meh cel.MacroExprFactory, _ ast.Expr, args []ast.Expr) (ast.Expr, *cel.Error) {
+	input := meh.NewIdent(sortInputVariable)
+	return bindSortInput(meh, args[0], meh.NewCall(sortByKeysFunction, meh.Copy(input), meh.Copy(input))), nil
+}
+
+// sortByMacro expands sortBy(list, x, keyExpr) into an expression equivalent
+// to:
+//
+//	cel.bind(input, list, @kro.sortByKeys(input, input.map(x, keyExpr)))
+func sortByMacro(meh cel.MacroExprFactory, _ ast.Expr, args []ast.Expr) (ast.Expr, *cel.Error) {
+	input := meh.NewIdent(sortInputVariable)
+	keys, err := parser.MakeMap(meh, meh.Copy(input), args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return bindSortInput(meh, args[0], meh.NewCall(sortByKeysFunction, meh.Copy(input), keys)), nil
+}
+
+// bindSortInput binds the list to sort to a variable, so that it is only
+// evaluated once, and returns the result of the given expression.
+func bindSortInput(meh cel.MacroExprFactory, list, result ast.Expr) ast.Expr {
+	return meh.NewComprehension(
+		meh.NewList(),
+		"#unused",
+		sortInputVariable,
+		list,
+		meh.NewLiteral(types.False),
+		meh.NewIdent(sortInputVariable),
+		result,
+	)
+}
@@ -0,0 +1,131 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortFunctions(t *testing.T) {
+	envVars := map[string]interface{}{
+		"schema": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"env": []interface{}{
+					map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+					map[string]interface{}{"name": "API_URL", "value": "https://example.com"},
+					map[string]interface{}{"name": "DB_HOST", "value": "db"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		vars       map[string]interface{}
+		want       []interface{}
+		wantErr    bool
+	}{
+		// sort
+		{
+			name:       "sort integers",
+			expression: `sort([3, 1, 2])`,
+			want:       []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:       "sort strings",
+			expression: `sort(["b", "c", "a"])`,
+			want:       []interface{}{"a", "b", "c"},
+		},
+		{
+			name:       "sort doubles",
+			expression: `sort([2.5, -1.0, 0.5])`,
+			want:       []interface{}{-1.0, 0.5, 2.5},
+		},
+		{
+			name:       "sort keeps duplicates",
+			expression: `sort([2, 1, 2, 1])`,
+			want:       []interface{}{int64(1), int64(1), int64(2), int64(2)},
+		},
+		{
+			name:       "sort empty list",
+			expression: `sort([])`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "sort mixed types",
+			expression: `sort([1, "a"])`,
+			wantErr:    true,
+		},
+		{
+			name:       "sort non comparable elements",
+			expression: `sort([[1], [2]])`,
+			wantErr:    true,
+		},
+
+		// sortBy
+		{
+			name:       "sortBy object field",
+			expression: `sortBy(schema.spec.env, e, e.name).map(e, e.name)`,
+			vars:       envVars,
+			want:       []interface{}{"API_URL", "DB_HOST", "LOG_LEVEL"},
+		},
+		{
+			name:       "sortBy computed key",
+			expression: `sortBy(schema.spec.env, e, -size(e.value)).map(e, e.name)`,
+			vars:       envVars,
+			want:       []interface{}{"API_URL", "LOG_LEVEL", "DB_HOST"},
+		},
+		{
+			name:       "sortBy is stable",
+			expression: `sortBy(["bb", "a", "cc", "b", "aa"], s, size(s))`,
+			want:       []interface{}{"a", "b", "bb", "cc", "aa"},
+		},
+		{
+			name:       "sortBy empty list",
+			expression: `sortBy([], x, x)`,
+			want:       []interface{}{},
+		},
+		{
+			name:       "sortBy non comparable key",
+			expression: `sortBy([1, 2], x, [x])`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, tt.vars, WithSortFunctions(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSortByRequiresIdentifier(t *testing.T) {
+	_, err := evalExpression(t, `sortBy([1, 2], x.y, x)`, nil, WithSortFunctions())
+	assert.Error(t, err)
+}
+
+func TestSortFunctionsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `sortBy([2, 1], x, x)`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/uuid"
+)
+
+// stableIDFunction is the internal function the stableID macro expands to.
+const stableIDFunction = "@kro.stableID"
+
+// stableIDNamespace is the namespace of the name-based UUIDs derived from
+// the instance UIDs. It must never change, or all the stable IDs would.
+var stableIDNamespace = uuid.MustParse("4b8f2d1e-6c3a-5e97-8d0b-2f7a9c4e1b63")
+
+// StableIDFromUID returns the stable identifier of the instance with the
+// given UID: a name-based (version 5) UUID derived from the UID. It is the
+// value of the stableID function, and of the status.stableID field of the
+// instances.
+func StableIDFromUID(uid string) string {
+	return uuid.NewSHA1(stableIDNamespace, []byte(uid)).String()
+}
+
+// StableID returns a CEL library that provides a stable identifier of the
+// instance, for the external systems (e.g cost tracking or CMDBs) tracking
+// the resources of the instances.
+//
+// The following function is available:
+//
+//	stableID() - a UUID derived from the UID of the instance
+//
+// Unlike instanceHash, the identifier only depends on the UID: it is the same
+// across all the reconciles of an instance whatever its name, and differs
+// between instances, including an instance deleted and recreated with the
+// same name. It is also reported in the status.stableID field of the
+// instances. The instance is read from the schema variable, which must be
+// declared in the environment.
+//
+// Examples:
+//
+//	stableID() // "bbe50873-6e25-5e91-bbce-f7a0b3508302"
+func StableID() cel.EnvOption {
+	return cel.Lib(&stableIDLib{})
+}
+
+type stableIDLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*stableIDLib) LibraryName() string {
+	return "kro.stableID"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*stableIDLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function(stableIDFunction,
+			cel.Overload("kro_stable_id_dyn",
+				[]*cel.Type{cel.DynType}, cel.StringType,
+				cel.UnaryBinding(stableID),
+			),
+		),
+		// stableID is a macro reading the instance variable, so that the
+		// expressions using it depend on the instance like any other
+		// expression reading it.
+		cel.Macros(
+			cel.GlobalMacro("stableID", 0, stableIDMacro),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*stableIDLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// stableIDMacro expands stableID() into @kro.stableID(schema).
+func stableIDMacro(meh cel.MacroExprFactory, _ ast.Expr, _ []ast.Expr) (ast.Expr, *cel.Error) {
+	return meh.NewCall(stableIDFunction, meh.NewIdent(instanceVariable)), nil
+}
+
+// stableID returns the stable identifier of the given instance. A missing UID
+// is derived as an empty string.
+func stableID(instanceVal ref.Val) ref.Val {
+	instance, ok := instanceVal.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(instanceVal)
+	}
+	var uid string
+	if metadataVal, found := instance.Find(types.String("metadata")); found {
+		if metadata, ok := metadataVal.(traits.Mapper); ok {
+			uid = stringField(metadata, "uid")
+		}
+	}
+	return types.String(StableIDFromUID(uid))
+}
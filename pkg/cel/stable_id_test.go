@@ -0,0 +1,66 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStableID(t *testing.T) {
+	stableID := func(vars map[string]interface{}) string {
+		t.Helper()
+		got, err := evalExpression(t, `stableID()`, vars, WithStableIDFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		return got.(string)
+	}
+
+	first := stableID(newHashedInstance("0b6c7a8e", "default", "app", 1))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), first)
+	assert.Equal(t, StableIDFromUID("0b6c7a8e"), first)
+
+	t.Run("stable across reconciles", func(t *testing.T) {
+		assert.Equal(t, first, stableID(newHashedInstance("0b6c7a8e", "default", "app", 3)))
+		// Only the UID matters, unlike instanceHash.
+		assert.Equal(t, first, stableID(newHashedInstance("0b6c7a8e", "team-a", "renamed", 1)))
+	})
+
+	t.Run("unique across instances", func(t *testing.T) {
+		assert.NotEqual(t, first, stableID(newHashedInstance("5d1e2f3a", "default", "other", 1)))
+		// An instance recreated with the same name gets a new UID.
+		assert.NotEqual(t, first, stableID(newHashedInstance("9f8e7d6c", "default", "app", 1)))
+	})
+
+	t.Run("in a label value", func(t *testing.T) {
+		got, err := evalExpression(t, `"id-" + stableID()`, newHashedInstance("0b6c7a8e", "default", "app", 1),
+			WithStableIDFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		assert.Equal(t, "id-"+first, got)
+	})
+
+	t.Run("instance without metadata", func(t *testing.T) {
+		got, err := evalExpression(t, `stableID()`, map[string]interface{}{"schema": map[string]interface{}{}},
+			WithStableIDFunction(), WithResourceIDs([]string{"schema"}))
+		require.NoError(t, err)
+		assert.Equal(t, StableIDFromUID(""), got)
+	})
+}
+
+func TestStableIDDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `stableID()`, nil, WithResourceIDs([]string{"schema"}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"unicode/utf8"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// StringLimits returns a CEL library that truncates strings to a length
+// limit without splitting the multibyte characters, e.g. to derive label
+// values, which are limited to 63 characters, from user input. Slicing a
+// string by bytes could produce invalid UTF-8, which the API server rejects.
+//
+// The following functions are available:
+//
+//	truncate(s, n)      - the first n runes (characters) of s
+//	truncateBytes(s, n) - the longest prefix of s of at most n bytes
+//
+// truncate counts runes, truncateBytes counts the bytes of the UTF-8
+// encoding: it drops a multibyte rune entirely rather than keeping a part of
+// it, so its result may be shorter than n bytes. Both return s unchanged when
+// it is within the limit, and report an error for a negative limit.
+//
+// Examples:
+//
+//	truncate("héllo", 2)                   // "hé"
+//	truncateBytes("héllo", 2)              // "h", "é" takes 2 bytes
+//	truncate(schema.spec.name, 63) + "-db" // a name of at most 66 runes
+func StringLimits() cel.EnvOption {
+	return cel.Lib(&stringLimitsLib{})
+}
+
+type stringLimitsLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*stringLimitsLib) LibraryName() string {
+	return "kro.stringLimits"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*stringLimitsLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("truncate",
+			cel.Overload("kro_truncate_string_int",
+				[]*cel.Type{cel.StringType, cel.IntType}, cel.StringType,
+				cel.BinaryBinding(truncateRunes),
+			),
+		),
+		cel.Function("truncateBytes",
+			cel.Overload("kro_truncate_bytes_string_int",
+				[]*cel.Type{cel.StringType, cel.IntType}, cel.StringType,
+				cel.BinaryBinding(truncateBytes),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*stringLimitsLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// truncateRunes truncates a string to a number of runes.
+func truncateRunes(str, limit ref.Val) ref.Val {
+	s, ok := str.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(str)
+	}
+	n, ok := limit.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(limit)
+	}
+	if n < 0 {
+		return types.NewErr("truncate: negative limit %d", n)
+	}
+	runes := 0
+	for i := range string(s) {
+		if runes == int(n) {
+			return s[:i]
+		}
+		runes++
+	}
+	return s
+}
+
+// truncateBytes truncates a string to a number of bytes, backing off to the
+// start of the rune the limit falls in.
+func truncateBytes(str, limit ref.Val) ref.Val {
+	s, ok := str.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(str)
+	}
+	n, ok := limit.(types.Int)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(limit)
+	}
+	if n < 0 {
+		return types.NewErr("truncateBytes: negative limit %d", n)
+	}
+	if int64(n) >= int64(len(s)) {
+		return s
+	}
+	end := int(n)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
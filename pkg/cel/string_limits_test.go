@@ -0,0 +1,69 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       string
+		wantErr    string
+	}{
+		// truncate counts runes
+		{name: "truncate ascii", expression: `truncate("hello", 3)`, want: "hel"},
+		{name: "truncate within the limit", expression: `truncate("hello", 10)`, want: "hello"},
+		{name: "truncate to zero", expression: `truncate("hello", 0)`, want: ""},
+		{name: "truncate multibyte", expression: `truncate("héllo", 2)`, want: "hé"},
+		{name: "truncate cjk", expression: `truncate("日本語の名前", 3)`, want: "日本語"},
+		{name: "truncate emoji", expression: `truncate("a🚀b", 2)`, want: "a🚀"},
+		{name: "truncate negative limit", expression: `truncate("hello", -1)`, wantErr: "truncate: negative limit -1"},
+
+		// truncateBytes counts bytes
+		{name: "truncateBytes ascii", expression: `truncateBytes("hello", 3)`, want: "hel"},
+		{name: "truncateBytes within the limit", expression: `truncateBytes("héllo", 6)`, want: "héllo"},
+		{name: "truncateBytes on a rune boundary", expression: `truncateBytes("héllo", 3)`, want: "hé"},
+		{name: "truncateBytes inside a rune", expression: `truncateBytes("héllo", 2)`, want: "h"},
+		{name: "truncateBytes inside a cjk rune", expression: `truncateBytes("日本語", 7)`, want: "日本"},
+		{name: "truncateBytes inside an emoji", expression: `truncateBytes("a🚀b", 4)`, want: "a"},
+		{name: "truncateBytes inside the first rune", expression: `truncateBytes("日本", 1)`, want: ""},
+		{name: "truncateBytes negative limit", expression: `truncateBytes("hello", -1)`, wantErr: "truncateBytes: negative limit -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, nil, WithStringLimitFunctions())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, utf8.ValidString(got.(string)))
+		})
+	}
+}
+
+func TestStringLimitsDisabledByDefault(t *testing.T) {
+	_, err := evalExpression(t, `truncate("hello", 3)`, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,139 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+var (
+	// templateActionRegex matches the {{ }} actions of a template.
+	templateActionRegex = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	// templateFieldRegex matches the field references supported in the
+	// actions, e.g .name or .database.host.
+	templateFieldRegex = regexp.MustCompile(`^\.[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+)
+
+// TemplateString returns a CEL library that renders strings using a subset of
+// the Go template syntax.
+//
+// The following functions are available:
+//
+//	templateString(template, data) - template with each {{ .field }} action
+//	                                 replaced by the value of field in data
+//
+// Only field references are supported, nested fields being separated by dots.
+// The fields must be strings, numbers or booleans. A missing field, or any
+// other action, is an error.
+//
+// Examples:
+//
+//	templateString("{{ .name }}.{{ .namespace }}.svc", {"name": "api", "namespace": "prod"})
+//	// "api.prod.svc"
+func TemplateString() cel.EnvOption {
+	return cel.Lib(&templateStringLib{})
+}
+
+type templateStringLib struct{}
+
+// LibraryName implements the cel.SingletonLibrary interface.
+func (*templateStringLib) LibraryName() string {
+	return "kro.templateString"
+}
+
+// CompileOptions implements the cel.Library interface.
+func (*templateStringLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("templateString",
+			cel.Overload("kro_template_string_string_map",
+				[]*cel.Type{cel.StringType, cel.MapType(cel.StringType, cel.DynType)},
+				cel.StringType,
+				cel.BinaryBinding(templateString),
+			),
+		),
+	}
+}
+
+// ProgramOptions implements the cel.Library interface.
+func (*templateStringLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+// templateString replaces the {{ .field }} actions of the template with the
+// values of the fields in data.
+func templateString(templateVal, dataVal ref.Val) ref.Val {
+	template, ok := templateVal.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(templateVal)
+	}
+	data, ok := dataVal.(traits.Mapper)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(dataVal)
+	}
+
+	var rendered strings.Builder
+	remaining := string(template)
+	for {
+		location := templateActionRegex.FindStringSubmatchIndex(remaining)
+		if location == nil {
+			break
+		}
+		rendered.WriteString(remaining[:location[0]])
+		action := strings.TrimSpace(remaining[location[2]:location[3]])
+		if !templateFieldRegex.MatchString(action) {
+			return types.NewErr("templateString: unsupported action {{ %s }}, only field references like {{ .name }} are supported", action)
+		}
+		value, err := templateField(data, action)
+		if err != nil {
+			return err
+		}
+		rendered.WriteString(value)
+		remaining = remaining[location[1]:]
+	}
+	if strings.Contains(remaining, "{{") {
+		return types.NewErr("templateString: unterminated action in %q", string(template))
+	}
+	rendered.WriteString(remaining)
+	return types.String(rendered.String())
+}
+
+// templateField returns the value of the given field of data, e.g
+// .database.host, as a string.
+func templateField(data traits.Mapper, field string) (string, ref.Val) {
+	var value ref.Val = data
+	for _, name := range strings.Split(strings.TrimPrefix(field, "."), ".") {
+		mapper, ok := value.(traits.Mapper)
+		if !ok {
+			return "", types.NewErr("templateString: field %s is not a map", field)
+		}
+		value, ok = mapper.Find(types.String(name))
+		if !ok {
+			return "", types.NewErr("templateString: no such key %s", field)
+		}
+	}
+
+	switch value.(type) {
+	case types.String, types.Int, types.Uint, types.Double, types.Bool:
+		if str, ok := value.ConvertToType(types.StringType).(types.String); ok {
+			return string(str), nil
+		}
+	}
+	return "", types.NewErr("templateString: field %s must be a string, a number or a boolean, got %s", field, value.Type().TypeName())
+}
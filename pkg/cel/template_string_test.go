@@ -0,0 +1,111 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateString(t *testing.T) {
+	schema := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"name":     "api",
+			"replicas": 3,
+			"public":   true,
+			"database": map[string]interface{}{"host": "db.internal", "port": 5432},
+			"tags":     []interface{}{"a"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		expression string
+		want       interface{}
+		wantErr    string
+	}{
+		{
+			name:       "full substitution",
+			expression: `templateString("{{ .name }}.{{.namespace}}.svc", {"name": "api", "namespace": "prod"})`,
+			want:       "api.prod.svc",
+		},
+		{
+			name:       "fields of a resource",
+			expression: `templateString("{{ .name }} x{{ .replicas }} public={{ .public }}", schema.spec)`,
+			want:       "api x3 public=true",
+		},
+		{
+			name:       "nested fields",
+			expression: `templateString("postgres://{{ .database.host }}:{{ .database.port }}", schema.spec)`,
+			want:       "postgres://db.internal:5432",
+		},
+		{
+			name:       "no action",
+			expression: `templateString("static", {})`,
+			want:       "static",
+		},
+		{
+			name:       "missing field",
+			expression: `templateString("{{ .name }}-{{ .region }}", schema.spec)`,
+			wantErr:    "templateString: no such key .region",
+		},
+		{
+			name:       "missing nested field",
+			expression: `templateString("{{ .database.user }}", schema.spec)`,
+			wantErr:    "templateString: no such key .database.user",
+		},
+		{
+			name:       "field of a non map",
+			expression: `templateString("{{ .name.first }}", schema.spec)`,
+			wantErr:    "templateString: field .name.first is not a map",
+		},
+		{
+			name:       "non scalar field",
+			expression: `templateString("{{ .tags }}", schema.spec)`,
+			wantErr:    "templateString: field .tags must be a string, a number or a boolean, got list",
+		},
+		{
+			name:       "unsupported action",
+			expression: `templateString("{{ range .tags }}", schema.spec)`,
+			wantErr:    "templateString: unsupported action {{ range .tags }}",
+		},
+		{
+			name:       "unterminated action",
+			expression: `templateString("{{ .name }}-{{ .replicas", schema.spec)`,
+			wantErr:    "templateString: unterminated action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalExpression(t, tt.expression, map[string]interface{}{"schema": schema},
+				WithTemplateStringFunction(), WithResourceIDs([]string{"schema"}))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		_, err := evalExpression(t, `templateString("{{ .name }}", {"name": "api"})`, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "undeclared reference to 'templateString'")
+	})
+}
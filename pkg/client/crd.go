@@ -46,6 +46,9 @@ type CRDClient interface {
 
 	// Get retrieves a CRD by name
 	Get(ctx context.Context, name string) (*v1.CustomResourceDefinition, error)
+
+	// List retrieves the CRDs matching the given label selector
+	List(ctx context.Context, labelSelector string) ([]v1.CustomResourceDefinition, error)
 }
 
 // CRDWrapper provides a simplified interface for CRD operations
@@ -120,6 +123,15 @@ func (w *CRDWrapper) Get(ctx context.Context, name string) (*v1.CustomResourceDe
 	return w.client.Get(ctx, name, metav1.GetOptions{})
 }
 
+// List retrieves the CRDs matching the given label selector
+func (w *CRDWrapper) List(ctx context.Context, labelSelector string) ([]v1.CustomResourceDefinition, error) {
+	list, err := w.client.List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 func (w *CRDWrapper) create(ctx context.Context, crd v1.CustomResourceDefinition) error {
 	_, err := w.client.Create(ctx, &crd, metav1.CreateOptions{})
 	return err
@@ -0,0 +1,204 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+// StartWatchingChildren makes the controller self-heal the children of the
+// objects of parentGVR: when a child is deleted, e.g. out-of-band by a user,
+// its parent is enqueued, so that the child gets recreated.
+//
+// The children are the objects of childGVRs labeled as owned by kro, whose
+// resource group ID label is parentID. Their parent is found using their
+// instance name and namespace labels.
+//
+// The informers of the child GVRs are shared by all the parents, they keep
+// running until the controller shuts down.
+func (dc *DynamicController) StartWatchingChildren(
+	ctx context.Context,
+	parentGVR schema.GroupVersionResource,
+	parentID string,
+	childGVRs []schema.GroupVersionResource,
+) error {
+	dc.parents.Store(parentID, parentGVR)
+	for _, gvr := range childGVRs {
+		if err := dc.startChildInformer(ctx, gvr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StopWatchingChildren stops enqueuing the parents of the children labeled
+// with the given parent ID.
+func (dc *DynamicController) StopWatchingChildren(parentID string) {
+	dc.parents.Delete(parentID)
+}
+
+// startChildInformer starts the informer watching the deletions of the
+// children of the given GVR, if it isn't running yet, and waits for its
+// initial sync. The wait is bounded by ctx and ChildInformerSyncTimeout, and
+// doesn't hold the lock, so that a GVR that isn't served doesn't block the
+// other parents. An informer failing to sync is stopped, and started again by
+// the next call.
+func (dc *DynamicController) startChildInformer(ctx context.Context, gvr schema.GroupVersionResource) error {
+	wrapper, informer, err := dc.registerChildInformer(gvr)
+	if err != nil || wrapper == nil {
+		return err
+	}
+
+	timeout := dc.config.ChildInformerSyncTimeout
+	if timeout <= 0 {
+		timeout = DefaultChildInformerSyncTimeout
+	}
+	syncCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		dc.childInformers.CompareAndDelete(gvr, wrapper)
+		wrapper.shutdown()
+		return fmt.Errorf("failed to sync child informer cache for GVR %s", gvr)
+	}
+	informerSyncDuration.WithLabelValues(gvr.String()).Observe(time.Since(startTime).Seconds())
+	return nil
+}
+
+// registerChildInformer creates, registers and runs the informer of the given
+// child GVR. It returns a nil wrapper if the informer is already registered.
+func (dc *DynamicController) registerChildInformer(gvr schema.GroupVersionResource) (*informerWrapper, cache.SharedIndexInformer, error) {
+	dc.childInformersMu.Lock()
+	defer dc.childInformersMu.Unlock()
+
+	if _, exists := dc.childInformers.Load(gvr); exists {
+		return nil, nil, nil
+	}
+
+	dc.log.V(1).Info("Starting child informer", "gvr", gvr)
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
+		dc.kubeClient,
+		dc.config.ResyncPeriod,
+		"",
+		InformerSelector{LabelSelector: metadata.OwnedLabel + "=true"}.tweakListOptions(),
+	)
+	informer := factory.ForResource(gvr).Informer()
+
+	// Only the deletions matter, the parents are already reconciled when
+	// their children are created or updated. Resyncs optionally catch up
+	// with the changes missed while the watch was broken.
+	handler := cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) { dc.enqueueParent(obj, gvr) },
+	}
+	if dc.config.RequeueOnChildResync {
+		handler.UpdateFunc = func(oldObj, newObj interface{}) { dc.enqueueParentOnResync(oldObj, newObj, gvr) }
+	}
+	if _, err := informer.AddEventHandler(handler); err != nil {
+		return nil, nil, fmt.Errorf("failed to add event handler for child GVR %s: %w", gvr, err)
+	}
+	informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		dc.log.Error(err, "Watch error", "gvr", gvr)
+	})
+
+	// The informer outlives the call, it runs until the controller shuts
+	// down or it fails to sync.
+	ctx, cancel := context.WithCancel(context.Background())
+	go informer.Run(ctx.Done())
+
+	wrapper := &informerWrapper{
+		informer: factory,
+		shutdown: cancel,
+	}
+	dc.childInformers.Store(gvr, wrapper)
+	return wrapper, informer, nil
+}
+
+// enqueueParent adds the parent of a deleted child to the workqueue.
+func (dc *DynamicController) enqueueParent(obj interface{}, childGVR schema.GroupVersionResource) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	child, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		dc.log.Error(nil, "failed to cast deleted child to unstructured", "gvr", childGVR)
+		return
+	}
+	objectIdentifiers, ok := dc.parentOf(child)
+	if !ok {
+		return
+	}
+	dc.log.V(1).Info("Enqueueing parent of deleted child",
+		"objectIdentifiers", objectIdentifiers,
+		"child", child.GetName(),
+		"childGVR", childGVR)
+
+	informerEventsTotal.WithLabelValues(childGVR.String(), "child_delete").Inc()
+	dc.queue.Add(objectIdentifiers)
+}
+
+// enqueueParentOnResync adds the parent of a resynced child to the workqueue,
+// once the coalescing period elapsed. The updates of the child are ignored:
+// only the resyncs, that don't change the resource version, are considered.
+func (dc *DynamicController) enqueueParentOnResync(oldObj, newObj interface{}, childGVR schema.GroupVersionResource) {
+	oldChild, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	child, ok := newObj.(*unstructured.Unstructured)
+	if !ok || child.GetResourceVersion() != oldChild.GetResourceVersion() {
+		return
+	}
+	objectIdentifiers, ok := dc.parentOf(child)
+	if !ok {
+		return
+	}
+
+	informerEventsTotal.WithLabelValues(childGVR.String(), "child_resync").Inc()
+	// The delaying queue only keeps the earliest of the pending additions
+	// of an item: the parent is enqueued once per coalescing period, however
+	// many of its children resync.
+	period := dc.config.ChildResyncCoalescePeriod
+	if period <= 0 {
+		period = DefaultChildResyncCoalescePeriod
+	}
+	dc.queue.AddAfter(objectIdentifiers, period)
+}
+
+// parentOf returns the identifiers of the parent of a child, found using its
+// labels. It returns false if the child isn't labeled with a watched parent.
+func (dc *DynamicController) parentOf(child *unstructured.Unstructured) (ObjectIdentifiers, bool) {
+	labels := child.GetLabels()
+	parentGVR, ok := dc.parents.Load(labels[metadata.ResourceGroupIDLabel])
+	if !ok || labels[metadata.InstanceLabel] == "" {
+		return ObjectIdentifiers{}, false
+	}
+	namespacedKey := labels[metadata.InstanceLabel]
+	if namespace := labels[metadata.InstanceNamespaceLabel]; namespace != "" {
+		namespacedKey = namespace + "/" + namespacedKey
+	}
+	return ObjectIdentifiers{
+		NamespacedKey: namespacedKey,
+		GVR:           parentGVR.(schema.GroupVersionResource),
+	}, true
+}
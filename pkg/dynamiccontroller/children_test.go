@@ -0,0 +1,211 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+
+	"github.com/awslabs/kro/internal/metadata"
+)
+
+func TestStartWatchingChildren(t *testing.T) {
+	parentGVR := schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "webapps"}
+	childGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newChild := func(name, parentID string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetLabels(map[string]string{
+			metadata.OwnedLabel:             "true",
+			metadata.ResourceGroupIDLabel:   parentID,
+			metadata.InstanceLabel:          "my-app",
+			metadata.InstanceNamespaceLabel: "default",
+		})
+		return obj
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			parentGVR: "WebAppList",
+			childGVR:  "ConfigMapList",
+		},
+		newChild("app-config", "rg-uid"),
+		newChild("other-config", "other-rg-uid"),
+	)
+	dc := NewDynamicController(noopLogger(), Config{
+		ResyncPeriod:    10 * time.Hour,
+		ShutdownTimeout: 5 * time.Second,
+	}, client)
+
+	var requests []string
+	handlerFunc := Handler(func(ctx context.Context, req controllerruntime.Request) error {
+		requests = append(requests, req.Name)
+		return nil
+	})
+	ctx := context.Background()
+	require.NoError(t, dc.StartServingGVK(ctx, parentGVR, handlerFunc))
+	require.NoError(t, dc.StartWatchingChildren(ctx, parentGVR, "rg-uid", []schema.GroupVersionResource{childGVR}))
+	defer func() {
+		require.NoError(t, dc.gracefulShutdown(5*time.Second))
+	}()
+
+	// Watching the children of another parent reuses the child informer.
+	require.NoError(t, dc.StartWatchingChildren(ctx, parentGVR, "another-rg-uid", []schema.GroupVersionResource{childGVR}))
+	informers := 0
+	dc.childInformers.Range(func(_, _ interface{}) bool {
+		informers++
+		return true
+	})
+	assert.Equal(t, 1, informers)
+
+	// Deleting a child of an unknown parent doesn't enqueue anything.
+	require.NoError(t, client.Resource(childGVR).Namespace("default").Delete(ctx, "other-config", metav1.DeleteOptions{}))
+	// Deleting a child enqueues its parent, which recreates it.
+	require.NoError(t, client.Resource(childGVR).Namespace("default").Delete(ctx, "app-config", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool { return dc.queue.Len() > 0 }, 5*time.Second, 10*time.Millisecond)
+	require.True(t, dc.processNextWorkItem(ctx))
+	assert.Equal(t, []string{"default/my-app"}, requests)
+	assert.Equal(t, 0, dc.queue.Len())
+
+	// Once the parent stops watching its children, their deletions are ignored.
+	dc.StopWatchingChildren("rg-uid")
+	_, err := client.Resource(childGVR).Namespace("default").Create(ctx, newChild("app-config", "rg-uid"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, client.Resource(childGVR).Namespace("default").Delete(ctx, "app-config", metav1.DeleteOptions{}))
+	require.Never(t, func() bool { return dc.queue.Len() > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestStartWatchingChildrenUnservedGVR(t *testing.T) {
+	parentGVR := schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "webapps"}
+	servedGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	unservedGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			servedGVR:   "ConfigMapList",
+			unservedGVR: "WidgetList",
+		},
+	)
+	client.PrependReactor("list", unservedGVR.Resource, func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(unservedGVR.GroupResource(), "")
+	})
+	dc := NewDynamicController(noopLogger(), Config{
+		ResyncPeriod:             10 * time.Hour,
+		ShutdownTimeout:          5 * time.Second,
+		ChildInformerSyncTimeout: 500 * time.Millisecond,
+	}, client)
+	defer func() {
+		require.NoError(t, dc.gracefulShutdown(5*time.Second))
+	}()
+
+	// The informer of a GVR that isn't served never syncs, the wait is bounded
+	// by the sync timeout, and doesn't block the other parents meanwhile.
+	done := make(chan error)
+	go func() {
+		done <- dc.StartWatchingChildren(context.Background(), parentGVR, "rg-uid", []schema.GroupVersionResource{unservedGVR})
+	}()
+	require.Eventually(t, func() bool {
+		_, ok := dc.childInformers.Load(unservedGVR)
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, dc.StartWatchingChildren(context.Background(), parentGVR, "another-rg-uid",
+		[]schema.GroupVersionResource{servedGVR}))
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to sync child informer cache")
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartWatchingChildren didn't time out")
+	}
+	// The informer failing to sync is stopped, it's started again on the
+	// next call.
+	_, ok := dc.childInformers.Load(unservedGVR)
+	assert.False(t, ok)
+
+	// The wait is also bounded by the context of the caller.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	require.Error(t, dc.StartWatchingChildren(ctx, parentGVR, "rg-uid", []schema.GroupVersionResource{unservedGVR}))
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestRequeueOnChildResync(t *testing.T) {
+	parentGVR := schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "webapps"}
+	childGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newChild := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetResourceVersion("1")
+		obj.SetLabels(map[string]string{
+			metadata.OwnedLabel:             "true",
+			metadata.ResourceGroupIDLabel:   "rg-uid",
+			metadata.InstanceLabel:          "my-app",
+			metadata.InstanceNamespaceLabel: "default",
+		})
+		return obj
+	}
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			parentGVR: "WebAppList",
+			childGVR:  "ConfigMapList",
+		},
+		newChild("app-config"),
+		newChild("app-secret-config"),
+	)
+	dc := NewDynamicController(noopLogger(), Config{
+		ResyncPeriod:              100 * time.Millisecond,
+		ShutdownTimeout:           5 * time.Second,
+		RequeueOnChildResync:      true,
+		ChildResyncCoalescePeriod: time.Second,
+	}, client)
+
+	var requests []string
+	handlerFunc := Handler(func(ctx context.Context, req controllerruntime.Request) error {
+		requests = append(requests, req.Name)
+		return nil
+	})
+	ctx := context.Background()
+	require.NoError(t, dc.StartServingGVK(ctx, parentGVR, handlerFunc))
+	require.NoError(t, dc.StartWatchingChildren(ctx, parentGVR, "rg-uid", []schema.GroupVersionResource{childGVR}))
+	defer func() {
+		require.NoError(t, dc.gracefulShutdown(5*time.Second))
+	}()
+
+	// The resyncs of both children, repeated during the coalescing period,
+	// enqueue their parent once.
+	require.Eventually(t, func() bool { return dc.queue.Len() > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, dc.queue.Len())
+	require.True(t, dc.processNextWorkItem(ctx))
+	assert.Equal(t, []string{"default/my-app"}, requests)
+	assert.Equal(t, 0, dc.queue.Len())
+}
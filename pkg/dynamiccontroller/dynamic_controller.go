@@ -58,13 +58,18 @@ package dynamiccontroller
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
 	"golang.org/x/time/rate"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -96,6 +101,146 @@ type Config struct {
 	// gracefully shutdown. We ideally want to avoid forceful shutdowns, giving
 	// the controller enough time to finish processing any pending items.
 	ShutdownTimeout time.Duration
+	// InformerSelectors holds the selectors restricting the objects watched
+	// and cached by the informer of a GVR. For high-cardinality resource types,
+	// this avoids caching objects the controller doesn't care about, e.g. by
+	// only watching the objects labeled as owned by kro. GVRs without a
+	// selector are fully watched.
+	InformerSelectors map[schema.GroupVersionResource]InformerSelector
+	// FairQueueing gives each GVR its own queue and rate limiter, the queues
+	// being served in a round robin fashion. Since each ResourceGroup serves
+	// its own GVR, this prevents a flood of instances of one ResourceGroup
+	// from starving the reconciles of the others.
+	FairQueueing bool
+	// MaxConcurrentReconcilesPerGVR is the maximum number of objects of a
+	// single GVR reconciled in parallel, guaranteeing the other GVRs a share
+	// of the workers. It is only used with fair queueing, and 0 means no
+	// limit.
+	MaxConcurrentReconcilesPerGVR int
+	// DisableLeaderElection runs the controller on all the replicas instead
+	// of only on the leader, when started by a manager. Every replica then
+	// reconciles the objects it watches: the reconciles must be idempotent,
+	// and concurrent replicas may race on the status updates until the
+	// objects are partitioned between them, e.g. with InformerSelectors.
+	DisableLeaderElection bool
+	// RequeueOnChildResync enqueues the parents of the children on every
+	// resync of the child informers, not only on the deletions of the
+	// children, to pick up the changes missed while their watches were
+	// broken. The enqueues of a parent are coalesced over
+	// ChildResyncCoalescePeriod, so that a resync enqueues it once whatever
+	// its number of children.
+	RequeueOnChildResync bool
+	// ChildResyncCoalescePeriod is the period over which the enqueues of a
+	// parent on child resyncs are coalesced. Defaults to
+	// DefaultChildResyncCoalescePeriod.
+	ChildResyncCoalescePeriod time.Duration
+	// ChildInformerSyncTimeout bounds the wait for the initial sync of a
+	// child informer, e.g. when the child GVR isn't served. Defaults to
+	// DefaultChildInformerSyncTimeout.
+	ChildInformerSyncTimeout time.Duration
+	// StartupReconcileBurst is the number of workers started when the
+	// controller starts, while the queue holds every existing object. The
+	// other workers are then started one at a time, every
+	// StartupRampUpInterval, until Workers run, so that the initial sync
+	// doesn't overload the API server. 0 starts all the workers at once.
+	StartupReconcileBurst int
+	// StartupRampUpInterval is the interval between the starts of the
+	// workers beyond StartupReconcileBurst. Defaults to
+	// DefaultStartupRampUpInterval.
+	StartupRampUpInterval time.Duration
+}
+
+// DefaultStartupRampUpInterval is the default interval between the starts of
+// the workers beyond the startup reconcile burst.
+const DefaultStartupRampUpInterval = 10 * time.Second
+
+// DefaultChildResyncCoalescePeriod is the default period over which the
+// enqueues of a parent on child resyncs are coalesced.
+const DefaultChildResyncCoalescePeriod = 5 * time.Second
+
+// DefaultChildInformerSyncTimeout is the default timeout of the initial sync
+// of a child informer.
+const DefaultChildInformerSyncTimeout = 30 * time.Second
+
+// InformerSelector restricts the objects watched by an informer. Both
+// selectors use the Kubernetes list options syntax, and are ignored when
+// empty.
+type InformerSelector struct {
+	// LabelSelector is a label selector, e.g. "kro.run/owned=true".
+	LabelSelector string
+	// FieldSelector is a field selector, e.g. "metadata.namespace!=kube-system".
+	FieldSelector string
+}
+
+// tweakListOptions returns a function applying the selector to the list and
+// watch requests of an informer, or nil if the selector is empty.
+func (s InformerSelector) tweakListOptions() dynamicinformer.TweakListOptionsFunc {
+	if s.LabelSelector == "" && s.FieldSelector == "" {
+		return nil
+	}
+	return func(options *metav1.ListOptions) {
+		options.LabelSelector = s.LabelSelector
+		options.FieldSelector = s.FieldSelector
+	}
+}
+
+// ParseInformerSelectors parses the informer selectors of the GVRs, each given
+// as "gvr=labelSelector[;fieldSelector]". The GVR is written "version/resource"
+// for the core group, and "group/version/resource" otherwise, e.g:
+//
+//	apps/v1/deployments=app.kubernetes.io/managed-by=kro
+//	v1/configmaps=;metadata.namespace!=kube-system
+func ParseInformerSelectors(values []string) (map[schema.GroupVersionResource]InformerSelector, error) {
+	selectors := make(map[schema.GroupVersionResource]InformerSelector, len(values))
+	for _, value := range values {
+		gvr, selector, err := parseInformerSelector(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid informer selector %q: %w", value, err)
+		}
+		if _, exists := selectors[gvr]; exists {
+			return nil, fmt.Errorf("invalid informer selector %q: duplicate selector for %s", value, gvr)
+		}
+		selectors[gvr] = selector
+	}
+	return selectors, nil
+}
+
+// parseInformerSelector parses a "gvr=labelSelector[;fieldSelector]" informer
+// selector.
+func parseInformerSelector(value string) (schema.GroupVersionResource, InformerSelector, error) {
+	rawGVR, rawSelectors, found := strings.Cut(value, "=")
+	if !found {
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("expected gvr=labelSelector[;fieldSelector]")
+	}
+
+	var gvr schema.GroupVersionResource
+	switch parts := strings.Split(strings.TrimSpace(rawGVR), "/"); len(parts) {
+	case 2:
+		gvr = schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}
+	case 3:
+		gvr = schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}
+	default:
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("expected a version/resource or group/version/resource GVR, got %q", rawGVR)
+	}
+	if gvr.Version == "" || gvr.Resource == "" {
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("expected a version/resource or group/version/resource GVR, got %q", rawGVR)
+	}
+
+	labelSelector, fieldSelector, _ := strings.Cut(rawSelectors, ";")
+	selector := InformerSelector{
+		LabelSelector: strings.TrimSpace(labelSelector),
+		FieldSelector: strings.TrimSpace(fieldSelector),
+	}
+	if selector.LabelSelector == "" && selector.FieldSelector == "" {
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("expected a label or a field selector")
+	}
+	if _, err := labels.Parse(selector.LabelSelector); err != nil {
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("invalid label selector: %w", err)
+	}
+	if _, err := fields.ParseSelector(selector.FieldSelector); err != nil {
+		return schema.GroupVersionResource{}, InformerSelector{}, fmt.Errorf("invalid field selector: %w", err)
+	}
+	return gvr, selector, nil
 }
 
 // DynamicController (DC) is a single controller capable of managing multiple different
@@ -122,8 +267,20 @@ type DynamicController struct {
 	// handler is responsible for managing a specific GVR.
 	handlers sync.Map
 
+	// childInformers is a safe map of GVR to the informers watching the
+	// deletions of the children of the served objects. childInformersMu
+	// serializes their creation.
+	childInformers   sync.Map
+	childInformersMu sync.Mutex
+	// parents is a safe map of parent ID to the GVR of the parents of the
+	// children labeled with that ID.
+	parents sync.Map
+
 	// queue is the workqueue used to process items
 	queue workqueue.RateLimitingInterface
+	// workers is the number of workers started, which ramps up to the
+	// configured number after the startup.
+	workers atomic.Int32
 
 	log logr.Logger
 }
@@ -133,6 +290,8 @@ type Handler func(ctx context.Context, req ctrl.Request) error
 type informerWrapper struct {
 	informer dynamicinformer.DynamicSharedInformerFactory
 	shutdown func()
+	// options are the options the GVR is served with.
+	options serveOptions
 }
 
 // NewDynamicController creates a new DynamicController instance.
@@ -147,45 +306,53 @@ func NewDynamicController(
 		config:     config,
 		kubeClient: kubeClient,
 		// TODO(a-hilaly): Make the queue size configurable.
-		queue: workqueue.NewNamedRateLimitingQueue(workqueue.NewMaxOfRateLimiter(
-			workqueue.NewItemExponentialFailureRateLimiter(200*time.Millisecond, 1000*time.Second),
-			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
-		), "dynamic-controller-queue"),
-		log: logger,
+		queue: newQueue("dynamic-controller-queue"),
+		log:   logger,
 		// pass version and pod id from env
 	}
+	if config.FairQueueing {
+		dc.queue = newFairQueue(func(gvr schema.GroupVersionResource) workqueue.RateLimitingInterface {
+			return newQueue(fmt.Sprintf("dynamic-controller-queue-%s/%s/%s", gvr.Group, gvr.Version, gvr.Resource))
+		}, config.MaxConcurrentReconcilesPerGVR)
+	}
 
 	return dc
 }
 
+// newQueue creates a rate limited workqueue with the given name.
+func newQueue(name string) workqueue.RateLimitingInterface {
+	return workqueue.NewNamedRateLimitingQueue(workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(200*time.Millisecond, 1000*time.Second),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	), name)
+}
+
 // AllInformerHaveSynced checks if all registered informers have synced, returns
 // true if they have.
 func (dc *DynamicController) AllInformerHaveSynced() bool {
-	var allSynced bool
-	var informerCount int
+	allSynced := true
 
 	// Unfortunately we can't know the number of informers in advance, so we need to
 	// iterate over all of them to check if they have synced.
-
+	//
+	// The informers can be registered before the controller runs, e.g. when
+	// the controller is started by a manager after the resource groups were
+	// reconciled.
 	dc.informers.Range(func(key, value interface{}) bool {
-		informerCount++
-		// possibly panic if the value is not a SharedIndexInformer
-		informer, ok := value.(cache.SharedIndexInformer)
-		if !ok {
+		gvr, isGVR := key.(schema.GroupVersionResource)
+		wrapper, isWrapper := value.(*informerWrapper)
+		if !isGVR || !isWrapper {
 			dc.log.Error(nil, "Failed to cast informer", "key", key)
 			allSynced = false
 			return false
 		}
-		if !informer.HasSynced() {
+		if !wrapper.informer.ForResource(gvr).Informer().HasSynced() {
 			allSynced = false
 			return false
 		}
 		return true
 	})
 
-	if informerCount == 0 {
-		return true
-	}
 	return allSynced
 }
 
@@ -213,17 +380,67 @@ func (dc *DynamicController) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to sync informers")
 	}
 
-	// Spin up workers.
-	//
-	// TODO(a-hilaly): Allow for dynamic scaling of workers.
-	for i := 0; i < dc.config.Workers; i++ {
-		go wait.UntilWithContext(ctx, dc.worker, time.Second)
-	}
+	dc.startWorkers(ctx)
 
 	<-ctx.Done()
 	return dc.gracefulShutdown(dc.config.ShutdownTimeout)
 }
 
+// Start implements the manager.Runnable interface, running the controller
+// until the context is cancelled.
+func (dc *DynamicController) Start(ctx context.Context) error {
+	return dc.Run(ctx)
+}
+
+// NeedLeaderElection implements the manager.LeaderElectionRunnable interface.
+// The controller only runs on the leader, unless leader election is disabled
+// in its configuration.
+func (dc *DynamicController) NeedLeaderElection() bool {
+	return !dc.config.DisableLeaderElection
+}
+
+// startWorkers starts the workers: the startup reconcile burst right away,
+// the others one at a time until the configured number of workers run.
+func (dc *DynamicController) startWorkers(ctx context.Context) {
+	workers := dc.config.Workers
+	burst := dc.config.StartupReconcileBurst
+	if burst <= 0 || burst > workers {
+		burst = workers
+	}
+	for i := 0; i < burst; i++ {
+		dc.startWorker(ctx)
+	}
+	if burst == workers {
+		return
+	}
+
+	interval := dc.config.StartupRampUpInterval
+	if interval <= 0 {
+		interval = DefaultStartupRampUpInterval
+	}
+	dc.log.Info("Ramping up the workers", "burst", burst, "workers", workers, "interval", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for started := burst; started < workers; started++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			dc.startWorker(ctx)
+		}
+		dc.log.Info("Finished ramping up the workers", "workers", workers)
+	}()
+}
+
+// startWorker starts a worker processing items from the queue until the
+// context is cancelled.
+func (dc *DynamicController) startWorker(ctx context.Context) {
+	activeWorkersTotal.Set(float64(dc.workers.Add(1)))
+	go wait.UntilWithContext(ctx, dc.worker, time.Second)
+}
+
 // worker processes items from the queue.
 func (dc *DynamicController) worker(ctx context.Context) {
 	for dc.processNextWorkItem(ctx) {
@@ -337,6 +554,16 @@ func (dc *DynamicController) gracefulShutdown(timeout time.Duration) error {
 		return true
 	})
 
+	dc.childInformers.Range(func(key, value interface{}) bool {
+		wg.Add(1)
+		go func(informer *informerWrapper) {
+			defer wg.Done()
+			informer.shutdown()
+			informer.informer.Shutdown()
+		}(value.(*informerWrapper))
+		return true
+	})
+
 	// Wait for all informers to shut down or timeout
 	done := make(chan struct{})
 	go func() {
@@ -379,17 +606,38 @@ func (dc *DynamicController) updateFunc(old, new interface{}) {
 		return
 	}
 
+	// Resyncs don't change the resource version. They are only reconciled
+	// for the GVRs served with an overridden resync period.
+	if newObj.GetResourceVersion() == oldObj.GetResourceVersion() && dc.reconcilesOnResync(metadata.GVKtoGVR(newObj.GroupVersionKind())) {
+		dc.enqueueObject(new, "resync")
+		return
+	}
+
 	if newObj.GetGeneration() == oldObj.GetGeneration() {
-		dc.log.V(2).Info("Skipping update due to unchanged generation",
+		if !reconcileRequested(oldObj, newObj) {
+			dc.log.V(2).Info("Skipping update due to unchanged generation",
+				"name", newObj.GetName(),
+				"namespace", newObj.GetNamespace(),
+				"generation", newObj.GetGeneration())
+			return
+		}
+		dc.log.V(1).Info("Reconcile requested through annotation",
 			"name", newObj.GetName(),
 			"namespace", newObj.GetNamespace(),
-			"generation", newObj.GetGeneration())
-		return
+			"value", newObj.GetAnnotations()[metadata.ReconcileNowAnnotation])
 	}
 
 	dc.enqueueObject(new, "update")
 }
 
+// reconcileRequested returns true if the reconcile-now annotation of the object
+// was set to a new value. The annotation is never cleared, so re-applying the
+// same value doesn't trigger a reconciliation again.
+func reconcileRequested(oldObj, newObj *unstructured.Unstructured) bool {
+	value := newObj.GetAnnotations()[metadata.ReconcileNowAnnotation]
+	return value != "" && value != oldObj.GetAnnotations()[metadata.ReconcileNowAnnotation]
+}
+
 // enqueueObject adds an object to the workqueue
 func (dc *DynamicController) enqueueObject(obj interface{}, eventType string) {
 	namespacedKey, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
@@ -422,25 +670,42 @@ func (dc *DynamicController) enqueueObject(obj interface{}, eventType string) {
 }
 
 // StartServingGVK registers a new GVK to the informers map safely.
-func (dc *DynamicController) StartServingGVK(ctx context.Context, gvr schema.GroupVersionResource, handler Handler) error {
+func (dc *DynamicController) StartServingGVK(ctx context.Context, gvr schema.GroupVersionResource, handler Handler, opts ...ServeOption) error {
 	dc.log.V(1).Info("Registering new GVK", "gvr", gvr)
 
-	_, exists := dc.informers.Load(gvr)
-	if exists {
-		// Even thought the informer is already registered, we should still
-		// still update the handler, as it might have changed.
-		dc.handlers.Store(gvr, handler)
-		return nil
+	var options serveOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
 
-	// Create a new informer
+	if existing, exists := dc.informers.Load(gvr); exists {
+		if existing.(*informerWrapper).options == options {
+			// Even thought the informer is already registered, we should still
+			// still update the handler, as it might have changed.
+			dc.handlers.Store(gvr, handler)
+			return nil
+		}
+		// The informer must be recreated to change its resync period.
+		dc.log.Info("Restarting informer to apply new serving options", "gvr", gvr)
+		if err := dc.StopServiceGVK(ctx, gvr); err != nil {
+			return err
+		}
+	}
+
+	resyncPeriod := dc.config.ResyncPeriod
+	if options.resyncPeriod > 0 {
+		resyncPeriod = options.resyncPeriod
+	}
+
+	// Create a new informer, only watching the objects matching the selector
+	// configured for the GVR, if any.
 	gvkInformer := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
 		dc.kubeClient,
-		dc.config.ResyncPeriod,
+		resyncPeriod,
 		// Maybe we can make this configurable in the future. Thinking that
-		// we might want to filter out some resources, by namespace or labels
+		// we might want to filter out some resources by namespace.
 		"",
-		nil,
+		dc.config.InformerSelectors[gvr].tweakListOptions(),
 	)
 
 	informer := gvkInformer.ForResource(gvr).Informer()
@@ -484,12 +749,20 @@ func (dc *DynamicController) StartServingGVK(ctx context.Context, gvr schema.Gro
 	dc.informers.Store(gvr, &informerWrapper{
 		informer: gvkInformer,
 		shutdown: cancel,
+		options:  options,
 	})
 	gvrCount.Inc()
 	dc.log.V(1).Info("Successfully registered GVK", "gvr", gvr)
 	return nil
 }
 
+// IsServingGVK returns whether the controller serves the given GVR, that is
+// whether its informer is registered.
+func (dc *DynamicController) IsServingGVK(gvr schema.GroupVersionResource) bool {
+	_, ok := dc.informers.Load(gvr)
+	return ok
+}
+
 // UnregisterGVK safely removes a GVK from the controller and cleans up associated resources.
 func (dc *DynamicController) StopServiceGVK(ctx context.Context, gvr schema.GroupVersionResource) error {
 	dc.log.Info("Unregistering GVK", "gvr", gvr)
@@ -521,6 +794,11 @@ func (dc *DynamicController) StopServiceGVK(ctx context.Context, gvr schema.Grou
 	dc.handlers.Delete(gvr)
 
 	gvrCount.Dec()
+	// With fair queueing, the pending items of the GVR are in their own queue,
+	// which can simply be dropped.
+	if fq, ok := dc.queue.(*fairQueue); ok {
+		fq.removeGVR(gvr)
+	}
 	// Clean up any pending items in the queue for this GVR
 	// NOTE(a-hilaly): This is a bit heavy.. maybe we can find a better way to do this.
 	// Thinking that we might want to have a queue per GVR.
@@ -15,6 +15,7 @@ package dynamiccontroller
 import (
 	"context"
 	"io/ioutil"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,8 +26,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/awslabs/kro/internal/metadata"
 )
 
 // NOTE(a-hilaly): I'm just playing around with the dynamic controller code here
@@ -138,3 +142,272 @@ func TestEnqueueObject(t *testing.T) {
 
 	assert.Equal(t, 1, dc.queue.Len())
 }
+
+func TestUpdateFuncReconcileNow(t *testing.T) {
+	newObject := func(generation int64, reconcileNow string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetName("test-object")
+		obj.SetNamespace("default")
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "test", Version: "v1", Kind: "Test"})
+		obj.SetGeneration(generation)
+		if reconcileNow != "" {
+			obj.SetAnnotations(map[string]string{metadata.ReconcileNowAnnotation: reconcileNow})
+		}
+		return obj
+	}
+
+	tests := []struct {
+		name        string
+		old         *unstructured.Unstructured
+		new         *unstructured.Unstructured
+		wantEnqueue bool
+	}{
+		{
+			name:        "generation changed",
+			old:         newObject(1, ""),
+			new:         newObject(2, ""),
+			wantEnqueue: true,
+		},
+		{
+			name: "unchanged object",
+			old:  newObject(1, ""),
+			new:  newObject(1, ""),
+		},
+		{
+			name:        "annotation set",
+			old:         newObject(1, ""),
+			new:         newObject(1, "2024-01-01T00:00:00Z"),
+			wantEnqueue: true,
+		},
+		{
+			name:        "annotation changed",
+			old:         newObject(1, "2024-01-01T00:00:00Z"),
+			new:         newObject(1, "2024-01-02T00:00:00Z"),
+			wantEnqueue: true,
+		},
+		{
+			name: "annotation unchanged",
+			old:  newObject(1, "2024-01-01T00:00:00Z"),
+			new:  newObject(1, "2024-01-01T00:00:00Z"),
+		},
+		{
+			name: "annotation removed",
+			old:  newObject(1, "2024-01-01T00:00:00Z"),
+			new:  newObject(1, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := NewDynamicController(noopLogger(), Config{}, setupFakeClient())
+			dc.updateFunc(tt.old, tt.new)
+			if tt.wantEnqueue {
+				assert.Equal(t, 1, dc.queue.Len())
+			} else {
+				assert.Equal(t, 0, dc.queue.Len())
+			}
+		})
+	}
+}
+
+func TestStartServingGVKWithInformerSelector(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "tests"}
+	newObject := func(name string, labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "test", Version: "v1", Kind: "Test"})
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		return obj
+	}
+
+	tests := []struct {
+		name         string
+		selectors    map[schema.GroupVersionResource]InformerSelector
+		wantLabels   string
+		wantFields   string
+		wantInformed []string
+	}{
+		{
+			name:         "no selector",
+			wantInformed: []string{"default/owned", "default/unowned"},
+		},
+		{
+			name: "selector of another GVR",
+			selectors: map[schema.GroupVersionResource]InformerSelector{
+				{Group: "other", Version: "v1", Resource: "others"}: {LabelSelector: "kro.run/owned=true"},
+			},
+			wantInformed: []string{"default/owned", "default/unowned"},
+		},
+		{
+			name: "label and field selectors",
+			selectors: map[schema.GroupVersionResource]InformerSelector{
+				gvr: {LabelSelector: "kro.run/owned=true", FieldSelector: "metadata.namespace=default"},
+			},
+			wantLabels:   "kro.run/owned=true",
+			wantFields:   "metadata.namespace=default",
+			wantInformed: []string{"default/owned"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{gvr: "TestList"},
+				newObject("owned", map[string]string{"kro.run/owned": "true"}),
+				newObject("unowned", nil),
+			)
+			dc := NewDynamicController(noopLogger(), Config{
+				ResyncPeriod:      10 * time.Hour,
+				ShutdownTimeout:   5 * time.Second,
+				InformerSelectors: tt.selectors,
+			}, client)
+
+			handlerFunc := Handler(func(ctx context.Context, req controllerruntime.Request) error {
+				return nil
+			})
+			require.NoError(t, dc.StartServingGVK(context.Background(), gvr, handlerFunc))
+			defer func() {
+				require.NoError(t, dc.StopServiceGVK(context.Background(), gvr))
+			}()
+
+			// The informer lists and watches with the configured selector.
+			var verbs []string
+			for _, action := range client.Actions() {
+				switch a := action.(type) {
+				case clienttesting.ListAction:
+					assert.Equal(t, tt.wantLabels, a.GetListRestrictions().Labels.String())
+					assert.Equal(t, tt.wantFields, a.GetListRestrictions().Fields.String())
+				case clienttesting.WatchAction:
+					assert.Equal(t, tt.wantLabels, a.GetWatchRestrictions().Labels.String())
+					assert.Equal(t, tt.wantFields, a.GetWatchRestrictions().Fields.String())
+				}
+				verbs = append(verbs, action.GetVerb())
+			}
+			assert.Contains(t, verbs, "list")
+
+			// Only the matching objects are cached.
+			informerObj, ok := dc.informers.Load(gvr)
+			require.True(t, ok)
+			informer := informerObj.(*informerWrapper).informer.ForResource(gvr).Informer()
+			assert.ElementsMatch(t, tt.wantInformed, informer.GetStore().ListKeys())
+		})
+	}
+}
+
+func TestParseInformerSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[schema.GroupVersionResource]InformerSelector
+		wantErr string
+	}{
+		{
+			name: "no selector",
+			want: map[schema.GroupVersionResource]InformerSelector{},
+		},
+		{
+			name: "label selector",
+			values: []string{
+				"apps/v1/deployments=app.kubernetes.io/managed-by=kro",
+			},
+			want: map[schema.GroupVersionResource]InformerSelector{
+				{Group: "apps", Version: "v1", Resource: "deployments"}: {LabelSelector: "app.kubernetes.io/managed-by=kro"},
+			},
+		},
+		{
+			name: "label and field selectors",
+			values: []string{
+				"apps/v1/deployments=kro.run/owned=true,tier in (web,api);metadata.namespace!=kube-system",
+				"v1/configmaps=;metadata.namespace=default",
+			},
+			want: map[schema.GroupVersionResource]InformerSelector{
+				{Group: "apps", Version: "v1", Resource: "deployments"}: {
+					LabelSelector: "kro.run/owned=true,tier in (web,api)",
+					FieldSelector: "metadata.namespace!=kube-system",
+				},
+				{Version: "v1", Resource: "configmaps"}: {FieldSelector: "metadata.namespace=default"},
+			},
+		},
+		{
+			name:    "missing selector",
+			values:  []string{"v1/configmaps"},
+			wantErr: "expected gvr=labelSelector[;fieldSelector]",
+		},
+		{
+			name:    "empty selectors",
+			values:  []string{"v1/configmaps=;"},
+			wantErr: "expected a label or a field selector",
+		},
+		{
+			name:    "invalid GVR",
+			values:  []string{"configmaps=kro.run/owned=true"},
+			wantErr: "expected a version/resource or group/version/resource GVR",
+		},
+		{
+			name:    "invalid label selector",
+			values:  []string{"v1/configmaps=kro.run/owned in ("},
+			wantErr: "invalid label selector",
+		},
+		{
+			name:    "invalid field selector",
+			values:  []string{"v1/configmaps=;metadata.namespace"},
+			wantErr: "invalid field selector",
+		},
+		{
+			name: "duplicate GVR",
+			values: []string{
+				"v1/configmaps=kro.run/owned=true",
+				"v1/configmaps=;metadata.namespace=default",
+			},
+			wantErr: "duplicate selector for /v1, Resource=configmaps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInformerSelectors(tt.values)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStartupReconcileBurst(t *testing.T) {
+	dc := NewDynamicController(noopLogger(), Config{
+		Workers:               3,
+		StartupReconcileBurst: 1,
+		StartupRampUpInterval: 300 * time.Millisecond,
+	}, setupFakeClient())
+
+	// The reconciles block until the end of the test, so that the number of
+	// reconciles in flight is the number of workers started.
+	gvr := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "tests"}
+	var inFlight atomic.Int32
+	release := make(chan struct{})
+	dc.handlers.Store(gvr, Handler(func(ctx context.Context, req controllerruntime.Request) error {
+		inFlight.Add(1)
+		<-release
+		return nil
+	}))
+	for _, name := range []string{"a", "b", "c", "d"} {
+		dc.queue.Add(ObjectIdentifiers{NamespacedKey: "default/" + name, GVR: gvr})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer close(release)
+	dc.startWorkers(ctx)
+
+	assert.Eventually(t, func() bool { return inFlight.Load() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), inFlight.Load(), "the initial concurrency must be capped to the burst")
+
+	assert.Eventually(t, func() bool { return inFlight.Load() == 3 }, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(3), dc.workers.Load())
+}
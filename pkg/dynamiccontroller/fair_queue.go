@@ -0,0 +1,271 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// fairQueue is a workqueue giving each GVR its own queue, and serving the
+// GVRs in a round robin fashion. Since each ResourceGroup serves its own GVR,
+// a flood of instances of one ResourceGroup can't starve the reconciles of the
+// other ResourceGroups.
+//
+// Each GVR queue is drained by a dispatcher goroutine handing its items one at
+// a time to the workers, over a shared unbuffered channel. The blocked senders
+// of a channel are woken up in FIFO order, so the workers take turns between
+// the GVRs having items to process.
+type fairQueue struct {
+	// newQueue creates the queue of a GVR.
+	newQueue func(gvr schema.GroupVersionResource) workqueue.RateLimitingInterface
+	// maxInFlight is the maximum number of items of a single GVR being
+	// processed at the same time. 0 or less means no limit.
+	maxInFlight int
+
+	mu           sync.Mutex
+	queues       map[schema.GroupVersionResource]*gvrQueue
+	processing   map[interface{}]*gvrQueue
+	shuttingDown bool
+
+	items chan dispatchedItem
+	stop  chan struct{}
+}
+
+// gvrQueue is the queue of a single GVR.
+type gvrQueue struct {
+	workqueue.RateLimitingInterface
+	// slots holds a token per item being processed. It is nil if the number
+	// of items being processed isn't bounded.
+	slots chan struct{}
+	stop  chan struct{}
+}
+
+type dispatchedItem struct {
+	item  interface{}
+	queue *gvrQueue
+}
+
+var _ workqueue.RateLimitingInterface = &fairQueue{}
+
+func newFairQueue(
+	newQueue func(gvr schema.GroupVersionResource) workqueue.RateLimitingInterface,
+	maxInFlight int,
+) *fairQueue {
+	return &fairQueue{
+		newQueue:    newQueue,
+		maxInFlight: maxInFlight,
+		queues:      make(map[schema.GroupVersionResource]*gvrQueue),
+		processing:  make(map[interface{}]*gvrQueue),
+		items:       make(chan dispatchedItem),
+		stop:        make(chan struct{}),
+	}
+}
+
+// itemGVR returns the GVR of the given item. Items which aren't
+// ObjectIdentifiers share the queue of the empty GVR.
+func itemGVR(item interface{}) schema.GroupVersionResource {
+	if oi, ok := item.(ObjectIdentifiers); ok {
+		return oi.GVR
+	}
+	return schema.GroupVersionResource{}
+}
+
+// lookup returns the queue of the GVR of the given item, if it exists.
+func (fq *fairQueue) lookup(item interface{}) (*gvrQueue, bool) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	q, ok := fq.queues[itemGVR(item)]
+	return q, ok
+}
+
+// queueFor returns the queue of the GVR of the given item, creating it if
+// needed.
+func (fq *fairQueue) queueFor(item interface{}) *gvrQueue {
+	gvr := itemGVR(item)
+
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	if q, ok := fq.queues[gvr]; ok {
+		return q
+	}
+
+	q := &gvrQueue{
+		RateLimitingInterface: fq.newQueue(gvr),
+		stop:                  make(chan struct{}),
+	}
+	if fq.maxInFlight > 0 {
+		q.slots = make(chan struct{}, fq.maxInFlight)
+	}
+	if fq.shuttingDown {
+		// Don't start dispatching items once the queue is shut down.
+		q.ShutDown()
+		return q
+	}
+	fq.queues[gvr] = q
+	go fq.dispatch(q)
+	return q
+}
+
+// dispatch hands the items of the given queue to the workers, until the queue
+// is shut down.
+func (fq *fairQueue) dispatch(q *gvrQueue) {
+	for {
+		if q.slots != nil {
+			select {
+			case q.slots <- struct{}{}:
+			case <-q.stop:
+				return
+			}
+		}
+
+		item, shutdown := q.Get()
+		if shutdown {
+			q.release()
+			return
+		}
+		select {
+		case fq.items <- dispatchedItem{item: item, queue: q}:
+		case <-q.stop:
+			q.Done(item)
+			q.release()
+			return
+		}
+	}
+}
+
+// release frees the slot of an item which is done being processed.
+func (q *gvrQueue) release() {
+	if q.slots == nil {
+		return
+	}
+	select {
+	case <-q.slots:
+	default:
+	}
+}
+
+// removeGVR shuts down the queue of the given GVR, dropping its pending items.
+func (fq *fairQueue) removeGVR(gvr schema.GroupVersionResource) {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	q, ok := fq.queues[gvr]
+	if !ok {
+		return
+	}
+	delete(fq.queues, gvr)
+	close(q.stop)
+	q.ShutDown()
+}
+
+func (fq *fairQueue) Add(item interface{}) {
+	fq.queueFor(item).Add(item)
+}
+
+func (fq *fairQueue) AddAfter(item interface{}, duration time.Duration) {
+	fq.queueFor(item).AddAfter(item, duration)
+}
+
+func (fq *fairQueue) AddRateLimited(item interface{}) {
+	fq.queueFor(item).AddRateLimited(item)
+}
+
+func (fq *fairQueue) Forget(item interface{}) {
+	if q, ok := fq.lookup(item); ok {
+		q.Forget(item)
+	}
+}
+
+func (fq *fairQueue) NumRequeues(item interface{}) int {
+	if q, ok := fq.lookup(item); ok {
+		return q.NumRequeues(item)
+	}
+	return 0
+}
+
+// Len returns the number of items waiting to be processed, across all GVRs.
+func (fq *fairQueue) Len() int {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	var n int
+	for _, q := range fq.queues {
+		n += q.Len()
+	}
+	return n
+}
+
+// Get blocks until an item can be processed, or the queue is shut down.
+func (fq *fairQueue) Get() (interface{}, bool) {
+	select {
+	case d := <-fq.items:
+		fq.mu.Lock()
+		fq.processing[d.item] = d.queue
+		fq.mu.Unlock()
+		return d.item, false
+	case <-fq.stop:
+		return nil, true
+	}
+}
+
+// Done marks the given item as done being processed.
+func (fq *fairQueue) Done(item interface{}) {
+	fq.mu.Lock()
+	q, ok := fq.processing[item]
+	delete(fq.processing, item)
+	fq.mu.Unlock()
+	if !ok {
+		return
+	}
+	q.Done(item)
+	q.release()
+}
+
+func (fq *fairQueue) ShutDown() {
+	for _, q := range fq.shutDown() {
+		q.ShutDown()
+	}
+}
+
+func (fq *fairQueue) ShutDownWithDrain() {
+	for _, q := range fq.shutDown() {
+		q.ShutDownWithDrain()
+	}
+}
+
+// shutDown stops dispatching items, and returns the queues to shut down.
+func (fq *fairQueue) shutDown() []*gvrQueue {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	if fq.shuttingDown {
+		return nil
+	}
+	fq.shuttingDown = true
+	close(fq.stop)
+
+	queues := make([]*gvrQueue, 0, len(fq.queues))
+	for _, q := range fq.queues {
+		close(q.stop)
+		queues = append(queues, q)
+	}
+	return queues
+}
+
+func (fq *fairQueue) ShuttingDown() bool {
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+	return fq.shuttingDown
+}
@@ -0,0 +1,184 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var (
+	busyGVR  = schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "busies"}
+	quietGVR = schema.GroupVersionResource{Group: "kro.run", Version: "v1alpha1", Resource: "quiets"}
+)
+
+func newTestFairQueue(maxInFlight int) *fairQueue {
+	return newFairQueue(func(gvr schema.GroupVersionResource) workqueue.RateLimitingInterface {
+		return workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	}, maxInFlight)
+}
+
+// asyncGet gets an item from the queue in the background.
+func asyncGet(q workqueue.RateLimitingInterface) <-chan interface{} {
+	got := make(chan interface{}, 1)
+	go func() {
+		item, shutdown := q.Get()
+		if !shutdown {
+			got <- item
+		}
+	}()
+	return got
+}
+
+func receive(got <-chan interface{}, timeout time.Duration) (interface{}, bool) {
+	select {
+	case item := <-got:
+		return item, true
+	case <-time.After(timeout):
+		return nil, false
+	}
+}
+
+func getWithTimeout(q workqueue.RateLimitingInterface, timeout time.Duration) (interface{}, bool) {
+	return receive(asyncGet(q), timeout)
+}
+
+// floodAndReconcile enqueues many instances of the busy GVR followed by a
+// single instance of the quiet GVR, and returns the number of busy instances
+// reconciled before the quiet one.
+func floodAndReconcile(t *testing.T, config Config) int {
+	dc := NewDynamicController(noopLogger(), config, setupFakeClient())
+
+	var mu sync.Mutex
+	var busyReconciled int
+	quietReconciled := make(chan int, 1)
+	dc.handlers.Store(busyGVR, Handler(func(ctx context.Context, req ctrl.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		busyReconciled++
+		return nil
+	}))
+	dc.handlers.Store(quietGVR, Handler(func(ctx context.Context, req ctrl.Request) error {
+		mu.Lock()
+		defer mu.Unlock()
+		quietReconciled <- busyReconciled
+		return nil
+	}))
+
+	for i := 0; i < 100; i++ {
+		dc.queue.Add(ObjectIdentifiers{NamespacedKey: fmt.Sprintf("default/busy-%d", i), GVR: busyGVR})
+	}
+	dc.queue.Add(ObjectIdentifiers{NamespacedKey: "default/quiet", GVR: quietGVR})
+	// Let the queues settle before starting the worker.
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer dc.queue.ShutDown()
+	go dc.worker(ctx)
+
+	select {
+	case n := <-quietReconciled:
+		return n
+	case <-time.After(10 * time.Second):
+		t.Fatal("the quiet instance was never reconciled")
+		return 0
+	}
+}
+
+func TestFairQueueing(t *testing.T) {
+	t.Run("shared queue", func(t *testing.T) {
+		// Without fair queueing, the quiet instance waits for the whole flood
+		// to be processed.
+		n := floodAndReconcile(t, Config{Workers: 1})
+		assert.Equal(t, 100, n)
+	})
+
+	t.Run("fair queueing", func(t *testing.T) {
+		n := floodAndReconcile(t, Config{Workers: 1, FairQueueing: true})
+		assert.LessOrEqual(t, n, 1)
+	})
+}
+
+func TestFairQueue_MaxInFlight(t *testing.T) {
+	fq := newTestFairQueue(2)
+	defer fq.ShutDown()
+
+	for i := 0; i < 10; i++ {
+		fq.Add(ObjectIdentifiers{NamespacedKey: fmt.Sprintf("default/busy-%d", i), GVR: busyGVR})
+		fq.Add(ObjectIdentifiers{NamespacedKey: fmt.Sprintf("default/quiet-%d", i), GVR: quietGVR})
+	}
+
+	inFlight := map[schema.GroupVersionResource][]interface{}{}
+	for i := 0; i < 4; i++ {
+		item, ok := getWithTimeout(fq, time.Second)
+		require.True(t, ok)
+		gvr := item.(ObjectIdentifiers).GVR
+		inFlight[gvr] = append(inFlight[gvr], item)
+	}
+	assert.Len(t, inFlight[busyGVR], 2)
+	assert.Len(t, inFlight[quietGVR], 2)
+
+	// Both GVRs are at capacity.
+	got := asyncGet(fq)
+	_, ok := receive(got, 100*time.Millisecond)
+	require.False(t, ok)
+
+	// Finishing a busy item frees a slot for the next one.
+	fq.Done(inFlight[busyGVR][0])
+	item, ok := receive(got, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, busyGVR, item.(ObjectIdentifiers).GVR)
+}
+
+func TestFairQueue_RemoveGVR(t *testing.T) {
+	fq := newTestFairQueue(0)
+	defer fq.ShutDown()
+
+	fq.Add(ObjectIdentifiers{NamespacedKey: "default/busy", GVR: busyGVR})
+	fq.AddAfter(ObjectIdentifiers{NamespacedKey: "default/quiet", GVR: quietGVR}, 50*time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	fq.removeGVR(busyGVR)
+
+	item, ok := getWithTimeout(fq, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, quietGVR, item.(ObjectIdentifiers).GVR)
+	assert.Equal(t, 0, fq.NumRequeues(ObjectIdentifiers{NamespacedKey: "default/busy", GVR: busyGVR}))
+	fq.Done(item)
+	assert.Equal(t, 0, fq.Len())
+}
+
+func TestFairQueue_ShutDown(t *testing.T) {
+	fq := newTestFairQueue(0)
+	fq.Add(ObjectIdentifiers{NamespacedKey: "default/busy", GVR: busyGVR})
+	fq.ShutDown()
+	assert.True(t, fq.ShuttingDown())
+
+	_, shutdown := fq.Get()
+	assert.True(t, shutdown)
+
+	// Adding items after the shutdown is a no-op.
+	fq.Add(ObjectIdentifiers{NamespacedKey: "default/busy-2", GVR: busyGVR})
+	fq.Add(ObjectIdentifiers{NamespacedKey: "default/quiet", GVR: quietGVR})
+	assert.Equal(t, 1, fq.Len())
+}
@@ -0,0 +1,121 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// heldLock is a leader election lock held by another replica, the manager
+// using it never becomes the leader.
+type heldLock struct{}
+
+var _ resourcelock.Interface = heldLock{}
+
+func (heldLock) Get(context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	now := metav1.Now()
+	return &resourcelock.LeaderElectionRecord{
+		HolderIdentity:       "another-replica",
+		LeaseDurationSeconds: 3600,
+		AcquireTime:          now,
+		RenewTime:            now,
+	}, []byte("another-replica"), nil
+}
+
+func (heldLock) Create(context.Context, resourcelock.LeaderElectionRecord) error {
+	return errors.New("lock is held by another replica")
+}
+
+func (heldLock) Update(context.Context, resourcelock.LeaderElectionRecord) error {
+	return errors.New("lock is held by another replica")
+}
+
+func (heldLock) RecordEvent(string) {}
+
+func (heldLock) Identity() string { return "this-replica" }
+
+func (heldLock) Describe() string { return "held lock" }
+
+func TestDynamicControllerLeaderElection(t *testing.T) {
+	tests := []struct {
+		name                  string
+		disableLeaderElection bool
+		wantStarted           bool
+	}{
+		{name: "leader elected", disableLeaderElection: false, wantStarted: false},
+		{name: "leader election disabled", disableLeaderElection: true, wantStarted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := NewDynamicController(noopLogger(), Config{
+				Workers:               1,
+				ResyncPeriod:          time.Hour,
+				QueueMaxRetries:       5,
+				ShutdownTimeout:       time.Second,
+				DisableLeaderElection: tt.disableLeaderElection,
+			}, setupFakeClient())
+			assert.Equal(t, !tt.disableLeaderElection, dc.NeedLeaderElection())
+
+			// The fake client holds an object, reconciled once the
+			// controller runs.
+			reconciled := make(chan struct{}, 1)
+			gvr := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "tests"}
+			require.NoError(t, dc.StartServingGVK(context.Background(), gvr, func(context.Context, ctrl.Request) error {
+				select {
+				case reconciled <- struct{}{}:
+				default:
+				}
+				return nil
+			}))
+			defer func() { _ = dc.StopServiceGVK(context.Background(), gvr) }()
+
+			// This replica never becomes the leader.
+			mgr, err := manager.New(&rest.Config{Host: "http://127.0.0.1:1"}, manager.Options{
+				Metrics:                             metricsserver.Options{BindAddress: "0"},
+				HealthProbeBindAddress:              "0",
+				LeaderElection:                      true,
+				LeaderElectionID:                    "test",
+				LeaderElectionNamespace:             "default",
+				LeaderElectionResourceLockInterface: heldLock{},
+			})
+			require.NoError(t, err)
+			require.NoError(t, mgr.Add(dc))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = mgr.Start(ctx) }()
+
+			select {
+			case <-reconciled:
+				assert.True(t, tt.wantStarted, "the dynamic controller started without the leadership")
+			case <-time.After(3 * time.Second):
+				assert.False(t, tt.wantStarted, "the dynamic controller didn't start")
+			}
+		})
+	}
+}
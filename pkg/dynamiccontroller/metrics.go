@@ -30,7 +30,7 @@ func init() {
 		handlerErrorsTotal,
 		informerSyncDuration,
 		informerEventsTotal,
-		// activeWorkersTotal,
+		activeWorkersTotal,
 	)
 }
 
@@ -93,11 +93,10 @@ var (
 		},
 		[]string{"gvr", "event_type"},
 	)
-	/* activeWorkersTotal = prometheus.NewGauge(
+	activeWorkersTotal = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "dynamic_controller_active_workers_total",
 			Help: "Total number of currently active workers",
 		},
 	)
-	*/
 )
@@ -0,0 +1,49 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServeOption configures how StartServingGVK serves a GVR.
+type ServeOption func(*serveOptions)
+
+// serveOptions are the options a GVR is served with. Changing them restarts
+// the informer of the GVR.
+type serveOptions struct {
+	// resyncPeriod overrides Config.ResyncPeriod when positive.
+	resyncPeriod time.Duration
+}
+
+// WithResyncPeriod overrides Config.ResyncPeriod for a GVR. Unlike the default
+// resyncs, which only refresh the informer cache, the resyncs of a GVR served
+// with an overridden period reconcile all its objects, correcting the drift of
+// the resources they manage at that cadence. A non positive period is ignored.
+func WithResyncPeriod(period time.Duration) ServeOption {
+	return func(o *serveOptions) {
+		if period > 0 {
+			o.resyncPeriod = period
+		}
+	}
+}
+
+// reconcilesOnResync returns true if the objects of gvr are reconciled on
+// the resyncs of its informer.
+func (dc *DynamicController) reconcilesOnResync(gvr schema.GroupVersionResource) bool {
+	wrapper, ok := dc.informers.Load(gvr)
+	return ok && wrapper.(*informerWrapper).options.resyncPeriod > 0
+}
@@ -0,0 +1,115 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dynamiccontroller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+)
+
+func TestStartServingGVKWithResyncPeriod(t *testing.T) {
+	overridden := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "tests"}
+	defaulted := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "others"}
+	newObject := func(kind string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: "test", Version: "v1", Kind: kind})
+		obj.SetNamespace("default")
+		obj.SetName("my-object")
+		return obj
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{overridden: "TestList", defaulted: "OtherList"},
+		newObject("Test"), newObject("Other"),
+	)
+	// The default resyncs only refresh the cache, whatever their period.
+	dc := NewDynamicController(noopLogger(), Config{
+		Workers:         1,
+		ResyncPeriod:    time.Second,
+		ShutdownTimeout: 5 * time.Second,
+	}, client)
+
+	var overriddenReconciles, defaultedReconciles atomic.Int32
+	require.NoError(t, dc.StartServingGVK(context.Background(), overridden,
+		func(context.Context, controllerruntime.Request) error {
+			overriddenReconciles.Add(1)
+			return nil
+		}, WithResyncPeriod(time.Second)))
+	require.NoError(t, dc.StartServingGVK(context.Background(), defaulted,
+		func(context.Context, controllerruntime.Request) error {
+			defaultedReconciles.Add(1)
+			return nil
+		}))
+	defer func() {
+		require.NoError(t, dc.StopServiceGVK(context.Background(), overridden))
+		require.NoError(t, dc.StopServiceGVK(context.Background(), defaulted))
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dc.startWorkers(ctx)
+
+	// The objects of the GVR served with an overridden resync period are
+	// reconciled on every resync, after their initial reconcile.
+	assert.Eventually(t, func() bool { return overriddenReconciles.Load() >= 3 }, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(1), defaultedReconciles.Load())
+}
+
+func TestStartServingGVKRestartsOnNewResyncPeriod(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "tests"}
+	dc := NewDynamicController(noopLogger(), Config{
+		ResyncPeriod:    10 * time.Hour,
+		ShutdownTimeout: 5 * time.Second,
+	}, setupFakeClient())
+	handlerFunc := Handler(func(context.Context, controllerruntime.Request) error { return nil })
+
+	informerOf := func() *informerWrapper {
+		informerObj, ok := dc.informers.Load(gvr)
+		require.True(t, ok)
+		return informerObj.(*informerWrapper)
+	}
+
+	require.NoError(t, dc.StartServingGVK(context.Background(), gvr, handlerFunc))
+	initial := informerOf()
+	assert.False(t, dc.reconcilesOnResync(gvr))
+
+	// Serving the GVR with the same options keeps its informer.
+	require.NoError(t, dc.StartServingGVK(context.Background(), gvr, handlerFunc))
+	assert.Same(t, initial, informerOf())
+
+	// Overriding its resync period restarts it.
+	require.NoError(t, dc.StartServingGVK(context.Background(), gvr, handlerFunc, WithResyncPeriod(time.Minute)))
+	overridden := informerOf()
+	assert.NotSame(t, initial, overridden)
+	assert.Equal(t, time.Minute, overridden.options.resyncPeriod)
+	assert.True(t, dc.reconcilesOnResync(gvr))
+	_, ok := dc.handlers.Load(gvr)
+	assert.True(t, ok)
+
+	// And so does removing the override.
+	require.NoError(t, dc.StartServingGVK(context.Background(), gvr, handlerFunc, WithResyncPeriod(0)))
+	assert.NotSame(t, overridden, informerOf())
+	assert.False(t, dc.reconcilesOnResync(gvr))
+
+	require.NoError(t, dc.StopServiceGVK(context.Background(), gvr))
+}
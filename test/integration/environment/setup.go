@@ -157,6 +157,8 @@ func (e *Environment) setupController() error {
 		e.ControllerConfig.AllowCRDDeletion,
 		dc,
 		e.GraphBuilder,
+		nil,
+		ctrlresourcegroup.ReconcilerConfig{},
 	)
 
 	var err error